@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/agent-platform/agix/internal/penalty"
+	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/ui"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Manage agents in the penalty box",
+	Long: `View and release agents throttled by the penalty box (penalty_box in config).
+
+Examples:
+  agix agent list               # Agents currently penalized
+  agix agent release mybot      # Lift a penalty before it expires`,
+}
+
+var agentListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List agents currently in the penalty box",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		box, st, err := openPenaltyBox()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		penalties, err := box.List()
+		if err != nil {
+			return err
+		}
+		if len(penalties) == 0 {
+			fmt.Println(ui.Dimf("No agents in the penalty box."))
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Agent", "Reason", "Since", "Expires"})
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+		for _, p := range penalties {
+			remaining := time.Until(p.ExpiresAt).Truncate(time.Second)
+			table.Append([]string{
+				ui.Cyanf("%s", p.Agent),
+				p.Reason,
+				p.StartedAt.Local().Format("2006-01-02 15:04:05"),
+				fmt.Sprintf("%s (%s)", p.ExpiresAt.Local().Format("15:04:05"), remaining),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var agentReleaseCmd = &cobra.Command{
+	Use:   "release <name>",
+	Short: "Release an agent from the penalty box",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		box, st, err := openPenaltyBox()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		released, err := box.Release(args[0])
+		if err != nil {
+			return err
+		}
+		if !released {
+			fmt.Println(ui.Dimf("Agent %q is not in the penalty box.", args[0]))
+			return nil
+		}
+		fmt.Printf("Released agent %q from the penalty box.\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.AddCommand(agentListCmd)
+	agentCmd.AddCommand(agentReleaseCmd)
+}
+
+// openPenaltyBox opens the store and the penalties table. The caller closes
+// the store.
+func openPenaltyBox() (*penalty.Box, *store.Store, error) {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := openStore(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+	box, err := penalty.New(penalty.Config{}, st, nil)
+	if err != nil {
+		st.Close()
+		return nil, nil, err
+	}
+	return box, st, nil
+}
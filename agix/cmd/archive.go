@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agent-platform/agix/internal/archive"
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var archiveJSON bool
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Read archived request/response bodies",
+	Long: `Read full request and response bodies stored by the archive (archive in config).
+
+Examples:
+  agix archive show 3f2a9c1e7b40         # Bodies for a request ID (X-Request-ID)
+  agix archive show 3f2a9c1e7b40 --json  # As JSON`,
+}
+
+var archiveShowCmd = &cobra.Command{
+	Use:   "show <request-id>",
+	Short: "Show the archived bodies of a request",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		backend, err := openArchive(cfg.Archive)
+		if err != nil {
+			return err
+		}
+		st, err := openStore(cfg)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer st.Close()
+
+		records, err := st.QueryRequestsByRequestID(args[0])
+		if err != nil {
+			return err
+		}
+		type shown struct {
+			Key      string `json:"archive_key"`
+			Model    string `json:"model"`
+			Status   int    `json:"status_code"`
+			Request  string `json:"request"`
+			Response string `json:"response"`
+		}
+		var out []shown
+		for _, r := range records {
+			if r.ArchiveKey == "" {
+				continue
+			}
+			e, err := archive.Get(context.Background(), backend, r.ArchiveKey)
+			if err != nil {
+				return err
+			}
+			out = append(out, shown{r.ArchiveKey, r.Model, r.StatusCode, e.Request, e.Response})
+		}
+		if len(out) == 0 {
+			return fmt.Errorf("no archived bodies for request %q", args[0])
+		}
+
+		if archiveJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		}
+		for _, s := range out {
+			fmt.Printf("%s %s  %s  status %d\n\n", ui.Boldf("Archive"), s.Key, s.Model, s.Status)
+			fmt.Println(ui.Boldf("Request"))
+			fmt.Println(indentJSON(s.Request))
+			fmt.Println()
+			fmt.Println(ui.Boldf("Response"))
+			fmt.Println(indentJSON(s.Response))
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+func init() {
+	archiveShowCmd.Flags().BoolVar(&archiveJSON, "json", false, "Output as JSON")
+	rootCmd.AddCommand(archiveCmd)
+	archiveCmd.AddCommand(archiveShowCmd)
+}
+
+// openArchive returns the configured archive backend.
+func openArchive(ac config.ArchiveConfig) (archive.Backend, error) {
+	if ac.S3.Bucket != "" {
+		return archive.NewS3(archive.S3Config{
+			Endpoint:        ac.S3.Endpoint,
+			Region:          ac.S3.Region,
+			Bucket:          ac.S3.Bucket,
+			Prefix:          ac.S3.Prefix,
+			AccessKeyID:     ac.S3.AccessKeyID,
+			SecretAccessKey: ac.S3.SecretAccessKey,
+			PathStyle:       ac.S3.PathStyle,
+		})
+	}
+	dir := ac.Dir
+	if dir == "" {
+		base, err := config.DefaultConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(base, "archive")
+	}
+	return archive.NewDir(dir)
+}
+
+// indentJSON pretty-prints s if it is JSON and returns it unchanged
+// otherwise (e.g. a streamed response).
+func indentJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(s), "", "  "); err != nil {
+		return s
+	}
+	return buf.String()
+}
@@ -4,9 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/agent-platform/agix/internal/audit"
-	"github.com/agent-platform/agix/internal/store"
 	"github.com/agent-platform/agix/internal/ui"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -31,13 +32,13 @@ var auditListCmd = &cobra.Command{
 			return err
 		}
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
 		defer st.Close()
 
-		logger := audit.New(st.DB(), true, st.Dialect())
+		logger := audit.New(st.ContentDB(), true, st.ContentDialect())
 		defer logger.Close()
 
 		events, err := logger.QueryRecent(auditListN, auditListType, auditListAgent)
@@ -74,6 +75,210 @@ var auditListCmd = &cobra.Command{
 	},
 }
 
+var auditSearchN int
+var auditSearchAgent string
+
+var auditSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Full-text search over logged request/response content",
+	Long: `Search content_log audit events for bodies containing every word of the query.
+Requires audit.content_log to have been enabled while the traffic was logged.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger, closeFn, err := openAuditLogger()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		events, err := logger.Search(strings.Join(args, " "), auditSearchAgent, auditSearchN)
+		if err != nil {
+			return fmt.Errorf("search events: %w", err)
+		}
+		if len(events) == 0 {
+			fmt.Println(ui.Dimf("No matching content found."))
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Time", "Agent", "Session", "Direction", "Excerpt"})
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+		for _, e := range events {
+			var d audit.ContentLogDetails
+			json.Unmarshal(e.Details, &d)
+			table.Append([]string{
+				fmt.Sprintf("%d", e.ID),
+				e.Timestamp.Format("2006-01-02 15:04:05"),
+				e.AgentName,
+				d.SessionID,
+				d.Direction,
+				excerpt(d.Body, args[0], 80),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+// excerpt returns up to n characters of body around the first occurrence of word.
+func excerpt(body, word string, n int) string {
+	body = strings.Join(strings.Fields(body), " ")
+	runes := []rune(body)
+	lower := strings.ToLower(body)
+	start := 0
+	if i := strings.Index(lower, strings.ToLower(word)); i >= 0 {
+		start = max(len([]rune(lower[:i]))-n/2, 0)
+	}
+	end := min(start+n, len(runes))
+	start = min(start, end)
+	out := string(runes[start:end])
+	if start > 0 {
+		out = "..." + out
+	}
+	if end < len(runes) {
+		out += "..."
+	}
+	return out
+}
+
+var auditHoldReason string
+
+var auditHoldCmd = &cobra.Command{
+	Use:   "hold",
+	Short: "Manage legal holds that exempt audit events from retention pruning",
+}
+
+var auditHoldAddCmd = &cobra.Command{
+	Use:   "add <agent|session> <name>",
+	Short: "Place an agent or session on legal hold",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger, closeFn, err := openAuditLogger()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		if err := logger.AddHold(args[0], args[1], auditHoldReason); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s %q is on legal hold\n", ui.Greenf("✓"), args[0], args[1])
+		return nil
+	},
+}
+
+var auditHoldRemoveCmd = &cobra.Command{
+	Use:   "remove <agent|session> <name>",
+	Short: "Release a legal hold",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger, closeFn, err := openAuditLogger()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		ok, err := logger.RemoveHold(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("no legal hold on %s %q", args[0], args[1])
+		}
+		fmt.Printf("%s legal hold on %s %q released\n", ui.Greenf("✓"), args[0], args[1])
+		return nil
+	},
+}
+
+var auditHoldListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List legal holds",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger, closeFn, err := openAuditLogger()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		holds, err := logger.ListHolds()
+		if err != nil {
+			return err
+		}
+		if len(holds) == 0 {
+			fmt.Println(ui.Dimf("No legal holds."))
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Kind", "Name", "Since", "Reason"})
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		for _, h := range holds {
+			table.Append([]string{h.Kind, h.Value, h.Timestamp.Format("2006-01-02"), h.Reason})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var auditPruneDays int
+
+var auditPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete audit events older than the retention period",
+	Long: `Delete audit events older than --days (default: audit.retention_days).
+Events of agents or sessions on legal hold are kept.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		days := auditPruneDays
+		if days <= 0 {
+			days = cfg.Audit.RetentionDays
+		}
+		if days <= 0 {
+			return fmt.Errorf("no retention period: pass --days or set audit.retention_days")
+		}
+
+		logger, closeFn, err := openAuditLogger()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		n, err := logger.Prune(time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s pruned %d event(s) older than %d day(s)\n", ui.Greenf("✓"), n, days)
+		return nil
+	},
+}
+
+// openAuditLogger opens the configured store for audit queries.
+func openAuditLogger() (*audit.Logger, func(), error) {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := openStore(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+	logger := audit.New(st.ContentDB(), false, st.ContentDialect())
+	return logger, func() {
+		logger.Close()
+		st.Close()
+	}, nil
+}
+
 func colorEventType(t string) string {
 	switch t {
 	case audit.EventFirewallBlock:
@@ -84,6 +289,8 @@ func colorEventType(t string) string {
 		return ui.Greenf("tool")
 	case audit.EventContentLog:
 		return ui.Dimf("content")
+	case audit.EventAdmin:
+		return ui.Cyanf("admin")
 	default:
 		return t
 	}
@@ -114,6 +321,11 @@ func summarizeDetails(eventType string, raw json.RawMessage) string {
 			}
 			return fmt.Sprintf("%s %s", d.Direction, d.Model)
 		}
+	case audit.EventAdmin:
+		var d audit.AdminDetails
+		if json.Unmarshal(raw, &d) == nil {
+			return fmt.Sprintf("%s %s → %d (%s)", d.Method, d.Path, d.Status, d.Remote)
+		}
 	}
 	return string(raw)
 }
@@ -122,6 +334,17 @@ func init() {
 	rootCmd.AddCommand(auditCmd)
 	auditCmd.AddCommand(auditListCmd)
 	auditListCmd.Flags().IntVarP(&auditListN, "number", "n", 20, "number of events to show")
-	auditListCmd.Flags().StringVarP(&auditListType, "type", "t", "", "filter by event type (tool_call, firewall_block, firewall_warn, content_log)")
+	auditListCmd.Flags().StringVarP(&auditListType, "type", "t", "", "filter by event type (tool_call, firewall_block, firewall_warn, content_log, admin)")
 	auditListCmd.Flags().StringVarP(&auditListAgent, "agent", "a", "", "filter by agent name")
+
+	auditCmd.AddCommand(auditSearchCmd)
+	auditSearchCmd.Flags().IntVarP(&auditSearchN, "number", "n", 20, "number of events to show")
+	auditSearchCmd.Flags().StringVarP(&auditSearchAgent, "agent", "a", "", "filter by agent name")
+
+	auditCmd.AddCommand(auditHoldCmd)
+	auditHoldCmd.AddCommand(auditHoldAddCmd, auditHoldRemoveCmd, auditHoldListCmd)
+	auditHoldAddCmd.Flags().StringVar(&auditHoldReason, "reason", "", "why the hold was placed (e.g. case number)")
+
+	auditCmd.AddCommand(auditPruneCmd)
+	auditPruneCmd.Flags().IntVar(&auditPruneDays, "days", 0, "delete events older than this many days (default audit.retention_days)")
 }
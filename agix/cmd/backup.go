@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/agent-platform/agix/internal/backup"
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreForce    bool
+	restoreNoConfig bool
+	restoreDatabase string
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <file>",
+	Short: "Back up the database and config to a file",
+	Long: `Write the database, the content database (if separate) and the config
+file to a single .tar.gz.
+
+SQLite is copied with the online backup API, so the gateway can keep
+running. PostgreSQL is dumped with pg_dump, which must be on PATH. MySQL is
+not supported; use mysqldump.
+
+Examples:
+  agix backup agix-2026-03-01.tar.gz
+  agix backup /mnt/backups/agix.tar.gz --config /etc/agix/config.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, cfgPath, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		f, err := os.OpenFile(args[0], os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		m, err := backup.Create(context.Background(), f, backup.Options{
+			ConfigPath: cfgPath,
+			DSN:        cfg.Database,
+			ContentDSN: cfg.ContentDatabase,
+		})
+		if err == nil {
+			err = f.Close()
+		} else {
+			f.Close()
+		}
+		if err != nil {
+			os.Remove(args[0])
+			return fmt.Errorf("backup: %w", err)
+		}
+
+		info, _ := os.Stat(args[0])
+		fmt.Printf("%s %s (%s, %d KB)\n", ui.Greenf("✓ Backed up to"), args[0], m.Dialect, info.Size()/1024)
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore the database and config from a backup",
+	Long: `Restore a file written by 'agix backup'. Stop the gateway first.
+
+The config is restored to --config (default ~/.agix/config.yaml) and the
+database to the path in that config, or --database. Existing files are only
+replaced with --force; PostgreSQL databases are always cleaned and reloaded
+with pg_restore.
+
+Examples:
+  agix restore agix-2026-03-01.tar.gz                 # Fresh host
+  agix restore agix.tar.gz --force                    # Replace config and database
+  agix restore agix.tar.gz --no-config --force        # Database only, keep config
+  agix restore agix.tar.gz --database /tmp/agix.db    # Restore to another path`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := backup.Open(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		defer b.Close()
+
+		cfgPath := cfgFile
+		if cfgPath == "" {
+			if cfgPath, err = config.DefaultConfigPath(); err != nil {
+				return fmt.Errorf("determine config path: %w", err)
+			}
+		}
+		if b.HasConfig() && !restoreNoConfig {
+			if err := b.RestoreConfig(cfgPath, restoreForce); err != nil {
+				return err
+			}
+			fmt.Printf("%s %s\n", ui.Greenf("✓ Restored config to"), cfgPath)
+		}
+		cfg, err := config.Load(cfgPath)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		ctx := context.Background()
+		dsn := cfg.Database
+		if restoreDatabase != "" {
+			dsn = restoreDatabase
+		}
+		if err := b.RestoreDatabase(ctx, dsn, restoreForce); err != nil {
+			return fmt.Errorf("restore database: %w", err)
+		}
+		fmt.Printf("%s %s\n", ui.Greenf("✓ Restored database to"), dsn)
+
+		if b.Manifest.Content {
+			if cfg.ContentDatabase == "" {
+				fmt.Printf("%s backup has a content database but content_database is not set; skipped\n", ui.Yellowf("!"))
+			} else {
+				if err := b.RestoreContent(ctx, cfg.ContentDatabase, restoreForce); err != nil {
+					return fmt.Errorf("restore content database: %w", err)
+				}
+				fmt.Printf("%s %s\n", ui.Greenf("✓ Restored content database to"), cfg.ContentDatabase)
+			}
+		}
+		fmt.Println(ui.Dimf("  Backup taken %s", b.Manifest.CreatedAt.Local().Format("2006-01-02 15:04")))
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Overwrite an existing config and database")
+	restoreCmd.Flags().BoolVar(&restoreNoConfig, "no-config", false, "Keep the current config; restore only the database")
+	restoreCmd.Flags().StringVar(&restoreDatabase, "database", "", "Restore the database here instead of the configured path")
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/confighistory"
+	"github.com/agent-platform/agix/internal/credits"
 	"github.com/agent-platform/agix/internal/store"
 	"github.com/agent-platform/agix/internal/ui"
 	"github.com/olekukonko/tablewriter"
@@ -13,9 +15,15 @@ import (
 )
 
 var (
-	budgetAgent   string
-	budgetDaily   float64
-	budgetMonthly float64
+	budgetAgent    string
+	budgetProvider string
+	budgetModel    string
+	budgetDaily    float64
+	budgetMonthly  float64
+	budgetRollover bool
+	budgetCredits  bool
+	topupAmount    float64
+	topupNote      string
 )
 
 var budgetCmd = &cobra.Command{
@@ -27,6 +35,10 @@ Examples:
   agix budget                                          # Show all budgets
   agix budget set --agent mybot --daily 5.00           # Set daily limit
   agix budget set --agent mybot --monthly 100.00       # Set monthly limit
+  agix budget set --agent mybot --rollover             # Carry unused daily budget over
+  agix budget set --agent mybot --credits              # Spend down prepaid credit
+  agix budget topup --agent mybot --amount 50          # Grant $50 of credit
+  agix budget credits                                  # Show credit balances
   agix budget remove --agent mybot                     # Remove budget`,
 }
 
@@ -48,29 +60,48 @@ var budgetListCmd = &cobra.Command{
 		fmt.Println(ui.Boldf("Agent Budgets"))
 		fmt.Println()
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
 		defer st.Close()
 
+		ledger, err := credits.New(st)
+		if err != nil {
+			return err
+		}
+
 		now := time.Now().UTC()
 
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Agent", "Daily Limit", "Daily Spend", "Monthly Limit", "Monthly Spend", "Status"})
+		table.SetHeader([]string{"Agent", "Daily Limit", "Daily Spend", "Monthly Limit", "Monthly Spend", "Credit", "Status"})
 		table.SetBorder(false)
 
-		for agent, b := range cfg.Budgets {
-			dailySpend, _ := st.QueryAgentDailySpend(agent, now)
-			monthlySpend, _ := st.QueryAgentMonthlySpend(agent, now.Year(), now.Month())
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for key, b := range cfg.Budgets {
+			agent, provider, model := config.BudgetScope(key)
+			filter := store.SpendFilter{Agent: agent, Provider: provider, Model: model}
+			dailySpend, _ := st.QuerySpend(filter, day, day.AddDate(0, 0, 1))
+			monthlySpend, _ := st.QuerySpend(filter, month, month.AddDate(0, 1, 0))
+			dailyLimit := b.DailyAllowance(now, monthlySpend-dailySpend)
+
+			credit := "-"
+			var bal credits.Balance
+			if b.Credits && agent != "" {
+				bal, _ = ledger.Balance(agent)
+				credit = fmt.Sprintf("$%.2f", bal.RemainingUSD)
+			}
 
 			status := "OK"
-			if b.DailyLimitUSD > 0 && dailySpend >= b.DailyLimitUSD {
+			if dailyLimit > 0 && dailySpend >= dailyLimit {
 				status = "DAILY LIMIT"
 			} else if b.MonthlyLimitUSD > 0 && monthlySpend >= b.MonthlyLimitUSD {
 				status = "MONTHLY LIMIT"
+			} else if b.Credits && agent != "" && bal.RemainingUSD <= 0 {
+				status = "NO CREDIT"
 			} else if b.AlertAtPercent > 0 {
-				if b.DailyLimitUSD > 0 && dailySpend/b.DailyLimitUSD*100 >= b.AlertAtPercent {
+				if dailyLimit > 0 && dailySpend/dailyLimit*100 >= b.AlertAtPercent {
 					status = "WARN"
 				}
 				if b.MonthlyLimitUSD > 0 && monthlySpend/b.MonthlyLimitUSD*100 >= b.AlertAtPercent {
@@ -78,12 +109,17 @@ var budgetListCmd = &cobra.Command{
 				}
 			}
 
+			daily := formatUSD(dailyLimit)
+			if b.Rollover && dailyLimit > 0 {
+				daily += " (rollover)"
+			}
 			table.Append([]string{
-				ui.Cyanf("%s", agent),
-				formatUSD(b.DailyLimitUSD),
+				ui.Cyanf("%s", key),
+				daily,
 				fmt.Sprintf("$%.2f", dailySpend),
 				formatUSD(b.MonthlyLimitUSD),
 				fmt.Sprintf("$%.2f", monthlySpend),
+				credit,
 				ui.BudgetStatusColor(status),
 			})
 		}
@@ -95,13 +131,14 @@ var budgetListCmd = &cobra.Command{
 
 var budgetSetCmd = &cobra.Command{
 	Use:   "set",
-	Short: "Set budget for an agent",
+	Short: "Set budget for an agent, a provider, a model or all traffic",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if budgetAgent == "" {
-			return fmt.Errorf("--agent is required")
+		key, err := budgetKey()
+		if err != nil {
+			return err
 		}
 
-		cfg, path, err := loadConfig()
+		cfg, path, err := loadConfigForEdit()
 		if err != nil {
 			return err
 		}
@@ -110,58 +147,181 @@ var budgetSetCmd = &cobra.Command{
 			cfg.Budgets = map[string]config.Budget{}
 		}
 
-		b := cfg.Budgets[budgetAgent]
+		b := cfg.Budgets[key]
 		if budgetDaily > 0 {
 			b.DailyLimitUSD = budgetDaily
 		}
 		if budgetMonthly > 0 {
 			b.MonthlyLimitUSD = budgetMonthly
 		}
+		if cmd.Flags().Changed("rollover") {
+			b.Rollover = budgetRollover
+		}
+		if cmd.Flags().Changed("credits") {
+			b.Credits = budgetCredits
+		}
 		if b.AlertAtPercent == 0 {
 			b.AlertAtPercent = 80 // Default alert threshold
 		}
-		cfg.Budgets[budgetAgent] = b
+		cfg.Budgets[key] = b
 
-		if err := config.Save(path, cfg); err != nil {
+		if err := saveConfig(path, cfg, "budget set "+key); err != nil {
 			return fmt.Errorf("save config: %w", err)
 		}
 
-		fmt.Printf("Budget set for agent %q:\n", budgetAgent)
+		fmt.Printf("Budget set for %s:\n", budgetTarget(key))
 		if b.DailyLimitUSD > 0 {
 			fmt.Printf("  Daily limit:  $%.2f\n", b.DailyLimitUSD)
 		}
 		if b.MonthlyLimitUSD > 0 {
 			fmt.Printf("  Monthly limit: $%.2f\n", b.MonthlyLimitUSD)
 		}
+		if b.Rollover {
+			fmt.Println("  Rollover:      unused daily budget carries over within the month")
+		}
+		if b.Credits {
+			fmt.Println("  Credits:       blocked when prepaid credit runs out")
+		}
 		return nil
 	},
 }
 
-var budgetRemoveCmd = &cobra.Command{
-	Use:   "remove",
-	Short: "Remove budget for an agent",
+var budgetTopupCmd = &cobra.Command{
+	Use:   "topup",
+	Short: "Grant prepaid credit to an agent",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if budgetAgent == "" {
 			return fmt.Errorf("--agent is required")
 		}
 
-		cfg, path, err := loadConfig()
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		st, err := openStore(cfg)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer st.Close()
+		ledger, err := credits.New(st)
+		if err != nil {
+			return err
+		}
+
+		if _, err := ledger.TopUp(budgetAgent, topupAmount, confighistory.Actor(), topupNote); err != nil {
+			return err
+		}
+		bal, err := ledger.Balance(budgetAgent)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Granted $%.2f to agent %q (balance $%.2f)\n", topupAmount, budgetAgent, bal.RemainingUSD)
+		if !cfg.Budgets[budgetAgent].Credits {
+			fmt.Println(ui.Dimf("Credit is not enforced for this agent yet; run 'agix budget set --agent %s --credits'.", budgetAgent))
+		}
+		return nil
+	},
+}
+
+var budgetCreditsCmd = &cobra.Command{
+	Use:   "credits",
+	Short: "Show prepaid credit balances and grants",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		st, err := openStore(cfg)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer st.Close()
+		ledger, err := credits.New(st)
+		if err != nil {
+			return err
+		}
+
+		grants, err := ledger.Grants(budgetAgent, 50)
+		if err != nil {
+			return err
+		}
+		if len(grants) == 0 {
+			fmt.Println(ui.Dimf("No credit granted."))
+			fmt.Println(ui.Dimf("Use 'agix budget topup --agent <name> --amount <usd>' to grant credit."))
+			return nil
+		}
+
+		fmt.Println(ui.Boldf("Credit Balances"))
+		fmt.Println()
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Agent", "Granted", "Spent", "Remaining", "Since"})
+		table.SetBorder(false)
+		seen := map[string]bool{}
+		for _, g := range grants {
+			if seen[g.Agent] {
+				continue
+			}
+			seen[g.Agent] = true
+			bal, err := ledger.Balance(g.Agent)
+			if err != nil {
+				return err
+			}
+			table.Append([]string{
+				ui.Cyanf("%s", g.Agent),
+				fmt.Sprintf("$%.2f", bal.GrantedUSD),
+				fmt.Sprintf("$%.2f", bal.SpentUSD),
+				fmt.Sprintf("$%.2f", bal.RemainingUSD),
+				bal.Since.Local().Format("2006-01-02"),
+			})
+		}
+		table.Render()
+
+		fmt.Println()
+		fmt.Println(ui.Boldf("Recent Grants"))
+		fmt.Println()
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Time", "Agent", "Amount", "By", "Note"})
+		table.SetBorder(false)
+		for _, g := range grants {
+			table.Append([]string{
+				g.Timestamp.Local().Format("2006-01-02 15:04"),
+				g.Agent,
+				fmt.Sprintf("$%.2f", g.AmountUSD),
+				g.Actor,
+				g.Note,
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var budgetRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove budget for an agent, a provider, a model or all traffic",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := budgetKey()
+		if err != nil {
+			return err
+		}
+
+		cfg, path, err := loadConfigForEdit()
 		if err != nil {
 			return err
 		}
 
-		if _, ok := cfg.Budgets[budgetAgent]; !ok {
-			fmt.Printf("No budget configured for agent %q\n", budgetAgent)
+		if _, ok := cfg.Budgets[key]; !ok {
+			fmt.Printf("No budget configured for %s in %s\n", budgetTarget(key), path)
 			return nil
 		}
 
-		delete(cfg.Budgets, budgetAgent)
+		delete(cfg.Budgets, key)
 
-		if err := config.Save(path, cfg); err != nil {
+		if err := saveConfig(path, cfg, "budget remove "+key); err != nil {
 			return fmt.Errorf("save config: %w", err)
 		}
 
-		fmt.Printf("Budget removed for agent %q\n", budgetAgent)
+		fmt.Printf("Budget removed for %s\n", budgetTarget(key))
 		return nil
 	},
 }
@@ -171,6 +331,8 @@ func init() {
 	budgetCmd.AddCommand(budgetListCmd)
 	budgetCmd.AddCommand(budgetSetCmd)
 	budgetCmd.AddCommand(budgetRemoveCmd)
+	budgetCmd.AddCommand(budgetTopupCmd)
+	budgetCmd.AddCommand(budgetCreditsCmd)
 
 	// Default to list when running `agix budget` without subcommand
 	budgetCmd.RunE = budgetListCmd.RunE
@@ -178,8 +340,61 @@ func init() {
 	budgetSetCmd.Flags().StringVarP(&budgetAgent, "agent", "a", "", "agent name")
 	budgetSetCmd.Flags().Float64VarP(&budgetDaily, "daily", "d", 0, "daily spending limit in USD")
 	budgetSetCmd.Flags().Float64VarP(&budgetMonthly, "monthly", "m", 0, "monthly spending limit in USD")
+	budgetSetCmd.Flags().BoolVar(&budgetRollover, "rollover", false, "carry unused daily budget over to later days of the month")
+	budgetSetCmd.Flags().BoolVar(&budgetCredits, "credits", false, "block the agent when its prepaid credit runs out")
+
+	budgetSetCmd.Flags().StringVar(&budgetProvider, "provider", "", "set the budget on all spend with this provider instead")
+	budgetSetCmd.Flags().StringVar(&budgetModel, "model", "", "set the budget on all spend on this model instead")
 
 	budgetRemoveCmd.Flags().StringVarP(&budgetAgent, "agent", "a", "", "agent name")
+	budgetRemoveCmd.Flags().StringVar(&budgetProvider, "provider", "", "remove this provider's budget instead")
+	budgetRemoveCmd.Flags().StringVar(&budgetModel, "model", "", "remove this model's budget instead")
+
+	budgetTopupCmd.Flags().StringVarP(&budgetAgent, "agent", "a", "", "agent name")
+	budgetTopupCmd.Flags().Float64Var(&topupAmount, "amount", 0, "credit to grant in USD")
+	budgetTopupCmd.Flags().StringVar(&topupNote, "note", "", "reason for the grant, e.g. a project or ticket")
+
+	budgetCreditsCmd.Flags().StringVarP(&budgetAgent, "agent", "a", "", "only show this agent")
+}
+
+// budgetKey returns the budgets entry --agent, --provider or --model
+// names. The agent "global" is the budget on all traffic.
+func budgetKey() (string, error) {
+	set := 0
+	for _, v := range []string{budgetAgent, budgetProvider, budgetModel} {
+		if v != "" {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return "", fmt.Errorf("--agent, --provider and --model are mutually exclusive")
+	case budgetProvider != "":
+		return config.ProviderBudget(budgetProvider), nil
+	case budgetModel != "":
+		return config.ModelBudget(budgetModel), nil
+	case budgetAgent == "":
+		return "", fmt.Errorf("--agent, --provider or --model is required")
+	case budgetAgent == "providers":
+		return "", fmt.Errorf("%q is reserved, use --provider", budgetAgent)
+	case budgetAgent == "models":
+		return "", fmt.Errorf("%q is reserved, use --model", budgetAgent)
+	}
+	return budgetAgent, nil
+}
+
+// budgetTarget describes whose spend the budget under key caps.
+func budgetTarget(key string) string {
+	agent, provider, model := config.BudgetScope(key)
+	switch {
+	case provider != "":
+		return fmt.Sprintf("provider %q", provider)
+	case model != "":
+		return fmt.Sprintf("model %q", model)
+	case agent == "":
+		return "all traffic"
+	}
+	return fmt.Sprintf("agent %q", agent)
 }
 
 func formatUSD(v float64) string {
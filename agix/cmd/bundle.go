@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/agent-platform/agix/internal/bundle"
-	"github.com/agent-platform/agix/internal/config"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -103,7 +102,7 @@ var bundleInstallCmd = &cobra.Command{
 	Short: "Install a bundle into config",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, cfgPath, err := loadConfig()
+		cfg, cfgPath, err := loadConfigForEdit()
 		if err != nil {
 			return err
 		}
@@ -117,7 +116,7 @@ var bundleInstallCmd = &cobra.Command{
 			return err
 		}
 
-		if err := config.Save(cfgPath, cfg); err != nil {
+		if err := saveConfig(cfgPath, cfg, "bundle install "+b.Name); err != nil {
 			return fmt.Errorf("save config: %w", err)
 		}
 
@@ -132,7 +131,7 @@ var bundleRemoveCmd = &cobra.Command{
 	Short: "Remove a bundle from config",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, cfgPath, err := loadConfig()
+		cfg, cfgPath, err := loadConfigForEdit()
 		if err != nil {
 			return err
 		}
@@ -146,7 +145,7 @@ var bundleRemoveCmd = &cobra.Command{
 			return err
 		}
 
-		if err := config.Save(cfgPath, cfg); err != nil {
+		if err := saveConfig(cfgPath, cfg, "bundle remove "+b.Name); err != nil {
 			return fmt.Errorf("save config: %w", err)
 		}
 
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/agent-platform/agix/internal/cache"
+	"github.com/agent-platform/agix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the response cache",
+}
+
+var (
+	cacheWarmFile    string
+	cacheWarmModel   string
+	cacheWarmMaxCost float64
+	cacheWarmWindow  string
+	cacheWarmAgent   string
+	cacheWarmGateway string
+	cacheWarmDelay   int
+)
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Pre-populate the cache from a file of seed prompts",
+	Long: `Send seed prompts through the running gateway so their responses are cached
+before a traffic spike. Each line of the JSONL file is {"messages": [...]} or
+{"prompt": "..."}, optionally with its own "model".
+
+Requests go through the full pipeline, so they are routed, budgeted and
+billed like any other agent (default agent name: cache-warmer).`,
+	Example: `  agix cache warm --file prompts.jsonl --model gpt-4o-mini
+  agix cache warm --file prompts.jsonl --model gpt-4o-mini --max-cost 5 --window 01:00-06:00`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cacheWarmFile == "" {
+			return fmt.Errorf("--file is required")
+		}
+
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if !cfg.Cache.Enabled {
+			return fmt.Errorf("cache is disabled; set cache.enabled: true and restart the gateway")
+		}
+
+		f, err := os.Open(cacheWarmFile)
+		if err != nil {
+			return fmt.Errorf("open seed file: %w", err)
+		}
+		seeds, err := cache.ParseSeeds(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", cacheWarmFile, err)
+		}
+		if len(seeds) == 0 {
+			fmt.Println(ui.Dimf("No seed prompts found."))
+			return nil
+		}
+		for i, s := range seeds {
+			if s.Model == "" && cacheWarmModel == "" {
+				return fmt.Errorf("seed %d has no model; pass --model", i+1)
+			}
+		}
+
+		var window *cache.Window
+		if cacheWarmWindow != "" {
+			window, err = cache.ParseWindow(cacheWarmWindow)
+			if err != nil {
+				return err
+			}
+		}
+
+		gateway := cacheWarmGateway
+		if gateway == "" {
+			gateway = localGateway(cfg)
+		}
+
+		wm := cache.NewWarmer(cache.WarmConfig{
+			GatewayURL: gateway,
+			AgentName:  cacheWarmAgent,
+			APIKey:     os.Getenv("AGIX_API_KEY"),
+			Model:      cacheWarmModel,
+			MaxCostUSD: cacheWarmMaxCost,
+			Window:     window,
+			Delay:      time.Duration(cacheWarmDelay) * time.Millisecond,
+		})
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if window != nil && !window.Contains(time.Now()) {
+			fmt.Printf("Waiting for window %s (%s)...\n", cacheWarmWindow, window.Until(time.Now()).Round(time.Second))
+		}
+		fmt.Printf("Warming cache with %d seed prompt(s) via %s\n", len(seeds), gateway)
+
+		res, err := wm.Run(ctx, seeds, func(i int, outcome string) {
+			fmt.Printf("  [%d/%d] %s\n", i+1, len(seeds), outcome)
+		})
+
+		fmt.Println()
+		fmt.Printf("  %s %d\n", ui.Dimf("Warmed:"), res.Warmed)
+		fmt.Printf("  %s %d\n", ui.Dimf("Already cached:"), res.Cached)
+		fmt.Printf("  %s %d\n", ui.Dimf("Failed:"), res.Failed)
+		fmt.Printf("  %s $%.4f\n", ui.Dimf("Cost:"), res.CostUSD)
+		switch res.StoppedBy {
+		case "cost_cap":
+			fmt.Println(ui.Yellowf("  Stopped at cost cap $%.2f; %d seed(s) not sent.", cacheWarmMaxCost, res.Skipped))
+		case "window":
+			fmt.Println(ui.Yellowf("  Window %s closed; %d seed(s) not sent.", cacheWarmWindow, res.Skipped))
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheWarmCmd)
+	cacheWarmCmd.Flags().StringVarP(&cacheWarmFile, "file", "f", "", "JSONL file of seed prompts (required)")
+	cacheWarmCmd.Flags().StringVarP(&cacheWarmModel, "model", "m", "", "model for seeds that don't set one")
+	cacheWarmCmd.Flags().Float64Var(&cacheWarmMaxCost, "max-cost", 0, "stop once spend reaches this many USD (0 = no cap)")
+	cacheWarmCmd.Flags().StringVar(&cacheWarmWindow, "window", "", "only send during this local-time window, e.g. 01:00-06:00")
+	cacheWarmCmd.Flags().StringVarP(&cacheWarmAgent, "agent", "a", "cache-warmer", "agent name the requests are attributed to")
+	cacheWarmCmd.Flags().StringVar(&cacheWarmGateway, "gateway", "", "gateway URL (default http(s)://localhost:<port>)")
+	cacheWarmCmd.Flags().IntVar(&cacheWarmDelay, "delay-ms", 0, "pause between requests in milliseconds")
+}
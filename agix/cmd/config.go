@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/confighistory"
+	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/ui"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Review and roll back configuration changes",
+	Long: `Every version of the config file is recorded with who applied it and how:
+changes made by agix commands (budget, bundle, rollback) when they happen,
+and manual edits when the gateway next starts.`,
+}
+
+var configHistoryLimit int
+
+var configHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recorded config versions",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, history, err := openConfigHistory()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		changes, err := history.List(configHistoryLimit)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Println(ui.Dimf("No config changes recorded."))
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Time", "Actor", "Source", "Changes"})
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		for _, c := range changes {
+			added, removed := confighistory.Stat(c.Diff)
+			table.Append([]string{
+				strconv.FormatInt(c.ID, 10),
+				c.Timestamp.Local().Format("2006-01-02 15:04:05"),
+				c.Actor,
+				c.Source,
+				ui.Greenf("+%d", added) + "/" + ui.Redf("-%d", removed),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var configShowFull bool
+
+var configShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the diff (or full YAML) of a config version",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getConfigChange(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Version %d — %s by %s (%s)\n\n", c.ID, c.Timestamp.Local().Format("2006-01-02 15:04:05"), c.Actor, c.Source)
+		if configShowFull {
+			fmt.Print(c.Config)
+			return nil
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(c.Diff, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				fmt.Println(ui.Greenf("%s", line))
+			case strings.HasPrefix(line, "-"):
+				fmt.Println(ui.Redf("%s", line))
+			case strings.HasPrefix(line, "@@"):
+				fmt.Println(ui.Cyanf("%s", line))
+			default:
+				fmt.Println(line)
+			}
+		}
+		return nil
+	},
+}
+
+var configRollbackCmd = &cobra.Command{
+	Use:   "rollback <id>",
+	Short: "Restore the config file to a recorded version",
+	Long: `Write a recorded version back to the config file. The rollback is itself
+recorded as a new version. Restart the gateway to apply it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getConfigChange(args[0])
+		if err != nil {
+			return err
+		}
+		if _, err := config.Parse([]byte(c.Config)); err != nil {
+			return fmt.Errorf("version %d: %w", c.ID, err)
+		}
+
+		path := cfgFile
+		if path == "" {
+			if path, err = config.DefaultConfigPath(); err != nil {
+				return fmt.Errorf("determine config path: %w", err)
+			}
+		}
+		err = applyConfigChange(path, fmt.Sprintf("rollback to %d", c.ID), func() error {
+			return os.WriteFile(path, []byte(c.Config), 0o600)
+		})
+		if err != nil {
+			return fmt.Errorf("write config file: %w", err)
+		}
+
+		fmt.Printf("Restored %s to version %d. Restart the gateway to apply it.\n", path, c.ID)
+		return nil
+	},
+}
+
+func getConfigChange(arg string) (*confighistory.Change, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q", arg)
+	}
+	st, history, err := openConfigHistory()
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	c, err := history.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("config version %d not found", id)
+	}
+	return c, nil
+}
+
+func openConfigHistory() (*store.Store, *confighistory.History, error) {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := openStore(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+	history, err := confighistory.New(st.DB(), st.Dialect())
+	if err != nil {
+		st.Close()
+		return nil, nil, err
+	}
+	return st, history, nil
+}
+
+// applyConfigChange runs write, which changes the config file at path, and
+// records the result. Hand edits made since the last recorded version are
+// recorded first, so the new version's diff shows only this change. The
+// history is best effort: failing to record never blocks the change.
+func applyConfigChange(path, source string, write func() error) error {
+	st, history, err := openConfigHistory()
+	if err != nil {
+		log.Printf("WARN: record config change: %v", err)
+		return write()
+	}
+	defer st.Close()
+
+	recordConfigVersion(history, path, sourceManualEdit)
+	if err := write(); err != nil {
+		return err
+	}
+	recordConfigVersion(history, path, source)
+	return nil
+}
+
+// saveConfig writes cfg to path as an agix command, recording the change.
+func saveConfig(path string, cfg *config.Config, source string) error {
+	return applyConfigChange(path, source, func() error { return config.Save(path, cfg) })
+}
+
+// sourceManualEdit marks versions found on disk rather than written by agix.
+const sourceManualEdit = "manual edit"
+
+// recordConfigVersion records the config file at path if it changed.
+func recordConfigVersion(history *confighistory.History, path, source string) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		_, err = history.Record(confighistory.Actor(), source, data)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("WARN: record config change: %v", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configHistoryCmd, configShowCmd, configRollbackCmd)
+
+	configHistoryCmd.Flags().IntVarP(&configHistoryLimit, "number", "n", 20, "number of versions to show")
+	configShowCmd.Flags().BoolVar(&configShowFull, "full", false, "print the full YAML instead of the diff")
+}
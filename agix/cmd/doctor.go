@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
 
+	"github.com/agent-platform/agix/internal/alert"
+	"github.com/agent-platform/agix/internal/config"
 	"github.com/agent-platform/agix/internal/doctor"
+	"github.com/agent-platform/agix/internal/secrets"
 	"github.com/spf13/cobra"
 )
 
+var doctorNotify string
+
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Check configuration and dependencies",
@@ -16,13 +23,33 @@ var doctorCmd = &cobra.Command{
   - API key validity (lightweight models list request)
   - Budget configuration sanity (daily < monthly)
   - Firewall rule regex syntax
-  - Database connectivity and integrity (SQLite or PostgreSQL)`,
+  - Models in failover chains, routing and experiments are known
+  - Database connectivity and integrity (SQLite, PostgreSQL or MySQL)
+
+With --notify, failures are emailed to the recipients of an alert
+destination, e.g. from a cron job:
+
+  agix doctor --notify ops`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, cfgPath, err := loadConfig()
 		if err != nil {
 			return err
 		}
-		fails := doctor.Run(os.Stdout, cfg, cfgPath)
+		if err := secrets.NewResolver().ResolveKeys(context.Background(), cfg); err != nil {
+			return fmt.Errorf("resolve secrets: %w", err)
+		}
+		results := doctor.Report(os.Stdout, cfg, cfgPath)
+		var fails int
+		for _, r := range results {
+			if r.Status == doctor.StatusFail {
+				fails++
+			}
+		}
+		if fails > 0 && doctorNotify != "" {
+			if err := notifyDoctorFailures(cfg, doctorNotify, results); err != nil {
+				fmt.Fprintf(os.Stderr, "notify %s: %v\n", doctorNotify, err)
+			}
+		}
 		if fails > 0 {
 			os.Exit(1)
 		}
@@ -32,4 +59,18 @@ var doctorCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVar(&doctorNotify, "notify", "", "email failures to this alert destination")
+}
+
+func notifyDoctorFailures(cfg *config.Config, destination string, results []doctor.Result) error {
+	host, _ := os.Hostname()
+	checks := make([]alert.CheckResult, 0, len(results))
+	for _, r := range results {
+		checks = append(checks, alert.CheckResult{Status: r.Status.String(), Message: r.Message})
+	}
+	subject, body, err := alert.RenderHealthReport(host, checks)
+	if err != nil {
+		return err
+	}
+	return sendEmail(cfg, destination, subject, body)
 }
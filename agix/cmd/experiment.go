@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/agent-platform/agix/internal/config"
 	"github.com/agent-platform/agix/internal/experiment"
+	"github.com/agent-platform/agix/internal/ui"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -66,18 +68,7 @@ var experimentCheckCmd = &cobra.Command{
 			return err
 		}
 
-		var exps []experiment.Config
-		for _, e := range cfg.Experiments {
-			exps = append(exps, experiment.Config{
-				Name:         e.Name,
-				Enabled:      e.Enabled,
-				ControlModel: e.ControlModel,
-				VariantModel: e.VariantModel,
-				TrafficPct:   e.TrafficPct,
-			})
-		}
-
-		em := experiment.New(exps)
+		em := experiment.New(experimentConfigs(cfg))
 		if em == nil {
 			fmt.Println("No enabled experiments.")
 			return nil
@@ -85,19 +76,155 @@ var experimentCheckCmd = &cobra.Command{
 
 		assignment := em.Assign(agentName, model)
 		if assignment == nil {
-			fmt.Printf("No experiment matches model %q\n", model)
+			fmt.Printf("No experiment matches model %q for agent %q\n", model, agentName)
 			return nil
 		}
 
+		// A persisted assignment overrides the hash bucket. Look it up
+		// read-only so check never assigns the agent itself.
+		source := "hash"
+		if st, err := openStore(cfg); err == nil {
+			defer st.Close()
+			if as, err := experiment.NewAssignmentStore(st.DB(), st.Dialect()); err == nil {
+				if rec, err := as.Get(assignment.ExperimentName, agentName); err == nil && rec != nil {
+					assignment.Variant = rec.Variant
+					assignment.Model = variantModel(cfg, assignment.ExperimentName, rec.Variant)
+					source = "persisted " + rec.AssignedAt.Local().Format("2006-01-02 15:04:05")
+				}
+			}
+		}
+
 		fmt.Printf("Experiment: %s\n", assignment.ExperimentName)
 		fmt.Printf("Variant:    %s\n", assignment.Variant)
 		fmt.Printf("Model:      %s\n", assignment.Model)
+		fmt.Printf("Source:     %s\n", source)
 		return nil
 	},
 }
 
+var experimentAssignmentsReset bool
+
+var experimentAssignmentsCmd = &cobra.Command{
+	Use:   "assignments [experiment]",
+	Short: "List persisted agent assignments",
+	Long: `List the variant each agent was assigned in an experiment, and when.
+
+Assignments are sticky: once an agent is assigned it keeps its variant even if
+traffic_pct changes. Use --reset to clear an experiment's assignments so
+agents are re-bucketed on their next request.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if experimentAssignmentsReset && name == "" {
+			return fmt.Errorf("--reset requires an experiment name")
+		}
+
+		st, err := openStore(cfg)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer st.Close()
+
+		as, err := experiment.NewAssignmentStore(st.DB(), st.Dialect())
+		if err != nil {
+			return err
+		}
+
+		if experimentAssignmentsReset {
+			n, err := as.Reset(name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Cleared %d assignment(s) for experiment %q.\n", n, name)
+			return nil
+		}
+
+		records, err := as.List(name)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			fmt.Println(ui.Dimf("No experiment assignments recorded."))
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Experiment", "Agent", "Variant", "Model", "Assigned At"})
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+
+		counts := make(map[string]map[string]int)
+		for _, r := range records {
+			if counts[r.Experiment] == nil {
+				counts[r.Experiment] = make(map[string]int)
+			}
+			counts[r.Experiment][r.Variant]++
+			table.Append([]string{
+				r.Experiment,
+				r.AgentName,
+				r.Variant,
+				variantModel(cfg, r.Experiment, r.Variant),
+				r.AssignedAt.Local().Format("2006-01-02 15:04:05"),
+			})
+		}
+		table.Render()
+
+		fmt.Println()
+		for _, r := range records {
+			c, ok := counts[r.Experiment]
+			if !ok {
+				continue
+			}
+			fmt.Printf("%s: %d control, %d variant\n", r.Experiment, c["control"], c["variant"])
+			delete(counts, r.Experiment)
+		}
+		return nil
+	},
+}
+
+// experimentConfigs converts the configured experiments.
+func experimentConfigs(cfg *config.Config) []experiment.Config {
+	var exps []experiment.Config
+	for _, e := range cfg.Experiments {
+		exps = append(exps, experiment.Config{
+			Name:          e.Name,
+			Enabled:       e.Enabled,
+			ControlModel:  e.ControlModel,
+			VariantModel:  e.VariantModel,
+			TrafficPct:    e.TrafficPct,
+			ExcludeAgents: e.ExcludeAgents,
+		})
+	}
+	return exps
+}
+
+// variantModel returns the model an experiment's variant currently maps to,
+// or "-" if the experiment is no longer configured.
+func variantModel(cfg *config.Config, name, variant string) string {
+	for _, e := range cfg.Experiments {
+		if e.Name != name {
+			continue
+		}
+		if variant == "variant" {
+			return e.VariantModel
+		}
+		return e.ControlModel
+	}
+	return "-"
+}
+
 func init() {
 	rootCmd.AddCommand(experimentCmd)
 	experimentCmd.AddCommand(experimentListCmd)
 	experimentCmd.AddCommand(experimentCheckCmd)
+	experimentCmd.AddCommand(experimentAssignmentsCmd)
+
+	experimentAssignmentsCmd.Flags().BoolVar(&experimentAssignmentsReset, "reset", false, "clear the experiment's assignments")
 }
@@ -1,12 +1,14 @@
 package cmd
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/export"
+	"github.com/agent-platform/agix/internal/tags"
 	"github.com/spf13/cobra"
 )
 
@@ -14,35 +16,70 @@ var (
 	exportFormat string
 	exportOutput string
 	exportPeriod string
+	exportSince  string
+	exportUntil  string
+	exportTags   []string
 )
 
 var exportCmd = &cobra.Command{
 	Use:   "export",
-	Short: "Export usage data to CSV or JSON",
+	Short: "Export usage data to CSV, JSON, NDJSON or Parquet",
 	Long: `Export recorded API usage data for analysis or reporting.
 
 Examples:
   agix export                          # CSV to stdout
   agix export --format json            # JSON to stdout
   agix export -o costs.csv             # CSV to file
-  agix export --period 30d -o report.json --format json`,
+  agix export --period 30d -o report.json --format json
+  agix export -f parquet --since 2026-03-01 --until 2026-04-01 -o march.parquet
+  agix export -f ndjson --since 2026-03-01T00:00:00Z > usage.ndjson
+  agix export --tag team=platform -o platform.csv
+
+--since and --until take YYYY-MM-DD or RFC 3339 times and override --period;
+--until is exclusive. --tag keeps only requests carrying that X-Request-Tags
+pair and may be repeated.
+
+When cost_centers is configured, each record carries the agent's cost center.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, _, err := loadConfig()
+		cfg, cfgPath, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		centers, err := loadCostCenters(cfg, cfgPath)
 		if err != nil {
 			return err
 		}
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
 		defer st.Close()
 
 		since, until := parsePeriod(exportPeriod)
+		if exportSince != "" {
+			if since, err = export.ParseTime(exportSince); err != nil {
+				return fmt.Errorf("--since: %w", err)
+			}
+		}
+		if exportUntil != "" {
+			if until, err = export.ParseTime(exportUntil); err != nil {
+				return fmt.Errorf("--until: %w", err)
+			}
+			until = until.Add(-time.Second)
+		}
+		if !slices.Contains(export.Formats, exportFormat) {
+			return fmt.Errorf("unsupported format: %s (use %s)", exportFormat, strings.Join(export.Formats, ", "))
+		}
+		filter, err := tags.ParseFilter(exportTags)
+		if err != nil {
+			return fmt.Errorf("--tag: %w", err)
+		}
 		records, err := st.ExportCSV(since, until)
 		if err != nil {
 			return fmt.Errorf("export data: %w", err)
 		}
+		records = tags.FilterRecords(records, filter)
 
 		if len(records) == 0 {
 			fmt.Fprintln(os.Stderr, "No records found for this period.")
@@ -62,87 +99,20 @@ Examples:
 			out = os.Stdout
 		}
 
-		switch exportFormat {
-		case "csv":
-			return exportCSV(out, records)
-		case "json":
-			return exportJSON(out, records)
-		default:
-			return fmt.Errorf("unsupported format: %s (use csv or json)", exportFormat)
+		var costCenter func(string) string
+		if centers != nil {
+			costCenter = centers.Lookup
 		}
+		return export.Write(out, exportFormat, records, costCenter)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
-	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "csv", "output format: csv, json")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "csv", "output format: csv, json, ndjson, parquet")
 	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "output file (default: stdout)")
 	exportCmd.Flags().StringVarP(&exportPeriod, "period", "P", "all", "time period: today, 7d, 30d, all")
-}
-
-func exportCSV(out *os.File, records []store.Record) error {
-	w := csv.NewWriter(out)
-	defer w.Flush()
-
-	// Header
-	if err := w.Write([]string{
-		"id", "timestamp", "agent_name", "model", "provider",
-		"input_tokens", "output_tokens", "cost_usd", "duration_ms", "status_code",
-	}); err != nil {
-		return err
-	}
-
-	for _, r := range records {
-		if err := w.Write([]string{
-			fmt.Sprintf("%d", r.ID),
-			r.Timestamp.Format("2006-01-02T15:04:05Z"),
-			r.AgentName,
-			r.Model,
-			r.Provider,
-			fmt.Sprintf("%d", r.InputTokens),
-			fmt.Sprintf("%d", r.OutputTokens),
-			fmt.Sprintf("%.6f", r.CostUSD),
-			fmt.Sprintf("%d", r.DurationMS),
-			fmt.Sprintf("%d", r.StatusCode),
-		}); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func exportJSON(out *os.File, records []store.Record) error {
-	type jsonRecord struct {
-		ID           int64   `json:"id"`
-		Timestamp    string  `json:"timestamp"`
-		AgentName    string  `json:"agent_name"`
-		Model        string  `json:"model"`
-		Provider     string  `json:"provider"`
-		InputTokens  int     `json:"input_tokens"`
-		OutputTokens int     `json:"output_tokens"`
-		CostUSD      float64 `json:"cost_usd"`
-		DurationMS   int64   `json:"duration_ms"`
-		StatusCode   int     `json:"status_code"`
-	}
-
-	output := make([]jsonRecord, len(records))
-	for i, r := range records {
-		output[i] = jsonRecord{
-			ID:           r.ID,
-			Timestamp:    r.Timestamp.Format("2006-01-02T15:04:05Z"),
-			AgentName:    r.AgentName,
-			Model:        r.Model,
-			Provider:     r.Provider,
-			InputTokens:  r.InputTokens,
-			OutputTokens: r.OutputTokens,
-			CostUSD:      r.CostUSD,
-			DurationMS:   r.DurationMS,
-			StatusCode:   r.StatusCode,
-		}
-	}
-
-	enc := json.NewEncoder(out)
-	enc.SetIndent("", "  ")
-	return enc.Encode(output)
+	exportCmd.Flags().StringVar(&exportSince, "since", "", "start time, YYYY-MM-DD or RFC 3339 (overrides --period)")
+	exportCmd.Flags().StringVar(&exportUntil, "until", "", "end time, exclusive, YYYY-MM-DD or RFC 3339 (overrides --period)")
+	exportCmd.Flags().StringArrayVar(&exportTags, "tag", nil, "only export requests tagged key=value (repeatable)")
 }
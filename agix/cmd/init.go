@@ -24,7 +24,7 @@ var initCmd = &cobra.Command{
 
 		// If config already exists, merge with defaults to pick up new keys
 		if _, err := os.Stat(path); err == nil {
-			cfg, loadErr := config.Load(path)
+			cfg, loadErr := config.LoadFile(path)
 			if loadErr != nil {
 				return fmt.Errorf("load existing config: %w", loadErr)
 			}
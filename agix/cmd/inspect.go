@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/agent-platform/agix/internal/audit"
+	"github.com/agent-platform/agix/internal/inspect"
+	"github.com/agent-platform/agix/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inspectDiff    bool
+	inspectContext int
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <request-id>",
+	Short: "Show how the gateway modified a request",
+	Long: `Show the request body as the agent sent it and after every pipeline stage
+that changed it (session override, prompt injection, routing, experiments,
+compression, tool injection, provider format conversion).
+
+Requires payload capture, which stores full request bodies:
+
+  audit:
+    enabled: true
+    content_log: true
+    payload_capture: true
+
+The request ID is returned in the X-Request-ID response header.
+
+Examples:
+  agix inspect 3f9a1c2b7d4e          # Print the body after each stage
+  agix inspect 3f9a1c2b7d4e --diff   # Show what each stage changed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		st, err := openStore(cfg)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer st.Close()
+
+		logger := audit.New(st.ContentDB(), false, st.ContentDialect())
+		event, details, err := logger.QueryPayloadCapture(args[0])
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return fmt.Errorf("no payload capture found for request %s (is audit.payload_capture enabled?)", args[0])
+		}
+
+		agent := event.AgentName
+		if agent == "" {
+			agent = "(unknown)"
+		}
+		fmt.Printf("%s %s\n", ui.Boldf("Request"), ui.Cyanf("%s", details.RequestID))
+		fmt.Printf("  %s  %s\n", ui.Dimf("Time: "), event.Timestamp.Format("2006-01-02 15:04:05"))
+		fmt.Printf("  %s  %s\n", ui.Dimf("Agent:"), agent)
+		fmt.Printf("  %s  %s\n", ui.Dimf("Model:"), details.Model)
+		fmt.Printf("  %s  %d\n", ui.Dimf("Stages:"), len(details.Stages))
+		fmt.Println()
+
+		for i, stage := range details.Stages {
+			fmt.Println(ui.Boldf("[%d] %s", i+1, stage.Stage) + ui.Dimf(" (%d bytes)", len(stage.Body)))
+			if !inspectDiff || i == 0 {
+				fmt.Println(inspect.Pretty(stage.Body))
+				fmt.Println()
+				continue
+			}
+			prev := inspect.Pretty(details.Stages[i-1].Body)
+			printDiff(inspect.Diff(prev, inspect.Pretty(stage.Body), inspectContext))
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+func printDiff(lines []inspect.Line) {
+	for _, l := range lines {
+		switch l.Op {
+		case inspect.OpInsert:
+			fmt.Println(ui.Greenf("+ %s", l.Text))
+		case inspect.OpDelete:
+			fmt.Println(ui.Redf("- %s", l.Text))
+		case inspect.OpSkip:
+			fmt.Println(ui.Dimf("  %s", l.Text))
+		default:
+			fmt.Printf("  %s\n", l.Text)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+	inspectCmd.Flags().BoolVar(&inspectDiff, "diff", false, "show changes between consecutive stages instead of full bodies")
+	inspectCmd.Flags().IntVarP(&inspectContext, "context", "C", 3, "unchanged lines of context around each change (with --diff)")
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/ui"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the API keys agents use to call agix",
+	Long: `Issues per-agent API keys. A request carrying a key (Authorization: Bearer
+agix-... or x-api-key) is attributed to the key's agent, whatever X-Agent-Name
+says. Set auth.require_keys to reject requests without a key.`,
+}
+
+// openKeys opens the database holding the api_keys table.
+func openKeys() (*apikeys.Keys, func(), error) {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := openStore(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+	keys, err := apikeys.New(st.DB(), st.Dialect())
+	if err != nil {
+		st.Close()
+		return nil, nil, err
+	}
+	return keys, func() { st.Close() }, nil
+}
+
+var keysCreateCmd = &cobra.Command{
+	Use:     "create <agent>",
+	Short:   "Issue an API key for an agent",
+	Example: `  agix keys create code-reviewer`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, closeFn, err := openKeys()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		secret, key, err := keys.Create(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s API key %d for agent %s\n\n", ui.Greenf("Created"), key.ID, ui.Boldf("%s", key.Agent))
+		fmt.Printf("  %s\n\n", secret)
+		fmt.Println(ui.Yellowf("Store it now: the key cannot be shown again."))
+		return nil
+	},
+}
+
+var keysListAll bool
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List issued API keys",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, closeFn, err := openKeys()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		list, err := keys.List(keysListAll)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			fmt.Println(ui.Dimf("No API keys. Create one with: agix keys create <agent>"))
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Prefix", "Agent", "Created", "Last used", "Status"})
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+		for _, k := range list {
+			used := "-"
+			if !k.LastUsedAt.IsZero() {
+				used = k.LastUsedAt.Local().Format("2006-01-02 15:04")
+			}
+			status := ui.Greenf("active")
+			if k.Revoked() {
+				status = ui.Redf("revoked %s", k.RevokedAt.Local().Format("2006-01-02"))
+			}
+			table.Append([]string{
+				fmt.Sprintf("%d", k.ID),
+				k.Prefix + "...",
+				k.Agent,
+				k.CreatedAt.Local().Format("2006-01-02 15:04"),
+				used,
+				status,
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var keysRevokeCmd = &cobra.Command{
+	Use:   "revoke <id|prefix>",
+	Short: "Revoke an API key",
+	Long: `Revokes a key by ID or by the prefix shown in 'agix keys list'. A running
+gateway stops accepting it within 30 seconds.`,
+	Example: `  agix keys revoke 3
+  agix keys revoke agix-1a2b3c4d`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, closeFn, err := openKeys()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		key, err := keys.Revoke(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s API key %d (%s...) for agent %s\n", ui.Greenf("Revoked"), key.ID, key.Prefix, key.Agent)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+	keysCmd.AddCommand(keysCreateCmd)
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysRevokeCmd)
+	keysListCmd.Flags().BoolVar(&keysListAll, "all", false, "include revoked keys")
+}
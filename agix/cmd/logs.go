@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/agent-platform/agix/internal/audit"
 	"github.com/agent-platform/agix/internal/store"
 	"github.com/agent-platform/agix/internal/ui"
 	"github.com/olekukonko/tablewriter"
@@ -12,9 +13,10 @@ import (
 )
 
 var (
-	logsLimit int
-	logsAgent string
-	logsTail  bool
+	logsLimit   int
+	logsAgent   string
+	logsTail    bool
+	logsRequest string
 )
 
 var logsCmd = &cobra.Command{
@@ -26,19 +28,23 @@ Examples:
   agix logs                  # Last 20 requests
   agix logs -n 50            # Last 50 requests
   agix logs --agent mybot    # Filter by agent
-  agix logs --tail           # Watch in real-time`,
+  agix logs --tail           # Watch in real-time
+  agix logs --request abc123 # Everything recorded for one X-Request-ID`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, _, err := loadConfig()
 		if err != nil {
 			return err
 		}
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
 		defer st.Close()
 
+		if logsRequest != "" {
+			return showRequest(st, logsRequest)
+		}
 		if logsTail {
 			return tailLogs(st)
 		}
@@ -52,6 +58,68 @@ func init() {
 	logsCmd.Flags().IntVarP(&logsLimit, "limit", "n", 20, "number of records to show")
 	logsCmd.Flags().StringVarP(&logsAgent, "agent", "a", "", "filter by agent name")
 	logsCmd.Flags().BoolVarP(&logsTail, "tail", "t", false, "watch for new requests in real-time")
+	logsCmd.Flags().StringVar(&logsRequest, "request", "", "show the requests, trace and audit events for one request ID")
+}
+
+// showRequest prints everything recorded under one X-Request-ID: the
+// requests rows (more than one after failover or a tool loop), the trace
+// and the audit events.
+func showRequest(st *store.Store, requestID string) error {
+	records, err := st.QueryRequestsByRequestID(requestID)
+	if err != nil {
+		return fmt.Errorf("query requests: %w", err)
+	}
+	tr, err := st.QueryTraceByRequestID(requestID)
+	if err != nil {
+		return fmt.Errorf("query trace: %w", err)
+	}
+	events, err := audit.New(st.ContentDB(), false, st.ContentDialect()).QueryByRequestID(requestID)
+	if err != nil {
+		return fmt.Errorf("query audit events: %w", err)
+	}
+
+	if len(records) == 0 && tr == nil && len(events) == 0 {
+		fmt.Println(ui.Dimf("Nothing recorded for request %s.", requestID))
+		return nil
+	}
+
+	fmt.Println(ui.Boldf("Request %s", requestID))
+	fmt.Println()
+
+	for _, r := range records {
+		line := fmt.Sprintf("  %s  %s  %s  %s in / %s out  %s  %dms  %s",
+			ui.Dimf("%s", r.Timestamp.Format("01-02 15:04:05")),
+			ui.Cyanf("%s", r.AgentName),
+			r.Model,
+			formatTokens(r.InputTokens),
+			formatTokens(r.OutputTokens),
+			ui.CostColor(r.CostUSD),
+			r.DurationMS,
+			ui.StatusColor(r.StatusCode))
+		if r.TTFTMS > 0 {
+			line += ui.Dimf("  (ttft %dms, %.1f tok/s)", r.TTFTMS, r.TokensPerSec)
+		}
+		if r.FailoverFrom != "" {
+			line += ui.Dimf("  (failover from %s)", r.FailoverFrom)
+		}
+		fmt.Println(line)
+	}
+
+	if tr != nil {
+		fmt.Printf("\n  Trace: %s  %s\n", tr.TraceID, ui.Dimf("(agix trace %s)", tr.TraceID))
+	}
+
+	if len(events) > 0 {
+		fmt.Println()
+		fmt.Println(ui.Boldf("  Audit events"))
+		for _, e := range events {
+			fmt.Printf("  %s  %-18s %s\n",
+				ui.Dimf("%s", e.Timestamp.Format("01-02 15:04:05")),
+				e.EventType,
+				ui.Dimf("%s", truncate(string(e.Details), 80)))
+		}
+	}
+	return nil
 }
 
 func showLogs(st *store.Store) error {
@@ -69,7 +137,7 @@ func showLogs(st *store.Store) error {
 	fmt.Println()
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Time", "Agent", "Model", "Input", "Output", "Cost", "Latency", "Status"})
+	table.SetHeader([]string{"Time", "Agent", "Model", "Input", "Output", "Cost", "Latency", "TTFT", "Status"})
 	table.SetBorder(false)
 	table.SetColumnAlignment([]int{
 		tablewriter.ALIGN_LEFT,
@@ -79,10 +147,15 @@ func showLogs(st *store.Store) error {
 		tablewriter.ALIGN_RIGHT,
 		tablewriter.ALIGN_RIGHT,
 		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
 		tablewriter.ALIGN_CENTER,
 	})
 
 	for _, r := range records {
+		ttft := ui.Dimf("-")
+		if r.TTFTMS > 0 {
+			ttft = fmt.Sprintf("%dms", r.TTFTMS)
+		}
 		table.Append([]string{
 			ui.Dimf("%s", r.Timestamp.Format("01-02 15:04:05")),
 			ui.Cyanf("%s", truncate(r.AgentName, 15)),
@@ -91,6 +164,7 @@ func showLogs(st *store.Store) error {
 			formatTokens(r.OutputTokens),
 			ui.CostColor(r.CostUSD),
 			fmt.Sprintf("%dms", r.DurationMS),
+			ttft,
 			ui.StatusColor(r.StatusCode),
 		})
 	}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/agent-platform/agix/internal/alert"
+	"github.com/agent-platform/agix/internal/config"
+)
+
+// newMailer converts the alerts.smtp section into an alert.Mailer (nil when
+// no host is configured).
+func newMailer(sc config.SMTPConfig) *alert.Mailer {
+	return alert.NewMailer(alert.SMTPConfig{
+		Host:     sc.Host,
+		Port:     sc.Port,
+		Username: sc.Username,
+		Password: sc.Password,
+		From:     sc.From,
+	})
+}
+
+// sendEmail delivers a rendered message to the email recipients of the named
+// alert destination.
+func sendEmail(cfg *config.Config, destination, subject, body string) error {
+	d, ok := cfg.Alerts.Destinations[destination]
+	if !ok {
+		return fmt.Errorf("unknown alert destination %q", destination)
+	}
+	if len(d.Email) == 0 {
+		return fmt.Errorf("alert destination %q has no email recipients", destination)
+	}
+	mailer := newMailer(cfg.Alerts.SMTP)
+	if mailer == nil {
+		return fmt.Errorf("alerts.smtp.host is not configured")
+	}
+	return mailer.Send(d.Email, subject, body)
+}
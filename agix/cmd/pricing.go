@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/ui"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var pricingCmd = &cobra.Command{
+	Use:   "pricing",
+	Short: "Inspect price history and recompute recorded costs",
+	Long: `agix records every list price a model has had, with the date it took
+effect. Recorded costs can then be recomputed against the price that applied
+when each request was made instead of today's.`,
+}
+
+var pricingHistoryCmd = &cobra.Command{
+	Use:   "history [model]",
+	Short: "Show recorded price versions",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, history, err := openPriceHistory()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		model := ""
+		if len(args) > 0 {
+			model = args[0]
+		}
+		versions := history.List(model)
+		if len(versions) == 0 {
+			fmt.Println(ui.Dimf("No price history recorded."))
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Model", "Effective From", "Input / 1M", "Output / 1M"})
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		for _, v := range versions {
+			from := "-"
+			if !v.EffectiveFrom.IsZero() {
+				from = v.EffectiveFrom.Local().Format("2006-01-02 15:04:05")
+			}
+			table.Append([]string{v.Model, from, fmt.Sprintf("$%.2f", v.InputPer1M), fmt.Sprintf("$%.2f", v.OutputPer1M)})
+		}
+		table.Render()
+		return nil
+	},
+}
+
+var (
+	pricingSetInput     float64
+	pricingSetOutput    float64
+	pricingSetEffective string
+)
+
+var pricingSetCmd = &cobra.Command{
+	Use:   "set <model>",
+	Short: "Record a price version for a model",
+	Long: `Record the list price of a model from a given date, e.g. a price change
+that happened before agix started tracking history, or a negotiated rate.`,
+	Example: `  agix pricing set gpt-4o --input 5 --output 15 --effective 2024-05-13`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if pricing.Lookup(args[0]) == nil {
+			return fmt.Errorf("unknown model %q", args[0])
+		}
+		effective, err := time.ParseInLocation("2006-01-02", pricingSetEffective, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --effective %q, want YYYY-MM-DD", pricingSetEffective)
+		}
+
+		st, history, err := openPriceHistory()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		v := pricing.Version{Model: args[0], InputPer1M: pricingSetInput, OutputPer1M: pricingSetOutput, EffectiveFrom: effective}
+		if err := history.Add(v); err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %s at $%.2f / $%.2f per 1M tokens from %s.\n",
+			args[0], pricingSetInput, pricingSetOutput, pricingSetEffective)
+		return nil
+	},
+}
+
+var (
+	pricingBackfillPeriod string
+	pricingBackfillDryRun bool
+)
+
+var pricingBackfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Recompute recorded costs with the price in effect at request time",
+	Long: `Recompute cost_usd of recorded requests using the list price that was in
+effect when each request was made, plus the configured discounts and the
+time-window rate that applied at that time.
+
+Requests for models without known pricing and requests billed by OpenRouter
+(which reports its own cost) are left unchanged.`,
+	Example: `  agix pricing backfill --dry-run
+  agix pricing backfill --period 30d`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if len(cfg.Pricing.Discounts) > 0 || len(cfg.Pricing.Windows) > 0 {
+			mods, err := initPricing(cfg.Pricing)
+			if err != nil {
+				return fmt.Errorf("initialize pricing: %w", err)
+			}
+			pricing.SetModifiers(mods)
+		}
+		initPricingAliases(cfg)
+
+		st, history, err := openPriceHistory()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		since, until := parsePeriod(pricingBackfillPeriod)
+		records, err := st.ExportCSV(since, until)
+		if err != nil {
+			return err
+		}
+
+		var updated int
+		var before, after float64
+		for _, r := range records {
+			if r.Provider == "openrouter" || history.LookupAt(r.Model, r.Timestamp) == nil {
+				continue
+			}
+			cost := history.CalculateCostAt(r.Model, r.InputTokens, r.OutputTokens, r.Timestamp) +
+				history.CacheCostAt(r.Model, r.CacheWriteTokens, r.CacheReadTokens, r.Timestamp)
+			if math.Abs(cost-r.CostUSD) < 1e-9 {
+				continue
+			}
+			updated++
+			before += r.CostUSD
+			after += cost
+			if pricingBackfillDryRun {
+				continue
+			}
+			if err := st.UpdateCost(r.ID, cost); err != nil {
+				return err
+			}
+		}
+
+		verb := "Updated"
+		if pricingBackfillDryRun {
+			verb = "Would update"
+		}
+		fmt.Printf("%s %d of %d request(s): %s → %s\n", verb, updated, len(records), ui.CostColor(before), ui.CostColor(after))
+		return nil
+	},
+}
+
+// openPriceHistory opens the store and its price history, recording a new
+// version for any model whose built-in price changed since the last run.
+func openPriceHistory() (*store.Store, *pricing.History, error) {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := openStore(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open database: %w", err)
+	}
+	history, err := pricing.NewHistory(st.DB(), st.Dialect())
+	if err == nil {
+		_, err = history.Snapshot(time.Now())
+	}
+	if err != nil {
+		st.Close()
+		return nil, nil, err
+	}
+	return st, history, nil
+}
+
+func init() {
+	rootCmd.AddCommand(pricingCmd)
+	pricingCmd.AddCommand(pricingHistoryCmd, pricingSetCmd, pricingBackfillCmd)
+
+	pricingSetCmd.Flags().Float64Var(&pricingSetInput, "input", 0, "USD per 1M input tokens")
+	pricingSetCmd.Flags().Float64Var(&pricingSetOutput, "output", 0, "USD per 1M output tokens")
+	pricingSetCmd.Flags().StringVar(&pricingSetEffective, "effective", "", "date the price took effect (YYYY-MM-DD)")
+	pricingSetCmd.MarkFlagRequired("effective")
+
+	pricingBackfillCmd.Flags().StringVarP(&pricingBackfillPeriod, "period", "P", "all", "time period: today, 7d, 30d, all")
+	pricingBackfillCmd.Flags().BoolVar(&pricingBackfillDryRun, "dry-run", false, "show what would change without writing")
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/confighistory"
+	"github.com/agent-platform/agix/internal/doctor"
+	"github.com/agent-platform/agix/internal/firewall"
+	"github.com/agent-platform/agix/internal/keypool"
+	"github.com/agent-platform/agix/internal/promptinject"
+	"github.com/agent-platform/agix/internal/proxy"
+	"github.com/agent-platform/agix/internal/ratelimit"
+	"github.com/agent-platform/agix/internal/router"
+	"github.com/agent-platform/agix/internal/secrets"
+)
+
+// configPollInterval is how often the running gateway checks the config
+// file for changes.
+const configPollInterval = 2 * time.Second
+
+// sharedCounters is where rate limiters count when redis.url is set; start
+// sets it before the first buildHot.
+var sharedCounters ratelimit.Shared
+
+// buildHot builds the components the gateway can swap without a restart.
+// prev is what is running now; its rate limiter is updated in place so
+// agents keep their request history across a reload.
+func buildHot(cfg *config.Config, prev proxy.Hot) (proxy.Hot, error) {
+	hot := proxy.Hot{Budgets: cfg.Budgets, Keys: proxy.ProviderKeys(cfg)}
+
+	if cfg.Firewall.Enabled {
+		var rules []firewall.RuleConfig
+		for _, r := range cfg.Firewall.Rules {
+			rules = append(rules, firewall.RuleConfig{
+				Name:     r.Name,
+				Category: r.Category,
+				Pattern:  r.Pattern,
+				Action:   firewall.Action(r.Action),
+			})
+		}
+		fw, err := firewall.New(firewall.Config{
+			Enabled: true,
+			Rules:   rules,
+		})
+		if err != nil {
+			return proxy.Hot{}, fmt.Errorf("initialize firewall: %w", err)
+		}
+		hot.Firewall = fw
+	}
+
+	if cfg.Routing.Enabled {
+		tiers := make(map[string]router.TierConfig, len(cfg.Routing.Tiers))
+		for name, t := range cfg.Routing.Tiers {
+			tiers[name] = router.TierConfig{
+				MaxMessageTokens: t.MaxMessageTokens,
+				MaxMessages:      t.MaxMessages,
+				KeywordsAbsent:   t.KeywordsAbsent,
+			}
+		}
+		hot.Router = router.New(router.Config{
+			Enabled:       true,
+			Tiers:         tiers,
+			ModelMap:      cfg.Routing.ModelMap,
+			ExcludeAgents: cfg.Routing.ExcludeAgents,
+		})
+	}
+
+	if len(cfg.KeyPools) > 0 {
+		hot.KeyPools = make(map[string]*keypool.Pool, len(cfg.KeyPools))
+		for provider, kp := range cfg.KeyPools {
+			pool, err := keypool.New(provider, kp)
+			if err != nil {
+				return proxy.Hot{}, fmt.Errorf("key_pools.%s: %w", provider, err)
+			}
+			pool.Inherit(prev.KeyPools[provider])
+			hot.KeyPools[provider] = pool
+		}
+	}
+
+	if cfg.PromptTemplates.Enabled {
+		hot.PromptInjector = promptinject.New(promptinject.Config{
+			Global:   cfg.PromptTemplates.Global,
+			Agents:   cfg.PromptTemplates.Agents,
+			Position: cfg.PromptTemplates.Position,
+		})
+	}
+
+	// Last, so a config that fails above leaves the running limits alone
+	if len(cfg.RateLimits) > 0 || cfg.GlobalRateLimit != (config.RateLimitConfig{}) {
+		toLimit := func(rl config.RateLimitConfig) ratelimit.Limit {
+			return ratelimit.Limit{
+				RequestsPerMinute: rl.RequestsPerMinute,
+				RequestsPerHour:   rl.RequestsPerHour,
+				TokensPerMinute:   rl.TokensPerMinute,
+				TokensPerDay:      rl.TokensPerDay,
+			}
+		}
+		limits := make(map[string]ratelimit.Limit, len(cfg.RateLimits)+1)
+		for agent, rl := range cfg.RateLimits {
+			if agent == ratelimit.All {
+				return proxy.Hot{}, fmt.Errorf("rate_limits: %q is not an agent; use global_rate_limit", agent)
+			}
+			limits[agent] = toLimit(rl)
+		}
+		if cfg.GlobalRateLimit != (config.RateLimitConfig{}) {
+			limits[ratelimit.All] = toLimit(cfg.GlobalRateLimit)
+		}
+		if prev.RateLimiter != nil {
+			prev.RateLimiter.SetLimits(limits)
+			hot.RateLimiter = prev.RateLimiter
+		} else {
+			hot.RateLimiter = ratelimit.New(limits)
+			if sharedCounters != nil {
+				hot.RateLimiter.SetShared(sharedCounters)
+			}
+		}
+	}
+	return hot, nil
+}
+
+// watchConfig reloads the hot parts of the config when the file or
+// config.d changes, or the process gets SIGHUP. A config that fails to
+// parse or lint is logged and ignored; the gateway keeps running on the
+// previous one.
+func watchConfig(p *proxy.Proxy, path string, history *confighistory.History) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	last := configSnapshot(path)
+	for {
+		select {
+		case <-hup:
+			log.Printf("INFO: SIGHUP, reloading %s", path)
+			last = configSnapshot(path)
+			reloadConfig(p, path, history)
+		case <-ticker.C:
+			snap := configSnapshot(path)
+			if snap == nil || bytes.Equal(snap, last) {
+				continue
+			}
+			last = snap
+			reloadConfig(p, path, history)
+		}
+	}
+}
+
+// configSnapshot returns the content of the config file and its config.d
+// files, or nil if the config file cannot be read.
+func configSnapshot(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	includes, _ := config.IncludeFiles(path)
+	for _, f := range includes {
+		inc, _ := os.ReadFile(f)
+		data = append(data, 0)
+		data = append(data, f...)
+		data = append(data, 0)
+		data = append(data, inc...)
+	}
+	return data
+}
+
+// reloadConfig applies the config at path.
+func reloadConfig(p *proxy.Proxy, path string, history *confighistory.History) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		log.Printf("ERROR: config reload: %v (keeping previous config)", err)
+		return
+	}
+	var lint bytes.Buffer
+	if fails := doctor.WriteLintSummary(&lint, doctor.Lint(cfg, path)); fails > 0 {
+		log.Printf("ERROR: config reload: %d fatal error(s), keeping previous config\n%s", fails, lint.String())
+		return
+	}
+	if err := secrets.NewResolver().ResolveKeys(context.Background(), cfg); err != nil {
+		log.Printf("ERROR: config reload: %v (keeping previous config)", err)
+		return
+	}
+	hot, err := buildHot(cfg, p.Hot())
+	if err != nil {
+		log.Printf("ERROR: config reload: %v (keeping previous config)", err)
+		return
+	}
+	p.Reload(hot)
+	recordConfigVersion(history, path, sourceManualEdit)
+	log.Printf("INFO: config reloaded: API keys, rate limits, budgets, firewall, routing and prompt templates updated; other settings apply on restart")
+}
+
+// budgetSaver saves budgets changed through the admin API to the config
+// file at path, recording each change in history. The config watcher then
+// reloads a file that already matches what the gateway runs.
+func budgetSaver(path string, history *confighistory.History) proxy.BudgetSaver {
+	return func(key string, budget *config.Budget) error {
+		merged, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		cfg, err := config.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		// config.d overrides the main file: a budget set there would come
+		// back on the next reload.
+		mb, inMerged := merged.Budgets[key]
+		fb, inFile := cfg.Budgets[key]
+		if inMerged != inFile || !reflect.DeepEqual(mb, fb) {
+			return proxy.ErrBudgetInInclude
+		}
+		if cfg.Budgets == nil {
+			cfg.Budgets = config.Budgets{}
+		}
+		if budget == nil {
+			delete(cfg.Budgets, key)
+		} else {
+			cfg.Budgets[key] = *budget
+		}
+		recordConfigVersion(history, path, sourceManualEdit)
+		if err := config.Save(path, cfg); err != nil {
+			return err
+		}
+		recordConfigVersion(history, path, "admin budgets "+key)
+		return nil
+	}
+}
@@ -31,6 +31,12 @@ Commands:
   agix trace list        List recent request traces
   agix trace <id>        Show detailed trace timeline
   agix audit list        List recent audit events
+  agix inspect <id>      Show how the gateway modified a request
+  agix config history    List recorded config changes
+  agix agent release <n> Release an agent from the penalty box
+  agix archive show <id> Show archived request/response bodies
+  agix backup <file>     Back up the database and config
+  agix restore <file>    Restore from a backup
 
 Features (configured in ~/.agix/config.yaml):
   rate_limits:    Per-agent request throttling (RPM/RPH)
@@ -44,7 +50,9 @@ Features (configured in ~/.agix/config.yaml):
   compression:    Auto-summarize long conversations
   experiments:    A/B test model variants with consistent hashing
   tracing:        Per-request pipeline tracing with timing
-  audit:          Append-only security event log (firewall, tools)`,
+  audit:          Append-only security event log (firewall, tools)
+  penalty_box:    Throttle agents with sustained errors or abuse
+  archive:        Gzipped request/response bodies in a directory or S3`,
 }
 
 // Execute runs the root command.
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/agent-platform/agix/internal/session"
-	"github.com/agent-platform/agix/internal/store"
 	"github.com/agent-platform/agix/internal/ui"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -15,7 +14,7 @@ import (
 var sessionCmd = &cobra.Command{
 	Use:   "session",
 	Short: "Manage session overrides",
-	Long:  "View and manage session-level config overrides (model, temperature, max_tokens).",
+	Long:  "View and manage session-level config overrides (model, temperature, max_tokens, spend cap).",
 }
 
 var sessionListCmd = &cobra.Command{
@@ -27,7 +26,7 @@ var sessionListCmd = &cobra.Command{
 			return err
 		}
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
@@ -57,7 +56,7 @@ var sessionListCmd = &cobra.Command{
 		}
 
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Session ID", "Agent", "Model", "Temp", "Max Tokens", "Expires"})
+		table.SetHeader([]string{"Session ID", "Agent", "Model", "Temp", "Max Tokens", "Spend", "Expires"})
 		table.SetBorder(false)
 		table.SetColumnSeparator(" ")
 		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
@@ -72,6 +71,10 @@ var sessionListCmd = &cobra.Command{
 			if o.MaxTokens != nil {
 				maxTok = fmt.Sprintf("%d", *o.MaxTokens)
 			}
+			spend := fmt.Sprintf("$%.4f", o.SpentUSD)
+			if o.MaxSessionCostUSD != nil {
+				spend = fmt.Sprintf("$%.4f / $%.2f", o.SpentUSD, *o.MaxSessionCostUSD)
+			}
 			model := o.Model
 			if model == "" {
 				model = "-"
@@ -79,7 +82,7 @@ var sessionListCmd = &cobra.Command{
 			remaining := time.Until(o.ExpiresAt).Truncate(time.Second)
 			expires := fmt.Sprintf("%s (%s)", o.ExpiresAt.Format("15:04:05"), remaining)
 
-			table.Append([]string{o.SessionID, o.AgentName, model, temp, maxTok, expires})
+			table.Append([]string{o.SessionID, o.AgentName, model, temp, maxTok, spend, expires})
 		}
 
 		table.Render()
@@ -96,7 +99,7 @@ var sessionCleanCmd = &cobra.Command{
 			return err
 		}
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
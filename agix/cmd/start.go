@@ -1,31 +1,57 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/agent-platform/agix/internal/audit"
+	"github.com/agent-platform/agix/internal/admission"
 	"github.com/agent-platform/agix/internal/alert"
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/archive"
+	"github.com/agent-platform/agix/internal/audit"
 	"github.com/agent-platform/agix/internal/webhook"
 	"github.com/agent-platform/agix/internal/cache"
+	"github.com/agent-platform/agix/internal/chaos"
+	"github.com/agent-platform/agix/internal/clickhouse"
 	"github.com/agent-platform/agix/internal/compressor"
 	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/credits"
+	"github.com/agent-platform/agix/internal/confighistory"
 	"github.com/agent-platform/agix/internal/experiment"
-	"github.com/agent-platform/agix/internal/promptinject"
 	"github.com/agent-platform/agix/internal/responsepolicy"
 	"github.com/agent-platform/agix/internal/dashboard"
+	"github.com/agent-platform/agix/internal/doctor"
 	"github.com/agent-platform/agix/internal/failover"
-	"github.com/agent-platform/agix/internal/firewall"
 	"github.com/agent-platform/agix/internal/qualitygate"
+	"github.com/agent-platform/agix/internal/ha"
+	"github.com/agent-platform/agix/internal/jwtauth"
+	"github.com/agent-platform/agix/internal/loadshed"
+	"github.com/agent-platform/agix/internal/modelcatalog"
+	"github.com/agent-platform/agix/internal/otlp"
+	"github.com/agent-platform/agix/internal/outagequeue"
+	"github.com/agent-platform/agix/internal/penalty"
+	"github.com/agent-platform/agix/internal/pricing"
 	"github.com/agent-platform/agix/internal/proxy"
 	"github.com/agent-platform/agix/internal/ratelimit"
-	"github.com/agent-platform/agix/internal/router"
+	"github.com/agent-platform/agix/internal/redis"
+	"github.com/agent-platform/agix/internal/secrets"
 	"github.com/agent-platform/agix/internal/session"
 	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/tlsserver"
+	"github.com/agent-platform/agix/internal/transform"
 	"github.com/agent-platform/agix/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -55,7 +81,7 @@ The gateway activates features based on your config (~/.agix/config.yaml):
   tracing         Per-request pipeline tracing with span timing
   audit           Append-only security event log (firewall, tools, content)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, _, err := loadConfig()
+		cfg, cfgPath, err := loadConfig()
 		if err != nil {
 			return err
 		}
@@ -64,13 +90,39 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 			cfg.Port = startPort
 		}
 
+		// Fetch vault: and keychain: API keys; the config is never saved
+		// from here, so the secrets stay off disk
+		if err := secrets.NewResolver().ResolveKeys(context.Background(), cfg); err != nil {
+			return fmt.Errorf("resolve secrets: %w", err)
+		}
+
+		// Fail fast on config errors instead of at the first matching request
+		if fails := doctor.WriteLintSummary(os.Stderr, doctor.Lint(cfg, cfgPath)); fails > 0 {
+			return fmt.Errorf("config has %d fatal error(s)", fails)
+		}
+
 		// Open store
-		st, err := store.New(cfg.Database)
+		spillFile := cfg.SpillFile
+		if spillFile == "" {
+			dir, err := config.DefaultConfigDir()
+			if err != nil {
+				return err
+			}
+			spillFile = filepath.Join(dir, "spill.jsonl")
+		}
+		st, err := openStore(cfg, store.WithSpillFile(spillFile))
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
 		defer st.Close()
 
+		// Record hand edits made to the config file since the last version
+		configHistory, err := confighistory.New(st.DB(), st.Dialect())
+		if err != nil {
+			return fmt.Errorf("initialize config history: %w", err)
+		}
+		recordConfigVersion(configHistory, cfgPath, sourceManualEdit)
+
 		// Initialize tool manager (if MCP servers are configured)
 		toolMgr, err := initToolManager(cfg)
 		if err != nil {
@@ -81,35 +133,114 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 		}
 
 		// Initialize audit logger
-		auditLogger := audit.New(st.DB(), cfg.Audit.Enabled, st.Dialect())
+		auditLogger := audit.New(st.ContentDB(), cfg.Audit.Enabled, st.ContentDialect())
 		defer auditLogger.Close()
+		if cfg.Audit.Enabled && cfg.Audit.RetentionDays > 0 {
+			auditLogger.StartRetention(time.Duration(cfg.Audit.RetentionDays) * 24 * time.Hour)
+		}
 
 		// Build proxy options
 		var proxyOpts []proxy.Option
+
+		// Prepaid credit ledger, used by budgets with credits: true
+		ledger, err := credits.New(st)
+		if err != nil {
+			return fmt.Errorf("initialize credits: %w", err)
+		}
+		proxyOpts = append(proxyOpts, proxy.WithCredits(ledger))
+
+		// Agent API keys issued with `agix keys create`
+		keys, err := apikeys.New(st.DB(), st.Dialect())
+		if err != nil {
+			return fmt.Errorf("initialize API keys: %w", err)
+		}
+		proxyOpts = append(proxyOpts, proxy.WithAPIKeys(keys, cfg.Auth.RequireKeys))
+		if cfg.Auth.JWT.Enabled() {
+			verifier, err := jwtauth.New(cfg.Auth.JWT, filepath.Dir(cfgPath))
+			if err != nil {
+				return err
+			}
+			proxyOpts = append(proxyOpts, proxy.WithJWT(verifier, cfg.Auth.JWT))
+		}
 		if cfg.Audit.Enabled {
 			proxyOpts = append(proxyOpts, proxy.WithAuditLogger(auditLogger, cfg.Audit))
 		}
+		if cfg.Admin.Token != "" {
+			proxyOpts = append(proxyOpts, proxy.WithAdminToken(cfg.Admin.Token))
+		}
+		proxyOpts = append(proxyOpts, proxy.WithBudgetSaver(budgetSaver(cfgPath, configHistory)))
 		if toolMgr != nil {
 			proxyOpts = append(proxyOpts, proxy.WithToolManager(toolMgr))
 		}
 
-		// Initialize rate limiter
-		if len(cfg.RateLimits) > 0 {
-			limits := make(map[string]ratelimit.Limit, len(cfg.RateLimits))
-			for agent, rl := range cfg.RateLimits {
-				limits[agent] = ratelimit.Limit{
-					RequestsPerMinute: rl.RequestsPerMinute,
-					RequestsPerHour:   rl.RequestsPerHour,
+		// Count rate limits and spend in Redis, shared with the other
+		// instances behind the same load balancer
+		if cfg.Redis.URL != "" {
+			prefix := cfg.Redis.Prefix
+			if prefix == "" {
+				prefix = "agix:"
+			}
+			rc, err := redis.New(cfg.Redis.URL, prefix)
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			if err := rc.Ping(); err != nil {
+				log.Printf("WARN: %v; limits and budgets are enforced per instance until Redis answers", err)
+			}
+			sharedCounters = rc
+			proxyOpts = append(proxyOpts, proxy.WithSharedSpend(redis.NewSpend(rc)))
+		}
+
+		// Rate limits, firewall, routing, prompt templates and key pools
+		// are rebuilt on reload
+		hot, err := buildHot(cfg, proxy.Hot{})
+		if err != nil {
+			return err
+		}
+		proxyOpts = append(proxyOpts,
+			proxy.WithRateLimiter(hot.RateLimiter),
+			proxy.WithFirewall(hot.Firewall),
+			proxy.WithRouter(hot.Router),
+			proxy.WithPromptInjector(hot.PromptInjector),
+			proxy.WithKeyPools(hot.KeyPools))
+
+		// Initialize provider-side pacing
+		if len(cfg.ProviderRateLimits) > 0 {
+			pacer, err := initPacer(cfg.ProviderRateLimits)
+			if err != nil {
+				return fmt.Errorf("initialize provider rate limits: %w", err)
+			}
+			proxyOpts = append(proxyOpts, proxy.WithPacer(pacer))
+		}
+
+		// Act on the quota providers report per model
+		if cfg.UpstreamQuota.Enabled {
+			minPercent := cfg.UpstreamQuota.MinRemainingPercent
+			if minPercent <= 0 {
+				minPercent = 5
+			}
+			maxWait := 5 * time.Second
+			if cfg.UpstreamQuota.MaxWait != "" {
+				d, err := time.ParseDuration(cfg.UpstreamQuota.MaxWait)
+				if err != nil || d < 0 {
+					return fmt.Errorf("upstream_quota.max_wait: invalid duration %q", cfg.UpstreamQuota.MaxWait)
 				}
+				maxWait = d
 			}
-			proxyOpts = append(proxyOpts, proxy.WithRateLimiter(ratelimit.New(limits)))
+			proxyOpts = append(proxyOpts, proxy.WithUpstreamQuota(minPercent, maxWait))
 		}
 
 		// Initialize failover
 		if len(cfg.Failover.Chains) > 0 {
+			agents := make(map[string]failover.AgentPolicy, len(cfg.Failover.Agents))
+			for name, a := range cfg.Failover.Agents {
+				agents[name] = failover.AgentPolicy{Chains: a.Chains, MaxRetries: a.MaxRetries}
+			}
 			f := failover.New(failover.Config{
 				MaxRetries: cfg.Failover.MaxRetries,
 				Chains:     cfg.Failover.Chains,
+				Agents:     agents,
 			})
 			if f != nil {
 				proxyOpts = append(proxyOpts, proxy.WithFailover(f))
@@ -117,29 +248,49 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 		}
 
 		// Initialize alerter for budget webhooks
-		proxyOpts = append(proxyOpts, proxy.WithAlerter(alert.NewAlerter(5*time.Minute)))
-
-		// Initialize firewall
-		if cfg.Firewall.Enabled {
-			var rules []firewall.RuleConfig
-			for _, r := range cfg.Firewall.Rules {
-				rules = append(rules, firewall.RuleConfig{
-					Name:     r.Name,
-					Category: r.Category,
-					Pattern:  r.Pattern,
-					Action:   firewall.Action(r.Action),
-				})
+		alerter, err := initAlerter(cfg.Alerts)
+		if err != nil {
+			return fmt.Errorf("initialize alerter: %w", err)
+		}
+		proxyOpts = append(proxyOpts, proxy.WithAlerter(alerter))
+
+		// Initialize load shedding
+		if cfg.LoadShedding.Enabled {
+			shedder, err := initShedder(cfg.LoadShedding, st)
+			if err != nil {
+				return fmt.Errorf("initialize load shedding: %w", err)
 			}
-			fw, err := firewall.New(firewall.Config{
-				Enabled: true,
-				Rules:   rules,
-			})
+			if shedder != nil {
+				proxyOpts = append(proxyOpts, proxy.WithShedder(shedder))
+			}
+		}
+
+		// Queue requests instead of rejecting them when limits are hit
+		if cfg.AdmissionQueue.Enabled {
+			queue, err := initAdmissionQueue(cfg.AdmissionQueue)
 			if err != nil {
-				return fmt.Errorf("initialize firewall: %w", err)
+				return fmt.Errorf("initialize admission queue: %w", err)
 			}
-			if fw != nil {
-				proxyOpts = append(proxyOpts, proxy.WithFirewall(fw))
+			proxyOpts = append(proxyOpts, proxy.WithAdmissionQueue(queue))
+		}
+
+		// Initialize fault injection (only hits requests with X-Chaos)
+		if cfg.Chaos.Enabled {
+			faults := make(map[string]chaos.Fault, len(cfg.Chaos.Providers))
+			for provider, f := range cfg.Chaos.Providers {
+				fault := chaos.Fault{
+					RateLimit:         f.RateLimit,
+					ServerError:       f.ServerError,
+					ServerErrorStatus: f.ServerErrorStatus,
+					Latency:           f.Latency,
+					Delay:             time.Duration(f.LatencyMS) * time.Millisecond,
+				}
+				if err := fault.Validate(); err != nil {
+					return fmt.Errorf("chaos.providers.%s: %w", provider, err)
+				}
+				faults[provider] = fault
 			}
+			proxyOpts = append(proxyOpts, proxy.WithChaos(chaos.New(faults)))
 		}
 
 		// Initialize semantic cache
@@ -147,12 +298,14 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 			var embedder *cache.EmbeddingClient
 			if apiKey, ok := cfg.Keys["openai"]; ok && apiKey != "" {
 				embedder = cache.NewEmbeddingClient(apiKey, "")
+				embedder.OnUsage = st.InsertAsync
 			}
 			sc, err := cache.New(cache.Config{
 				Enabled:             true,
 				SimilarityThreshold: cfg.Cache.SimilarityThreshold,
 				TTLMinutes:          cfg.Cache.TTLMinutes,
-			}, st.DB(), embedder, st.Dialect())
+				EquivalentModels:    cfg.Cache.EquivalentModels,
+			}, st.ContentDB(), embedder, st.ContentDialect())
 			if err != nil {
 				return fmt.Errorf("initialize cache: %w", err)
 			}
@@ -169,43 +322,67 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 				OnEmpty:     qualitygate.ActionType(cfg.QualityGate.OnEmpty),
 				OnTruncated: qualitygate.ActionType(cfg.QualityGate.OnTruncated),
 				OnRefusal:   qualitygate.ActionType(cfg.QualityGate.OnRefusal),
+				OnSchemaMismatch: qualitygate.ActionType(cfg.QualityGate.OnSchemaMismatch),
 			})
 			if qg != nil {
 				proxyOpts = append(proxyOpts, proxy.WithQualityGate(qg))
 			}
 		}
 
+		// In-process callers (summaries, webhooks, the outage queue) send
+		// requests through the gateway, marked with a token only this
+		// process knows. With TLS on they use a plain loopback listener
+		// instead, which only serves those requests.
+		internalToken, err := apikeys.NewInternalToken()
+		if err != nil {
+			return err
+		}
+		proxyOpts = append(proxyOpts, proxy.WithInternalToken(internalToken))
+		gateway := fmt.Sprintf("http://localhost:%d", cfg.Port)
+		var tlsConfig *tls.Config
+		var internalLn net.Listener
+		if cfg.TLS.Enabled() {
+			tlsConfig, err = tlsserver.Config(cfg.TLS, filepath.Dir(cfgPath))
+			if err != nil {
+				return err
+			}
+			internalLn, err = net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return fmt.Errorf("internal listener: %w", err)
+			}
+			defer internalLn.Close()
+			gateway = "http://" + internalLn.Addr().String()
+		}
+
 		// Initialize context compressor
 		if cfg.Compression.Enabled {
+			// Without a summary model, fall back to an extractive summary
+			var summarize compressor.SummarizeFunc
+			if cfg.Compression.SummaryModel != "" {
+				summarize = compressor.GatewaySummarizer(gateway, internalToken)
+			}
 			comp := compressor.New(compressor.Config{
 				Enabled:         true,
 				ThresholdTokens: cfg.Compression.ThresholdTokens,
 				KeepRecent:      cfg.Compression.KeepRecent,
 				SummaryModel:    cfg.Compression.SummaryModel,
-			}, nil) // nil summarize func = fallback to extractive summary
+			}, summarize)
 			if comp != nil {
 				proxyOpts = append(proxyOpts, proxy.WithCompressor(comp))
 			}
 		}
 
-		// Initialize smart router
-		if cfg.Routing.Enabled {
-			tiers := make(map[string]router.TierConfig, len(cfg.Routing.Tiers))
-			for name, t := range cfg.Routing.Tiers {
-				tiers[name] = router.TierConfig{
-					MaxMessageTokens: t.MaxMessageTokens,
-					MaxMessages:      t.MaxMessages,
-					KeywordsAbsent:   t.KeywordsAbsent,
-				}
+		// Summarization service: the compressor's summaries on demand
+		if cfg.Summarizer.Enabled {
+			model := cfg.Summarizer.Model
+			if model == "" {
+				model = cfg.Compression.SummaryModel
 			}
-			rt := router.New(router.Config{
-				Enabled:  true,
-				Tiers:    tiers,
-				ModelMap: cfg.Routing.ModelMap,
-			})
-			if rt != nil {
-				proxyOpts = append(proxyOpts, proxy.WithRouter(rt))
+			var summarize compressor.SummarizeFunc
+			if model != "" {
+				summarize = compressor.GatewaySummarizerAs(gateway, store.AgentSummarizer, internalToken)
 			}
+			proxyOpts = append(proxyOpts, proxy.WithSummarizer(compressor.NewSummarizer(model, summarize)))
 		}
 
 		// Initialize experiments
@@ -218,32 +395,29 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 					ControlModel: e.ControlModel,
 					VariantModel: e.VariantModel,
 					TrafficPct:   e.TrafficPct,
+					ExcludeAgents: e.ExcludeAgents,
 				})
 			}
-			em := experiment.New(exps)
+			as, err := experiment.NewAssignmentStore(st.DB(), st.Dialect())
+			if err != nil {
+				return fmt.Errorf("initialize experiment assignments: %w", err)
+			}
+			em := experiment.New(exps, experiment.WithAssignmentStore(as))
 			if em != nil {
 				proxyOpts = append(proxyOpts, proxy.WithExperiments(em))
 			}
 		}
 
-		// Initialize prompt template injector
-		if cfg.PromptTemplates.Enabled {
-			inj := promptinject.New(promptinject.Config{
-				Global:   cfg.PromptTemplates.Global,
-				Agents:   cfg.PromptTemplates.Agents,
-				Position: cfg.PromptTemplates.Position,
-			})
-			if inj != nil {
-				proxyOpts = append(proxyOpts, proxy.WithPromptInjector(inj))
-			}
-		}
-
 		// Initialize response policy
 		if cfg.ResponsePolicy.Enabled {
 			rpCfg := responsepolicy.Config{
 				Enabled:        true,
 				MaxOutputChars: cfg.ResponsePolicy.MaxOutputChars,
 				ForceFormat:    cfg.ResponsePolicy.ForceFormat,
+				Language:       cfg.ResponsePolicy.Language,
+				BannedPhrases:  cfg.ResponsePolicy.BannedPhrases,
+				OnViolation:    cfg.ResponsePolicy.OnViolation,
+				MaxRetries:     cfg.ResponsePolicy.MaxRetries,
 			}
 			for _, rp := range cfg.ResponsePolicy.RedactPatterns {
 				rpCfg.RedactPatterns = append(rpCfg.RedactPatterns, responsepolicy.RedactRuleConfig{
@@ -258,6 +432,9 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 					agentPol := responsepolicy.AgentPolicy{
 						MaxOutputChars: ap.MaxOutputChars,
 						ForceFormat:    ap.ForceFormat,
+						Language:       ap.Language,
+						BannedPhrases:  ap.BannedPhrases,
+						OnViolation:    ap.OnViolation,
 					}
 					for _, rp := range ap.RedactPatterns {
 						agentPol.RedactPatterns = append(agentPol.RedactPatterns, responsepolicy.RedactRuleConfig{
@@ -285,11 +462,27 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 				sampleRate = 1.0
 			}
 			proxyOpts = append(proxyOpts, proxy.WithTracing(true, sampleRate))
+
+			if cfg.Tracing.OTLP.Endpoint != "" {
+				exp, err := otlp.New(otlp.Config{
+					Endpoint:    cfg.Tracing.OTLP.Endpoint,
+					Headers:     cfg.Tracing.OTLP.Headers,
+					ServiceName: cfg.Tracing.OTLP.ServiceName,
+				})
+				if err != nil {
+					return fmt.Errorf("initialize otlp exporter: %w", err)
+				}
+				exp.Start()
+				defer exp.Close()
+				proxyOpts = append(proxyOpts, proxy.WithTraceExporter(exp))
+			}
 		}
 
 		// Initialize webhooks
 		if cfg.Webhooks.Enabled && len(cfg.Webhooks.Definitions) > 0 {
 			wh := webhook.New(cfg.Webhooks, cfg, st)
+			wh.SetGateway(gateway)
+			wh.SetInternalToken(internalToken)
 			proxyOpts = append(proxyOpts, proxy.WithWebhookHandler(wh))
 		}
 
@@ -309,14 +502,124 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 			proxyOpts = append(proxyOpts, proxy.WithSessionManager(sm))
 		}
 
+		// Initialize active-standby leader election
+		var elector *ha.Elector
+		if cfg.HA.Enabled {
+			elector, err = initElector(cfg.HA, st)
+			if err != nil {
+				return fmt.Errorf("initialize HA: %w", err)
+			}
+			defer elector.Close()
+			proxyOpts = append(proxyOpts, proxy.WithElector(elector))
+		}
+
+		// Initialize outage queue (drained by the HA leader only)
+		if cfg.OutageQueue.Enabled {
+			var active func() bool
+			if elector != nil {
+				active = elector.IsLeader
+			}
+			oq, err := initOutageQueue(cfg.OutageQueue, gateway, internalToken, st, active)
+			if err != nil {
+				return fmt.Errorf("initialize outage queue: %w", err)
+			}
+			oq.Start()
+			defer oq.Close()
+			proxyOpts = append(proxyOpts, proxy.WithOutageQueue(oq))
+		}
+
+		// Stream records to ClickHouse for long-term analytics
+		if cfg.ClickHouse.Enabled {
+			sink, err := initClickHouse(cfg.ClickHouse)
+			if err != nil {
+				return fmt.Errorf("initialize clickhouse: %w", err)
+			}
+			sink.Start()
+			defer sink.Close()
+			proxyOpts = append(proxyOpts, proxy.WithAnalyticsSink(sink))
+		}
+
+		// Archive full request/response bodies outside the database
+		if cfg.Archive.Enabled {
+			backend, err := openArchive(cfg.Archive)
+			if err != nil {
+				return fmt.Errorf("initialize archive: %w", err)
+			}
+			arc := archive.New(backend)
+			arc.Start()
+			defer arc.Close()
+			proxyOpts = append(proxyOpts, proxy.WithArchiver(arc))
+		}
+
+		// Serve live model lists and metadata from GET /v1/models
+		if cfg.ModelCatalog.Refresh || len(cfg.ModelCatalog.Metadata) > 0 {
+			cat, err := initModelCatalog(cfg)
+			if err != nil {
+				return fmt.Errorf("initialize model catalog: %w", err)
+			}
+			if cfg.ModelCatalog.Refresh {
+				cat.Start()
+				defer cat.Close()
+			}
+			proxyOpts = append(proxyOpts, proxy.WithModelCatalog(cat))
+		}
+
+		// Throttle agents with sustained error or abuse patterns
+		if cfg.PenaltyBox.Enabled {
+			box, err := initPenaltyBox(cfg.PenaltyBox, st, auditLogger)
+			if err != nil {
+				return fmt.Errorf("initialize penalty box: %w", err)
+			}
+			proxyOpts = append(proxyOpts, proxy.WithPenaltyBox(box))
+		}
+
+		// Initialize per-provider transforms
+		if len(cfg.Transforms) > 0 {
+			tf, err := initTransformer(cfg.Transforms)
+			if err != nil {
+				return fmt.Errorf("initialize transforms: %w", err)
+			}
+			if tf != nil {
+				proxyOpts = append(proxyOpts, proxy.WithTransformer(tf))
+			}
+		}
+
+		// Apply negotiated discounts and time-window pricing to cost calculation
+		if len(cfg.Pricing.Discounts) > 0 || len(cfg.Pricing.Windows) > 0 {
+			mods, err := initPricing(cfg.Pricing)
+			if err != nil {
+				return fmt.Errorf("initialize pricing: %w", err)
+			}
+			pricing.SetModifiers(mods)
+		}
+
+		// Record a new price version for every model whose list price changed
+		history, err := pricing.NewHistory(st.DB(), st.Dialect())
+		if err != nil {
+			return fmt.Errorf("initialize price history: %w", err)
+		}
+		if _, err := history.Snapshot(time.Now()); err != nil {
+			return fmt.Errorf("record price history: %w", err)
+		}
+
+		// Price Azure deployments and Bedrock models as their base models
+		initPricingAliases(cfg)
+
+		// Route model prefixes to custom OpenAI-compatible providers
+		if err := initProviders(cfg); err != nil {
+			return fmt.Errorf("initialize providers: %w", err)
+		}
+
 		// Create proxy
 		p := proxy.New(cfg, st, proxyOpts...)
+		go watchConfig(p, cfgPath, configHistory)
 
 		// Set up HTTP handler (proxy + optional dashboard)
 		var handler http.Handler = p
 		if cfg.Dashboard.Enabled {
 			mux := http.NewServeMux()
 			dash := dashboard.New(cfg, st)
+			dash.SetAdmin(p.AdminOnly)
 			dash.Register(mux)
 			// Proxy handles all non-dashboard routes
 			mux.Handle("/", p)
@@ -327,9 +630,17 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 		srv := &http.Server{
 			Addr:              addr,
 			Handler:           handler,
+			TLSConfig:         tlsConfig,
 			ReadHeaderTimeout: 10 * time.Second,
 			IdleTimeout:       120 * time.Second,
 		}
+		if internalLn != nil {
+			go http.Serve(internalLn, internalOnly(handler, internalToken))
+		}
+		scheme := "http"
+		if tlsConfig != nil {
+			scheme = "https"
+		}
 
 		// Handle graceful shutdown
 		go func() {
@@ -345,8 +656,11 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 		fmt.Println()
 		fmt.Println(ui.Boldf("  agix") + ui.Dimf(" - AI agent gateway"))
 		fmt.Println()
-		fmt.Printf("  %s  %s\n", ui.Dimf("Listening:"), ui.Greenf("http://localhost%s", addr))
+		fmt.Printf("  %s  %s\n", ui.Dimf("Listening:"), ui.Greenf("%s://localhost%s", scheme, addr))
 		fmt.Printf("  %s  %s\n", ui.Dimf("Database: "), cfg.Database)
+		if includes, _ := config.IncludeFiles(cfgPath); len(includes) > 0 {
+			fmt.Printf("  %s  %d file(s) from %s\n", ui.Dimf("Includes: "), len(includes), filepath.Join(filepath.Dir(cfgPath), config.IncludeDir))
+		}
 		fmt.Println()
 
 		// Show configured providers
@@ -366,16 +680,59 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 		} else {
 			fmt.Printf("    %s  %s\n", ui.Dimf("deepseek"), ui.Yellowf("not configured"))
 		}
+		if key, ok := cfg.Keys["azure"]; ok && key != "" && cfg.Azure.Resource != "" {
+			fmt.Printf("    %s  %s\n", ui.Greenf("azure"), ui.Dimf("azure/* (%s, %d deployment(s))", cfg.Azure.Resource, len(cfg.Azure.Deployments)))
+		}
+		if cfg.Ollama.BaseURL != "" || len(cfg.Ollama.Models) > 0 {
+			base := cfg.Ollama.BaseURL
+			if base == "" {
+				base = "http://localhost:11434"
+			}
+			fmt.Printf("    %s  %s\n", ui.Greenf("ollama"), ui.Dimf("ollama/* (%s)", base))
+		}
+		if key, ok := cfg.Keys["openrouter"]; ok && key != "" {
+			fmt.Printf("    %s  %s\n", ui.Greenf("openrouter"), ui.Dimf("openrouter/*"))
+		}
+		if cfg.Bedrock.Region != "" || len(cfg.Bedrock.Models) > 0 {
+			fmt.Printf("    %s  %s\n", ui.Greenf("bedrock"), ui.Dimf("bedrock/* (%d model(s))", len(cfg.Bedrock.Models)))
+		}
+		for _, pc := range cfg.Providers {
+			prefixes := pc.Prefixes
+			if len(prefixes) == 0 {
+				prefixes = []string{pc.Name + "/"}
+			}
+			fmt.Printf("    %s  %s\n", ui.Greenf("%s", pc.Name), ui.Dimf("%s* (%s)", strings.Join(prefixes, "*, "), pc.BaseURL))
+		}
 		fmt.Println()
 
 		// Show how to connect
 		fmt.Printf("  %s\n", ui.Dimf("Connect your agents:"))
-		fmt.Printf("    %s\n", ui.Cyanf("OPENAI_BASE_URL=http://localhost%s/v1", addr))
+		fmt.Printf("    %s\n", ui.Cyanf("OPENAI_BASE_URL=%s://localhost%s/v1", scheme, addr))
 		fmt.Println()
 
+		// Show HA role
+		if elector != nil {
+			role := ui.Yellowf("standby")
+			if elector.IsLeader() {
+				role = ui.Greenf("leader")
+			}
+			fmt.Printf("  %s %s (active-standby)\n", ui.Dimf("HA:"), role)
+			fmt.Println()
+		}
+
+		// Show CORS info
+		if cfg.CORS.Enabled {
+			if len(cfg.CORS.AllowedOrigins) == 0 {
+				fmt.Printf("  %s enabled but no allowed_origins; browser requests will be rejected\n", ui.Yellowf("CORS:   "))
+			} else {
+				fmt.Printf("  %s %s\n", ui.Dimf("CORS:   "), strings.Join(cfg.CORS.AllowedOrigins, ", "))
+			}
+			fmt.Println()
+		}
+
 		// Show budget info
 		if len(cfg.Budgets) > 0 {
-			fmt.Printf("  %s %d agent(s) with budget limits\n", ui.Dimf("Budgets:"), len(cfg.Budgets))
+			fmt.Printf("  %s %d budget limit(s)\n", ui.Dimf("Budgets:"), len(cfg.Budgets))
 			fmt.Println()
 		}
 
@@ -395,6 +752,9 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 				rate = 1.0
 			}
 			fmt.Printf("  %s enabled (sample rate: %.0f%%)\n", ui.Dimf("Tracing:"), rate*100)
+			if cfg.Tracing.OTLP.Endpoint != "" {
+				fmt.Printf("  %s %s\n", ui.Dimf("OTLP:"), cfg.Tracing.OTLP.Endpoint)
+			}
 			fmt.Println()
 		}
 
@@ -424,6 +784,20 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 			fmt.Println()
 		}
 
+		// Show outage queue info
+		if cfg.OutageQueue.Enabled {
+			pending, _ := st.CountQueuedRequests()
+			fmt.Printf("  %s enabled (%d request(s) pending retry)\n", ui.Dimf("Outage queue:"), pending)
+			fmt.Println()
+		}
+
+		// Show pricing modifiers info
+		if len(cfg.Pricing.Discounts) > 0 || len(cfg.Pricing.Windows) > 0 {
+			fmt.Printf("  %s %d discount(s), %d time window(s)\n",
+				ui.Dimf("Pricing:"), len(cfg.Pricing.Discounts), len(cfg.Pricing.Windows))
+			fmt.Println()
+		}
+
 		// Show response policy info
 		if cfg.ResponsePolicy.Enabled {
 			ruleCount := len(cfg.ResponsePolicy.RedactPatterns)
@@ -435,16 +809,28 @@ The gateway activates features based on your config (~/.agix/config.yaml):
 			fmt.Println()
 		}
 
+		// Show fault injection info
+		if cfg.Chaos.Enabled {
+			fmt.Printf("  %s enabled for %d provider rule(s), requests with X-Chaos only\n",
+				ui.Dimf("Chaos:"), len(cfg.Chaos.Providers))
+			fmt.Println()
+		}
+
 		// Show dashboard info
 		if cfg.Dashboard.Enabled {
-			fmt.Printf("  %s %s\n", ui.Dimf("Dashboard:"), ui.Cyanf("http://localhost%s/dashboard", addr))
+			fmt.Printf("  %s %s\n", ui.Dimf("Dashboard:"), ui.Cyanf("%s://localhost%s/dashboard", scheme, addr))
 			fmt.Println()
 		}
 
 		fmt.Println(ui.Dimf("  Press Ctrl+C to stop"))
 		fmt.Println()
 
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if tlsConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			return fmt.Errorf("server error: %w", err)
 		}
 
@@ -457,7 +843,448 @@ func init() {
 	startCmd.Flags().IntVarP(&startPort, "port", "p", 0, "port to listen on (overrides config)")
 }
 
+// internalOnly serves only requests agix sends to itself, which carry
+// token.
+func internalOnly(h http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !apikeys.IsInternal(r, token) {
+			http.Error(w, `{"error":"internal listener"}`, http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// initAlerter converts the alerts config section into an alert.Config.
+func initAlerter(ac config.AlertsConfig) (*alert.Alerter, error) {
+	acfg := alert.Config{Cooldown: 5 * time.Minute}
+	if ac.Cooldown != "" {
+		d, err := time.ParseDuration(ac.Cooldown)
+		if err != nil {
+			return nil, fmt.Errorf("cooldown: %w", err)
+		}
+		acfg.Cooldown = d
+	}
+
+	for _, l := range ac.Levels {
+		level := alert.Level{
+			Name:             l.Name,
+			AtPercent:        l.AtPercent,
+			BypassQuietHours: l.BypassQuietHours,
+		}
+		if l.DedupWindow != "" {
+			d, err := time.ParseDuration(l.DedupWindow)
+			if err != nil {
+				return nil, fmt.Errorf("level %s dedup_window: %w", l.Name, err)
+			}
+			level.DedupWindow = d
+		}
+		acfg.Levels = append(acfg.Levels, level)
+	}
+
+	if ac.QuietHours.Start != "" || ac.QuietHours.End != "" {
+		start, err := alert.ParseClock(ac.QuietHours.Start)
+		if err != nil {
+			return nil, fmt.Errorf("quiet_hours.start: %w", err)
+		}
+		end, err := alert.ParseClock(ac.QuietHours.End)
+		if err != nil {
+			return nil, fmt.Errorf("quiet_hours.end: %w", err)
+		}
+		loc := time.UTC
+		if ac.QuietHours.Timezone != "" {
+			loc, err = time.LoadLocation(ac.QuietHours.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("quiet_hours.timezone: %w", err)
+			}
+		}
+		acfg.QuietHours = &alert.QuietHours{Start: start, End: end, Location: loc}
+	}
+
+	if len(ac.Destinations) > 0 {
+		acfg.Destinations = make(map[string]alert.Destination, len(ac.Destinations))
+		for name, d := range ac.Destinations {
+			dest := alert.Destination{URL: d.URL, Email: d.Email, Levels: d.Levels}
+			if d.Template != "" {
+				tmpl, err := alert.ParseTemplate(name, d.Template)
+				if err != nil {
+					return nil, fmt.Errorf("destination %s: %w", name, err)
+				}
+				dest.Template = tmpl
+			}
+			acfg.Destinations[name] = dest
+		}
+	}
+	acfg.Mailer = newMailer(ac.SMTP)
+
+	if ac.Template != "" {
+		tmpl, err := alert.ParseTemplate("default", ac.Template)
+		if err != nil {
+			return nil, err
+		}
+		acfg.Template = tmpl
+	}
+	acfg.DashboardURL = ac.DashboardURL
+
+	return alert.New(acfg), nil
+}
+
+func initShedder(lc config.LoadSheddingConfig, st *store.Store) (*loadshed.Shedder, error) {
+	def, err := loadshed.ParsePriority(lc.DefaultPriority)
+	if err != nil {
+		return nil, fmt.Errorf("default_priority: %w", err)
+	}
+	prios := make(map[string]loadshed.Priority, len(lc.Priorities))
+	for agent, name := range lc.Priorities {
+		prio, err := loadshed.ParsePriority(name)
+		if err != nil {
+			return nil, fmt.Errorf("priorities.%s: %w", agent, err)
+		}
+		prios[agent] = prio
+	}
+
+	return loadshed.New(loadshed.Config{
+		Enabled:         true,
+		MaxInFlight:     lc.MaxInFlight,
+		MaxWriteBacklog: lc.MaxWriteBacklog,
+		MaxMemoryMB:     lc.MaxMemoryMB,
+		LowPriorityAt:   lc.LowPriorityAtPercent / 100,
+		RetryAfter:      time.Duration(lc.RetryAfterSeconds) * time.Second,
+		DefaultPriority: def,
+		Priorities:      prios,
+	}, st.Backlog), nil
+}
+
+func initAdmissionQueue(qc config.AdmissionQueueConfig) (*admission.Queue, error) {
+	def, err := loadshed.ParsePriority(qc.DefaultPriority)
+	if err != nil {
+		return nil, fmt.Errorf("default_priority: %w", err)
+	}
+	prios := make(map[string]loadshed.Priority, len(qc.Priorities))
+	for agent, name := range qc.Priorities {
+		prio, err := loadshed.ParsePriority(name)
+		if err != nil {
+			return nil, fmt.Errorf("priorities.%s: %w", agent, err)
+		}
+		prios[agent] = prio
+	}
+	var maxWait time.Duration
+	if qc.MaxWait != "" {
+		maxWait, err = time.ParseDuration(qc.MaxWait)
+		if err != nil || maxWait <= 0 {
+			return nil, fmt.Errorf("max_wait: invalid duration %q", qc.MaxWait)
+		}
+	}
+	if qc.MaxQueued < 0 {
+		return nil, fmt.Errorf("max_queued: must not be negative")
+	}
+
+	return admission.New(admission.Config{
+		MaxQueued:       qc.MaxQueued,
+		MaxWait:         maxWait,
+		DefaultPriority: def,
+		Priorities:      prios,
+	}), nil
+}
+
+func initElector(hc config.HAConfig, st *store.Store) (*ha.Elector, error) {
+	if st.Dialect() != store.DialectPostgres {
+		return nil, fmt.Errorf("ha requires a PostgreSQL database")
+	}
+	interval := 5 * time.Second
+	if hc.CheckInterval != "" {
+		d, err := time.ParseDuration(hc.CheckInterval)
+		if err != nil {
+			return nil, fmt.Errorf("check_interval: %w", err)
+		}
+		interval = d
+	}
+	key := hc.LockKey
+	if key == 0 {
+		key = ha.DefaultLockKey
+	}
+
+	elector := ha.New(ha.NewPGLocker(st.DB(), key), interval)
+	elector.Start()
+	return elector, nil
+}
+
+func initOutageQueue(oc config.OutageQueueConfig, gateway, internalToken string, st *store.Store, active func() bool) (*outagequeue.Queue, error) {
+	qc := outagequeue.Config{
+		Enabled:        true,
+		MaxAttempts:    oc.MaxAttempts,
+		CallbackSecret: oc.CallbackSecret,
+	}
+	if oc.RetryInterval != "" {
+		d, err := time.ParseDuration(oc.RetryInterval)
+		if err != nil {
+			return nil, fmt.Errorf("retry_interval: %w", err)
+		}
+		qc.RetryInterval = d
+	}
+	if oc.MaxAge != "" {
+		d, err := time.ParseDuration(oc.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("max_age: %w", err)
+		}
+		qc.MaxAge = d
+	}
+	return outagequeue.New(qc, st, outagequeue.LocalSender(gateway, internalToken), active), nil
+}
+
+func initClickHouse(cc config.ClickHouseConfig) (*clickhouse.Sink, error) {
+	c := clickhouse.Config{
+		URL:       cc.URL,
+		Database:  cc.Database,
+		Table:     cc.Table,
+		Username:  cc.Username,
+		Password:  cc.Password,
+		BatchSize: cc.BatchSize,
+	}
+	if cc.FlushInterval != "" {
+		d, err := time.ParseDuration(cc.FlushInterval)
+		if err != nil {
+			return nil, fmt.Errorf("flush_interval: %w", err)
+		}
+		c.FlushInterval = d
+	}
+	sink, err := clickhouse.New(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := sink.CreateTable(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// initPenaltyBox logs and audits every penalty and posts it to the
+// configured webhook.
+func initPenaltyBox(pc config.PenaltyBoxConfig, st *store.Store, auditLogger *audit.Logger) (*penalty.Box, error) {
+	c := penalty.Config{
+		MinRequests:           pc.MinRequests,
+		ErrorRate:             pc.ErrorRate,
+		FirewallBlocksPerHour: pc.FirewallBlocksPerHour,
+		DuplicateRequests:     pc.DuplicateRequests,
+		ThrottleRPM:           pc.ThrottleRPM,
+		Exempt:                pc.Exempt,
+	}
+	if pc.Window != "" {
+		d, err := time.ParseDuration(pc.Window)
+		if err != nil {
+			return nil, fmt.Errorf("window: %w", err)
+		}
+		c.Window = d
+	}
+	if pc.Duration != "" {
+		d, err := time.ParseDuration(pc.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("duration: %w", err)
+		}
+		c.Duration = d
+	}
+	notify := func(p penalty.Penalty) {
+		log.Printf("PENALTY: %s throttled until %s: %s", p.Agent, p.ExpiresAt.Format(time.RFC3339), p.Reason)
+		auditLogger.Log(audit.EventPenalty, p.Agent, audit.PenaltyDetails{Reason: p.Reason, ExpiresAt: p.ExpiresAt.Format(time.RFC3339)})
+		if pc.Webhook == "" {
+			return
+		}
+		go func() {
+			body, _ := json.Marshal(p)
+			resp, err := http.Post(pc.Webhook, "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Printf("PENALTY: webhook failed for %s: %v", p.Agent, err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	return penalty.New(c, st, notify)
+}
+
+// initModelCatalog lists models from every provider with a key: the
+// built-in OpenAI, Anthropic and DeepSeek endpoints and the /models
+// endpoint of each custom provider.
+func initModelCatalog(cfg *config.Config) (*modelcatalog.Catalog, error) {
+	interval := modelcatalog.DefaultInterval
+	if s := cfg.ModelCatalog.RefreshInterval; s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("refresh_interval: %w", err)
+		}
+		interval = d
+	}
+
+	var sources []modelcatalog.Source
+	if key := cfg.Keys["openai"]; key != "" {
+		sources = append(sources, modelcatalog.Source{Provider: "openai", URL: "https://api.openai.com/v1/models",
+			Headers: map[string]string{"Authorization": "Bearer " + key}})
+	}
+	if key := cfg.Keys["anthropic"]; key != "" {
+		sources = append(sources, modelcatalog.Source{Provider: "anthropic", URL: "https://api.anthropic.com/v1/models?limit=1000",
+			Headers: map[string]string{"x-api-key": key, "anthropic-version": "2023-06-01"}})
+	}
+	if key := cfg.Keys["deepseek"]; key != "" {
+		sources = append(sources, modelcatalog.Source{Provider: "deepseek", URL: "https://api.deepseek.com/models",
+			Headers: map[string]string{"Authorization": "Bearer " + key}})
+	}
+	for _, pc := range cfg.Providers {
+		headers := map[string]string{}
+		key := pc.APIKey
+		if key == "" {
+			key = cfg.Keys[pc.Name]
+		}
+		if key != "" {
+			headers["Authorization"] = "Bearer " + key
+		}
+		// Listed IDs only route back here with a stripped "name/" prefix.
+		prefix := pc.Name + "/"
+		if len(pc.Prefixes) > 0 {
+			prefix = ""
+			if p := pc.Prefixes[0]; strings.HasSuffix(p, "/") {
+				prefix = p
+			}
+		}
+		sources = append(sources, modelcatalog.Source{Provider: pc.Name, URL: strings.TrimRight(pc.BaseURL, "/") + "/models",
+			Headers: headers, Prefix: prefix})
+	}
+
+	overrides := make(map[string]modelcatalog.Metadata, len(cfg.ModelCatalog.Metadata))
+	for id, m := range cfg.ModelCatalog.Metadata {
+		if m.DeprecationDate != "" {
+			if _, err := time.Parse("2006-01-02", m.DeprecationDate); err != nil {
+				return nil, fmt.Errorf("metadata %s: deprecation_date must be YYYY-MM-DD", id)
+			}
+		}
+		overrides[id] = modelcatalog.Metadata{ContextWindow: m.ContextWindow, Modalities: m.Modalities, DeprecationDate: m.DeprecationDate}
+	}
+	return modelcatalog.New(sources, overrides, interval), nil
+}
+
+func initTransformer(tc map[string]config.ProviderTransformConfig) (*transform.Transformer, error) {
+	rules := func(rc config.TransformRulesConfig) transform.Rules {
+		return transform.Rules{Rename: rc.Rename, Drop: rc.Drop, Defaults: rc.Defaults, Set: rc.Set}
+	}
+	providers := make(map[string]transform.Provider, len(tc))
+	for name, pc := range tc {
+		providers[name] = transform.Provider{Request: rules(pc.Request), Response: rules(pc.Response)}
+	}
+	return transform.New(providers)
+}
+
+func initPacer(pc map[string]config.ProviderRateLimitConfig) (*ratelimit.Pacer, error) {
+	limits := make(map[string]ratelimit.ProviderLimit, len(pc))
+	for provider, rl := range pc {
+		limit := ratelimit.ProviderLimit{
+			RequestsPerMinute: rl.RequestsPerMinute,
+			TokensPerMinute:   rl.TokensPerMinute,
+		}
+		if rl.Burst != "" {
+			d, err := time.ParseDuration(rl.Burst)
+			if err != nil {
+				return nil, fmt.Errorf("%s burst: %w", provider, err)
+			}
+			limit.Burst = d
+		}
+		if rl.MaxWait != "" {
+			d, err := time.ParseDuration(rl.MaxWait)
+			if err != nil {
+				return nil, fmt.Errorf("%s max_wait: %w", provider, err)
+			}
+			limit.MaxWait = d
+		}
+		limits[provider] = limit
+	}
+	return ratelimit.NewPacer(limits), nil
+}
+
+func initPricing(pc config.PricingConfig) (pricing.Modifiers, error) {
+	mods := pricing.Modifiers{Discounts: pc.Discounts}
+	for name, d := range pc.Discounts {
+		if d < 0 || d >= 1 {
+			return mods, fmt.Errorf("discount %s: %v must be in [0, 1)", name, d)
+		}
+	}
+	for i, wc := range pc.Windows {
+		name := wc.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		w, err := pricing.NewWindow(name, wc.Start, wc.End, wc.Timezone, wc.Multiplier, wc.Providers, wc.Models)
+		if err != nil {
+			return mods, fmt.Errorf("window %s: %w", name, err)
+		}
+		mods.Windows = append(mods.Windows, w)
+	}
+	return mods, nil
+}
+
+// builtinProviders are the provider names a custom provider may not reuse.
+var builtinProviders = []string{"openai", "anthropic", "deepseek", "azure", "ollama", "openrouter", "bedrock"}
+
+// initProviders validates the custom providers and registers their model
+// prefixes.
+func initProviders(cfg *config.Config) error {
+	prefixes := make(map[string]string)
+	for i, pc := range cfg.Providers {
+		if pc.Name == "" {
+			return fmt.Errorf("providers[%d]: name is required", i)
+		}
+		if slices.Contains(builtinProviders, pc.Name) {
+			return fmt.Errorf("provider %s: name is reserved for the built-in provider", pc.Name)
+		}
+		if pc.BaseURL == "" {
+			return fmt.Errorf("provider %s: base_url is required", pc.Name)
+		}
+		list := pc.Prefixes
+		if len(list) == 0 {
+			list = []string{pc.Name + "/"}
+		}
+		for _, prefix := range list {
+			prefix = strings.ToLower(prefix)
+			if other, ok := prefixes[prefix]; ok {
+				return fmt.Errorf("provider %s: prefix %q is already used by %s", pc.Name, prefix, other)
+			}
+			prefixes[prefix] = pc.Name
+		}
+	}
+	pricing.SetProviders(prefixes)
+	return nil
+}
+
+// initPricingAliases prices Azure deployments and Bedrock models as the
+// base models they are mapped to.
+func initPricingAliases(cfg *config.Config) {
+	if len(cfg.Azure.Deployments) == 0 && len(cfg.Bedrock.Models) == 0 {
+		return
+	}
+	aliases := make(map[string]string, len(cfg.Azure.Deployments)+len(cfg.Bedrock.Models))
+	for deployment, base := range cfg.Azure.Deployments {
+		aliases["azure/"+deployment] = base
+	}
+	for id, base := range cfg.Bedrock.Models {
+		aliases["bedrock/"+id] = base
+	}
+	pricing.SetAliases(aliases)
+}
+
+// openStore opens the configured database, with the bulky content tables
+// in content_database when set.
+func openStore(cfg *config.Config, opts ...store.Option) (*store.Store, error) {
+	return store.New(cfg.Database, append([]store.Option{store.WithContentDSN(cfg.ContentDatabase)}, opts...)...)
+}
+
 func loadConfig() (*config.Config, string, error) {
+	return loadConfigWith(config.Load)
+}
+
+// loadConfigForEdit loads the config file without config.d, for commands
+// that change and save it.
+func loadConfigForEdit() (*config.Config, string, error) {
+	return loadConfigWith(config.LoadFile)
+}
+
+func loadConfigWith(load func(string) (*config.Config, error)) (*config.Config, string, error) {
 	path := cfgFile
 	if path == "" {
 		var err error
@@ -467,7 +1294,7 @@ func loadConfig() (*config.Config, string, error) {
 		}
 	}
 
-	cfg, err := config.Load(path)
+	cfg, err := load(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, "", fmt.Errorf("config not found at %s (run 'agix init' first)", path)
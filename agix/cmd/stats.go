@@ -1,20 +1,45 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/agent-platform/agix/internal/alert"
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/costcenter"
+	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/statscompare"
 	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/tags"
 	"github.com/agent-platform/agix/internal/ui"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
 var (
-	statsPeriod  string
-	statsGroupBy string
-	statsFormat  string
+	statsPeriod   string
+	statsGroupBy  string
+	statsFormat   string
+	statsFailover bool
+	statsRouted   bool
+	statsStream   bool
+	statsLatency  bool
+	statsEmail    string
+	statsTags     []string
+
+	statsCompareA     string
+	statsCompareB     string
+	statsCompareBy    string
+	statsCompareLimit int
+
+	statsErrorsPeriod   string
+	statsErrorsBucket   string
+	statsErrorsProvider string
 )
 
 var statsCmd = &cobra.Command{
@@ -28,14 +53,28 @@ Examples:
   agix stats --period 30d       # Last 30 days
   agix stats --group-by agent   # Group by agent
   agix stats --group-by model   # Group by model
-  agix stats --group-by day     # Group by day`,
+  agix stats --group-by day     # Group by day
+  agix stats --group-by cost-center  # Group by cost center (needs cost_centers)
+  agix stats --group-by project      # Group by project (X-Project or projects)
+  agix stats --group-by tag:team     # Group by the team tag (X-Request-Tags)
+  agix stats --tag ticket=ABC-123    # Only requests tagged ticket=ABC-123
+  agix stats --failover         # How often failover changed the model
+  agix stats --routed           # What routing/experiments saved
+  agix stats --streaming        # Time to first token and tokens/sec per model
+  agix stats --latency          # Latency p50/p95/p99 per model
+  agix stats --latency -g provider   # ... per provider (or agent)
+  agix stats --period yesterday --email finance  # Email a digest (e.g. from cron)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, _, err := loadConfig()
+		cfg, cfgPath, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		centers, err := loadCostCenters(cfg, cfgPath)
 		if err != nil {
 			return err
 		}
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
@@ -43,6 +82,30 @@ Examples:
 
 		since, until := parsePeriod(statsPeriod)
 
+		if statsEmail != "" {
+			return emailDigest(cfg, st, centers, since, until)
+		}
+		if statsFailover {
+			return showFailoverStats(st, since, until)
+		}
+		if statsRouted {
+			return showRoutedStats(st, since, until)
+		}
+		if statsStream {
+			return showStreamingStats(st, since, until)
+		}
+		if statsLatency {
+			return showLatencyStats(st, since, until)
+		}
+
+		if len(statsTags) > 0 || strings.HasPrefix(statsGroupBy, "tag:") {
+			filter, err := tags.ParseFilter(statsTags)
+			if err != nil {
+				return fmt.Errorf("--tag: %w", err)
+			}
+			return showTagStats(st, filter, since, until)
+		}
+
 		switch statsGroupBy {
 		case "agent":
 			return showAgentStats(st, since, until)
@@ -50,17 +113,311 @@ Examples:
 			return showModelStats(st, since, until)
 		case "day":
 			return showDailyStats(st, since, until)
+		case "project":
+			return showProjectStats(st, since, until)
+		case "cost-center":
+			if centers == nil {
+				return fmt.Errorf("--group-by cost-center needs a cost_centers mapping file in the config")
+			}
+			return showCostCenterStats(st, centers, since, until)
 		default:
 			return showOverallStats(st, since, until)
 		}
 	},
 }
 
+var statsCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare usage between two time ranges",
+	Long: `Compare cost, volume, latency and error rate per agent and per model
+between range A (baseline) and range B.
+
+Ranges: today, yesterday, this week, last week, this month, last month,
+Nd (last N days), YYYY-MM, YYYY-MM-DD, YYYY-MM-DD..YYYY-MM-DD (UTC).`,
+	Example: `  agix stats compare --a "last week" --b "this week"
+  agix stats compare --a 2026-01 --b 2026-02 --by model
+  agix stats compare --a yesterday --b today --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		now := time.Now()
+		a, err := statscompare.ParseRange(statsCompareA, now)
+		if err != nil {
+			return fmt.Errorf("--a: %w", err)
+		}
+		b, err := statscompare.ParseRange(statsCompareB, now)
+		if err != nil {
+			return fmt.Errorf("--b: %w", err)
+		}
+
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		st, err := openStore(cfg)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer st.Close()
+
+		c, err := statscompare.Compare(st, a, b)
+		if err != nil {
+			return err
+		}
+
+		if statsFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(c)
+		}
+
+		if c.Total.A.Requests == 0 && c.Total.B.Requests == 0 {
+			fmt.Println(ui.Dimf("No requests recorded in either range."))
+			return nil
+		}
+
+		fmt.Println(ui.Boldf("Comparison") + ui.Dimf(" (A: %s %s → %s, B: %s %s → %s)",
+			a.Label, a.Since.Format("2006-01-02"), a.Until.Format("2006-01-02"),
+			b.Label, b.Since.Format("2006-01-02"), b.Until.Format("2006-01-02")))
+		fmt.Println()
+		renderComparison("Total", []statscompare.Row{c.Total}, 0)
+		if statsCompareBy == "" || statsCompareBy == "agent" {
+			renderComparison("Agent", c.Agents, statsCompareLimit)
+		}
+		if statsCompareBy == "" || statsCompareBy == "model" {
+			renderComparison("Model", c.Models, statsCompareLimit)
+		}
+		return nil
+	},
+}
+
+var statsErrorsCmd = &cobra.Command{
+	Use:   "errors",
+	Short: "Show error rates and status codes by provider and model",
+	Long: `Show the share of non-2xx responses per provider over time, and which
+status codes each provider and model returned, to spot a degrading provider
+early.
+
+Buckets are hourly for today and yesterday and daily otherwise, unless
+--bucket is set.`,
+	Example: `  agix stats errors                     # Last 7 days, daily
+  agix stats errors -P today            # Today, hourly
+  agix stats errors --provider anthropic
+  agix stats errors -P 30d --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bucket := statsErrorsBucket
+		if bucket == "" {
+			bucket = "day"
+			if statsErrorsPeriod == "today" || statsErrorsPeriod == "yesterday" {
+				bucket = "hour"
+			}
+		}
+
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		st, err := openStore(cfg)
+		if err != nil {
+			return fmt.Errorf("open database: %w", err)
+		}
+		defer st.Close()
+
+		since, until := parsePeriod(statsErrorsPeriod)
+		rates, err := st.QueryErrorRates(bucket, since, until)
+		if err != nil {
+			return err
+		}
+		codes, err := st.QueryStatusCodes(since, until)
+		if err != nil {
+			return err
+		}
+		if statsErrorsProvider != "" {
+			rates = slices.DeleteFunc(rates, func(r store.ErrorRate) bool { return r.Provider != statsErrorsProvider })
+			codes = slices.DeleteFunc(codes, func(c store.StatusCount) bool { return c.Provider != statsErrorsProvider })
+		}
+
+		if statsFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Rates       []store.ErrorRate   `json:"error_rates"`
+				StatusCodes []store.StatusCount `json:"status_codes"`
+			}{rates, codes})
+		}
+		if len(rates) == 0 {
+			fmt.Println(ui.Dimf("No requests recorded for this period."))
+			return nil
+		}
+
+		fmt.Println(ui.Boldf("Error Rate") + ui.Dimf(" (%s, by %s)", periodLabel(statsErrorsPeriod), bucket))
+		fmt.Println()
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Bucket", "Provider", "Requests", "Errors", "Rate"})
+		table.SetBorder(false)
+		table.SetColumnAlignment([]int{
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_RIGHT,
+			tablewriter.ALIGN_RIGHT,
+			tablewriter.ALIGN_RIGHT,
+		})
+		for _, r := range rates {
+			rate := fmt.Sprintf("%.1f%%", r.Rate*100)
+			switch {
+			case r.Rate >= 0.05:
+				rate = ui.Redf("%s", rate)
+			case r.Rate > 0:
+				rate = ui.Yellowf("%s", rate)
+			}
+			table.Append([]string{
+				r.Bucket,
+				r.Provider,
+				fmt.Sprintf("%d", r.Requests),
+				fmt.Sprintf("%d", r.Errors),
+				rate,
+			})
+		}
+		table.Render()
+
+		if len(codes) == 0 {
+			fmt.Println()
+			fmt.Println(ui.Dimf("No non-2xx responses."))
+			return nil
+		}
+		fmt.Println()
+		fmt.Println(ui.Boldf("Status Codes"))
+		fmt.Println()
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Provider", "Model", "Status", "Count"})
+		table.SetBorder(false)
+		table.SetColumnAlignment([]int{
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_LEFT,
+			tablewriter.ALIGN_RIGHT,
+			tablewriter.ALIGN_RIGHT,
+		})
+		for _, c := range codes {
+			table.Append([]string{
+				c.Provider,
+				c.Model,
+				ui.StatusColor(c.StatusCode),
+				fmt.Sprintf("%d", c.Count),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(statsCmd)
+	statsCmd.AddCommand(statsCompareCmd)
+	statsCmd.AddCommand(statsErrorsCmd)
 	statsCmd.Flags().StringVarP(&statsPeriod, "period", "P", "today", "time period: today, 7d, 30d, all")
-	statsCmd.Flags().StringVarP(&statsGroupBy, "group-by", "g", "", "group by: agent, model, day")
+	statsCmd.Flags().StringVarP(&statsGroupBy, "group-by", "g", "", "group by: agent, model, day, cost-center, project, tag:<key>")
+	statsCmd.Flags().StringArrayVar(&statsTags, "tag", nil, "only count requests tagged key=value (repeatable)")
 	statsCmd.Flags().StringVarP(&statsFormat, "format", "f", "table", "output format: table, json")
+	statsCmd.Flags().BoolVar(&statsFailover, "failover", false, "show failover breakdown (requested → fallback model)")
+	statsCmd.Flags().BoolVar(&statsRouted, "routed", false, "show routing/experiment breakdown with estimated savings")
+	statsCmd.Flags().BoolVar(&statsStream, "streaming", false, "show time to first token and tokens/sec percentiles per model")
+	statsCmd.Flags().BoolVar(&statsLatency, "latency", false, "show latency percentiles per model (or --group-by provider, agent)")
+	statsCmd.Flags().StringVar(&statsEmail, "email", "", "email a usage digest for the period to this alert destination")
+	statsCmd.MarkFlagsMutuallyExclusive("failover", "routed")
+
+	statsCompareCmd.Flags().StringVar(&statsCompareA, "a", "last week", "baseline range")
+	statsCompareCmd.Flags().StringVar(&statsCompareB, "b", "this week", "range compared against the baseline")
+	statsCompareCmd.Flags().StringVar(&statsCompareBy, "by", "", "only show one breakdown: agent, model")
+	statsCompareCmd.Flags().IntVarP(&statsCompareLimit, "number", "n", 20, "rows per breakdown (0 = all)")
+	statsCompareCmd.Flags().StringVarP(&statsFormat, "format", "f", "table", "output format: table, json")
+
+	statsErrorsCmd.Flags().StringVarP(&statsErrorsPeriod, "period", "P", "7d", "time period: today, yesterday, 7d, 30d, all, YYYY-MM")
+	statsErrorsCmd.Flags().StringVar(&statsErrorsBucket, "bucket", "", "time bucket: hour, day (default hour for today/yesterday, else day)")
+	statsErrorsCmd.Flags().StringVar(&statsErrorsProvider, "provider", "", "only show this provider")
+	statsErrorsCmd.Flags().StringVarP(&statsFormat, "format", "f", "table", "output format: table, json")
+}
+
+// renderComparison prints A/B metrics with up/down indicators. Rising cost,
+// latency and error rate are red; falling ones are green.
+func renderComparison(label string, rows []statscompare.Row, limit int) {
+	if len(rows) == 0 {
+		return
+	}
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{label, "Requests A", "Requests B", "Δ", "Cost A", "Cost B", "Δ", "Latency A", "Latency B", "Δ", "Errors A", "Errors B", "Δ"})
+	table.SetBorder(false)
+	table.SetAutoFormatHeaders(false)
+	align := []int{tablewriter.ALIGN_LEFT}
+	for i := 0; i < 12; i++ {
+		align = append(align, tablewriter.ALIGN_RIGHT)
+	}
+	table.SetColumnAlignment(align)
+
+	for _, r := range rows {
+		table.Append([]string{
+			ui.Cyanf("%s", r.Key),
+			fmt.Sprintf("%d", r.A.Requests),
+			fmt.Sprintf("%d", r.B.Requests),
+			requestsDelta(r),
+			fmt.Sprintf("$%.4f", r.A.CostUSD),
+			fmt.Sprintf("$%.4f", r.B.CostUSD),
+			formatDelta(r.Delta.CostPct, true),
+			fmt.Sprintf("%.0fms", r.A.AvgDurationMS),
+			fmt.Sprintf("%.0fms", r.B.AvgDurationMS),
+			formatDelta(r.Delta.AvgDurationPct, true),
+			fmt.Sprintf("%.1f%%", r.A.ErrorRate*100),
+			fmt.Sprintf("%.1f%%", r.B.ErrorRate*100),
+			formatPointDelta(r.Delta.ErrorRatePoints),
+		})
+	}
+	table.Render()
+	fmt.Println()
+}
+
+// requestsDelta marks rows that only exist in range B as new.
+func requestsDelta(r statscompare.Row) string {
+	if r.A.Requests == 0 {
+		return ui.Cyanf("new")
+	}
+	return formatDelta(r.Delta.RequestsPct, false)
+}
+
+// formatDelta renders a percentage change as ▲/▼. When upIsBad, increases
+// are red and decreases green; otherwise the arrow is uncolored.
+func formatDelta(p *float64, upIsBad bool) string {
+	if p == nil {
+		return ui.Dimf("-")
+	}
+	switch {
+	case *p > 0.05:
+		s := fmt.Sprintf("▲ %.1f%%", *p)
+		if upIsBad {
+			return ui.Redf("%s", s)
+		}
+		return s
+	case *p < -0.05:
+		s := fmt.Sprintf("▼ %.1f%%", -*p)
+		if upIsBad {
+			return ui.Greenf("%s", s)
+		}
+		return s
+	default:
+		return ui.Dimf("=")
+	}
+}
+
+// formatPointDelta renders an error-rate change in percentage points.
+func formatPointDelta(points float64) string {
+	switch {
+	case points > 0.05:
+		return ui.Redf("▲ %.1fpp", points)
+	case points < -0.05:
+		return ui.Greenf("▼ %.1fpp", -points)
+	default:
+		return ui.Dimf("=")
+	}
 }
 
 func parsePeriod(period string) (time.Time, time.Time) {
@@ -71,6 +428,10 @@ func parsePeriod(period string) (time.Time, time.Time) {
 	case "today":
 		y, m, d := now.Date()
 		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), until
+	case "yesterday":
+		y, m, d := now.Date()
+		today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return today.AddDate(0, 0, -1), today.Add(-time.Second)
 	case "7d", "week":
 		return now.AddDate(0, 0, -7), until
 	case "30d", "month":
@@ -93,6 +454,8 @@ func periodLabel(period string) string {
 	switch period {
 	case "today":
 		return "Today"
+	case "yesterday":
+		return "Yesterday"
 	case "7d", "week":
 		return "Last 7 days"
 	case "30d", "month":
@@ -104,6 +467,66 @@ func periodLabel(period string) string {
 	}
 }
 
+// loadCostCenters reads the cost_centers mapping file, resolving relative
+// paths against the config file's directory. It returns nil when no mapping
+// is configured.
+func loadCostCenters(cfg *config.Config, cfgPath string) (*costcenter.Map, error) {
+	if cfg.CostCenters == "" {
+		return nil, nil
+	}
+	path := cfg.CostCenters
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(cfgPath), path)
+	}
+	return costcenter.Load(path)
+}
+
+// emailDigest sends the period's totals and per-agent/per-model breakdown to
+// the email recipients of an alert destination. With a cost center mapping
+// the digest also breaks spend down by cost center.
+func emailDigest(cfg *config.Config, st *store.Store, centers *costcenter.Map, since, until time.Time) error {
+	stats, err := st.QueryStats(since, until)
+	if err != nil {
+		return fmt.Errorf("query stats: %w", err)
+	}
+	agents, err := st.QueryStatsByAgent(since, until)
+	if err != nil {
+		return fmt.Errorf("query agent stats: %w", err)
+	}
+	models, err := st.QueryStatsByModel(since, until)
+	if err != nil {
+		return fmt.Errorf("query model stats: %w", err)
+	}
+
+	d := alert.Digest{
+		Period:   fmt.Sprintf("%s (%s)", periodLabel(statsPeriod), since.Format("2006-01-02")),
+		Requests: stats.TotalRequests,
+		Tokens:   stats.TotalInput + stats.TotalOutput,
+		CostUSD:  stats.TotalCostUSD,
+	}
+	for _, a := range agents {
+		d.Agents = append(d.Agents, alert.DigestRow{Name: a.AgentName, Requests: a.Requests, Tokens: a.InputTokens + a.OutputTokens, CostUSD: a.CostUSD})
+	}
+	for _, m := range models {
+		d.Models = append(d.Models, alert.DigestRow{Name: m.Model, Requests: m.Requests, Tokens: m.InputTokens + m.OutputTokens, CostUSD: m.CostUSD})
+	}
+	if centers != nil {
+		for _, c := range centers.Rollup(agents) {
+			d.CostCenters = append(d.CostCenters, alert.DigestRow{Name: c.CostCenter, Requests: c.Requests, Tokens: c.InputTokens + c.OutputTokens, CostUSD: c.CostUSD})
+		}
+	}
+
+	subject, body, err := alert.RenderDigest(d)
+	if err != nil {
+		return err
+	}
+	if err := sendEmail(cfg, statsEmail, subject, body); err != nil {
+		return err
+	}
+	fmt.Printf("Digest for %s sent to %s.\n", d.Period, statsEmail)
+	return nil
+}
+
 func showOverallStats(st *store.Store, since, until time.Time) error {
 	stats, err := st.QueryStats(since, until)
 	if err != nil {
@@ -137,6 +560,72 @@ func showOverallStats(st *store.Store, since, until time.Time) error {
 	return nil
 }
 
+// showTagStats shows usage filtered by tags, grouped by agent, model or a
+// tag key (--group-by tag:<key>), or as one total.
+func showTagStats(st *store.Store, filter tags.Tags, since, until time.Time) error {
+	rows, err := st.QueryTagStats(since, until)
+	if err != nil {
+		return err
+	}
+	groups, err := tags.Rollup(rows, filter, statsGroupBy)
+	if err != nil {
+		return err
+	}
+
+	if statsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(groups)
+	}
+	if len(groups) == 0 {
+		fmt.Println(ui.Dimf("No matching requests recorded for this period."))
+		return nil
+	}
+
+	label := periodLabel(statsPeriod)
+	if len(filter) > 0 {
+		label += ", " + filter.String()
+	}
+	header := "Group"
+	switch {
+	case statsGroupBy == "agent":
+		header = "Agent"
+	case statsGroupBy == "model":
+		header = "Model"
+	case strings.HasPrefix(statsGroupBy, "tag:"):
+		header = "Tag " + strings.TrimPrefix(statsGroupBy, "tag:")
+	}
+	fmt.Println(ui.Boldf("Tagged Usage") + ui.Dimf(" (%s)", label))
+	fmt.Println()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{header, "Requests", "Input Tokens", "Output Tokens", "Cost"})
+	table.SetBorder(false)
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+	})
+
+	var totalCost float64
+	for _, g := range groups {
+		totalCost += g.CostUSD
+		table.Append([]string{
+			ui.Cyanf("%s", g.Key),
+			fmt.Sprintf("%d", g.Requests),
+			formatTokens(g.InputTokens),
+			formatTokens(g.OutputTokens),
+			ui.CostColor(g.CostUSD),
+		})
+	}
+
+	table.SetFooter([]string{"", "", "", "Total", ui.CostColor(totalCost)})
+	table.Render()
+	return nil
+}
+
 func showAgentStats(st *store.Store, since, until time.Time) error {
 	agents, err := st.QueryStatsByAgent(since, until)
 	if err != nil {
@@ -179,6 +668,105 @@ func showAgentStats(st *store.Store, since, until time.Time) error {
 	return nil
 }
 
+func showProjectStats(st *store.Store, since, until time.Time) error {
+	projects, err := st.QueryStatsByProject(since, until)
+	if err != nil {
+		return err
+	}
+
+	if statsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(projects)
+	}
+	if len(projects) == 0 {
+		fmt.Println(ui.Dimf("No requests recorded for this period."))
+		return nil
+	}
+
+	fmt.Println(ui.Boldf("Cost by Project") + ui.Dimf(" (%s)", periodLabel(statsPeriod)))
+	fmt.Println()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Project", "Agents", "Requests", "Input Tokens", "Output Tokens", "Cost"})
+	table.SetBorder(false)
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+	})
+
+	var totalCost float64
+	for _, p := range projects {
+		totalCost += p.CostUSD
+		table.Append([]string{
+			ui.Cyanf("%s", p.Project),
+			fmt.Sprintf("%d", p.Agents),
+			fmt.Sprintf("%d", p.Requests),
+			formatTokens(p.InputTokens),
+			formatTokens(p.OutputTokens),
+			ui.CostColor(p.CostUSD),
+		})
+	}
+
+	table.SetFooter([]string{"", "", "", "", "Total", ui.CostColor(totalCost)})
+	table.Render()
+	return nil
+}
+
+func showCostCenterStats(st *store.Store, centers *costcenter.Map, since, until time.Time) error {
+	agents, err := st.QueryStatsByAgent(since, until)
+	if err != nil {
+		return err
+	}
+	rows := centers.Rollup(agents)
+
+	if statsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	if len(rows) == 0 {
+		fmt.Println(ui.Dimf("No requests recorded for this period."))
+		return nil
+	}
+
+	fmt.Println(ui.Boldf("Cost by Cost Center") + ui.Dimf(" (%s)", periodLabel(statsPeriod)))
+	fmt.Println()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Cost Center", "Agents", "Requests", "Input Tokens", "Output Tokens", "Cost"})
+	table.SetBorder(false)
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+	})
+
+	var totalCost float64
+	for _, c := range rows {
+		totalCost += c.CostUSD
+		table.Append([]string{
+			ui.Cyanf("%s", c.CostCenter),
+			fmt.Sprintf("%d", len(c.Agents)),
+			fmt.Sprintf("%d", c.Requests),
+			formatTokens(c.InputTokens),
+			formatTokens(c.OutputTokens),
+			ui.CostColor(c.CostUSD),
+		})
+	}
+
+	table.SetFooter([]string{"", "", "", "", "Total", ui.CostColor(totalCost)})
+	table.Render()
+	return nil
+}
+
 func showModelStats(st *store.Store, since, until time.Time) error {
 	models, err := st.QueryStatsByModel(since, until)
 	if err != nil {
@@ -261,6 +849,221 @@ func showDailyStats(st *store.Store, since, until time.Time) error {
 	return nil
 }
 
+func showStreamingStats(st *store.Store, since, until time.Time) error {
+	stats, err := st.QueryStreamingStats(since, until)
+	if err != nil {
+		return err
+	}
+	if statsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	if len(stats) == 0 {
+		fmt.Println(ui.Dimf("No streaming responses recorded for this period."))
+		return nil
+	}
+
+	fmt.Println(ui.Boldf("Streaming Latency") + ui.Dimf(" (%s)", periodLabel(statsPeriod)))
+	fmt.Println()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Model", "Streams", "TTFT p50", "TTFT p95", "Tok/s p50", "Tok/s p95"})
+	table.SetBorder(false)
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+	})
+	for _, s := range stats {
+		table.Append([]string{
+			s.Model,
+			fmt.Sprintf("%d", s.Streams),
+			fmt.Sprintf("%dms", s.TTFTP50MS),
+			fmt.Sprintf("%dms", s.TTFTP95MS),
+			fmt.Sprintf("%.1f", s.TokensPerSecP50),
+			fmt.Sprintf("%.1f", s.TokensPerSecP95),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func showLatencyStats(st *store.Store, since, until time.Time) error {
+	groupBy := statsGroupBy
+	if groupBy == "" {
+		groupBy = "model"
+	}
+	stats, err := st.QueryLatencyStats(groupBy, since, until)
+	if err != nil {
+		return err
+	}
+	if statsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	if len(stats) == 0 {
+		fmt.Println(ui.Dimf("No requests recorded for this period."))
+		return nil
+	}
+
+	fmt.Println(ui.Boldf("Latency") + ui.Dimf(" (%s)", periodLabel(statsPeriod)))
+	fmt.Println()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{strings.ToUpper(groupBy[:1]) + groupBy[1:], "Requests", "Avg", "p50", "p95", "p99", "Max"})
+	table.SetBorder(false)
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+	})
+	for _, s := range stats {
+		table.Append([]string{
+			s.Key,
+			fmt.Sprintf("%d", s.Requests),
+			fmt.Sprintf("%.0fms", s.AvgMS),
+			fmt.Sprintf("%dms", s.P50MS),
+			fmt.Sprintf("%dms", s.P95MS),
+			fmt.Sprintf("%dms", s.P99MS),
+			fmt.Sprintf("%dms", s.MaxMS),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func showFailoverStats(st *store.Store, since, until time.Time) error {
+	changes, err := st.QueryFailoverStats(since, until)
+	if err != nil {
+		return err
+	}
+	if statsFormat == "json" {
+		type failover struct {
+			store.ModelChange
+			RequestedEstUSD float64 `json:"requested_est_usd"`
+			ExtraCostUSD    float64 `json:"extra_cost_usd"`
+		}
+		rows := make([]failover, 0, len(changes))
+		for _, c := range changes {
+			original := pricing.CalculateCost(c.FromModel, c.InputTokens, c.OutputTokens)
+			rows = append(rows, failover{c, original, c.CostUSD - original})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	if len(changes) == 0 {
+		fmt.Println(ui.Dimf("No failover events recorded for this period."))
+		return nil
+	}
+
+	total, err := st.QueryStats(since, until)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Boldf("Failover Breakdown") + ui.Dimf(" (%s)", periodLabel(statsPeriod)))
+	fmt.Println()
+	renderModelChanges(changes, total.TotalRequests, "Extra Cost", func(actual, original float64) float64 {
+		return actual - original
+	})
+	return nil
+}
+
+func showRoutedStats(st *store.Store, since, until time.Time) error {
+	changes, err := st.QueryRoutingStats(since, until)
+	if err != nil {
+		return err
+	}
+	if statsFormat == "json" {
+		type routed struct {
+			store.ModelChange
+			RequestedEstUSD float64 `json:"requested_est_usd"`
+			SavedUSD        float64 `json:"saved_usd"`
+		}
+		rows := make([]routed, 0, len(changes))
+		for _, c := range changes {
+			original := pricing.CalculateCost(c.FromModel, c.InputTokens, c.OutputTokens)
+			rows = append(rows, routed{c, original, original - c.CostUSD})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	if len(changes) == 0 {
+		fmt.Println(ui.Dimf("No routed requests recorded for this period."))
+		return nil
+	}
+
+	total, err := st.QueryStats(since, until)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.Boldf("Routing Breakdown") + ui.Dimf(" (%s)", periodLabel(statsPeriod)))
+	fmt.Println()
+	renderModelChanges(changes, total.TotalRequests, "Saved", func(actual, original float64) float64 {
+		return original - actual
+	})
+	return nil
+}
+
+// renderModelChanges prints a requested → actual model table. The cost of the
+// requested model is estimated by re-pricing the recorded tokens; delta turns
+// (actual, estimated original) into the value shown in the last column.
+func renderModelChanges(changes []store.ModelChange, totalRequests int, deltaLabel string, delta func(actual, original float64) float64) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Requested", "Actual", "Requests", "Share", "Cost", "Requested Est.", deltaLabel})
+	table.SetBorder(false)
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_LEFT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+		tablewriter.ALIGN_RIGHT,
+	})
+
+	var totalCost, totalDelta float64
+	var changed int
+	for _, c := range changes {
+		original := pricing.CalculateCost(c.FromModel, c.InputTokens, c.OutputTokens)
+		d := delta(c.CostUSD, original)
+		totalCost += c.CostUSD
+		totalDelta += d
+		changed += c.Requests
+		table.Append([]string{
+			c.FromModel,
+			ui.Cyanf("%s", c.ToModel),
+			fmt.Sprintf("%d", c.Requests),
+			formatShare(c.Requests, totalRequests),
+			ui.CostColor(c.CostUSD),
+			fmt.Sprintf("$%.4f", original),
+			fmt.Sprintf("$%.4f", d),
+		})
+	}
+
+	table.SetFooter([]string{"", "Total", fmt.Sprintf("%d", changed), formatShare(changed, totalRequests), ui.CostColor(totalCost), "", fmt.Sprintf("$%.4f", totalDelta)})
+	table.Render()
+}
+
+func formatShare(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)/float64(total)*100)
+}
+
 func formatTokens(n int) string {
 	if n >= 1_000_000 {
 		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/agent-platform/agix/internal/config"
 	"github.com/agent-platform/agix/internal/toolmgr"
@@ -83,9 +88,89 @@ and displays them in a table. Use this to verify your MCP server configuration.`
 	},
 }
 
+var (
+	toolsCallArgs    string
+	toolsCallAgent   string
+	toolsCallGateway string
+)
+
+var toolsCallCmd = &cobra.Command{
+	Use:   "call <tool>",
+	Short: "Invoke an MCP tool through the running gateway",
+	Long: `Executes one tool through the running gateway's tool manager, without an
+LLM round trip. The agent's allow/deny rules apply and the call is written to
+the audit log, so this is a quick way to test an MCP server end to end.`,
+	Example: `  agix tools call read_file --args '{"path":"/tmp/x"}'
+  agix tools call create_issue --agent code-reviewer --args '{"title":"test"}'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		var arguments map[string]any
+		if err := json.Unmarshal([]byte(toolsCallArgs), &arguments); err != nil {
+			return fmt.Errorf("--args must be a JSON object: %w", err)
+		}
+		body, err := json.Marshal(map[string]any{"arguments": arguments})
+		if err != nil {
+			return err
+		}
+
+		gateway := toolsCallGateway
+		if gateway == "" {
+			gateway = localGateway(cfg)
+		}
+		req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(gateway, "/")+"/v1/tools/"+args[0]+"/call", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if toolsCallAgent != "" {
+			req.Header.Set("X-Agent-Name", toolsCallAgent)
+		}
+		if key := os.Getenv("AGIX_API_KEY"); key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+
+		client := &http.Client{Timeout: 2 * time.Minute}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("reach gateway at %s (is agix start running?): %w", gateway, err)
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			Server     string `json:"server"`
+			Result     string `json:"result"`
+			Error      string `json:"error"`
+			DurationMS int64  `json:"duration_ms"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("gateway returned %s", resp.Status)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("gateway returned %s: %s", resp.Status, result.Error)
+		}
+
+		fmt.Println(ui.Dimf("%s via %s in %dms", args[0], result.Server, result.DurationMS))
+		if result.Error != "" {
+			return fmt.Errorf("tool %s failed: %s", args[0], result.Error)
+		}
+		fmt.Println(result.Result)
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(toolsCmd)
 	toolsCmd.AddCommand(toolsListCmd)
+	toolsCmd.AddCommand(toolsCallCmd)
+
+	toolsCallCmd.Flags().StringVar(&toolsCallArgs, "args", "{}", "tool arguments as a JSON object")
+	toolsCallCmd.Flags().StringVarP(&toolsCallAgent, "agent", "a", "", "agent whose tool access rules apply")
+	toolsCallCmd.Flags().StringVar(&toolsCallGateway, "gateway", "", "gateway URL (default http(s)://localhost:<port>)")
 }
 
 // initToolManager creates a tool manager from config. Returns nil if no servers configured.
@@ -101,3 +186,12 @@ func initToolManager(cfg *config.Config) (*toolmgr.Manager, error) {
 
 	return mgr, nil
 }
+
+// localGateway is the URL of the gateway started from cfg on this host.
+func localGateway(cfg *config.Config) string {
+	scheme := "http"
+	if cfg.TLS.Enabled() {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://localhost:%d", scheme, cfg.Port)
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/agent-platform/agix/internal/store"
 	"github.com/agent-platform/agix/internal/ui"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -43,7 +42,7 @@ var traceListCmd = &cobra.Command{
 			return err
 		}
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
@@ -87,7 +86,7 @@ func showTrace(traceID string) error {
 		return err
 	}
 
-	st, err := store.New(cfg.Database)
+	st, err := openStore(cfg)
 	if err != nil {
 		return fmt.Errorf("open database: %w", err)
 	}
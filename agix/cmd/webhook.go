@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/agent-platform/agix/internal/store"
 	"github.com/agent-platform/agix/internal/ui"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -69,7 +68,7 @@ var webhookHistoryCmd = &cobra.Command{
 			return err
 		}
 
-		st, err := store.New(cfg.Database)
+		st, err := openStore(cfg)
 		if err != nil {
 			return fmt.Errorf("open database: %w", err)
 		}
@@ -0,0 +1,275 @@
+// Package admission holds requests the gateway cannot admit yet, because
+// their agent is rate limited or the gateway is at its in-flight limit,
+// instead of rejecting them outright. Waiting requests are retried in
+// priority order, so interactive traffic gets freed capacity before batch
+// traffic.
+package admission
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/loadshed"
+)
+
+// Defaults for Config.
+const (
+	DefaultMaxQueued = 100
+	DefaultMaxWait   = 30 * time.Second
+)
+
+var (
+	// ErrFull is returned when the queue holds MaxQueued requests of the
+	// same or higher priority.
+	ErrFull = errors.New("admission queue is full")
+	// ErrTimeout is returned when a request waited MaxWait without being
+	// admitted.
+	ErrTimeout = errors.New("timed out in admission queue")
+)
+
+// Config bounds the queue and sets agents' priorities.
+type Config struct {
+	MaxQueued       int
+	MaxWait         time.Duration
+	DefaultPriority loadshed.Priority
+	Priorities      map[string]loadshed.Priority
+}
+
+// Try attempts to admit a request. It returns true once admitted; when
+// not, retryAfter is when trying again may succeed, or 0 to try again
+// only when capacity is released (see Queue.Release).
+type Try func() (ok bool, retryAfter time.Duration)
+
+// Queue is a bounded priority queue of requests waiting for admission.
+// It is safe for concurrent use.
+type Queue struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	waiters []*waiter // by priority, highest first, then by arrival
+	timer   *time.Timer
+
+	pumpMu sync.Mutex // serializes attempts
+}
+
+type waiterState int
+
+const (
+	queued waiterState = iota
+	trying
+	admitted
+	gone
+)
+
+type waiter struct {
+	prio   loadshed.Priority
+	try    Try
+	next   time.Time // zero: retry on Release
+	state  waiterState
+	done   chan struct{} // closed when admitted, evicted or abandoned
+	evicts bool
+
+	abandoned bool // Wait gave up during an attempt
+}
+
+// New returns a queue for cfg, filling in defaults.
+func New(cfg Config) *Queue {
+	if cfg.MaxQueued <= 0 {
+		cfg.MaxQueued = DefaultMaxQueued
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = DefaultMaxWait
+	}
+	return &Queue{cfg: cfg, now: time.Now}
+}
+
+// Priority returns the priority of a request from agent: the agent's
+// configured priority, or the default. The X-Priority header can lower it
+// but never raise it, so an agent cannot jump the queue by asking.
+func (q *Queue) Priority(agent, header string) loadshed.Priority {
+	prio, ok := q.cfg.Priorities[agent]
+	if !ok {
+		prio = q.cfg.DefaultPriority
+	}
+	if header != "" {
+		if p, err := loadshed.ParsePriority(strings.ToLower(strings.TrimSpace(header))); err == nil && p.Rank() < prio.Rank() {
+			return p
+		}
+	}
+	return prio
+}
+
+// Wait queues a request that try just rejected, asking to retry after
+// retryAfter, and blocks until try admits it, MaxWait passes or ctx is
+// done. A full queue makes room for a request by evicting the newest
+// request of lower priority.
+func (q *Queue) Wait(ctx context.Context, prio loadshed.Priority, retryAfter time.Duration, try Try) error {
+	w := &waiter{prio: prio, try: try, done: make(chan struct{})}
+	if retryAfter > 0 {
+		w.next = q.now().Add(retryAfter)
+	}
+	if !q.push(w) {
+		return ErrFull
+	}
+	q.schedule()
+
+	timeout := time.NewTimer(q.cfg.MaxWait)
+	defer timeout.Stop()
+	var err error
+	select {
+	case <-w.done:
+	case <-timeout.C:
+		err = ErrTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	q.mu.Lock()
+	if w.state == trying {
+		// An attempt is under way; its outcome decides.
+		w.abandoned = true
+		q.mu.Unlock()
+		<-w.done
+		q.mu.Lock()
+	}
+	state := w.state
+	if state == queued {
+		q.remove(w)
+	}
+	q.mu.Unlock()
+
+	switch {
+	case state == admitted:
+		return nil
+	case w.evicts:
+		return ErrFull
+	}
+	return err
+}
+
+// push inserts w in priority order. It reports false if the queue is full
+// of requests at least as important.
+func (q *Queue) push(w *waiter) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiters) >= q.cfg.MaxQueued {
+		// The newest of the least important waiters sits at the end.
+		last := q.waiters[len(q.waiters)-1]
+		if last.prio.Rank() >= w.prio.Rank() || last.state != queued {
+			return false
+		}
+		last.state, last.evicts = gone, true
+		close(last.done)
+		q.waiters = q.waiters[:len(q.waiters)-1]
+	}
+	i := sort.Search(len(q.waiters), func(i int) bool {
+		return q.waiters[i].prio.Rank() < w.prio.Rank()
+	})
+	q.waiters = append(q.waiters, nil)
+	copy(q.waiters[i+1:], q.waiters[i:])
+	q.waiters[i] = w
+	return true
+}
+
+// remove drops w from the queue. q.mu must be held.
+func (q *Queue) remove(w *waiter) {
+	for i, c := range q.waiters {
+		if c == w {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			break
+		}
+	}
+	w.state = gone
+}
+
+// Len returns the number of waiting requests.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiters)
+}
+
+// Release tells the queue capacity was freed, such as a request
+// finishing, and retries the requests waiting for it.
+func (q *Queue) Release() {
+	q.mu.Lock()
+	n := len(q.waiters)
+	q.mu.Unlock()
+	if n > 0 {
+		go q.pump(true)
+	}
+}
+
+// schedule arms the timer for the earliest timed retry.
+func (q *Queue) schedule() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var next time.Time
+	for _, w := range q.waiters {
+		if !w.next.IsZero() && (next.IsZero() || w.next.Before(next)) {
+			next = w.next
+		}
+	}
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	if next.IsZero() {
+		return
+	}
+	q.timer = time.AfterFunc(max(next.Sub(q.now()), 0), func() { q.pump(false) })
+}
+
+// pump retries due requests in queue order, so a more important request
+// gets capacity first. released also retries requests waiting for
+// capacity to be released.
+func (q *Queue) pump(released bool) {
+	q.pumpMu.Lock()
+	defer q.pumpMu.Unlock()
+
+	q.mu.Lock()
+	now := q.now()
+	var due []*waiter
+	for _, w := range q.waiters {
+		if w.next.IsZero() && released || !w.next.IsZero() && !now.Before(w.next) {
+			due = append(due, w)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, w := range due {
+		q.mu.Lock()
+		if w.state != queued {
+			q.mu.Unlock()
+			continue
+		}
+		w.state = trying
+		q.mu.Unlock()
+
+		ok, retryAfter := w.try()
+
+		q.mu.Lock()
+		switch {
+		case ok:
+			q.remove(w)
+			w.state = admitted
+			close(w.done)
+		case w.abandoned:
+			q.remove(w)
+			close(w.done)
+		default:
+			w.state = queued
+			w.next = time.Time{}
+			if retryAfter > 0 {
+				w.next = q.now().Add(retryAfter)
+			}
+		}
+		q.mu.Unlock()
+	}
+	q.schedule()
+}
@@ -0,0 +1,144 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/loadshed"
+)
+
+// slots is a capacity the queued requests compete for.
+type slots struct {
+	mu    sync.Mutex
+	free  int
+	order []string
+}
+
+func (s *slots) try(name string) Try {
+	return func() (bool, time.Duration) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.free == 0 {
+			return false, 0
+		}
+		s.free--
+		s.order = append(s.order, name)
+		return true, 0
+	}
+}
+
+func (s *slots) add(n int) {
+	s.mu.Lock()
+	s.free += n
+	s.mu.Unlock()
+}
+
+// waitQueued blocks until q holds n requests.
+func waitQueued(t *testing.T, q *Queue, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for q.Len() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Len = %d, want %d", q.Len(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWait_PriorityOrder(t *testing.T) {
+	q := New(Config{})
+	s := &slots{}
+	var wg sync.WaitGroup
+	for _, w := range []struct {
+		name string
+		prio loadshed.Priority
+	}{
+		{"low", loadshed.PriorityLow},
+		{"normal", loadshed.PriorityNormal},
+		{"high", loadshed.PriorityHigh},
+	} {
+		n := q.Len()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := q.Wait(context.Background(), w.prio, 0, s.try(w.name)); err != nil {
+				t.Errorf("%s: %v", w.name, err)
+			}
+		}()
+		waitQueued(t, q, n+1)
+	}
+
+	for range 3 {
+		s.add(1)
+		q.Release()
+		time.Sleep(10 * time.Millisecond)
+	}
+	wg.Wait()
+	if got := s.order; len(got) != 3 || got[0] != "high" || got[1] != "normal" || got[2] != "low" {
+		t.Errorf("admitted %v, want high, normal, low", got)
+	}
+}
+
+func TestWait_RetryAfter(t *testing.T) {
+	q := New(Config{})
+	tries := 0
+	err := q.Wait(context.Background(), loadshed.PriorityNormal, 20*time.Millisecond, func() (bool, time.Duration) {
+		tries++
+		return tries == 2, 10 * time.Millisecond
+	})
+	if err != nil || tries != 2 {
+		t.Errorf("Wait = %v after %d tries, want admitted on the second", err, tries)
+	}
+}
+
+func TestWait_FullAndTimeout(t *testing.T) {
+	q := New(Config{MaxQueued: 1, MaxWait: 50 * time.Millisecond})
+	never := func() (bool, time.Duration) { return false, 0 }
+
+	lowErr := make(chan error)
+	go func() { lowErr <- q.Wait(context.Background(), loadshed.PriorityLow, 0, never) }()
+	waitQueued(t, q, 1)
+
+	if err := q.Wait(context.Background(), loadshed.PriorityLow, 0, never); !errors.Is(err, ErrFull) {
+		t.Errorf("same priority on a full queue: %v, want ErrFull", err)
+	}
+	// A more important request takes the place of the low one
+	if err := q.Wait(context.Background(), loadshed.PriorityHigh, 0, never); !errors.Is(err, ErrTimeout) {
+		t.Errorf("high priority: %v, want ErrTimeout", err)
+	}
+	if err := <-lowErr; !errors.Is(err, ErrFull) {
+		t.Errorf("evicted low priority: %v, want ErrFull", err)
+	}
+	if n := q.Len(); n != 0 {
+		t.Errorf("Len = %d after timeouts, want 0", n)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	q := New(Config{
+		DefaultPriority: loadshed.PriorityLow,
+		Priorities:      map[string]loadshed.Priority{"chat": loadshed.PriorityHigh},
+	})
+	tests := []struct {
+		agent, header string
+		want          loadshed.Priority
+	}{
+		{"batch", "", loadshed.PriorityLow},
+		{"chat", "", loadshed.PriorityHigh},
+		{"chat", "low", loadshed.PriorityLow},
+		{"chat", " Normal", loadshed.PriorityNormal},
+		{"batch", "urgent", loadshed.PriorityLow},
+		// The header only lowers priority
+		{"batch", "high", loadshed.PriorityLow},
+		{"batch", "normal", loadshed.PriorityLow},
+		{"chat", "high", loadshed.PriorityHigh},
+	}
+	for _, tt := range tests {
+		if got := q.Priority(tt.agent, tt.header); got != tt.want {
+			t.Errorf("Priority(%q, %q) = %v, want %v", tt.agent, tt.header, got, tt.want)
+		}
+	}
+}
@@ -2,11 +2,13 @@ package alert
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
 )
 
@@ -14,9 +16,17 @@ import (
 type BudgetStatus struct {
 	DailyPercent   float64
 	MonthlyPercent float64
+	WeeklyPercent  float64
+	// RollingPercent is the highest utilization of the rolling windows.
+	RollingPercent float64
 	Alert          bool
 }
 
+// Peak returns the highest utilization of any budget period.
+func (bs BudgetStatus) Peak() float64 {
+	return max(bs.DailyPercent, bs.MonthlyPercent, bs.WeeklyPercent, bs.RollingPercent)
+}
+
 // ComputeBudgetStatus calculates the current budget utilization.
 func ComputeBudgetStatus(dailySpend, dailyLimit, monthlySpend, monthlyLimit, alertPercent float64) BudgetStatus {
 	bs := BudgetStatus{}
@@ -37,19 +47,214 @@ func ComputeBudgetStatus(dailySpend, dailyLimit, monthlySpend, monthlyLimit, ale
 	return bs
 }
 
-// Alerter sends webhook alerts with deduplication.
+// Level is an escalation step, e.g. "warn" at 80% and "page" at 100%.
+type Level struct {
+	Name             string
+	AtPercent        float64
+	DedupWindow      time.Duration // 0 = use the alerter cooldown
+	BypassQuietHours bool
+}
+
+// Destination is a named webhook and/or email list that receives alerts
+// for selected levels.
+type Destination struct {
+	URL      string
+	Email    []string           // recipients; requires Config.Mailer
+	Levels   []string           // empty = all levels
+	Template *template.Template // webhook body; nil = Config.Template
+}
+
+// accepts reports whether the destination wants alerts for the level.
+func (d Destination) accepts(level string) bool {
+	if len(d.Levels) == 0 {
+		return true
+	}
+	for _, l := range d.Levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// QuietHours is a daily window during which non-bypass alerts are suppressed.
+// Start and End are offsets from local midnight; the window wraps midnight
+// when Start > End (e.g. 22:00–07:00).
+type QuietHours struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// Contains reports whether t falls within the quiet window.
+func (q *QuietHours) Contains(t time.Time) bool {
+	if q == nil || q.Start == q.End {
+		return false
+	}
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	lt := t.In(loc)
+	offset := time.Duration(lt.Hour())*time.Hour + time.Duration(lt.Minute())*time.Minute
+	if q.Start < q.End {
+		return offset >= q.Start && offset < q.End
+	}
+	return offset >= q.Start || offset < q.End
+}
+
+// ParseClock parses an "HH:MM" string into an offset from midnight.
+func ParseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM): %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Config holds alert delivery policy.
+type Config struct {
+	Cooldown     time.Duration          // default dedup window per agent/level/destination
+	Levels       []Level                // escalation levels; empty = single "warn" level at the budget's alert_at_percent
+	QuietHours   *QuietHours            // nil = no quiet hours
+	Destinations map[string]Destination // named destinations referenced by budgets
+	Mailer       *Mailer                // nil = email destinations are skipped
+	Template     *template.Template     // default webhook body; nil = WebhookPayload as JSON
+	DashboardURL string                 // exposed to templates as .DashboardURL
+}
+
+// Alerter sends webhook alerts with deduplication, escalation, and quiet hours.
 type Alerter struct {
 	mu       sync.Mutex
-	lastSent map[string]time.Time // agent → last alert time
+	lastSent map[string]time.Time // dedup key → last alert time
 	cooldown time.Duration
+	cfg      Config
 }
 
 // NewAlerter creates an Alerter with the given cooldown between alerts per agent.
 func NewAlerter(cooldown time.Duration) *Alerter {
+	return New(Config{Cooldown: cooldown})
+}
+
+// New creates an Alerter with the given delivery policy.
+func New(cfg Config) *Alerter {
+	cfg.Levels = append([]Level(nil), cfg.Levels...)
+	sort.SliceStable(cfg.Levels, func(i, j int) bool {
+		return cfg.Levels[i].AtPercent < cfg.Levels[j].AtPercent
+	})
 	return &Alerter{
 		lastSent: make(map[string]time.Time),
-		cooldown: cooldown,
+		cooldown: cfg.Cooldown,
+		cfg:      cfg,
+	}
+}
+
+// Notification describes a budget alert candidate for one agent.
+type Notification struct {
+	Agent          string
+	Percent        float64  // highest of daily/monthly utilization
+	AlertAtPercent float64  // budget's alert_at_percent, used when no levels are configured
+	Webhook        string   // budget's legacy alert_webhook (receives every level)
+	Destinations   []string // names from Config.Destinations
+	Payload        WebhookPayload
+	Now            time.Time
+}
+
+// Escalate returns the highest level reached by percent, or nil if none.
+func (a *Alerter) Escalate(percent, alertAtPercent float64) *Level {
+	levels := a.cfg.Levels
+	if len(levels) == 0 {
+		if alertAtPercent <= 0 {
+			return nil
+		}
+		levels = []Level{{Name: "warn", AtPercent: alertAtPercent}}
+	}
+	var reached *Level
+	for i := range levels {
+		if percent >= levels[i].AtPercent {
+			reached = &levels[i]
+		}
+	}
+	return reached
+}
+
+// Notify escalates, routes, and delivers an alert. It returns the level that
+// was reached (nil if none) and the targets that were actually sent to, after
+// quiet hours and per-level dedup windows were applied. Email targets are
+// reported as "mailto:" plus the comma-separated recipients.
+func (a *Alerter) Notify(n Notification) (*Level, []string) {
+	level := a.Escalate(n.Percent, n.AlertAtPercent)
+	if level == nil {
+		return nil, nil
+	}
+	if n.Now.IsZero() {
+		n.Now = time.Now()
+	}
+	if !level.BypassQuietHours && a.cfg.QuietHours.Contains(n.Now) {
+		return level, nil
+	}
+
+	var urls []string
+	templates := make(map[string]*template.Template)
+	if n.Webhook != "" {
+		urls = append(urls, n.Webhook)
+	}
+	for _, name := range n.Destinations {
+		d, ok := a.cfg.Destinations[name]
+		if !ok {
+			log.Printf("ALERT: unknown destination %q for %s", name, n.Agent)
+			continue
+		}
+		if !d.accepts(level.Name) {
+			continue
+		}
+		if d.URL != "" {
+			urls = append(urls, d.URL)
+			if d.Template != nil && templates[d.URL] == nil {
+				templates[d.URL] = d.Template
+			}
+		}
+		if len(d.Email) > 0 {
+			if a.cfg.Mailer == nil {
+				log.Printf("ALERT: destination %q has email recipients but smtp is not configured", name)
+				continue
+			}
+			urls = append(urls, "mailto:"+strings.Join(d.Email, ","))
+		}
+	}
+
+	window := level.DedupWindow
+	if window <= 0 {
+		window = a.cooldown
 	}
+
+	payload := n.Payload
+	payload.Level = level.Name
+
+	var sent []string
+	seen := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+		key := n.Agent + "|" + level.Name + "|" + url
+		a.mu.Lock()
+		if last, ok := a.lastSent[key]; ok && n.Now.Sub(last) < window {
+			a.mu.Unlock()
+			continue
+		}
+		a.lastSent[key] = n.Now
+		a.mu.Unlock()
+
+		if to, ok := strings.CutPrefix(url, "mailto:"); ok {
+			a.mail(strings.Split(to, ","), n.Agent, payload)
+		} else {
+			a.post(url, n.Agent, payload, templates[url])
+		}
+		sent = append(sent, url)
+	}
+	return level, sent
 }
 
 // WebhookPayload is the JSON body sent to alert webhooks.
@@ -61,9 +266,19 @@ type WebhookPayload struct {
 	MonthlySpend   float64 `json:"monthly_spend_usd"`
 	MonthlyLimit   float64 `json:"monthly_limit_usd"`
 	MonthlyPercent float64 `json:"monthly_percent"`
+	WeeklySpend    float64 `json:"weekly_spend_usd,omitempty"`
+	WeeklyLimit    float64 `json:"weekly_limit_usd,omitempty"`
+	WeeklyPercent  float64 `json:"weekly_percent,omitempty"`
+	RollingPercent float64 `json:"rolling_percent,omitempty"`
+	Level          string  `json:"level,omitempty"`
 	Timestamp      string  `json:"timestamp"`
 }
 
+// Percent returns the highest utilization of any budget period.
+func (p WebhookPayload) Percent() float64 {
+	return max(p.DailyPercent, p.MonthlyPercent, p.WeeklyPercent, p.RollingPercent)
+}
+
 // SendWebhook fires a webhook alert if the cooldown has elapsed for this agent.
 // The call is async (non-blocking).
 func (a *Alerter) SendWebhook(url, agent string, payload WebhookPayload) {
@@ -79,10 +294,19 @@ func (a *Alerter) SendWebhook(url, agent string, payload WebhookPayload) {
 	a.lastSent[agent] = time.Now()
 	a.mu.Unlock()
 
+	a.post(url, agent, payload, nil)
+}
+
+// post delivers the payload asynchronously (non-blocking), rendered with
+// tmpl or, if nil, the default template.
+func (a *Alerter) post(url, agent string, payload WebhookPayload, tmpl *template.Template) {
+	if tmpl == nil {
+		tmpl = a.cfg.Template
+	}
 	go func() {
-		body, err := json.Marshal(payload)
+		body, err := a.renderBody(tmpl, payload)
 		if err != nil {
-			log.Printf("ALERT: failed to marshal webhook payload: %v", err)
+			log.Printf("ALERT: failed to build webhook body for %s: %v", agent, err)
 			return
 		}
 
@@ -99,6 +323,20 @@ func (a *Alerter) SendWebhook(url, agent string, payload WebhookPayload) {
 	}()
 }
 
+// mail delivers the alert as an HTML email asynchronously (non-blocking).
+func (a *Alerter) mail(to []string, agent string, payload WebhookPayload) {
+	go func() {
+		subject, body, err := RenderBudgetAlert(payload)
+		if err != nil {
+			log.Printf("ALERT: %v", err)
+			return
+		}
+		if err := a.cfg.Mailer.Send(to, subject, body); err != nil {
+			log.Printf("ALERT: email failed for %s: %v", agent, err)
+		}
+	}()
+}
+
 // FormatHeaders returns budget headers to add to the response.
 func FormatHeaders(bs BudgetStatus) map[string]string {
 	headers := make(map[string]string)
@@ -108,5 +346,11 @@ func FormatHeaders(bs BudgetStatus) map[string]string {
 	if bs.MonthlyPercent > 0 {
 		headers["X-Budget-Monthly-Percent"] = fmt.Sprintf("%.1f", bs.MonthlyPercent)
 	}
+	if bs.WeeklyPercent > 0 {
+		headers["X-Budget-Weekly-Percent"] = fmt.Sprintf("%.1f", bs.WeeklyPercent)
+	}
+	if bs.RollingPercent > 0 {
+		headers["X-Budget-Rolling-Percent"] = fmt.Sprintf("%.1f", bs.RollingPercent)
+	}
 	return headers
 }
@@ -1,6 +1,8 @@
 package alert
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -96,3 +98,156 @@ func TestAlerter_Cooldown(t *testing.T) {
 	a.SendWebhook("http://example.com/webhook", "agent1", WebhookPayload{Agent: "agent1"})
 	// No real assertion on HTTP call since it's async + test URL, but we verify no panic
 }
+
+func newTestReceiver(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestAlerter_Escalate(t *testing.T) {
+	a := New(Config{Levels: []Level{
+		{Name: "page", AtPercent: 100},
+		{Name: "warn", AtPercent: 80},
+	}})
+
+	tests := []struct {
+		name    string
+		percent float64
+		want    string
+	}{
+		{"below all levels", 50, ""},
+		{"warn", 85, "warn"},
+		{"page", 100, "page"},
+		{"over page", 140, "page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := a.Escalate(tt.percent, 0)
+			name := ""
+			if got != nil {
+				name = got.Name
+			}
+			if name != tt.want {
+				t.Errorf("Escalate(%.0f) = %q, want %q", tt.percent, name, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlerter_EscalateDefaultLevel(t *testing.T) {
+	a := NewAlerter(time.Minute)
+	if got := a.Escalate(85, 80); got == nil || got.Name != "warn" {
+		t.Errorf("Escalate(85, 80) = %v, want warn", got)
+	}
+	if got := a.Escalate(85, 0); got != nil {
+		t.Errorf("Escalate(85, 0) = %v, want nil when alert_at_percent unset", got)
+	}
+}
+
+func TestAlerter_NotifyRoutingAndDedup(t *testing.T) {
+	slack := newTestReceiver(t)
+	pager := newTestReceiver(t)
+	a := New(Config{
+		Cooldown: time.Hour,
+		Levels: []Level{
+			{Name: "warn", AtPercent: 80},
+			{Name: "page", AtPercent: 100, DedupWindow: 10 * time.Minute},
+		},
+		Destinations: map[string]Destination{
+			"slack": {URL: slack},
+			"pager": {URL: pager, Levels: []string{"page"}},
+		},
+	})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	n := Notification{Agent: "a1", Destinations: []string{"slack", "pager"}, Now: now}
+
+	n.Percent = 85
+	level, sent := a.Notify(n)
+	if level == nil || level.Name != "warn" || len(sent) != 1 || sent[0] != slack {
+		t.Fatalf("warn: level=%v sent=%v, want warn → [slack]", level, sent)
+	}
+
+	// Same level within the cooldown is deduplicated.
+	n.Now = now.Add(30 * time.Minute)
+	if _, sent := a.Notify(n); len(sent) != 0 {
+		t.Errorf("duplicate warn sent to %v, want none", sent)
+	}
+
+	// Escalation to page is a new alert type and reaches both destinations.
+	n.Percent = 100
+	if _, sent := a.Notify(n); len(sent) != 2 {
+		t.Errorf("page sent to %v, want slack and pager", sent)
+	}
+
+	// Page has its own shorter dedup window.
+	n.Now = now.Add(45 * time.Minute)
+	if _, sent := a.Notify(n); len(sent) != 2 {
+		t.Errorf("page after dedup window sent to %v, want 2 destinations", sent)
+	}
+}
+
+func TestAlerter_QuietHours(t *testing.T) {
+	url := newTestReceiver(t)
+	a := New(Config{
+		Levels: []Level{
+			{Name: "warn", AtPercent: 80},
+			{Name: "page", AtPercent: 100, BypassQuietHours: true},
+		},
+		QuietHours: &QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour},
+	})
+
+	night := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	if _, sent := a.Notify(Notification{Agent: "a1", Percent: 90, Webhook: url, Now: night}); len(sent) != 0 {
+		t.Errorf("warn during quiet hours sent to %v, want none", sent)
+	}
+	if _, sent := a.Notify(Notification{Agent: "a1", Percent: 100, Webhook: url, Now: night}); len(sent) != 1 {
+		t.Errorf("page during quiet hours sent to %v, want bypass", sent)
+	}
+
+	// A dropped alert is not replayed, but is not deduplicated either.
+	morning := night.Add(8 * time.Hour)
+	if _, sent := a.Notify(Notification{Agent: "a2", Percent: 90, Webhook: url, Now: night}); len(sent) != 0 {
+		t.Errorf("warn during quiet hours sent to %v, want none", sent)
+	}
+	if _, sent := a.Notify(Notification{Agent: "a2", Percent: 90, Webhook: url, Now: morning}); len(sent) != 1 {
+		t.Errorf("warn after quiet hours sent to %v, want 1", sent)
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	tests := []struct {
+		name  string
+		start time.Duration
+		end   time.Duration
+		hour  int
+		want  bool
+	}{
+		{"wrapping window, late night", 22 * time.Hour, 7 * time.Hour, 23, true},
+		{"wrapping window, early morning", 22 * time.Hour, 7 * time.Hour, 6, true},
+		{"wrapping window, daytime", 22 * time.Hour, 7 * time.Hour, 12, false},
+		{"same-day window inside", 12 * time.Hour, 14 * time.Hour, 13, true},
+		{"same-day window end exclusive", 12 * time.Hour, 14 * time.Hour, 14, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &QuietHours{Start: tt.start, End: tt.end}
+			at := time.Date(2026, 3, 1, tt.hour, 0, 0, 0, time.UTC)
+			if got := q.Contains(at); got != tt.want {
+				t.Errorf("Contains(%02d:00) = %v, want %v", tt.hour, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	if d, err := ParseClock("22:30"); err != nil || d != 22*time.Hour+30*time.Minute {
+		t.Errorf("ParseClock(22:30) = %v, %v", d, err)
+	}
+	if _, err := ParseClock("25:00"); err == nil {
+		t.Error("ParseClock(25:00) should fail")
+	}
+}
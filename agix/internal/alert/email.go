@@ -0,0 +1,192 @@
+package alert
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the outgoing mail server used for email destinations.
+type SMTPConfig struct {
+	Host     string
+	Port     int // default 587
+	Username string
+	Password string
+	From     string
+}
+
+// Mailer sends HTML email through an SMTP server.
+type Mailer struct {
+	cfg  SMTPConfig
+	send func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewMailer creates a Mailer. Returns nil if no SMTP host is configured.
+func NewMailer(cfg SMTPConfig) *Mailer {
+	if cfg.Host == "" {
+		return nil
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	return &Mailer{cfg: cfg, send: smtp.SendMail}
+}
+
+// Send delivers an HTML message to the given recipients.
+func (m *Mailer) Send(to []string, subject, htmlBody string) error {
+	if m == nil {
+		return fmt.Errorf("smtp is not configured")
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	msg.WriteString(htmlBody)
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	if err := m.send(addr, auth, m.cfg.From, to, msg.Bytes()); err != nil {
+		return fmt.Errorf("send mail via %s: %w", addr, err)
+	}
+	return nil
+}
+
+const emailLayout = `{{define "layout"}}<!DOCTYPE html>
+<html><body style="font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#1f2328;max-width:640px">
+<h2 style="margin-bottom:4px">{{.Title}}</h2>
+{{template "content" .}}
+<p style="color:#6e7781;font-size:12px;margin-top:24px">Sent by agix.</p>
+</body></html>{{end}}`
+
+var budgetAlertTmpl = template.Must(template.New("budget").Parse(emailLayout + `
+{{define "content"}}<p>Agent <b>{{.Agent}}</b> reached the <b>{{.Level}}</b> budget level.</p>
+<table cellpadding="6" style="border-collapse:collapse">
+<tr><th align="left"></th><th align="right">Spend</th><th align="right">Limit</th><th align="right">Used</th></tr>
+{{if .DailyLimit}}<tr><td>Daily</td><td align="right">${{printf "%.2f" .DailySpend}}</td><td align="right">${{printf "%.2f" .DailyLimit}}</td><td align="right">{{printf "%.1f" .DailyPercent}}%</td></tr>{{end}}
+{{if .MonthlyLimit}}<tr><td>Monthly</td><td align="right">${{printf "%.2f" .MonthlySpend}}</td><td align="right">${{printf "%.2f" .MonthlyLimit}}</td><td align="right">{{printf "%.1f" .MonthlyPercent}}%</td></tr>{{end}}
+{{if .WeeklyLimit}}<tr><td>Weekly</td><td align="right">${{printf "%.2f" .WeeklySpend}}</td><td align="right">${{printf "%.2f" .WeeklyLimit}}</td><td align="right">{{printf "%.1f" .WeeklyPercent}}%</td></tr>{{end}}
+{{if .RollingPercent}}<tr><td>Rolling</td><td></td><td></td><td align="right">{{printf "%.1f" .RollingPercent}}%</td></tr>{{end}}
+</table>
+<p style="color:#6e7781">{{.Timestamp}}</p>{{end}}`))
+
+// RenderBudgetAlert returns the subject and HTML body for a budget alert.
+func RenderBudgetAlert(p WebhookPayload) (string, string, error) {
+	level := p.Level
+	if level == "" {
+		level = "warn"
+	}
+	var buf bytes.Buffer
+	err := budgetAlertTmpl.ExecuteTemplate(&buf, "layout", struct {
+		WebhookPayload
+		Title string
+		Level string
+	}{p, "Budget alert: " + p.Agent, level})
+	if err != nil {
+		return "", "", fmt.Errorf("render budget alert: %w", err)
+	}
+	subject := fmt.Sprintf("[agix] %s: %s at %.0f%% of budget", level, p.Agent, p.Percent())
+	return subject, buf.String(), nil
+}
+
+// DigestRow is one line of a usage digest.
+type DigestRow struct {
+	Name     string
+	Requests int
+	Tokens   int
+	CostUSD  float64
+}
+
+// Digest is a usage summary for a period, e.g. a daily digest.
+type Digest struct {
+	Period   string
+	Requests int
+	Tokens   int
+	CostUSD  float64
+	Agents   []DigestRow
+	Models   []DigestRow
+
+	CostCenters []DigestRow // empty unless a cost center mapping is configured
+}
+
+var digestTmpl = template.Must(template.New("digest").Funcs(template.FuncMap{"rows": digestRows}).Parse(emailLayout + `
+{{define "rows"}}<table cellpadding="6" style="border-collapse:collapse">
+<tr><th align="left">{{.Label}}</th><th align="right">Requests</th><th align="right">Tokens</th><th align="right">Cost</th></tr>
+{{range .Rows}}<tr><td>{{.Name}}</td><td align="right">{{.Requests}}</td><td align="right">{{.Tokens}}</td><td align="right">${{printf "%.4f" .CostUSD}}</td></tr>
+{{end}}</table>{{end}}
+{{define "content"}}<p>{{.Requests}} requests, {{.Tokens}} tokens, <b>${{printf "%.2f" .CostUSD}}</b></p>
+{{if .Agents}}<h3>By agent</h3>{{template "rows" (rows "Agent" .Agents)}}{{end}}
+{{if .Models}}<h3>By model</h3>{{template "rows" (rows "Model" .Models)}}{{end}}
+{{if .CostCenters}}<h3>By cost center</h3>{{template "rows" (rows "Cost center" .CostCenters)}}{{end}}{{end}}`))
+
+func digestRows(label string, rows []DigestRow) any {
+	return struct {
+		Label string
+		Rows  []DigestRow
+	}{label, rows}
+}
+
+// RenderDigest returns the subject and HTML body for a usage digest.
+func RenderDigest(d Digest) (string, string, error) {
+	var buf bytes.Buffer
+	err := digestTmpl.ExecuteTemplate(&buf, "layout", struct {
+		Digest
+		Title string
+	}{d, "Usage digest: " + d.Period})
+	if err != nil {
+		return "", "", fmt.Errorf("render digest: %w", err)
+	}
+	subject := fmt.Sprintf("[agix] %s: $%.2f across %d requests", d.Period, d.CostUSD, d.Requests)
+	return subject, buf.String(), nil
+}
+
+// CheckResult is one line of a health report.
+type CheckResult struct {
+	Status  string // PASS, WARN or FAIL
+	Message string
+}
+
+var reportTmpl = template.Must(template.New("report").Parse(emailLayout + `
+{{define "content"}}<p>{{.Fails}} check(s) failed on <b>{{.Host}}</b>.</p>
+<table cellpadding="6" style="border-collapse:collapse">
+{{range .Results}}<tr><td valign="top"><b style="color:{{if eq .Status "FAIL"}}#cf222e{{else if eq .Status "WARN"}}#9a6700{{else}}#1a7f37{{end}}">{{.Status}}</b></td><td><pre style="margin:0;white-space:pre-wrap">{{.Message}}</pre></td></tr>
+{{end}}</table>{{end}}`))
+
+// RenderHealthReport returns the subject and HTML body for a doctor report.
+func RenderHealthReport(host string, results []CheckResult) (string, string, error) {
+	var fails int
+	for _, r := range results {
+		if r.Status == "FAIL" {
+			fails++
+		}
+	}
+	var buf bytes.Buffer
+	err := reportTmpl.ExecuteTemplate(&buf, "layout", struct {
+		Title   string
+		Host    string
+		Fails   int
+		Results []CheckResult
+	}{"agix doctor: " + host, host, fails, results})
+	if err != nil {
+		return "", "", fmt.Errorf("render health report: %w", err)
+	}
+	subject := fmt.Sprintf("[agix] doctor: %d check(s) failed on %s", fails, host)
+	return subject, buf.String(), nil
+}
@@ -0,0 +1,131 @@
+package alert
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sentMail struct {
+	addr string
+	from string
+	to   []string
+	msg  string
+}
+
+func newTestMailer(t *testing.T) (*Mailer, chan sentMail) {
+	t.Helper()
+	m := NewMailer(SMTPConfig{Host: "smtp.example.com", Username: "agix@example.com", Password: "secret"})
+	ch := make(chan sentMail, 4)
+	m.send = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+		ch <- sentMail{addr, from, to, string(msg)}
+		return nil
+	}
+	return m, ch
+}
+
+func TestNewMailer_Disabled(t *testing.T) {
+	if m := NewMailer(SMTPConfig{}); m != nil {
+		t.Fatal("expected nil mailer without host")
+	}
+	var m *Mailer
+	if err := m.Send([]string{"a@example.com"}, "s", "b"); err == nil {
+		t.Error("expected error from nil mailer")
+	}
+}
+
+func TestMailer_Send(t *testing.T) {
+	m, ch := newTestMailer(t)
+	if err := m.Send([]string{"a@example.com", "b@example.com"}, "hello", "<p>hi</p>"); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	got := <-ch
+	if got.addr != "smtp.example.com:587" || got.from != "agix@example.com" || len(got.to) != 2 {
+		t.Errorf("sent = %+v", got)
+	}
+	for _, want := range []string{"Subject: hello\r\n", "To: a@example.com, b@example.com\r\n", "Content-Type: text/html", "<p>hi</p>"} {
+		if !strings.Contains(got.msg, want) {
+			t.Errorf("message missing %q:\n%s", want, got.msg)
+		}
+	}
+}
+
+func TestAlerter_NotifyEmail(t *testing.T) {
+	m, ch := newTestMailer(t)
+	a := New(Config{
+		Cooldown: time.Hour,
+		Levels:   []Level{{Name: "warn", AtPercent: 80}, {Name: "page", AtPercent: 100}},
+		Destinations: map[string]Destination{
+			"finance": {Email: []string{"cfo@example.com"}, Levels: []string{"page"}},
+		},
+		Mailer: m,
+	})
+	n := Notification{Agent: "a1", Destinations: []string{"finance"}, Now: time.Now(),
+		Payload: WebhookPayload{Agent: "a1", DailySpend: 10, DailyLimit: 10, DailyPercent: 100}}
+
+	n.Percent = 85
+	if _, sent := a.Notify(n); len(sent) != 0 {
+		t.Errorf("warn sent to %v, want none (finance only takes page)", sent)
+	}
+
+	n.Percent = 100
+	_, sent := a.Notify(n)
+	if len(sent) != 1 || sent[0] != "mailto:cfo@example.com" {
+		t.Fatalf("page sent to %v, want mailto:cfo@example.com", sent)
+	}
+	select {
+	case got := <-ch:
+		if !strings.Contains(got.msg, "Subject: [agix] page: a1 at 100% of budget") || !strings.Contains(got.msg, "$10.00") {
+			t.Errorf("unexpected message:\n%s", got.msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("email was not sent")
+	}
+}
+
+func TestAlerter_EmailWithoutMailerSkipped(t *testing.T) {
+	a := New(Config{Destinations: map[string]Destination{"finance": {Email: []string{"cfo@example.com"}}}})
+	_, sent := a.Notify(Notification{Agent: "a1", Percent: 90, AlertAtPercent: 80, Destinations: []string{"finance"}})
+	if len(sent) != 0 {
+		t.Errorf("sent = %v, want none without smtp", sent)
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	subject, body, err := RenderDigest(Digest{
+		Period:   "2026-03-01",
+		Requests: 12,
+		Tokens:   3400,
+		CostUSD:  1.5,
+		Agents:   []DigestRow{{Name: "<script>", Requests: 12, Tokens: 3400, CostUSD: 1.5}},
+	})
+	if err != nil {
+		t.Fatalf("RenderDigest() error: %v", err)
+	}
+	if subject != "[agix] 2026-03-01: $1.50 across 12 requests" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "By agent") || strings.Contains(body, "By model") || strings.Contains(body, "By cost center") {
+		t.Errorf("body sections wrong:\n%s", body)
+	}
+	if strings.Contains(body, "<script>") {
+		t.Error("agent name was not escaped")
+	}
+}
+
+func TestRenderHealthReport(t *testing.T) {
+	subject, body, err := RenderHealthReport("gw-1", []CheckResult{
+		{Status: "PASS", Message: "Database: OK"},
+		{Status: "FAIL", Message: "Models: 1 unknown model(s)"},
+	})
+	if err != nil {
+		t.Fatalf("RenderHealthReport() error: %v", err)
+	}
+	if subject != "[agix] doctor: 1 check(s) failed on gw-1" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "Models: 1 unknown model(s)") {
+		t.Errorf("body missing failure:\n%s", body)
+	}
+}
@@ -0,0 +1,58 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// TemplateData is what webhook body templates are executed against. All
+// WebhookPayload fields are available directly ({{.Agent}}, {{.DailySpend}},
+// {{.Level}}, ...), plus:
+type TemplateData struct {
+	WebhookPayload
+	// Percent is the highest of DailyPercent, MonthlyPercent, WeeklyPercent
+	// and RollingPercent.
+	Percent float64
+	// DashboardURL links to the agix dashboard; empty unless
+	// alerts.dashboard_url is configured.
+	DashboardURL string
+}
+
+// ParseTemplate parses a webhook body template. In addition to the standard
+// template functions, {{json .X}} encodes a value as a JSON literal, which
+// keeps agent names and other strings correctly quoted.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// renderBody returns the webhook body for payload: the template's output if
+// tmpl is set, which must be valid JSON, or the payload itself.
+func (a *Alerter) renderBody(tmpl *template.Template, payload WebhookPayload) ([]byte, error) {
+	if tmpl == nil {
+		return json.Marshal(payload)
+	}
+	var buf bytes.Buffer
+	data := TemplateData{
+		WebhookPayload: payload,
+		Percent:        payload.Percent(),
+		DashboardURL:   a.cfg.DashboardURL,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("render template %s: %w", tmpl.Name(), err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template %s did not produce valid JSON: %s", tmpl.Name(), buf.String())
+	}
+	return buf.Bytes(), nil
+}
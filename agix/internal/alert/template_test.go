@@ -0,0 +1,73 @@
+package alert
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderBody(t *testing.T) {
+	a := New(Config{DashboardURL: "https://agix.example.com/dashboard"})
+	payload := WebhookPayload{Agent: `say "hi"`, DailySpend: 8.5, DailyLimit: 10, DailyPercent: 85, MonthlyPercent: 40, Level: "warn"}
+
+	body, err := a.renderBody(nil, payload)
+	if err != nil || !strings.Contains(string(body), `"daily_spend_usd":8.5`) {
+		t.Errorf("default body = %s, %v", body, err)
+	}
+
+	tmpl, err := ParseTemplate("slack", `{"text": {{json (printf "%s hit %.0f%% (%s)" .Agent .Percent .Level)}}, "link": {{json .DashboardURL}}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error: %v", err)
+	}
+	body, err = a.renderBody(tmpl, payload)
+	want := `{"text": "say \"hi\" hit 85% (warn)", "link": "https://agix.example.com/dashboard"}`
+	if err != nil || string(body) != want {
+		t.Errorf("templated body = %s, %v; want %s", body, err, want)
+	}
+
+	bad, _ := ParseTemplate("bad", `{"text": "{{.Agent}}"}`)
+	if _, err := a.renderBody(bad, payload); err == nil || !strings.Contains(err.Error(), "valid JSON") {
+		t.Errorf("unquoted agent name: err = %v, want invalid JSON", err)
+	}
+	if _, err := ParseTemplate("broken", `{{.Agent`); err == nil {
+		t.Error("ParseTemplate() accepted a malformed template")
+	}
+}
+
+func TestAlerter_NotifyTemplates(t *testing.T) {
+	bodies := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- r.URL.Path + " " + string(b)
+	}))
+	defer srv.Close()
+
+	custom, _ := ParseTemplate("custom", `{"who":{{json .Agent}}}`)
+	fallback, _ := ParseTemplate("default", `{"agent":{{json .Agent}},"level":{{json .Level}}}`)
+	a := New(Config{
+		Template: fallback,
+		Destinations: map[string]Destination{
+			"custom": {URL: srv.URL + "/custom", Template: custom},
+		},
+	})
+	a.Notify(Notification{
+		Agent: "bot", Percent: 90, AlertAtPercent: 80, Payload: WebhookPayload{Agent: "bot"},
+		Webhook: srv.URL + "/legacy", Destinations: []string{"custom"},
+	})
+
+	got := make(map[string]bool)
+	for range 2 {
+		select {
+		case b := <-bodies:
+			got[b] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out; got %v", got)
+		}
+	}
+	if !got[`/legacy {"agent":"bot","level":"warn"}`] || !got[`/custom {"who":"bot"}`] {
+		t.Errorf("bodies = %v", got)
+	}
+}
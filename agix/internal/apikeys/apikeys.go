@@ -0,0 +1,301 @@
+// Package apikeys issues and checks the bearer keys agents use to call
+// agix. A key is bound to one agent, so attribution, budgets and rate
+// limits follow the key instead of the caller-supplied X-Agent-Name.
+// Only a SHA-256 hash of each key is stored.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Prefix starts every key, so agix keys are told apart from the dummy
+// provider keys SDKs send.
+const Prefix = "agix-"
+
+// displayLen is how much of a key is kept in clear to identify it.
+const displayLen = len(Prefix) + 8
+
+const (
+	// cacheTTL is how long a validated key is trusted before it is read
+	// again, which bounds how long a revocation made by another process
+	// takes to apply.
+	cacheTTL = 30 * time.Second
+	// touchInterval limits last_used_at writes to one per key per minute.
+	touchInterval = time.Minute
+)
+
+// Errors returned by Authenticate.
+var (
+	ErrUnknownKey = errors.New("invalid API key")
+	ErrRevoked    = errors.New("API key has been revoked")
+)
+
+// Key is an issued key. The secret itself is only returned by Create.
+type Key struct {
+	ID         int64     `json:"id"`
+	Prefix     string    `json:"prefix"` // first characters of the key, e.g. agix-1a2b3c4d
+	Agent      string    `json:"agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"` // zero if never used
+	RevokedAt  time.Time `json:"revoked_at"`   // zero while active
+}
+
+// Revoked reports whether the key has been revoked.
+func (k *Key) Revoked() bool { return !k.RevokedAt.IsZero() }
+
+// Keys stores keys in the api_keys table.
+type Keys struct {
+	db      *sql.DB
+	dialect store.Dialect
+
+	mu    sync.Mutex
+	cache map[string]*cached // by key hash
+}
+
+type cached struct {
+	key    *Key // nil for an unknown key
+	loaded time.Time
+}
+
+// New creates the api_keys table if needed.
+func New(db *sql.DB, dialect store.Dialect) (*Keys, error) {
+	if err := createTable(db, dialect); err != nil {
+		return nil, fmt.Errorf("create api_keys table: %w", err)
+	}
+	return &Keys{db: db, dialect: dialect, cache: map[string]*cached{}}, nil
+}
+
+func createTable(db *sql.DB, dialect store.Dialect) error {
+	id, text, empty := "INTEGER PRIMARY KEY AUTOINCREMENT", "TEXT", "''"
+	hash := "TEXT"
+	switch dialect {
+	case store.DialectPostgres:
+		id = "BIGSERIAL PRIMARY KEY"
+	case store.DialectMySQL:
+		// MySQL cannot index TEXT and only takes expression defaults on it.
+		id, text, empty, hash = "BIGINT AUTO_INCREMENT PRIMARY KEY", "VARCHAR(255)", "''", "CHAR(64)"
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS api_keys (
+		id           ` + id + `,
+		key_hash     ` + hash + ` NOT NULL UNIQUE,
+		prefix       ` + text + ` NOT NULL,
+		agent_name   ` + text + ` NOT NULL,
+		created_at   ` + text + ` NOT NULL,
+		last_used_at ` + text + ` NOT NULL DEFAULT ` + empty + `,
+		revoked_at   ` + text + ` NOT NULL DEFAULT ` + empty + `
+	)`)
+	return err
+}
+
+func hashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create issues a key for agent and returns the secret, which is not
+// stored and cannot be shown again.
+func (k *Keys) Create(agent string) (string, *Key, error) {
+	if agent == "" {
+		return "", nil, fmt.Errorf("agent name is required")
+	}
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	secret := Prefix + hex.EncodeToString(b)
+	key := &Key{Prefix: secret[:displayLen], Agent: agent, CreatedAt: time.Now().UTC()}
+
+	ts := key.CreatedAt.Format(time.RFC3339)
+	var err error
+	if k.dialect == store.DialectPostgres {
+		err = k.db.QueryRow(
+			`INSERT INTO api_keys (key_hash, prefix, agent_name, created_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+			hashKey(secret), key.Prefix, key.Agent, ts,
+		).Scan(&key.ID)
+	} else {
+		var res sql.Result
+		res, err = k.db.Exec(
+			`INSERT INTO api_keys (key_hash, prefix, agent_name, created_at) VALUES (?, ?, ?, ?)`,
+			hashKey(secret), key.Prefix, key.Agent, ts,
+		)
+		if err == nil {
+			key.ID, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("create API key: %w", err)
+	}
+	return secret, key, nil
+}
+
+// List returns keys, newest first. Revoked keys are included only if all
+// is set.
+func (k *Keys) List(all bool) ([]Key, error) {
+	where := `WHERE revoked_at = ''`
+	if all {
+		where = ""
+	}
+	return k.query(where + ` ORDER BY id DESC`)
+}
+
+// Revoke revokes the key with the given ID or display prefix and returns
+// it. Revoking an already revoked key is not an error.
+func (k *Keys) Revoke(idOrPrefix string) (*Key, error) {
+	var keys []Key
+	var err error
+	if id, perr := strconv.ParseInt(idOrPrefix, 10, 64); perr == nil {
+		keys, err = k.query(`WHERE id = ?`, id)
+	} else {
+		keys, err = k.query(`WHERE prefix = ?`, idOrPrefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch len(keys) {
+	case 0:
+		return nil, fmt.Errorf("no API key %q", idOrPrefix)
+	case 1:
+	default:
+		return nil, fmt.Errorf("%q matches %d keys; revoke by ID", idOrPrefix, len(keys))
+	}
+	key := keys[0]
+	if key.Revoked() {
+		return &key, nil
+	}
+
+	key.RevokedAt = time.Now().UTC()
+	if _, err := k.db.Exec(store.Rebind(k.dialect, `UPDATE api_keys SET revoked_at = ? WHERE id = ?`),
+		key.RevokedAt.Format(time.RFC3339), key.ID); err != nil {
+		return nil, fmt.Errorf("revoke API key: %w", err)
+	}
+	k.mu.Lock()
+	for h, c := range k.cache {
+		if c.key != nil && c.key.ID == key.ID {
+			delete(k.cache, h)
+		}
+	}
+	k.mu.Unlock()
+	return &key, nil
+}
+
+// Authenticate returns the key for secret and records its use.
+func (k *Keys) Authenticate(secret string) (*Key, error) {
+	h := hashKey(secret)
+	now := time.Now().UTC()
+
+	k.mu.Lock()
+	c, ok := k.cache[h]
+	k.mu.Unlock()
+	if !ok || now.Sub(c.loaded) > cacheTTL {
+		keys, err := k.query(`WHERE key_hash = ?`, h)
+		if err != nil {
+			return nil, err
+		}
+		c = &cached{loaded: now}
+		if len(keys) == 1 {
+			c.key = &keys[0]
+		}
+		k.mu.Lock()
+		k.cache[h] = c
+		k.mu.Unlock()
+	}
+
+	if c.key == nil {
+		return nil, ErrUnknownKey
+	}
+	k.mu.Lock()
+	key := *c.key
+	touch := now.Sub(key.LastUsedAt) >= touchInterval
+	if touch {
+		c.key.LastUsedAt = now
+	}
+	k.mu.Unlock()
+	if key.Revoked() {
+		return nil, ErrRevoked
+	}
+	if touch {
+		if _, err := k.db.Exec(store.Rebind(k.dialect, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`),
+			now.Format(time.RFC3339), key.ID); err != nil {
+			return nil, fmt.Errorf("record API key use: %w", err)
+		}
+		key.LastUsedAt = now
+	}
+	return &key, nil
+}
+
+func (k *Keys) query(where string, args ...any) ([]Key, error) {
+	rows, err := k.db.Query(store.Rebind(k.dialect,
+		`SELECT id, prefix, agent_name, created_at, last_used_at, revoked_at FROM api_keys `+where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		var key Key
+		var created, used, revoked string
+		if err := rows.Scan(&key.ID, &key.Prefix, &key.Agent, &created, &used, &revoked); err != nil {
+			return nil, fmt.Errorf("scan API key: %w", err)
+		}
+		key.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		key.LastUsedAt, _ = time.Parse(time.RFC3339, used)
+		key.RevokedAt, _ = time.Parse(time.RFC3339, revoked)
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// FromRequest returns the agix key a request carries in
+// "Authorization: Bearer" (OpenAI SDKs) or x-api-key (Anthropic SDKs), or
+// "" if it carries none.
+func FromRequest(r *http.Request) string {
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.HasPrefix(v, Prefix) {
+		return strings.TrimSpace(v)
+	}
+	if v := r.Header.Get("x-api-key"); strings.HasPrefix(v, Prefix) {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// InternalHeader marks requests agix sends to itself (summaries,
+// webhooks, the outage queue). Its value is a random token, see
+// NewInternalToken, that lives only in the gateway process.
+const InternalHeader = "X-Agix-Internal"
+
+// NewInternalToken returns a random token for InternalHeader.
+func NewInternalToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate internal token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// MarkInternal lets req through key checks on the gateway holding token.
+func MarkInternal(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set(InternalHeader, token)
+	}
+}
+
+// IsInternal reports whether r carries token, i.e. was sent by the
+// gateway itself. An empty token matches nothing.
+func IsInternal(r *http.Request, token string) bool {
+	v := r.Header.Get(InternalHeader)
+	return v != "" && token != "" && subtle.ConstantTimeCompare([]byte(v), []byte(token)) == 1
+}
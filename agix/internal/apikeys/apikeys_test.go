@@ -0,0 +1,106 @@
+package apikeys
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/store"
+	_ "modernc.org/sqlite"
+)
+
+func testKeys(t *testing.T) *Keys {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	k, err := New(db, store.DialectSQLite)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return k
+}
+
+func TestCreateAuthenticateRevoke(t *testing.T) {
+	k := testKeys(t)
+
+	secret, key, err := k.Create("code-reviewer")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !strings.HasPrefix(secret, Prefix) || !strings.HasPrefix(secret, key.Prefix) || len(key.Prefix) != displayLen {
+		t.Errorf("secret %q, prefix %q", secret, key.Prefix)
+	}
+
+	got, err := k.Authenticate(secret)
+	if err != nil || got.Agent != "code-reviewer" || got.ID != key.ID {
+		t.Fatalf("Authenticate = %+v, %v", got, err)
+	}
+	list, _ := k.List(false)
+	if len(list) != 1 || list[0].LastUsedAt.IsZero() {
+		t.Errorf("List = %+v, want one key with last_used_at set", list)
+	}
+
+	if _, err := k.Authenticate(Prefix + "0000"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key: err = %v", err)
+	}
+
+	if _, err := k.Revoke(key.Prefix); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := k.Authenticate(secret); !errors.Is(err, ErrRevoked) {
+		t.Errorf("revoked key: err = %v", err)
+	}
+	if list, _ := k.List(false); len(list) != 0 {
+		t.Errorf("List(false) = %d keys after revoke", len(list))
+	}
+	if list, _ := k.List(true); len(list) != 1 || !list[0].Revoked() {
+		t.Errorf("List(true) = %+v", list)
+	}
+	if _, err := k.Revoke("999"); err == nil {
+		t.Error("Revoke of a missing key succeeded")
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		header, value, want string
+	}{
+		{"Authorization", "Bearer agix-abc", "agix-abc"},
+		{"x-api-key", "agix-abc", "agix-abc"},
+		{"Authorization", "Bearer sk-placeholder", ""},
+		{"x-api-key", "sk-ant-placeholder", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+		r.Header.Set(tt.header, tt.value)
+		if got := FromRequest(r); got != tt.want {
+			t.Errorf("FromRequest(%s: %s) = %q, want %q", tt.header, tt.value, got, tt.want)
+		}
+	}
+
+	token, err := NewInternalToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	if IsInternal(r, token) {
+		t.Error("unmarked request is internal")
+	}
+	r.Header.Set(InternalHeader, "guess")
+	if IsInternal(r, token) {
+		t.Error("request with a wrong token is internal")
+	}
+	MarkInternal(r, token)
+	if !IsInternal(r, token) {
+		t.Error("marked request is not internal")
+	}
+	if IsInternal(r, "") {
+		t.Error("request is internal to a gateway without a token")
+	}
+}
@@ -0,0 +1,172 @@
+// Package archive stores full request and response bodies outside the SQL
+// store, gzipped and content-addressed, in a local directory or an
+// S3-compatible bucket. The request row keeps only the key.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// Backend stores archived objects by key.
+type Backend interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Entry is one archived exchange. Bodies are kept verbatim; a streamed
+// response is the upstream SSE stream.
+type Entry struct {
+	Request  string `json:"request"`
+	Response string `json:"response"`
+}
+
+const (
+	queueSize    = 256
+	writeTimeout = 30 * time.Second
+)
+
+// Archiver writes entries to a Backend in the background. Identical
+// exchanges share one object.
+type Archiver struct {
+	backend Backend
+	ch      chan job
+
+	mu      sync.Mutex
+	dropped int
+
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type job struct {
+	key  string
+	data []byte
+}
+
+// New creates an Archiver. Nothing is written until Start is called.
+func New(backend Backend) *Archiver {
+	return &Archiver{
+		backend: backend,
+		ch:      make(chan job, queueSize),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start launches the background writer.
+func (a *Archiver) Start() {
+	a.started = true
+	go a.run()
+}
+
+// Archive queues the exchange and returns its key. The key is returned
+// even if the write later fails; a full queue drops the entry and
+// returns "".
+func (a *Archiver) Archive(request, response []byte) string {
+	data, err := json.Marshal(Entry{Request: string(request), Response: string(response)})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+	select {
+	case a.ch <- job{key: key, data: data}:
+		return key
+	default:
+		a.mu.Lock()
+		a.dropped++
+		a.mu.Unlock()
+		return ""
+	}
+}
+
+// Dropped returns the number of entries that were not written.
+func (a *Archiver) Dropped() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.dropped
+}
+
+// Close writes queued entries and stops the writer.
+func (a *Archiver) Close() {
+	a.closeOnce.Do(func() {
+		close(a.ch)
+		if a.started {
+			<-a.done
+		}
+	})
+}
+
+func (a *Archiver) run() {
+	defer close(a.done)
+	for j := range a.ch {
+		if err := a.write(j); err != nil {
+			log.Printf("WARN: archive %s: %v", j.key, err)
+			a.mu.Lock()
+			a.dropped++
+			a.mu.Unlock()
+		}
+	}
+}
+
+func (a *Archiver) write(j job) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(j.data); err != nil {
+		return fmt.Errorf("compress: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compress: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	return a.backend.Put(ctx, ObjectName(j.key), buf.Bytes())
+}
+
+// Get reads and decompresses the entry stored under key.
+func Get(ctx context.Context, backend Backend, key string) (*Entry, error) {
+	if !validKey(key) {
+		return nil, fmt.Errorf("invalid archive key %q", key)
+	}
+	data, err := backend.Get(ctx, ObjectName(key))
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompress: %w", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompress: %w", err)
+	}
+	var e Entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, fmt.Errorf("decode entry: %w", err)
+	}
+	return &e, nil
+}
+
+// ObjectName is the object path for key, fanned out by its first two
+// characters to keep directories small.
+func ObjectName(key string) string {
+	return key[:2] + "/" + key + ".json.gz"
+}
+
+func validKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
@@ -0,0 +1,134 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestArchiveDir(t *testing.T) {
+	root := t.TempDir()
+	dir, err := NewDir(root)
+	if err != nil {
+		t.Fatalf("NewDir: %v", err)
+	}
+	a := New(dir)
+	a.Start()
+
+	req := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	resp := []byte(`{"choices":[{"message":{"content":"hello"}}]}`)
+	key := a.Archive(req, resp)
+	if again := a.Archive(req, resp); again != key {
+		t.Errorf("identical exchange got key %q, want %q", again, key)
+	}
+	other := a.Archive(req, []byte(`{"choices":[]}`))
+	if other == key || other == "" {
+		t.Errorf("different exchange got key %q", other)
+	}
+	a.Close()
+
+	if _, err := os.Stat(filepath.Join(root, key[:2], key+".json.gz")); err != nil {
+		t.Errorf("archived object missing: %v", err)
+	}
+	e, err := Get(context.Background(), dir, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if e.Request != string(req) || e.Response != string(resp) {
+		t.Errorf("Get() = %+v", e)
+	}
+	if _, err := Get(context.Background(), dir, "../../etc/passwd"); err == nil {
+		t.Error("Get() accepted an invalid key")
+	}
+}
+
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	auth    string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization")
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+func TestArchiveS3(t *testing.T) {
+	fs := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fs)
+	defer srv.Close()
+
+	s3, err := NewS3(S3Config{
+		Endpoint:        srv.URL,
+		Bucket:          "logs",
+		Prefix:          "agix/",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	a := New(s3)
+	a.Start()
+	key := a.Archive([]byte("req"), []byte("data: {}\n\ndata: [DONE]\n"))
+	a.Close()
+
+	fs.mu.Lock()
+	_, stored := fs.objects["/logs/agix/"+ObjectName(key)]
+	auth := fs.auth
+	fs.mu.Unlock()
+	if !stored {
+		t.Fatalf("object not stored under bucket and prefix: %v", fs.objects)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/us-east-1/s3/aws4_request") {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	e, err := Get(context.Background(), s3, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if e.Request != "req" || !strings.Contains(e.Response, "[DONE]") {
+		t.Errorf("Get() = %+v", e)
+	}
+	missing := strings.Repeat("0", 64)
+	if _, err := Get(context.Background(), s3, missing); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Get(missing) error = %v", err)
+	}
+}
+
+func TestNewS3(t *testing.T) {
+	if _, err := NewS3(S3Config{}); err == nil {
+		t.Error("NewS3 without bucket succeeded")
+	}
+	s3, err := NewS3(S3Config{Bucket: "logs", Region: "eu-west-1"})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	if got := s3.base.String(); got != "https://logs.s3.eu-west-1.amazonaws.com" {
+		t.Errorf("virtual-hosted base = %q", got)
+	}
+	if got := uriEncodePath("/a b/c+d"); got != "/a%20b/c%2Bd" {
+		t.Errorf("uriEncodePath = %q", got)
+	}
+}
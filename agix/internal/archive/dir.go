@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir stores objects as files under a local directory.
+type Dir struct {
+	root string
+}
+
+// NewDir creates root if needed.
+func NewDir(root string) (*Dir, error) {
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("create archive dir: %w", err)
+	}
+	return &Dir{root: root}, nil
+}
+
+// Put writes data to key unless it already exists: keys are content
+// hashes, so an existing file already holds the same bytes.
+func (d *Dir) Put(_ context.Context, key string, data []byte) error {
+	path := filepath.Join(d.root, filepath.FromSlash(key))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create archive dir: %w", err)
+	}
+	// Write to a temp file and rename so readers never see partial objects.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write archive: %w", err)
+	}
+	return nil
+}
+
+// Get reads key.
+func (d *Dir) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(d.root, filepath.FromSlash(key)))
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	return data, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/agent-platform/agix/internal/store"
@@ -13,10 +14,14 @@ import (
 
 // EventType constants for audit events.
 const (
-	EventToolCall      = "tool_call"
-	EventFirewallBlock = "firewall_block"
-	EventFirewallWarn  = "firewall_warn"
-	EventContentLog    = "content_log"
+	EventToolCall       = "tool_call"
+	EventToolLoop       = "tool_loop"
+	EventFirewallBlock  = "firewall_block"
+	EventFirewallWarn   = "firewall_warn"
+	EventContentLog     = "content_log"
+	EventPayloadCapture = "payload_capture"
+	EventPenalty        = "penalty"
+	EventAdmin          = "admin"
 )
 
 // Event represents a single audit event.
@@ -25,6 +30,7 @@ type Event struct {
 	Timestamp time.Time       `json:"timestamp"`
 	EventType string          `json:"event_type"`
 	AgentName string          `json:"agent_name"`
+	RequestID string          `json:"request_id,omitempty"`
 	Details   json.RawMessage `json:"details"`
 }
 
@@ -38,6 +44,15 @@ type ToolCallDetails struct {
 	Args       string `json:"args,omitempty"`
 }
 
+// ToolLoopDetails holds details for tool_loop events: a tool loop stopped
+// because the model kept repeating the same call.
+type ToolLoopDetails struct {
+	Tool      string `json:"tool"`
+	Repeats   int    `json:"repeats"`
+	Iteration int    `json:"iteration"`
+	Args      string `json:"args,omitempty"`
+}
+
 // FirewallDetails holds details for firewall_block and firewall_warn events.
 type FirewallDetails struct {
 	Rule     string `json:"rule"`
@@ -45,13 +60,57 @@ type FirewallDetails struct {
 	Excerpt  string `json:"excerpt"`
 }
 
+// PenaltyDetails holds details for penalty events.
+type PenaltyDetails struct {
+	Reason    string `json:"reason"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// AdminDetails holds details for admin events: a write made through the
+// admin API.
+type AdminDetails struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+	Remote string `json:"remote"`
+}
+
 // ContentLogDetails holds details for content_log events.
 type ContentLogDetails struct {
 	Direction string `json:"direction"`
 	Model     string `json:"model"`
+	SessionID string `json:"session_id,omitempty"`
 	Body      string `json:"body"`
 }
 
+// Legal hold kinds.
+const (
+	HoldAgent   = "agent"
+	HoldSession = "session"
+)
+
+// Hold exempts an agent's or session's audit events from retention pruning.
+type Hold struct {
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PayloadStage is a snapshot of the request body after one pipeline stage.
+type PayloadStage struct {
+	Stage string `json:"stage"`
+	Body  string `json:"body"`
+}
+
+// PayloadCaptureDetails holds details for payload_capture events: the request
+// body as the agent sent it and after every stage that modified it.
+type PayloadCaptureDetails struct {
+	RequestID string         `json:"request_id"`
+	Model     string         `json:"model"`
+	Stages    []PayloadStage `json:"stages"`
+}
+
 // Logger writes audit events to the database asynchronously.
 type Logger struct {
 	db      *sql.DB
@@ -59,6 +118,8 @@ type Logger struct {
 	enabled bool
 	eventCh chan *Event
 	done    chan struct{}
+
+	retentionStop chan struct{}
 }
 
 // New creates a new audit Logger. If not enabled, Log calls are no-ops.
@@ -78,6 +139,12 @@ func New(db *sql.DB, enabled bool, dialect store.Dialect) *Logger {
 
 // Log records an audit event asynchronously. No-op if disabled.
 func (l *Logger) Log(eventType, agentName string, details any) {
+	l.LogRequest(eventType, agentName, "", details)
+}
+
+// LogRequest is Log for an event raised while handling a request, tagged
+// with its request ID.
+func (l *Logger) LogRequest(eventType, agentName, requestID string, details any) {
 	if !l.enabled {
 		return
 	}
@@ -92,6 +159,7 @@ func (l *Logger) Log(eventType, agentName string, details any) {
 		Timestamp: time.Now().UTC(),
 		EventType: eventType,
 		AgentName: agentName,
+		RequestID: requestID,
 		Details:   detailsJSON,
 	}
 
@@ -112,7 +180,7 @@ func (l *Logger) DB() *sql.DB {
 
 // QueryRecent returns recent audit events, optionally filtered.
 func (l *Logger) QueryRecent(limit int, eventType, agentFilter string) ([]Event, error) {
-	query := `SELECT id, timestamp, event_type, agent_name, details FROM audit_events`
+	query := `SELECT id, timestamp, event_type, agent_name, request_id, details FROM audit_events`
 	var conditions []string
 	var args []any
 
@@ -148,7 +216,35 @@ func (l *Logger) QueryRecent(limit int, eventType, agentFilter string) ([]Event,
 	for rows.Next() {
 		var e Event
 		var ts, details string
-		if err := rows.Scan(&e.ID, &ts, &e.EventType, &e.AgentName, &details); err != nil {
+		if err := rows.Scan(&e.ID, &ts, &e.EventType, &e.AgentName, &e.RequestID, &details); err != nil {
+			return nil, fmt.Errorf("scan audit event: %w", err)
+		}
+		e.Timestamp, _ = time.Parse("2006-01-02T15:04:05Z", ts)
+		e.Details = json.RawMessage(details)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// QueryByRequestID returns the audit events raised while handling one
+// request, oldest first.
+func (l *Logger) QueryByRequestID(requestID string) ([]Event, error) {
+	rows, err := l.db.Query(
+		store.Rebind(l.dialect, `SELECT id, timestamp, event_type, agent_name, request_id, details FROM audit_events
+		 WHERE request_id = ?
+		 ORDER BY id ASC`),
+		requestID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query audit events by request ID: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var ts, details string
+		if err := rows.Scan(&e.ID, &ts, &e.EventType, &e.AgentName, &e.RequestID, &details); err != nil {
 			return nil, fmt.Errorf("scan audit event: %w", err)
 		}
 		e.Timestamp, _ = time.Parse("2006-01-02T15:04:05Z", ts)
@@ -158,8 +254,213 @@ func (l *Logger) QueryRecent(limit int, eventType, agentFilter string) ([]Event,
 	return events, rows.Err()
 }
 
+// QueryPayloadCapture returns the payload_capture event recorded for a request
+// ID along with its decoded details. Both are nil if no capture exists.
+func (l *Logger) QueryPayloadCapture(requestID string) (*Event, *PayloadCaptureDetails, error) {
+	var e Event
+	var ts, details string
+	err := l.db.QueryRow(
+		store.Rebind(l.dialect, `SELECT id, timestamp, event_type, agent_name, request_id, details FROM audit_events
+		 WHERE event_type = ? AND request_id = ?
+		 ORDER BY timestamp DESC LIMIT 1`),
+		EventPayloadCapture, requestID,
+	).Scan(&e.ID, &ts, &e.EventType, &e.AgentName, &e.RequestID, &details)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("query payload capture: %w", err)
+	}
+	var d PayloadCaptureDetails
+	if err := json.Unmarshal([]byte(details), &d); err != nil {
+		return nil, nil, fmt.Errorf("decode payload capture: %w", err)
+	}
+	e.Timestamp, _ = time.Parse("2006-01-02T15:04:05Z", ts)
+	e.Details = json.RawMessage(details)
+	return &e, &d, nil
+}
+
+// Search returns content_log events whose body matches all words of query,
+// newest first, optionally filtered by agent. Uses SQLite FTS5 or a
+// PostgreSQL tsvector index; MySQL scans the content logs with LIKE.
+func (l *Logger) Search(query, agentFilter string, limit int) ([]Event, error) {
+	var q string
+	var args []any
+	switch l.dialect {
+	case store.DialectPostgres:
+		q = `SELECT id, timestamp, event_type, agent_name, request_id, details FROM audit_events
+		 WHERE event_type = ? AND to_tsvector('simple', (details::jsonb)->>'body') @@ plainto_tsquery('simple', ?)`
+		args = append(args, EventContentLog, query)
+	case store.DialectMySQL:
+		words := strings.Fields(strings.ToLower(query))
+		if len(words) == 0 {
+			return nil, nil
+		}
+		q = `SELECT id, timestamp, event_type, agent_name, request_id, details FROM audit_events WHERE event_type = ?`
+		args = append(args, EventContentLog)
+		for _, w := range words {
+			q += ` AND LOWER(JSON_UNQUOTE(JSON_EXTRACT(details, '$.body'))) LIKE ?`
+			args = append(args, "%"+likeEscaper.Replace(w)+"%")
+		}
+	default:
+		match := ftsQuery(query)
+		if match == "" {
+			return nil, nil
+		}
+		q = `SELECT e.id, e.timestamp, e.event_type, e.agent_name, e.request_id, e.details FROM audit_content_fts f
+		 JOIN audit_events e ON e.id = f.rowid
+		 WHERE audit_content_fts MATCH ?`
+		args = append(args, match)
+	}
+	if agentFilter != "" {
+		q += " AND agent_name = ?"
+		args = append(args, agentFilter)
+	}
+	q += " ORDER BY timestamp DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := l.db.Query(store.Rebind(l.dialect, q), args...)
+	if err != nil {
+		return nil, fmt.Errorf("search audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var ts, details string
+		if err := rows.Scan(&e.ID, &ts, &e.EventType, &e.AgentName, &e.RequestID, &details); err != nil {
+			return nil, fmt.Errorf("scan audit event: %w", err)
+		}
+		e.Timestamp, _ = time.Parse("2006-01-02T15:04:05Z", ts)
+		e.Details = json.RawMessage(details)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// likeEscaper escapes LIKE wildcards with MySQL's default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ftsQuery turns free text into an FTS5 query matching every word, quoting
+// each so operators and punctuation in user input are taken literally.
+func ftsQuery(query string) string {
+	words := strings.Fields(query)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+// AddHold places an agent or session on legal hold. Adding an existing hold
+// updates its reason.
+func (l *Logger) AddHold(kind, value, reason string) error {
+	if kind != HoldAgent && kind != HoldSession {
+		return fmt.Errorf("unknown hold kind %q (want agent or session)", kind)
+	}
+	if value == "" {
+		return fmt.Errorf("%s name required", kind)
+	}
+	upsert := ` ON CONFLICT (kind, value) DO UPDATE SET reason = excluded.reason`
+	if l.dialect == store.DialectMySQL {
+		upsert = ` ON DUPLICATE KEY UPDATE reason = VALUES(reason)`
+	}
+	_, err := l.db.Exec(
+		store.Rebind(l.dialect, `INSERT INTO legal_holds (timestamp, kind, value, reason) VALUES (?, ?, ?, ?)`+upsert),
+		time.Now().UTC().Format("2006-01-02T15:04:05Z"), kind, value, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("add legal hold: %w", err)
+	}
+	return nil
+}
+
+// RemoveHold releases a legal hold. Reports whether a hold existed.
+func (l *Logger) RemoveHold(kind, value string) (bool, error) {
+	res, err := l.db.Exec(store.Rebind(l.dialect, `DELETE FROM legal_holds WHERE kind = ? AND value = ?`), kind, value)
+	if err != nil {
+		return false, fmt.Errorf("remove legal hold: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListHolds returns all active legal holds.
+func (l *Logger) ListHolds() ([]Hold, error) {
+	rows, err := l.db.Query(`SELECT kind, value, reason, timestamp FROM legal_holds ORDER BY kind, value`)
+	if err != nil {
+		return nil, fmt.Errorf("list legal holds: %w", err)
+	}
+	defer rows.Close()
+
+	var holds []Hold
+	for rows.Next() {
+		var h Hold
+		var ts string
+		if err := rows.Scan(&h.Kind, &h.Value, &h.Reason, &ts); err != nil {
+			return nil, fmt.Errorf("scan legal hold: %w", err)
+		}
+		h.Timestamp, _ = time.Parse("2006-01-02T15:04:05Z", ts)
+		holds = append(holds, h)
+	}
+	return holds, rows.Err()
+}
+
+// Prune deletes audit events older than before, except those of agents or
+// sessions on legal hold. Returns the number of events deleted.
+func (l *Logger) Prune(before time.Time) (int64, error) {
+	sessionExpr := `json_extract(details, '$.session_id')`
+	switch l.dialect {
+	case store.DialectPostgres:
+		sessionExpr = `(details::jsonb)->>'session_id'`
+	case store.DialectMySQL:
+		sessionExpr = `JSON_UNQUOTE(JSON_EXTRACT(details, '$.session_id'))`
+	}
+	res, err := l.db.Exec(
+		store.Rebind(l.dialect, `DELETE FROM audit_events
+		 WHERE timestamp < ?
+		 AND agent_name NOT IN (SELECT value FROM legal_holds WHERE kind = ?)
+		 AND COALESCE(`+sessionExpr+`, '') NOT IN (SELECT value FROM legal_holds WHERE kind = ?)`),
+		before.UTC().Format("2006-01-02T15:04:05Z"), HoldAgent, HoldSession,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("prune audit events: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// StartRetention prunes events older than maxAge now and then hourly,
+// until Close.
+func (l *Logger) StartRetention(maxAge time.Duration) {
+	l.retentionStop = make(chan struct{})
+	prune := func() {
+		n, err := l.Prune(time.Now().Add(-maxAge))
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+		} else if n > 0 {
+			log.Printf("AUDIT: pruned %d event(s) older than %s", n, maxAge)
+		}
+	}
+	go func() {
+		prune()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.retentionStop:
+				return
+			case <-ticker.C:
+				prune()
+			}
+		}
+	}()
+}
+
 // Close flushes pending events and stops the background writer.
 func (l *Logger) Close() {
+	if l.retentionStop != nil {
+		close(l.retentionStop)
+	}
 	if !l.enabled {
 		return
 	}
@@ -206,7 +507,7 @@ func (l *Logger) insertBatch(events []*Event) {
 	}
 
 	stmt, err := tx.Prepare(
-		store.Rebind(l.dialect, `INSERT INTO audit_events (timestamp, event_type, agent_name, details) VALUES (?, ?, ?, ?)`),
+		store.Rebind(l.dialect, `INSERT INTO audit_events (timestamp, event_type, agent_name, request_id, details) VALUES (?, ?, ?, ?, ?)`),
 	)
 	if err != nil {
 		log.Printf("ERROR: prepare audit batch stmt: %v", err)
@@ -217,7 +518,7 @@ func (l *Logger) insertBatch(events []*Event) {
 
 	for _, e := range events {
 		ts := e.Timestamp.UTC().Format("2006-01-02T15:04:05Z")
-		if _, err := stmt.Exec(ts, e.EventType, e.AgentName, string(e.Details)); err != nil {
+		if _, err := stmt.Exec(ts, e.EventType, e.AgentName, e.RequestID, string(e.Details)); err != nil {
 			log.Printf("ERROR: audit batch insert: %v", err)
 		}
 	}
@@ -230,8 +531,8 @@ func (l *Logger) insertBatch(events []*Event) {
 func (l *Logger) insert(e *Event) error {
 	ts := e.Timestamp.UTC().Format("2006-01-02T15:04:05Z")
 	_, err := l.db.Exec(
-		store.Rebind(l.dialect, `INSERT INTO audit_events (timestamp, event_type, agent_name, details) VALUES (?, ?, ?, ?)`),
-		ts, e.EventType, e.AgentName, string(e.Details),
+		store.Rebind(l.dialect, `INSERT INTO audit_events (timestamp, event_type, agent_name, request_id, details) VALUES (?, ?, ?, ?, ?)`),
+		ts, e.EventType, e.AgentName, e.RequestID, string(e.Details),
 	)
 	if err != nil {
 		return fmt.Errorf("insert audit event: %w", err)
@@ -17,6 +17,7 @@ CREATE TABLE IF NOT EXISTS audit_events (
 	timestamp   DATETIME NOT NULL,
 	event_type  TEXT NOT NULL,
 	agent_name  TEXT NOT NULL DEFAULT '',
+	request_id  TEXT NOT NULL DEFAULT '',
 	details     TEXT NOT NULL DEFAULT '{}'
 );
 CREATE INDEX IF NOT EXISTS idx_audit_events_timestamp ON audit_events(timestamp);
@@ -250,6 +251,50 @@ func TestLogger_ContentLog(t *testing.T) {
 	}
 }
 
+func TestLogger_QueryPayloadCapture(t *testing.T) {
+	db := newTestDB(t)
+	l := New(db, true, store.DialectSQLite)
+
+	for _, id := range []string{"aaa111", "bbb222"} {
+		l.LogRequest(EventPayloadCapture, "agent-1", id, PayloadCaptureDetails{
+			RequestID: id,
+			Model:     "gpt-4o",
+			Stages: []PayloadStage{
+				{Stage: "original", Body: `{"model":"gpt-4o"}`},
+				{Stage: "routing", Body: `{"model":"gpt-4o-mini"}`},
+			},
+		})
+	}
+	l.Close()
+
+	event, details, err := l.QueryPayloadCapture("bbb222")
+	if err != nil {
+		t.Fatalf("QueryPayloadCapture() error: %v", err)
+	}
+	if event == nil || details == nil {
+		t.Fatal("QueryPayloadCapture() returned nil for existing request")
+	}
+	if details.RequestID != "bbb222" {
+		t.Errorf("RequestID = %q, want bbb222", details.RequestID)
+	}
+	if event.AgentName != "agent-1" {
+		t.Errorf("AgentName = %q, want agent-1", event.AgentName)
+	}
+	if len(details.Stages) != 2 || details.Stages[1].Stage != "routing" {
+		t.Errorf("Stages = %+v, want [original routing]", details.Stages)
+	}
+
+	for _, id := range []string{"missing", "bbb_22"} {
+		event, details, err = l.QueryPayloadCapture(id)
+		if err != nil {
+			t.Fatalf("QueryPayloadCapture(%s) error: %v", id, err)
+		}
+		if event != nil || details != nil {
+			t.Errorf("QueryPayloadCapture(%s) should return nil", id)
+		}
+	}
+}
+
 func TestLogger_BatchFlush(t *testing.T) {
 	db := newTestDB(t)
 	l := New(db, true, store.DialectSQLite)
@@ -315,3 +360,139 @@ func TestSecureKeyMatch(t *testing.T) {
 		})
 	}
 }
+
+// newStoreLogger opens a full agix store so FTS and legal-hold tables exist.
+func newStoreLogger(t *testing.T) (*Logger, *sql.DB) {
+	t.Helper()
+	st, err := store.New(filepath.Join(t.TempDir(), "agix.db"))
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return New(st.DB(), true, st.Dialect()), st.DB()
+}
+
+func TestLogger_Search(t *testing.T) {
+	l, _ := newStoreLogger(t)
+
+	l.Log(EventContentLog, "agent-1", ContentLogDetails{Direction: "request", Model: "gpt-4o", SessionID: "s-1", Body: "please reset my password"})
+	l.Log(EventContentLog, "agent-2", ContentLogDetails{Direction: "request", Model: "gpt-4o", Body: "how do I reset the router"})
+	l.Log(EventContentLog, "agent-1", ContentLogDetails{Direction: "response", Model: "gpt-4o", Body: "weather is sunny"})
+	l.Log(EventToolCall, "agent-1", ToolCallDetails{Tool: "reset"})
+	l.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		agent string
+		want  int
+	}{
+		{"single word", "reset", "", 2},
+		{"all words", "reset password", "", 1},
+		{"agent filter", "reset", "agent-2", 1},
+		{"no match", "kubernetes", "", 0},
+		{"operators are literal", `reset OR "sunny`, "", 0},
+		{"empty", "  ", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := l.Search(tt.query, tt.agent, 10)
+			if err != nil {
+				t.Fatalf("Search() error: %v", err)
+			}
+			if len(events) != tt.want {
+				t.Errorf("Search(%q, %q) returned %d events, want %d", tt.query, tt.agent, len(events), tt.want)
+			}
+		})
+	}
+
+	events, _ := l.Search("password", "", 10)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	var d ContentLogDetails
+	if err := json.Unmarshal(events[0].Details, &d); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if d.SessionID != "s-1" {
+		t.Errorf("session_id = %q, want s-1", d.SessionID)
+	}
+}
+
+func TestLogger_Holds(t *testing.T) {
+	l, _ := newStoreLogger(t)
+	defer l.Close()
+
+	if err := l.AddHold(HoldAgent, "agent-1", "litigation"); err != nil {
+		t.Fatalf("AddHold() error: %v", err)
+	}
+	if err := l.AddHold(HoldAgent, "agent-1", "updated"); err != nil {
+		t.Fatalf("AddHold() again error: %v", err)
+	}
+	if err := l.AddHold(HoldSession, "s-1", ""); err != nil {
+		t.Fatalf("AddHold(session) error: %v", err)
+	}
+	if err := l.AddHold("team", "x", ""); err == nil {
+		t.Error("expected error for unknown hold kind")
+	}
+
+	holds, err := l.ListHolds()
+	if err != nil {
+		t.Fatalf("ListHolds() error: %v", err)
+	}
+	if len(holds) != 2 {
+		t.Fatalf("len(holds) = %d, want 2", len(holds))
+	}
+	if holds[0].Kind != HoldAgent || holds[0].Reason != "updated" {
+		t.Errorf("holds[0] = %+v, want agent hold with reason updated", holds[0])
+	}
+
+	removed, err := l.RemoveHold(HoldSession, "s-1")
+	if err != nil || !removed {
+		t.Fatalf("RemoveHold() = %v, %v; want true, nil", removed, err)
+	}
+	removed, _ = l.RemoveHold(HoldSession, "s-1")
+	if removed {
+		t.Error("RemoveHold() of missing hold reported true")
+	}
+}
+
+func TestLogger_PruneRespectsHolds(t *testing.T) {
+	l, db := newStoreLogger(t)
+
+	old := "2020-01-01T00:00:00Z"
+	insert := func(agent, session string) {
+		details, _ := json.Marshal(ContentLogDetails{Direction: "request", SessionID: session, Body: "hello"})
+		if _, err := db.Exec(`INSERT INTO audit_events (timestamp, event_type, agent_name, details) VALUES (?, ?, ?, ?)`,
+			old, EventContentLog, agent, string(details)); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	insert("held-agent", "")
+	insert("agent-1", "held-session")
+	insert("agent-1", "other")
+	insert("agent-2", "")
+	l.Log(EventToolCall, "agent-2", ToolCallDetails{Tool: "t"}) // recent, kept
+
+	l.AddHold(HoldAgent, "held-agent", "")
+	l.AddHold(HoldSession, "held-session", "")
+
+	// Flush the recent event before pruning
+	l.Close()
+
+	n, err := l.Prune(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Prune() deleted %d, want 2", n)
+	}
+
+	events, _ := l.QueryRecent(10, "", "")
+	if len(events) != 3 {
+		t.Errorf("expected 3 remaining events, got %d", len(events))
+	}
+	if hits, _ := l.Search("hello", "", 10); len(hits) != 2 {
+		t.Errorf("expected pruned events removed from search index, got %d hits", len(hits))
+	}
+}
@@ -0,0 +1,188 @@
+// Package backup snapshots the agix database and config into a single
+// .tar.gz file and restores it, so a gateway can move hosts without
+// losing its cost history. SQLite is copied with the online backup API;
+// PostgreSQL goes through pg_dump and pg_restore.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+	"modernc.org/sqlite"
+)
+
+// Format is the backup layout version written to the manifest.
+const Format = 1
+
+// Archive member names.
+const (
+	manifestName = "manifest.json"
+	configName   = "config.yaml"
+	databaseName = "database"
+	contentName  = "content"
+)
+
+// Manifest describes a backup.
+type Manifest struct {
+	Format    int           `json:"format"`
+	CreatedAt time.Time     `json:"created_at"`
+	Dialect   store.Dialect `json:"dialect"`
+	Content   bool          `json:"content,omitempty"` // separate content database included
+}
+
+// Options selects what to back up.
+type Options struct {
+	ConfigPath string
+	DSN        string // database
+	ContentDSN string // separate content database, if any
+}
+
+// Create writes a backup of opts to w.
+func Create(ctx context.Context, w io.Writer, opts Options) (*Manifest, error) {
+	dialect := store.DetectDialect(opts.DSN)
+	if dialect == store.DialectMySQL {
+		return nil, fmt.Errorf("backup of MySQL databases is not supported; use mysqldump")
+	}
+	content := opts.ContentDSN != "" && opts.ContentDSN != opts.DSN
+	if content && store.DetectDialect(opts.ContentDSN) != dialect {
+		return nil, fmt.Errorf("content database must use the same backend as the main database")
+	}
+
+	tmp, err := os.MkdirTemp("", "agix-backup-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	dbFile := filepath.Join(tmp, databaseName)
+	if err := dump(ctx, dialect, opts.DSN, dbFile); err != nil {
+		return nil, err
+	}
+	contentFile := filepath.Join(tmp, contentName)
+	if content {
+		if err := dump(ctx, dialect, opts.ContentDSN, contentFile); err != nil {
+			return nil, fmt.Errorf("content database: %w", err)
+		}
+	}
+
+	m := &Manifest{Format: Format, CreatedAt: time.Now().UTC().Truncate(time.Second), Dialect: dialect, Content: content}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := addBytes(tw, manifestName, manifest, m.CreatedAt); err != nil {
+		return nil, err
+	}
+	if opts.ConfigPath != "" {
+		if err := addFile(tw, configName, opts.ConfigPath); err != nil {
+			return nil, fmt.Errorf("add config: %w", err)
+		}
+	}
+	if err := addFile(tw, databaseName, dbFile); err != nil {
+		return nil, err
+	}
+	if content {
+		if err := addFile(tw, contentName, contentFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// dump writes a consistent copy of dsn to file.
+func dump(ctx context.Context, dialect store.Dialect, dsn, file string) error {
+	if dialect == store.DialectPostgres {
+		out, err := exec.CommandContext(ctx, "pg_dump", "--format=custom", "--no-owner", "--dbname", dsn, "--file", file).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("pg_dump: %v: %s", err, out)
+		}
+		return nil
+	}
+	return snapshotSQLite(ctx, dsn, file)
+}
+
+// snapshotSQLite copies the database at src to dst with the online backup
+// API, which is safe while the gateway is writing.
+func snapshotSQLite(ctx context.Context, src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	db, err := sql.Open("sqlite", src+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return fmt.Errorf("open sqlite: %w", err)
+	}
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("open sqlite: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(dc any) error {
+		b, ok := dc.(interface {
+			NewBackup(string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return fmt.Errorf("sqlite driver does not support online backup")
+		}
+		bk, err := b.NewBackup(dst)
+		if err != nil {
+			return fmt.Errorf("start backup: %w", err)
+		}
+		for {
+			more, err := bk.Step(256)
+			if err != nil {
+				bk.Finish()
+				return fmt.Errorf("backup: %w", err)
+			}
+			if !more {
+				break
+			}
+		}
+		return bk.Finish()
+	})
+}
+
+func addBytes(tw *tar.Writer, name string, data []byte, mod time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: mod}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func addFile(tw *tar.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestBackupRestoreSQLite(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "agix.db")
+	st, err := store.New(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Left open while backing up: the online backup must see committed WAL
+	// writes.
+	defer st.Close()
+	if err := st.Insert(&store.Record{Timestamp: time.Now().UTC(), AgentName: "bot", Model: "gpt-4o", Provider: "openai", CostUSD: 0.01, StatusCode: 200}); err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(cfgPath, []byte("port: 8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	m, err := Create(context.Background(), &buf, Options{ConfigPath: cfgPath, DSN: dsn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Dialect != store.DialectSQLite || m.Content {
+		t.Errorf("manifest = %+v", m)
+	}
+
+	b, err := Open(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if !b.HasConfig() {
+		t.Error("backup has no config")
+	}
+
+	restored := filepath.Join(t.TempDir(), "agix.db")
+	if err := b.RestoreDatabase(context.Background(), restored, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RestoreDatabase(context.Background(), restored, false); err == nil {
+		t.Error("restore over an existing database succeeded without force")
+	}
+	if err := b.RestoreDatabase(context.Background(), restored, true); err != nil {
+		t.Fatalf("forced restore: %v", err)
+	}
+	newCfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := b.RestoreConfig(newCfg, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(newCfg); string(data) != "port: 8080\n" {
+		t.Errorf("restored config = %q", data)
+	}
+
+	rs, err := store.New(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Close()
+	records, err := rs.QueryRecentRequests(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].AgentName != "bot" {
+		t.Errorf("restored records = %+v", records)
+	}
+
+	if err := b.RestoreDatabase(context.Background(), "postgres://localhost/agix", true); err == nil {
+		t.Error("restoring a SQLite backup into PostgreSQL succeeded")
+	}
+}
+
+func TestOpenRejectsGarbage(t *testing.T) {
+	if _, err := Open(bytes.NewReader([]byte("not a backup"))); err == nil {
+		t.Error("Open succeeded on garbage")
+	}
+}
@@ -0,0 +1,212 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Backup is an opened backup file, unpacked to a temporary directory.
+// Close removes it.
+type Backup struct {
+	Manifest Manifest
+	dir      string
+}
+
+// Open unpacks a backup written by Create.
+func Open(r io.Reader) (*Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup file: %w", err)
+	}
+	defer gz.Close()
+
+	dir, err := os.MkdirTemp("", "agix-restore-")
+	if err != nil {
+		return nil, err
+	}
+	b := &Backup{dir: dir}
+
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			b.Close()
+			return nil, fmt.Errorf("read backup: %w", err)
+		}
+		switch h.Name {
+		case manifestName, configName, databaseName, contentName:
+		default:
+			continue // written by a newer agix; ignore
+		}
+		f, err := os.OpenFile(filepath.Join(dir, h.Name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			b.Close()
+			return nil, err
+		}
+		_, err = io.Copy(f, tr)
+		f.Close()
+		if err != nil {
+			b.Close()
+			return nil, fmt.Errorf("read backup: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		b.Close()
+		return nil, fmt.Errorf("backup has no manifest")
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if b.Manifest.Format > Format {
+		b.Close()
+		return nil, fmt.Errorf("backup format %d is newer than this agix supports (%d)", b.Manifest.Format, Format)
+	}
+	if _, err := os.Stat(filepath.Join(dir, databaseName)); err != nil {
+		b.Close()
+		return nil, fmt.Errorf("backup has no database")
+	}
+	return b, nil
+}
+
+// Close removes the unpacked files.
+func (b *Backup) Close() error {
+	return os.RemoveAll(b.dir)
+}
+
+// HasConfig reports whether the backup includes a config file.
+func (b *Backup) HasConfig() bool {
+	_, err := os.Stat(filepath.Join(b.dir, configName))
+	return err == nil
+}
+
+// RestoreConfig writes the backed-up config to path. An existing file is
+// only replaced with force.
+func (b *Backup) RestoreConfig(path string, force bool) error {
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(b.dir, configName))
+	if err != nil {
+		return fmt.Errorf("backup has no config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// RestoreDatabase loads the backed-up database into dsn. For SQLite an
+// existing file is only replaced with force; PostgreSQL objects are
+// dropped and recreated by pg_restore --clean. The gateway must be
+// stopped.
+func (b *Backup) RestoreDatabase(ctx context.Context, dsn string, force bool) error {
+	return b.restore(ctx, databaseName, dsn, force)
+}
+
+// RestoreContent loads the separate content database, if the backup has
+// one, into dsn.
+func (b *Backup) RestoreContent(ctx context.Context, dsn string, force bool) error {
+	if !b.Manifest.Content {
+		return nil
+	}
+	return b.restore(ctx, contentName, dsn, force)
+}
+
+func (b *Backup) restore(ctx context.Context, name, dsn string, force bool) error {
+	dialect := store.DetectDialect(dsn)
+	if dialect != b.Manifest.Dialect {
+		return fmt.Errorf("backup is a %s database but the target is %s", b.Manifest.Dialect, dialect)
+	}
+	src := filepath.Join(b.dir, name)
+
+	if dialect == store.DialectPostgres {
+		out, err := exec.CommandContext(ctx, "pg_restore", "--clean", "--if-exists", "--no-owner", "--dbname", dsn, src).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("pg_restore: %v: %s", err, out)
+		}
+		return nil
+	}
+
+	if !force {
+		if _, err := os.Stat(dsn); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", dsn)
+		}
+	}
+	if err := checkSQLite(src); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dsn), 0o700); err != nil {
+		return err
+	}
+	tmp := dsn + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		return err
+	}
+	// A WAL left by the replaced database would be replayed over the
+	// restored one.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dsn + suffix); err != nil && !os.IsNotExist(err) {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, dsn); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// checkSQLite verifies the backed-up file is an intact SQLite database.
+func checkSQLite(path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("open backed-up database: %w", err)
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow(`PRAGMA quick_check`).Scan(&result); err != nil {
+		return fmt.Errorf("check backed-up database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backed-up database is corrupt: %s", result)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -20,6 +20,11 @@ type Config struct {
 	Enabled             bool    `yaml:"enabled"`
 	SimilarityThreshold float64 `yaml:"similarity_threshold"`
 	TTLMinutes          int     `yaml:"ttl_minutes"`
+
+	// EquivalentModels groups models that share cache entries, e.g.
+	// [gpt-4o, gpt-4o-2024-11-20]. Entries are keyed by the first model in
+	// the group.
+	EquivalentModels [][]string `yaml:"equivalent_models"`
 }
 
 // Entry represents a cached response.
@@ -45,6 +50,7 @@ type Cache struct {
 	embedder  *EmbeddingClient
 	threshold float64
 	ttl       time.Duration
+	canonical map[string]string // model → first model of its equivalence group
 }
 
 const createCacheTableSQLite = `
@@ -74,6 +80,18 @@ var createCacheTablePostgres = []string{
 	`CREATE INDEX IF NOT EXISTS idx_cache_created ON cache_entries(created_at)`,
 }
 
+const createCacheTableMySQL = `
+CREATE TABLE IF NOT EXISTS cache_entries (
+	hash       VARCHAR(64) NOT NULL,
+	model      VARCHAR(255) NOT NULL,
+	response   LONGBLOB NOT NULL,
+	embedding  MEDIUMBLOB,
+	created_at VARCHAR(32) NOT NULL,
+	PRIMARY KEY (hash, model),
+	INDEX idx_cache_model (model),
+	INDEX idx_cache_created (created_at)
+)`
+
 // New creates a new Cache. Returns nil if not enabled.
 func New(cfg Config, db *sql.DB, embedder *EmbeddingClient, dialect store.Dialect) (*Cache, error) {
 	if !cfg.Enabled {
@@ -87,14 +105,20 @@ func New(cfg Config, db *sql.DB, embedder *EmbeddingClient, dialect store.Dialec
 		cfg.TTLMinutes = 60
 	}
 
-	if dialect == store.DialectPostgres {
-		for _, stmt := range createCacheTablePostgres {
-			if _, err := db.Exec(stmt); err != nil {
-				return nil, fmt.Errorf("create cache table: %w", err)
-			}
-		}
-	} else {
-		if _, err := db.Exec(createCacheTableSQLite); err != nil {
+	canonical, err := equivalenceMap(cfg.EquivalentModels)
+	if err != nil {
+		return nil, err
+	}
+
+	stmts := []string{createCacheTableSQLite}
+	switch dialect {
+	case store.DialectPostgres:
+		stmts = createCacheTablePostgres
+	case store.DialectMySQL:
+		stmts = []string{createCacheTableMySQL}
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
 			return nil, fmt.Errorf("create cache table: %w", err)
 		}
 	}
@@ -105,12 +129,44 @@ func New(cfg Config, db *sql.DB, embedder *EmbeddingClient, dialect store.Dialec
 		embedder:  embedder,
 		threshold: cfg.SimilarityThreshold,
 		ttl:       time.Duration(cfg.TTLMinutes) * time.Minute,
+		canonical: canonical,
 	}, nil
 }
 
+// equivalenceMap maps every model in a group to the group's first model.
+func equivalenceMap(groups [][]string) (map[string]string, error) {
+	canonical := make(map[string]string)
+	for i, group := range groups {
+		if len(group) < 2 {
+			return nil, fmt.Errorf("cache equivalent_models[%d]: need at least two models", i)
+		}
+		for _, m := range group {
+			if m == "" {
+				return nil, fmt.Errorf("cache equivalent_models[%d]: empty model name", i)
+			}
+			if prev, ok := canonical[m]; ok {
+				return nil, fmt.Errorf("cache equivalent_models: %s is in more than one group (with %s)", m, prev)
+			}
+			canonical[m] = group[0]
+		}
+	}
+	return canonical, nil
+}
+
+// cacheModel returns the model name entries are keyed by: the first model of
+// its equivalence group, or the model itself.
+func (c *Cache) cacheModel(model string) string {
+	if m, ok := c.canonical[model]; ok {
+		return m
+	}
+	return model
+}
+
 // Lookup checks the cache for a matching response.
 // It first tries an exact SHA-256 match, then falls back to semantic similarity.
+// Equivalent models share entries.
 func (c *Cache) Lookup(model string, messages json.RawMessage) LookupResult {
+	model = c.cacheModel(model)
 	contentKey := extractContentKey(messages)
 	hash := sha256Hash(contentKey)
 
@@ -148,6 +204,7 @@ func (c *Cache) Lookup(model string, messages json.RawMessage) LookupResult {
 
 // Store saves a response in the cache.
 func (c *Cache) Store(model string, messages json.RawMessage, response []byte) {
+	model = c.cacheModel(model)
 	contentKey := extractContentKey(messages)
 	hash := sha256Hash(contentKey)
 
@@ -162,10 +219,14 @@ func (c *Cache) Store(model string, messages json.RawMessage, response []byte) {
 	}
 
 	var query string
-	if c.dialect == store.DialectPostgres {
+	switch c.dialect {
+	case store.DialectPostgres:
 		query = `INSERT INTO cache_entries (hash, model, response, embedding, created_at) VALUES ($1, $2, $3, $4, $5)
 			ON CONFLICT (hash, model) DO UPDATE SET response = EXCLUDED.response, embedding = EXCLUDED.embedding, created_at = EXCLUDED.created_at`
-	} else {
+	case store.DialectMySQL:
+		query = `INSERT INTO cache_entries (hash, model, response, embedding, created_at) VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE response = VALUES(response), embedding = VALUES(embedding), created_at = VALUES(created_at)`
+	default:
 		query = `INSERT OR REPLACE INTO cache_entries (hash, model, response, embedding, created_at) VALUES (?, ?, ?, ?, ?)`
 	}
 	_, err := c.db.Exec(
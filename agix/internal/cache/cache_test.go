@@ -3,6 +3,8 @@ package cache
 import (
 	"database/sql"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"testing"
 	"time"
@@ -96,6 +98,53 @@ func TestExactMatch_DifferentModel(t *testing.T) {
 	}
 }
 
+func TestExactMatch_EquivalentModels(t *testing.T) {
+	db := openTestDB(t)
+	c, err := New(Config{
+		Enabled:          true,
+		TTLMinutes:       60,
+		EquivalentModels: [][]string{{"gpt-4o", "gpt-4o-2024-11-20", "gpt-4o-2024-08-06"}},
+	}, db, nil, store.DialectSQLite)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	msgs, _ := json.Marshal([]map[string]string{
+		{"role": "user", "content": "What is 2+2?"},
+	})
+	response := []byte(`{"choices":[{"message":{"content":"4"}}]}`)
+
+	// Stored under a dated alias, served to the base model and other aliases
+	c.Store("gpt-4o-2024-11-20", msgs, response)
+	for _, model := range []string{"gpt-4o", "gpt-4o-2024-11-20", "gpt-4o-2024-08-06"} {
+		if result := c.Lookup(model, msgs); !result.Hit {
+			t.Errorf("Lookup(%s) missed, want hit via equivalence group", model)
+		}
+	}
+	if result := c.Lookup("gpt-4o-mini", msgs); result.Hit {
+		t.Error("expected miss for model outside the group")
+	}
+}
+
+func TestNew_InvalidEquivalentModels(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups [][]string
+	}{
+		{"single model", [][]string{{"gpt-4o"}}},
+		{"empty name", [][]string{{"gpt-4o", ""}}},
+		{"model in two groups", [][]string{{"gpt-4o", "gpt-4o-2024-11-20"}, {"gpt-4o-2024-11-20", "gpt-4o-latest"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if _, err := New(Config{Enabled: true, EquivalentModels: tt.groups}, db, nil, store.DialectSQLite); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
 func TestExactMatch_Expired(t *testing.T) {
 	db := openTestDB(t)
 	c, err := New(Config{Enabled: true, TTLMinutes: 1}, db, nil, store.DialectSQLite)
@@ -194,3 +243,32 @@ func TestExtractContentKey(t *testing.T) {
 		t.Errorf("key = %q, want %q", key, "Hello\nHow are you?")
 	}
 }
+
+func TestEmbeddingClient_RecordsUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"embedding":[0.1,0.2]}],"usage":{"prompt_tokens":1000000,"total_tokens":1000000}}`))
+	}))
+	defer srv.Close()
+
+	c := NewEmbeddingClient("sk-test", "")
+	c.endpoint = srv.URL
+	var got []*store.Record
+	c.OnUsage = func(r *store.Record) { got = append(got, r) }
+
+	if _, err := c.Embed("hello"); err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("usage records = %d, want 1", len(got))
+	}
+	r := got[0]
+	if r.AgentName != store.AgentEmbeddings || r.Model != "text-embedding-3-small" || r.Provider != "openai" {
+		t.Errorf("record = %+v", r)
+	}
+	if r.InputTokens != 1000000 || r.StatusCode != http.StatusOK {
+		t.Errorf("tokens = %d, status = %d", r.InputTokens, r.StatusCode)
+	}
+	if r.CostUSD < 0.0199 || r.CostUSD > 0.0201 {
+		t.Errorf("CostUSD = %f, want 0.02", r.CostUSD)
+	}
+}
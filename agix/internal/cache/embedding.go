@@ -7,13 +7,21 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/store"
 )
 
 // EmbeddingClient generates text embeddings via the OpenAI API.
 type EmbeddingClient struct {
-	apiKey string
-	model  string
-	client *http.Client
+	apiKey   string
+	model    string
+	endpoint string
+	client   *http.Client
+
+	// OnUsage, if set, receives a usage record for every embedding call,
+	// attributed to the _gateway/cache-embeddings agent.
+	OnUsage func(*store.Record)
 }
 
 // NewEmbeddingClient creates a new embedding client.
@@ -22,9 +30,10 @@ func NewEmbeddingClient(apiKey, model string) *EmbeddingClient {
 		model = "text-embedding-3-small"
 	}
 	return &EmbeddingClient{
-		apiKey: apiKey,
-		model:  model,
-		client: &http.Client{Timeout: 30 * time.Second},
+		apiKey:   apiKey,
+		model:    model,
+		endpoint: "https://api.openai.com/v1/embeddings",
+		client:   &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
@@ -35,13 +44,14 @@ func (c *EmbeddingClient) Embed(text string) ([]float32, error) {
 		"model": c.model,
 	})
 
-	req, err := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/embeddings", bytes.NewReader(reqBody))
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
+	start := time.Now()
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("embedding request: %w", err)
@@ -52,6 +62,7 @@ func (c *EmbeddingClient) Embed(text string) ([]float32, error) {
 	if err != nil {
 		return nil, fmt.Errorf("read response: %w", err)
 	}
+	c.recordUsage(start, resp.StatusCode, body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("embedding API error (status %d): %s", resp.StatusCode, string(body))
@@ -71,3 +82,28 @@ func (c *EmbeddingClient) Embed(text string) ([]float32, error) {
 
 	return result.Data[0].Embedding, nil
 }
+
+// recordUsage reports the tokens consumed by one embedding call.
+func (c *EmbeddingClient) recordUsage(start time.Time, status int, body []byte) {
+	if c.OnUsage == nil {
+		return
+	}
+	var result struct {
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
+	}
+	json.Unmarshal(body, &result)
+	tokens := result.Usage.PromptTokens
+	c.OnUsage(&store.Record{
+		Timestamp:   start,
+		AgentName:   store.AgentEmbeddings,
+		RequestType: store.RequestTypeEmbedding,
+		Model:       c.model,
+		Provider:    "openai",
+		InputTokens: tokens,
+		CostUSD:     pricing.CalculateCost(c.model, tokens, 0),
+		DurationMS:  time.Since(start).Milliseconds(),
+		StatusCode:  status,
+	})
+}
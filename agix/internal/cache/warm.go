@@ -0,0 +1,270 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Seed is one prompt from a cache warm-up file. Each JSONL line holds
+// either "messages" (OpenAI chat format) or a plain "prompt", and may
+// override the model.
+type Seed struct {
+	Model    string          `json:"model,omitempty"`
+	Messages json.RawMessage `json:"messages,omitempty"`
+	Prompt   string          `json:"prompt,omitempty"`
+}
+
+// ParseSeeds reads seed prompts from JSONL. Blank lines and lines starting
+// with # are ignored.
+func ParseSeeds(r io.Reader) ([]Seed, error) {
+	var seeds []Seed
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var s Seed
+		if err := json.Unmarshal([]byte(text), &s); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(s.Messages) == 0 && s.Prompt == "" {
+			return nil, fmt.Errorf("line %d: need \"messages\" or \"prompt\"", line)
+		}
+		seeds = append(seeds, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read seeds: %w", err)
+	}
+	return seeds, nil
+}
+
+// messages returns the seed as a chat messages array.
+func (s Seed) messages() json.RawMessage {
+	if len(s.Messages) > 0 {
+		return s.Messages
+	}
+	data, _ := json.Marshal([]map[string]string{{"role": "user", "content": s.Prompt}})
+	return data
+}
+
+// Window is a daily local-time window such as 01:00-06:00. A window whose
+// end is before its start wraps past midnight.
+type Window struct {
+	start, end time.Duration // offsets from midnight
+}
+
+// ParseWindow parses "HH:MM-HH:MM".
+func ParseWindow(s string) (*Window, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid window %q (want HH:MM-HH:MM)", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid window %q: start equals end", s)
+	}
+	return &Window{start: start, end: end}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// offset returns the time elapsed since local midnight.
+func offset(t time.Time) time.Duration {
+	y, m, d := t.Date()
+	return t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+}
+
+// Contains reports whether t falls inside the window.
+func (w *Window) Contains(t time.Time) bool {
+	off := offset(t)
+	if w.start < w.end {
+		return off >= w.start && off < w.end
+	}
+	return off >= w.start || off < w.end
+}
+
+// Until returns how long until the window next opens (0 if open now).
+func (w *Window) Until(t time.Time) time.Duration {
+	if w.Contains(t) {
+		return 0
+	}
+	d := w.start - offset(t)
+	if d < 0 {
+		d += 24 * time.Hour
+	}
+	return d
+}
+
+// WarmConfig controls a cache warm-up run.
+type WarmConfig struct {
+	GatewayURL string        // e.g. http://localhost:8080
+	AgentName  string        // sent as X-Agent-Name
+	APIKey     string        // agix API key, sent as a Bearer token when set
+	Model      string        // used for seeds without a model
+	MaxCostUSD float64       // stop once spend reaches this; 0 means no cap
+	Window     *Window       // only send inside this window; nil means any time
+	Delay      time.Duration // pause between requests
+}
+
+// WarmResult summarizes a warm-up run.
+type WarmResult struct {
+	Sent      int     `json:"sent"`
+	Warmed    int     `json:"warmed"`  // cache misses that were answered and stored
+	Cached    int     `json:"cached"`  // already cached
+	Failed    int     `json:"failed"`  // non-2xx or transport errors
+	Skipped   int     `json:"skipped"` // not sent because the cap was hit or the window closed
+	CostUSD   float64 `json:"cost_usd"`
+	StoppedBy string  `json:"stopped_by,omitempty"` // "cost_cap" or "window"
+}
+
+// Warmer sends seed prompts through the gateway so responses land in the
+// cache via the normal pipeline (routing, budgets and cost tracking apply).
+type Warmer struct {
+	cfg    WarmConfig
+	client *http.Client
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewWarmer creates a Warmer.
+func NewWarmer(cfg WarmConfig) *Warmer {
+	if cfg.AgentName == "" {
+		cfg.AgentName = "cache-warmer"
+	}
+	return &Warmer{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Minute},
+		now:    time.Now,
+		sleep:  sleepCtx,
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Run sends every seed once. If a window is set, it waits for the window to
+// open and stops when it closes. progress, if non-nil, is called after each
+// request with the seed index and the outcome ("warmed", "cached" or an error).
+func (wm *Warmer) Run(ctx context.Context, seeds []Seed, progress func(i int, outcome string)) (WarmResult, error) {
+	var res WarmResult
+
+	if wm.cfg.Window != nil {
+		if d := wm.cfg.Window.Until(wm.now()); d > 0 {
+			if err := wm.sleep(ctx, d); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	for i, seed := range seeds {
+		if wm.cfg.MaxCostUSD > 0 && res.CostUSD >= wm.cfg.MaxCostUSD {
+			res.StoppedBy = "cost_cap"
+		} else if wm.cfg.Window != nil && !wm.cfg.Window.Contains(wm.now()) {
+			res.StoppedBy = "window"
+		}
+		if res.StoppedBy != "" {
+			res.Skipped = len(seeds) - i
+			break
+		}
+		if i > 0 && wm.cfg.Delay > 0 {
+			if err := wm.sleep(ctx, wm.cfg.Delay); err != nil {
+				return res, err
+			}
+		}
+
+		outcome, cost, err := wm.send(ctx, seed)
+		res.Sent++
+		res.CostUSD += cost
+		switch {
+		case err != nil:
+			res.Failed++
+			outcome = err.Error()
+		case outcome == "cached":
+			res.Cached++
+		default:
+			res.Warmed++
+		}
+		if progress != nil {
+			progress(i, outcome)
+		}
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+	}
+	return res, nil
+}
+
+// send posts one seed and returns its outcome and the reported cost.
+func (wm *Warmer) send(ctx context.Context, seed Seed) (string, float64, error) {
+	model := seed.Model
+	if model == "" {
+		model = wm.cfg.Model
+	}
+	body, err := json.Marshal(map[string]any{
+		"model":    model,
+		"messages": seed.messages(),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(wm.cfg.GatewayURL, "/") + "/v1/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent-Name", wm.cfg.AgentName)
+	if wm.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+wm.cfg.APIKey)
+	}
+
+	resp, err := wm.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	cost, _ := strconv.ParseFloat(resp.Header.Get("X-Cost-USD"), 64)
+	if resp.StatusCode >= 300 {
+		return "", cost, fmt.Errorf("gateway returned %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Cache") == "HIT" {
+		return "cached", cost, nil
+	}
+	return "warmed", cost, nil
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSeeds(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"prompt and messages", "{\"prompt\":\"hi\"}\n\n# comment\n{\"model\":\"gpt-4o\",\"messages\":[{\"role\":\"user\",\"content\":\"yo\"}]}\n", 2, false},
+		{"empty", "\n\n", 0, false},
+		{"missing content", `{"model":"gpt-4o"}`, 0, true},
+		{"bad json", `{"prompt":`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seeds, err := ParseSeeds(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeeds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(seeds) != tt.want {
+				t.Errorf("len(seeds) = %d, want %d", len(seeds), tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 1, h, m, 0, 0, time.Local) }
+
+	tests := []struct {
+		window    string
+		t         time.Time
+		contains  bool
+		wantUntil time.Duration
+	}{
+		{"01:00-06:00", at(3, 0), true, 0},
+		{"01:00-06:00", at(6, 0), false, 19 * time.Hour},
+		{"01:00-06:00", at(0, 30), false, 30 * time.Minute},
+		{"22:00-02:00", at(23, 0), true, 0},
+		{"22:00-02:00", at(1, 59), true, 0},
+		{"22:00-02:00", at(12, 0), false, 10 * time.Hour},
+	}
+	for _, tt := range tests {
+		w, err := ParseWindow(tt.window)
+		if err != nil {
+			t.Fatalf("ParseWindow(%q) error: %v", tt.window, err)
+		}
+		if got := w.Contains(tt.t); got != tt.contains {
+			t.Errorf("%s Contains(%s) = %v, want %v", tt.window, tt.t.Format("15:04"), got, tt.contains)
+		}
+		if got := w.Until(tt.t); got != tt.wantUntil {
+			t.Errorf("%s Until(%s) = %v, want %v", tt.window, tt.t.Format("15:04"), got, tt.wantUntil)
+		}
+	}
+
+	for _, bad := range []string{"", "01:00", "25:00-02:00", "03:00-03:00"} {
+		if _, err := ParseWindow(bad); err == nil {
+			t.Errorf("ParseWindow(%q) expected error", bad)
+		}
+	}
+}
+
+// fakeGateway answers chat completions, reporting a HIT for prompts it has
+// already seen and charging cost per miss.
+func fakeGateway(t *testing.T, cost string) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var reqs []map[string]any
+	seen := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Agent-Name") != "cache-warmer" {
+			t.Errorf("X-Agent-Name = %q, want cache-warmer", r.Header.Get("X-Agent-Name"))
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		reqs = append(reqs, body)
+
+		key, _ := json.Marshal(body["messages"])
+		if body["model"] == "broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if seen[string(key)] {
+			w.Header().Set("X-Cache", "HIT")
+			w.Header().Set("X-Cost-USD", "0.000000")
+		} else {
+			seen[string(key)] = true
+			w.Header().Set("X-Cache", "MISS")
+			w.Header().Set("X-Cost-USD", cost)
+		}
+		w.Write([]byte(`{"choices":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+func TestWarmer_Run(t *testing.T) {
+	srv, reqs := fakeGateway(t, "0.010000")
+	wm := NewWarmer(WarmConfig{GatewayURL: srv.URL, Model: "gpt-4o-mini"})
+
+	seeds := []Seed{
+		{Prompt: "a"},
+		{Prompt: "a"},
+		{Model: "gpt-4o", Prompt: "b"},
+		{Model: "broken", Prompt: "c"},
+	}
+	var outcomes []string
+	res, err := wm.Run(context.Background(), seeds, func(i int, outcome string) {
+		outcomes = append(outcomes, outcome)
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.Sent != 4 || res.Warmed != 2 || res.Cached != 1 || res.Failed != 1 {
+		t.Errorf("result = %+v, want sent 4, warmed 2, cached 1, failed 1", res)
+	}
+	if res.CostUSD < 0.0199 || res.CostUSD > 0.0201 {
+		t.Errorf("CostUSD = %f, want 0.02", res.CostUSD)
+	}
+	if len(outcomes) != 4 || outcomes[1] != "cached" {
+		t.Errorf("outcomes = %v", outcomes)
+	}
+	if (*reqs)[0]["model"] != "gpt-4o-mini" || (*reqs)[2]["model"] != "gpt-4o" {
+		t.Errorf("models = %v, %v; want default then seed override", (*reqs)[0]["model"], (*reqs)[2]["model"])
+	}
+}
+
+func TestWarmer_CostCap(t *testing.T) {
+	srv, reqs := fakeGateway(t, "0.500000")
+	wm := NewWarmer(WarmConfig{GatewayURL: srv.URL, Model: "gpt-4o", MaxCostUSD: 1})
+
+	seeds := []Seed{{Prompt: "a"}, {Prompt: "b"}, {Prompt: "c"}, {Prompt: "d"}}
+	res, err := wm.Run(context.Background(), seeds, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if len(*reqs) != 2 || res.Skipped != 2 || res.StoppedBy != "cost_cap" {
+		t.Errorf("sent %d, result %+v; want 2 sent, 2 skipped by cost_cap", len(*reqs), res)
+	}
+}
+
+func TestWarmer_WaitsForWindow(t *testing.T) {
+	srv, reqs := fakeGateway(t, "0.010000")
+	window, _ := ParseWindow("01:00-02:00")
+
+	now := time.Date(2026, 3, 1, 0, 30, 0, 0, time.Local)
+	wm := NewWarmer(WarmConfig{GatewayURL: srv.URL, Model: "gpt-4o", Window: window})
+	wm.now = func() time.Time { return now }
+	var slept time.Duration
+	wm.sleep = func(ctx context.Context, d time.Duration) error {
+		slept += d
+		now = now.Add(d)
+		return nil
+	}
+
+	res, err := wm.Run(context.Background(), []Seed{{Prompt: "a"}, {Prompt: "b"}}, func(i int, _ string) {
+		now = now.Add(45 * time.Minute) // the window closes after the second request starts
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if slept != 30*time.Minute {
+		t.Errorf("slept %v, want 30m", slept)
+	}
+	if len(*reqs) != 2 {
+		t.Errorf("sent %d, want 2", len(*reqs))
+	}
+
+	// Starting near the end of the window stops once it closes
+	now = time.Date(2026, 3, 1, 1, 30, 0, 0, time.Local)
+	res, _ = wm.Run(context.Background(), []Seed{{Prompt: "x"}, {Prompt: "y"}}, func(i int, _ string) {
+		now = now.Add(45 * time.Minute)
+	})
+	if res.Sent != 1 || res.StoppedBy != "window" || res.Skipped != 1 {
+		t.Errorf("result = %+v, want 1 sent then stopped by window", res)
+	}
+}
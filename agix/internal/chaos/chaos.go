@@ -0,0 +1,129 @@
+// Package chaos injects upstream faults — rate limits, server errors and
+// latency — so agent teams can exercise their retry and failover handling
+// in staging without provoking real providers.
+package chaos
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Header opts a request in to fault injection. Requests without it are
+// never affected, so chaos can stay configured on a shared gateway.
+// Injected responses carry InjectedHeader naming the fault.
+const (
+	Header         = "X-Chaos"
+	InjectedHeader = "X-Chaos-Injected"
+)
+
+// Wildcard is the provider key whose faults apply to providers without
+// their own entry.
+const Wildcard = "*"
+
+// Fault holds the per-call probabilities (0-1) of each fault for one
+// provider. Latency is added before the call and combines with an error.
+type Fault struct {
+	RateLimit   float64       // probability of a 429
+	ServerError float64       // probability of ServerErrorStatus
+	Latency     float64       // probability of adding Delay
+	Delay       time.Duration // default 2s
+	// ServerErrorStatus is the injected 5xx status, default 503.
+	ServerErrorStatus int
+}
+
+// Validate checks that the probabilities are within 0-1 and the server
+// error status is a 5xx.
+func (f Fault) Validate() error {
+	for _, p := range []struct {
+		name string
+		v    float64
+	}{{"rate_limit", f.RateLimit}, {"server_error", f.ServerError}, {"latency", f.Latency}} {
+		if p.v < 0 || p.v > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", p.name, p.v)
+		}
+	}
+	if f.RateLimit+f.ServerError > 1 {
+		return fmt.Errorf("rate_limit + server_error must not exceed 1")
+	}
+	if s := f.ServerErrorStatus; s != 0 && (s < 500 || s > 599) {
+		return fmt.Errorf("server_error_status must be a 5xx status, got %d", s)
+	}
+	return nil
+}
+
+// Outcome is the fault chosen for one upstream call.
+type Outcome struct {
+	Delay      time.Duration
+	StatusCode int // 0 means the call goes through
+}
+
+// Injector rolls faults per upstream call.
+type Injector struct {
+	faults map[string]Fault
+	rand   func() float64
+}
+
+// New creates an Injector for faults keyed by provider name or Wildcard.
+func New(faults map[string]Fault) *Injector {
+	return &Injector{faults: faults, rand: rand.Float64}
+}
+
+// Roll picks the fault, if any, for one call to provider.
+func (in *Injector) Roll(provider string) Outcome {
+	f, ok := in.faults[provider]
+	if !ok {
+		if f, ok = in.faults[Wildcard]; !ok {
+			return Outcome{}
+		}
+	}
+	var o Outcome
+	if f.Latency > 0 && in.rand() < f.Latency {
+		o.Delay = f.Delay
+		if o.Delay <= 0 {
+			o.Delay = 2 * time.Second
+		}
+	}
+	// One roll decides between the errors, so their probabilities add up.
+	switch x := in.rand(); {
+	case x < f.RateLimit:
+		o.StatusCode = http.StatusTooManyRequests
+	case x < f.RateLimit+f.ServerError:
+		o.StatusCode = f.ServerErrorStatus
+		if o.StatusCode == 0 {
+			o.StatusCode = http.StatusServiceUnavailable
+		}
+	}
+	return o
+}
+
+// Response builds the upstream response for an injected error, shaped like
+// an OpenAI error so clients parse it as they would a real one.
+func Response(req *http.Request, status int) *http.Response {
+	kind := "server_error"
+	if status == http.StatusTooManyRequests {
+		kind = "rate_limit_error"
+	}
+	body := fmt.Sprintf(`{"error":{"message":"injected by agix chaos mode","type":%q,"code":%d}}`, kind, status)
+	h := http.Header{
+		"Content-Type": {"application/json"},
+		InjectedHeader: {kind},
+	}
+	if status == http.StatusTooManyRequests {
+		h.Set("Retry-After", "1")
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
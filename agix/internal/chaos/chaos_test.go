@@ -0,0 +1,84 @@
+package chaos
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixed returns rolls in order, repeating the last one.
+func fixed(rolls ...float64) func() float64 {
+	i := 0
+	return func() float64 {
+		r := rolls[min(i, len(rolls)-1)]
+		i++
+		return r
+	}
+}
+
+func TestRoll(t *testing.T) {
+	faults := map[string]Fault{
+		"openai": {RateLimit: 0.2, ServerError: 0.3, ServerErrorStatus: 502, Latency: 0.5, Delay: time.Second},
+		Wildcard: {ServerError: 1},
+	}
+	tests := []struct {
+		name     string
+		provider string
+		rolls    []float64 // latency roll, then error roll
+		want     Outcome
+	}{
+		{"rate limit", "openai", []float64{0.9, 0.1}, Outcome{StatusCode: 429}},
+		{"server error", "openai", []float64{0.9, 0.4}, Outcome{StatusCode: 502}},
+		{"no fault", "openai", []float64{0.9, 0.6}, Outcome{}},
+		{"latency and error", "openai", []float64{0.1, 0.1}, Outcome{Delay: time.Second, StatusCode: 429}},
+		{"wildcard", "anthropic", []float64{0.5}, Outcome{StatusCode: 503}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := New(faults)
+			in.rand = fixed(tt.rolls...)
+			if got := in.Roll(tt.provider); got != tt.want {
+				t.Errorf("Roll(%s) = %+v, want %+v", tt.provider, got, tt.want)
+			}
+		})
+	}
+
+	if got := New(map[string]Fault{"openai": {RateLimit: 1}}).Roll("deepseek"); got != (Outcome{}) {
+		t.Errorf("Roll() for a provider without faults = %+v", got)
+	}
+}
+
+func TestFaultValidate(t *testing.T) {
+	tests := []struct {
+		fault Fault
+		want  string // "" for valid
+	}{
+		{Fault{RateLimit: 0.1, ServerError: 0.2, Latency: 1}, ""},
+		{Fault{RateLimit: 1.5}, "rate_limit must be between 0 and 1"},
+		{Fault{Latency: -0.1}, "latency must be between 0 and 1"},
+		{Fault{RateLimit: 0.6, ServerError: 0.6}, "must not exceed 1"},
+		{Fault{ServerError: 0.1, ServerErrorStatus: 404}, "must be a 5xx status"},
+	}
+	for _, tt := range tests {
+		err := tt.fault.Validate()
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.fault, err, tt.want)
+		}
+	}
+}
+
+func TestResponse(t *testing.T) {
+	resp := Response(nil, http.StatusTooManyRequests)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 429 || resp.Header.Get("Retry-After") != "1" || resp.Header.Get(InjectedHeader) != "rate_limit_error" {
+		t.Errorf("Response(429) = %d %v", resp.StatusCode, resp.Header)
+	}
+	if !strings.Contains(string(body), `"type":"rate_limit_error"`) {
+		t.Errorf("Response(429) body = %s", body)
+	}
+	if resp := Response(nil, 503); resp.Header.Get(InjectedHeader) != "server_error" || resp.Header.Get("Retry-After") != "" {
+		t.Errorf("Response(503) headers = %v", resp.Header)
+	}
+}
@@ -0,0 +1,274 @@
+// Package clickhouse streams request records to ClickHouse for long-term
+// analytics. The primary database stays the operational store; the sink is
+// best effort and drops records it cannot deliver.
+package clickhouse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Config defines the ClickHouse HTTP endpoint and batching.
+type Config struct {
+	URL           string // HTTP interface, e.g. http://localhost:8123
+	Database      string // default "default"
+	Table         string // default "agix_requests"
+	Username      string
+	Password      string
+	BatchSize     int           // default 1000
+	FlushInterval time.Duration // default 5s
+}
+
+const (
+	defaultDatabase      = "default"
+	defaultTable         = "agix_requests"
+	defaultBatchSize     = 1000
+	defaultFlushInterval = 5 * time.Second
+)
+
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Sink batches records and inserts them with INSERT ... FORMAT JSONEachRow.
+type Sink struct {
+	cfg    Config
+	client *http.Client
+	ch     chan *store.Record
+
+	mu      sync.Mutex
+	dropped int
+
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New validates cfg and fills in defaults. The sink does not send anything
+// until Start is called.
+func New(cfg Config) (*Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("clickhouse url is required")
+	}
+	if _, err := url.Parse(cfg.URL); err != nil {
+		return nil, fmt.Errorf("clickhouse url: %w", err)
+	}
+	if cfg.Database == "" {
+		cfg.Database = defaultDatabase
+	}
+	if cfg.Table == "" {
+		cfg.Table = defaultTable
+	}
+	for _, id := range []string{cfg.Database, cfg.Table} {
+		if !identRe.MatchString(id) {
+			return nil, fmt.Errorf("invalid clickhouse identifier %q", id)
+		}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	return &Sink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		ch:     make(chan *store.Record, cfg.BatchSize*4),
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// CreateTable creates the destination table if it does not exist.
+func (s *Sink) CreateTable() error {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s (
+	timestamp          DateTime64(3, 'UTC'),
+	request_id         String,
+	agent_name         LowCardinality(String),
+	model              LowCardinality(String),
+	provider           LowCardinality(String),
+	request_type       LowCardinality(String),
+	input_tokens       UInt32,
+	output_tokens      UInt32,
+	reasoning_tokens   UInt32,
+	cache_write_tokens UInt32,
+	cache_read_tokens  UInt32,
+	cost_usd           Float64,
+	duration_ms        UInt32,
+	status_code        UInt16,
+	failover_from      String,
+	original_model     String,
+	ttft_ms            UInt32,
+	tokens_per_sec     Float64
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (agent_name, timestamp)`, s.cfg.Database, s.cfg.Table)
+	return s.exec(ddl, nil)
+}
+
+// Start launches the background batch writer.
+func (s *Sink) Start() {
+	s.started = true
+	go s.run()
+}
+
+// Send queues a record. When the queue is full the record is dropped so a
+// slow ClickHouse never holds up requests.
+func (s *Sink) Send(r *store.Record) {
+	select {
+	case s.ch <- r:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+}
+
+// Dropped returns the number of records that were not delivered.
+func (s *Sink) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close flushes queued records and stops the writer.
+func (s *Sink) Close() {
+	s.closeOnce.Do(func() {
+		close(s.ch)
+		if s.started {
+			<-s.done
+		}
+	})
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+
+	buf := make([]*store.Record, 0, s.cfg.BatchSize)
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(buf) == 0 {
+			return
+		}
+		if err := s.insert(buf); err != nil {
+			log.Printf("WARN: clickhouse: dropped %d record(s): %v", len(buf), err)
+			s.mu.Lock()
+			s.dropped += len(buf)
+			s.mu.Unlock()
+		}
+		buf = buf[:0]
+	}
+
+	for {
+		select {
+		case r, ok := <-s.ch:
+			if !ok {
+				flush()
+				return
+			}
+			buf = append(buf, r)
+			if len(buf) >= s.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// row is the JSONEachRow encoding of a record.
+type row struct {
+	Timestamp        string  `json:"timestamp"`
+	RequestID        string  `json:"request_id"`
+	AgentName        string  `json:"agent_name"`
+	Model            string  `json:"model"`
+	Provider         string  `json:"provider"`
+	RequestType      string  `json:"request_type"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	ReasoningTokens  int     `json:"reasoning_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	DurationMS       int64   `json:"duration_ms"`
+	StatusCode       int     `json:"status_code"`
+	FailoverFrom     string  `json:"failover_from"`
+	OriginalModel    string  `json:"original_model"`
+	TTFTMS           int64   `json:"ttft_ms"`
+	TokensPerSec     float64 `json:"tokens_per_sec"`
+}
+
+func toRow(r *store.Record) row {
+	requestType := r.RequestType
+	if requestType == "" {
+		requestType = store.RequestTypeChat
+	}
+	return row{
+		Timestamp:        r.Timestamp.UTC().Format("2006-01-02 15:04:05.000"),
+		RequestID:        r.RequestID,
+		AgentName:        r.AgentName,
+		Model:            r.Model,
+		Provider:         r.Provider,
+		RequestType:      requestType,
+		InputTokens:      r.InputTokens,
+		OutputTokens:     r.OutputTokens,
+		ReasoningTokens:  r.ReasoningTokens,
+		CacheWriteTokens: r.CacheWriteTokens,
+		CacheReadTokens:  r.CacheReadTokens,
+		CostUSD:          r.CostUSD,
+		DurationMS:       r.DurationMS,
+		StatusCode:       r.StatusCode,
+		FailoverFrom:     r.FailoverFrom,
+		OriginalModel:    r.OriginalModel,
+		TTFTMS:           r.TTFTMS,
+		TokensPerSec:     r.TokensPerSec,
+	}
+}
+
+func (s *Sink) insert(records []*store.Record) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, r := range records {
+		if err := enc.Encode(toRow(r)); err != nil {
+			return fmt.Errorf("encode record: %w", err)
+		}
+	}
+	return s.exec(fmt.Sprintf("INSERT INTO %s.%s FORMAT JSONEachRow", s.cfg.Database, s.cfg.Table), &body)
+}
+
+// exec runs query over the HTTP interface. Data, if any, is sent as the
+// request body after the query.
+func (s *Sink) exec(query string, data io.Reader) error {
+	u := strings.TrimRight(s.cfg.URL, "/") + "/?" + url.Values{"query": {query}}.Encode()
+	if data == nil {
+		data = http.NoBody
+	}
+	req, err := http.NewRequest(http.MethodPost, u, data)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	if s.cfg.Username != "" {
+		req.Header.Set("X-ClickHouse-User", s.cfg.Username)
+		req.Header.Set("X-ClickHouse-Key", s.cfg.Password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("clickhouse request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("clickhouse returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
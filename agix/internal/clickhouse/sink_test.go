@@ -0,0 +1,134 @@
+package clickhouse
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+type fakeServer struct {
+	mu      sync.Mutex
+	queries []string
+	rows    []row
+	user    string
+	status  int
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := r.URL.Query().Get("query")
+	f.queries = append(f.queries, q)
+	f.user = r.Header.Get("X-ClickHouse-User")
+	if f.status != 0 {
+		http.Error(w, "Code: 60. Table does not exist", f.status)
+		return
+	}
+	if strings.HasPrefix(q, "INSERT") {
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var rw row
+			if err := json.Unmarshal(sc.Bytes(), &rw); err == nil {
+				f.rows = append(f.rows, rw)
+			}
+		}
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing url", Config{}},
+		{"bad table", Config{URL: "http://localhost:8123", Table: "requests; DROP TABLE x"}},
+		{"bad database", Config{URL: "http://localhost:8123", Database: "a.b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("New() succeeded, want error")
+			}
+		})
+	}
+
+	s, err := New(Config{URL: "http://localhost:8123"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if s.cfg.Database != "default" || s.cfg.Table != "agix_requests" || s.cfg.BatchSize != 1000 || s.cfg.FlushInterval != 5*time.Second {
+		t.Errorf("defaults not applied: %+v", s.cfg)
+	}
+}
+
+func TestSinkBatchesInserts(t *testing.T) {
+	fake := &fakeServer{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s, err := New(Config{URL: srv.URL, Database: "analytics", Username: "agix", Password: "pw", BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.CreateTable(); err != nil {
+		t.Fatalf("CreateTable: %v", err)
+	}
+	s.Start()
+
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 250e6, time.UTC)
+	s.Send(&store.Record{Timestamp: ts, AgentName: "a", Model: "gpt-4o", Provider: "openai", InputTokens: 10, CostUSD: 0.5, StatusCode: 200})
+	s.Send(&store.Record{Timestamp: ts, AgentName: "b", Model: "gpt-4o", RequestType: store.RequestTypeEmbedding})
+	s.Send(&store.Record{Timestamp: ts, AgentName: "c"}) // flushed on Close
+	s.Close()
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.queries) != 3 {
+		t.Fatalf("got %d queries, want CREATE + 2 INSERTs: %q", len(fake.queries), fake.queries)
+	}
+	if !strings.HasPrefix(fake.queries[0], "CREATE TABLE IF NOT EXISTS analytics.agix_requests") {
+		t.Errorf("first query = %q", fake.queries[0])
+	}
+	if fake.queries[1] != "INSERT INTO analytics.agix_requests FORMAT JSONEachRow" {
+		t.Errorf("insert query = %q", fake.queries[1])
+	}
+	if fake.user != "agix" {
+		t.Errorf("X-ClickHouse-User = %q", fake.user)
+	}
+	if len(fake.rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(fake.rows))
+	}
+	if r := fake.rows[0]; r.Timestamp != "2026-03-01 12:00:00.250" || r.RequestType != "chat" || r.CostUSD != 0.5 {
+		t.Errorf("row[0] = %+v", r)
+	}
+	if fake.rows[1].RequestType != "embedding" {
+		t.Errorf("row[1].RequestType = %q", fake.rows[1].RequestType)
+	}
+}
+
+func TestSinkCountsFailedBatches(t *testing.T) {
+	fake := &fakeServer{status: http.StatusNotFound}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s, err := New(Config{URL: srv.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.CreateTable(); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("CreateTable() error = %v, want 404", err)
+	}
+	s.Start()
+	s.Send(&store.Record{Timestamp: time.Now(), AgentName: "a"})
+	s.Close()
+	if got := s.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1", got)
+	}
+}
@@ -119,19 +119,21 @@ func (c *Compressor) Compress(messages json.RawMessage) json.RawMessage {
 
 func (c *Compressor) summarize(msgs []Message) (string, error) {
 	if c.summarizeFn == nil {
-		return c.fallbackSummarize(msgs), nil
+		return extractiveSummary(msgs), nil
 	}
+	return c.summarizeFn(c.cfg.SummaryModel, summaryPrompt(msgs))
+}
 
-	summaryPrompt := []Message{
+// summaryPrompt builds the LLM request that summarizes msgs.
+func summaryPrompt(msgs []Message) []Message {
+	return []Message{
 		{Role: "system", Content: "Summarize the following conversation concisely. Focus on key decisions, facts, and context that would be needed to continue the conversation. Be brief."},
 		{Role: "user", Content: formatMessagesForSummary(msgs)},
 	}
-
-	return c.summarizeFn(c.cfg.SummaryModel, summaryPrompt)
 }
 
-// fallbackSummarize creates a simple extractive summary without an LLM.
-func (c *Compressor) fallbackSummarize(msgs []Message) string {
+// extractiveSummary creates a simple extractive summary without an LLM.
+func extractiveSummary(msgs []Message) string {
 	var parts []string
 	for _, m := range msgs {
 		content := m.Content
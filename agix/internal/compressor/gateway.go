@@ -0,0 +1,70 @@
+package compressor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// GatewaySummarizer returns a SummarizeFunc that sends summary requests
+// through the gateway at baseURL as agent _gateway/compressor, so the
+// call is priced, recorded and budgeted like any other request. token is
+// the gateway's internal token, see apikeys.MarkInternal.
+func GatewaySummarizer(baseURL, token string) SummarizeFunc {
+	return GatewaySummarizerAs(baseURL, store.AgentCompressor, token)
+}
+
+// GatewaySummarizerAs is GatewaySummarizer with the calls attributed to
+// agent instead.
+func GatewaySummarizerAs(baseURL, agent, token string) SummarizeFunc {
+	client := &http.Client{Timeout: 60 * time.Second}
+	url := baseURL + "/v1/chat/completions"
+	return func(model string, messages []Message) (string, error) {
+		body, err := json.Marshal(map[string]any{"model": model, "messages": messages})
+		if err != nil {
+			return "", fmt.Errorf("marshal request: %w", err)
+		}
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return "", fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Agent-Name", agent)
+		apikeys.MarkInternal(req, token)
+		req.Header.Set("X-Force-Model", model) // keep the configured summary model
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("summary request: %w", err)
+		}
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("read response: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("summary request returned status %d: %s", resp.StatusCode, string(respBody))
+		}
+
+		var result struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return "", fmt.Errorf("parse response: %w", err)
+		}
+		if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+			return "", fmt.Errorf("empty summary")
+		}
+		return result.Choices[0].Message.Content, nil
+	}
+}
@@ -0,0 +1,52 @@
+package compressor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/apikeys"
+)
+
+func TestGatewaySummarizer(t *testing.T) {
+	var gotModel string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Agent-Name"); got != "_gateway/compressor" {
+			t.Errorf("X-Agent-Name = %q, want _gateway/compressor", got)
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body.Model
+		if got := r.Header.Get("X-Force-Model"); got != gotModel {
+			t.Errorf("X-Force-Model = %q, want %q", got, gotModel)
+		}
+		if got := r.Header.Get(apikeys.InternalHeader); got != "gateway-token" {
+			t.Errorf("%s = %q, want the gateway token", apikeys.InternalHeader, got)
+		}
+		if gotModel == "broken" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"short summary"}}]}`))
+	}))
+	defer srv.Close()
+
+	fn := GatewaySummarizer(srv.URL, "gateway-token")
+	got, err := fn("gpt-4o-mini", []Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("summarize error: %v", err)
+	}
+	if got != "short summary" || gotModel != "gpt-4o-mini" {
+		t.Errorf("summary = %q (model %q), want %q", got, gotModel, "short summary")
+	}
+
+	if _, err := fn("broken", []Message{{Role: "user", Content: "hi"}}); err == nil {
+		t.Error("expected error on non-200 response")
+	}
+}
@@ -0,0 +1,91 @@
+package compressor
+
+import "fmt"
+
+// Summary methods.
+const (
+	MethodAuto       = "auto"
+	MethodLLM        = "llm"
+	MethodExtractive = "extractive"
+)
+
+// Summarizer exposes the compressor's summarization as a standalone
+// service, for agents that want a summary explicitly rather than as a side
+// effect of compression.
+type Summarizer struct {
+	model       string
+	summarizeFn SummarizeFunc
+}
+
+// Summary is the result of Summarizer.Summarize.
+type Summary struct {
+	Summary  string `json:"summary"`
+	Method   string `json:"method"`
+	Model    string `json:"model,omitempty"`
+	Messages int    `json:"messages"`
+	// Estimated token counts of the input conversation and the summary.
+	InputTokens   int `json:"input_tokens"`
+	SummaryTokens int `json:"summary_tokens"`
+}
+
+// NewSummarizer creates a Summarizer. Without fn (or a model), only
+// extractive summaries are available.
+func NewSummarizer(model string, fn SummarizeFunc) *Summarizer {
+	if model == "" {
+		fn = nil
+	}
+	return &Summarizer{model: model, summarizeFn: fn}
+}
+
+// Model returns the LLM summary model, or "" if only extractive summaries
+// are available.
+func (s *Summarizer) Model() string {
+	if s.summarizeFn == nil {
+		return ""
+	}
+	return s.model
+}
+
+// Summarize summarizes msgs. method is MethodLLM, MethodExtractive or
+// MethodAuto (LLM when a summary model is configured, else extractive).
+// model overrides the configured summary model for LLM summaries.
+func (s *Summarizer) Summarize(msgs []Message, method, model string) (Summary, error) {
+	if len(msgs) == 0 {
+		return Summary{}, fmt.Errorf("no messages to summarize")
+	}
+	if method == "" {
+		method = MethodAuto
+	}
+	if method == MethodAuto {
+		method = MethodExtractive
+		if s.summarizeFn != nil {
+			method = MethodLLM
+		}
+	}
+
+	out := Summary{Method: method, Messages: len(msgs)}
+	for _, m := range msgs {
+		out.InputTokens += estimateTokens(m.Content)
+	}
+
+	switch method {
+	case MethodExtractive:
+		out.Summary = extractiveSummary(msgs)
+	case MethodLLM:
+		if s.summarizeFn == nil {
+			return Summary{}, fmt.Errorf("LLM summaries need a summary model")
+		}
+		if model == "" {
+			model = s.model
+		}
+		text, err := s.summarizeFn(model, summaryPrompt(msgs))
+		if err != nil {
+			return Summary{}, err
+		}
+		out.Summary, out.Model = text, model
+	default:
+		return Summary{}, fmt.Errorf("unknown method %q (want %s, %s or %s)", method, MethodAuto, MethodLLM, MethodExtractive)
+	}
+	out.SummaryTokens = estimateTokens(out.Summary)
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package compressor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSummarizer(t *testing.T) {
+	msgs := []Message{
+		{Role: "user", Content: "Deploy the service to staging."},
+		{Role: "assistant", Content: "Deployed build 42 to staging."},
+	}
+	var gotModel string
+	llm := func(model string, prompt []Message) (string, error) {
+		gotModel = model
+		if model == "broken" {
+			return "", errors.New("upstream 429")
+		}
+		if len(prompt) != 2 || !strings.Contains(prompt[1].Content, "build 42") {
+			t.Errorf("summary prompt = %+v", prompt)
+		}
+		return "Build 42 is on staging.", nil
+	}
+
+	tests := []struct {
+		name       string
+		s          *Summarizer
+		method     string
+		model      string
+		wantMethod string
+		wantModel  string
+		wantText   string
+		wantErr    bool
+	}{
+		{"auto uses llm", NewSummarizer("gpt-4o-mini", llm), "", "", MethodLLM, "gpt-4o-mini", "Build 42 is on staging.", false},
+		{"auto without model is extractive", NewSummarizer("", llm), MethodAuto, "", MethodExtractive, "", "[assistant]: Deployed build 42", false},
+		{"explicit extractive", NewSummarizer("gpt-4o-mini", llm), MethodExtractive, "", MethodExtractive, "", "[user]: Deploy the service", false},
+		{"model override", NewSummarizer("gpt-4o-mini", llm), MethodLLM, "claude-haiku-4-5", MethodLLM, "claude-haiku-4-5", "Build 42", false},
+		{"llm without model", NewSummarizer("", nil), MethodLLM, "", "", "", "", true},
+		{"llm error", NewSummarizer("gpt-4o-mini", llm), MethodLLM, "broken", "", "", "", true},
+		{"unknown method", NewSummarizer("gpt-4o-mini", llm), "abstractive", "", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotModel = ""
+			got, err := tt.s.Summarize(msgs, tt.method, tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Summarize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Method != tt.wantMethod || got.Model != tt.wantModel || !strings.Contains(got.Summary, tt.wantText) {
+				t.Errorf("Summarize() = %+v, want method %s model %q containing %q", got, tt.wantMethod, tt.wantModel, tt.wantText)
+			}
+			if tt.wantMethod == MethodLLM && gotModel != tt.wantModel {
+				t.Errorf("LLM called with model %q, want %q", gotModel, tt.wantModel)
+			}
+			if got.Messages != 2 || got.InputTokens == 0 || got.SummaryTokens == 0 {
+				t.Errorf("counts = %+v", got)
+			}
+		})
+	}
+
+	if _, err := NewSummarizer("", nil).Summarize(nil, "", ""); err == nil {
+		t.Error("expected error for empty messages")
+	}
+}
@@ -0,0 +1,76 @@
+package compressor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCannotFit is returned by Truncate when the messages it always keeps
+// exceed the budget on their own.
+var ErrCannotFit = errors.New("system messages and the last message exceed the token budget")
+
+// Truncation is the result of Truncate.
+type Truncation struct {
+	Messages  []Message `json:"messages"`
+	Dropped   int       `json:"dropped"`
+	MaxTokens int       `json:"max_tokens"`
+	// Token counts of the input and the trimmed messages, estimated the
+	// way the compressor counts them.
+	InputTokens int `json:"input_tokens"`
+	Tokens      int `json:"tokens"`
+}
+
+// Truncate trims msgs to at most maxTokens by dropping the oldest
+// conversation messages, the same ones Compress would summarize. System
+// messages and the last message are always kept.
+func Truncate(msgs []Message, maxTokens int) (Truncation, error) {
+	if maxTokens <= 0 {
+		return Truncation{}, fmt.Errorf("max tokens must be positive, got %d", maxTokens)
+	}
+	out := Truncation{MaxTokens: maxTokens}
+	tokens := make([]int, len(msgs))
+	for i, m := range msgs {
+		tokens[i] = estimateTokens(m.Content)
+		out.InputTokens += tokens[i]
+	}
+
+	keep := make([]bool, len(msgs))
+	total := 0
+	for i, m := range msgs {
+		if m.Role == "system" || i == len(msgs)-1 {
+			keep[i] = true
+			total += tokens[i]
+		}
+	}
+	if total > maxTokens {
+		return Truncation{}, fmt.Errorf("%w: ~%d tokens (max %d)", ErrCannotFit, total, maxTokens)
+	}
+
+	// Keep conversation messages newest first until the next one won't
+	// fit; everything older goes, so the kept history stays contiguous.
+	for i := len(msgs) - 2; i >= 0; i-- {
+		if keep[i] {
+			continue
+		}
+		if total+tokens[i] > maxTokens {
+			break
+		}
+		keep[i] = true
+		total += tokens[i]
+	}
+
+	out.Messages = make([]Message, 0, len(msgs))
+	for i, m := range msgs {
+		if keep[i] {
+			out.Messages = append(out.Messages, m)
+		}
+	}
+	out.Dropped = len(msgs) - len(out.Messages)
+	out.Tokens = total
+	return out, nil
+}
+
+// Threshold returns the token count above which Compress compresses.
+func (c *Compressor) Threshold() int {
+	return c.cfg.ThresholdTokens
+}
@@ -0,0 +1,64 @@
+package compressor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	words := func(n int) string { return strings.TrimSpace(strings.Repeat("word ", n)) }
+	// 10 words estimate to 13 tokens
+	msgs := []Message{
+		{Role: "system", Content: words(10)},
+		{Role: "user", Content: words(10)},
+		{Role: "assistant", Content: words(10)},
+		{Role: "user", Content: words(10)},
+		{Role: "assistant", Content: words(10)},
+		{Role: "user", Content: words(10)},
+	}
+	roles := func(ms []Message) string {
+		var r []string
+		for _, m := range ms {
+			r = append(r, m.Role)
+		}
+		return strings.Join(r, ",")
+	}
+
+	tests := []struct {
+		name      string
+		maxTokens int
+		wantRoles string
+		dropped   int
+		tokens    int
+		wantErr   error
+	}{
+		{"fits", 100, "system,user,assistant,user,assistant,user", 0, 78, nil},
+		{"drops oldest", 52, "system,user,assistant,user", 2, 52, nil},
+		{"just under a message", 51, "system,assistant,user", 3, 39, nil},
+		{"only kept messages", 26, "system,user", 4, 26, nil},
+		{"cannot fit", 25, "", 0, 0, ErrCannotFit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Truncate(msgs, tt.maxTokens)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Truncate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Truncate() error = %v", err)
+			}
+			if roles(got.Messages) != tt.wantRoles || got.Dropped != tt.dropped || got.Tokens != tt.tokens || got.InputTokens != 78 {
+				t.Errorf("Truncate() = %s, dropped %d, %d/%d tokens; want %s, dropped %d, %d/78 tokens",
+					roles(got.Messages), got.Dropped, got.Tokens, got.InputTokens, tt.wantRoles, tt.dropped, tt.tokens)
+			}
+		})
+	}
+
+	if _, err := Truncate(msgs, 0); err == nil {
+		t.Error("Truncate() with zero budget: want error")
+	}
+}
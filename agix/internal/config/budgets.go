@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Budgets maps agents, and JWT budget groups, to their budgets. Three kinds
+// of entries cap more than one agent: "global" caps all traffic, with or
+// without an agent name, the entries under "providers" cap the spend on
+// one provider and those under "models" the spend on one model:
+//
+//	budgets:
+//	  global:
+//	    daily_limit_usd: 200
+//	  providers:
+//	    openai:
+//	      monthly_limit_usd: 3000
+//	  models:
+//	    gpt-4o:
+//	      daily_limit_usd: 50
+//	  research-agent:
+//	    daily_limit_usd: 20
+//
+// A provider's entry is held under ProviderBudget(name), a model's under
+// ModelBudget(name).
+type Budgets map[string]Budget
+
+// GlobalBudget is the key of the budget on all traffic.
+const GlobalBudget = "global"
+
+const (
+	providerBudgetPrefix = "provider:"
+	modelBudgetPrefix    = "model:"
+)
+
+// ProviderBudget returns the key of provider's budget.
+func ProviderBudget(provider string) string {
+	return providerBudgetPrefix + provider
+}
+
+// ModelBudget returns the key of model's budget.
+func ModelBudget(model string) string {
+	return modelBudgetPrefix + model
+}
+
+// BudgetScope returns whose spend the budget under key caps: an agent, a
+// provider, a model, or none of them for the global budget.
+func BudgetScope(key string) (agent, provider, model string) {
+	if key == GlobalBudget {
+		return "", "", ""
+	}
+	if p, ok := strings.CutPrefix(key, providerBudgetPrefix); ok {
+		return "", p, ""
+	}
+	if m, ok := strings.CutPrefix(key, modelBudgetPrefix); ok {
+		return "", "", m
+	}
+	return key, "", ""
+}
+
+// sections are the budgets sections whose entries are keyed by prefix.
+var sections = map[string]func(string) string{
+	"providers": ProviderBudget,
+	"models":    ModelBudget,
+}
+
+// UnmarshalYAML flattens the providers and models sections into
+// ProviderBudget and ModelBudget keys.
+func (b *Budgets) UnmarshalYAML(n *yaml.Node) error {
+	var raw map[string]yaml.Node
+	if err := n.Decode(&raw); err != nil {
+		return err
+	}
+	out := make(Budgets, len(raw))
+	for key, v := range raw {
+		if keyOf, ok := sections[key]; ok {
+			var entries map[string]Budget
+			if err := v.Decode(&entries); err != nil {
+				return fmt.Errorf("budgets.%s: %w", key, err)
+			}
+			for name, eb := range entries {
+				out[keyOf(name)] = eb
+			}
+			continue
+		}
+		var budget Budget
+		if err := v.Decode(&budget); err != nil {
+			return fmt.Errorf("budgets.%s: %w", key, err)
+		}
+		out[key] = budget
+	}
+	*b = out
+	return nil
+}
+
+// MarshalYAML writes provider and model budgets back under their
+// sections.
+func (b Budgets) MarshalYAML() (any, error) {
+	out := make(map[string]any, len(b))
+	providers := map[string]Budget{}
+	models := map[string]Budget{}
+	for key, budget := range b {
+		switch _, p, m := BudgetScope(key); {
+		case p != "":
+			providers[p] = budget
+		case m != "":
+			models[m] = budget
+		default:
+			out[key] = budget
+		}
+	}
+	if len(providers) > 0 {
+		out["providers"] = providers
+	}
+	if len(models) > 0 {
+		out["models"] = models
+	}
+	return out, nil
+}
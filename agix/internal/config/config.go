@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,11 +15,15 @@ import (
 type Config struct {
 	Port       int                        `yaml:"port"`
 	Keys       map[string]string          `yaml:"keys"`
+	KeyPools   map[string]KeyPoolConfig   `yaml:"key_pools"` // provider → several keys used in turn
 	Database   string                     `yaml:"database"`
 	LogLevel   string                     `yaml:"log_level"`
-	Budgets    map[string]Budget          `yaml:"budgets"`
+	Budgets    Budgets                    `yaml:"budgets"` // agent, "global" or provider → budget
 	Tools      ToolsConfig                `yaml:"tools"`
 	RateLimits map[string]RateLimitConfig `yaml:"rate_limits"`
+	GlobalRateLimit RateLimitConfig       `yaml:"global_rate_limit"` // all agents combined
+	ProviderRateLimits map[string]ProviderRateLimitConfig `yaml:"provider_rate_limits"` // provider → outgoing ceilings
+	UpstreamQuota UpstreamQuotaConfig  `yaml:"upstream_quota"`
 	Failover   FailoverConfig             `yaml:"failover"`
 	Routing    RoutingConfig              `yaml:"routing"`
 	Dashboard  DashboardConfig            `yaml:"dashboard"`
@@ -33,6 +39,404 @@ type Config struct {
 	Webhooks         WebhookConfig             `yaml:"webhooks"`
 	Bundles          []string                  `yaml:"bundles"`
 	ResponsePolicy   ResponsePolicyConfig      `yaml:"response_policy"`
+	Alerts           AlertsConfig              `yaml:"alerts"`
+	LoadShedding     LoadSheddingConfig        `yaml:"load_shedding"`
+	AdmissionQueue   AdmissionQueueConfig      `yaml:"admission_queue"`
+	PenaltyBox       PenaltyBoxConfig          `yaml:"penalty_box"`
+	HA               HAConfig                  `yaml:"ha"`
+	Thinking         ThinkingConfig            `yaml:"thinking"`
+	PromptCaching    PromptCachingConfig       `yaml:"prompt_caching"`
+	DataAPI          DataAPIConfig             `yaml:"data_api"`
+	Auth             AuthConfig                `yaml:"auth"`
+	TLS              TLSConfig                 `yaml:"tls"`
+	Admin            AdminConfig               `yaml:"admin"`
+	Redis            RedisConfig               `yaml:"redis"`
+	ClickHouse       ClickHouseConfig          `yaml:"clickhouse"`
+	Archive          ArchiveConfig             `yaml:"archive"`
+	ModelCatalog     ModelCatalogConfig        `yaml:"model_catalog"`
+	CostCenters      string                    `yaml:"cost_centers"` // agent → cost center mapping file, relative to the config file
+	Projects         map[string]string         `yaml:"projects"`     // agent name or "prefix*" → project, for requests without X-Project
+	Chaos            ChaosConfig               `yaml:"chaos"`
+	OutageQueue      OutageQueueConfig         `yaml:"outage_queue"`
+	Transforms       map[string]ProviderTransformConfig `yaml:"transforms"` // provider → transforms
+	Pricing          PricingConfig             `yaml:"pricing"`
+	UsageTrailer     UsageTrailerConfig        `yaml:"usage_trailer"`
+	Summarizer       SummarizerConfig          `yaml:"summarizer"`
+	Azure            AzureConfig               `yaml:"azure"`
+
+	CORS       CORSConfig       `yaml:"cors"`
+	Ollama     OllamaConfig     `yaml:"ollama"`
+	OpenRouter OpenRouterConfig `yaml:"openrouter"`
+	Bedrock    BedrockConfig    `yaml:"bedrock"`
+	Providers  []ProviderConfig `yaml:"providers"`
+
+	// ContentDatabase keeps traces, audit events and cache entries out of
+	// the primary database. Empty = everything in database.
+	ContentDatabase string `yaml:"content_database"`
+
+	// SpillFile holds request records the database could not take until
+	// they can be replayed. Empty = ~/.agix/spill.jsonl.
+	SpillFile string `yaml:"spill_file"`
+
+	env envRefs
+}
+
+// ProviderConfig registers an OpenAI-compatible upstream (vLLM, Groq,
+// Mistral, ...). Models matching one of its prefixes are sent to
+// {base_url}/chat/completions; prefixes ending in "/" are stripped first.
+type ProviderConfig struct {
+	Name     string   `yaml:"name"`
+	BaseURL  string   `yaml:"base_url"` // e.g. https://api.groq.com/openai/v1
+	APIKey   string   `yaml:"api_key"`  // default keys.{name}; empty = no auth
+	Prefixes []string `yaml:"prefixes"` // default "{name}/"
+	Models   []string `yaml:"models"`   // full model names listed in GET /v1/models
+}
+
+// BedrockConfig configures AWS Bedrock. Agents request "bedrock/{modelId}";
+// unset credentials and region fall back to the standard AWS environment
+// variables.
+type BedrockConfig struct {
+	Region          string            `yaml:"region"`            // default AWS_REGION / AWS_DEFAULT_REGION
+	AccessKeyID     string            `yaml:"access_key_id"`     // default AWS_ACCESS_KEY_ID
+	SecretAccessKey string            `yaml:"secret_access_key"` // default AWS_SECRET_ACCESS_KEY
+	SessionToken    string            `yaml:"session_token"`     // default AWS_SESSION_TOKEN
+	Models          map[string]string `yaml:"models"`            // model ID → base model, for pricing
+}
+
+// OpenRouterConfig configures OpenRouter. Agents request
+// "openrouter/{vendor}/{model}"; the key is keys.openrouter.
+type OpenRouterConfig struct {
+	Referer string   `yaml:"referer"` // sent as HTTP-Referer, identifies the app to OpenRouter
+	Title   string   `yaml:"title"`   // sent as X-Title
+	Models  []string `yaml:"models"`  // listed in GET /v1/models, e.g. "meta-llama/llama-3.3-70b-instruct"
+}
+
+// OllamaConfig configures locally hosted models. Agents request
+// "ollama/{model}"; no API key is needed.
+type OllamaConfig struct {
+	BaseURL string   `yaml:"base_url"` // default http://localhost:11434
+	Models  []string `yaml:"models"`   // listed in GET /v1/models
+}
+
+// CORSConfig lets browser-based agents call the gateway directly. Requests
+// may send the agix headers (X-Agent-Name, X-Session-ID, ...) by default.
+type CORSConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	AllowedOrigins []string `yaml:"allowed_origins"` // exact origins, "*", or a port wildcard like "http://localhost:*"
+	AllowedHeaders []string `yaml:"allowed_headers"` // request headers allowed in addition to the agix defaults
+	MaxAge         int      `yaml:"max_age"`         // preflight cache lifetime in seconds, default 600
+}
+
+// AzureConfig configures Azure OpenAI. Agents request "azure/{deployment}";
+// the key is keys.azure.
+type AzureConfig struct {
+	Resource    string            `yaml:"resource"`    // {resource}.openai.azure.com
+	APIVersion  string            `yaml:"api_version"` // default 2024-10-21
+	Deployments map[string]string `yaml:"deployments"` // deployment → base model, for pricing
+}
+
+// SummarizerConfig enables POST /v1/summarize, which exposes the context
+// compressor's summarization as a service. LLM summaries run as agent
+// _gateway/summarizer so they can be budgeted separately.
+type SummarizerConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	Model          string `yaml:"model"`            // default: compression.summary_model; empty = extractive only
+	MaxInputTokens int    `yaml:"max_input_tokens"` // default 100000
+}
+
+// UsageTrailerConfig appends a usage summary (model, tokens, cost) to
+// responses so agents can log their own consumption without reading headers.
+// Agents can also opt in per request with the X-Usage-Trailer header.
+type UsageTrailerConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Agents  []string `yaml:"agents"` // empty = all agents
+}
+
+// PricingConfig adjusts list prices so recorded costs match the invoice.
+type PricingConfig struct {
+	Discounts map[string]float64    `yaml:"discounts"` // provider or model → fraction off list price (0.2 = 20% off)
+	Windows   []PricingWindowConfig `yaml:"windows"`   // time-of-day rates; first match applies
+}
+
+// PricingWindowConfig applies a price multiplier during a daily time window.
+type PricingWindowConfig struct {
+	Name       string   `yaml:"name"`
+	Start      string   `yaml:"start"`      // "HH:MM"
+	End        string   `yaml:"end"`        // "HH:MM"; before start wraps past midnight
+	Timezone   string   `yaml:"timezone"`   // IANA name, default local time
+	Providers  []string `yaml:"providers"`  // empty = all providers
+	Models     []string `yaml:"models"`     // model name prefixes, empty = all models
+	Multiplier float64  `yaml:"multiplier"` // e.g. 0.5 for half price
+}
+
+// ProviderTransformConfig defines declarative body transforms for one provider.
+type ProviderTransformConfig struct {
+	Request  TransformRulesConfig `yaml:"request"`  // applied to the provider-native upstream body
+	Response TransformRulesConfig `yaml:"response"` // applied to non-streaming responses
+}
+
+// TransformRulesConfig edits top-level JSON fields: rename, then drop, then defaults, then set.
+type TransformRulesConfig struct {
+	Rename   map[string]string `yaml:"rename"`   // old → new field name
+	Drop     []string          `yaml:"drop"`     // fields to remove
+	Defaults map[string]any    `yaml:"defaults"` // added only when absent
+	Set      map[string]any    `yaml:"set"`      // always overwritten
+}
+
+// OutageQueueConfig defines durable retry of opt-in requests (X-Queue-Callback)
+// that fail because every model in their failover chain is down.
+type OutageQueueConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	RetryInterval  string `yaml:"retry_interval"`  // first retry delay, doubled per attempt; default "30s"
+	MaxAttempts    int    `yaml:"max_attempts"`    // default 10
+	MaxAge         string `yaml:"max_age"`         // give up after, default "24h"
+	CallbackSecret string `yaml:"callback_secret"` // signs callbacks with X-Agix-Signature
+}
+
+// ThinkingConfig controls Anthropic extended-thinking content returned to agents.
+type ThinkingConfig struct {
+	Strip bool `yaml:"strip"` // remove thinking blocks from responses (still billed)
+}
+
+// PromptCachingConfig controls automatic Anthropic prompt caching. When
+// enabled, system prompts of at least MinTokens (estimated) are marked with
+// a cache_control breakpoint so later requests read them from the cache.
+type PromptCachingConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	MinTokens int  `yaml:"min_tokens"` // default 1024, Anthropic's minimum cacheable prompt
+}
+
+// PenaltyBoxConfig temporarily throttles agents with sustained error or
+// abuse patterns. Penalties expire on their own or are lifted with
+// `agix agent release`.
+type PenaltyBoxConfig struct {
+	Enabled               bool     `yaml:"enabled"`
+	Window                string   `yaml:"window"`                   // error-rate and duplicate window, default "10m"
+	MinRequests           int      `yaml:"min_requests"`             // requests in window before error_rate applies, default 20
+	ErrorRate             float64  `yaml:"error_rate"`               // fraction of 5xx/429 responses, e.g. 0.5; 0 = off
+	FirewallBlocksPerHour int      `yaml:"firewall_blocks_per_hour"` // 0 = off
+	DuplicateRequests     int      `yaml:"duplicate_requests"`       // identical bodies within window; 0 = off
+	Duration              string   `yaml:"duration"`                 // default "15m"
+	ThrottleRPM           int      `yaml:"throttle_rpm"`             // requests/minute while penalized; 0 = reject all
+	Exempt                []string `yaml:"exempt"`                   // agents never penalized
+	Webhook               string   `yaml:"webhook"`                  // POSTed the penalty as JSON when an agent enters the box
+}
+
+// ModelCatalogConfig enriches GET /v1/models with live model lists from
+// configured providers and per-model metadata.
+type ModelCatalogConfig struct {
+	Refresh         bool                           `yaml:"refresh"`          // fetch live lists from providers with keys
+	RefreshInterval string                         `yaml:"refresh_interval"` // default "1h"
+	Metadata        map[string]ModelMetadataConfig `yaml:"metadata"`         // model ID → metadata, overrides built-ins
+}
+
+// ModelMetadataConfig describes a model's capabilities. Empty fields keep
+// the built-in values.
+type ModelMetadataConfig struct {
+	ContextWindow   int      `yaml:"context_window"`
+	Modalities      []string `yaml:"modalities"`       // e.g. [text, image]
+	DeprecationDate string   `yaml:"deprecation_date"` // YYYY-MM-DD
+}
+
+// ClickHouseConfig streams request records to ClickHouse over its HTTP
+// interface for long-term analytics; the primary database remains the
+// operational store.
+type ClickHouseConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	URL           string `yaml:"url"`            // e.g. http://localhost:8123
+	Database      string `yaml:"database"`       // default "default"
+	Table         string `yaml:"table"`          // default "agix_requests", created if missing
+	Username      string `yaml:"username"`
+	Password      string `yaml:"password"`
+	BatchSize     int    `yaml:"batch_size"`     // default 1000
+	FlushInterval string `yaml:"flush_interval"` // default "5s"
+}
+
+// ArchiveConfig stores full chat request and response bodies, gzipped and
+// content-addressed, outside the database. Each request row keeps the
+// object's key.
+type ArchiveConfig struct {
+	Enabled bool            `yaml:"enabled"`
+	Dir     string          `yaml:"dir"` // local directory, default ~/.agix/archive; ignored when s3.bucket is set
+	S3      ArchiveS3Config `yaml:"s3"`
+}
+
+// ArchiveS3Config identifies an S3-compatible bucket.
+type ArchiveS3Config struct {
+	Endpoint        string `yaml:"endpoint"` // default https://s3.<region>.amazonaws.com
+	Region          string `yaml:"region"`   // default us-east-1
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	PathStyle       bool   `yaml:"path_style"` // required by MinIO
+}
+
+// AuthConfig controls how agix identifies callers: API keys it issues
+// (agix keys) and JWTs from an external identity provider. A valid
+// credential always sets the agent; RequireKeys also rejects requests
+// without one instead of trusting X-Agent-Name.
+type AuthConfig struct {
+	RequireKeys bool      `yaml:"require_keys"`
+	JWT         JWTConfig `yaml:"jwt"`
+}
+
+// JWTConfig validates bearer JWTs against a JWKS URL or a static key and
+// maps their claims to the request's agent, project and budget group.
+// It is enabled when any key source is set.
+type JWTConfig struct {
+	JWKSURL       string `yaml:"jwks_url"`
+	PublicKeyFile string `yaml:"public_key_file"` // PEM RSA, ECDSA or Ed25519 public key; relative to the config file
+	Secret        string `yaml:"secret"`          // HMAC secret for HS256/384/512
+	Issuer        string `yaml:"issuer"`          // required iss, if set
+	Audience      string `yaml:"audience"`        // required aud, if set
+	// Claims naming the agent (default "sub"), the project and the budget
+	// group. A dotted name reads a nested claim, e.g. "agix.project".
+	AgentClaim       string `yaml:"agent_claim"`
+	ProjectClaim     string `yaml:"project_claim"`
+	BudgetGroupClaim string `yaml:"budget_group_claim"`
+}
+
+// Enabled reports whether JWT authentication is configured.
+func (j JWTConfig) Enabled() bool {
+	return j.JWKSURL != "" || j.PublicKeyFile != "" || j.Secret != ""
+}
+
+// RedisConfig points gateway instances at a shared Redis, so rate limits
+// and budget spend are enforced across all of them instead of per
+// process.
+type RedisConfig struct {
+	URL    string `yaml:"url"`    // redis://[:password@]host:port/db, or rediss:// for TLS
+	Prefix string `yaml:"prefix"` // key prefix, default "agix:"
+}
+
+// AdminConfig protects the management endpoints under /admin/.
+type AdminConfig struct {
+	// Token is the bearer token admin requests must carry. Without one,
+	// the admin API only answers requests from this host.
+	Token string `yaml:"token"`
+}
+
+// TLSConfig serves the gateway over HTTPS. Certificate files are re-read
+// when they change, so renewed certificates apply without a restart.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"` // PEM certificate chain; relative to the config file
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile enables mTLS: clients must present a certificate
+	// signed by one of these CAs.
+	ClientCAFile string `yaml:"client_ca_file"`
+	// ClientAuth is "require" (default with client_ca_file) or "optional",
+	// which verifies a certificate only if the client sends one.
+	ClientAuth string `yaml:"client_auth"`
+	MinVersion string `yaml:"min_version"` // "1.2" (default) or "1.3"
+}
+
+// Enabled reports whether the gateway serves HTTPS.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// DataAPIConfig exposes recorded requests and usage totals read-only at
+// /v1/data/ for BI tools. The API is off when no keys are configured.
+type DataAPIConfig struct {
+	Keys []DataAPIKey `yaml:"keys"`
+}
+
+// DataAPIKey is a bearer token for the data API.
+type DataAPIKey struct {
+	Name   string   `yaml:"name"`   // shown in errors and logs
+	Key    string   `yaml:"key"`
+	Agents []string `yaml:"agents"` // agents whose data the key may read; empty = all
+}
+
+// ChaosConfig injects upstream faults for resilience testing. Faults only
+// hit requests that carry the X-Chaos header.
+type ChaosConfig struct {
+	Enabled   bool                        `yaml:"enabled"`
+	Providers map[string]ChaosFaultConfig `yaml:"providers"` // provider → faults; "*" = any other provider
+}
+
+// ChaosFaultConfig sets per-call fault probabilities (0-1) for a provider.
+type ChaosFaultConfig struct {
+	RateLimit         float64 `yaml:"rate_limit"`          // 429
+	ServerError       float64 `yaml:"server_error"`        // server_error_status
+	ServerErrorStatus int     `yaml:"server_error_status"` // default 503
+	Latency           float64 `yaml:"latency"`             // added delay
+	LatencyMS         int     `yaml:"latency_ms"`          // default 2000
+}
+
+// HAConfig defines active-standby mode for gateway pairs sharing a PostgreSQL store.
+type HAConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	LockKey       int64  `yaml:"lock_key"`       // advisory lock key, default derived from "agix"
+	CheckInterval string `yaml:"check_interval"` // e.g. "5s"
+}
+
+// LoadSheddingConfig defines overload self-protection limits.
+// A zero limit disables that signal.
+type LoadSheddingConfig struct {
+	Enabled              bool              `yaml:"enabled"`
+	MaxInFlight          int               `yaml:"max_in_flight"`
+	MaxWriteBacklog      int               `yaml:"max_write_backlog"` // queued async store writes
+	MaxMemoryMB          int               `yaml:"max_memory_mb"`
+	LowPriorityAtPercent float64           `yaml:"low_priority_at_percent"` // default 80
+	RetryAfterSeconds    int               `yaml:"retry_after_seconds"`     // default 5
+	DefaultPriority      string            `yaml:"default_priority"`        // low | normal | high
+	Priorities           map[string]string `yaml:"priorities"`              // agent → priority
+}
+
+// AdmissionQueueConfig makes rate-limited and load-shed requests wait
+// for capacity, most important first, instead of failing at once.
+type AdmissionQueueConfig struct {
+	Enabled         bool              `yaml:"enabled"`
+	MaxQueued       int               `yaml:"max_queued"`       // default 100
+	MaxWait         string            `yaml:"max_wait"`         // default "30s"
+	DefaultPriority string            `yaml:"default_priority"` // low | normal | high, for requests without X-Priority
+	Priorities      map[string]string `yaml:"priorities"`       // agent → priority
+}
+
+// AlertsConfig defines budget alert delivery policy.
+type AlertsConfig struct {
+	Cooldown     string                      `yaml:"cooldown"` // default dedup window, e.g. "5m"
+	Levels       []AlertLevelConfig          `yaml:"levels"`
+	QuietHours   QuietHoursConfig            `yaml:"quiet_hours"`
+	Destinations map[string]AlertDestination `yaml:"destinations"`
+	SMTP         SMTPConfig                  `yaml:"smtp"`
+	Template     string                      `yaml:"template"`      // Go template for webhook bodies; empty = default JSON
+	DashboardURL string                      `yaml:"dashboard_url"` // exposed to templates as .DashboardURL
+}
+
+// SMTPConfig defines the mail server used by email alert destinations.
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"` // default 587
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"` // default username
+}
+
+// AlertLevelConfig defines an escalation level (e.g. warn at 80%, page at 100%).
+type AlertLevelConfig struct {
+	Name             string  `yaml:"name"`
+	AtPercent        float64 `yaml:"at_percent"`
+	DedupWindow      string  `yaml:"dedup_window"` // e.g. "1h"; empty = cooldown
+	BypassQuietHours bool    `yaml:"bypass_quiet_hours"`
+}
+
+// QuietHoursConfig defines a daily window during which alerts are dropped,
+// not queued: the first request still over the threshold afterwards alerts.
+type QuietHoursConfig struct {
+	Start    string `yaml:"start"`    // "22:00"
+	End      string `yaml:"end"`      // "07:00"
+	Timezone string `yaml:"timezone"` // IANA name, default UTC
+}
+
+// AlertDestination defines a named alert webhook and/or email recipients.
+type AlertDestination struct {
+	URL      string   `yaml:"url"`
+	Email    []string `yaml:"email"`    // requires alerts.smtp
+	Levels   []string `yaml:"levels"`   // empty = all levels
+	Template string   `yaml:"template"` // overrides alerts.template for this webhook
 }
 
 // ResponsePolicyConfig defines response post-processing policy settings.
@@ -41,6 +445,10 @@ type ResponsePolicyConfig struct {
 	RedactPatterns []RedactRuleConfig                  `yaml:"redact_patterns"`
 	MaxOutputChars int                                 `yaml:"max_output_chars"`
 	ForceFormat    string                              `yaml:"force_format"`
+	Language       string                              `yaml:"language,omitempty"`       // ISO 639-1 code, e.g. "zh"
+	BannedPhrases  []string                            `yaml:"banned_phrases,omitempty"` // case-insensitive
+	OnViolation    string                              `yaml:"on_violation,omitempty"`   // retry (default), reject, warn
+	MaxRetries     int                                 `yaml:"max_retries,omitempty"`    // default 1
 	Agents         map[string]AgentResponsePolicyConfig `yaml:"agents"`
 }
 
@@ -56,6 +464,9 @@ type AgentResponsePolicyConfig struct {
 	RedactPatterns []RedactRuleConfig `yaml:"redact_patterns"`
 	MaxOutputChars int                `yaml:"max_output_chars"`
 	ForceFormat    string             `yaml:"force_format"`
+	Language       string             `yaml:"language,omitempty"`
+	BannedPhrases  []string           `yaml:"banned_phrases,omitempty"` // added to the global list
+	OnViolation    string             `yaml:"on_violation,omitempty"`
 }
 
 // WebhookConfig defines generic webhook endpoint settings.
@@ -82,13 +493,24 @@ type SessionOverrideConfig struct {
 type AuditConfig struct {
 	Enabled        bool     `yaml:"enabled"`
 	ContentLog     bool     `yaml:"content_log"`
+	PayloadCapture bool     `yaml:"payload_capture"` // snapshot body per pipeline stage (requires content_log)
 	DangerousTools []string `yaml:"dangerous_tools"`
+	RetentionDays  int      `yaml:"retention_days"` // prune older events (except legal holds); 0 keeps forever
 }
 
 // TracingConfig defines request tracing settings.
 type TracingConfig struct {
 	Enabled    bool    `yaml:"enabled"`
 	SampleRate float64 `yaml:"sample_rate"`
+	// OTLP exports sampled traces to an OpenTelemetry collector as well.
+	OTLP OTLPConfig `yaml:"otlp"`
+}
+
+// OTLPConfig defines an OTLP/HTTP trace exporter.
+type OTLPConfig struct {
+	Endpoint    string            `yaml:"endpoint"`     // e.g. http://localhost:4318; /v1/traces is appended
+	Headers     map[string]string `yaml:"headers"`      // e.g. x-honeycomb-team
+	ServiceName string            `yaml:"service_name"` // default "agix"
 }
 
 // PromptTemplateConfig defines prompt template injection settings.
@@ -120,6 +542,7 @@ type ExperimentConfig struct {
 	ControlModel string `yaml:"control_model"`
 	VariantModel string `yaml:"variant_model"`
 	TrafficPct   int    `yaml:"traffic_pct"`
+	ExcludeAgents []string `yaml:"exclude_agents"` // agents never enrolled
 }
 
 // CompressionConfig defines context compressor settings.
@@ -135,6 +558,10 @@ type CacheConfig struct {
 	Enabled             bool    `yaml:"enabled"`
 	SimilarityThreshold float64 `yaml:"similarity_threshold"`
 	TTLMinutes          int     `yaml:"ttl_minutes"`
+
+	// EquivalentModels lists groups of models that share cache entries,
+	// e.g. a model and its dated aliases.
+	EquivalentModels [][]string `yaml:"equivalent_models,omitempty"`
 }
 
 // QualityGateConfig defines quality gate settings.
@@ -144,6 +571,9 @@ type QualityGateConfig struct {
 	OnEmpty     string `yaml:"on_empty"`
 	OnTruncated string `yaml:"on_truncated"`
 	OnRefusal   string `yaml:"on_refusal"`
+	// OnSchemaMismatch validates answers against the request's
+	// response_format: retry, warn or reject. Empty disables the check.
+	OnSchemaMismatch string `yaml:"on_schema_mismatch,omitempty"`
 }
 
 // DashboardConfig defines the web dashboard settings.
@@ -156,6 +586,7 @@ type RoutingConfig struct {
 	Enabled  bool                          `yaml:"enabled"`
 	Tiers    map[string]RoutingTier        `yaml:"tiers"`
 	ModelMap map[string]map[string]string   `yaml:"model_map"`
+	ExcludeAgents []string                 `yaml:"exclude_agents"` // agents never routed
 }
 
 // RoutingTier defines criteria for classifying a request.
@@ -169,12 +600,60 @@ type RoutingTier struct {
 type FailoverConfig struct {
 	MaxRetries int                 `yaml:"max_retries"`
 	Chains     map[string][]string `yaml:"chains"`
+
+	Agents map[string]FailoverAgentConfig `yaml:"agents"` // per-agent limits on X-Failover / X-Max-Retries
+}
+
+// FailoverAgentConfig permits an agent to override failover per request.
+type FailoverAgentConfig struct {
+	Chains     []string `yaml:"chains"`      // chain names selectable with X-Failover
+	MaxRetries int      `yaml:"max_retries"` // highest X-Max-Retries allowed
 }
 
 // RateLimitConfig defines per-agent rate limits.
 type RateLimitConfig struct {
 	RequestsPerMinute int `yaml:"requests_per_minute"`
 	RequestsPerHour   int `yaml:"requests_per_hour"`
+	// Token limits count prompt and completion tokens. A request is
+	// admitted on an estimate (prompt size plus max_tokens), corrected
+	// once the response reports its usage.
+	TokensPerMinute int `yaml:"tokens_per_minute"`
+	TokensPerDay    int `yaml:"tokens_per_day"`
+}
+
+// ProviderRateLimitConfig defines ceilings on outgoing traffic to one
+// provider. Requests over the ceiling are delayed rather than rejected.
+type ProviderRateLimitConfig struct {
+	RequestsPerMinute int    `yaml:"requests_per_minute"`
+	TokensPerMinute   int    `yaml:"tokens_per_minute"` // estimated prompt tokens + max_tokens
+	Burst             string `yaml:"burst"`             // unused capacity that may accumulate, default "1s"
+	MaxWait           string `yaml:"max_wait"`          // reject with 429 beyond this delay, default "30s"
+}
+
+// UpstreamQuotaConfig acts on the rate limit headers providers return
+// per model (x-ratelimit-remaining-*, anthropic-ratelimit-*): requests to
+// a model nearly out of quota wait for the reset or move to the failover
+// chain before the provider starts answering 429.
+type UpstreamQuotaConfig struct {
+	Enabled             bool    `yaml:"enabled"`
+	MinRemainingPercent float64 `yaml:"min_remaining_percent"` // default 5
+	MaxWait             string  `yaml:"max_wait"`              // hold for a reset at most this long, default "5s"
+}
+
+// KeyPoolConfig spreads a provider's traffic over several API keys. Keys
+// the provider rejects (401/403) or rate-limits (429) are skipped for a
+// while, so one exhausted key does not fail every agent.
+type KeyPoolConfig struct {
+	Strategy       string    `yaml:"strategy"`        // round_robin (default) or weighted
+	Keys           []PoolKey `yaml:"keys"`
+	Quarantine     string    `yaml:"quarantine"`      // how long a 429'd key is skipped without Retry-After, default "1m"
+	AuthQuarantine string    `yaml:"auth_quarantine"` // how long a 401/403'd key is skipped, default "1h"
+}
+
+// PoolKey is one key in a KeyPoolConfig.
+type PoolKey struct {
+	Key    string `yaml:"key"`    // the key or a vault:/keychain: reference
+	Weight int    `yaml:"weight"` // share under the weighted strategy, default 1
 }
 
 // Budget represents a spending budget for an agent.
@@ -183,11 +662,77 @@ type Budget struct {
 	MonthlyLimitUSD float64 `yaml:"monthly_limit_usd"`
 	AlertAtPercent  float64 `yaml:"alert_at_percent"`
 	AlertWebhook    string  `yaml:"alert_webhook"`
+	// AlertDestinations names entries in alerts.destinations.
+	AlertDestinations []string `yaml:"alert_destinations,omitempty"`
+	// Rollover carries unused daily budget over to later days of the same
+	// month: an agent idle on Monday may spend two days' worth on Tuesday.
+	Rollover bool `yaml:"rollover,omitempty"`
+	// Credits enables the prepaid credit model: the agent spends down the
+	// credit granted to it (agix budget topup, POST /admin/credits/{agent})
+	// and is blocked once the balance reaches zero.
+	Credits bool `yaml:"credits,omitempty"`
+	// WeeklyLimitUSD caps spend in the calendar week, Monday to Sunday UTC.
+	WeeklyLimitUSD float64 `yaml:"weekly_limit_usd,omitempty"`
+	// Rolling caps spend over trailing windows, such as the last 7 days.
+	Rolling []RollingLimit `yaml:"rolling,omitempty"`
+	// OnExceed is what happens to requests once the budget is used up:
+	// "block" (the default) rejects them with 429, "downgrade" sends them
+	// to FallbackModel instead.
+	OnExceed      string `yaml:"on_exceed,omitempty"`
+	FallbackModel string `yaml:"fallback_model,omitempty"`
+}
+
+// RollingLimit caps spend over the trailing Window.
+type RollingLimit struct {
+	// Window is a number of days, such as "7d", or a duration such as "12h".
+	Window   string  `yaml:"window"`
+	LimitUSD float64 `yaml:"limit_usd"`
+}
+
+// Duration returns the length of the window.
+func (r RollingLimit) Duration() (time.Duration, error) {
+	if n, ok := strings.CutSuffix(r.Window, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(r.Window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("rolling window %q: want days such as 7d or a duration such as 12h", r.Window)
+	}
+	return d, nil
+}
+
+// Values of Budget.OnExceed.
+const (
+	OnExceedBlock     = "block"
+	OnExceedDowngrade = "downgrade"
+)
+
+// Downgrades reports whether requests over the budget are sent to its
+// fallback model rather than rejected.
+func (b Budget) Downgrades() bool {
+	return b.OnExceed == OnExceedDowngrade && b.FallbackModel != ""
+}
+
+// DailyAllowance returns the daily limit in effect on day, given the
+// agent's spend earlier in the same month. Without rollover it is
+// DailyLimitUSD; with rollover, budget left unused on earlier days of the
+// month is added. Overspending an earlier day never lowers the allowance.
+func (b Budget) DailyAllowance(day time.Time, spentBeforeToday float64) float64 {
+	if !b.Rollover || b.DailyLimitUSD <= 0 {
+		return b.DailyLimitUSD
+	}
+	carry := b.DailyLimitUSD*float64(day.Day()-1) - spentBeforeToday
+	return b.DailyLimitUSD + max(carry, 0)
 }
 
 // ToolsConfig holds shared MCP tool configuration.
 type ToolsConfig struct {
 	MaxIterations int                    `yaml:"max_iterations"`
+	// MaxRepeatCalls stops a tool loop once the model has issued the same
+	// call with identical arguments this many times (default 3).
+	MaxRepeatCalls int `yaml:"max_repeat_calls,omitempty"`
 	Servers       map[string]MCPServer   `yaml:"servers"`
 	Agents        map[string]AgentTools  `yaml:"agents"`
 }
@@ -203,8 +748,23 @@ type MCPServer struct {
 type AgentTools struct {
 	Allow []string `yaml:"allow"`
 	Deny  []string `yaml:"deny"`
+	// ClientTools sets what happens to tools the agent sends itself:
+	// merge (default), replace or skip.
+	ClientTools string `yaml:"client_tools,omitempty"`
 }
 
+// Client tool modes for AgentTools.ClientTools.
+const (
+	// ClientToolsMerge offers the agent's tools next to the MCP tools and
+	// returns calls to the agent's tools for it to run.
+	ClientToolsMerge = "merge"
+	// ClientToolsReplace drops the agent's tools in favor of the MCP tools.
+	ClientToolsReplace = "replace"
+	// ClientToolsSkip leaves requests that carry their own tools alone: no
+	// MCP tools are injected.
+	ClientToolsSkip = "skip"
+)
+
 // DefaultConfigDir returns the default configuration directory (~/.agix).
 func DefaultConfigDir() (string, error) {
 	home, err := os.UserHomeDir()
@@ -240,22 +800,63 @@ func DefaultConfig() Config {
 		Keys:     map[string]string{},
 		Database: dbPath,
 		LogLevel: "info",
-		Budgets:  map[string]Budget{},
+		Budgets:  Budgets{},
 		Tools: ToolsConfig{
 			MaxIterations: 10,
 		},
 	}
 }
 
-// Load reads a config file from disk.
+// Load reads a config file from disk and merges the config.d files next
+// to it over it.
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read config file: %w", err)
 	}
+	includes, err := IncludeFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", IncludeDir, err)
+	}
+
+	return parse(data, includes)
+}
+
+// LoadFile reads only the config file at path, without config.d. Commands
+// that change and Save the config use it, so included settings are not
+// copied into the main file.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes config YAML on top of the defaults. ${VAR} and
+// ${VAR:-default} in values are replaced from the environment.
+func Parse(data []byte) (*Config, error) {
+	return parse(data, nil)
+}
 
+// parse decodes data with the include files merged over it.
+func parse(data []byte, includes []string) (*Config, error) {
 	cfg := DefaultConfig()
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file: %w", err)
+	}
+	for _, f := range includes {
+		if err := mergeFile(&doc, f); err != nil {
+			return nil, err
+		}
+	}
+	if doc.Kind == 0 {
+		return &cfg, nil // empty file
+	}
+	expandEnv(&doc, "", &cfg.env)
+	if err := doc.Decode(&cfg); err != nil {
 		return nil, fmt.Errorf("parse config file: %w", err)
 	}
 
@@ -269,7 +870,7 @@ func Save(path string, cfg *Config) error {
 		return fmt.Errorf("create config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(cfg)
+	data, err := cfg.marshal()
 	if err != nil {
 		return fmt.Errorf("marshal config: %w", err)
 	}
@@ -289,7 +890,7 @@ func SaveWithComments(path string, cfg *Config) error {
 		return fmt.Errorf("create config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(cfg)
+	data, err := cfg.marshal()
 	if err != nil {
 		return fmt.Errorf("marshal config: %w", err)
 	}
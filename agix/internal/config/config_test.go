@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -94,6 +97,80 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestBudgetsSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yml := `budgets:
+  global:
+    daily_limit_usd: 200
+  providers:
+    openai:
+      monthly_limit_usd: 3000
+  models:
+    gpt-4o:
+      daily_limit_usd: 50
+  agent-1:
+    daily_limit_usd: 10
+`
+	if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := Budgets{
+		GlobalBudget:             {DailyLimitUSD: 200},
+		ProviderBudget("openai"): {MonthlyLimitUSD: 3000},
+		ModelBudget("gpt-4o"):    {DailyLimitUSD: 50},
+		"agent-1":                {DailyLimitUSD: 10},
+	}
+	if !reflect.DeepEqual(cfg.Budgets, want) {
+		t.Fatalf("Budgets = %+v, want %+v", cfg.Budgets, want)
+	}
+	if agent, provider, model := BudgetScope(ProviderBudget("openai")); agent != "" || provider != "openai" || model != "" {
+		t.Errorf("BudgetScope(provider:openai) = %q, %q, %q", agent, provider, model)
+	}
+	if agent, provider, model := BudgetScope(ModelBudget("gpt-4o")); agent != "" || provider != "" || model != "gpt-4o" {
+		t.Errorf("BudgetScope(model:gpt-4o) = %q, %q, %q", agent, provider, model)
+	}
+
+	// Save writes provider budgets back under providers.
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "provider:openai") || strings.Contains(string(data), "model:gpt-4o") {
+		t.Errorf("saved config has flattened budget keys:\n%s", data)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() after Save: %v", err)
+	}
+	if !reflect.DeepEqual(reloaded.Budgets, want) {
+		t.Errorf("Budgets after Save = %+v, want %+v", reloaded.Budgets, want)
+	}
+}
+
+func TestRollingLimitDuration(t *testing.T) {
+	tests := []struct {
+		window string
+		want   time.Duration
+	}{
+		{"7d", 7 * 24 * time.Hour},
+		{"30d", 30 * 24 * time.Hour},
+		{"12h", 12 * time.Hour},
+		{"0d", 0},
+		{"-1h", 0},
+		{"week", 0},
+	}
+	for _, tt := range tests {
+		got, err := RollingLimit{Window: tt.window}.Duration()
+		if got != tt.want || (err == nil) != (tt.want > 0) {
+			t.Errorf("Duration(%q) = %v, %v; want %v", tt.window, got, err, tt.want)
+		}
+	}
+}
+
 func TestLoadNonExistentFile(t *testing.T) {
 	_, err := Load("/nonexistent/path/config.yaml")
 	if err == nil {
@@ -353,3 +430,255 @@ func TestBudgetStruct(t *testing.T) {
 		})
 	}
 }
+
+func TestBudgetDailyAllowance(t *testing.T) {
+	day := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC) // 3 days before it this month
+	tests := []struct {
+		name   string
+		budget Budget
+		spent  float64
+		want   float64
+	}{
+		{"no rollover", Budget{DailyLimitUSD: 5}, 0, 5},
+		{"unused days carry over", Budget{DailyLimitUSD: 5, Rollover: true}, 4, 16},
+		{"fully spent", Budget{DailyLimitUSD: 5, Rollover: true}, 15, 5},
+		{"overspent", Budget{DailyLimitUSD: 5, Rollover: true}, 20, 5},
+		{"no daily limit", Budget{MonthlyLimitUSD: 100, Rollover: true}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.budget.DailyAllowance(day, tt.spent); got != tt.want {
+				t.Errorf("DailyAllowance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformsConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := `
+transforms:
+  anthropic:
+    request:
+      drop: [frequency_penalty]
+      defaults:
+        temperature: 0.7
+  deepseek:
+    request:
+      rename:
+        max_completion_tokens: max_tokens
+    response:
+      set:
+        provider: deepseek
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	anth := cfg.Transforms["anthropic"].Request
+	if len(anth.Drop) != 1 || anth.Drop[0] != "frequency_penalty" || anth.Defaults["temperature"] != 0.7 {
+		t.Errorf("anthropic request transform = %+v", anth)
+	}
+	ds := cfg.Transforms["deepseek"]
+	if ds.Request.Rename["max_completion_tokens"] != "max_tokens" || ds.Response.Set["provider"] != "deepseek" {
+		t.Errorf("deepseek transform = %+v", ds)
+	}
+}
+
+func TestPricingConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := `
+pricing:
+  discounts:
+    openai: 0.15
+  windows:
+    - name: deepseek-offpeak
+      start: "16:30"
+      end: "00:30"
+      timezone: UTC
+      providers: [deepseek]
+      multiplier: 0.5
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Pricing.Discounts["openai"] != 0.15 {
+		t.Errorf("discounts = %v, want openai 0.15", cfg.Pricing.Discounts)
+	}
+	if len(cfg.Pricing.Windows) != 1 {
+		t.Fatalf("len(windows) = %d, want 1", len(cfg.Pricing.Windows))
+	}
+	w := cfg.Pricing.Windows[0]
+	if w.Start != "16:30" || w.End != "00:30" || w.Timezone != "UTC" || w.Multiplier != 0.5 || len(w.Providers) != 1 {
+		t.Errorf("window = %+v", w)
+	}
+}
+
+func TestEnvInterpolation(t *testing.T) {
+	t.Setenv("AGIX_TEST_OPENAI_KEY", "sk-from-env")
+	t.Setenv("AGIX_TEST_PORT", "9191")
+
+	cfg, err := Parse([]byte(`
+port: ${AGIX_TEST_PORT}
+keys:
+  openai: ${AGIX_TEST_OPENAI_KEY}
+  anthropic: "${AGIX_TEST_UNSET_KEY}"
+database: ${AGIX_TEST_UNSET_DB:-/tmp/agix.db}
+log_level: $${NOT_EXPANDED}
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if cfg.Port != 9191 {
+		t.Errorf("Port = %d, want 9191", cfg.Port)
+	}
+	if cfg.Keys["openai"] != "sk-from-env" {
+		t.Errorf("Keys[openai] = %q", cfg.Keys["openai"])
+	}
+	if cfg.Database != "/tmp/agix.db" {
+		t.Errorf("Database = %q, want default", cfg.Database)
+	}
+	if cfg.LogLevel != "${NOT_EXPANDED}" {
+		t.Errorf("LogLevel = %q, want escaped literal", cfg.LogLevel)
+	}
+	if got := cfg.UnsetEnv(); len(got) != 1 || got[0] != "AGIX_TEST_UNSET_KEY" {
+		t.Errorf("UnsetEnv() = %v", got)
+	}
+
+	// Saving writes the references back, not the secrets.
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg.Budgets["bot"] = Budget{DailyLimitUSD: 5}
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-from-env") || !strings.Contains(string(data), "${AGIX_TEST_OPENAI_KEY}") {
+		t.Errorf("saved config leaks the expanded key:\n%s", data)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if reloaded.Port != 9191 || reloaded.Keys["openai"] != "sk-from-env" || reloaded.Budgets["bot"].DailyLimitUSD != 5 {
+		t.Errorf("reloaded config = port %d, key %q, budgets %v", reloaded.Port, reloaded.Keys["openai"], reloaded.Budgets)
+	}
+}
+
+func TestSaveRestoresEnvByPath(t *testing.T) {
+	t.Setenv("AGIX_TEST_SHARED_KEY", "sk-shared")
+
+	cfg, err := Parse([]byte(`
+keys:
+  openai: ${AGIX_TEST_SHARED_KEY}
+  anthropic: sk-shared
+  deepseek: ${AGIX_TEST_SHARED_KEY}
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	cfg.Keys["deepseek"] = "sk-rotated"
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the field that held the reference gets it back; a literal with
+	// the same value and a value changed since Parse are written as they are.
+	for _, want := range []string{
+		"openai: ${AGIX_TEST_SHARED_KEY}",
+		"anthropic: sk-shared",
+		"deepseek: sk-rotated",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("saved config lacks %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestLoadIncludes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(path, `
+port: 9000
+keys:
+  openai: sk-main
+budgets:
+  bot:
+    daily_limit_usd: 5
+    monthly_limit_usd: 50
+firewall:
+  enabled: true
+  rules:
+    - {name: main, category: pii, pattern: "a", action: log}
+`)
+	incDir := filepath.Join(dir, IncludeDir)
+	write(filepath.Join(incDir, "10-budgets.yaml"), `
+budgets:
+  bot:
+    daily_limit_usd: 8
+  ci:
+    monthly_limit_usd: 20
+`)
+	write(filepath.Join(incDir, "20-firewall.yml"), `
+firewall:
+  rules:
+    - {name: extra, category: pii, pattern: "b", action: block}
+`)
+	write(filepath.Join(incDir, "30-empty.yaml"), "")
+	write(filepath.Join(incDir, "notes.txt"), "port: 1")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Port != 9000 || cfg.Keys["openai"] != "sk-main" {
+		t.Errorf("main values lost: port %d, key %q", cfg.Port, cfg.Keys["openai"])
+	}
+	if b := cfg.Budgets["bot"]; b.DailyLimitUSD != 8 || b.MonthlyLimitUSD != 50 {
+		t.Errorf("budgets[bot] = %+v, want daily 8 (include) and monthly 50 (main)", b)
+	}
+	if _, ok := cfg.Budgets["ci"]; !ok {
+		t.Error("budgets[ci] from include missing")
+	}
+	if n := len(cfg.Firewall.Rules); n != 2 || !cfg.Firewall.Enabled {
+		t.Errorf("firewall = enabled %v, %d rules, want rules appended", cfg.Firewall.Enabled, n)
+	}
+
+	main, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+	if _, ok := main.Budgets["ci"]; ok || len(main.Firewall.Rules) != 1 {
+		t.Error("LoadFile() merged config.d")
+	}
+
+	write(filepath.Join(incDir, "40-bad.yaml"), "- not a mapping")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "40-bad.yaml") {
+		t.Errorf("Load() with a list include: %v", err)
+	}
+}
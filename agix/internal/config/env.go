@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPattern matches ${VAR} and ${VAR:-default}. $${VAR} is an escaped,
+// literal ${VAR}.
+var envPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// envRefs remembers how values were interpolated so Save can write the
+// references back instead of the secrets they resolved to.
+type envRefs struct {
+	original map[string]envRef // YAML path → interpolated value
+	unset    map[string]bool
+}
+
+// envRef is a value that was interpolated: what it expanded to and the
+// text in the file.
+type envRef struct {
+	expanded, text string
+}
+
+// childPath returns the YAML path of a mapping key or sequence index below
+// path.
+func childPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// expandEnv replaces environment references in every scalar value of the
+// document. Mapping keys are left alone.
+func expandEnv(n *yaml.Node, path string, refs *envRefs) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			expandEnv(c, path, refs)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			expandEnv(c, childPath(path, strconv.Itoa(i)), refs)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			expandEnv(n.Content[i], childPath(path, n.Content[i-1].Value), refs)
+		}
+	case yaml.ScalarNode:
+		if !envPattern.MatchString(n.Value) {
+			return
+		}
+		expanded := envPattern.ReplaceAllStringFunc(n.Value, func(m string) string {
+			if m[1] == '$' {
+				return m[1:]
+			}
+			sub := envPattern.FindStringSubmatch(m)
+			if v, ok := os.LookupEnv(sub[1]); ok && v != "" {
+				return v
+			}
+			if sub[2] == "" {
+				if refs.unset == nil {
+					refs.unset = map[string]bool{}
+				}
+				refs.unset[sub[1]] = true
+			}
+			return sub[2]
+		})
+		if expanded != "" && expanded != n.Value {
+			if refs.original == nil {
+				refs.original = map[string]envRef{}
+			}
+			refs.original[path] = envRef{expanded: expanded, text: n.Value}
+		}
+		n.Value = expanded
+		// Let an unquoted value resolve to its expanded type, so
+		// port: ${PORT} decodes as an int.
+		if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) == 0 {
+			n.Tag = ""
+		}
+	}
+}
+
+// restoreEnv puts the file's environment references back into an encoded
+// config, at the paths they were read from. A value changed since Load is
+// written as it is.
+func restoreEnv(n *yaml.Node, path string, refs envRefs) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			restoreEnv(c, path, refs)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			restoreEnv(c, childPath(path, strconv.Itoa(i)), refs)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			restoreEnv(n.Content[i], childPath(path, n.Content[i-1].Value), refs)
+		}
+	case yaml.ScalarNode:
+		if ref, ok := refs.original[path]; ok && n.Value == ref.expanded {
+			n.Value = ref.text
+			n.Tag = "!!str"
+			n.Style = 0
+		}
+	}
+}
+
+// UnsetEnv returns the environment variables the config references that
+// were unset or empty and had no default.
+func (c *Config) UnsetEnv() []string {
+	var names []string
+	for name := range c.env.unset {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// marshal encodes c, keeping environment references in place of the
+// values they expanded to.
+func (c *Config) marshal() ([]byte, error) {
+	if len(c.env.original) == 0 {
+		return yaml.Marshal(c)
+	}
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return nil, err
+	}
+	restoreEnv(&doc, "", c.env)
+	return yaml.Marshal(&doc)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IncludeDir is the directory, next to the config file, whose *.yaml
+// files are merged over it.
+const IncludeDir = "config.d"
+
+// IncludeFiles returns the config.d files for the config at path, in the
+// order they are merged (lexical).
+func IncludeFiles(path string) ([]string, error) {
+	dir := filepath.Join(filepath.Dir(path), IncludeDir)
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		m, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, m...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// mergeFile reads one config.d file and merges it over doc.
+func mergeFile(doc *yaml.Node, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	var inc yaml.Node
+	if err := yaml.Unmarshal(data, &inc); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if inc.Kind == 0 {
+		return nil // empty file
+	}
+	if inc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: top level must be a mapping", path)
+	}
+	if doc.Kind == 0 {
+		*doc = inc
+		return nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("config file: top level must be a mapping")
+	}
+	mergeNodes(doc.Content[0], inc.Content[0])
+	return nil
+}
+
+// mergeNodes merges src over dst: mappings merge key by key, sequences
+// are appended, and anything else in src replaces dst.
+func mergeNodes(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, val := src.Content[i], src.Content[i+1]
+		j := mappingIndex(dst, key.Value)
+		if j < 0 {
+			dst.Content = append(dst.Content, key, val)
+			continue
+		}
+		cur := dst.Content[j+1]
+		switch {
+		case cur.Kind == yaml.MappingNode && val.Kind == yaml.MappingNode:
+			mergeNodes(cur, val)
+		case cur.Kind == yaml.SequenceNode && val.Kind == yaml.SequenceNode:
+			cur.Content = append(cur.Content, val.Content...)
+		default:
+			dst.Content[j+1] = val
+		}
+	}
+}
+
+// mappingIndex returns the index of key in mapping node n, or -1.
+func mappingIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,172 @@
+// Package confighistory records every version of the config file, who
+// applied it and how, so changes can be reviewed and rolled back.
+package confighistory
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Change is one recorded version of the config file.
+type Change struct {
+	ID        int64
+	Timestamp time.Time
+	Actor     string // OS user or API caller that applied the change
+	Source    string // how it was applied, e.g. "start", "budget set", "rollback"
+	Diff      string // line diff against the previous version
+	Config    string // full YAML of this version
+}
+
+// History stores config versions in the config_changes table.
+type History struct {
+	db      *sql.DB
+	dialect store.Dialect
+}
+
+// New creates the config_changes table if needed.
+func New(db *sql.DB, dialect store.Dialect) (*History, error) {
+	if err := createTable(db, dialect); err != nil {
+		return nil, fmt.Errorf("create config_changes table: %w", err)
+	}
+	return &History{db: db, dialect: dialect}, nil
+}
+
+func createTable(db *sql.DB, dialect store.Dialect) error {
+	id, text, empty := "INTEGER PRIMARY KEY AUTOINCREMENT", "TEXT", "''"
+	switch dialect {
+	case store.DialectPostgres:
+		id = "BIGSERIAL PRIMARY KEY"
+	case store.DialectMySQL:
+		// MySQL TEXT columns only take expression defaults.
+		id, text, empty = "BIGINT AUTO_INCREMENT PRIMARY KEY", "MEDIUMTEXT", "('')"
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS config_changes (
+		id        ` + id + `,
+		timestamp ` + text + ` NOT NULL,
+		actor     ` + text + ` NOT NULL DEFAULT ` + empty + `,
+		source    ` + text + ` NOT NULL DEFAULT ` + empty + `,
+		diff      ` + text + ` NOT NULL DEFAULT ` + empty + `,
+		config    ` + text + ` NOT NULL
+	)`)
+	return err
+}
+
+// Record stores config as a new version unless it is identical to the
+// latest one. It returns the new change, or nil if nothing changed.
+func (h *History) Record(actor, source string, config []byte) (*Change, error) {
+	prev, err := h.Latest()
+	if err != nil {
+		return nil, err
+	}
+	var old string
+	if prev != nil {
+		old = prev.Config
+	}
+	if old == string(config) {
+		return nil, nil
+	}
+
+	c := &Change{
+		Timestamp: time.Now().UTC(),
+		Actor:     actor,
+		Source:    source,
+		Diff:      Diff(old, string(config)),
+		Config:    string(config),
+	}
+	ts := c.Timestamp.Format(time.RFC3339)
+	if h.dialect == store.DialectPostgres {
+		err = h.db.QueryRow(
+			`INSERT INTO config_changes (timestamp, actor, source, diff, config) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			ts, c.Actor, c.Source, c.Diff, c.Config,
+		).Scan(&c.ID)
+	} else {
+		var res sql.Result
+		res, err = h.db.Exec(
+			`INSERT INTO config_changes (timestamp, actor, source, diff, config) VALUES (?, ?, ?, ?, ?)`,
+			ts, c.Actor, c.Source, c.Diff, c.Config,
+		)
+		if err == nil {
+			c.ID, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("record config change: %w", err)
+	}
+	return c, nil
+}
+
+// Latest returns the most recent version, or nil if none is recorded.
+func (h *History) Latest() (*Change, error) {
+	changes, err := h.query(`ORDER BY id DESC LIMIT 1`)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return &changes[0], nil
+}
+
+// Get returns the version with the given ID, or nil if it does not exist.
+func (h *History) Get(id int64) (*Change, error) {
+	changes, err := h.query(`WHERE id = ?`, id)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return &changes[0], nil
+}
+
+// List returns the most recent versions, newest first.
+func (h *History) List(limit int) ([]Change, error) {
+	return h.query(`ORDER BY id DESC LIMIT ?`, limit)
+}
+
+func (h *History) query(clause string, args ...any) ([]Change, error) {
+	rows, err := h.db.Query(
+		store.Rebind(h.dialect, `SELECT id, timestamp, actor, source, diff, config FROM config_changes `+clause),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query config changes: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Change
+	for rows.Next() {
+		var c Change
+		var ts string
+		if err := rows.Scan(&c.ID, &ts, &c.Actor, &c.Source, &c.Diff, &c.Config); err != nil {
+			return nil, fmt.Errorf("scan config change: %w", err)
+		}
+		c.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Actor returns the name of the local user applying a change.
+func Actor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// Stat summarizes a diff as the number of added and removed lines.
+func Stat(diff string) (added, removed int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,94 @@
+package confighistory
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/store"
+	_ "modernc.org/sqlite"
+)
+
+func testHistory(t *testing.T) *History {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	h, err := New(db, store.DialectSQLite)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return h
+}
+
+func TestRecord(t *testing.T) {
+	h := testHistory(t)
+
+	v1 := "port: 8080\nfirewall:\n  enabled: true\n"
+	c1, err := h.Record("alice", "manual edit", []byte(v1))
+	if err != nil || c1 == nil {
+		t.Fatalf("Record() = %v, %v", c1, err)
+	}
+	if c, _ := h.Record("bob", "start", []byte(v1)); c != nil {
+		t.Errorf("Record() of an unchanged config = %+v, want nil", c)
+	}
+
+	v2 := "port: 8080\nfirewall:\n  enabled: false\n"
+	c2, err := h.Record("bob", "budget set", []byte(v2))
+	if err != nil || c2 == nil {
+		t.Fatalf("Record() = %v, %v", c2, err)
+	}
+	want := "@@ -1,3 +1,3 @@\n port: 8080\n firewall:\n-  enabled: true\n+  enabled: false\n"
+	if c2.Diff != want {
+		t.Errorf("Diff =\n%s\nwant\n%s", c2.Diff, want)
+	}
+
+	list, err := h.List(10)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != c2.ID || list[0].Actor != "bob" || list[1].Source != "manual edit" {
+		t.Errorf("List() = %+v, want newest first", list)
+	}
+
+	got, err := h.Get(c1.ID)
+	if err != nil || got == nil || got.Config != v1 {
+		t.Errorf("Get(%d) = %+v, %v", c1.ID, got, err)
+	}
+	if got, _ := h.Get(999); got != nil {
+		t.Errorf("Get() of a missing version = %+v, want nil", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"identical", "a\nb\n", "a\nb\n", ""},
+		{"from empty", "", "a\nb\n", "@@ -1,0 +1,2 @@\n+a\n+b\n"},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n",
+			"@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.old, tt.new); got != tt.want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStat(t *testing.T) {
+	added, removed := Stat("@@ -1,2 +1,3 @@\n a\n-b\n+c\n+d\n")
+	if added != 2 || removed != 1 {
+		t.Errorf("Stat() = +%d -%d, want +2 -1", added, removed)
+	}
+}
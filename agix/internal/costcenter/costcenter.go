@@ -0,0 +1,139 @@
+// Package costcenter maps agents to cost centers (departments, teams) so
+// stats, digests and exports can be attributed without every agent sending
+// a tag header.
+package costcenter
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/store"
+	"gopkg.in/yaml.v3"
+)
+
+// Unassigned is reported for agents the mapping does not cover and that
+// have no default.
+const Unassigned = "(unassigned)"
+
+// File is the on-disk mapping format:
+//
+//	default: shared
+//	agents:
+//	  support-bot: customer-success
+//	  "ci-*": engineering
+type File struct {
+	Default string            `yaml:"default"`
+	Agents  map[string]string `yaml:"agents"` // agent name or "prefix*" → cost center
+}
+
+// Map resolves agent names to cost centers. A nil Map resolves everything
+// to Unassigned.
+type Map struct {
+	exact    map[string]string
+	prefixes []prefixRule // longest prefix first
+	fallback string
+}
+
+type prefixRule struct {
+	prefix string
+	center string
+}
+
+// Load reads a mapping file from disk.
+func Load(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cost center file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a mapping file.
+func Parse(data []byte) (*Map, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse cost center file: %w", err)
+	}
+	m := &Map{exact: map[string]string{}, fallback: f.Default}
+	for agent, center := range f.Agents {
+		if center == "" {
+			return nil, fmt.Errorf("cost center for agent %q is empty", agent)
+		}
+		if prefix, ok := strings.CutSuffix(agent, "*"); ok {
+			m.prefixes = append(m.prefixes, prefixRule{prefix, center})
+			continue
+		}
+		m.exact[agent] = center
+	}
+	sort.Slice(m.prefixes, func(i, j int) bool {
+		if len(m.prefixes[i].prefix) != len(m.prefixes[j].prefix) {
+			return len(m.prefixes[i].prefix) > len(m.prefixes[j].prefix)
+		}
+		return m.prefixes[i].prefix < m.prefixes[j].prefix
+	})
+	return m, nil
+}
+
+// Lookup returns the cost center for agent: an exact entry, else the
+// longest matching prefix, else the default, else Unassigned.
+func (m *Map) Lookup(agent string) string {
+	if m == nil {
+		return Unassigned
+	}
+	if c, ok := m.exact[agent]; ok {
+		return c
+	}
+	for _, p := range m.prefixes {
+		if strings.HasPrefix(agent, p.prefix) {
+			return p.center
+		}
+	}
+	if m.fallback != "" {
+		return m.fallback
+	}
+	return Unassigned
+}
+
+// Stats is usage rolled up to one cost center.
+type Stats struct {
+	CostCenter   string   `json:"cost_center"`
+	Agents       []string `json:"agents"`
+	Requests     int      `json:"requests"`
+	InputTokens  int      `json:"input_tokens"`
+	OutputTokens int      `json:"output_tokens"`
+	CostUSD      float64  `json:"cost_usd"`
+}
+
+// Rollup aggregates per-agent stats by cost center, most expensive first.
+func (m *Map) Rollup(agents []store.AgentStats) []Stats {
+	byCenter := map[string]*Stats{}
+	var out []*Stats
+	for _, a := range agents {
+		c := m.Lookup(a.AgentName)
+		s, ok := byCenter[c]
+		if !ok {
+			s = &Stats{CostCenter: c}
+			byCenter[c] = s
+			out = append(out, s)
+		}
+		s.Agents = append(s.Agents, a.AgentName)
+		s.Requests += a.Requests
+		s.InputTokens += a.InputTokens
+		s.OutputTokens += a.OutputTokens
+		s.CostUSD += a.CostUSD
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].CostUSD != out[j].CostUSD {
+			return out[i].CostUSD > out[j].CostUSD
+		}
+		return out[i].CostCenter < out[j].CostCenter
+	})
+	result := make([]Stats, len(out))
+	for i, s := range out {
+		sort.Strings(s.Agents)
+		result[i] = *s
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package costcenter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+const testMapping = `
+default: shared
+agents:
+  support-bot: customer-success
+  "ci-*": engineering
+  "ci-release-*": release
+`
+
+func TestLookup(t *testing.T) {
+	m, err := Parse([]byte(testMapping))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		agent string
+		want  string
+	}{
+		{"exact", "support-bot", "customer-success"},
+		{"prefix", "ci-lint", "engineering"},
+		{"longest prefix wins", "ci-release-prod", "release"},
+		{"default", "research", "shared"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Lookup(tt.agent); got != tt.want {
+				t.Errorf("Lookup(%q) = %q, want %q", tt.agent, got, tt.want)
+			}
+		})
+	}
+
+	var nilMap *Map
+	if got := nilMap.Lookup("anything"); got != Unassigned {
+		t.Errorf("nil Lookup = %q, want %q", got, Unassigned)
+	}
+	noDefault, _ := Parse([]byte("agents: {a: x}"))
+	if got := noDefault.Lookup("b"); got != Unassigned {
+		t.Errorf("Lookup without default = %q, want %q", got, Unassigned)
+	}
+}
+
+func TestParseRejectsEmptyCenter(t *testing.T) {
+	if _, err := Parse([]byte("agents: {a: ''}")); err == nil {
+		t.Error("Parse accepted an empty cost center")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cost_centers.yaml")
+	if err := os.WriteFile(path, []byte(testMapping), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := m.Lookup("support-bot"); got != "customer-success" {
+		t.Errorf("Lookup = %q", got)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
+
+func TestRollup(t *testing.T) {
+	m, _ := Parse([]byte(testMapping))
+	rows := m.Rollup([]store.AgentStats{
+		{AgentName: "ci-lint", Requests: 2, InputTokens: 10, OutputTokens: 5, CostUSD: 1},
+		{AgentName: "support-bot", Requests: 1, InputTokens: 100, OutputTokens: 50, CostUSD: 4},
+		{AgentName: "ci-build", Requests: 3, InputTokens: 20, OutputTokens: 10, CostUSD: 2},
+	})
+	if len(rows) != 2 {
+		t.Fatalf("Rollup returned %d rows, want 2: %+v", len(rows), rows)
+	}
+	if rows[0].CostCenter != "customer-success" || rows[0].CostUSD != 4 {
+		t.Errorf("rows[0] = %+v, want customer-success first", rows[0])
+	}
+	eng := rows[1]
+	if eng.CostCenter != "engineering" || eng.Requests != 5 || eng.InputTokens != 30 || eng.CostUSD != 3 {
+		t.Errorf("engineering = %+v", eng)
+	}
+	if len(eng.Agents) != 2 || eng.Agents[0] != "ci-build" || eng.Agents[1] != "ci-lint" {
+		t.Errorf("engineering agents = %v, want sorted [ci-build ci-lint]", eng.Agents)
+	}
+}
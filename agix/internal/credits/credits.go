@@ -0,0 +1,162 @@
+// Package credits implements prepaid credit for agents: amounts granted
+// ahead of time that requests spend down, as an alternative to calendar
+// budgets.
+package credits
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Grant is one credit top-up.
+type Grant struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Agent     string    `json:"agent"`
+	AmountUSD float64   `json:"amount_usd"`
+	Actor     string    `json:"actor"` // OS user or API caller that granted it
+	Note      string    `json:"note,omitempty"`
+}
+
+// Balance is an agent's credit position. Spend counts every request the
+// agent made since its first grant.
+type Balance struct {
+	Agent        string    `json:"agent"`
+	GrantedUSD   float64   `json:"granted_usd"`
+	SpentUSD     float64   `json:"spent_usd"`
+	RemainingUSD float64   `json:"remaining_usd"`
+	Since        time.Time `json:"since,omitzero"` // first grant; zero if none
+}
+
+// Ledger stores grants in the credit_grants table and derives balances
+// from the spend recorded in the requests table.
+type Ledger struct {
+	st *store.Store
+}
+
+// New creates the credit_grants table if needed.
+func New(st *store.Store) (*Ledger, error) {
+	if err := createTable(st.DB(), st.Dialect()); err != nil {
+		return nil, fmt.Errorf("create credit_grants table: %w", err)
+	}
+	return &Ledger{st: st}, nil
+}
+
+func createTable(db *sql.DB, dialect store.Dialect) error {
+	id, amount, text := "INTEGER PRIMARY KEY AUTOINCREMENT", "REAL", "TEXT"
+	switch dialect {
+	case store.DialectPostgres:
+		id, amount = "BIGSERIAL PRIMARY KEY", "DOUBLE PRECISION"
+	case store.DialectMySQL:
+		// MySQL cannot index TEXT columns or give them literal defaults.
+		id, amount, text = "BIGINT AUTO_INCREMENT PRIMARY KEY", "DOUBLE", "VARCHAR(255)"
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS credit_grants (
+		id         ` + id + `,
+		timestamp  ` + text + ` NOT NULL,
+		agent_name ` + text + ` NOT NULL,
+		amount_usd ` + amount + ` NOT NULL,
+		actor      ` + text + ` NOT NULL DEFAULT '',
+		note       ` + text + ` NOT NULL DEFAULT ''
+	)`); err != nil {
+		return err
+	}
+	return store.CreateIndex(db, dialect, "idx_credit_grants_agent", "credit_grants", "agent_name")
+}
+
+// TopUp grants amountUSD of credit to agent.
+func (l *Ledger) TopUp(agent string, amountUSD float64, actor, note string) (*Grant, error) {
+	if agent == "" {
+		return nil, fmt.Errorf("agent is required")
+	}
+	if amountUSD <= 0 {
+		return nil, fmt.Errorf("amount must be positive, got %.2f", amountUSD)
+	}
+	g := &Grant{
+		Timestamp: time.Now().UTC().Truncate(time.Second),
+		Agent:     agent,
+		AmountUSD: amountUSD,
+		Actor:     actor,
+		Note:      note,
+	}
+	db, dialect := l.st.DB(), l.st.Dialect()
+	ts := g.Timestamp.Format(time.RFC3339)
+	var err error
+	if dialect == store.DialectPostgres {
+		err = db.QueryRow(
+			`INSERT INTO credit_grants (timestamp, agent_name, amount_usd, actor, note) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			ts, g.Agent, g.AmountUSD, g.Actor, g.Note,
+		).Scan(&g.ID)
+	} else {
+		var res sql.Result
+		res, err = db.Exec(
+			`INSERT INTO credit_grants (timestamp, agent_name, amount_usd, actor, note) VALUES (?, ?, ?, ?, ?)`,
+			ts, g.Agent, g.AmountUSD, g.Actor, g.Note,
+		)
+		if err == nil {
+			g.ID, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("record credit grant: %w", err)
+	}
+	return g, nil
+}
+
+// Balance returns agent's credit position. An agent never granted credit
+// has a zero balance.
+func (l *Ledger) Balance(agent string) (Balance, error) {
+	b := Balance{Agent: agent}
+	db, dialect := l.st.DB(), l.st.Dialect()
+	var first sql.NullString
+	err := db.QueryRow(
+		store.Rebind(dialect, `SELECT COALESCE(SUM(amount_usd), 0), MIN(timestamp) FROM credit_grants WHERE agent_name = ?`),
+		agent,
+	).Scan(&b.GrantedUSD, &first)
+	if err != nil {
+		return b, fmt.Errorf("query credit grants: %w", err)
+	}
+	if !first.Valid {
+		return b, nil
+	}
+	b.Since, _ = time.Parse(time.RFC3339, first.String)
+	if b.SpentUSD, err = l.st.QueryAgentSpendSince(agent, b.Since); err != nil {
+		return b, err
+	}
+	b.RemainingUSD = b.GrantedUSD - b.SpentUSD
+	return b, nil
+}
+
+// Grants returns agent's most recent grants, newest first. An empty agent
+// lists grants to every agent.
+func (l *Ledger) Grants(agent string, limit int) ([]Grant, error) {
+	query := `SELECT id, timestamp, agent_name, amount_usd, actor, note FROM credit_grants`
+	var args []any
+	if agent != "" {
+		query += ` WHERE agent_name = ?`
+		args = append(args, agent)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := l.st.DB().Query(store.Rebind(l.st.Dialect(), query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query credit grants: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Grant
+	for rows.Next() {
+		var g Grant
+		var ts string
+		if err := rows.Scan(&g.ID, &ts, &g.Agent, &g.AmountUSD, &g.Actor, &g.Note); err != nil {
+			return nil, fmt.Errorf("scan credit grant: %w", err)
+		}
+		g.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
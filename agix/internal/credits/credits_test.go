@@ -0,0 +1,105 @@
+package credits
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func testLedger(t *testing.T) (*Ledger, *store.Store) {
+	t.Helper()
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	l, err := New(st)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return l, st
+}
+
+func spend(t *testing.T, st *store.Store, agent string, at time.Time, cost float64) {
+	t.Helper()
+	r := &store.Record{Timestamp: at, AgentName: agent, Model: "gpt-4o", Provider: "openai", CostUSD: cost, StatusCode: 200}
+	if err := st.Insert(r); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+}
+
+func TestBalance(t *testing.T) {
+	l, st := testLedger(t)
+
+	b, err := l.Balance("research")
+	if err != nil || b.GrantedUSD != 0 || b.RemainingUSD != 0 || !b.Since.IsZero() {
+		t.Fatalf("Balance() before any grant = %+v, %v", b, err)
+	}
+
+	// Spend before the first grant is not charged against the credit.
+	spend(t, st, "research", time.Now().Add(-time.Hour), 7)
+
+	if _, err := l.TopUp("research", 20, "alice", "Q3 research"); err != nil {
+		t.Fatalf("TopUp: %v", err)
+	}
+	if _, err := l.TopUp("research", 5, "bob", ""); err != nil {
+		t.Fatalf("TopUp: %v", err)
+	}
+	spend(t, st, "research", time.Now(), 4.5)
+	spend(t, st, "other", time.Now(), 3)
+
+	b, err = l.Balance("research")
+	if err != nil {
+		t.Fatalf("Balance: %v", err)
+	}
+	if b.GrantedUSD != 25 || math.Abs(b.SpentUSD-4.5) > 1e-9 || math.Abs(b.RemainingUSD-20.5) > 1e-9 {
+		t.Errorf("Balance() = %+v, want granted 25, spent 4.5, remaining 20.5", b)
+	}
+	if b.Since.IsZero() {
+		t.Error("Balance().Since is zero, want the first grant time")
+	}
+}
+
+func TestTopUpRejectsInvalid(t *testing.T) {
+	l, _ := testLedger(t)
+	if _, err := l.TopUp("research", 0, "alice", ""); err == nil {
+		t.Error("TopUp(0) succeeded, want error")
+	}
+	if _, err := l.TopUp("research", -5, "alice", ""); err == nil {
+		t.Error("TopUp(-5) succeeded, want error")
+	}
+	if _, err := l.TopUp("", 5, "alice", ""); err == nil {
+		t.Error("TopUp without agent succeeded, want error")
+	}
+}
+
+func TestGrants(t *testing.T) {
+	l, _ := testLedger(t)
+	for _, g := range []struct {
+		agent  string
+		amount float64
+	}{{"a", 1}, {"b", 2}, {"a", 3}} {
+		if _, err := l.TopUp(g.agent, g.amount, "alice", ""); err != nil {
+			t.Fatalf("TopUp: %v", err)
+		}
+	}
+
+	grants, err := l.Grants("a", 10)
+	if err != nil {
+		t.Fatalf("Grants: %v", err)
+	}
+	if len(grants) != 2 || grants[0].AmountUSD != 3 || grants[1].AmountUSD != 1 || grants[0].Actor != "alice" {
+		t.Errorf("Grants(a) = %+v, want the two grants to a, newest first", grants)
+	}
+
+	all, err := l.Grants("", 2)
+	if err != nil {
+		t.Fatalf("Grants: %v", err)
+	}
+	if len(all) != 2 || all[0].Agent != "a" || all[1].Agent != "b" {
+		t.Errorf("Grants(\"\", 2) = %+v, want the last two grants", all)
+	}
+}
@@ -6,10 +6,17 @@ import (
 	"fmt"
 	"io/fs"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/agent-platform/agix/internal/audit"
 	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/export"
+	"github.com/agent-platform/agix/internal/statscompare"
 	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/tags"
 )
 
 //go:embed static
@@ -17,8 +24,9 @@ var staticFiles embed.FS
 
 // Dashboard serves the web dashboard and API endpoints.
 type Dashboard struct {
-	store   *store.Store
-	cfg     *config.Config
+	store *store.Store
+	cfg   *config.Config
+	admin func(http.HandlerFunc) http.HandlerFunc
 }
 
 // New creates a Dashboard handler.
@@ -26,6 +34,22 @@ func New(cfg *config.Config, st *store.Store) *Dashboard {
 	return &Dashboard{store: st, cfg: cfg}
 }
 
+// SetAdmin sets the check wrapped around endpoints that return prompt or
+// response content. Without one those endpoints answer 403.
+func (d *Dashboard) SetAdmin(wrap func(http.HandlerFunc) http.HandlerFunc) {
+	d.admin = wrap
+}
+
+// adminOnly wraps h in the admin check set by SetAdmin.
+func (d *Dashboard) adminOnly(h http.HandlerFunc) http.HandlerFunc {
+	if d.admin == nil {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error":"admin authentication is not configured"}`, http.StatusForbidden)
+		}
+	}
+	return d.admin(h)
+}
+
 // Register adds dashboard routes to the given mux.
 func (d *Dashboard) Register(mux *http.ServeMux) {
 	// Serve static files
@@ -39,9 +63,16 @@ func (d *Dashboard) Register(mux *http.ServeMux) {
 	// API endpoints
 	mux.HandleFunc("/api/stats", d.handleStats)
 	mux.HandleFunc("/api/agents", d.handleAgents)
+	mux.HandleFunc("/api/projects", d.handleProjects)
 	mux.HandleFunc("/api/budgets", d.handleBudgets)
 	mux.HandleFunc("/api/costs/daily", d.handleDailyCosts)
 	mux.HandleFunc("/api/logs", d.handleLogs)
+	mux.HandleFunc("/api/audit/search", d.adminOnly(d.handleAuditSearch))
+	mux.HandleFunc("/api/stats/compare", d.handleStatsCompare)
+	mux.HandleFunc("/api/stats/streaming", d.handleStreamingStats)
+	mux.HandleFunc("/api/stats/latency", d.handleLatencyStats)
+	mux.HandleFunc("/api/requests/", d.adminOnly(d.handleRequest))
+	mux.HandleFunc("/api/export", d.handleExport)
 }
 
 func (d *Dashboard) handleStats(w http.ResponseWriter, r *http.Request) {
@@ -73,6 +104,22 @@ func (d *Dashboard) handleAgents(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(agents)
 }
 
+// handleProjects serves GET /api/projects: spend per project over the last
+// 30 days, for charging back by project.
+func (d *Dashboard) handleProjects(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -30)
+
+	projects, err := d.store.QueryStatsByProject(since, now)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(projects)
+}
+
 type budgetInfo struct {
 	DailyLimitUSD   float64 `json:"daily_limit_usd"`
 	MonthlyLimitUSD float64 `json:"monthly_limit_usd"`
@@ -84,26 +131,32 @@ func (d *Dashboard) handleBudgets(w http.ResponseWriter, r *http.Request) {
 	now := time.Now().UTC()
 	result := make(map[string]budgetInfo)
 
-	for agent, budget := range d.cfg.Budgets {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for key, budget := range d.cfg.Budgets {
+		agent, provider, model := config.BudgetScope(key)
+		filter := store.SpendFilter{Agent: agent, Provider: provider, Model: model}
 		info := budgetInfo{
 			DailyLimitUSD:   budget.DailyLimitUSD,
 			MonthlyLimitUSD: budget.MonthlyLimitUSD,
 		}
 
 		if budget.DailyLimitUSD > 0 {
-			spend, err := d.store.QueryAgentDailySpend(agent, now)
+			spend, err := d.store.QuerySpend(filter, day, day.AddDate(0, 0, 1))
 			if err == nil {
 				info.DailySpend = spend
 			}
 		}
-		if budget.MonthlyLimitUSD > 0 {
-			spend, err := d.store.QueryAgentMonthlySpend(agent, now.Year(), now.Month())
+		if budget.MonthlyLimitUSD > 0 || budget.Rollover {
+			spend, err := d.store.QuerySpend(filter, month, month.AddDate(0, 1, 0))
 			if err == nil {
 				info.MonthlySpend = spend
+				// Show today's allowance, including rolled-over budget.
+				info.DailyLimitUSD = budget.DailyAllowance(now, spend-info.DailySpend)
 			}
 		}
 
-		result[agent] = info
+		result[key] = info
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -133,6 +186,27 @@ type logEntry struct {
 	CostUSD      float64 `json:"cost_usd"`
 	DurationMS   int64   `json:"duration_ms"`
 	StatusCode   int     `json:"status_code"`
+	RequestID    string  `json:"request_id"`
+	RequestType  string  `json:"request_type"`
+	TTFTMS       int64   `json:"ttft_ms,omitempty"`
+	TokensPerSec float64 `json:"tokens_per_sec,omitempty"`
+}
+
+func newLogEntry(rec store.Record) logEntry {
+	return logEntry{
+		Timestamp:    rec.Timestamp.Format(time.RFC3339),
+		AgentName:    rec.AgentName,
+		Model:        rec.Model,
+		InputTokens:  rec.InputTokens,
+		OutputTokens: rec.OutputTokens,
+		CostUSD:      rec.CostUSD,
+		DurationMS:   rec.DurationMS,
+		StatusCode:   rec.StatusCode,
+		RequestID:    rec.RequestID,
+		RequestType:  rec.RequestType,
+		TTFTMS:       rec.TTFTMS,
+		TokensPerSec: rec.TokensPerSec,
+	}
 }
 
 func (d *Dashboard) handleLogs(w http.ResponseWriter, r *http.Request) {
@@ -144,18 +218,219 @@ func (d *Dashboard) handleLogs(w http.ResponseWriter, r *http.Request) {
 
 	entries := make([]logEntry, 0, len(records))
 	for _, rec := range records {
-		entries = append(entries, logEntry{
-			Timestamp:    rec.Timestamp.Format(time.RFC3339),
-			AgentName:    rec.AgentName,
-			Model:        rec.Model,
-			InputTokens:  rec.InputTokens,
-			OutputTokens: rec.OutputTokens,
-			CostUSD:      rec.CostUSD,
-			DurationMS:   rec.DurationMS,
-			StatusCode:   rec.StatusCode,
-		})
+		entries = append(entries, newLogEntry(rec))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(entries)
 }
+
+// handleExport serves GET /api/export?format=...&since=...&until=...&tag=...:
+// the requests in [since, until) as csv (default), json, ndjson or parquet.
+// since defaults to 30 days ago and until to now; each tag=key=value
+// keeps only requests carrying that tag.
+func (d *Dashboard) handleExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if !slices.Contains(export.Formats, format) {
+		http.Error(w, fmt.Sprintf(`{"error":"unsupported format %q"}`, format), http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	since, until := now.AddDate(0, 0, -30), now
+	for param, t := range map[string]*time.Time{"since": &since, "until": &until} {
+		if v := q.Get(param); v != "" {
+			parsed, err := export.ParseTime(v)
+			if err != nil {
+				http.Error(w, fmt.Sprintf(`{"error":%q}`, param+": "+err.Error()), http.StatusBadRequest)
+				return
+			}
+			*t = parsed
+		}
+	}
+
+	filter, err := tags.ParseFilter(q["tag"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	records, err := d.store.ExportCSV(since, until.Add(-time.Second))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	records = tags.FilterRecords(records, filter)
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="agix-requests.%s"`, format))
+	export.Write(w, format, records, nil)
+}
+
+type requestDetail struct {
+	RequestID   string             `json:"request_id"`
+	Requests    []logEntry         `json:"requests"`
+	Trace       *store.TraceRecord `json:"trace"`
+	AuditEvents []audit.Event      `json:"audit_events"`
+}
+
+// handleRequest serves GET /api/requests/{id}: the requests rows, trace and
+// audit events recorded under one X-Request-ID.
+func (d *Dashboard) handleRequest(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/requests/")
+	if id == "" {
+		http.Error(w, `{"error":"request id is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	records, err := d.store.QueryRequestsByRequestID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	tr, err := d.store.QueryTraceByRequestID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	events, err := audit.New(d.store.ContentDB(), false, d.store.ContentDialect()).QueryByRequestID(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	if len(records) == 0 && tr == nil && len(events) == 0 {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, "no data recorded for request "+id), http.StatusNotFound)
+		return
+	}
+
+	detail := requestDetail{RequestID: id, Requests: make([]logEntry, 0, len(records)), Trace: tr, AuditEvents: events}
+	for _, rec := range records {
+		detail.Requests = append(detail.Requests, newLogEntry(rec))
+	}
+	if detail.AuditEvents == nil {
+		detail.AuditEvents = []audit.Event{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+type auditSearchResult struct {
+	ID        int64  `json:"id"`
+	Timestamp string `json:"timestamp"`
+	AgentName string `json:"agent_name"`
+	SessionID string `json:"session_id"`
+	Direction string `json:"direction"`
+	Model     string `json:"model"`
+	Body      string `json:"body"`
+}
+
+// handleAuditSearch serves GET /api/audit/search?q=...&agent=...&limit=...
+// over logged request/response content.
+func (d *Dashboard) handleAuditSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, `{"error":"q is required"}`, http.StatusBadRequest)
+		return
+	}
+	limit := 50
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+
+	logger := audit.New(d.store.ContentDB(), false, d.store.ContentDialect())
+	events, err := logger.Search(q, r.URL.Query().Get("agent"), limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]auditSearchResult, 0, len(events))
+	for _, e := range events {
+		var details audit.ContentLogDetails
+		json.Unmarshal(e.Details, &details)
+		results = append(results, auditSearchResult{
+			ID:        e.ID,
+			Timestamp: e.Timestamp.Format(time.RFC3339),
+			AgentName: e.AgentName,
+			SessionID: details.SessionID,
+			Direction: details.Direction,
+			Model:     details.Model,
+			Body:      details.Body,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleStatsCompare serves GET /api/stats/compare?a=...&b=... with per-agent
+// and per-model deltas between two ranges (default: last week vs this week).
+func (d *Dashboard) handleStatsCompare(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	parse := func(param, def string) (statscompare.Range, error) {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			v = def
+		}
+		return statscompare.ParseRange(v, now)
+	}
+	a, err := parse("a", "last week")
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, "a: "+err.Error()), http.StatusBadRequest)
+		return
+	}
+	b, err := parse("b", "this week")
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, "b: "+err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	c, err := statscompare.Compare(d.store, a, b)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// handleLatencyStats serves GET /api/stats/latency?by=model|provider|agent:
+// request latency percentiles over the last 30 days.
+func (d *Dashboard) handleLatencyStats(w http.ResponseWriter, r *http.Request) {
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = "model"
+	}
+	if by != "model" && by != "provider" && by != "agent" {
+		http.Error(w, fmt.Sprintf(`{"error":"unsupported by %q (use model, provider or agent)"}`, by), http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	stats, err := d.store.QueryLatencyStats(by, now.AddDate(0, 0, -30), now)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleStreamingStats serves GET /api/stats/streaming: per-model time to
+// first token and tokens/sec percentiles over the last 30 days.
+func (d *Dashboard) handleStreamingStats(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	stats, err := d.store.QueryStreamingStats(now.AddDate(0, 0, -30), now)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/agent-platform/agix/internal/audit"
 	"github.com/agent-platform/agix/internal/config"
 	"github.com/agent-platform/agix/internal/store"
 )
@@ -65,6 +68,308 @@ func TestDashboardAPIAgents(t *testing.T) {
 	}
 }
 
+func TestDashboardAPIProjects(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	defer st.Close()
+	if err := st.Insert(&store.Record{Timestamp: time.Now().UTC(), AgentName: "bot", Model: "gpt-4o", Provider: "openai", CostUSD: 0.01, StatusCode: 200, Project: "search"}); err != nil {
+		t.Fatal(err)
+	}
+
+	d := New(&config.Config{}, st)
+	mux := http.NewServeMux()
+	d.Register(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/projects", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("projects status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var projects []store.ProjectStats
+	if err := json.Unmarshal(w.Body.Bytes(), &projects); err != nil {
+		t.Fatalf("failed to parse projects: %v", err)
+	}
+	if len(projects) != 1 || projects[0].Project != "search" || projects[0].Requests != 1 {
+		t.Errorf("projects = %+v", projects)
+	}
+}
+
+func TestDashboardAPILatency(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	defer st.Close()
+	for _, ms := range []int64{100, 200, 900} {
+		if err := st.Insert(&store.Record{Timestamp: time.Now().UTC(), Model: "gpt-4o", Provider: "openai", DurationMS: ms, StatusCode: 200}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := New(&config.Config{}, st)
+	mux := http.NewServeMux()
+	d.Register(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stats/latency?by=provider", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("latency status = %d: %s", w.Code, w.Body.String())
+	}
+	var stats []store.LatencyStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to parse latency: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Key != "openai" || stats[0].P50MS != 200 || stats[0].P99MS != 900 {
+		t.Errorf("latency = %+v", stats)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stats/latency?by=day", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("by=day status = %d, want 400", w.Code)
+	}
+}
+
+// testAdmin stands in for the proxy's admin token check.
+func testAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer admin-secret" {
+			http.Error(w, `{"error":"invalid or missing admin token"}`, http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func TestDashboardAPIAuditSearch(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	defer st.Close()
+
+	logger := audit.New(st.DB(), true, st.Dialect())
+	logger.Log(audit.EventContentLog, "agent-1", audit.ContentLogDetails{
+		Direction: "request",
+		Model:     "gpt-4o",
+		SessionID: "s-1",
+		Body:      "refund my last order",
+	})
+	logger.Close()
+
+	cfg := &config.Config{Budgets: map[string]config.Budget{}}
+	d := New(cfg, st)
+
+	mux := http.NewServeMux()
+	d.Register(mux)
+
+	// Content is never served without an admin check
+	req := httptest.NewRequest(http.MethodGet, "/api/audit/search?q=refund", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("search without admin check status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	d.SetAdmin(testAdmin)
+	mux = http.NewServeMux()
+	d.Register(mux)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/audit/search?q=refund", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("search without token status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/audit/search?q=refund", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("search status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var results []auditSearchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatalf("failed to parse results: %v", err)
+	}
+	if len(results) != 1 || results[0].SessionID != "s-1" || results[0].AgentName != "agent-1" {
+		t.Errorf("results = %+v, want one agent-1/s-1 match", results)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/audit/search", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("missing q status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestDashboardAPIRequestByID(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	if err := st.Insert(&store.Record{Timestamp: now, AgentName: "agent-1", Model: "gpt-4o", StatusCode: 200, RequestID: "req-42"}); err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+	if err := st.InsertTrace("trace-1", "req-42", "agent-1", "gpt-4o", now, []byte("[]")); err != nil {
+		t.Fatalf("InsertTrace() error: %v", err)
+	}
+	logger := audit.New(st.DB(), true, st.Dialect())
+	logger.LogRequest(audit.EventFirewallWarn, "agent-1", "req-42", map[string]string{"rule": "pii"})
+	logger.Close()
+
+	cfg := &config.Config{Budgets: map[string]config.Budget{}}
+	d := New(cfg, st)
+
+	mux := http.NewServeMux()
+	d.Register(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/requests/req-42", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("request without admin check status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	d.SetAdmin(testAdmin)
+	mux = http.NewServeMux()
+	d.Register(mux)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/requests/req-42", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("request without token status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/requests/req-42", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("request status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var detail requestDetail
+	if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("failed to parse detail: %v", err)
+	}
+	if len(detail.Requests) != 1 || detail.Requests[0].RequestID != "req-42" {
+		t.Errorf("requests = %+v, want one req-42 row", detail.Requests)
+	}
+	if detail.Trace == nil || detail.Trace.TraceID != "trace-1" {
+		t.Errorf("trace = %+v, want trace-1", detail.Trace)
+	}
+	if len(detail.AuditEvents) != 1 || detail.AuditEvents[0].EventType != audit.EventFirewallWarn {
+		t.Errorf("audit events = %+v, want one firewall warn", detail.AuditEvents)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/requests/unknown", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown id status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestDashboardAPIExport(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	defer st.Close()
+
+	for _, ts := range []time.Time{
+		time.Date(2026, 2, 28, 23, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+	} {
+		if err := st.Insert(&store.Record{Timestamp: ts, AgentName: "agent-1", Model: "gpt-4o", StatusCode: 200}); err != nil {
+			t.Fatalf("Insert() error: %v", err)
+		}
+	}
+
+	d := New(&config.Config{Budgets: map[string]config.Budget{}}, st)
+	mux := http.NewServeMux()
+	d.Register(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export?format=ndjson&since=2026-03-01&until=2026-03-02", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("export status = %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"timestamp":"2026-03-01T09:00:00Z"`) {
+		t.Errorf("export = %q, want only the 2026-03-01 row", w.Body.String())
+	}
+
+	for _, q := range []string{"format=xml", "since=yesterday"} {
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/export?"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", q, w.Code)
+		}
+	}
+}
+
+func TestDashboardAPIStatsCompare(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	defer st.Close()
+
+	cfg := &config.Config{Budgets: map[string]config.Budget{}}
+	d := New(cfg, st)
+
+	mux := http.NewServeMux()
+	d.Register(mux)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+	}{
+		{"", http.StatusOK},
+		{"?a=2026-01&b=2026-02", http.StatusOK},
+		{"?a=someday", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats/compare"+tt.query, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != tt.wantStatus {
+			t.Errorf("compare%s status = %d, want %d", tt.query, w.Code, tt.wantStatus)
+			continue
+		}
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("compare%s: invalid JSON: %v", tt.query, err)
+		}
+	}
+}
+
 func TestDashboardStaticFiles(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "test.db")
 	st, err := store.New(dbPath)
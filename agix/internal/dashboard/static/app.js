@@ -161,6 +161,111 @@
       .join("");
   }
 
+  function renderProjectsTable(projects) {
+    var tbody = document.querySelector("#projects-data tbody");
+    if (!projects || projects.length === 0) {
+      tbody.innerHTML =
+        '<tr><td colspan="6" style="text-align:center;color:#8888aa">No project data</td></tr>';
+      return;
+    }
+    tbody.innerHTML = projects
+      .map(function (p) {
+        return (
+          "<tr>" +
+          "<td>" +
+          escapeHTML(p.project) +
+          "</td>" +
+          "<td>" +
+          p.agents +
+          "</td>" +
+          "<td>" +
+          formatTokens(p.requests) +
+          "</td>" +
+          "<td>" +
+          formatTokens(p.input_tokens) +
+          "</td>" +
+          "<td>" +
+          formatTokens(p.output_tokens) +
+          "</td>" +
+          "<td>" +
+          formatUSD(p.cost_usd) +
+          "</td>" +
+          "</tr>"
+        );
+      })
+      .join("");
+  }
+
+  function renderLatencyTable(stats) {
+    var tbody = document.querySelector("#latency-data tbody");
+    if (!stats || stats.length === 0) {
+      tbody.innerHTML =
+        '<tr><td colspan="6" style="text-align:center;color:#8888aa">No requests</td></tr>';
+      return;
+    }
+    tbody.innerHTML = stats
+      .map(function (m) {
+        return (
+          "<tr>" +
+          "<td>" +
+          escapeHTML(m.key) +
+          "</td>" +
+          "<td>" +
+          formatTokens(m.requests) +
+          "</td>" +
+          "<td>" +
+          formatDuration(Math.round(m.avg_ms)) +
+          "</td>" +
+          "<td>" +
+          formatDuration(m.p50_ms) +
+          "</td>" +
+          "<td>" +
+          formatDuration(m.p95_ms) +
+          "</td>" +
+          "<td>" +
+          formatDuration(m.p99_ms) +
+          "</td>" +
+          "</tr>"
+        );
+      })
+      .join("");
+  }
+
+  function renderStreamingTable(stats) {
+    var tbody = document.querySelector("#streaming-data tbody");
+    if (!stats || stats.length === 0) {
+      tbody.innerHTML =
+        '<tr><td colspan="6" style="text-align:center;color:#8888aa">No streaming responses</td></tr>';
+      return;
+    }
+    tbody.innerHTML = stats
+      .map(function (m) {
+        return (
+          "<tr>" +
+          "<td>" +
+          m.model +
+          "</td>" +
+          "<td>" +
+          formatTokens(m.streams) +
+          "</td>" +
+          "<td>" +
+          formatDuration(m.ttft_p50_ms) +
+          "</td>" +
+          "<td>" +
+          formatDuration(m.ttft_p95_ms) +
+          "</td>" +
+          "<td>" +
+          Number(m.tokens_per_sec_p50).toFixed(1) +
+          "</td>" +
+          "<td>" +
+          Number(m.tokens_per_sec_p95).toFixed(1) +
+          "</td>" +
+          "</tr>"
+        );
+      })
+      .join("");
+  }
+
   function renderBudgets(budgets) {
     var el = document.getElementById("budgets-list");
     if (!budgets || Object.keys(budgets).length === 0) {
@@ -269,6 +374,77 @@
       .join("");
   }
 
+  function escapeHTML(s) {
+    return String(s == null ? "" : s).replace(/[&<>"']/g, function (c) {
+      return {
+        "&": "&amp;",
+        "<": "&lt;",
+        ">": "&gt;",
+        '"': "&quot;",
+        "'": "&#39;",
+      }[c];
+    });
+  }
+
+  function excerpt(body, query) {
+    body = (body || "").replace(/\s+/g, " ");
+    var word = (query.split(/\s+/)[0] || "").toLowerCase();
+    var start = Math.max(0, body.toLowerCase().indexOf(word) - 60);
+    var out = body.substr(start, 160);
+    if (start > 0) out = "..." + out;
+    if (start + 160 < body.length) out += "...";
+    return out;
+  }
+
+  function renderAuditSearch(results, query) {
+    var tbody = document.querySelector("#audit-search-data tbody");
+    if (!results || results.length === 0) {
+      tbody.innerHTML =
+        '<tr><td colspan="5" style="text-align:center;color:#8888aa">No matching content</td></tr>';
+      return;
+    }
+    tbody.innerHTML = results
+      .map(function (e) {
+        return (
+          "<tr>" +
+          "<td>" +
+          new Date(e.timestamp).toLocaleString() +
+          "</td>" +
+          "<td>" +
+          escapeHTML(e.agent_name || "-") +
+          "</td>" +
+          "<td>" +
+          escapeHTML(e.session_id || "-") +
+          "</td>" +
+          "<td>" +
+          escapeHTML(e.direction) +
+          "</td>" +
+          "<td>" +
+          escapeHTML(excerpt(e.body, query)) +
+          "</td>" +
+          "</tr>"
+        );
+      })
+      .join("");
+  }
+
+  async function searchAudit(ev) {
+    ev.preventDefault();
+    var q = document.getElementById("audit-search-q").value.trim();
+    var agent = document.getElementById("audit-search-agent").value.trim();
+    if (!q) return;
+    var url = "/api/audit/search?q=" + encodeURIComponent(q);
+    if (agent) url += "&agent=" + encodeURIComponent(agent);
+    try {
+      renderAuditSearch(await fetchJSON(url), q);
+    } catch (e) {
+      showError(
+        document.querySelector("#audit-search-data tbody"),
+        "Error searching content"
+      );
+    }
+  }
+
   // --- Data loading ---
 
   async function loadAll() {
@@ -278,6 +454,9 @@
       fetchJSON("/api/budgets"),
       fetchJSON("/api/costs/daily"),
       fetchJSON("/api/logs"),
+      fetchJSON("/api/stats/streaming"),
+      fetchJSON("/api/projects"),
+      fetchJSON("/api/stats/latency"),
     ]);
 
     if (results[0].status === "fulfilled") {
@@ -315,10 +494,40 @@
         "Error loading data"
       );
     }
+
+    if (results[5].status === "fulfilled") {
+      renderStreamingTable(results[5].value);
+    } else {
+      showError(
+        document.querySelector("#streaming-data tbody"),
+        "Error loading data"
+      );
+    }
+
+    if (results[6].status === "fulfilled") {
+      renderProjectsTable(results[6].value);
+    } else {
+      showError(
+        document.querySelector("#projects-data tbody"),
+        "Error loading data"
+      );
+    }
+
+    if (results[7].status === "fulfilled") {
+      renderLatencyTable(results[7].value);
+    } else {
+      showError(
+        document.querySelector("#latency-data tbody"),
+        "Error loading data"
+      );
+    }
   }
 
   // --- Init ---
 
+  document
+    .getElementById("audit-search-form")
+    .addEventListener("submit", searchAudit);
   loadAll();
   setInterval(loadAll, 5000);
 })();
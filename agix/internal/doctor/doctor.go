@@ -7,11 +7,15 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/keypool"
 	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/tlsserver"
 	"github.com/agent-platform/agix/internal/ui"
 )
 
@@ -24,6 +28,20 @@ const (
 	StatusFail
 )
 
+// String returns PASS, WARN or FAIL.
+func (s Status) String() string {
+	switch s {
+	case StatusPass:
+		return "PASS"
+	case StatusWarn:
+		return "WARN"
+	case StatusFail:
+		return "FAIL"
+	default:
+		return "????"
+	}
+}
+
 // Result holds the outcome of a single check.
 type Result struct {
 	Name    string
@@ -34,13 +52,28 @@ type Result struct {
 // Check is a single health check function.
 type Check func(cfg *config.Config, configPath string) Result
 
-// Run executes all checks and prints a diagnostic report.
+// Run executes all checks, prints a diagnostic report and returns the
+// number of failures.
 func Run(w io.Writer, cfg *config.Config, configPath string) int {
+	var fails int
+	for _, r := range Report(w, cfg, configPath) {
+		if r.Status == StatusFail {
+			fails++
+		}
+	}
+	return fails
+}
+
+// Report is Run returning every check result.
+func Report(w io.Writer, cfg *config.Config, configPath string) []Result {
 	checks := []Check{
 		CheckConfigPermissions,
+		CheckEnv,
+		CheckTLS,
 		CheckAPIKeys,
 		CheckBudgetSanity,
 		CheckFirewallRules,
+		CheckModels,
 		CheckDatabase,
 	}
 
@@ -49,6 +82,7 @@ func Run(w io.Writer, cfg *config.Config, configPath string) int {
 	fmt.Fprintln(w)
 
 	var fails int
+	results := make([]Result, 0, len(checks))
 	for _, check := range checks {
 		result := check(cfg, configPath)
 		icon := statusIcon(result.Status)
@@ -56,6 +90,7 @@ func Run(w io.Writer, cfg *config.Config, configPath string) int {
 		if result.Status == StatusFail {
 			fails++
 		}
+		results = append(results, result)
 	}
 
 	fmt.Fprintln(w)
@@ -65,7 +100,7 @@ func Run(w io.Writer, cfg *config.Config, configPath string) int {
 		fmt.Fprintln(w, ui.Redf("  %d check(s) failed", fails))
 	}
 	fmt.Fprintln(w)
-	return fails
+	return results
 }
 
 func statusIcon(s Status) string {
@@ -99,21 +134,55 @@ func CheckConfigPermissions(cfg *config.Config, configPath string) Result {
 
 // CheckAPIKeys validates configured API keys by making lightweight requests.
 func CheckAPIKeys(cfg *config.Config, _ string) Result {
-	providers := []struct {
+	type provider struct {
 		name    string
 		url     string
 		headers map[string]string
-	}{
+	}
+	providers := []provider{
 		{"openai", "https://api.openai.com/v1/models", nil},
 		{"anthropic", "https://api.anthropic.com/v1/models", map[string]string{"anthropic-version": "2023-06-01"}},
 		{"deepseek", "https://api.deepseek.com/models", nil},
+		{"openrouter", "https://openrouter.ai/api/v1/key", nil},
+	}
+	if cfg.Azure.Resource != "" {
+		version := cfg.Azure.APIVersion
+		if version == "" {
+			version = "2024-10-21"
+		}
+		providers = append(providers, provider{"azure", fmt.Sprintf("https://%s.openai.azure.com/openai/models?api-version=%s", cfg.Azure.Resource, version), nil})
+	}
+	// Custom providers may carry their key inline instead of under keys.
+	keys := make(map[string]string, len(cfg.Keys))
+	for name, key := range cfg.Keys {
+		keys[name] = key
+	}
+	for _, pc := range cfg.Providers {
+		if pc.APIKey != "" {
+			keys[pc.Name] = pc.APIKey
+		}
+		providers = append(providers, provider{pc.Name, strings.TrimRight(pc.BaseURL, "/") + "/models", nil})
 	}
 
 	var configured, valid int
 	var details []string
 
 	for _, p := range providers {
-		key, ok := cfg.Keys[p.name]
+		// Every key in a pool is checked; a dead one would only show up
+		// as quarantine warnings under traffic.
+		if kp := cfg.KeyPools[p.name]; len(kp.Keys) > 0 {
+			for _, k := range kp.Keys {
+				configured++
+				if err := validateAPIKey(p.name, p.url, k.Key, p.headers); err != nil {
+					details = append(details, fmt.Sprintf("%s key %s: %v", p.name, keypool.Label(k.Key), err))
+				} else {
+					valid++
+					details = append(details, fmt.Sprintf("%s key %s: valid", p.name, keypool.Label(k.Key)))
+				}
+			}
+			continue
+		}
+		key, ok := keys[p.name]
 		if !ok || key == "" {
 			continue
 		}
@@ -156,6 +225,8 @@ func validateAPIKey(provider, url, key string, extraHeaders map[string]string) e
 	switch provider {
 	case "anthropic":
 		req.Header.Set("x-api-key", key)
+	case "azure":
+		req.Header.Set("api-key", key)
 	default:
 		req.Header.Set("Authorization", "Bearer "+key)
 	}
@@ -179,6 +250,55 @@ func validateAPIKey(provider, url, key string, extraHeaders map[string]string) e
 	return nil
 }
 
+// CheckEnv warns about ${VAR} references in the config that resolved to
+// an empty string because the variable is not set.
+func CheckEnv(cfg *config.Config, _ string) Result {
+	unset := cfg.UnsetEnv()
+	if len(unset) == 0 {
+		return Result{Name: "env", Status: StatusPass,
+			Message: "Environment: all referenced variables set"}
+	}
+	return Result{Name: "env", Status: StatusWarn,
+		Message: fmt.Sprintf("Environment: %s not set (expanded to empty)", strings.Join(unset, ", "))}
+}
+
+// tlsExpiryWarning is how close to expiry the server certificate must be
+// for CheckTLS to warn.
+const tlsExpiryWarning = 14 * 24 * time.Hour
+
+// CheckTLS loads the tls section's certificate, key and client CAs and
+// warns when the certificate expires soon.
+func CheckTLS(cfg *config.Config, configPath string) Result {
+	if !cfg.TLS.Enabled() {
+		return Result{Name: "tls", Status: StatusPass,
+			Message: "TLS: off (plain HTTP)"}
+	}
+	tc, err := tlsserver.Config(cfg.TLS, filepath.Dir(configPath))
+	if err != nil {
+		return Result{Name: "tls", Status: StatusFail,
+			Message: fmt.Sprintf("TLS: %v", err)}
+	}
+	cert, _ := tc.GetCertificate(nil)
+	mtls := ""
+	if tc.ClientCAs != nil {
+		mtls = ", client certificates verified"
+	}
+	if cert.Leaf != nil {
+		left := time.Until(cert.Leaf.NotAfter)
+		switch {
+		case left <= 0:
+			return Result{Name: "tls", Status: StatusFail,
+				Message: fmt.Sprintf("TLS: certificate expired on %s", cert.Leaf.NotAfter.Format("2006-01-02"))}
+		case left < tlsExpiryWarning:
+			return Result{Name: "tls", Status: StatusWarn,
+				Message: fmt.Sprintf("TLS: certificate expires on %s", cert.Leaf.NotAfter.Format("2006-01-02"))}
+		}
+		mtls = fmt.Sprintf(", valid until %s", cert.Leaf.NotAfter.Format("2006-01-02")) + mtls
+	}
+	return Result{Name: "tls", Status: StatusPass,
+		Message: "TLS: certificate loaded" + mtls}
+}
+
 // CheckBudgetSanity validates budget configuration makes sense.
 func CheckBudgetSanity(cfg *config.Config, _ string) Result {
 	if len(cfg.Budgets) == 0 {
@@ -194,6 +314,27 @@ func CheckBudgetSanity(cfg *config.Config, _ string) Result {
 		if b.AlertAtPercent > 0 && (b.AlertAtPercent < 1 || b.AlertAtPercent > 100) {
 			issues = append(issues, fmt.Sprintf("%s: alert_at_percent %.0f%% out of range [1,100]", agent, b.AlertAtPercent))
 		}
+		for _, rl := range b.Rolling {
+			if _, err := rl.Duration(); err != nil {
+				issues = append(issues, fmt.Sprintf("%s: %v", agent, err))
+			} else if rl.LimitUSD <= 0 {
+				issues = append(issues, fmt.Sprintf("%s: rolling window %s has no limit_usd", agent, rl.Window))
+			}
+		}
+		switch b.OnExceed {
+		case "", config.OnExceedBlock:
+		case config.OnExceedDowngrade:
+			if b.FallbackModel == "" {
+				issues = append(issues, fmt.Sprintf("%s: on_exceed downgrade without fallback_model blocks instead", agent))
+			}
+		default:
+			issues = append(issues, fmt.Sprintf("%s: on_exceed %q is not block or downgrade", agent, b.OnExceed))
+		}
+		for _, dest := range b.AlertDestinations {
+			if _, ok := cfg.Alerts.Destinations[dest]; !ok {
+				issues = append(issues, fmt.Sprintf("%s: alert destination %q not defined in alerts.destinations", agent, dest))
+			}
+		}
 	}
 
 	if len(issues) > 0 {
@@ -204,7 +345,7 @@ func CheckBudgetSanity(cfg *config.Config, _ string) Result {
 		return Result{Name: "budgets", Status: StatusWarn, Message: msg}
 	}
 	return Result{Name: "budgets", Status: StatusPass,
-		Message: fmt.Sprintf("Budgets: %d agent(s) configured OK", len(cfg.Budgets))}
+		Message: fmt.Sprintf("Budgets: %d budget(s) configured OK", len(cfg.Budgets))}
 }
 
 // CheckFirewallRules validates firewall rule regex patterns.
@@ -248,8 +389,11 @@ func CheckDatabase(cfg *config.Config, _ string) Result {
 
 	dialect := store.DetectDialect(cfg.Database)
 
-	if dialect == store.DialectPostgres {
+	switch dialect {
+	case store.DialectPostgres:
 		return checkPostgres(cfg.Database)
+	case store.DialectMySQL:
+		return checkMySQL(cfg.Database)
 	}
 	return checkSQLite(cfg.Database)
 }
@@ -280,6 +424,28 @@ func checkPostgres(dsn string) Result {
 		Message: fmt.Sprintf("Database: postgres OK (%s)", version)}
 }
 
+func checkMySQL(dsn string) Result {
+	// OpenDB converts the mysql:// URL and pings the server.
+	db, _, err := store.OpenDB(dsn)
+	if err != nil {
+		return Result{Name: "database", Status: StatusFail,
+			Message: fmt.Sprintf("Database: %v", err)}
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT version()").Scan(&version); err != nil {
+		return Result{Name: "database", Status: StatusWarn,
+			Message: "Database: mysql connected but version query failed"}
+	}
+
+	return Result{Name: "database", Status: StatusPass,
+		Message: fmt.Sprintf("Database: mysql OK (%s)", version)}
+}
+
 func checkSQLite(dbPath string) Result {
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		return Result{Name: "database", Status: StatusWarn,
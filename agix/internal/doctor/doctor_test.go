@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/agent-platform/agix/internal/config"
@@ -72,6 +73,27 @@ func TestCheckBudgetSanity(t *testing.T) {
 			},
 			wantStat: StatusWarn,
 		},
+		{
+			name: "undefined alert destination",
+			budgets: map[string]config.Budget{
+				"agent1": {DailyLimitUSD: 10, AlertAtPercent: 80, AlertDestinations: []string{"pager"}},
+			},
+			wantStat: StatusWarn,
+		},
+		{
+			name: "valid rolling windows",
+			budgets: map[string]config.Budget{
+				"agent1": {Rolling: []config.RollingLimit{{Window: "7d", LimitUSD: 50}, {Window: "12h", LimitUSD: 10}}},
+			},
+			wantStat: StatusPass,
+		},
+		{
+			name: "bad rolling window",
+			budgets: map[string]config.Budget{
+				"agent1": {Rolling: []config.RollingLimit{{Window: "a week", LimitUSD: 50}}},
+			},
+			wantStat: StatusWarn,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -191,3 +213,81 @@ func TestRun_Output(t *testing.T) {
 		t.Errorf("expected 0 fails, got %d\noutput:\n%s", fails, output)
 	}
 }
+
+func TestCheckModels(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		wantStat Status
+	}{
+		{"none", config.Config{}, StatusPass},
+		{
+			name:     "known chain",
+			cfg:      config.Config{Failover: config.FailoverConfig{Chains: map[string][]string{"gpt-4o": {"claude-sonnet-4-20250514"}}}},
+			wantStat: StatusPass,
+		},
+		{
+			name:     "unknown model in chain",
+			cfg:      config.Config{Failover: config.FailoverConfig{Chains: map[string][]string{"gpt-4o": {"gemini-pro"}}}},
+			wantStat: StatusFail,
+		},
+		{
+			name:     "unpriced model",
+			cfg:      config.Config{Failover: config.FailoverConfig{Chains: map[string][]string{"gpt-4o": {"gpt-35-turbo"}}}},
+			wantStat: StatusWarn,
+		},
+		{
+			name: "unknown experiment variant",
+			cfg: config.Config{Experiments: []config.ExperimentConfig{
+				{Name: "exp", Enabled: true, ControlModel: "gpt-4o", VariantModel: "mystery"},
+			}},
+			wantStat: StatusFail,
+		},
+		{
+			name: "disabled routing ignored",
+			cfg: config.Config{Routing: config.RoutingConfig{
+				ModelMap: map[string]map[string]string{"gpt-4o": {"simple": "mystery"}},
+			}},
+			wantStat: StatusPass,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := CheckModels(&tt.cfg, "")
+			if r.Status != tt.wantStat {
+				t.Errorf("got status %d, want %d: %s", r.Status, tt.wantStat, r.Message)
+			}
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(f, []byte("port: 8080"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Budgets: map[string]config.Budget{"a": {DailyLimitUSD: 10, MonthlyLimitUSD: 5}},
+		Firewall: config.FirewallConfig{Enabled: true, Rules: []config.FirewallRule{
+			{Name: "bad", Pattern: `[invalid`, Action: "block"},
+		}},
+	}
+
+	results := Lint(cfg, f)
+	if len(results) != 2 {
+		t.Fatalf("Lint() = %d results, want 2 (budget warning, firewall failure): %+v", len(results), results)
+	}
+
+	var buf bytes.Buffer
+	if fails := WriteLintSummary(&buf, results); fails != 1 {
+		t.Errorf("fails = %d, want 1", fails)
+	}
+	if !strings.Contains(buf.String(), "Firewall") || !strings.Contains(buf.String(), "Budgets") {
+		t.Errorf("summary missing results:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if fails := WriteLintSummary(&buf, nil); fails != 0 || buf.Len() != 0 {
+		t.Errorf("empty summary wrote %q", buf.String())
+	}
+}
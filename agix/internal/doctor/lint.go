@@ -0,0 +1,137 @@
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/ui"
+)
+
+// staticChecks are the checks that need neither network nor database
+// access, so they are cheap enough to run on every start.
+var staticChecks = []Check{
+	CheckConfigPermissions,
+	CheckEnv,
+	CheckTLS,
+	CheckBudgetSanity,
+	CheckFirewallRules,
+	CheckModels,
+}
+
+// Lint runs the static checks and returns the warnings and failures.
+func Lint(cfg *config.Config, configPath string) []Result {
+	var results []Result
+	for _, check := range staticChecks {
+		if r := check(cfg, configPath); r.Status != StatusPass {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// WriteLintSummary prints a compact block of lint results and returns the
+// number of failures. It prints nothing when results is empty.
+func WriteLintSummary(w io.Writer, results []Result) int {
+	var fails int
+	for _, r := range results {
+		fmt.Fprintf(w, "  %s  %s\n", statusIcon(r.Status), r.Message)
+		if r.Status == StatusFail {
+			fails++
+		}
+	}
+	if len(results) > 0 {
+		fmt.Fprintln(w, ui.Dimf("  Run `agix doctor` for a full report."))
+		fmt.Fprintln(w)
+	}
+	return fails
+}
+
+// CheckModels verifies that every model named in failover chains, routing,
+// experiments and compression maps to a provider. A model with a provider
+// but no pricing entry is only a warning: it works but is recorded at $0.
+func CheckModels(cfg *config.Config, _ string) Result {
+	refs := make(map[string][]string) // model → where it is referenced
+	add := func(model, where string) {
+		if model != "" {
+			refs[model] = append(refs[model], where)
+		}
+	}
+	for primary, chain := range cfg.Failover.Chains {
+		add(primary, "failover chain "+primary)
+		for _, m := range chain {
+			add(m, "failover chain "+primary)
+		}
+	}
+	if cfg.Routing.Enabled {
+		for from, tiers := range cfg.Routing.ModelMap {
+			add(from, "routing.model_map")
+			for _, to := range tiers {
+				add(to, "routing.model_map."+from)
+			}
+		}
+	}
+	for _, e := range cfg.Experiments {
+		if e.Enabled {
+			add(e.ControlModel, "experiment "+e.Name)
+			add(e.VariantModel, "experiment "+e.Name)
+		}
+	}
+	if cfg.Compression.Enabled {
+		add(cfg.Compression.SummaryModel, "compression.summary_model")
+	}
+
+	if len(refs) == 0 {
+		return Result{Name: "models", Status: StatusPass,
+			Message: "Models: none referenced in config (OK)"}
+	}
+
+	models := make([]string, 0, len(refs))
+	for m := range refs {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+
+	var unknown, unpriced []string
+	for _, m := range models {
+		where := strings.Join(dedupe(refs[m]), ", ")
+		switch {
+		case pricing.ProviderForModel(m) == "unknown":
+			unknown = append(unknown, fmt.Sprintf("%s: unknown model (%s)", m, where))
+		case pricing.Lookup(m) == nil:
+			unpriced = append(unpriced, fmt.Sprintf("%s: no pricing, cost recorded as $0 (%s)", m, where))
+		}
+	}
+
+	if len(unknown) > 0 {
+		msg := fmt.Sprintf("Models: %d unknown model(s)", len(unknown))
+		for _, u := range append(unknown, unpriced...) {
+			msg += fmt.Sprintf("\n         %s", u)
+		}
+		return Result{Name: "models", Status: StatusFail, Message: msg}
+	}
+	if len(unpriced) > 0 {
+		msg := fmt.Sprintf("Models: %d model(s) without pricing", len(unpriced))
+		for _, u := range unpriced {
+			msg += fmt.Sprintf("\n         %s", u)
+		}
+		return Result{Name: "models", Status: StatusWarn, Message: msg}
+	}
+	return Result{Name: "models", Status: StatusPass,
+		Message: fmt.Sprintf("Models: %d referenced model(s) known", len(models))}
+}
+
+func dedupe(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	var out []string
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package experiment
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Record is a persisted assignment of an agent to an experiment variant.
+type Record struct {
+	Experiment string
+	AgentName  string
+	Variant    string // "control" or "variant"
+	AssignedAt time.Time
+}
+
+// AssignmentStore persists experiment assignments so they stay sticky when
+// traffic_pct changes mid-experiment.
+type AssignmentStore struct {
+	db      *sql.DB
+	dialect store.Dialect
+}
+
+const createAssignmentsTable = `
+CREATE TABLE IF NOT EXISTS experiment_assignments (
+	experiment  TEXT NOT NULL,
+	agent_name  TEXT NOT NULL,
+	variant     TEXT NOT NULL,
+	assigned_at TEXT NOT NULL,
+	PRIMARY KEY (experiment, agent_name)
+)`
+
+// MySQL cannot key on TEXT columns.
+const createAssignmentsTableMySQL = `
+CREATE TABLE IF NOT EXISTS experiment_assignments (
+	experiment  VARCHAR(255) NOT NULL,
+	agent_name  VARCHAR(255) NOT NULL,
+	variant     VARCHAR(255) NOT NULL,
+	assigned_at VARCHAR(32) NOT NULL,
+	PRIMARY KEY (experiment, agent_name)
+)`
+
+// NewAssignmentStore creates the experiment_assignments table if needed.
+func NewAssignmentStore(db *sql.DB, dialect store.Dialect) (*AssignmentStore, error) {
+	ddl := createAssignmentsTable
+	if dialect == store.DialectMySQL {
+		ddl = createAssignmentsTableMySQL
+	}
+	if _, err := db.Exec(ddl); err != nil {
+		return nil, fmt.Errorf("create experiment_assignments table: %w", err)
+	}
+	return &AssignmentStore{db: db, dialect: dialect}, nil
+}
+
+// Get returns the stored assignment for agent in experiment, or nil.
+func (s *AssignmentStore) Get(experiment, agentName string) (*Record, error) {
+	row := s.db.QueryRow(
+		store.Rebind(s.dialect, `SELECT experiment, agent_name, variant, assigned_at FROM experiment_assignments WHERE experiment = ? AND agent_name = ?`),
+		experiment, agentName,
+	)
+	r, err := scanRecord(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query assignment: %w", err)
+	}
+	return r, nil
+}
+
+// Put stores r unless the agent already has an assignment in the
+// experiment, and returns the assignment that is in effect.
+func (s *AssignmentStore) Put(r Record) (*Record, error) {
+	if r.AssignedAt.IsZero() {
+		r.AssignedAt = time.Now()
+	}
+	var query string
+	switch s.dialect {
+	case store.DialectPostgres:
+		query = `INSERT INTO experiment_assignments (experiment, agent_name, variant, assigned_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (experiment, agent_name) DO NOTHING`
+	case store.DialectMySQL:
+		query = `INSERT IGNORE INTO experiment_assignments (experiment, agent_name, variant, assigned_at) VALUES (?, ?, ?, ?)`
+	default:
+		query = `INSERT OR IGNORE INTO experiment_assignments (experiment, agent_name, variant, assigned_at) VALUES (?, ?, ?, ?)`
+	}
+	if _, err := s.db.Exec(query, r.Experiment, r.AgentName, r.Variant, r.AssignedAt.UTC().Format(time.RFC3339)); err != nil {
+		return nil, fmt.Errorf("store assignment: %w", err)
+	}
+	// Another instance may have assigned the agent first.
+	return s.Get(r.Experiment, r.AgentName)
+}
+
+// List returns the assignments of one experiment, or of all experiments if
+// experiment is empty, oldest first.
+func (s *AssignmentStore) List(experiment string) ([]Record, error) {
+	query := `SELECT experiment, agent_name, variant, assigned_at FROM experiment_assignments`
+	var args []any
+	if experiment != "" {
+		query += ` WHERE experiment = ?`
+		args = append(args, experiment)
+	}
+	query += ` ORDER BY experiment, assigned_at, agent_name`
+
+	rows, err := s.db.Query(store.Rebind(s.dialect, query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Record
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan assignment: %w", err)
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// Reset deletes the assignments of an experiment so agents are re-bucketed
+// on their next request. It returns the number of assignments removed.
+func (s *AssignmentStore) Reset(experiment string) (int64, error) {
+	res, err := s.db.Exec(store.Rebind(s.dialect, `DELETE FROM experiment_assignments WHERE experiment = ?`), experiment)
+	if err != nil {
+		return 0, fmt.Errorf("reset assignments: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecord(row scanner) (*Record, error) {
+	var r Record
+	var ts string
+	if err := row.Scan(&r.Experiment, &r.AgentName, &r.Variant, &ts); err != nil {
+		return nil, err
+	}
+	r.AssignedAt, _ = time.Parse(time.RFC3339, ts)
+	return &r, nil
+}
@@ -0,0 +1,125 @@
+package experiment
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/store"
+	_ "modernc.org/sqlite"
+)
+
+func testStore(t *testing.T) *AssignmentStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	s, err := NewAssignmentStore(db, store.DialectSQLite)
+	if err != nil {
+		t.Fatalf("NewAssignmentStore: %v", err)
+	}
+	return s
+}
+
+func TestAssign_StickyAcrossTrafficChange(t *testing.T) {
+	s := testStore(t)
+	cfg := Config{Name: "sticky", Enabled: true, ControlModel: "gpt-4o", VariantModel: "gpt-4o-mini", TrafficPct: 0}
+
+	m := New([]Config{cfg}, WithAssignmentStore(s))
+	for i := 0; i < 20; i++ {
+		a := m.Assign(fmt.Sprintf("agent-%d", i), "gpt-4o")
+		if a.Variant != "control" {
+			t.Fatalf("agent-%d: variant = %q at 0%% traffic", i, a.Variant)
+		}
+		if a.AssignedAt.IsZero() {
+			t.Errorf("agent-%d: AssignedAt not set", i)
+		}
+	}
+
+	// Raising traffic_pct (a config edit, i.e. a fresh Manager) must not
+	// move agents that were already assigned.
+	cfg.TrafficPct = 100
+	m = New([]Config{cfg}, WithAssignmentStore(s))
+	for i := 0; i < 20; i++ {
+		a := m.Assign(fmt.Sprintf("agent-%d", i), "gpt-4o")
+		if a.Variant != "control" || a.Model != "gpt-4o" {
+			t.Errorf("agent-%d: got %s/%s, want sticky control/gpt-4o", i, a.Variant, a.Model)
+		}
+	}
+
+	// New agents are bucketed with the current traffic_pct.
+	if a := m.Assign("new-agent", "gpt-4o"); a.Variant != "variant" || a.Model != "gpt-4o-mini" {
+		t.Errorf("new agent: got %s/%s, want variant/gpt-4o-mini", a.Variant, a.Model)
+	}
+}
+
+func TestAssign_StickyFollowsCurrentModels(t *testing.T) {
+	s := testStore(t)
+	cfg := Config{Name: "models", Enabled: true, ControlModel: "gpt-4o", VariantModel: "gpt-4o-mini", TrafficPct: 100}
+	New([]Config{cfg}, WithAssignmentStore(s)).Assign("agent-1", "gpt-4o")
+
+	cfg.VariantModel = "o3-mini"
+	cfg.TrafficPct = 0
+	a := New([]Config{cfg}, WithAssignmentStore(s)).Assign("agent-1", "gpt-4o")
+	if a.Variant != "variant" || a.Model != "o3-mini" {
+		t.Errorf("got %s/%s, want variant/o3-mini", a.Variant, a.Model)
+	}
+}
+
+func TestAssignmentStore_PutKeepsFirst(t *testing.T) {
+	s := testStore(t)
+	first, err := s.Put(Record{Experiment: "e", AgentName: "a", Variant: "control"})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	second, err := s.Put(Record{Experiment: "e", AgentName: "a", Variant: "variant"})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if first.Variant != "control" || second.Variant != "control" {
+		t.Errorf("variants = %q, %q; want the first assignment kept", first.Variant, second.Variant)
+	}
+}
+
+func TestAssignmentStore_ListAndReset(t *testing.T) {
+	s := testStore(t)
+	for _, r := range []Record{
+		{Experiment: "e1", AgentName: "a", Variant: "control"},
+		{Experiment: "e1", AgentName: "b", Variant: "variant"},
+		{Experiment: "e2", AgentName: "a", Variant: "variant"},
+	} {
+		if _, err := s.Put(r); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	tests := []struct {
+		experiment string
+		want       int
+	}{
+		{"", 3},
+		{"e1", 2},
+		{"e2", 1},
+		{"missing", 0},
+	}
+	for _, tt := range tests {
+		got, err := s.List(tt.experiment)
+		if err != nil {
+			t.Fatalf("List(%q): %v", tt.experiment, err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("List(%q) = %d records, want %d", tt.experiment, len(got), tt.want)
+		}
+	}
+
+	n, err := s.Reset("e1")
+	if err != nil || n != 2 {
+		t.Fatalf("Reset = %d, %v; want 2", n, err)
+	}
+	if rec, _ := s.Get("e1", "a"); rec != nil {
+		t.Errorf("Get after reset = %+v, want nil", rec)
+	}
+}
@@ -3,15 +3,20 @@ package experiment
 import (
 	"fmt"
 	"hash/fnv"
+	"log"
+	"slices"
+	"sync"
+	"time"
 )
 
 // Config defines an A/B test experiment.
 type Config struct {
-	Name         string `yaml:"name"`
-	Enabled      bool   `yaml:"enabled"`
-	ControlModel string `yaml:"control_model"`
-	VariantModel string `yaml:"variant_model"`
-	TrafficPct   int    `yaml:"traffic_pct"` // 0-100, percentage routed to variant
+	Name          string   `yaml:"name"`
+	Enabled       bool     `yaml:"enabled"`
+	ControlModel  string   `yaml:"control_model"`
+	VariantModel  string   `yaml:"variant_model"`
+	TrafficPct    int      `yaml:"traffic_pct"`    // 0-100, percentage routed to variant
+	ExcludeAgents []string `yaml:"exclude_agents"` // agents never enrolled
 }
 
 // Assignment is the result of experiment evaluation.
@@ -19,15 +24,29 @@ type Assignment struct {
 	ExperimentName string
 	Variant        string // "control" or "variant"
 	Model          string
+	AssignedAt     time.Time // when the assignment was persisted; zero without a store
 }
 
 // Manager evaluates experiment assignments.
 type Manager struct {
 	experiments []Config
+	assignments *AssignmentStore
+
+	mu     sync.RWMutex
+	sticky map[[2]string]Record // (experiment, agent) → persisted assignment
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithAssignmentStore persists assignments: an agent keeps its first variant
+// for the life of the experiment, even if traffic_pct changes.
+func WithAssignmentStore(s *AssignmentStore) Option {
+	return func(m *Manager) { m.assignments = s }
 }
 
 // New creates an experiment Manager. Returns nil if no experiments are enabled.
-func New(experiments []Config) *Manager {
+func New(experiments []Config, opts ...Option) *Manager {
 	var enabled []Config
 	for _, e := range experiments {
 		if e.Enabled {
@@ -37,35 +56,79 @@ func New(experiments []Config) *Manager {
 	if len(enabled) == 0 {
 		return nil
 	}
-	return &Manager{experiments: enabled}
+	m := &Manager{experiments: enabled, sticky: make(map[[2]string]Record)}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // Assign determines which experiment variant an agent should use for a given model.
-// Uses FNV-1a consistent hashing so the same agent always gets the same variant.
-// Returns nil if no experiment matches the model.
+// Uses FNV-1a consistent hashing so the same agent always gets the same variant;
+// with an assignment store, the first variant an agent gets is kept for the
+// life of the experiment. Returns nil if no experiment matches the model or
+// the agent is excluded.
 func (m *Manager) Assign(agentName, model string) *Assignment {
 	for _, exp := range m.experiments {
-		if exp.ControlModel != model {
+		if exp.ControlModel != model || slices.Contains(exp.ExcludeAgents, agentName) {
 			continue
 		}
 
-		bucket := hashBucket(agentName, exp.Name)
-		if bucket < exp.TrafficPct {
-			return &Assignment{
-				ExperimentName: exp.Name,
-				Variant:        "variant",
-				Model:          exp.VariantModel,
-			}
+		variant := "control"
+		if hashBucket(agentName, exp.Name) < exp.TrafficPct {
+			variant = "variant"
+		}
+		a := &Assignment{ExperimentName: exp.Name, Variant: variant}
+		if rec, ok := m.persisted(exp.Name, agentName, variant); ok {
+			a.Variant, a.AssignedAt = rec.Variant, rec.AssignedAt
 		}
-		return &Assignment{
-			ExperimentName: exp.Name,
-			Variant:        "control",
-			Model:          exp.ControlModel,
+		a.Model = exp.ControlModel
+		if a.Variant == "variant" {
+			a.Model = exp.VariantModel
 		}
+		return a
 	}
 	return nil
 }
 
+// Lookup returns the persisted assignment of agentName in an experiment
+// without creating one. It returns nil without an assignment store.
+func (m *Manager) Lookup(experiment, agentName string) (*Record, error) {
+	if m.assignments == nil {
+		return nil, nil
+	}
+	return m.assignments.Get(experiment, agentName)
+}
+
+// persisted returns the agent's stored assignment, storing variant as its
+// assignment if it has none. ok is false without a store or on errors, in
+// which case the hashed variant applies.
+func (m *Manager) persisted(experiment, agentName, variant string) (Record, bool) {
+	if m.assignments == nil {
+		return Record{}, false
+	}
+	key := [2]string{experiment, agentName}
+	m.mu.RLock()
+	rec, ok := m.sticky[key]
+	m.mu.RUnlock()
+	if ok {
+		return rec, true
+	}
+
+	stored, err := m.assignments.Get(experiment, agentName)
+	if err == nil && stored == nil {
+		stored, err = m.assignments.Put(Record{Experiment: experiment, AgentName: agentName, Variant: variant, AssignedAt: time.Now()})
+	}
+	if err != nil || stored == nil {
+		log.Printf("EXPERIMENT: assignment store: %v", err)
+		return Record{}, false
+	}
+	m.mu.Lock()
+	m.sticky[key] = *stored
+	m.mu.Unlock()
+	return *stored, true
+}
+
 // List returns all enabled experiments.
 func (m *Manager) List() []Config {
 	return m.experiments
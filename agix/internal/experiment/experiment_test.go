@@ -133,3 +133,15 @@ func TestHashBucket_Range(t *testing.T) {
 		}
 	}
 }
+
+func TestAssign_ExcludedAgent(t *testing.T) {
+	m := New([]Config{
+		{Name: "exp1", Enabled: true, ControlModel: "gpt-4o", VariantModel: "gpt-4o-mini", TrafficPct: 100, ExcludeAgents: []string{"eval-runner"}},
+	})
+	if a := m.Assign("eval-runner", "gpt-4o"); a != nil {
+		t.Errorf("excluded agent assigned %+v, want nil", a)
+	}
+	if a := m.Assign("app", "gpt-4o"); a == nil || a.Variant != "variant" {
+		t.Errorf("app assignment = %+v, want variant", a)
+	}
+}
@@ -0,0 +1,200 @@
+// Package export writes recorded requests as CSV, JSON, NDJSON or Parquet
+// for spreadsheets and data warehouses. The CLI (agix export) and the
+// dashboard's /api/export share it so both produce the same columns.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{"csv", "json", "ndjson", "parquet"}
+
+// ContentType returns the MIME type for format.
+func ContentType(format string) string {
+	switch format {
+	case "json":
+		return "application/json"
+	case "ndjson":
+		return "application/x-ndjson"
+	case "parquet":
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv"
+	}
+}
+
+// Row is one exported request.
+type Row struct {
+	ID               int64   `json:"id"`
+	Timestamp        string  `json:"timestamp"`
+	AgentName        string  `json:"agent_name"`
+	Model            string  `json:"model"`
+	Provider         string  `json:"provider"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	DurationMS       int64   `json:"duration_ms"`
+	StatusCode       int     `json:"status_code"`
+	ReasoningTokens  int     `json:"reasoning_tokens"`   // included in output_tokens
+	CacheWriteTokens int     `json:"cache_write_tokens"` // not included in input_tokens
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	Project          string  `json:"project,omitempty"`
+	Tags             string  `json:"tags,omitempty"` // X-Request-Tags, key=value pairs sorted by key
+	CostCenter       string  `json:"cost_center,omitempty"`
+}
+
+const timeFormat = "2006-01-02T15:04:05Z"
+
+// ParseTime parses a --since/--until bound: a YYYY-MM-DD date (UTC
+// midnight) or an RFC 3339 time.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use YYYY-MM-DD or RFC 3339)", s)
+	}
+	return t.UTC(), nil
+}
+
+// Write encodes records in format. costCenter maps an agent to its cost
+// center and may be nil.
+func Write(w io.Writer, format string, records []store.Record, costCenter func(agent string) string) error {
+	rows := make([]Row, len(records))
+	for i, r := range records {
+		rows[i] = Row{
+			ID:               r.ID,
+			Timestamp:        r.Timestamp.UTC().Format(timeFormat),
+			AgentName:        r.AgentName,
+			Model:            r.Model,
+			Provider:         r.Provider,
+			InputTokens:      r.InputTokens,
+			OutputTokens:     r.OutputTokens,
+			CostUSD:          r.CostUSD,
+			DurationMS:       r.DurationMS,
+			StatusCode:       r.StatusCode,
+			ReasoningTokens:  r.ReasoningTokens,
+			CacheWriteTokens: r.CacheWriteTokens,
+			CacheReadTokens:  r.CacheReadTokens,
+			Project:          r.Project,
+			Tags:             r.Tags,
+		}
+		if costCenter != nil {
+			rows[i].CostCenter = costCenter(r.AgentName)
+		}
+	}
+
+	switch format {
+	case "csv":
+		return writeCSV(w, rows)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, r := range rows {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "parquet":
+		return writeParquet(w, records, rows)
+	default:
+		return fmt.Errorf("unsupported format: %s (use csv, json, ndjson or parquet)", format)
+	}
+}
+
+func writeCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{
+		"id", "timestamp", "agent_name", "model", "provider",
+		"input_tokens", "output_tokens", "cost_usd", "duration_ms", "status_code",
+		"reasoning_tokens", "cache_write_tokens", "cache_read_tokens", "project", "tags", "cost_center",
+	}); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write([]string{
+			strconv.FormatInt(r.ID, 10),
+			r.Timestamp,
+			r.AgentName,
+			r.Model,
+			r.Provider,
+			strconv.Itoa(r.InputTokens),
+			strconv.Itoa(r.OutputTokens),
+			fmt.Sprintf("%.6f", r.CostUSD),
+			strconv.FormatInt(r.DurationMS, 10),
+			strconv.Itoa(r.StatusCode),
+			strconv.Itoa(r.ReasoningTokens),
+			strconv.Itoa(r.CacheWriteTokens),
+			strconv.Itoa(r.CacheReadTokens),
+			r.Project,
+			r.Tags,
+			r.CostCenter,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var parquetColumns = []parquetColumn{
+	{"id", parquetInt64},
+	{"timestamp", parquetTimestamp},
+	{"agent_name", parquetString},
+	{"model", parquetString},
+	{"provider", parquetString},
+	{"input_tokens", parquetInt64},
+	{"output_tokens", parquetInt64},
+	{"cost_usd", parquetDouble},
+	{"duration_ms", parquetInt64},
+	{"status_code", parquetInt64},
+	{"reasoning_tokens", parquetInt64},
+	{"cache_write_tokens", parquetInt64},
+	{"cache_read_tokens", parquetInt64},
+	{"project", parquetString},
+	{"tags", parquetString},
+	{"cost_center", parquetString},
+}
+
+func writeParquet(w io.Writer, records []store.Record, rows []Row) error {
+	pw, err := newParquetWriter(w, parquetColumns)
+	if err != nil {
+		return err
+	}
+	for i, r := range rows {
+		if err := pw.write([]any{
+			r.ID,
+			records[i].Timestamp.UTC(),
+			r.AgentName,
+			r.Model,
+			r.Provider,
+			int64(r.InputTokens),
+			int64(r.OutputTokens),
+			r.CostUSD,
+			r.DurationMS,
+			int64(r.StatusCode),
+			int64(r.ReasoningTokens),
+			int64(r.CacheWriteTokens),
+			int64(r.CacheReadTokens),
+			r.Project,
+			r.Tags,
+			r.CostCenter,
+		}); err != nil {
+			return err
+		}
+	}
+	return pw.close()
+}
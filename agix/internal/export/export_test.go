@@ -0,0 +1,170 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+var testRecords = []store.Record{
+	{ID: 1, Timestamp: time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC), AgentName: "bot", Model: "gpt-4o", Provider: "openai", InputTokens: 100, OutputTokens: 20, CostUSD: 0.0045, DurationMS: 800, StatusCode: 200},
+	{ID: 2, Timestamp: time.Date(2026, 3, 1, 9, 31, 0, 0, time.UTC), AgentName: "reviewer", Model: "claude-sonnet-4-20250514", Provider: "anthropic", InputTokens: 50, OutputTokens: 5, CostUSD: 0.001, DurationMS: 400, StatusCode: 529, CacheReadTokens: 1000},
+}
+
+func teamOf(agent string) string {
+	if agent == "bot" {
+		return "platform"
+	}
+	return ""
+}
+
+func TestWriteTextFormats(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "csv", testRecords, teamOf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[1], ",platform") || !strings.HasPrefix(lines[2], "2,2026-03-01T09:31:00Z,reviewer") {
+		t.Errorf("csv = %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "ndjson", testRecords, nil); err != nil {
+		t.Fatal(err)
+	}
+	sc := bufio.NewScanner(&buf)
+	var got []Row
+	for sc.Scan() {
+		var r Row
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("ndjson line %q: %v", sc.Text(), err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != 2 || got[1].CacheReadTokens != 1000 || got[0].CostCenter != "" {
+		t.Errorf("ndjson rows = %+v", got)
+	}
+
+	if err := Write(&buf, "xml", testRecords, nil); err == nil {
+		t.Error("Write(xml) succeeded")
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "parquet", testRecords, teamOf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatal("missing PAR1 magic")
+	}
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := readThriftStruct(t, bytes.NewReader(data[len(data)-8-metaLen:len(data)-8]))
+
+	if meta[3] != int64(2) {
+		t.Errorf("num_rows = %v, want 2", meta[3])
+	}
+	schema := meta[2].([]any)
+	if len(schema) != len(parquetColumns)+1 || schema[0].(map[int16]any)[5] != int64(len(parquetColumns)) {
+		t.Fatalf("schema = %v", schema)
+	}
+	if name := schema[3].(map[int16]any)[4]; name != "agent_name" {
+		t.Errorf("third column = %v", name)
+	}
+
+	groups := meta[4].([]any)
+	columns := groups[0].(map[int16]any)[1].([]any)
+	// Read each column chunk from its recorded offset and check its values.
+	values := func(col int) []byte {
+		cm := columns[col].(map[int16]any)[3].(map[int16]any)
+		off := cm[9].(int64)
+		r := bytes.NewReader(data[off:])
+		header := readThriftStruct(t, r)
+		if header[1] != int64(pageTypeData) || header[5].(map[int16]any)[1] != int64(2) {
+			t.Fatalf("column %d page header = %v", col, header)
+		}
+		size := header[3].(int64)
+		start := int(off) + len(data[off:]) - r.Len()
+		if int64(start)-off+size != cm[7].(int64) {
+			t.Errorf("column %d: header + data = %d, total_compressed_size = %d", col, int64(start)-off+size, cm[7])
+		}
+		return data[start : start+int(size)]
+	}
+
+	if ts := int64(binary.LittleEndian.Uint64(values(1)[8:])); ts != testRecords[1].Timestamp.UnixMilli() {
+		t.Errorf("timestamp[1] = %d", ts)
+	}
+	agents := values(2)
+	if n := binary.LittleEndian.Uint32(agents); string(agents[4:4+n]) != "bot" {
+		t.Errorf("agent_name[0] = %q", agents[4:4+n])
+	}
+	if cost := math.Float64frombits(binary.LittleEndian.Uint64(values(7))); cost != 0.0045 {
+		t.Errorf("cost_usd[0] = %v", cost)
+	}
+	if status := int64(binary.LittleEndian.Uint64(values(9)[8:])); status != 529 {
+		t.Errorf("status_code[1] = %d", status)
+	}
+}
+
+// readThriftStruct decodes a compact-protocol struct into field ID →
+// value (int64, string, []any or nested map).
+func readThriftStruct(t *testing.T, r *bytes.Reader) map[int16]any {
+	t.Helper()
+	out := map[int16]any{}
+	var last int16
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			t.Fatalf("read field header: %v", err)
+		}
+		if b == 0 {
+			return out
+		}
+		typ := b & 0x0f
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			v, _ := binary.ReadUvarint(r)
+			id = int16(unzigzag(v))
+		}
+		last = id
+		out[id] = readThriftValue(t, r, typ)
+	}
+}
+
+func readThriftValue(t *testing.T, r *bytes.Reader, typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		v, _ := binary.ReadUvarint(r)
+		return unzigzag(v)
+	case thriftBinary:
+		n, _ := binary.ReadUvarint(r)
+		b := make([]byte, n)
+		r.Read(b)
+		return string(b)
+	case thriftList:
+		h, _ := r.ReadByte()
+		n := uint64(h >> 4)
+		if n == 15 {
+			n, _ = binary.ReadUvarint(r)
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = readThriftValue(t, r, h&0x0f)
+		}
+		return list
+	case thriftStruct:
+		return readThriftStruct(t, r)
+	}
+	t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+func unzigzag(v uint64) int64 { return int64(v>>1) ^ -int64(v&1) }
@@ -0,0 +1,337 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// A minimal Parquet writer: flat schemas of required columns, PLAIN
+// encoding, no compression, one data page per column per row group. That
+// is enough for warehouses (BigQuery, Snowflake, DuckDB, Spark) to load
+// usage exports without pulling in a Parquet library.
+
+// parquetType is a column's physical type plus its annotation.
+type parquetType int
+
+const (
+	parquetString parquetType = iota
+	parquetInt64
+	parquetDouble
+	parquetTimestamp // milliseconds since the epoch, UTC
+)
+
+type parquetColumn struct {
+	name string
+	typ  parquetType
+}
+
+// Parquet format enums (parquet.thrift).
+const (
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageTypeData       = 0
+)
+
+const parquetMagic = "PAR1"
+
+// parquetRowGroupSize bounds the rows buffered in memory per row group.
+const parquetRowGroupSize = 100000
+
+type parquetWriter struct {
+	w       io.Writer
+	cols    []parquetColumn
+	offset  int64
+	rows    [][]any
+	groups  []rowGroupMeta
+	numRows int64
+}
+
+type rowGroupMeta struct {
+	columns   []columnChunkMeta
+	numRows   int64
+	totalSize int64
+}
+
+type columnChunkMeta struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+func newParquetWriter(w io.Writer, cols []parquetColumn) (*parquetWriter, error) {
+	if _, err := io.WriteString(w, parquetMagic); err != nil {
+		return nil, err
+	}
+	return &parquetWriter{w: w, cols: cols, offset: int64(len(parquetMagic))}, nil
+}
+
+// write buffers one row; values must match the column types (string,
+// int64, float64, time.Time).
+func (p *parquetWriter) write(row []any) error {
+	if len(row) != len(p.cols) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(p.cols))
+	}
+	p.rows = append(p.rows, row)
+	if len(p.rows) >= parquetRowGroupSize {
+		return p.flush()
+	}
+	return nil
+}
+
+// flush writes buffered rows as a row group.
+func (p *parquetWriter) flush() error {
+	if len(p.rows) == 0 {
+		return nil
+	}
+	g := rowGroupMeta{numRows: int64(len(p.rows))}
+	for i, col := range p.cols {
+		var data bytes.Buffer
+		for _, row := range p.rows {
+			if err := encodePlain(&data, col.typ, row[i]); err != nil {
+				return fmt.Errorf("parquet column %s: %w", col.name, err)
+			}
+		}
+
+		var header bytes.Buffer
+		t := &thriftWriter{buf: &header}
+		t.structBegin()
+		t.fieldI32(1, pageTypeData)
+		t.fieldI32(2, int32(data.Len()))
+		t.fieldI32(3, int32(data.Len()))
+		t.fieldStructBegin(5)
+		t.fieldI32(1, int32(len(p.rows)))
+		t.fieldI32(2, encodingPlain)
+		t.fieldI32(3, encodingRLE)
+		t.fieldI32(4, encodingRLE)
+		t.structEnd()
+		t.structEnd()
+
+		chunk := columnChunkMeta{offset: p.offset, size: int64(header.Len() + data.Len()), values: int64(len(p.rows))}
+		if _, err := p.w.Write(header.Bytes()); err != nil {
+			return err
+		}
+		if _, err := p.w.Write(data.Bytes()); err != nil {
+			return err
+		}
+		p.offset += chunk.size
+		g.totalSize += chunk.size
+		g.columns = append(g.columns, chunk)
+	}
+	p.groups = append(p.groups, g)
+	p.numRows += g.numRows
+	p.rows = p.rows[:0]
+	return nil
+}
+
+// close writes the remaining rows and the footer.
+func (p *parquetWriter) close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+
+	var meta bytes.Buffer
+	t := &thriftWriter{buf: &meta}
+	t.structBegin()
+	t.fieldI32(1, 1) // version
+
+	t.fieldListBegin(2, thriftStruct, len(p.cols)+1)
+	t.structBegin()
+	t.fieldString(4, "schema")
+	t.fieldI32(5, int32(len(p.cols)))
+	t.structEnd()
+	for _, col := range p.cols {
+		t.structBegin()
+		t.fieldI32(1, physicalType(col.typ))
+		t.fieldI32(3, repetitionRequired)
+		t.fieldString(4, col.name)
+		switch col.typ {
+		case parquetString:
+			t.fieldI32(6, convertedUTF8)
+		case parquetTimestamp:
+			t.fieldI32(6, convertedTimestampMillis)
+		}
+		t.structEnd()
+	}
+
+	t.fieldI64(3, p.numRows)
+
+	t.fieldListBegin(4, thriftStruct, len(p.groups))
+	for _, g := range p.groups {
+		t.structBegin()
+		t.fieldListBegin(1, thriftStruct, len(g.columns))
+		for i, c := range g.columns {
+			t.structBegin()
+			t.fieldI64(2, c.offset)
+			t.fieldStructBegin(3)
+			t.fieldI32(1, physicalType(p.cols[i].typ))
+			t.fieldListBegin(2, thriftI32, 2)
+			t.i32(encodingPlain)
+			t.i32(encodingRLE)
+			t.fieldListBegin(3, thriftBinary, 1)
+			t.binary(p.cols[i].name)
+			t.fieldI32(4, codecUncompressed)
+			t.fieldI64(5, c.values)
+			t.fieldI64(6, c.size)
+			t.fieldI64(7, c.size)
+			t.fieldI64(9, c.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.fieldI64(2, g.totalSize)
+		t.fieldI64(3, g.numRows)
+		t.structEnd()
+	}
+
+	t.fieldString(6, "agix")
+	t.structEnd()
+
+	if _, err := p.w.Write(meta.Bytes()); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(meta.Len()))
+	if _, err := p.w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(p.w, parquetMagic)
+	return err
+}
+
+func physicalType(t parquetType) int32 {
+	switch t {
+	case parquetString:
+		return physicalByteArray
+	case parquetDouble:
+		return physicalDouble
+	default:
+		return physicalInt64
+	}
+}
+
+func encodePlain(buf *bytes.Buffer, typ parquetType, v any) error {
+	var b [8]byte
+	switch typ {
+	case parquetString:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("got %T, want string", v)
+		}
+		binary.LittleEndian.PutUint32(b[:4], uint32(len(s)))
+		buf.Write(b[:4])
+		buf.WriteString(s)
+	case parquetInt64:
+		n, ok := v.(int64)
+		if !ok {
+			return fmt.Errorf("got %T, want int64", v)
+		}
+		binary.LittleEndian.PutUint64(b[:], uint64(n))
+		buf.Write(b[:])
+	case parquetDouble:
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("got %T, want float64", v)
+		}
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+		buf.Write(b[:])
+	case parquetTimestamp:
+		ts, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("got %T, want time.Time", v)
+		}
+		binary.LittleEndian.PutUint64(b[:], uint64(ts.UnixMilli()))
+		buf.Write(b[:])
+	}
+	return nil
+}
+
+// Thrift compact protocol, just the parts the footer and page headers use.
+
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+type thriftWriter struct {
+	buf  *bytes.Buffer
+	last []int16 // last field ID per open struct
+}
+
+func (t *thriftWriter) structBegin() { t.last = append(t.last, 0) }
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0) // stop
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(uint64(zigzag(int64(id))))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.i32(v)
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) fieldString(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binary(s)
+}
+
+func (t *thriftWriter) fieldStructBegin(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.structBegin()
+}
+
+// fieldListBegin writes a list header; the caller writes n elements.
+func (t *thriftWriter) fieldListBegin(id int16, elem byte, n int) {
+	t.fieldHeader(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+	} else {
+		t.buf.WriteByte(0xF0 | elem)
+		t.varint(uint64(n))
+	}
+}
+
+func (t *thriftWriter) i32(v int32) { t.varint(zigzag(int64(v))) }
+
+func (t *thriftWriter) binary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func zigzag(v int64) uint64 { return uint64((v << 1) ^ (v >> 63)) }
@@ -1,17 +1,40 @@
 package failover
 
-import "github.com/agent-platform/agix/internal/pricing"
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/agent-platform/agix/internal/pricing"
+)
+
+// Off is the X-Failover value that disables failover for a request.
+const Off = "off"
 
 // Config holds failover configuration.
 type Config struct {
-	MaxRetries int                 `yaml:"max_retries"`
-	Chains     map[string][]string `yaml:"chains"`
+	MaxRetries int                    `yaml:"max_retries"`
+	Chains     map[string][]string    `yaml:"chains"`
+	Agents     map[string]AgentPolicy `yaml:"agents"`
+}
+
+// AgentPolicy limits the per-request overrides an agent may make.
+type AgentPolicy struct {
+	Chains     []string `yaml:"chains"`      // chain names the agent may select with X-Failover
+	MaxRetries int      `yaml:"max_retries"` // highest X-Max-Retries allowed above the global max_retries
 }
 
 // Failover resolves fallback models for a given model.
 type Failover struct {
 	maxRetries int
 	chains     map[string][]string
+	agents     map[string]AgentPolicy
+}
+
+// Plan is the fallback chain and retry count used for one request.
+type Plan struct {
+	Chain      []string
+	MaxRetries int
 }
 
 // New creates a Failover from config. Returns nil if config is empty.
@@ -26,6 +49,7 @@ func New(cfg Config) *Failover {
 	return &Failover{
 		maxRetries: maxRetries,
 		chains:     cfg.Chains,
+		agents:     cfg.Agents,
 	}
 }
 
@@ -40,6 +64,44 @@ func (f *Failover) FallbackModels(model string) []string {
 	return f.chains[model]
 }
 
+// Plan returns the failover plan for a request from agent for model,
+// applying the agent's X-Failover (chain) and X-Max-Retries (retries)
+// overrides. Turning failover off and lowering retries are always allowed;
+// selecting another chain or raising retries above max_retries must be
+// permitted by the agent's policy.
+func (f *Failover) Plan(agent, model, chain, retries string) (Plan, error) {
+	plan := Plan{Chain: f.chains[model], MaxRetries: f.maxRetries}
+	policy := f.agents[agent]
+
+	switch chain {
+	case "":
+	case Off:
+		plan.Chain = nil
+	default:
+		c, ok := f.chains[chain]
+		if !ok {
+			return Plan{}, fmt.Errorf("unknown failover chain %q", chain)
+		}
+		if !slices.Contains(policy.Chains, chain) {
+			return Plan{}, fmt.Errorf("failover chain %q not permitted for agent %q", chain, agent)
+		}
+		plan.Chain = c
+	}
+
+	if retries != "" {
+		n, err := strconv.Atoi(retries)
+		if err != nil || n < 0 {
+			return Plan{}, fmt.Errorf("invalid X-Max-Retries %q", retries)
+		}
+		if limit := max(f.maxRetries, policy.MaxRetries); n > limit {
+			return Plan{}, fmt.Errorf("X-Max-Retries %d exceeds %d permitted for agent %q", n, limit, agent)
+		}
+		plan.MaxRetries = n
+	}
+	plan.MaxRetries = min(plan.MaxRetries, len(plan.Chain))
+	return plan, nil
+}
+
 // IsRetryable returns true if the status code is retryable (5xx).
 func IsRetryable(statusCode int) bool {
 	return statusCode >= 500 && statusCode < 600
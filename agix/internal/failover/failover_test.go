@@ -70,3 +70,59 @@ func TestIsRetryable(t *testing.T) {
 		}
 	}
 }
+
+func TestPlan(t *testing.T) {
+	f := New(Config{
+		MaxRetries: 1,
+		Chains: map[string][]string{
+			"gpt-4o":  {"claude-sonnet-4-20250514", "deepseek-chat", "gpt-4o-mini"},
+			"premium": {"claude-opus-4-6", "gpt-5"},
+		},
+		Agents: map[string]AgentPolicy{
+			"batch": {Chains: []string{"premium"}, MaxRetries: 3},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		agent       string
+		chain       string
+		retries     string
+		wantFirst   string
+		wantRetries int
+		wantErr     bool
+	}{
+		{name: "defaults", agent: "any", wantFirst: "claude-sonnet-4-20250514", wantRetries: 1},
+		{name: "off", agent: "any", chain: "off", wantRetries: 0},
+		{name: "fewer retries", agent: "any", retries: "0", wantFirst: "claude-sonnet-4-20250514", wantRetries: 0},
+		{name: "more retries not permitted", agent: "any", retries: "3", wantErr: true},
+		{name: "more retries permitted", agent: "batch", retries: "3", wantFirst: "claude-sonnet-4-20250514", wantRetries: 3},
+		{name: "retries above agent limit", agent: "batch", retries: "4", wantErr: true},
+		{name: "retries capped by chain", agent: "batch", chain: "premium", retries: "3", wantFirst: "claude-opus-4-6", wantRetries: 2},
+		{name: "chain not permitted", agent: "any", chain: "premium", wantErr: true},
+		{name: "unknown chain", agent: "batch", chain: "nope", wantErr: true},
+		{name: "bad retries", agent: "any", retries: "-1", wantErr: true},
+		{name: "non-numeric retries", agent: "any", retries: "lots", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := f.Plan(tt.agent, "gpt-4o", tt.chain, tt.retries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Plan error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if plan.MaxRetries != tt.wantRetries {
+				t.Errorf("MaxRetries = %d, want %d", plan.MaxRetries, tt.wantRetries)
+			}
+			first := ""
+			if len(plan.Chain) > 0 {
+				first = plan.Chain[0]
+			}
+			if first != tt.wantFirst {
+				t.Errorf("Chain[0] = %q, want %q", first, tt.wantFirst)
+			}
+		})
+	}
+}
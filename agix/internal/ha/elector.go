@@ -0,0 +1,166 @@
+package ha
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLockKey is the Postgres advisory lock key used when none is configured.
+const DefaultLockKey int64 = 0x61676978 // "agix"
+
+// Locker acquires and holds a cluster-wide lock.
+type Locker interface {
+	// TryLock attempts to take the lock without blocking.
+	TryLock(ctx context.Context) (bool, error)
+	// Check verifies a held lock is still held.
+	Check(ctx context.Context) error
+	// Unlock releases the lock.
+	Unlock(ctx context.Context) error
+}
+
+// Elector runs active-standby leader election. The instance holding the
+// lock is the leader; the others stay standby and retry on every interval.
+type Elector struct {
+	locker   Locker
+	interval time.Duration
+	leader   atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New creates an Elector. Call Start to begin campaigning.
+func New(locker Locker, interval time.Duration) *Elector {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &Elector{
+		locker:   locker,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start makes a first election attempt synchronously, then keeps
+// campaigning (or verifying leadership) in the background.
+func (e *Elector) Start() {
+	e.tick()
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.stop:
+				return
+			case <-ticker.C:
+				e.tick()
+			}
+		}
+	}()
+}
+
+// IsLeader reports whether this instance currently holds the lock.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Close stops campaigning and releases leadership.
+func (e *Elector) Close() error {
+	close(e.stop)
+	<-e.done
+	if !e.leader.Swap(false) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+	return e.locker.Unlock(ctx)
+}
+
+func (e *Elector) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+
+	if e.leader.Load() {
+		if err := e.locker.Check(ctx); err != nil {
+			e.leader.Store(false)
+			log.Printf("HA: lost leadership (%v), now standby", err)
+		}
+		return
+	}
+
+	ok, err := e.locker.TryLock(ctx)
+	if err != nil {
+		log.Printf("WARN: HA lock attempt failed: %v", err)
+		return
+	}
+	if ok {
+		e.leader.Store(true)
+		log.Printf("HA: promoted to leader")
+	}
+}
+
+// PGLocker implements Locker with a Postgres session-level advisory lock.
+// The lock lives on a dedicated connection, so it is released by the server
+// if this process dies or the connection drops.
+type PGLocker struct {
+	db   *sql.DB
+	key  int64
+	conn *sql.Conn
+}
+
+// NewPGLocker creates a PGLocker for the given advisory lock key.
+func NewPGLocker(db *sql.DB, key int64) *PGLocker {
+	return &PGLocker{db: db, key: key}
+}
+
+// TryLock implements Locker.
+func (l *PGLocker) TryLock(ctx context.Context) (bool, error) {
+	if l.conn == nil {
+		conn, err := l.db.Conn(ctx)
+		if err != nil {
+			return false, fmt.Errorf("reserve connection: %w", err)
+		}
+		l.conn = conn
+	}
+	var ok bool
+	if err := l.conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&ok); err != nil {
+		l.reset()
+		return false, fmt.Errorf("pg_try_advisory_lock: %w", err)
+	}
+	return ok, nil
+}
+
+// Check implements Locker by pinging the connection that holds the lock.
+func (l *PGLocker) Check(ctx context.Context) error {
+	if l.conn == nil {
+		return fmt.Errorf("no lock connection")
+	}
+	if err := l.conn.PingContext(ctx); err != nil {
+		l.reset()
+		return err
+	}
+	return nil
+}
+
+// Unlock implements Locker.
+func (l *PGLocker) Unlock(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key)
+	l.reset()
+	return err
+}
+
+// reset drops the lock connection; the server releases any lock it held.
+func (l *PGLocker) reset() {
+	if l.conn != nil {
+		l.conn.Close()
+		l.conn = nil
+	}
+}
@@ -0,0 +1,111 @@
+package ha
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLock is a shared in-memory lock for simulating an HA pair.
+type fakeLock struct {
+	mu     sync.Mutex
+	holder *fakeLocker
+}
+
+type fakeLocker struct {
+	lock     *fakeLock
+	checkErr error
+	unlocked bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context) (bool, error) {
+	l.lock.mu.Lock()
+	defer l.lock.mu.Unlock()
+	if l.lock.holder == nil {
+		l.lock.holder = l
+	}
+	return l.lock.holder == l, nil
+}
+
+func (l *fakeLocker) Check(ctx context.Context) error {
+	if l.checkErr != nil {
+		l.lock.mu.Lock()
+		l.lock.holder = nil // server drops the lock with the session
+		l.lock.mu.Unlock()
+	}
+	return l.checkErr
+}
+
+func (l *fakeLocker) Unlock(ctx context.Context) error {
+	l.lock.mu.Lock()
+	defer l.lock.mu.Unlock()
+	if l.lock.holder == l {
+		l.lock.holder = nil
+	}
+	l.unlocked = true
+	return nil
+}
+
+func TestElectorActiveStandby(t *testing.T) {
+	lock := &fakeLock{}
+	primary := &fakeLocker{lock: lock}
+	standby := &fakeLocker{lock: lock}
+
+	a := New(primary, time.Hour)
+	b := New(standby, time.Hour)
+	a.tick()
+	b.tick()
+
+	if !a.IsLeader() {
+		t.Error("first instance should be leader")
+	}
+	if b.IsLeader() {
+		t.Error("second instance should be standby")
+	}
+
+	// Primary loses its connection; standby is promoted on its next attempt.
+	primary.checkErr = errors.New("connection reset")
+	a.tick()
+	if a.IsLeader() {
+		t.Error("instance should step down after losing the lock")
+	}
+	b.tick()
+	if !b.IsLeader() {
+		t.Error("standby should be promoted once the lock is free")
+	}
+}
+
+func TestElectorCloseReleasesLock(t *testing.T) {
+	lock := &fakeLock{}
+	l := &fakeLocker{lock: lock}
+	e := New(l, time.Hour)
+	e.Start()
+
+	if !e.IsLeader() {
+		t.Fatal("Start() should elect synchronously when the lock is free")
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if e.IsLeader() || !l.unlocked {
+		t.Error("Close() should release leadership")
+	}
+}
+
+func TestElectorStandbyCloseDoesNotUnlock(t *testing.T) {
+	lock := &fakeLock{}
+	lock.holder = &fakeLocker{lock: lock}
+	l := &fakeLocker{lock: lock}
+	e := New(l, time.Hour)
+	e.Start()
+
+	if e.IsLeader() {
+		t.Fatal("instance should be standby while another holds the lock")
+	}
+	e.Close()
+	if l.unlocked {
+		t.Error("standby should not unlock on Close()")
+	}
+}
@@ -0,0 +1,148 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/audit"
+)
+
+// Capture records the request body as it moves through the gateway pipeline.
+// A nil *Capture is valid and records nothing, so callers need no nil checks.
+type Capture struct {
+	RequestID string
+	stages    []audit.PayloadStage
+}
+
+// NewCapture starts a capture for requestID with the body exactly as the
+// agent sent it.
+func NewCapture(requestID string, original []byte) *Capture {
+	c := &Capture{RequestID: requestID}
+	c.stages = append(c.stages, audit.PayloadStage{Stage: "original", Body: string(original)})
+	return c
+}
+
+// Record snapshots the body after a pipeline stage. Stages that left the
+// body unchanged are skipped so the capture only holds real mutations.
+func (c *Capture) Record(stage string, body []byte) {
+	if c == nil {
+		return
+	}
+	if last := c.stages[len(c.stages)-1]; last.Body == string(body) {
+		return
+	}
+	c.stages = append(c.stages, audit.PayloadStage{Stage: stage, Body: string(body)})
+}
+
+// Stages returns a copy of the recorded stages, oldest first.
+func (c *Capture) Stages() []audit.PayloadStage {
+	if c == nil {
+		return nil
+	}
+	out := make([]audit.PayloadStage, len(c.stages))
+	copy(out, c.stages)
+	return out
+}
+
+// Pretty indents a JSON body for line-based diffing. Non-JSON input is
+// returned unchanged.
+func Pretty(body string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(body), "", "  "); err != nil {
+		return body
+	}
+	return buf.String()
+}
+
+// Op identifies the kind of a diff line.
+type Op byte
+
+const (
+	OpEqual  Op = ' '
+	OpInsert Op = '+'
+	OpDelete Op = '-'
+	OpSkip   Op = '~' // marks unchanged lines elided from the output
+)
+
+// Line is a single line of diff output.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Diff computes a line diff between a and b using the longest common
+// subsequence. Unchanged runs are trimmed to context lines around each
+// change; the elided lines are replaced by a single OpSkip line.
+func Diff(a, b string, context int) []Line {
+	al := strings.Split(a, "\n")
+	bl := strings.Split(b, "\n")
+
+	// lcs[i][j] = length of the LCS of al[i:] and bl[j:].
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var full []Line
+	i, j := 0, 0
+	for i < len(al) && j < len(bl) {
+		switch {
+		case al[i] == bl[j]:
+			full = append(full, Line{OpEqual, al[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			full = append(full, Line{OpDelete, al[i]})
+			i++
+		default:
+			full = append(full, Line{OpInsert, bl[j]})
+			j++
+		}
+	}
+	for ; i < len(al); i++ {
+		full = append(full, Line{OpDelete, al[i]})
+	}
+	for ; j < len(bl); j++ {
+		full = append(full, Line{OpInsert, bl[j]})
+	}
+
+	return trimContext(full, context)
+}
+
+// trimContext keeps only equal lines within context lines of a change.
+func trimContext(full []Line, context int) []Line {
+	keep := make([]bool, len(full))
+	for idx, l := range full {
+		if l.Op == OpEqual {
+			continue
+		}
+		for k := max(0, idx-context); k <= min(len(full)-1, idx+context); k++ {
+			keep[k] = true
+		}
+	}
+
+	var out []Line
+	skipped := false
+	for idx, l := range full {
+		if keep[idx] {
+			out = append(out, l)
+			skipped = false
+			continue
+		}
+		if !skipped {
+			out = append(out, Line{OpSkip, "..."})
+			skipped = true
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package inspect
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCaptureRecord(t *testing.T) {
+	c := NewCapture("3f9a1c2b7d4e", []byte(`{"model":"gpt-4o"}`))
+	if c.RequestID != "3f9a1c2b7d4e" {
+		t.Errorf("RequestID = %q, want 3f9a1c2b7d4e", c.RequestID)
+	}
+
+	c.Record("session_override", []byte(`{"model":"gpt-4o"}`)) // unchanged, skipped
+	c.Record("routing", []byte(`{"model":"gpt-4o-mini"}`))
+	c.Record("compression", []byte(`{"model":"gpt-4o-mini"}`)) // unchanged, skipped
+
+	stages := c.Stages()
+	if len(stages) != 2 {
+		t.Fatalf("len(Stages()) = %d, want 2", len(stages))
+	}
+	if stages[0].Stage != "original" || stages[1].Stage != "routing" {
+		t.Errorf("stages = [%s, %s], want [original, routing]", stages[0].Stage, stages[1].Stage)
+	}
+}
+
+func TestCaptureNilSafe(t *testing.T) {
+	var c *Capture
+	c.Record("routing", []byte(`{}`))
+	if got := c.Stages(); got != nil {
+		t.Errorf("nil Capture Stages() = %v, want nil", got)
+	}
+}
+
+func TestPretty(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"json object", `{"a":1}`, "{\n  \"a\": 1\n}"},
+		{"not json", "plain text", "plain text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Pretty(tt.in); got != tt.want {
+				t.Errorf("Pretty(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{
+			name:    "single change",
+			a:       "a\nb\nc",
+			b:       "a\nx\nc",
+			context: 1,
+			want:    " a|-b|+x| c",
+		},
+		{
+			name:    "insertion at end",
+			a:       "a\nb",
+			b:       "a\nb\nc",
+			context: 0,
+			want:    "~...|+c",
+		},
+		{
+			name:    "unchanged lines elided",
+			a:       "1\n2\n3\n4\n5\n6",
+			b:       "1\n2\n3\n4\n5\nX",
+			context: 1,
+			want:    "~...| 5|-6|+X",
+		},
+		{
+			name:    "deletion",
+			a:       "a\nb\nc",
+			b:       "a\nc",
+			context: 5,
+			want:    " a|-b| c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parts []string
+			for _, l := range Diff(tt.a, tt.b, tt.context) {
+				parts = append(parts, string(l.Op)+l.Text)
+			}
+			if got := strings.Join(parts, "|"); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
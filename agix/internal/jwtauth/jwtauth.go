@@ -0,0 +1,336 @@
+// Package jwtauth validates the bearer JWTs agents present when they
+// already hold a workload identity (Kubernetes service accounts, SPIFFE,
+// an OIDC provider). Keys come from a JWKS URL or a static key; only the
+// standard library is used.
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+// leeway tolerates clock skew between agix and the token issuer.
+const leeway = time.Minute
+
+// Claims are a token's decoded payload.
+type Claims map[string]any
+
+// String returns the claim name as a string, following dots into nested
+// objects. Numbers are formatted; anything else yields "".
+func (c Claims) String(name string) string {
+	var v any = map[string]any(c)
+	for _, part := range strings.Split(name, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+		v = m[part]
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// Verifier checks token signatures and registered claims.
+type Verifier struct {
+	cfg    config.JWTConfig
+	static crypto.PublicKey // from public_key_file
+	jwks   *jwks
+	now    func() time.Time
+}
+
+// New returns a verifier for cfg. Relative key files are resolved against
+// baseDir. The JWKS is fetched lazily on first use.
+func New(cfg config.JWTConfig, baseDir string) (*Verifier, error) {
+	v := &Verifier{cfg: cfg, now: time.Now}
+	if cfg.PublicKeyFile != "" {
+		path := cfg.PublicKeyFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		key, err := loadPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("auth.jwt.public_key_file: %w", err)
+		}
+		v.static = key
+	}
+	if cfg.JWKSURL != "" {
+		v.jwks = newJWKS(cfg.JWKSURL, &http.Client{Timeout: 10 * time.Second})
+	}
+	if v.static == nil && v.jwks == nil && cfg.Secret == "" {
+		return nil, fmt.Errorf("auth.jwt: set jwks_url, public_key_file or secret")
+	}
+	return v, nil
+}
+
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// LooksLikeJWT reports whether s has the three-part shape of a compact
+// JWS, so tokens can be told apart from placeholder API keys.
+func LooksLikeJWT(s string) bool {
+	return strings.HasPrefix(s, "eyJ") && strings.Count(s, ".") == 2
+}
+
+// FromRequest returns a JWT carried in "Authorization: Bearer" or
+// x-api-key, or "".
+func FromRequest(r *http.Request) string {
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && LooksLikeJWT(strings.TrimSpace(v)) {
+		return strings.TrimSpace(v)
+	}
+	if v := strings.TrimSpace(r.Header.Get("x-api-key")); LooksLikeJWT(v) {
+		return v
+	}
+	return ""
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks token's signature, exp, nbf, iss and aud and returns its
+// claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("token header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("token signature: %w", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	if err := v.verifySignature(ctx, h, signed, sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("token claims: %w", err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(ctx context.Context, h header, signed, sig []byte) error {
+	if strings.HasPrefix(h.Alg, "HS") {
+		// HMAC only with the configured secret, never with a public key
+		if v.cfg.Secret == "" {
+			return fmt.Errorf("unsupported algorithm %q", h.Alg)
+		}
+		hash, err := hashFor(h.Alg)
+		if err != nil {
+			return err
+		}
+		mac := hmac.New(hash.New, []byte(v.cfg.Secret))
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return errSignature
+		}
+		return nil
+	}
+
+	var keys []crypto.PublicKey
+	var err error
+	if v.static != nil {
+		keys = append(keys, v.static)
+	}
+	if v.jwks != nil {
+		var found []crypto.PublicKey
+		found, err = v.jwks.lookup(ctx, h.Kid)
+		if err != nil && len(keys) == 0 {
+			return err
+		}
+		keys = append(keys, found...)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no key for kid %q", h.Kid)
+	}
+	for _, key := range keys {
+		if err = verifyWith(h.Alg, key, signed, sig); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+var (
+	errSignature = errors.New("invalid token signature")
+	errAlgorithm = errors.New("token algorithm does not match the key")
+)
+
+// hashFor returns the hash of a three-letter-plus-size algorithm name
+// such as RS256.
+func hashFor(alg string) (crypto.Hash, error) {
+	if len(alg) != 5 {
+		return 0, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	switch hash {
+	case crypto.SHA384:
+		sum := sha512.Sum384(data)
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512(data)
+		return sum[:]
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// verifyWith checks sig over signed with key under alg.
+func verifyWith(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	if alg == "EdDSA" {
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errAlgorithm
+		}
+		if !ed25519.Verify(k, signed, sig) {
+			return errSignature
+		}
+		return nil
+	}
+	hash, err := hashFor(alg)
+	if err != nil {
+		return err
+	}
+	sum := digest(hash, signed)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errAlgorithm
+		}
+		if alg[:2] == "PS" {
+			err = rsa.VerifyPSS(k, hash, sum, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			err = rsa.VerifyPKCS1v15(k, hash, sum, sig)
+		}
+		if err != nil {
+			return errSignature
+		}
+		return nil
+	case "ES":
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errAlgorithm
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, sum, r, s) {
+			return errSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+func (v *Verifier) checkClaims(c Claims) error {
+	now := v.now()
+	exp, ok := c["exp"].(float64)
+	if !ok {
+		return errors.New("token has no exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := c["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	if v.cfg.Issuer != "" && c.String("iss") != v.cfg.Issuer {
+		return fmt.Errorf("token issuer %q is not %q", c.String("iss"), v.cfg.Issuer)
+	}
+	if v.cfg.Audience != "" && !hasAudience(c["aud"], v.cfg.Audience) {
+		return fmt.Errorf("token audience does not include %q", v.cfg.Audience)
+	}
+	return nil
+}
+
+// hasAudience reports whether aud (a string or a list of strings)
+// contains want.
+func hasAudience(aud any, want string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == want
+	case []any:
+		for _, a := range aud {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
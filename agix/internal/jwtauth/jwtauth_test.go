@@ -0,0 +1,189 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+var b64 = base64.RawURLEncoding.EncodeToString
+
+// sign builds a compact JWS; sign computes the signature over the
+// encoded header and claims.
+func sign(t *testing.T, hdr map[string]string, claims map[string]any, signer func([]byte) []byte) string {
+	t.Helper()
+	h, _ := json.Marshal(hdr)
+	c, _ := json.Marshal(claims)
+	signed := b64(h) + "." + b64(c)
+	return signed + "." + b64(signer([]byte(signed)))
+}
+
+func hs256(secret string) func([]byte) []byte {
+	return func(data []byte) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(data)
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(key *rsa.PrivateKey) func([]byte) []byte {
+	return func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+		return sig
+	}
+}
+
+func es256(key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		r, s, _ := ecdsa.Sign(rand.Reader, key, sum[:])
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	}
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":  "code-reviewer",
+		"iss":  "https://idp.example.com",
+		"aud":  []string{"agix", "other"},
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"agix": map[string]any{"project": "search"},
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	v, err := New(config.JWTConfig{Secret: "s3cret", Issuer: "https://idp.example.com", Audience: "agix"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	hdr := map[string]string{"alg": "HS256", "typ": "JWT"}
+
+	claims, err := v.Verify(ctx, sign(t, hdr, validClaims(), hs256("s3cret")))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.String("sub") != "code-reviewer" || claims.String("agix.project") != "search" {
+		t.Errorf("claims = %v", claims)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(map[string]any)
+		secret string
+		alg    string
+	}{
+		{"wrong secret", func(map[string]any) {}, "other", "HS256"},
+		{"expired", func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, "s3cret", "HS256"},
+		{"no exp", func(c map[string]any) { delete(c, "exp") }, "s3cret", "HS256"},
+		{"not yet valid", func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() }, "s3cret", "HS256"},
+		{"wrong issuer", func(c map[string]any) { c["iss"] = "https://evil.example.com" }, "s3cret", "HS256"},
+		{"wrong audience", func(c map[string]any) { c["aud"] = "other" }, "s3cret", "HS256"},
+		{"alg none", func(map[string]any) {}, "s3cret", "none"},
+	}
+	for _, tt := range tests {
+		c := validClaims()
+		tt.mutate(c)
+		token := sign(t, map[string]string{"alg": tt.alg}, c, hs256(tt.secret))
+		if _, err := v.Verify(ctx, token); err == nil {
+			t.Errorf("%s: Verify succeeded", tt.name)
+		}
+	}
+}
+
+func TestVerifyJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var fetches atomic.Int32
+	var withEC atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		keys := []map[string]string{{
+			"kty": "RSA", "kid": "rsa-1", "use": "sig",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		}}
+		if withEC.Load() {
+			keys = append(keys, map[string]string{
+				"kty": "EC", "kid": "ec-1", "crv": "P-256",
+				"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer srv.Close()
+
+	v, err := New(config.JWTConfig{JWKSURL: srv.URL}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := v.Verify(ctx, sign(t, map[string]string{"alg": "RS256", "kid": "rsa-1"}, validClaims(), rs256(rsaKey))); err != nil {
+		t.Fatalf("RS256: %v", err)
+	}
+	// An RSA key must not verify a token claiming HMAC with the public key as secret.
+	if _, err := v.Verify(ctx, sign(t, map[string]string{"alg": "HS256", "kid": "rsa-1"}, validClaims(), hs256("x"))); err == nil {
+		t.Error("HS256 token verified against a JWKS")
+	}
+
+	// A new kid is fetched only after jwksMinRefresh has passed.
+	withEC.Store(true)
+	ecToken := sign(t, map[string]string{"alg": "ES256", "kid": "ec-1"}, validClaims(), es256(ecKey))
+	if _, err := v.Verify(ctx, ecToken); err == nil {
+		t.Error("unknown kid verified before refresh")
+	}
+	v.jwks.fetched = time.Now().Add(-2 * jwksMinRefresh)
+	if _, err := v.Verify(ctx, ecToken); err != nil {
+		t.Fatalf("ES256 after rotation: %v", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", n)
+	}
+}
+
+func TestVerifyPublicKeyFile(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "idp.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := New(config.JWTConfig{PublicKeyFile: "idp.pem"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := sign(t, map[string]string{"alg": "ES256"}, validClaims(), es256(ecKey))
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	tampered := strings.Replace(token, ".", ".e30", 1)
+	if _, err := v.Verify(context.Background(), tampered); err == nil {
+		t.Error("tampered token verified")
+	}
+}
@@ -0,0 +1,225 @@
+// Package keypool spreads requests to a provider over several API keys
+// and sets aside keys the provider rejects (401/403) or rate-limits (429)
+// until they are likely to work again.
+package keypool
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+// Defaults for KeyPoolConfig.Quarantine and AuthQuarantine.
+const (
+	DefaultQuarantine     = time.Minute
+	DefaultAuthQuarantine = time.Hour
+)
+
+// Pool hands out one provider's keys in turn. It is safe for concurrent
+// use.
+type Pool struct {
+	provider       string
+	quarantine     time.Duration
+	authQuarantine time.Duration
+	now            func() time.Time
+
+	mu      sync.Mutex
+	members []*member
+}
+
+type member struct {
+	key     string
+	weight  int
+	current int       // smooth weighted round-robin state
+	until   time.Time // quarantined before this time
+	status  int       // the response that quarantined it
+}
+
+// New returns the pool for provider's cfg.
+func New(provider string, cfg config.KeyPoolConfig) (*Pool, error) {
+	p := &Pool{
+		provider:       provider,
+		quarantine:     DefaultQuarantine,
+		authQuarantine: DefaultAuthQuarantine,
+		now:            time.Now,
+	}
+	if len(cfg.Keys) == 0 {
+		return nil, fmt.Errorf("no keys")
+	}
+	weighted := false
+	switch cfg.Strategy {
+	case "", "round_robin":
+	case "weighted":
+		weighted = true
+	default:
+		return nil, fmt.Errorf("strategy: want round_robin or weighted, got %q", cfg.Strategy)
+	}
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"quarantine", cfg.Quarantine, &p.quarantine},
+		{"auth_quarantine", cfg.AuthQuarantine, &p.authQuarantine},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("%s: invalid duration %q", d.name, d.value)
+		}
+		*d.dst = v
+	}
+
+	seen := map[string]bool{}
+	for i, k := range cfg.Keys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("keys[%d]: empty key", i)
+		}
+		if seen[k.Key] {
+			return nil, fmt.Errorf("keys[%d]: duplicate key", i)
+		}
+		seen[k.Key] = true
+		if k.Weight < 0 {
+			return nil, fmt.Errorf("keys[%d]: negative weight", i)
+		}
+		w := 1
+		if weighted && k.Weight > 0 {
+			w = k.Weight
+		}
+		p.members = append(p.members, &member{key: k.Key, weight: w})
+	}
+	return p, nil
+}
+
+// Inherit carries over the quarantines prev holds on keys that are also
+// in p, so a config reload does not hand out a key just set aside.
+func (p *Pool) Inherit(prev *Pool) {
+	if prev == nil {
+		return
+	}
+	prev.mu.Lock()
+	old := make(map[string]member, len(prev.members))
+	for _, m := range prev.members {
+		old[m.key] = *m
+	}
+	prev.mu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, m := range p.members {
+		if o, ok := old[m.key]; ok {
+			m.until, m.status = o.until, o.status
+		}
+	}
+}
+
+// Pick returns the next key. Quarantined keys are skipped; if every key
+// is quarantined, the one released soonest is returned rather than
+// failing the request outright.
+func (p *Pool) Pick() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+
+	// Smooth weighted round robin (as in nginx): with equal weights it
+	// visits the keys in order, with unequal ones it interleaves them.
+	var best *member
+	total := 0
+	for _, m := range p.members {
+		if now.Before(m.until) {
+			continue
+		}
+		m.current += m.weight
+		total += m.weight
+		if best == nil || m.current > best.current {
+			best = m
+		}
+	}
+	if best != nil {
+		best.current -= total
+		return best.key
+	}
+
+	soonest := p.members[0]
+	for _, m := range p.members[1:] {
+		if m.until.Before(soonest.until) {
+			soonest = m
+		}
+	}
+	return soonest.key
+}
+
+// Report records the response the provider gave to a request made with
+// key. It reports whether key belongs to the pool.
+func (p *Pool) Report(key string, status int, header http.Header) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var m *member
+	for _, c := range p.members {
+		if c.key == key {
+			m = c
+			break
+		}
+	}
+	if m == nil {
+		return false
+	}
+
+	var d time.Duration
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		d = p.authQuarantine
+	case status == http.StatusTooManyRequests:
+		d = p.quarantine
+		if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil && secs > 0 {
+			d = time.Duration(secs) * time.Second
+		}
+	case status < 400:
+		m.until, m.status = time.Time{}, 0
+		return true
+	default:
+		// Server errors say nothing about the key.
+		return true
+	}
+	m.until, m.status = p.now().Add(d), status
+	log.Printf("WARN: key_pools.%s: key %s got %d, skipping it for %s", p.provider, Label(key), status, d)
+	return true
+}
+
+// KeyStatus describes one key for display.
+type KeyStatus struct {
+	Label            string    `json:"key"`
+	Weight           int       `json:"weight"`
+	QuarantinedUntil time.Time `json:"quarantined_until,omitzero"`
+	Status           int       `json:"status,omitempty"` // response that caused the quarantine
+}
+
+// Status returns the pool's keys in configured order.
+func (p *Pool) Status() []KeyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	out := make([]KeyStatus, len(p.members))
+	for i, m := range p.members {
+		out[i] = KeyStatus{Label: Label(m.key), Weight: m.weight}
+		if now.Before(m.until) {
+			out[i].QuarantinedUntil, out[i].Status = m.until, m.status
+		}
+	}
+	return out
+}
+
+// Label identifies key in logs without revealing it.
+func Label(key string) string {
+	if len(key) <= 8 {
+		return "..."
+	}
+	return "..." + key[len(key)-4:]
+}
@@ -0,0 +1,113 @@
+package keypool
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+func newPool(t *testing.T, cfg config.KeyPoolConfig) (*Pool, *time.Time) {
+	t.Helper()
+	p, err := New("openai", cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	return p, &now
+}
+
+func picks(p *Pool, n int) string {
+	var got []string
+	for range n {
+		got = append(got, p.Pick())
+	}
+	return strings.Join(got, ",")
+}
+
+func TestPick(t *testing.T) {
+	keys := []config.PoolKey{{Key: "a", Weight: 3}, {Key: "b", Weight: 1}}
+
+	rr, _ := newPool(t, config.KeyPoolConfig{Keys: keys})
+	if got := picks(rr, 4); got != "a,b,a,b" {
+		t.Errorf("round_robin = %s, want a,b,a,b (weights ignored)", got)
+	}
+
+	w, _ := newPool(t, config.KeyPoolConfig{Strategy: "weighted", Keys: keys})
+	if got := picks(w, 8); strings.Count(got, "a") != 6 || strings.Count(got, "b") != 2 {
+		t.Errorf("weighted = %s, want a 3 times as often as b", got)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	p, now := newPool(t, config.KeyPoolConfig{
+		Keys: []config.PoolKey{{Key: "a"}, {Key: "b"}, {Key: "c"}},
+	})
+
+	p.Report("a", http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}})
+	p.Report("b", http.StatusUnauthorized, nil)
+	if got := picks(p, 3); got != "c,c,c" {
+		t.Errorf("picks = %s, want only c", got)
+	}
+
+	// All quarantined: the one released soonest is still handed out.
+	p.Report("c", http.StatusTooManyRequests, nil)
+	if got := p.Pick(); got != "a" {
+		t.Errorf("all quarantined: Pick = %s, want a", got)
+	}
+
+	*now = now.Add(31 * time.Second)
+	if got := picks(p, 2); got != "a,a" {
+		t.Errorf("after Retry-After: picks = %s, want a,a", got)
+	}
+	*now = now.Add(DefaultQuarantine)
+	if got := picks(p, 2); got != "c,a" && got != "a,c" {
+		t.Errorf("after quarantine: picks = %s, want a and c", got)
+	}
+
+	// A success clears a quarantine early, 5xx does not set one.
+	p.Report("b", http.StatusOK, nil)
+	p.Report("a", http.StatusInternalServerError, nil)
+	if got := picks(p, 3); strings.Count(got, "b") != 1 || strings.Count(got, "a") != 1 {
+		t.Errorf("picks = %s, want each key once", got)
+	}
+	if p.Report("z", http.StatusUnauthorized, nil) {
+		t.Error("Report of a foreign key = true")
+	}
+}
+
+func TestInherit(t *testing.T) {
+	old, _ := newPool(t, config.KeyPoolConfig{Keys: []config.PoolKey{{Key: "a"}, {Key: "b"}}})
+	old.Report("a", http.StatusUnauthorized, nil)
+
+	p, _ := newPool(t, config.KeyPoolConfig{Keys: []config.PoolKey{{Key: "a"}, {Key: "c"}}})
+	p.Inherit(old)
+	if got := picks(p, 2); got != "c,c" {
+		t.Errorf("picks = %s, want a still quarantined", got)
+	}
+	st := p.Status()
+	if st[0].Status != http.StatusUnauthorized || st[0].QuarantinedUntil.IsZero() || !st[1].QuarantinedUntil.IsZero() {
+		t.Errorf("Status = %+v", st)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.KeyPoolConfig
+	}{
+		{"no keys", config.KeyPoolConfig{}},
+		{"bad strategy", config.KeyPoolConfig{Strategy: "random", Keys: []config.PoolKey{{Key: "a"}}}},
+		{"duplicate", config.KeyPoolConfig{Keys: []config.PoolKey{{Key: "a"}, {Key: "a"}}}},
+		{"empty key", config.KeyPoolConfig{Keys: []config.PoolKey{{Key: ""}}}},
+		{"bad duration", config.KeyPoolConfig{Quarantine: "soon", Keys: []config.PoolKey{{Key: "a"}}}},
+	}
+	for _, tt := range tests {
+		if _, err := New("openai", tt.cfg); err == nil {
+			t.Errorf("%s: New succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package loadshed
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Priority ranks agents for shedding. Low priority is rejected first.
+// The zero value is PriorityNormal.
+type Priority int
+
+const (
+	PriorityNormal Priority = iota
+	PriorityLow
+	PriorityHigh
+)
+
+// ParsePriority parses "low", "normal" or "high". Empty means normal.
+func ParsePriority(s string) (Priority, error) {
+	switch s {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return PriorityNormal, fmt.Errorf("unknown priority %q (want low, normal or high)", s)
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+// Rank orders priorities from low (0) to high (2), for queues that serve
+// more important requests first.
+func (p Priority) Rank() int {
+	switch p {
+	case PriorityHigh:
+		return 2
+	case PriorityLow:
+		return 0
+	}
+	return 1
+}
+
+// Config defines load shedding limits. A zero limit disables that signal.
+type Config struct {
+	Enabled         bool
+	MaxInFlight     int
+	MaxWriteBacklog int
+	MaxMemoryMB     int
+	// LowPriorityAt is the pressure (0-1) at which low-priority traffic is
+	// shed. Normal traffic is shed at 1.0; high priority is never shed.
+	LowPriorityAt   float64
+	RetryAfter      time.Duration
+	DefaultPriority Priority
+	Priorities      map[string]Priority
+}
+
+// Result is returned by Admit.
+type Result struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	Pressure   float64
+	Err        error
+}
+
+// memSampleInterval bounds how often runtime.ReadMemStats (a stop-the-world
+// call) runs on the request path.
+const memSampleInterval = time.Second
+
+// Shedder rejects traffic by priority when the gateway is overloaded.
+// Pressure is the highest ratio of in-flight requests, store write backlog
+// and heap use to their configured limits.
+type Shedder struct {
+	cfg      Config
+	backlog  func() int
+	inFlight atomic.Int64
+
+	mu         sync.Mutex
+	memSampled time.Time
+	memMB      float64
+	readMem    func() uint64 // overridable in tests
+}
+
+// New creates a Shedder. backlog reports the store's pending async writes
+// and may be nil. Returns nil if not enabled or no limit is set.
+func New(cfg Config, backlog func() int) *Shedder {
+	if !cfg.Enabled || (cfg.MaxInFlight <= 0 && cfg.MaxWriteBacklog <= 0 && cfg.MaxMemoryMB <= 0) {
+		return nil
+	}
+	if cfg.LowPriorityAt <= 0 || cfg.LowPriorityAt > 1 {
+		cfg.LowPriorityAt = 0.8
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = 5 * time.Second
+	}
+	if backlog == nil {
+		backlog = func() int { return 0 }
+	}
+	return &Shedder{
+		cfg:     cfg,
+		backlog: backlog,
+		readMem: heapInUse,
+	}
+}
+
+// Admit decides whether to accept a request from agent. When allowed, the
+// caller must invoke release once the request completes.
+func (s *Shedder) Admit(agent string) (release func(), res Result) {
+	pressure, signal := s.Pressure()
+	prio := s.priorityFor(agent)
+
+	threshold := 1.0
+	switch prio {
+	case PriorityLow:
+		threshold = s.cfg.LowPriorityAt
+	case PriorityHigh:
+		threshold = 0 // never shed
+	}
+
+	if threshold > 0 && pressure >= threshold {
+		return func() {}, Result{
+			Allowed:    false,
+			RetryAfter: s.cfg.RetryAfter,
+			Pressure:   pressure,
+			Err:        fmt.Errorf("%s pressure at %.0f%%, shedding %s priority traffic", signal, pressure*100, prio),
+		}
+	}
+
+	s.inFlight.Add(1)
+	var once sync.Once
+	return func() { once.Do(func() { s.inFlight.Add(-1) }) }, Result{Allowed: true, Pressure: pressure}
+}
+
+// Pressure returns the current load as a fraction of the tightest limit,
+// along with the name of the signal that produced it.
+func (s *Shedder) Pressure() (float64, string) {
+	var pressure float64
+	signal := "in-flight"
+
+	if s.cfg.MaxInFlight > 0 {
+		pressure = float64(s.inFlight.Load()) / float64(s.cfg.MaxInFlight)
+	}
+	if s.cfg.MaxWriteBacklog > 0 {
+		if p := float64(s.backlog()) / float64(s.cfg.MaxWriteBacklog); p > pressure {
+			pressure, signal = p, "write backlog"
+		}
+	}
+	if s.cfg.MaxMemoryMB > 0 {
+		if p := s.memoryMB() / float64(s.cfg.MaxMemoryMB); p > pressure {
+			pressure, signal = p, "memory"
+		}
+	}
+	return pressure, signal
+}
+
+// InFlight returns the number of admitted requests still running.
+func (s *Shedder) InFlight() int {
+	return int(s.inFlight.Load())
+}
+
+func (s *Shedder) priorityFor(agent string) Priority {
+	if p, ok := s.cfg.Priorities[agent]; ok {
+		return p
+	}
+	return s.cfg.DefaultPriority
+}
+
+// memoryMB returns heap use in MB, resampled at most once per memSampleInterval.
+func (s *Shedder) memoryMB() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := time.Now(); now.Sub(s.memSampled) >= memSampleInterval {
+		s.memMB = float64(s.readMem()) / (1 << 20)
+		s.memSampled = now
+	}
+	return s.memMB
+}
+
+func heapInUse() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
@@ -0,0 +1,137 @@
+package loadshed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew_NilWhenDisabled(t *testing.T) {
+	if s := New(Config{MaxInFlight: 10}, nil); s != nil {
+		t.Error("expected nil shedder when not enabled")
+	}
+	if s := New(Config{Enabled: true}, nil); s != nil {
+		t.Error("expected nil shedder with no limits")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Priority
+		wantErr bool
+	}{
+		{in: "", want: PriorityNormal},
+		{in: "low", want: PriorityLow},
+		{in: "normal", want: PriorityNormal},
+		{in: "high", want: PriorityHigh},
+		{in: "urgent", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePriority(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePriority(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParsePriority(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdmit_InFlightByPriority(t *testing.T) {
+	s := New(Config{
+		Enabled:     true,
+		MaxInFlight: 10,
+		Priorities: map[string]Priority{
+			"batch":    PriorityLow,
+			"critical": PriorityHigh,
+		},
+	}, nil)
+
+	var releases []func()
+	for i := 0; i < 8; i++ {
+		release, res := s.Admit("worker")
+		if !res.Allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+		releases = append(releases, release)
+	}
+
+	// 8/10 = 80%: low priority is shed, normal still admitted.
+	if _, res := s.Admit("batch"); res.Allowed {
+		t.Error("low priority should be shed at 80% pressure")
+	} else if res.RetryAfter != 5*time.Second {
+		t.Errorf("RetryAfter = %v, want 5s", res.RetryAfter)
+	}
+	for i := 0; i < 2; i++ {
+		release, res := s.Admit("worker")
+		if !res.Allowed {
+			t.Fatalf("normal request should be allowed below 100%%")
+		}
+		releases = append(releases, release)
+	}
+
+	// 10/10: normal shed, high still admitted.
+	if _, res := s.Admit("worker"); res.Allowed {
+		t.Error("normal priority should be shed at 100% pressure")
+	}
+	release, res := s.Admit("critical")
+	if !res.Allowed {
+		t.Error("high priority should never be shed")
+	}
+	releases = append(releases, release)
+
+	for _, r := range releases {
+		r()
+	}
+	if n := s.InFlight(); n != 0 {
+		t.Errorf("InFlight() = %d after release, want 0", n)
+	}
+	if _, res := s.Admit("batch"); !res.Allowed {
+		t.Error("low priority should be admitted once load drops")
+	}
+}
+
+func TestAdmit_ReleaseIsIdempotent(t *testing.T) {
+	s := New(Config{Enabled: true, MaxInFlight: 5}, nil)
+	release, _ := s.Admit("a")
+	release()
+	release()
+	if n := s.InFlight(); n != 0 {
+		t.Errorf("InFlight() = %d, want 0", n)
+	}
+}
+
+func TestPressure_WriteBacklog(t *testing.T) {
+	backlog := 0
+	s := New(Config{Enabled: true, MaxInFlight: 100, MaxWriteBacklog: 200}, func() int { return backlog })
+
+	backlog = 200
+	p, signal := s.Pressure()
+	if p != 1.0 || signal != "write backlog" {
+		t.Errorf("Pressure() = %v, %q; want 1.0, write backlog", p, signal)
+	}
+	if _, res := s.Admit("a"); res.Allowed {
+		t.Error("expected shedding with a full write backlog")
+	}
+}
+
+func TestPressure_MemorySampled(t *testing.T) {
+	s := New(Config{Enabled: true, MaxMemoryMB: 100}, nil)
+	calls := 0
+	s.readMem = func() uint64 {
+		calls++
+		return 90 << 20
+	}
+
+	for i := 0; i < 3; i++ {
+		p, signal := s.Pressure()
+		if p != 0.9 || signal != "memory" {
+			t.Fatalf("Pressure() = %v, %q; want 0.9, memory", p, signal)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("readMem called %d times, want 1 within the sample interval", calls)
+	}
+}
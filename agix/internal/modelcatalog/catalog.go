@@ -0,0 +1,273 @@
+// Package modelcatalog builds the GET /v1/models listing: the models agix
+// knows how to price and route, merged with live model lists fetched from
+// providers and annotated with context window, modality and deprecation
+// metadata.
+package modelcatalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metadata describes a model's capabilities.
+type Metadata struct {
+	ContextWindow   int      `json:"context_window,omitempty"`
+	Modalities      []string `json:"modalities,omitempty"`       // input modalities, e.g. text, image, audio
+	DeprecationDate string   `json:"deprecation_date,omitempty"` // YYYY-MM-DD
+}
+
+// builtin is metadata for well-known models, matched by longest prefix so
+// dated snapshots (gpt-4o-2024-08-06) inherit from their family. Deprecation
+// dates change too often to ship; set them in config.
+var builtin = map[string]Metadata{
+	"gpt-5":                  {ContextWindow: 400000, Modalities: []string{"text", "image"}},
+	"gpt-4.1":                {ContextWindow: 1047576, Modalities: []string{"text", "image"}},
+	"gpt-4o":                 {ContextWindow: 128000, Modalities: []string{"text", "image"}},
+	"o1":                     {ContextWindow: 200000, Modalities: []string{"text", "image"}},
+	"o3":                     {ContextWindow: 200000, Modalities: []string{"text", "image"}},
+	"o3-mini":                {ContextWindow: 200000, Modalities: []string{"text"}},
+	"o4-mini":                {ContextWindow: 200000, Modalities: []string{"text", "image"}},
+	"gpt-3.5-turbo-instruct": {ContextWindow: 4096, Modalities: []string{"text"}},
+	"davinci-002":            {ContextWindow: 16384, Modalities: []string{"text"}},
+	"babbage-002":            {ContextWindow: 16384, Modalities: []string{"text"}},
+	"text-embedding-3":       {ContextWindow: 8191, Modalities: []string{"text"}},
+	"text-embedding-ada-002": {ContextWindow: 8191, Modalities: []string{"text"}},
+
+	"claude-opus-4":    {ContextWindow: 200000, Modalities: []string{"text", "image"}},
+	"claude-sonnet-4":  {ContextWindow: 200000, Modalities: []string{"text", "image"}},
+	"claude-haiku-4":   {ContextWindow: 200000, Modalities: []string{"text", "image"}},
+	"claude-3-5-haiku": {ContextWindow: 200000, Modalities: []string{"text", "image"}},
+	"claude-3-haiku":   {ContextWindow: 200000, Modalities: []string{"text", "image"}},
+
+	"deepseek-chat":     {ContextWindow: 64000, Modalities: []string{"text"}},
+	"deepseek-reasoner": {ContextWindow: 64000, Modalities: []string{"text"}},
+}
+
+// Model is one entry of the /v1/models listing.
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created,omitempty"`
+	OwnedBy string `json:"owned_by"`
+	Metadata
+}
+
+// Source is a provider endpoint that lists models in the OpenAI
+// ({"data":[{"id":...}]}) shape, which Anthropic and DeepSeek also use.
+type Source struct {
+	Provider string
+	URL      string
+	Headers  map[string]string
+	Prefix   string // prepended to listed IDs so they route back to Provider
+}
+
+// Catalog caches live model lists and merges them with local metadata.
+// A nil Catalog only annotates models with built-in metadata.
+type Catalog struct {
+	sources   []Source
+	overrides map[string]Metadata
+	interval  time.Duration
+	client    *http.Client
+
+	mu   sync.RWMutex
+	live map[string][]Model // provider → last successful listing
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// DefaultInterval is how often live lists are refreshed.
+const DefaultInterval = time.Hour
+
+// New creates a Catalog. overrides are matched by exact model ID and their
+// non-empty fields take precedence over built-in metadata. Call Start to
+// fetch live lists.
+func New(sources []Source, overrides map[string]Metadata, interval time.Duration) *Catalog {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	o := make(map[string]Metadata, len(overrides))
+	for id, m := range overrides {
+		o[strings.ToLower(id)] = m
+	}
+	return &Catalog{
+		sources:   sources,
+		overrides: o,
+		interval:  interval,
+		client:    &http.Client{Timeout: 15 * time.Second},
+		live:      map[string][]Model{},
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start fetches live lists now and then every interval.
+func (c *Catalog) Start() {
+	go func() {
+		c.Refresh(context.Background())
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.Refresh(context.Background())
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops background refreshes.
+func (c *Catalog) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// Refresh fetches every source. A provider that fails keeps its previous
+// listing; the errors are logged and returned joined.
+func (c *Catalog) Refresh(ctx context.Context) error {
+	var errs []string
+	for _, src := range c.sources {
+		models, err := c.fetch(ctx, src)
+		if err != nil {
+			log.Printf("WARN: model catalog: %s: %v", src.Provider, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", src.Provider, err))
+			continue
+		}
+		c.mu.Lock()
+		c.live[src.Provider] = models
+		c.mu.Unlock()
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("refresh model catalog: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *Catalog) fetch(ctx context.Context, src Source) ([]Model, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	for k, v := range src.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var body struct {
+		Data []struct {
+			ID        string `json:"id"`
+			Created   int64  `json:"created"`
+			CreatedAt string `json:"created_at"` // Anthropic
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode model list: %w", err)
+	}
+	models := make([]Model, 0, len(body.Data))
+	for _, d := range body.Data {
+		if d.ID == "" {
+			continue
+		}
+		created := d.Created
+		if created == 0 && d.CreatedAt != "" {
+			if t, err := time.Parse(time.RFC3339, d.CreatedAt); err == nil {
+				created = t.Unix()
+			}
+		}
+		models = append(models, Model{ID: src.Prefix + d.ID, Object: "model", Created: created, OwnedBy: src.Provider})
+	}
+	return models, nil
+}
+
+// Merge combines the static listing with cached live lists, dropping
+// duplicate IDs, and annotates every model with metadata. Live entries
+// supply the created timestamp for static ones. The result is sorted by ID.
+func (c *Catalog) Merge(static []Model) []Model {
+	byID := make(map[string]int, len(static))
+	out := make([]Model, 0, len(static))
+	add := func(m Model) {
+		if i, ok := byID[m.ID]; ok {
+			if out[i].Created == 0 {
+				out[i].Created = m.Created
+			}
+			return
+		}
+		byID[m.ID] = len(out)
+		out = append(out, m)
+	}
+	for _, m := range static {
+		add(m)
+	}
+	if c != nil {
+		c.mu.RLock()
+		providers := make([]string, 0, len(c.live))
+		for p := range c.live {
+			providers = append(providers, p)
+		}
+		sort.Strings(providers)
+		for _, p := range providers {
+			for _, m := range c.live[p] {
+				add(m)
+			}
+		}
+		c.mu.RUnlock()
+	}
+	for i := range out {
+		out[i].Metadata = c.Lookup(out[i].ID)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Lookup returns metadata for a model: the longest matching built-in
+// prefix, with any configured override fields laid over it. Provider
+// prefixes such as "azure/" or "openrouter/openai/" are ignored when
+// matching built-ins.
+func (c *Catalog) Lookup(model string) Metadata {
+	id := strings.ToLower(model)
+	base := id
+	if i := strings.LastIndex(base, "/"); i >= 0 {
+		base = base[i+1:]
+	}
+	var best string
+	for name := range builtin {
+		if strings.HasPrefix(base, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	var m Metadata
+	if best != "" {
+		m = builtin[best]
+	}
+	if c == nil {
+		return m
+	}
+	if o, ok := c.overrides[id]; ok {
+		if o.ContextWindow > 0 {
+			m.ContextWindow = o.ContextWindow
+		}
+		if len(o.Modalities) > 0 {
+			m.Modalities = o.Modalities
+		}
+		if o.DeprecationDate != "" {
+			m.DeprecationDate = o.DeprecationDate
+		}
+	}
+	return m
+}
@@ -0,0 +1,120 @@
+package modelcatalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	c := New(nil, map[string]Metadata{
+		"groq/llama-3.3-70b": {ContextWindow: 131072},
+		"gpt-4o-2024-05-13":  {DeprecationDate: "2026-06-30"},
+	}, 0)
+
+	tests := []struct {
+		name  string
+		model string
+		want  int
+	}{
+		{"exact builtin", "gpt-4o", 128000},
+		{"dated snapshot", "gpt-4o-2024-08-06", 128000},
+		{"longest prefix", "o3-mini", 200000},
+		{"provider prefix stripped", "azure/gpt-4o", 128000},
+		{"override", "groq/llama-3.3-70b", 131072},
+		{"unknown", "mystery-model", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Lookup(tt.model).ContextWindow; got != tt.want {
+				t.Errorf("Lookup(%q).ContextWindow = %d, want %d", tt.model, got, tt.want)
+			}
+		})
+	}
+
+	if m := c.Lookup("gpt-4o-2024-05-13"); m.DeprecationDate != "2026-06-30" || m.ContextWindow != 128000 {
+		t.Errorf("partial override = %+v, want deprecation date over built-in context window", m)
+	}
+
+	var nilCatalog *Catalog
+	if got := nilCatalog.Lookup("gpt-4o").ContextWindow; got != 128000 {
+		t.Errorf("nil Lookup = %d, want built-in 128000", got)
+	}
+}
+
+func TestRefreshAndMerge(t *testing.T) {
+	openai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"gpt-4o","created":1715367049},{"id":"gpt-4o-2024-11-20","created":1732060800}]}`))
+	}))
+	defer openai.Close()
+	anthropic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":"claude-sonnet-4-20250514","created_at":"2025-05-22T00:00:00Z"}]}`))
+	}))
+	defer anthropic.Close()
+	groq := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":"llama-3.3-70b"}]}`))
+	}))
+	defer groq.Close()
+
+	c := New([]Source{
+		{Provider: "openai", URL: openai.URL, Headers: map[string]string{"Authorization": "Bearer sk-test"}},
+		{Provider: "anthropic", URL: anthropic.URL},
+		{Provider: "groq", URL: groq.URL, Prefix: "groq/"},
+	}, nil, 0)
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	got := c.Merge([]Model{{ID: "gpt-4o", Object: "model", OwnedBy: "openai"}})
+	byID := map[string]Model{}
+	for _, m := range got {
+		if _, dup := byID[m.ID]; dup {
+			t.Errorf("duplicate model %q", m.ID)
+		}
+		byID[m.ID] = m
+	}
+	if len(got) != 4 {
+		t.Errorf("Merge returned %d models, want 4: %+v", len(got), got)
+	}
+	if m := byID["gpt-4o"]; m.Created != 1715367049 || m.ContextWindow != 128000 {
+		t.Errorf("gpt-4o = %+v, want live created and built-in context window", m)
+	}
+	if m := byID["claude-sonnet-4-20250514"]; m.Created != 1747872000 || m.OwnedBy != "anthropic" {
+		t.Errorf("claude entry = %+v", m)
+	}
+	if _, ok := byID["groq/llama-3.3-70b"]; !ok {
+		t.Error("custom provider model not prefixed")
+	}
+	if got[0].ID > got[len(got)-1].ID {
+		t.Error("Merge result not sorted")
+	}
+}
+
+func TestRefreshKeepsLastGoodListing(t *testing.T) {
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "boom", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"deepseek-chat"}]}`))
+	}))
+	defer srv.Close()
+
+	c := New([]Source{{Provider: "deepseek", URL: srv.URL}}, nil, 0)
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	fail = true
+	if err := c.Refresh(context.Background()); err == nil {
+		t.Error("Refresh() succeeded against a failing provider")
+	}
+	if got := c.Merge(nil); len(got) != 1 || got[0].ID != "deepseek-chat" {
+		t.Errorf("Merge after failed refresh = %+v, want cached deepseek-chat", got)
+	}
+}
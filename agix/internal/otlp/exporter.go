@@ -0,0 +1,360 @@
+// Package otlp exports request traces to an OpenTelemetry collector over
+// OTLP/HTTP with the JSON encoding, so they show up in Jaeger, Tempo or
+// Honeycomb. Export is best effort: traces that cannot be delivered are
+// dropped.
+package otlp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/trace"
+)
+
+// Config defines the collector endpoint and batching.
+type Config struct {
+	Endpoint      string            // OTLP/HTTP base URL, e.g. http://localhost:4318; /v1/traces is appended
+	Headers       map[string]string // e.g. x-honeycomb-team
+	ServiceName   string            // default "agix"
+	BatchSize     int               // traces per request, default 100
+	FlushInterval time.Duration     // default 5s
+}
+
+const (
+	defaultServiceName   = "agix"
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+
+	// rootSpanName covers the whole pipeline; recorded spans are its children.
+	rootSpanName = "agix.request"
+)
+
+// Exporter batches traces and posts them to the collector.
+type Exporter struct {
+	cfg    Config
+	url    string
+	client *http.Client
+	ch     chan *trace.Trace
+
+	mu      sync.Mutex
+	dropped int
+
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New validates cfg and fills in defaults. Nothing is sent until Start is
+// called.
+func New(cfg Config) (*Exporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("otlp endpoint is required")
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid otlp endpoint %q", cfg.Endpoint)
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaultServiceName
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	return &Exporter{
+		cfg:    cfg,
+		url:    endpoint,
+		client: &http.Client{Timeout: 10 * time.Second},
+		ch:     make(chan *trace.Trace, cfg.BatchSize*4),
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// Start launches the background exporter.
+func (e *Exporter) Start() {
+	e.started = true
+	go e.run()
+}
+
+// Export queues a completed trace. When the queue is full the trace is
+// dropped so a slow collector never holds up requests.
+func (e *Exporter) Export(t *trace.Trace) {
+	if t == nil {
+		return
+	}
+	select {
+	case e.ch <- t:
+	default:
+		e.mu.Lock()
+		e.dropped++
+		e.mu.Unlock()
+	}
+}
+
+// Dropped returns the number of traces that were not delivered.
+func (e *Exporter) Dropped() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+// Close flushes queued traces and stops the exporter.
+func (e *Exporter) Close() {
+	e.closeOnce.Do(func() {
+		close(e.ch)
+		if e.started {
+			<-e.done
+		}
+	})
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+
+	buf := make([]*trace.Trace, 0, e.cfg.BatchSize)
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(buf) == 0 {
+			return
+		}
+		if err := e.send(buf); err != nil {
+			log.Printf("WARN: otlp: dropped %d trace(s): %v", len(buf), err)
+			e.mu.Lock()
+			e.dropped += len(buf)
+			e.mu.Unlock()
+		}
+		buf = buf[:0]
+	}
+
+	for {
+		select {
+		case t, ok := <-e.ch:
+			if !ok {
+				flush()
+				return
+			}
+			buf = append(buf, t)
+			if len(buf) >= e.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (e *Exporter) send(traces []*trace.Trace) error {
+	body, err := json.Marshal(e.encode(traces))
+	if err != nil {
+		return fmt.Errorf("encode traces: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// OTLP JSON encoding (opentelemetry-proto ExportTraceServiceRequest).
+// Trace and span IDs are hex strings and 64-bit integers are strings.
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+)
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func (e *Exporter) encode(traces []*trace.Trace) exportRequest {
+	var spans []span
+	for _, t := range traces {
+		spans = append(spans, encodeTrace(t)...)
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{attr("service.name", e.cfg.ServiceName)}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: "github.com/agent-platform/agix"},
+			Spans: spans,
+		}},
+	}}}
+}
+
+// encodeTrace turns a trace into a root span spanning the whole pipeline
+// with one child per recorded step.
+func encodeTrace(t *trace.Trace) []span {
+	traceID := TraceID(t.ID)
+	rootID := newSpanID()
+	recorded := t.Spans()
+
+	start, end := t.Timestamp, t.Timestamp
+	for _, s := range recorded {
+		if s.StartTime.Before(start) {
+			start = s.StartTime
+		}
+		if e := s.StartTime.Add(time.Duration(s.DurationMS) * time.Millisecond); e.After(end) {
+			end = e
+		}
+	}
+
+	rootAttrs := []keyValue{attr("agix.trace_id", t.ID)}
+	if t.RequestID != "" {
+		rootAttrs = append(rootAttrs, attr("agix.request_id", t.RequestID))
+	}
+	if t.AgentName != "" {
+		rootAttrs = append(rootAttrs, attr("agix.agent", t.AgentName))
+	}
+	if t.Model != "" {
+		rootAttrs = append(rootAttrs, attr("gen_ai.request.model", t.Model))
+	}
+
+	out := make([]span, 0, len(recorded)+1)
+	out = append(out, span{
+		TraceID:           traceID,
+		SpanID:            rootID,
+		Name:              rootSpanName,
+		Kind:              spanKindServer,
+		StartTimeUnixNano: unixNano(start),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes:        rootAttrs,
+	})
+	for _, s := range recorded {
+		keys := make([]string, 0, len(s.Metadata))
+		for k := range s.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]keyValue, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, attr("agix."+k, s.Metadata[k]))
+		}
+		out = append(out, span{
+			TraceID:           traceID,
+			SpanID:            newSpanID(),
+			ParentSpanID:      rootID,
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(s.StartTime),
+			EndTimeUnixNano:   unixNano(s.StartTime.Add(time.Duration(s.DurationMS) * time.Millisecond)),
+			Attributes:        attrs,
+		})
+	}
+	return out
+}
+
+// TraceID widens an agix trace ID (12 hex characters) to the 32 hex
+// characters OTLP requires by left-padding with zeros, so the ID shown in
+// X-Trace-ID can be searched for in the tracing backend.
+func TraceID(id string) string {
+	if len(id) >= 32 {
+		return id[:32]
+	}
+	return strings.Repeat("0", 32-len(id)) + id
+}
+
+func newSpanID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func attr(key string, v any) keyValue {
+	var av anyValue
+	switch x := v.(type) {
+	case string:
+		av.StringValue = &x
+	case bool:
+		av.BoolValue = &x
+	case int:
+		s := strconv.Itoa(x)
+		av.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		av.IntValue = &s
+	case float64:
+		av.DoubleValue = &x
+	default:
+		s := fmt.Sprint(x)
+		av.StringValue = &s
+	}
+	return keyValue{Key: key, Value: av}
+}
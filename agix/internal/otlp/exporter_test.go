@@ -0,0 +1,123 @@
+package otlp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/trace"
+)
+
+type fakeCollector struct {
+	mu       sync.Mutex
+	path     string
+	apiKey   string
+	requests []exportRequest
+	status   int
+}
+
+func (f *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.path = r.URL.Path
+	f.apiKey = r.Header.Get("x-honeycomb-team")
+	if f.status != 0 {
+		http.Error(w, "unavailable", f.status)
+		return
+	}
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil {
+		f.requests = append(f.requests, req)
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	for _, endpoint := range []string{"", "localhost:4318", "://bad"} {
+		if _, err := New(Config{Endpoint: endpoint}); err == nil {
+			t.Errorf("New(%q) succeeded, want error", endpoint)
+		}
+	}
+	e, err := New(Config{Endpoint: "http://collector:4318/"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if e.url != "http://collector:4318/v1/traces" {
+		t.Errorf("url = %q", e.url)
+	}
+}
+
+func TestExport(t *testing.T) {
+	fc := &fakeCollector{}
+	srv := httptest.NewServer(fc)
+	defer srv.Close()
+
+	e, err := New(Config{
+		Endpoint:      srv.URL,
+		Headers:       map[string]string{"x-honeycomb-team": "hc-key"},
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	e.Start()
+
+	tr := trace.New()
+	tr.AgentName = "bot"
+	tr.Model = "gpt-4o"
+	tr.RequestID = "req-1"
+	tr.StartSpan("firewall").Set("blocked", false).Set("rules", 3).End()
+	tr.StartSpan("upstream").Set("status", "ok").End()
+	e.Export(tr)
+	e.Export(nil)
+	e.Close()
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.path != "/v1/traces" || fc.apiKey != "hc-key" {
+		t.Errorf("path = %q, header = %q", fc.path, fc.apiKey)
+	}
+	if len(fc.requests) != 1 {
+		t.Fatalf("got %d export requests, want 1", len(fc.requests))
+	}
+	rs := fc.requests[0].ResourceSpans[0]
+	if v := rs.Resource.Attributes[0]; v.Key != "service.name" || *v.Value.StringValue != "agix" {
+		t.Errorf("resource attribute = %+v", v)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want root + 2", len(spans))
+	}
+	root := spans[0]
+	if root.Name != rootSpanName || root.ParentSpanID != "" || root.TraceID != TraceID(tr.ID) || len(root.TraceID) != 32 {
+		t.Errorf("root span = %+v", root)
+	}
+	for _, s := range spans[1:] {
+		if s.ParentSpanID != root.SpanID || s.TraceID != root.TraceID {
+			t.Errorf("span %q not a child of the root span", s.Name)
+		}
+	}
+	fw := spans[1]
+	if fw.Name != "firewall" || len(fw.Attributes) != 2 || fw.Attributes[0].Key != "agix.blocked" || *fw.Attributes[1].Value.IntValue != "3" {
+		t.Errorf("firewall span = %+v", fw)
+	}
+}
+
+func TestExportFailureDrops(t *testing.T) {
+	srv := httptest.NewServer(&fakeCollector{status: http.StatusServiceUnavailable})
+	defer srv.Close()
+
+	e, err := New(Config{Endpoint: srv.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	e.Start()
+	e.Export(trace.New())
+	e.Export(trace.New())
+	e.Close()
+	if got := e.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %d, want 2", got)
+	}
+}
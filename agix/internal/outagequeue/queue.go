@@ -0,0 +1,308 @@
+package outagequeue
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/loadshed"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Config defines how queued requests are retried.
+type Config struct {
+	Enabled        bool
+	RetryInterval  time.Duration // delay before the first retry, doubled per attempt
+	MaxAttempts    int
+	MaxAge         time.Duration // give up on requests older than this
+	CallbackSecret string        // signs callbacks when set
+}
+
+// maxBackoff caps the exponential retry delay.
+const maxBackoff = 30 * time.Minute
+
+// pollInterval is how often the queue looks for due requests.
+const pollInterval = 5 * time.Second
+
+// batchSize bounds the requests replayed per poll.
+const batchSize = 20
+
+// Sender replays a queued request and returns the HTTP status and body.
+type Sender func(ctx context.Context, q *store.QueuedRequest) (int, []byte, error)
+
+// Queue is a durable, store-backed retry queue for requests that failed
+// because every model in their failover chain was down.
+type Queue struct {
+	cfg    Config
+	store  *store.Store
+	send   Sender
+	active func() bool
+	client *http.Client
+	now    func() time.Time
+
+	started  bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a Queue. active reports whether this instance should drain
+// the queue (e.g. it is the HA leader) and may be nil.
+// Returns nil if not enabled.
+func New(cfg Config, st *store.Store, send Sender, active func() bool) *Queue {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 30 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 24 * time.Hour
+	}
+	if active == nil {
+		active = func() bool { return true }
+	}
+	return &Queue{
+		cfg:    cfg,
+		store:  st,
+		send:   send,
+		active: active,
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// LocalSender replays requests through the gateway at base (e.g.
+// http://localhost:8080), so routing, budgets and usage tracking apply as
+// for any other request. token is the gateway's internal token, see
+// apikeys.MarkInternal.
+func LocalSender(base, token string) Sender {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	url := base + "/v1/chat/completions"
+	return func(ctx context.Context, q *store.QueuedRequest) (int, []byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader([]byte(q.Body)))
+		if err != nil {
+			return 0, nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Agent-Name", q.AgentName)
+		apikeys.MarkInternal(req, token)
+		req.Header.Set("X-Force-Model", q.Model) // routing already ran when the request was queued
+		req.Header.Set("X-Queue-Id", strconv.FormatInt(q.ID, 10))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("send request: %w", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return resp.StatusCode, nil, fmt.Errorf("read response: %w", err)
+		}
+		return resp.StatusCode, body, nil
+	}
+}
+
+// Enqueue persists a request for retry and returns its queue ID. More
+// important requests are retried first; the queue stores priority's rank.
+func (q *Queue) Enqueue(agent, model string, priority loadshed.Priority, body []byte, callbackURL string) (int64, error) {
+	now := q.now()
+	id, err := q.store.InsertQueuedRequest(&store.QueuedRequest{
+		Timestamp:     now,
+		AgentName:     agent,
+		Model:         model,
+		Priority:      priority.Rank(),
+		Body:          string(body),
+		CallbackURL:   callbackURL,
+		NextAttemptAt: now.Add(q.cfg.RetryInterval),
+	})
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("QUEUE: request %d queued for %s (agent %q)", id, model, agent)
+	return id, nil
+}
+
+// Start begins draining due requests in the background.
+func (q *Queue) Start() {
+	q.started = true
+	go func() {
+		defer close(q.done)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-q.stop:
+				return
+			case <-ticker.C:
+				if q.active() {
+					q.drain()
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the background loop. Pending requests stay in the store.
+func (q *Queue) Close() {
+	q.stopOnce.Do(func() { close(q.stop) })
+	if q.started {
+		<-q.done
+	}
+}
+
+// drain retries every due request once.
+func (q *Queue) drain() {
+	due, err := q.store.QueryDueQueuedRequests(q.now(), batchSize)
+	if err != nil {
+		log.Printf("ERROR: queue: %v", err)
+		return
+	}
+	for i := range due {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+		q.attempt(&due[i])
+	}
+}
+
+// attempt replays one request and records the outcome.
+func (q *Queue) attempt(r *store.QueuedRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	status, body, err := q.send(ctx, r)
+	cancel()
+
+	r.Attempts++
+	r.StatusCode = status
+	now := q.now()
+
+	switch {
+	case err == nil && status < 300:
+		r.Status = "completed"
+		r.Response = string(body)
+		r.Error = ""
+	case err == nil && status < 500 && status != http.StatusTooManyRequests:
+		// The provider is back but rejected the request: retrying won't help
+		r.Status = "failed"
+		r.Response = string(body)
+		r.Error = fmt.Sprintf("upstream returned %d", status)
+	default:
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Error = fmt.Sprintf("upstream returned %d", status)
+		}
+		switch {
+		case r.Attempts >= q.cfg.MaxAttempts:
+			r.Status = "failed"
+		case now.Sub(r.Timestamp) >= q.cfg.MaxAge:
+			r.Status = "expired"
+		default:
+			r.NextAttemptAt = now.Add(q.backoff(r.Attempts))
+			if err := q.store.UpdateQueuedRequest(r); err != nil {
+				log.Printf("ERROR: queue: %v", err)
+			}
+			return
+		}
+	}
+
+	if r.CallbackURL != "" {
+		code, err := q.notify(r)
+		r.CallbackCode = code
+		if err != nil {
+			log.Printf("QUEUE: callback for request %d failed: %v", r.ID, err)
+		}
+	}
+	if err := q.store.UpdateQueuedRequest(r); err != nil {
+		log.Printf("ERROR: queue: %v", err)
+	}
+	log.Printf("QUEUE: request %d %s after %d attempt(s)", r.ID, r.Status, r.Attempts)
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.cfg.RetryInterval
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// Callback is the JSON payload POSTed to the caller's callback URL once a
+// queued request reaches a final state.
+type Callback struct {
+	QueueID    int64           `json:"queue_id"`
+	Status     string          `json:"status"`
+	AgentName  string          `json:"agent_name"`
+	Model      string          `json:"model"`
+	Attempts   int             `json:"attempts"`
+	StatusCode int             `json:"status_code"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// notify POSTs the final outcome to the callback URL.
+func (q *Queue) notify(r *store.QueuedRequest) (int, error) {
+	cb := Callback{
+		QueueID:    r.ID,
+		Status:     r.Status,
+		AgentName:  r.AgentName,
+		Model:      r.Model,
+		Attempts:   r.Attempts,
+		StatusCode: r.StatusCode,
+		Error:      r.Error,
+	}
+	if json.Valid([]byte(r.Response)) {
+		cb.Response = json.RawMessage(r.Response)
+	}
+	body, err := json.Marshal(cb)
+	if err != nil {
+		return 0, fmt.Errorf("marshal callback: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("create callback: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if q.cfg.CallbackSecret != "" {
+		req.Header.Set("X-Agix-Signature", Sign(q.cfg.CallbackSecret, body))
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post callback: %w", err)
+	}
+	defer resp.Body.Close()
+	io.ReadAll(resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, fmt.Errorf("callback returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as sent in X-Agix-Signature.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
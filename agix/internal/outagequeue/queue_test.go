@@ -0,0 +1,163 @@
+package outagequeue
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/loadshed"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func newTestQueue(t *testing.T, cfg Config, send Sender) (*Queue, *store.Store) {
+	t.Helper()
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	cfg.Enabled = true
+	return New(cfg, st, send, nil), st
+}
+
+func TestNewDisabled(t *testing.T) {
+	if q := New(Config{}, nil, nil, nil); q != nil {
+		t.Error("expected nil queue when disabled")
+	}
+}
+
+func TestDrainRetriesUntilProviderRecovers(t *testing.T) {
+	var callback Callback
+	var signature string
+	cb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get("X-Agix-Signature")
+		if Sign("s3cret", body) != signature {
+			signature = "invalid"
+		}
+		json.Unmarshal(body, &callback)
+	}))
+	defer cb.Close()
+
+	up := false
+	send := func(ctx context.Context, r *store.QueuedRequest) (int, []byte, error) {
+		if !up {
+			return http.StatusBadGateway, []byte(`{"error":"upstream request failed"}`), nil
+		}
+		return http.StatusOK, []byte(`{"id":"chatcmpl-1"}`), nil
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	q, st := newTestQueue(t, Config{RetryInterval: time.Minute, CallbackSecret: "s3cret"}, send)
+	q.now = func() time.Time { return now }
+
+	id, err := q.Enqueue("batch-agent", "gpt-4o", loadshed.PriorityNormal, []byte(`{"model":"gpt-4o"}`), cb.URL)
+	if err != nil {
+		t.Fatalf("Enqueue() error: %v", err)
+	}
+
+	// Not yet due
+	q.drain()
+	if got, _ := st.QueryQueuedRequest(id); got.Attempts != 0 {
+		t.Fatalf("attempts = %d before retry interval elapsed, want 0", got.Attempts)
+	}
+
+	// Still down: rescheduled with backoff
+	now = now.Add(time.Minute)
+	q.drain()
+	got, _ := st.QueryQueuedRequest(id)
+	if got.Status != "queued" || got.Attempts != 1 {
+		t.Fatalf("after failed retry: status %q attempts %d, want queued/1", got.Status, got.Attempts)
+	}
+	if want := now.Add(time.Minute); !got.NextAttemptAt.Equal(want) {
+		t.Errorf("NextAttemptAt = %v, want %v", got.NextAttemptAt, want)
+	}
+
+	// Recovered: completed and caller notified
+	up = true
+	now = now.Add(time.Minute)
+	q.drain()
+	got, _ = st.QueryQueuedRequest(id)
+	if got.Status != "completed" || got.Attempts != 2 || got.CallbackCode != http.StatusOK {
+		t.Errorf("after recovery: status %q attempts %d callback %d, want completed/2/200", got.Status, got.Attempts, got.CallbackCode)
+	}
+	if callback.QueueID != id || callback.Status != "completed" || string(callback.Response) != `{"id":"chatcmpl-1"}` {
+		t.Errorf("callback = %+v", callback)
+	}
+	if signature == "" || signature == "invalid" {
+		t.Errorf("callback signature = %q, want valid HMAC", signature)
+	}
+}
+
+func TestDrainGivesUpAfterMaxAttempts(t *testing.T) {
+	var callback Callback
+	cb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&callback)
+	}))
+	defer cb.Close()
+
+	send := func(ctx context.Context, r *store.QueuedRequest) (int, []byte, error) {
+		return http.StatusServiceUnavailable, nil, nil
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	q, st := newTestQueue(t, Config{RetryInterval: time.Second, MaxAttempts: 2}, send)
+	q.now = func() time.Time { return now }
+
+	id, _ := q.Enqueue("batch-agent", "gpt-4o", loadshed.PriorityNormal, []byte(`{}`), cb.URL)
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Hour)
+		q.drain()
+	}
+
+	got, _ := st.QueryQueuedRequest(id)
+	if got.Status != "failed" || got.Attempts != 2 {
+		t.Errorf("status %q attempts %d, want failed/2", got.Status, got.Attempts)
+	}
+	if callback.Status != "failed" || callback.Error == "" {
+		t.Errorf("callback = %+v, want failed with error", callback)
+	}
+}
+
+func TestDrainOrdersByPriority(t *testing.T) {
+	var order []string
+	send := func(ctx context.Context, r *store.QueuedRequest) (int, []byte, error) {
+		order = append(order, r.AgentName)
+		return http.StatusOK, []byte(`{}`), nil
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	q, _ := newTestQueue(t, Config{RetryInterval: time.Second}, send)
+	q.now = func() time.Time { return now }
+
+	q.Enqueue("low", "gpt-4o", loadshed.PriorityLow, []byte(`{}`), "")
+	q.Enqueue("normal", "gpt-4o", loadshed.PriorityNormal, []byte(`{}`), "")
+	q.Enqueue("high", "gpt-4o", loadshed.PriorityHigh, []byte(`{}`), "")
+
+	now = now.Add(time.Minute)
+	q.drain()
+	if len(order) != 3 || order[0] != "high" || order[1] != "normal" || order[2] != "low" {
+		t.Errorf("retry order = %v, want [high normal low]", order)
+	}
+}
+
+func TestBackoffCapped(t *testing.T) {
+	q := New(Config{Enabled: true, RetryInterval: time.Minute}, nil, nil, nil)
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{20, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := q.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
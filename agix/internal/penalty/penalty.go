@@ -0,0 +1,362 @@
+// Package penalty implements the penalty box: agents that show sustained
+// error or abuse patterns are throttled for a while, then released
+// automatically or by an operator.
+package penalty
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Config defines when an agent is penalized and how hard.
+type Config struct {
+	Window                time.Duration // error-rate and duplicate window, default 10m
+	MinRequests           int           // requests in Window before the error rate counts, default 20
+	ErrorRate             float64       // fraction of failed requests (status ≥ 500 or 429); 0 = off
+	FirewallBlocksPerHour int           // 0 = off
+	DuplicateRequests     int           // identical request bodies within Window; 0 = off
+	Duration              time.Duration // default 15m
+	ThrottleRPM           int           // requests per minute allowed while penalized; 0 = none
+	Exempt                []string      // agents never penalized
+}
+
+const (
+	defaultWindow      = 10 * time.Minute
+	defaultMinRequests = 20
+	defaultDuration    = 15 * time.Minute
+
+	// maxTrackedBodies triggers a sweep of stale request hashes.
+	maxTrackedBodies = 256
+)
+
+// Penalty is an agent's stay in the penalty box.
+type Penalty struct {
+	Agent     string    `json:"agent"`
+	Reason    string    `json:"reason"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Result is the outcome of Check.
+type Result struct {
+	Allowed    bool
+	Penalty    *Penalty // nil when the agent is not penalized
+	RetryAfter time.Duration
+}
+
+// Box tracks agent behaviour in memory and stores penalties in the
+// penalties table, so `agix agent release` takes effect on a running
+// gateway.
+type Box struct {
+	cfg     Config
+	db      *sql.DB
+	dialect store.Dialect
+	notify  func(Penalty)
+	now     func() time.Time
+	exempt  map[string]bool
+
+	mu       sync.Mutex
+	activity map[string]*activity
+	throttle map[string]*minuteCount
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+type activity struct {
+	outcomes []outcome
+	blocks   []time.Time
+	bodies   map[[32]byte][]time.Time
+}
+
+type minuteCount struct {
+	minute time.Time
+	n      int
+}
+
+// New creates the penalties table if needed. notify is called when an
+// agent enters the box and may be nil.
+func New(cfg Config, st *store.Store, notify func(Penalty)) (*Box, error) {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = defaultMinRequests
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = defaultDuration
+	}
+	if err := createTable(st.DB(), st.Dialect()); err != nil {
+		return nil, fmt.Errorf("create penalties table: %w", err)
+	}
+	exempt := make(map[string]bool, len(cfg.Exempt))
+	for _, a := range cfg.Exempt {
+		exempt[a] = true
+	}
+	return &Box{
+		cfg:      cfg,
+		db:       st.DB(),
+		dialect:  st.Dialect(),
+		notify:   notify,
+		now:      time.Now,
+		exempt:   exempt,
+		activity: make(map[string]*activity),
+		throttle: make(map[string]*minuteCount),
+	}, nil
+}
+
+func createTable(db *sql.DB, dialect store.Dialect) error {
+	key := "TEXT"
+	if dialect == store.DialectMySQL {
+		// MySQL cannot key on TEXT columns.
+		key = "VARCHAR(255)"
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS penalties (
+		agent_name ` + key + ` PRIMARY KEY,
+		reason     TEXT NOT NULL,
+		started_at ` + key + ` NOT NULL,
+		expires_at ` + key + ` NOT NULL
+	)`)
+	return err
+}
+
+// Check reports whether agent may send a request. Penalized agents get
+// ThrottleRPM requests per minute; the rest are refused until expiry.
+func (b *Box) Check(agent string) (Result, error) {
+	p, err := b.Get(agent)
+	if err != nil || p == nil {
+		return Result{Allowed: true}, err
+	}
+	now := b.now()
+	retry := p.ExpiresAt.Sub(now)
+	if b.cfg.ThrottleRPM > 0 {
+		minute := now.Truncate(time.Minute)
+		b.mu.Lock()
+		c := b.throttle[agent]
+		if c == nil || !c.minute.Equal(minute) {
+			c = &minuteCount{minute: minute}
+			b.throttle[agent] = c
+		}
+		c.n++
+		allowed := c.n <= b.cfg.ThrottleRPM
+		b.mu.Unlock()
+		if allowed {
+			return Result{Allowed: true, Penalty: p}, nil
+		}
+		if next := minute.Add(time.Minute).Sub(now); next < retry {
+			retry = next
+		}
+	}
+	return Result{Allowed: false, Penalty: p, RetryAfter: retry}, nil
+}
+
+// ObserveRequest records a request body; identical bodies repeated
+// DuplicateRequests times within Window penalize the agent.
+func (b *Box) ObserveRequest(agent string, body []byte) *Penalty {
+	if b.cfg.DuplicateRequests <= 0 || !b.tracked(agent) {
+		return nil
+	}
+	sum := sha256.Sum256(body)
+	now := b.now()
+
+	cutoff := now.Add(-b.cfg.Window)
+
+	b.mu.Lock()
+	a := b.activityFor(agent)
+	if len(a.bodies) > maxTrackedBodies {
+		for k, times := range a.bodies {
+			if len(prune(times, cutoff)) == 0 {
+				delete(a.bodies, k)
+			}
+		}
+	}
+	times := append(prune(a.bodies[sum], cutoff), now)
+	a.bodies[sum] = times
+	tripped := len(times) >= b.cfg.DuplicateRequests
+	b.mu.Unlock()
+
+	if !tripped {
+		return nil
+	}
+	return b.penalize(agent, fmt.Sprintf("%d identical requests within %s", len(times), b.cfg.Window))
+}
+
+// ObserveOutcome records an upstream response status.
+func (b *Box) ObserveOutcome(agent string, status int) *Penalty {
+	if b.cfg.ErrorRate <= 0 || !b.tracked(agent) {
+		return nil
+	}
+	now := b.now()
+	cutoff := now.Add(-b.cfg.Window)
+
+	b.mu.Lock()
+	a := b.activityFor(agent)
+	i := 0
+	for i < len(a.outcomes) && a.outcomes[i].at.Before(cutoff) {
+		i++
+	}
+	a.outcomes = append(a.outcomes[i:], outcome{at: now, failed: status >= 500 || status == 429})
+	total, failed := len(a.outcomes), 0
+	for _, o := range a.outcomes {
+		if o.failed {
+			failed++
+		}
+	}
+	b.mu.Unlock()
+
+	rate := float64(failed) / float64(total)
+	if total < b.cfg.MinRequests || rate < b.cfg.ErrorRate {
+		return nil
+	}
+	return b.penalize(agent, fmt.Sprintf("error rate %.0f%% over %d requests", rate*100, total))
+}
+
+// ObserveFirewallBlock records a request the firewall blocked.
+func (b *Box) ObserveFirewallBlock(agent string) *Penalty {
+	if b.cfg.FirewallBlocksPerHour <= 0 || !b.tracked(agent) {
+		return nil
+	}
+	now := b.now()
+
+	b.mu.Lock()
+	a := b.activityFor(agent)
+	a.blocks = append(prune(a.blocks, now.Add(-time.Hour)), now)
+	n := len(a.blocks)
+	b.mu.Unlock()
+
+	if n < b.cfg.FirewallBlocksPerHour {
+		return nil
+	}
+	return b.penalize(agent, fmt.Sprintf("%d firewall blocks in the last hour", n))
+}
+
+func (b *Box) tracked(agent string) bool {
+	return agent != "" && !b.exempt[agent]
+}
+
+// activityFor must be called with b.mu held.
+func (b *Box) activityFor(agent string) *activity {
+	a := b.activity[agent]
+	if a == nil {
+		a = &activity{bodies: make(map[[32]byte][]time.Time)}
+		b.activity[agent] = a
+	}
+	return a
+}
+
+// prune drops times before cutoff from an ascending slice.
+func prune(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// penalize puts agent in the box for Duration and forgets its recorded
+// activity, so it starts with a clean slate once released.
+func (b *Box) penalize(agent, reason string) *Penalty {
+	b.mu.Lock()
+	delete(b.activity, agent)
+	b.mu.Unlock()
+
+	now := b.now().UTC().Truncate(time.Second)
+	p := Penalty{Agent: agent, Reason: reason, StartedAt: now, ExpiresAt: now.Add(b.cfg.Duration)}
+	if err := b.put(p); err != nil {
+		log.Printf("WARN: %v", err)
+		return nil
+	}
+	if b.notify != nil {
+		b.notify(p)
+	}
+	return &p
+}
+
+func (b *Box) put(p Penalty) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("record penalty: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(store.Rebind(b.dialect, `DELETE FROM penalties WHERE agent_name = ?`), p.Agent); err != nil {
+		return fmt.Errorf("record penalty: %w", err)
+	}
+	if _, err := tx.Exec(
+		store.Rebind(b.dialect, `INSERT INTO penalties (agent_name, reason, started_at, expires_at) VALUES (?, ?, ?, ?)`),
+		p.Agent, p.Reason, p.StartedAt.Format(time.RFC3339), p.ExpiresAt.Format(time.RFC3339),
+	); err != nil {
+		return fmt.Errorf("record penalty: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Get returns agent's active penalty, or nil.
+func (b *Box) Get(agent string) (*Penalty, error) {
+	var p Penalty
+	var started, expires string
+	err := b.db.QueryRow(
+		store.Rebind(b.dialect, `SELECT agent_name, reason, started_at, expires_at FROM penalties WHERE agent_name = ? AND expires_at > ?`),
+		agent, b.now().UTC().Format(time.RFC3339),
+	).Scan(&p.Agent, &p.Reason, &started, &expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query penalty: %w", err)
+	}
+	p.StartedAt, _ = time.Parse(time.RFC3339, started)
+	p.ExpiresAt, _ = time.Parse(time.RFC3339, expires)
+	return &p, nil
+}
+
+// List returns active penalties, soonest expiry first.
+func (b *Box) List() ([]Penalty, error) {
+	rows, err := b.db.Query(
+		store.Rebind(b.dialect, `SELECT agent_name, reason, started_at, expires_at FROM penalties WHERE expires_at > ? ORDER BY expires_at`),
+		b.now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query penalties: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Penalty
+	for rows.Next() {
+		var p Penalty
+		var started, expires string
+		if err := rows.Scan(&p.Agent, &p.Reason, &started, &expires); err != nil {
+			return nil, fmt.Errorf("scan penalty: %w", err)
+		}
+		p.StartedAt, _ = time.Parse(time.RFC3339, started)
+		p.ExpiresAt, _ = time.Parse(time.RFC3339, expires)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// Release lets agent out of the box early. It reports whether the agent
+// had an active penalty.
+func (b *Box) Release(agent string) (bool, error) {
+	res, err := b.db.Exec(
+		store.Rebind(b.dialect, `DELETE FROM penalties WHERE agent_name = ? AND expires_at > ?`),
+		agent, b.now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return false, fmt.Errorf("release penalty: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	b.mu.Lock()
+	delete(b.activity, agent)
+	delete(b.throttle, agent)
+	b.mu.Unlock()
+	return n > 0, nil
+}
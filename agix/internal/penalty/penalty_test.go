@@ -0,0 +1,129 @@
+package penalty
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func testBox(t *testing.T, cfg Config) (*Box, *time.Time, *[]Penalty) {
+	t.Helper()
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	var notified []Penalty
+	b, err := New(cfg, st, func(p Penalty) { notified = append(notified, p) })
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+	return b, &now, &notified
+}
+
+func TestErrorRate(t *testing.T) {
+	b, now, notified := testBox(t, Config{ErrorRate: 0.5, MinRequests: 4})
+
+	// Failures below MinRequests do not count.
+	for i := 0; i < 3; i++ {
+		if p := b.ObserveOutcome("flaky", 502); p != nil {
+			t.Fatalf("penalized after %d requests: %+v", i+1, p)
+		}
+	}
+	p := b.ObserveOutcome("flaky", 200)
+	if p == nil {
+		t.Fatal("not penalized at 75% errors over 4 requests")
+	}
+	if p.ExpiresAt.Sub(p.StartedAt) != 15*time.Minute {
+		t.Errorf("penalty duration = %s, want default 15m", p.ExpiresAt.Sub(p.StartedAt))
+	}
+	if len(*notified) != 1 || (*notified)[0].Agent != "flaky" {
+		t.Errorf("notified = %+v", *notified)
+	}
+
+	res, err := b.Check("flaky")
+	if err != nil || res.Allowed || res.Penalty == nil || res.RetryAfter != 15*time.Minute {
+		t.Errorf("Check() while penalized = %+v, %v", res, err)
+	}
+	if res, _ := b.Check("other"); !res.Allowed || res.Penalty != nil {
+		t.Errorf("Check(other) = %+v, want allowed", res)
+	}
+
+	// Expires automatically.
+	*now = now.Add(16 * time.Minute)
+	if res, _ := b.Check("flaky"); !res.Allowed {
+		t.Errorf("Check() after expiry = %+v, want allowed", res)
+	}
+}
+
+func TestErrorsOutsideWindowExpire(t *testing.T) {
+	b, now, _ := testBox(t, Config{ErrorRate: 0.5, MinRequests: 2, Window: time.Minute})
+	b.ObserveOutcome("a", 500)
+	*now = now.Add(2 * time.Minute)
+	if p := b.ObserveOutcome("a", 500); p != nil {
+		t.Errorf("penalized with one error in the window: %+v", p)
+	}
+}
+
+func TestFirewallBlocksAndDuplicates(t *testing.T) {
+	b, _, _ := testBox(t, Config{FirewallBlocksPerHour: 3, DuplicateRequests: 3, Exempt: []string{"trusted"}})
+
+	b.ObserveFirewallBlock("attacker")
+	b.ObserveFirewallBlock("attacker")
+	if p := b.ObserveFirewallBlock("attacker"); p == nil || p.Reason != "3 firewall blocks in the last hour" {
+		t.Errorf("third firewall block = %+v", p)
+	}
+
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	b.ObserveRequest("looper", body)
+	b.ObserveRequest("looper", []byte(`{"different":true}`))
+	b.ObserveRequest("looper", body)
+	if p := b.ObserveRequest("looper", body); p == nil {
+		t.Error("third identical request did not penalize")
+	}
+
+	for i := 0; i < 5; i++ {
+		if p := b.ObserveFirewallBlock("trusted"); p != nil {
+			t.Fatalf("exempt agent penalized: %+v", p)
+		}
+	}
+}
+
+func TestThrottleAndRelease(t *testing.T) {
+	b, now, _ := testBox(t, Config{FirewallBlocksPerHour: 1, ThrottleRPM: 2, Duration: time.Hour})
+	if b.ObserveFirewallBlock("noisy") == nil {
+		t.Fatal("not penalized")
+	}
+	*now = now.Add(10 * time.Second)
+
+	for i := 0; i < 2; i++ {
+		if res, _ := b.Check("noisy"); !res.Allowed || res.Penalty == nil {
+			t.Fatalf("request %d within throttle refused: %+v", i+1, res)
+		}
+	}
+	res, _ := b.Check("noisy")
+	if res.Allowed || res.RetryAfter != 50*time.Second {
+		t.Errorf("request over throttle = %+v, want refused until next minute", res)
+	}
+
+	list, err := b.List()
+	if err != nil || len(list) != 1 || list[0].Agent != "noisy" {
+		t.Fatalf("List() = %+v, %v", list, err)
+	}
+
+	released, err := b.Release("noisy")
+	if err != nil || !released {
+		t.Fatalf("Release() = %v, %v", released, err)
+	}
+	if res, _ := b.Check("noisy"); !res.Allowed || res.Penalty != nil {
+		t.Errorf("Check() after release = %+v", res)
+	}
+	if released, _ := b.Release("noisy"); released {
+		t.Error("second Release() reported an active penalty")
+	}
+}
@@ -0,0 +1,56 @@
+package pricing
+
+import (
+	"strings"
+)
+
+// Transcription is billed per minute of audio (USD per minute).
+var transcriptionPrices = map[string]float64{
+	"whisper-1":              0.006,
+	"gpt-4o-transcribe":      0.006,
+	"gpt-4o-mini-transcribe": 0.003,
+}
+
+// Speech synthesis is billed per input character (USD per 1M characters).
+// gpt-4o-mini-tts is token-priced upstream; its rate here is OpenAI's
+// published per-minute estimate converted to characters.
+var speechPrices = map[string]float64{
+	"tts-1":           15.0,
+	"tts-1-hd":        30.0,
+	"gpt-4o-mini-tts": 15.0,
+}
+
+// longestPrefix returns the key of prices that model starts with, preferring
+// the longest, so versioned names match their base model.
+func longestPrefix(prices map[string]float64, model string) string {
+	model = strings.ToLower(BaseModel(model))
+	var best string
+	for name := range prices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	return best
+}
+
+// TranscriptionCost returns the cost in USD of transcribing seconds of audio
+// with model, with any configured discounts and time-window rates applied.
+// Unknown models cost zero.
+func TranscriptionCost(model string, seconds float64) float64 {
+	name := longestPrefix(transcriptionPrices, model)
+	if name == "" || seconds <= 0 {
+		return 0
+	}
+	return transcriptionPrices[name] * seconds / 60 * Multiplier(model, now())
+}
+
+// SpeechCost returns the cost in USD of synthesizing chars characters of
+// input with model, with any configured discounts and time-window rates
+// applied. Unknown models cost zero.
+func SpeechCost(model string, chars int) float64 {
+	name := longestPrefix(speechPrices, model)
+	if name == "" || chars <= 0 {
+		return 0
+	}
+	return speechPrices[name] * float64(chars) / 1_000_000 * Multiplier(model, now())
+}
@@ -0,0 +1,46 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTranscriptionCost(t *testing.T) {
+	tests := []struct {
+		model   string
+		seconds float64
+		want    float64
+	}{
+		{"whisper-1", 60, 0.006},
+		{"whisper-1", 90, 0.009},
+		{"gpt-4o-mini-transcribe", 120, 0.006},
+		{"gpt-4o-transcribe-2025-03-20", 60, 0.006},
+		{"gpt-4o", 60, 0},
+		{"whisper-1", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := TranscriptionCost(tt.model, tt.seconds); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("TranscriptionCost(%q, %v) = %v, want %v", tt.model, tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestSpeechCost(t *testing.T) {
+	tests := []struct {
+		model string
+		chars int
+		want  float64
+	}{
+		{"tts-1", 1000, 0.015},
+		{"tts-1-hd", 1000, 0.030},
+		{"tts-1-hd-1106", 2000, 0.060},
+		{"gpt-4o-mini-tts", 1000, 0.015},
+		{"gpt-4o", 1000, 0},
+		{"tts-1", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := SpeechCost(tt.model, tt.chars); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("SpeechCost(%q, %d) = %v, want %v", tt.model, tt.chars, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package pricing
+
+import "time"
+
+// Anthropic bills prompt-cache tokens relative to the model's input price:
+// writing a 5-minute cache entry costs 25% more than plain input, and
+// reading one costs a tenth of it.
+const (
+	cacheWriteRate = 1.25
+	cacheReadRate  = 0.10
+)
+
+// CacheCost returns the cost in USD of prompt-cache writes and reads, with
+// any configured discounts and time-window rates applied. The tokens are
+// in addition to the request's regular input tokens.
+func CacheCost(model string, writeTokens, readTokens int) float64 {
+	return cacheCost(Lookup(model), writeTokens, readTokens) * Multiplier(model, now())
+}
+
+// CacheCostAt is CacheCost for a request made at t.
+func (h *History) CacheCostAt(model string, writeTokens, readTokens int, t time.Time) float64 {
+	return cacheCost(h.LookupAt(model, t), writeTokens, readTokens) * Multiplier(model, t)
+}
+
+func cacheCost(p *ModelPricing, writeTokens, readTokens int) float64 {
+	if p == nil {
+		return 0
+	}
+	tokens := float64(writeTokens)*cacheWriteRate + float64(readTokens)*cacheReadRate
+	return tokens / 1_000_000 * p.InputPer1M
+}
@@ -0,0 +1,42 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCacheCost(t *testing.T) {
+	tests := []struct {
+		model       string
+		write, read int
+		want        float64
+	}{
+		// claude-sonnet-4: $3 input → $3.75 write, $0.30 read per 1M
+		{"claude-sonnet-4-20250514", 1_000_000, 0, 3.75},
+		{"claude-sonnet-4-20250514", 0, 1_000_000, 0.30},
+		{"claude-haiku-4-5-20251001", 2000, 10_000, 0.0025 + 0.001},
+		{"claude-sonnet-4-20250514", 0, 0, 0},
+		{"unknown-model", 1000, 1000, 0},
+	}
+	for _, tt := range tests {
+		if got := CacheCost(tt.model, tt.write, tt.read); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("CacheCost(%q, %d, %d) = %v, want %v", tt.model, tt.write, tt.read, got, tt.want)
+		}
+	}
+}
+
+func TestCacheCostAt(t *testing.T) {
+	h := testHistory(t)
+	changedAt := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if err := h.Add(Version{Model: "claude-sonnet-4-20250514", InputPer1M: 6, OutputPer1M: 30, EffectiveFrom: changedAt}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := h.CacheCostAt("claude-sonnet-4-20250514", 0, 1_000_000, changedAt.Add(-time.Hour)); math.Abs(got-0.30) > 1e-9 {
+		t.Errorf("CacheCostAt before change = %v, want 0.30", got)
+	}
+	if got := h.CacheCostAt("claude-sonnet-4-20250514", 0, 1_000_000, changedAt); math.Abs(got-0.60) > 1e-9 {
+		t.Errorf("CacheCostAt after change = %v, want 0.60", got)
+	}
+}
@@ -0,0 +1,215 @@
+package pricing
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Version is a model's list price from EffectiveFrom until the next version
+// of the same model. A zero EffectiveFrom marks the first price seen.
+type Version struct {
+	Model         string
+	InputPer1M    float64
+	OutputPer1M   float64
+	EffectiveFrom time.Time
+}
+
+// History keeps every list price a model has had, so costs can be
+// recomputed with the price that was in effect when a request was made
+// rather than today's.
+type History struct {
+	db      *sql.DB
+	dialect store.Dialect
+
+	mu       sync.RWMutex
+	versions map[string][]Version // model → versions, oldest first
+}
+
+const createPriceHistoryTable = `
+CREATE TABLE IF NOT EXISTS model_prices (
+	model          TEXT NOT NULL,
+	effective_from TEXT NOT NULL,
+	input_per_1m   REAL NOT NULL,
+	output_per_1m  REAL NOT NULL,
+	PRIMARY KEY (model, effective_from)
+)`
+
+// MySQL cannot key on TEXT columns.
+const createPriceHistoryTableMySQL = `
+CREATE TABLE IF NOT EXISTS model_prices (
+	model          VARCHAR(255) NOT NULL,
+	effective_from VARCHAR(32) NOT NULL,
+	input_per_1m   DOUBLE NOT NULL,
+	output_per_1m  DOUBLE NOT NULL,
+	PRIMARY KEY (model, effective_from)
+)`
+
+// NewHistory creates the model_prices table if needed and loads it.
+func NewHistory(db *sql.DB, dialect store.Dialect) (*History, error) {
+	ddl := createPriceHistoryTable
+	if dialect == store.DialectMySQL {
+		ddl = createPriceHistoryTableMySQL
+	}
+	if _, err := db.Exec(ddl); err != nil {
+		return nil, fmt.Errorf("create model_prices table: %w", err)
+	}
+	h := &History{db: db, dialect: dialect}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *History) load() error {
+	rows, err := h.db.Query(`SELECT model, effective_from, input_per_1m, output_per_1m FROM model_prices ORDER BY model, effective_from`)
+	if err != nil {
+		return fmt.Errorf("query model prices: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[string][]Version)
+	for rows.Next() {
+		var v Version
+		var from string
+		if err := rows.Scan(&v.Model, &from, &v.InputPer1M, &v.OutputPer1M); err != nil {
+			return fmt.Errorf("scan model price: %w", err)
+		}
+		v.EffectiveFrom, _ = time.Parse(time.RFC3339, from)
+		versions[v.Model] = append(versions[v.Model], v)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query model prices: %w", err)
+	}
+
+	h.mu.Lock()
+	h.versions = versions
+	h.mu.Unlock()
+	return nil
+}
+
+// Add records a price for v.Model starting at v.EffectiveFrom, replacing
+// any version with the same model and start time. Versioned names and
+// aliases are recorded under the pricing table entry they resolve to.
+func (h *History) Add(v Version) error {
+	v.Model = strings.ToLower(v.Model)
+	if name, _, ok := resolve(v.Model); ok {
+		v.Model = name
+	}
+	if err := h.insert(v); err != nil {
+		return err
+	}
+	return h.load()
+}
+
+func (h *History) insert(v Version) error {
+	var query string
+	switch h.dialect {
+	case store.DialectPostgres:
+		query = `INSERT INTO model_prices (model, effective_from, input_per_1m, output_per_1m) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (model, effective_from) DO UPDATE SET input_per_1m = EXCLUDED.input_per_1m, output_per_1m = EXCLUDED.output_per_1m`
+	case store.DialectMySQL:
+		query = `INSERT INTO model_prices (model, effective_from, input_per_1m, output_per_1m) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE input_per_1m = VALUES(input_per_1m), output_per_1m = VALUES(output_per_1m)`
+	default:
+		query = `INSERT OR REPLACE INTO model_prices (model, effective_from, input_per_1m, output_per_1m) VALUES (?, ?, ?, ?)`
+	}
+	from := v.EffectiveFrom.UTC().Format(time.RFC3339)
+	if _, err := h.db.Exec(query, v.Model, from, v.InputPer1M, v.OutputPer1M); err != nil {
+		return fmt.Errorf("store model price: %w", err)
+	}
+	return nil
+}
+
+// Snapshot compares the built-in pricing table with the latest recorded
+// version of each model and records a new version effective at t for every
+// model whose price changed. Models without history are recorded with a
+// zero EffectiveFrom, so older requests are priced with the first price
+// agix knew. It returns the number of versions added.
+func (h *History) Snapshot(t time.Time) (int, error) {
+	h.mu.RLock()
+	var changed []Version
+	for name, p := range models {
+		v := Version{Model: name, InputPer1M: p.InputPer1M, OutputPer1M: p.OutputPer1M, EffectiveFrom: t}
+		vs := h.versions[name]
+		if len(vs) == 0 {
+			v.EffectiveFrom = time.Time{}
+		} else if last := vs[len(vs)-1]; last.InputPer1M == p.InputPer1M && last.OutputPer1M == p.OutputPer1M {
+			continue
+		}
+		changed = append(changed, v)
+	}
+	h.mu.RUnlock()
+
+	if len(changed) == 0 {
+		return 0, nil
+	}
+	for _, v := range changed {
+		if err := h.insert(v); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), h.load()
+}
+
+// List returns the recorded versions of model, or of all models if model
+// is empty, ordered by model and start time.
+func (h *History) List(model string) []Version {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if model != "" {
+		if name, _, ok := resolve(model); ok {
+			model = name
+		}
+		return append([]Version(nil), h.versions[strings.ToLower(model)]...)
+	}
+	var out []Version
+	for _, vs := range h.versions {
+		out = append(out, vs...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Model != out[j].Model {
+			return out[i].Model < out[j].Model
+		}
+		return out[i].EffectiveFrom.Before(out[j].EffectiveFrom)
+	})
+	return out
+}
+
+// LookupAt returns the list price of model in effect at t. Models without
+// recorded history fall back to the current pricing table; unknown models
+// return nil.
+func (h *History) LookupAt(model string, t time.Time) *ModelPricing {
+	name, p, ok := resolve(model)
+	if !ok {
+		return nil
+	}
+	h.mu.RLock()
+	vs := h.versions[name]
+	h.mu.RUnlock()
+
+	// Versions are sorted oldest first; take the last one started by t.
+	i := sort.Search(len(vs), func(i int) bool { return vs[i].EffectiveFrom.After(t) })
+	if i > 0 {
+		p.InputPer1M, p.OutputPer1M = vs[i-1].InputPer1M, vs[i-1].OutputPer1M
+	}
+	return &p
+}
+
+// CalculateCostAt is CalculateCost for a request made at t: it uses the
+// list price in effect at t and the time-window rate that applied then.
+func (h *History) CalculateCostAt(model string, inputTokens, outputTokens int, t time.Time) float64 {
+	p := h.LookupAt(model, t)
+	if p == nil {
+		return 0
+	}
+	inputCost := float64(inputTokens) / 1_000_000 * p.InputPer1M
+	outputCost := float64(outputTokens) / 1_000_000 * p.OutputPer1M
+	return (inputCost + outputCost) * Multiplier(model, t)
+}
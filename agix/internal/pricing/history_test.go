@@ -0,0 +1,97 @@
+package pricing
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+	_ "modernc.org/sqlite"
+)
+
+func testHistory(t *testing.T) *History {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	h, err := NewHistory(db, store.DialectSQLite)
+	if err != nil {
+		t.Fatalf("NewHistory: %v", err)
+	}
+	return h
+}
+
+func TestHistorySnapshot(t *testing.T) {
+	h := testHistory(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	n, err := h.Snapshot(start)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if n != len(models) {
+		t.Errorf("first Snapshot added %d versions, want %d", n, len(models))
+	}
+	if vs := h.List("gpt-4o"); len(vs) != 1 || !vs[0].EffectiveFrom.IsZero() {
+		t.Errorf("seeded versions = %+v, want one with zero EffectiveFrom", vs)
+	}
+	if n, _ := h.Snapshot(start.Add(time.Hour)); n != 0 {
+		t.Errorf("unchanged Snapshot added %d versions", n)
+	}
+
+	// A release that changes a list price records a new version.
+	orig := models["gpt-4o"]
+	models["gpt-4o"] = ModelPricing{Provider: "openai", InputPer1M: 2.00, OutputPer1M: 8.00}
+	t.Cleanup(func() { models["gpt-4o"] = orig })
+
+	changedAt := start.AddDate(0, 1, 0)
+	if n, _ := h.Snapshot(changedAt); n != 1 {
+		t.Fatalf("Snapshot after price change added %d versions, want 1", n)
+	}
+	if got := h.LookupAt("gpt-4o-2024-08-06", changedAt.Add(-time.Second)); got.InputPer1M != orig.InputPer1M {
+		t.Errorf("price before change = %+v, want %+v", got, orig)
+	}
+	if got := h.LookupAt("gpt-4o", changedAt); got.InputPer1M != 2.00 {
+		t.Errorf("price at change = %+v, want input 2.00", got)
+	}
+
+	want := (1000.0/1_000_000)*orig.InputPer1M + (500.0/1_000_000)*orig.OutputPer1M
+	if got := h.CalculateCostAt("gpt-4o", 1000, 500, start); math.Abs(got-want) > 1e-12 {
+		t.Errorf("CalculateCostAt before change = %f, want %f", got, want)
+	}
+}
+
+func TestHistoryAdd(t *testing.T) {
+	h := testHistory(t)
+	if _, err := h.Snapshot(time.Now()); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	cut := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	if err := h.Add(Version{Model: "GPT-4o-2024-08-06", InputPer1M: 5, OutputPer1M: 15, EffectiveFrom: cut}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	vs := h.List("gpt-4o")
+	if len(vs) != 2 || vs[1].Model != "gpt-4o" || !vs[1].EffectiveFrom.Equal(cut) {
+		t.Fatalf("versions = %+v, want the new one recorded under gpt-4o", vs)
+	}
+	if got := h.LookupAt("gpt-4o", cut.AddDate(0, 0, 1)); got.InputPer1M != 5 || got.OutputPer1M != 15 {
+		t.Errorf("LookupAt after Add = %+v", got)
+	}
+
+	// Re-adding the same start time replaces the version.
+	if err := h.Add(Version{Model: "gpt-4o", InputPer1M: 4, OutputPer1M: 12, EffectiveFrom: cut}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if vs := h.List("gpt-4o"); len(vs) != 2 || vs[1].InputPer1M != 4 {
+		t.Errorf("versions after replace = %+v", vs)
+	}
+
+	if got := h.LookupAt("llama-3-70b", cut); got != nil {
+		t.Errorf("LookupAt of unknown model = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,83 @@
+package pricing
+
+import (
+	"strings"
+)
+
+// Image generation is billed per image by quality and size (USD per image).
+var imagePrices = map[string]map[string]map[string]float64{
+	"dall-e-2": {
+		"standard": {"256x256": 0.016, "512x512": 0.018, "1024x1024": 0.020},
+	},
+	"dall-e-3": {
+		"standard": {"1024x1024": 0.040, "1024x1792": 0.080, "1792x1024": 0.080},
+		"hd":       {"1024x1024": 0.080, "1024x1792": 0.120, "1792x1024": 0.120},
+	},
+	"gpt-image-1": {
+		"low":    {"1024x1024": 0.011, "1024x1536": 0.016, "1536x1024": 0.016},
+		"medium": {"1024x1024": 0.042, "1024x1536": 0.063, "1536x1024": 0.063},
+		"high":   {"1024x1024": 0.167, "1024x1536": 0.250, "1536x1024": 0.250},
+	},
+}
+
+// defaultImageQuality is the quality each model uses when the request
+// leaves it unset or "auto". gpt-image-1 picks per prompt, so budget for
+// the most expensive tier.
+var defaultImageQuality = map[string]string{
+	"dall-e-2":    "standard",
+	"dall-e-3":    "standard",
+	"gpt-image-1": "high",
+}
+
+// imageModel returns the image pricing table entry for model, matching
+// versioned names by longest prefix.
+func imageModel(model string) string {
+	model = strings.ToLower(BaseModel(model))
+	var best string
+	for name := range imagePrices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	return best
+}
+
+// ImageCost returns the cost in USD of generating n images, with any
+// configured discounts and time-window rates applied. An empty or "auto"
+// size means 1024x1024; sizes or qualities missing from the table are
+// priced at the model's most expensive option, so budgets err on the safe
+// side. Unknown models cost zero.
+func ImageCost(model, size, quality string, n int) float64 {
+	name := imageModel(model)
+	if name == "" || n <= 0 {
+		return 0
+	}
+	tiers := imagePrices[name]
+	if quality == "" || quality == "auto" {
+		quality = defaultImageQuality[name]
+	}
+	if size == "" || size == "auto" {
+		size = "1024x1024"
+	}
+
+	var price float64
+	if sizes, ok := tiers[quality]; ok {
+		price, ok = sizes[size]
+		if !ok {
+			price = maxPrice(sizes)
+		}
+	} else {
+		for _, sizes := range tiers {
+			price = max(price, maxPrice(sizes))
+		}
+	}
+	return price * float64(n) * Multiplier(model, now())
+}
+
+func maxPrice(sizes map[string]float64) float64 {
+	var m float64
+	for _, p := range sizes {
+		m = max(m, p)
+	}
+	return m
+}
@@ -0,0 +1,34 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestImageCost(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   string
+		size    string
+		quality string
+		n       int
+		want    float64
+	}{
+		{"dall-e-3 defaults", "dall-e-3", "", "", 1, 0.040},
+		{"dall-e-3 hd wide", "dall-e-3", "1792x1024", "hd", 2, 0.240},
+		{"dall-e-2 small", "dall-e-2", "256x256", "", 4, 0.064},
+		{"gpt-image-1 auto quality", "gpt-image-1", "auto", "auto", 1, 0.167},
+		{"gpt-image-1 low portrait", "gpt-image-1", "1024x1536", "low", 1, 0.016},
+		{"unknown size priced at the most expensive", "dall-e-3", "2048x2048", "standard", 1, 0.080},
+		{"unknown quality priced at the most expensive", "dall-e-3", "1024x1024", "ultra", 1, 0.120},
+		{"unknown model", "gpt-4o", "1024x1024", "", 1, 0},
+		{"no images", "dall-e-3", "", "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ImageCost(tt.model, tt.size, tt.quality, tt.n); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ImageCost(%q, %q, %q, %d) = %v, want %v", tt.model, tt.size, tt.quality, tt.n, got, tt.want)
+			}
+		})
+	}
+}
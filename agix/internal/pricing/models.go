@@ -1,6 +1,9 @@
 package pricing
 
-import "strings"
+import (
+	"strings"
+	"sync"
+)
 
 // ModelPricing holds per-token pricing for a model.
 type ModelPricing struct {
@@ -28,6 +31,14 @@ var models = map[string]ModelPricing{
 	"o3":       {Provider: "openai", InputPer1M: 2.00, OutputPer1M: 8.00},
 	"o3-mini":  {Provider: "openai", InputPer1M: 1.10, OutputPer1M: 4.40},
 	"o4-mini":  {Provider: "openai", InputPer1M: 1.10, OutputPer1M: 4.40},
+	// OpenAI — legacy completions models
+	"gpt-3.5-turbo-instruct": {Provider: "openai", InputPer1M: 1.50, OutputPer1M: 2.00},
+	"davinci-002":            {Provider: "openai", InputPer1M: 2.00, OutputPer1M: 2.00},
+	"babbage-002":            {Provider: "openai", InputPer1M: 0.40, OutputPer1M: 0.40},
+	// OpenAI — embeddings (input only)
+	"text-embedding-3-small": {Provider: "openai", InputPer1M: 0.02},
+	"text-embedding-3-large": {Provider: "openai", InputPer1M: 0.13},
+	"text-embedding-ada-002": {Provider: "openai", InputPer1M: 0.10},
 
 	// Anthropic — current models
 	"claude-opus-4-6":            {Provider: "anthropic", InputPer1M: 5.00, OutputPer1M: 25.00},
@@ -46,12 +57,48 @@ var models = map[string]ModelPricing{
 	"deepseek-reasoner": {Provider: "deepseek", InputPer1M: 0.55, OutputPer1M: 2.19},
 }
 
+var (
+	aliasMu sync.RWMutex
+	aliases map[string]string
+)
+
+// SetAliases registers model names that are priced as another model, e.g.
+// an Azure deployment "azure/prod-gpt4o" → "gpt-4o". Keys are matched
+// case-insensitively.
+func SetAliases(m map[string]string) {
+	a := make(map[string]string, len(m))
+	for k, v := range m {
+		a[strings.ToLower(k)] = strings.ToLower(v)
+	}
+	aliasMu.Lock()
+	aliases = a
+	aliasMu.Unlock()
+}
+
+// BaseModel returns the model an alias is priced as, or model itself.
+func BaseModel(model string) string {
+	aliasMu.RLock()
+	defer aliasMu.RUnlock()
+	if base, ok := aliases[strings.ToLower(model)]; ok {
+		return base
+	}
+	return model
+}
+
 // Lookup returns the pricing for a model. Returns nil if unknown.
 func Lookup(model string) *ModelPricing {
-	model = strings.ToLower(model)
-	if p, ok := models[model]; ok {
+	if _, p, ok := resolve(model); ok {
 		return &p
 	}
+	return nil
+}
+
+// resolve returns the pricing table entry a model is priced as.
+func resolve(model string) (string, ModelPricing, bool) {
+	model = strings.ToLower(BaseModel(model))
+	if p, ok := models[model]; ok {
+		return model, p, true
+	}
 	// Try prefix matching for versioned models (e.g. gpt-4o-2024-08-06).
 	// Use longest prefix match to avoid "gpt-4" matching before "gpt-4o".
 	var bestName string
@@ -63,12 +110,13 @@ func Lookup(model string) *ModelPricing {
 		}
 	}
 	if bestName != "" {
-		return &bestPricing
+		return bestName, bestPricing, true
 	}
-	return nil
+	return "", ModelPricing{}, false
 }
 
-// CalculateCost returns the cost in USD for a given number of tokens.
+// CalculateCost returns the cost in USD for a given number of tokens,
+// with any configured discounts and time-window rates applied.
 func CalculateCost(model string, inputTokens, outputTokens int) float64 {
 	p := Lookup(model)
 	if p == nil {
@@ -76,14 +124,70 @@ func CalculateCost(model string, inputTokens, outputTokens int) float64 {
 	}
 	inputCost := float64(inputTokens) / 1_000_000 * p.InputPer1M
 	outputCost := float64(outputTokens) / 1_000_000 * p.OutputPer1M
-	return inputCost + outputCost
+	return (inputCost + outputCost) * Multiplier(model, now())
+}
+
+var (
+	providerMu      sync.RWMutex
+	customProviders map[string]string // model prefix → provider
+)
+
+// SetProviders registers custom OpenAI-compatible providers by the model
+// prefixes they serve (prefix → provider name). Prefixes are matched
+// case-insensitively and take precedence over the built-in providers.
+func SetProviders(prefixes map[string]string) {
+	p := make(map[string]string, len(prefixes))
+	for prefix, name := range prefixes {
+		p[strings.ToLower(prefix)] = name
+	}
+	providerMu.Lock()
+	customProviders = p
+	providerMu.Unlock()
+}
+
+// CustomProvider returns the custom provider serving model and the prefix
+// it matched; the longest matching prefix wins.
+func CustomProvider(model string) (name, prefix string, ok bool) {
+	model = strings.ToLower(model)
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	for pre, n := range customProviders {
+		if strings.HasPrefix(model, pre) && len(pre) > len(prefix) {
+			name, prefix, ok = n, pre, true
+		}
+	}
+	return name, prefix, ok
+}
+
+// IsCustomProvider reports whether name was registered with SetProviders.
+func IsCustomProvider(name string) bool {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	for _, n := range customProviders {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
 
 // ProviderForModel returns the provider name for a model based on prefix.
 func ProviderForModel(model string) string {
+	if name, _, ok := CustomProvider(model); ok {
+		return name
+	}
 	model = strings.ToLower(model)
 	switch {
-	case strings.HasPrefix(model, "gpt-"), strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+	case strings.HasPrefix(model, "azure/"):
+		return "azure"
+	case strings.HasPrefix(model, "ollama/"):
+		return "ollama"
+	case strings.HasPrefix(model, "openrouter/"):
+		return "openrouter"
+	case strings.HasPrefix(model, "bedrock/"):
+		return "bedrock"
+	case strings.HasPrefix(model, "gpt-"), strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"), strings.HasPrefix(model, "dall-e"),
+		strings.HasPrefix(model, "whisper-"), strings.HasPrefix(model, "tts-"):
 		return "openai"
 	case strings.HasPrefix(model, "claude-"):
 		return "anthropic"
@@ -98,6 +202,32 @@ func ProviderForModel(model string) string {
 	}
 }
 
+// IsReasoningModel reports whether model is a reasoning model that bills
+// hidden reasoning tokens as output: OpenAI o-series and GPT-5, and
+// deepseek-reasoner. OpenAI reasoning models take max_completion_tokens
+// instead of max_tokens.
+func IsReasoningModel(model string) bool {
+	model = strings.ToLower(BaseModel(model))
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5", "deepseek-reasoner"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCompletionModel reports whether model is served only by the legacy
+// completions API and has no chat endpoint.
+func IsCompletionModel(model string) bool {
+	model = strings.ToLower(BaseModel(model))
+	for _, prefix := range []string{"gpt-3.5-turbo-instruct", "davinci-002", "babbage-002"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // ListModels returns all known model names.
 func ListModels() []string {
 	result := make([]string, 0, len(models))
@@ -204,11 +204,18 @@ func TestProviderForModel(t *testing.T) {
 		{name: "openai o3", model: "o3", want: "openai"},
 		{name: "openai o3-mini", model: "o3-mini", want: "openai"},
 		{name: "openai o4-mini", model: "o4-mini", want: "openai"},
+		{name: "openai dall-e-3", model: "dall-e-3", want: "openai"},
+		{name: "openai whisper-1", model: "whisper-1", want: "openai"},
+		{name: "openai tts-1-hd", model: "tts-1-hd", want: "openai"},
 		{name: "anthropic claude-opus-4-6", model: "claude-opus-4-6", want: "anthropic"},
 		{name: "anthropic claude-sonnet-4-5-20250929", model: "claude-sonnet-4-5-20250929", want: "anthropic"},
 		{name: "anthropic claude-haiku-4-5-20251001", model: "claude-haiku-4-5-20251001", want: "anthropic"},
 		{name: "deepseek deepseek-chat", model: "deepseek-chat", want: "deepseek"},
 		{name: "deepseek deepseek-reasoner", model: "deepseek-reasoner", want: "deepseek"},
+		{name: "ollama prefix", model: "ollama/llama3.1:8b", want: "ollama"},
+		{name: "ollama gpt-named model", model: "ollama/gpt-oss:20b", want: "ollama"},
+		{name: "openrouter prefix", model: "openrouter/anthropic/claude-sonnet-4", want: "openrouter"},
+		{name: "bedrock prefix", model: "bedrock/anthropic.claude-3-5-sonnet-20241022-v2:0", want: "bedrock"},
 		{name: "unknown model", model: "llama-3-70b", want: "unknown"},
 		{name: "empty model", model: "", want: "unknown"},
 		{name: "case insensitive gpt", model: "GPT-4o", want: "openai"},
@@ -225,6 +232,51 @@ func TestProviderForModel(t *testing.T) {
 	}
 }
 
+func TestIsReasoningModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{model: "o1", want: true},
+		{model: "o3-mini", want: true},
+		{model: "o4-mini-2025-04-16", want: true},
+		{model: "gpt-5-mini", want: true},
+		{model: "deepseek-reasoner", want: true},
+		{model: "gpt-4o", want: false},
+		{model: "deepseek-chat", want: false},
+		{model: "claude-opus-4-6", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := IsReasoningModel(tt.model); got != tt.want {
+				t.Errorf("IsReasoningModel(%q) = %v, want %v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCompletionModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{model: "gpt-3.5-turbo-instruct", want: true},
+		{model: "gpt-3.5-turbo-instruct-0914", want: true},
+		{model: "davinci-002", want: true},
+		{model: "gpt-3.5-turbo", want: false},
+		{model: "gpt-4o", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := IsCompletionModel(tt.model); got != tt.want {
+				t.Errorf("IsCompletionModel(%q) = %v, want %v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestListModels(t *testing.T) {
 	got := ListModels()
 	if len(got) == 0 {
@@ -244,3 +296,57 @@ func TestListModels(t *testing.T) {
 		}
 	}
 }
+
+func TestAliases(t *testing.T) {
+	SetAliases(map[string]string{"azure/Prod-GPT4o": "gpt-4o", "azure/reasoner": "o3-mini"})
+	t.Cleanup(func() { SetAliases(nil) })
+
+	if got := BaseModel("azure/prod-gpt4o"); got != "gpt-4o" {
+		t.Errorf("BaseModel() = %q, want gpt-4o", got)
+	}
+	if got := BaseModel("gpt-4o-mini"); got != "gpt-4o-mini" {
+		t.Errorf("BaseModel() of a non-alias = %q", got)
+	}
+	want := CalculateCost("gpt-4o", 1000, 500)
+	if got := CalculateCost("azure/prod-gpt4o", 1000, 500); math.Abs(got-want) > 1e-12 {
+		t.Errorf("alias cost = %f, want base model cost %f", got, want)
+	}
+	if got := ProviderForModel("azure/prod-gpt4o"); got != "azure" {
+		t.Errorf("ProviderForModel() = %q, want azure", got)
+	}
+	if !IsReasoningModel("azure/reasoner") {
+		t.Error("alias of a reasoning model should be a reasoning model")
+	}
+	if p := Lookup("azure/unmapped"); p != nil {
+		t.Errorf("Lookup() of an unmapped deployment = %+v, want nil", p)
+	}
+}
+
+func TestCustomProviders(t *testing.T) {
+	SetProviders(map[string]string{"groq/": "groq", "Mistral-": "mistral", "gpt-oss": "vllm"})
+	t.Cleanup(func() { SetProviders(nil) })
+
+	tests := []struct {
+		model      string
+		want       string
+		wantPrefix string
+	}{
+		{"groq/llama-3.1-8b-instant", "groq", "groq/"},
+		{"mistral-large-latest", "mistral", "mistral-"},
+		{"gpt-oss-120b", "vllm", "gpt-oss"},
+		{"gpt-4o", "openai", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := ProviderForModel(tt.model); got != tt.want {
+				t.Errorf("ProviderForModel(%q) = %q, want %q", tt.model, got, tt.want)
+			}
+			if _, prefix, _ := CustomProvider(tt.model); prefix != tt.wantPrefix {
+				t.Errorf("CustomProvider(%q) prefix = %q, want %q", tt.model, prefix, tt.wantPrefix)
+			}
+		})
+	}
+	if !IsCustomProvider("groq") || IsCustomProvider("openai") {
+		t.Error("IsCustomProvider should report only registered providers")
+	}
+}
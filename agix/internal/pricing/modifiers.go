@@ -0,0 +1,144 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window applies a price multiplier during a daily time window, e.g. a
+// provider's off-peak discount. A window whose end is before its start
+// wraps past midnight.
+type Window struct {
+	Name       string
+	Start      time.Duration // offset from midnight in Location
+	End        time.Duration
+	Location   *time.Location
+	Providers  []string // empty matches every provider
+	Models     []string // model name prefixes; empty matches every model
+	Multiplier float64  // e.g. 0.5 for half price
+}
+
+// NewWindow builds a Window from "HH:MM" times and an IANA timezone name
+// (empty means local time).
+func NewWindow(name, start, end, timezone string, multiplier float64, providers, models []string) (Window, error) {
+	w := Window{Name: name, Providers: providers, Models: models, Multiplier: multiplier, Location: time.Local}
+	var err error
+	if w.Start, err = parseClock(start); err != nil {
+		return w, fmt.Errorf("start: %w", err)
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return w, fmt.Errorf("end: %w", err)
+	}
+	if w.Start == w.End {
+		return w, fmt.Errorf("start and end are both %s", start)
+	}
+	if timezone != "" {
+		if w.Location, err = time.LoadLocation(timezone); err != nil {
+			return w, fmt.Errorf("timezone: %w", err)
+		}
+	}
+	if multiplier < 0 {
+		return w, fmt.Errorf("multiplier must not be negative")
+	}
+	return w, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// matches reports whether the window covers model at t.
+func (w Window) matches(model string, t time.Time) bool {
+	if len(w.Providers) > 0 && !contains(w.Providers, ProviderForModel(model)) {
+		return false
+	}
+	if len(w.Models) > 0 {
+		ok := false
+		for _, prefix := range w.Models {
+			if strings.HasPrefix(model, strings.ToLower(prefix)) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	t = t.In(w.Location)
+	y, m, d := t.Date()
+	off := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, w.Location))
+	if w.Start < w.End {
+		return off >= w.Start && off < w.End
+	}
+	return off >= w.Start || off < w.End
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Modifiers adjust list prices to what is actually invoiced.
+type Modifiers struct {
+	// Discounts maps a provider or model name to the fraction taken off list
+	// price (0.2 = 20% off), e.g. a negotiated or batch rate. A model entry
+	// takes precedence over its provider's.
+	Discounts map[string]float64
+	// Windows are checked in order; the first match applies.
+	Windows []Window
+}
+
+var (
+	modMu     sync.RWMutex
+	modifiers Modifiers
+
+	// now is replaced in tests.
+	now = time.Now
+)
+
+// SetModifiers installs the pricing modifiers used by CalculateCost.
+func SetModifiers(m Modifiers) {
+	discounts := make(map[string]float64, len(m.Discounts))
+	for k, v := range m.Discounts {
+		discounts[strings.ToLower(k)] = v
+	}
+	m.Discounts = discounts
+
+	modMu.Lock()
+	modifiers = m
+	modMu.Unlock()
+}
+
+// Multiplier returns the factor applied to the list price of model at t:
+// the provider/model discount times the first matching window's multiplier.
+func Multiplier(model string, t time.Time) float64 {
+	model = strings.ToLower(model)
+
+	modMu.RLock()
+	defer modMu.RUnlock()
+
+	factor := 1.0
+	if d, ok := modifiers.Discounts[model]; ok {
+		factor = 1 - d
+	} else if d, ok := modifiers.Discounts[ProviderForModel(model)]; ok {
+		factor = 1 - d
+	}
+	for _, w := range modifiers.Windows {
+		if w.matches(model, t) {
+			factor *= w.Multiplier
+			break
+		}
+	}
+	return factor
+}
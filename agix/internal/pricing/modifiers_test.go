@@ -0,0 +1,90 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMultiplier(t *testing.T) {
+	offPeak, err := NewWindow("deepseek-offpeak", "16:30", "00:30", "UTC", 0.5, []string{"deepseek"}, nil)
+	if err != nil {
+		t.Fatalf("NewWindow() error: %v", err)
+	}
+	nightly, err := NewWindow("mini-nightly", "01:00", "05:00", "UTC", 0.8, nil, []string{"gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("NewWindow() error: %v", err)
+	}
+	SetModifiers(Modifiers{
+		Discounts: map[string]float64{"OpenAI": 0.1, "gpt-4o": 0.2},
+		Windows:   []Window{offPeak, nightly},
+	})
+	t.Cleanup(func() { SetModifiers(Modifiers{}) })
+
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 1, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		model string
+		t     time.Time
+		want  float64
+	}{
+		{"model discount wins over provider", "gpt-4o", at(12, 0), 0.8},
+		{"provider discount", "gpt-4.1", at(12, 0), 0.9},
+		{"no modifiers", "claude-opus-4-6", at(12, 0), 1},
+		{"window wraps midnight", "deepseek-chat", at(23, 0), 0.5},
+		{"window after midnight", "deepseek-reasoner", at(0, 15), 0.5},
+		{"outside window", "deepseek-chat", at(12, 0), 1},
+		{"window end exclusive", "deepseek-chat", at(0, 30), 1},
+		{"window stacks with discount", "gpt-4o-mini", at(2, 0), 0.9 * 0.8},
+		{"model prefix mismatch", "gpt-4.1", at(2, 0), 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Multiplier(tt.model, tt.t); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Multiplier(%q, %s) = %v, want %v", tt.model, tt.t.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateCostWithModifiers(t *testing.T) {
+	offPeak, _ := NewWindow("offpeak", "00:00", "08:00", "UTC", 0.5, []string{"openai"}, nil)
+	SetModifiers(Modifiers{Windows: []Window{offPeak}})
+	t.Cleanup(func() {
+		SetModifiers(Modifiers{})
+		now = time.Now
+	})
+
+	list := (1000.0/1_000_000)*2.50 + (500.0/1_000_000)*10.00
+
+	now = func() time.Time { return time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC) }
+	if got := CalculateCost("gpt-4o", 1000, 500); math.Abs(got-list*0.5) > 1e-12 {
+		t.Errorf("off-peak cost = %f, want %f", got, list*0.5)
+	}
+	now = func() time.Time { return time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC) }
+	if got := CalculateCost("gpt-4o", 1000, 500); math.Abs(got-list) > 1e-12 {
+		t.Errorf("peak cost = %f, want %f", got, list)
+	}
+}
+
+func TestNewWindowErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		start, end, tz string
+		multiplier     float64
+	}{
+		{"bad start", "25:00", "02:00", "", 0.5},
+		{"bad end", "01:00", "x", "", 0.5},
+		{"empty window", "01:00", "01:00", "", 0.5},
+		{"bad timezone", "01:00", "02:00", "Mars/Olympus", 0.5},
+		{"negative multiplier", "01:00", "02:00", "", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWindow("w", tt.start, tt.end, tt.tz, tt.multiplier, nil, nil); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package providerlimits
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bucket is one provider-side limit (requests, tokens, ...) as last reported.
+type Bucket struct {
+	Limit     int64      `json:"limit,omitempty"`
+	Remaining int64      `json:"remaining"`
+	Reset     string     `json:"reset,omitempty"`    // raw header value
+	ResetAt   *time.Time `json:"reset_at,omitempty"` // parsed, if possible
+}
+
+// Snapshot is the rate-limit state reported on one upstream response.
+type Snapshot struct {
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	ObservedAt   time.Time `json:"observed_at"`
+	Requests     *Bucket   `json:"requests,omitempty"`
+	Tokens       *Bucket   `json:"tokens,omitempty"`
+	InputTokens  *Bucket   `json:"input_tokens,omitempty"`
+	OutputTokens *Bucket   `json:"output_tokens,omitempty"`
+}
+
+// clone copies s with its buckets, which Take updates in place.
+func (s Snapshot) clone() Snapshot {
+	for _, b := range []**Bucket{&s.Requests, &s.Tokens, &s.InputTokens, &s.OutputTokens} {
+		if *b != nil {
+			c := **b
+			*b = &c
+		}
+	}
+	return s
+}
+
+// Parse extracts rate-limit headers from an upstream response.
+// Returns nil if the response carries none.
+func Parse(provider string, h http.Header, now time.Time) *Snapshot {
+	s := &Snapshot{ObservedAt: now}
+	switch provider {
+	case "anthropic":
+		// anthropic-ratelimit-{requests,tokens,input-tokens,output-tokens}-{limit,remaining,reset}
+		s.Requests = parseBucket(h, "anthropic-ratelimit-requests-%s", now)
+		s.Tokens = parseBucket(h, "anthropic-ratelimit-tokens-%s", now)
+		s.InputTokens = parseBucket(h, "anthropic-ratelimit-input-tokens-%s", now)
+		s.OutputTokens = parseBucket(h, "anthropic-ratelimit-output-tokens-%s", now)
+	default:
+		// OpenAI-style: x-ratelimit-{limit,remaining,reset}-{requests,tokens}
+		s.Requests = parseBucket(h, "x-ratelimit-%s-requests", now)
+		s.Tokens = parseBucket(h, "x-ratelimit-%s-tokens", now)
+	}
+	if s.Requests == nil && s.Tokens == nil && s.InputTokens == nil && s.OutputTokens == nil {
+		return nil
+	}
+	return s
+}
+
+// parseBucket reads the limit/remaining/reset headers named by pattern,
+// where %s is replaced by the field name.
+func parseBucket(h http.Header, pattern string, now time.Time) *Bucket {
+	name := func(field string) string { return strings.Replace(pattern, "%s", field, 1) }
+	limit := h.Get(name("limit"))
+	remaining := h.Get(name("remaining"))
+	reset := h.Get(name("reset"))
+	if limit == "" && remaining == "" {
+		return nil
+	}
+
+	b := &Bucket{Reset: reset}
+	b.Limit, _ = strconv.ParseInt(limit, 10, 64)
+	b.Remaining, _ = strconv.ParseInt(remaining, 10, 64)
+	if at, ok := parseReset(reset, now); ok {
+		b.ResetAt = &at
+	}
+	return b
+}
+
+// parseReset accepts an RFC 3339 timestamp (Anthropic) or a relative
+// duration such as "6m0s" or "20ms" (OpenAI).
+func parseReset(v string, now time.Time) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), true
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// Tracker keeps the most recent snapshot per provider and model.
+type Tracker struct {
+	mu     sync.Mutex
+	latest map[string]map[string]Snapshot
+}
+
+// New creates an empty Tracker.
+func New() *Tracker {
+	return &Tracker{latest: make(map[string]map[string]Snapshot)}
+}
+
+// Observe records rate-limit headers from an upstream response, if any.
+func (t *Tracker) Observe(provider, model string, h http.Header, now time.Time) {
+	s := Parse(provider, h, now)
+	if s == nil {
+		return
+	}
+	s.Provider = provider
+	s.Model = model
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	byModel, ok := t.latest[provider]
+	if !ok {
+		byModel = make(map[string]Snapshot)
+		t.latest[provider] = byModel
+	}
+	byModel[model] = *s
+}
+
+// Limits returns the latest snapshot for each model of provider, most
+// recently observed first.
+func (t *Tracker) Limits(provider string) []Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Snapshot, 0, len(t.latest[provider]))
+	for _, s := range t.latest[provider] {
+		out = append(out, s.clone())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ObservedAt.After(out[j].ObservedAt) })
+	return out
+}
+
+// QuotaError is returned for a request to a model whose provider-side
+// quota is nearly used up until it resets.
+type QuotaError struct {
+	Provider   string
+	Model      string
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("provider %s quota for %s nearly used up, resets in %s", e.Provider, e.Model, e.RetryAfter.Round(time.Second))
+}
+
+// Take checks a request of about tokens tokens to model against the
+// provider's last reported quota. If sending it would leave less than
+// minPercent of any limit before that limit resets, it returns how long
+// until the reset and false. Otherwise it deducts the request from the
+// remaining counts, so requests sent before the next response updates
+// them are accounted for, and returns true.
+func (t *Tracker) Take(provider, model string, tokens int, minPercent float64, now time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.latest[provider][model]
+	if !ok {
+		return 0, true
+	}
+	var wait time.Duration
+	buckets := []struct {
+		b    *Bucket
+		need int64
+	}{
+		{s.Requests, 1},
+		{s.Tokens, int64(tokens)},
+		{s.InputTokens, int64(tokens)},
+	}
+	for _, c := range buckets {
+		b := c.b
+		// A limit that has reset since it was reported is back to full.
+		if b == nil || b.Limit <= 0 || b.ResetAt == nil || !now.Before(*b.ResetAt) {
+			continue
+		}
+		if float64(b.Remaining-c.need) < float64(b.Limit)*minPercent/100 {
+			wait = max(wait, b.ResetAt.Sub(now))
+		}
+	}
+	if wait > 0 {
+		return wait, false
+	}
+	for _, c := range buckets {
+		if c.b != nil {
+			c.b.Remaining -= c.need
+		}
+	}
+	return 0, true
+}
@@ -0,0 +1,150 @@
+package providerlimits
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		provider      string
+		headers       map[string]string
+		wantNil       bool
+		wantRemaining int64
+		wantResetAt   time.Time
+	}{
+		{
+			name:     "openai relative reset",
+			provider: "openai",
+			headers: map[string]string{
+				"x-ratelimit-limit-requests":     "500",
+				"x-ratelimit-remaining-requests": "499",
+				"x-ratelimit-reset-requests":     "120ms",
+				"x-ratelimit-limit-tokens":       "30000",
+				"x-ratelimit-remaining-tokens":   "29000",
+				"x-ratelimit-reset-tokens":       "2m0s",
+			},
+			wantRemaining: 499,
+			wantResetAt:   now.Add(120 * time.Millisecond),
+		},
+		{
+			name:     "anthropic absolute reset",
+			provider: "anthropic",
+			headers: map[string]string{
+				"anthropic-ratelimit-requests-limit":     "50",
+				"anthropic-ratelimit-requests-remaining": "3",
+				"anthropic-ratelimit-requests-reset":     "2026-03-01T12:00:30Z",
+			},
+			wantRemaining: 3,
+			wantResetAt:   now.Add(30 * time.Second),
+		},
+		{
+			name:     "no headers",
+			provider: "deepseek",
+			headers:  map[string]string{"Content-Type": "application/json"},
+			wantNil:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			s := Parse(tt.provider, h, now)
+			if tt.wantNil {
+				if s != nil {
+					t.Fatalf("Parse() = %+v, want nil", s)
+				}
+				return
+			}
+			if s == nil || s.Requests == nil {
+				t.Fatalf("Parse() = %+v, want request bucket", s)
+			}
+			if s.Requests.Remaining != tt.wantRemaining {
+				t.Errorf("Requests.Remaining = %d, want %d", s.Requests.Remaining, tt.wantRemaining)
+			}
+			if s.Requests.ResetAt == nil || !s.Requests.ResetAt.Equal(tt.wantResetAt) {
+				t.Errorf("Requests.ResetAt = %v, want %v", s.Requests.ResetAt, tt.wantResetAt)
+			}
+		})
+	}
+}
+
+func TestTrackerKeepsLatestPerModel(t *testing.T) {
+	tr := New()
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	h := func(remaining string) http.Header {
+		return http.Header{
+			"X-Ratelimit-Limit-Requests":     {"100"},
+			"X-Ratelimit-Remaining-Requests": {remaining},
+		}
+	}
+	tr.Observe("openai", "gpt-4o", h("90"), t0)
+	tr.Observe("openai", "gpt-4o-mini", h("50"), t0.Add(time.Second))
+	tr.Observe("openai", "gpt-4o", h("80"), t0.Add(2*time.Second))
+	tr.Observe("openai", "gpt-4o", http.Header{}, t0.Add(3*time.Second)) // ignored
+
+	got := tr.Limits("openai")
+	if len(got) != 2 {
+		t.Fatalf("len(Limits) = %d, want 2", len(got))
+	}
+	if got[0].Model != "gpt-4o" || got[0].Requests.Remaining != 80 {
+		t.Errorf("Limits()[0] = %s remaining %d, want gpt-4o remaining 80", got[0].Model, got[0].Requests.Remaining)
+	}
+	if len(tr.Limits("anthropic")) != 0 {
+		t.Error("expected no limits for unobserved provider")
+	}
+}
+
+func TestTake(t *testing.T) {
+	tr := New()
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tr.Observe("openai", "gpt-4o", http.Header{
+		"X-Ratelimit-Limit-Requests":     {"100"},
+		"X-Ratelimit-Remaining-Requests": {"7"},
+		"X-Ratelimit-Reset-Requests":     {"30s"},
+		"X-Ratelimit-Limit-Tokens":       {"10000"},
+		"X-Ratelimit-Remaining-Tokens":   {"9000"},
+		"X-Ratelimit-Reset-Tokens":       {"10s"},
+	}, t0)
+
+	if _, ok := tr.Take("openai", "gpt-4o-mini", 100, 5, t0); !ok {
+		t.Error("unobserved model should have headroom")
+	}
+	// 7 requests left, 5% of 100 kept back: two more fit
+	for i := range 2 {
+		if _, ok := tr.Take("openai", "gpt-4o", 100, 5, t0); !ok {
+			t.Fatalf("request %d should fit", i+1)
+		}
+	}
+	if got := tr.Limits("openai")[0].Requests.Remaining; got != 5 {
+		t.Errorf("Limits Remaining = %d, want 5 after two requests", got)
+	}
+	wait, ok := tr.Take("openai", "gpt-4o", 100, 5, t0)
+	if ok || wait != 30*time.Second {
+		t.Errorf("Take = %v, %v; want refused until the reset in 30s", wait, ok)
+	}
+	if wait, ok := tr.Take("openai", "gpt-4o", 0, 5, t0.Add(31*time.Second)); !ok {
+		t.Errorf("Take after the reset = %v, refused", wait)
+	}
+
+	// A large request runs into the token limit
+	tr.Observe("openai", "o3", http.Header{
+		"X-Ratelimit-Limit-Tokens":     {"10000"},
+		"X-Ratelimit-Remaining-Tokens": {"9000"},
+		"X-Ratelimit-Reset-Tokens":     {"10s"},
+	}, t0)
+	if _, ok := tr.Take("openai", "o3", 8000, 5, t0); !ok {
+		t.Error("8000 tokens of 9000 remaining should fit")
+	}
+	if wait, ok := tr.Take("openai", "o3", 1000, 5, t0.Add(5*time.Second)); ok || wait != 5*time.Second {
+		t.Errorf("Take(1000 tokens) = %v, %v; want refused for 5s", wait, ok)
+	}
+}
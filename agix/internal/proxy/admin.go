@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/audit"
+	"github.com/agent-platform/agix/internal/keypool"
+)
+
+// adminPrefix is where management endpoints live, apart from the /v1/
+// surface agents call.
+const adminPrefix = "/admin/"
+
+// WithAdminToken sets the bearer token required on /admin/. Without one
+// the admin API only answers loopback requests.
+func WithAdminToken(token string) Option {
+	return func(p *Proxy) { p.adminToken = token }
+}
+
+// legacyAdminPaths are management endpoints that used to sit under /v1/.
+// They still work, with admin authentication, and are marked deprecated.
+var legacyAdminPaths = []string{"/v1/sessions/", "/v1/credits/"}
+
+// registerAdmin adds the admin routes to the proxy's mux.
+func (p *Proxy) registerAdmin() {
+	p.mux.HandleFunc(adminPrefix+"sessions/", p.admin(p.handleSessions))
+	p.mux.HandleFunc(adminPrefix+"credits/", p.admin(p.handleCredits))
+	p.mux.HandleFunc(adminPrefix+"keys", p.admin(p.handleAdminKeys))
+	p.mux.HandleFunc(adminPrefix+"keys/", p.admin(p.handleAdminKeys))
+	p.mux.HandleFunc(adminPrefix+"key-pools", p.admin(p.handleAdminKeyPools))
+	p.mux.HandleFunc(adminPrefix+"budgets", p.admin(p.handleAdminBudgets))
+	p.mux.HandleFunc(adminPrefix+"budgets/", p.admin(p.handleAdminBudgets))
+	p.mux.HandleFunc("/v1/sessions/", p.admin(p.handleSessions))
+	p.mux.HandleFunc("/v1/credits/", p.admin(p.handleCredits))
+}
+
+// adminTail returns what follows /admin/<name>/ or its legacy /v1/<name>/
+// form in path.
+func adminTail(path, name string) string {
+	if rest, ok := strings.CutPrefix(path, adminPrefix+name+"/"); ok {
+		return rest
+	}
+	return strings.TrimPrefix(path, "/v1/"+name+"/")
+}
+
+// admin wraps an admin handler: it checks the admin token and writes an
+// audit event for every request that changes state.
+func (p *Proxy) admin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.adminAuthorized(w, r) {
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, adminPrefix) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, adminPrefix, strings.TrimPrefix(r.URL.Path, "/v1/")))
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			h(w, r)
+			return
+		}
+
+		sw := &adminStatusWriter{ResponseWriter: w, status: http.StatusOK}
+		h(sw, r)
+		log.Printf("INFO: admin %s %s from %s: %d", r.Method, r.URL.Path, r.RemoteAddr, sw.status)
+		if p.auditLogger != nil {
+			p.auditLogger.Log(audit.EventAdmin, "", audit.AdminDetails{
+				Method: r.Method, Path: r.URL.Path, Status: sw.status, Remote: r.RemoteAddr,
+			})
+		}
+	}
+}
+
+// AdminOnly wraps a handler served outside the proxy's mux, like the
+// dashboard's content endpoints, in the same admin token check.
+func (p *Proxy) AdminOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p.adminAuthorized(w, r) {
+			h(w, r)
+		}
+	}
+}
+
+// adminAuthorized checks the admin token, or without one that the
+// request comes from this host. It writes the 401 or 403 itself.
+func (p *Proxy) adminAuthorized(w http.ResponseWriter, r *http.Request) bool {
+	if p.adminToken == "" {
+		if isLoopback(r.RemoteAddr) {
+			return true
+		}
+		http.Error(w, `{"error":"admin API is only accepted from localhost unless admin.token is set"}`, http.StatusForbidden)
+		return false
+	}
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(p.adminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="agix-admin"`)
+		http.Error(w, `{"error":"invalid or missing admin token"}`, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// adminStatusWriter records the status an admin handler wrote.
+type adminStatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *adminStatusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// handleAdminKeys serves the agent API keys: GET /admin/keys lists them
+// (?all=true includes revoked keys), POST /admin/keys {"agent": ...}
+// issues one and returns its secret, and DELETE /admin/keys/{id|prefix}
+// revokes one.
+func (p *Proxy) handleAdminKeys(w http.ResponseWriter, r *http.Request) {
+	if p.apiKeys == nil {
+		http.Error(w, `{"error":"API keys not enabled"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	id := adminTail(r.URL.Path, "keys")
+	if r.URL.Path == adminPrefix+"keys" {
+		id = ""
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		keys, err := p.apiKeys.List(r.URL.Query().Get("all") == "true")
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+
+	case id == "" && r.Method == http.MethodPost:
+		var req struct {
+			Agent string `json:"agent"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+			return
+		}
+		if !validAgentName(req.Agent) {
+			http.Error(w, `{"error":"invalid agent name"}`, http.StatusBadRequest)
+			return
+		}
+		secret, key, err := p.apiKeys.Create(req.Agent)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(struct {
+			Key    *apikeys.Key `json:"key"`
+			Secret string       `json:"secret"`
+		}{key, secret})
+
+	case id != "" && r.Method == http.MethodDelete:
+		key, err := p.apiKeys.Revoke(id)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"key": key})
+
+	default:
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminKeyPools serves GET /admin/key-pools: each provider key
+// pool's keys, identified by their last characters, and which of them are
+// quarantined.
+func (p *Proxy) handleAdminKeyPools(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	pools := map[string][]keypool.KeyStatus{}
+	for provider, pool := range p.hot.Load().KeyPools {
+		pools[provider] = pool.Status()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"pools": pools})
+}
@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/audit"
+)
+
+func TestAdminAuth(t *testing.T) {
+	p, st := newTestProxy(t)
+	keys, err := apikeys.New(st.DB(), st.Dialect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithAPIKeys(keys, true)(p)
+
+	do := func(method, path, remote, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"agent":"code-reviewer"}`))
+		req.RemoteAddr = remote
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without a token only loopback callers get in.
+	if w := do(http.MethodGet, "/admin/keys", "10.0.0.5:4000", ""); w.Code != http.StatusForbidden {
+		t.Errorf("remote without token: status = %d, want 403", w.Code)
+	}
+	if w := do(http.MethodGet, "/admin/keys", "127.0.0.1:4000", ""); w.Code != http.StatusOK {
+		t.Errorf("loopback without token: status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	p.adminToken = "admin-secret"
+	for _, tt := range []struct {
+		name, remote, token string
+		want                int
+	}{
+		{"no token", "127.0.0.1:4000", "", http.StatusUnauthorized},
+		{"wrong token", "10.0.0.5:4000", "guess", http.StatusUnauthorized},
+		{"agent key is not admin", "10.0.0.5:4000", apikeys.Prefix + "0000", http.StatusUnauthorized},
+		{"admin token", "10.0.0.5:4000", "admin-secret", http.StatusOK},
+	} {
+		if w := do(http.MethodGet, "/admin/keys", tt.remote, tt.token); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+
+	// Legacy paths need the admin token too and point to their successor.
+	if w := do(http.MethodGet, "/v1/sessions/demo", "10.0.0.5:4000", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("legacy path without token: status = %d, want 401", w.Code)
+	}
+	w := do(http.MethodGet, "/v1/credits/x", "10.0.0.5:4000", "admin-secret")
+	if w.Header().Get("Deprecation") != "true" || !strings.Contains(w.Header().Get("Link"), "/admin/credits/x") {
+		t.Errorf("legacy path headers = %v", w.Header())
+	}
+
+	// Handlers mounted outside the proxy, like the dashboard's, share the check.
+	h := p.AdminOnly(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "admin-secret": http.StatusNoContent} {
+		req := httptest.NewRequest(http.MethodGet, "/api/audit/search?q=x", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h(w, req)
+		if w.Code != want {
+			t.Errorf("AdminOnly with token %q: status = %d, want %d", token, w.Code, want)
+		}
+	}
+}
+
+func TestAdminKeysAudited(t *testing.T) {
+	p, st := newTestProxy(t)
+	keys, err := apikeys.New(st.DB(), st.Dialect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithAPIKeys(keys, false)(p)
+	logger := audit.New(st.DB(), true, st.Dialect())
+	p.auditLogger = logger
+	p.adminToken = "admin-secret"
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/admin/keys", `{"agent":"code-reviewer"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		Key    apikeys.Key `json:"key"`
+		Secret string      `json:"secret"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if _, err := keys.Authenticate(created.Secret); err != nil {
+		t.Fatalf("created key does not authenticate: %v", err)
+	}
+	if w := do(http.MethodPost, "/admin/keys", `{"agent":"bad name"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid agent: status = %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/admin/keys/"+created.Key.Prefix, ""); w.Code != http.StatusOK {
+		t.Errorf("revoke: status = %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodDelete, "/admin/keys/999", ""); w.Code != http.StatusNotFound {
+		t.Errorf("revoke missing: status = %d", w.Code)
+	}
+
+	logger.Close()
+	events, err := logger.QueryRecent(10, audit.EventAdmin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d admin audit events, want 4 (writes only)", len(events))
+	}
+	var found bool
+	for _, e := range events {
+		var d audit.AdminDetails
+		json.Unmarshal(e.Details, &d)
+		found = found || d.Method == http.MethodPost && d.Path == "/admin/keys" && d.Status == http.StatusCreated
+	}
+	if !found {
+		t.Errorf("no audit event for the key creation in %d events", len(events))
+	}
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/agent-platform/agix/internal/admission"
+)
+
+// WithAdmissionQueue makes rate-limited and load-shed requests wait in q
+// for capacity instead of being rejected at once.
+func WithAdmissionQueue(q *admission.Queue) Option {
+	return func(p *Proxy) { p.admission = q }
+}
+
+// shed admits r past the load shedder, waiting in the admission queue
+// while the gateway is overloaded if there is one. It writes the 503
+// itself and returns false if the request is rejected; otherwise the
+// caller must call release when the request is done.
+func (p *Proxy) shed(w http.ResponseWriter, r *http.Request) (func(), bool) {
+	agent := r.Header.Get("X-Agent-Name")
+	release, result := p.shedder.Admit(agent)
+	if !result.Allowed && p.admission != nil {
+		err := p.admission.Wait(r.Context(), p.admission.Priority(agent, r.Header.Get("X-Priority")), 0,
+			func() (bool, time.Duration) {
+				release, result = p.shedder.Admit(agent)
+				return result.Allowed, 0
+			})
+		if err != nil {
+			result.Err = fmt.Errorf("%w (%v)", result.Err, err)
+		}
+	}
+	if !result.Allowed {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(result.RetryAfter.Seconds())))
+		http.Error(w, fmt.Sprintf(`{"error":"overloaded: %s"}`, result.Err.Error()), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	if p.admission == nil {
+		return release, true
+	}
+	// A finished request frees a slot for the next one waiting
+	return func() {
+		release()
+		p.admission.Release()
+	}, true
+}
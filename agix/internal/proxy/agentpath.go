@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// agentPathPrefix is the virtual base URL for per-agent endpoints:
+// /agents/{name}/v1/... behaves like /v1/... with X-Agent-Name: {name}, for
+// clients that can only configure a base URL and key.
+const agentPathPrefix = "/agents/"
+
+// maxAgentNameLen bounds agent names taken from the path.
+const maxAgentNameLen = 64
+
+// handleAgentPath rewrites /agents/{name}/v1/... to /v1/... with the agent
+// name from the path, then dispatches it like any other request. The path
+// name overrides any X-Agent-Name header so attribution and policy follow the
+// base URL the client was configured with.
+func (p *Proxy) handleAgentPath(w http.ResponseWriter, r *http.Request) {
+	name, rest, ok := splitAgentPath(r.URL.Path)
+	if !ok {
+		http.Error(w, `{"error":"expected /agents/{name}/v1/..."}`, http.StatusNotFound)
+		return
+	}
+	if !validAgentName(name) {
+		http.Error(w, `{"error":"invalid agent name in path"}`, http.StatusBadRequest)
+		return
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = rest
+	r2.URL.RawPath = ""
+	r2.RequestURI = r2.URL.RequestURI()
+	r2.Header.Set("X-Agent-Name", name)
+	p.mux.ServeHTTP(w, r2)
+}
+
+// splitAgentPath splits "/agents/{name}/v1/..." into the agent name and the
+// "/v1/..." remainder.
+func splitAgentPath(path string) (name, rest string, ok bool) {
+	tail, found := strings.CutPrefix(path, agentPathPrefix)
+	if !found {
+		return "", "", false
+	}
+	i := strings.Index(tail, "/")
+	if i <= 0 {
+		return "", "", false
+	}
+	name, rest = tail[:i], tail[i:]
+	if !strings.HasPrefix(rest, "/v1/") {
+		return "", "", false
+	}
+	return name, rest, true
+}
+
+// validAgentName accepts 1-64 characters of letters, digits and ._- so the
+// name is safe to use as a header value and store key.
+func validAgentName(name string) bool {
+	if name == "" || len(name) > maxAgentNameLen {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestSplitAgentPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		wantName string
+		wantRest string
+		wantOK   bool
+	}{
+		{"/agents/coder/v1/chat/completions", "coder", "/v1/chat/completions", true},
+		{"/agents/coder/v1/models", "coder", "/v1/models", true},
+		{"/agents/coder/health", "", "", false},
+		{"/agents/coder", "", "", false},
+		{"/agents//v1/chat/completions", "", "", false},
+		{"/v1/chat/completions", "", "", false},
+	}
+	for _, tt := range tests {
+		name, rest, ok := splitAgentPath(tt.path)
+		if name != tt.wantName || rest != tt.wantRest || ok != tt.wantOK {
+			t.Errorf("splitAgentPath(%q) = %q, %q, %v; want %q, %q, %v", tt.path, name, rest, ok, tt.wantName, tt.wantRest, tt.wantOK)
+		}
+	}
+}
+
+func TestValidAgentName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"code-reviewer", true},
+		{"team.bot_2", true},
+		{"", false},
+		{"has space", false},
+		{"a%2Fb", false},
+		{strings.Repeat("a", 64), true},
+		{strings.Repeat("a", 65), false},
+	}
+	for _, tt := range tests {
+		if got := validAgentName(tt.name); got != tt.want {
+			t.Errorf("validAgentName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAgentPathSetsAgentName(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	// Push budget-agent over its daily limit so attribution shows up as a 429.
+	if err := st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "budget-agent", Model: "gpt-4o", Provider: "openai",
+		InputTokens: 100, OutputTokens: 50, CostUSD: 20.00, DurationMS: 100, StatusCode: 200,
+	}); err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+	body := `{"model":"llama-3-70b","messages":[{"role":"user","content":"hello"}]}`
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+	}{
+		{"path agent over budget", "/agents/budget-agent/v1/chat/completions", "", http.StatusTooManyRequests},
+		{"path overrides header", "/agents/other-agent/v1/chat/completions", "budget-agent", http.StatusBadGateway},
+		{"header still works", "/v1/chat/completions", "budget-agent", http.StatusTooManyRequests},
+		{"invalid agent name", "/agents/bad%20name/v1/chat/completions", "", http.StatusBadRequest},
+		{"not a v1 path", "/agents/budget-agent/health", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body))
+			if tt.header != "" {
+				req.Header.Set("X-Agent-Name", tt.header)
+			}
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAgentPathModels(t *testing.T) {
+	p, _ := newTestProxy(t)
+	req := httptest.NewRequest(http.MethodGet, "/agents/coder/v1/models", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"object":"list"`) {
+		t.Errorf("GET /agents/coder/v1/models = %d %s", w.Code, w.Body.String())
+	}
+}
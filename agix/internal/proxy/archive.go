@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+)
+
+type archiveKey struct{}
+
+// archiveBodies collects the bodies of one chat request until its usage
+// is recorded.
+type archiveBodies struct {
+	mu       sync.Mutex
+	request  []byte
+	response bytes.Buffer
+}
+
+// withArchive returns r carrying a body collector when archiving is on.
+func (p *Proxy) withArchive(r *http.Request) *http.Request {
+	if p.archiver == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), archiveKey{}, &archiveBodies{}))
+}
+
+func archiveFrom(r *http.Request) *archiveBodies {
+	if r == nil {
+		return nil
+	}
+	a, _ := r.Context().Value(archiveKey{}).(*archiveBodies)
+	return a
+}
+
+// archiveRequest keeps the client request body for archiving.
+func archiveRequest(r *http.Request, body []byte) {
+	if a := archiveFrom(r); a != nil {
+		a.mu.Lock()
+		a.request = body
+		a.mu.Unlock()
+	}
+}
+
+// archiveResponse sets the upstream response body, replacing any earlier
+// one (e.g. a previous tool-loop round).
+func archiveResponse(r *http.Request, body []byte) {
+	if a := archiveFrom(r); a != nil {
+		a.mu.Lock()
+		a.response.Reset()
+		a.response.Write(body)
+		a.mu.Unlock()
+	}
+}
+
+// archiveStreamLine appends one line of a streamed upstream response.
+func archiveStreamLine(r *http.Request, line string) {
+	if a := archiveFrom(r); a != nil {
+		a.mu.Lock()
+		a.response.WriteString(line)
+		a.response.WriteByte('\n')
+		a.mu.Unlock()
+	}
+}
+
+// archiveExchange writes the collected bodies and returns the archive key,
+// or "" if r carries none.
+func (p *Proxy) archiveExchange(r *http.Request) string {
+	a := archiveFrom(r)
+	if p.archiver == nil || a == nil {
+		return ""
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return p.archiver.Archive(a.request, a.response.Bytes())
+}
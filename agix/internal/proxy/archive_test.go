@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/archive"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestArchiveStoresBodies(t *testing.T) {
+	p, st := newTestProxy(t)
+	dir, err := archive.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	arc := archive.New(dir)
+	arc.Start()
+	WithArchiver(arc)(p)
+
+	reply := `{"choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":5,"completion_tokens":1}}`
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(reply)),
+			Request:    r,
+		}, nil
+	})
+
+	body := `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Request-ID", "archived-1")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	arc.Close()
+
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.QueryRequestsByRequestID("archived-1")
+	}
+	if len(records) != 1 || records[0].ArchiveKey == "" {
+		t.Fatalf("records = %+v, want one with an archive key", records)
+	}
+	e, err := archive.Get(context.Background(), dir, records[0].ArchiveKey)
+	if err != nil {
+		t.Fatalf("archive.Get: %v", err)
+	}
+	if e.Request != body || e.Response != reply {
+		t.Errorf("archived entry = %+v", e)
+	}
+}
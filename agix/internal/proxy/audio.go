@@ -0,0 +1,290 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// assumedAudioBytesPerSecond estimates an upload's length when the
+// transcription response doesn't report one (text, srt and vtt formats):
+// 128 kbps, so compressed audio is billed about right and uncompressed
+// audio errs on the expensive side.
+const assumedAudioBytesPerSecond = 16000
+
+// handleAudioTranscriptions proxies POST /v1/audio/transcriptions and
+// records the per-minute cost with request type "audio". The multipart body
+// is forwarded as sent, apart from the model name where the provider needs
+// its own. Rate limits and budgets apply as for chat requests.
+func (p *Proxy) handleAudioTranscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := requestIDFor(r)
+	w.Header().Set("X-Request-ID", requestID)
+	r = withRequestID(r, requestID)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	contentType := r.Header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		http.Error(w, `{"error":"request body must be multipart/form-data"}`, http.StatusBadRequest)
+		return
+	}
+	boundary := params["boundary"]
+	model, fileBytes, err := parseAudioForm(body, boundary)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, "invalid multipart body: "+err.Error()), http.StatusBadRequest)
+		return
+	}
+	if model == "" {
+		http.Error(w, `{"error":"model field is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	provider := pricing.ProviderForModel(model)
+	agentName := r.Header.Get("X-Agent-Name")
+
+	// The upstream builders work on JSON, so run just the model through them
+	// to learn the URL, headers and the model name the provider expects.
+	modelJSON, _ := json.Marshal(map[string]string{"model": model})
+	upstreamURL, upstreamHeaders, adapted, err := p.openAIEndpoint(provider, model, "/audio/transcriptions", modelJSON)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	var upstream struct {
+		Model string `json:"model"`
+	}
+	json.Unmarshal(adapted, &upstream)
+	if upstream.Model != "" && upstream.Model != model {
+		if body, err = replaceFormModel(body, boundary, upstream.Model); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, "invalid multipart body: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	upstreamHeaders["Content-Type"] = contentType
+
+	budget, ok := p.admit(w, r, agentName, provider, model, 0, nil)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	resp, respBody, err := p.sendRaw(r, provider, model, upstreamURL, upstreamHeaders, body)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	duration := time.Since(start)
+
+	// whisper-1 reports the billed seconds in usage, verbose_json carries the
+	// duration, and the plain-text formats carry neither.
+	var parsed struct {
+		Duration float64 `json:"duration"`
+		Usage    struct {
+			Seconds      float64 `json:"seconds"`
+			InputTokens  int     `json:"input_tokens"`
+			OutputTokens int     `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	json.Unmarshal(respBody, &parsed)
+	var seconds, cost float64
+	if resp.StatusCode < 400 {
+		seconds = parsed.Usage.Seconds
+		if seconds == 0 {
+			seconds = parsed.Duration
+		}
+		if seconds == 0 {
+			seconds = float64(fileBytes) / assumedAudioBytesPerSecond
+		}
+		cost = pricing.TranscriptionCost(model, seconds)
+	}
+
+	p.recordUsage(r, &store.Record{
+		Timestamp:    start,
+		AgentName:    agentName,
+		Model:        model,
+		Provider:     provider,
+		InputTokens:  parsed.Usage.InputTokens,
+		OutputTokens: parsed.Usage.OutputTokens,
+		CostUSD:      cost,
+		DurationMS:   duration.Milliseconds(),
+		StatusCode:   resp.StatusCode,
+		RequestID:    requestID,
+		RequestType:  store.RequestTypeAudio,
+	})
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("X-Cost-USD", fmt.Sprintf("%.6f", cost))
+	w.Header().Set("X-Audio-Seconds", fmt.Sprintf("%.1f", seconds))
+	p.reportBudget(w.Header(), budget, cost)
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// handleAudioSpeech proxies POST /v1/audio/speech unchanged and records the
+// per-character cost of the input text with request type "audio". Rate
+// limits and budgets apply as for chat requests.
+func (p *Proxy) handleAudioSpeech(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := requestIDFor(r)
+	w.Header().Set("X-Request-ID", requestID)
+	r = withRequestID(r, requestID)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req struct {
+		Model string `json:"model"`
+		Input string `json:"input"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, `{"error":"invalid JSON in request body"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Model == "" {
+		http.Error(w, `{"error":"model field is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	provider := pricing.ProviderForModel(req.Model)
+	agentName := r.Header.Get("X-Agent-Name")
+
+	upstreamURL, upstreamHeaders, upstreamBody, err := p.openAIEndpoint(provider, req.Model, "/audio/speech", body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	budget, ok := p.admit(w, r, agentName, provider, req.Model, 0, nil)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	resp, respBody, err := p.sendRaw(r, provider, req.Model, upstreamURL, upstreamHeaders, upstreamBody)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	duration := time.Since(start)
+
+	chars := utf8.RuneCountInString(req.Input)
+	var cost float64
+	if resp.StatusCode < 400 {
+		cost = pricing.SpeechCost(req.Model, chars)
+	}
+
+	p.recordUsage(r, &store.Record{
+		Timestamp:   start,
+		AgentName:   agentName,
+		Model:       req.Model,
+		Provider:    provider,
+		CostUSD:     cost,
+		DurationMS:  duration.Milliseconds(),
+		StatusCode:  resp.StatusCode,
+		RequestID:   requestID,
+		RequestType: store.RequestTypeAudio,
+	})
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("X-Cost-USD", fmt.Sprintf("%.6f", cost))
+	w.Header().Set("X-Audio-Characters", fmt.Sprintf("%d", chars))
+	p.reportBudget(w.Header(), budget, cost)
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// parseAudioForm returns the model field and the size of the uploaded file
+// in a multipart transcription request.
+func parseAudioForm(body []byte, boundary string) (model string, fileBytes int64, err error) {
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return model, fileBytes, nil
+		}
+		if err != nil {
+			return "", 0, err
+		}
+		switch part.FormName() {
+		case "model":
+			b, err := io.ReadAll(io.LimitReader(part, 256))
+			if err != nil {
+				return "", 0, err
+			}
+			model = string(b)
+		case "file":
+			if fileBytes, err = io.Copy(io.Discard, part); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+}
+
+// replaceFormModel rewrites the model field of a multipart body, keeping the
+// other parts and the boundary as they were.
+func replaceFormModel(body []byte, boundary, model string) ([]byte, error) {
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	var out bytes.Buffer
+	mw := multipart.NewWriter(&out)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		pw, err := mw.CreatePart(part.Header)
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "model" {
+			_, err = io.WriteString(pw, model)
+		} else {
+			_, err = io.Copy(pw, part)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// transcriptionForm builds a multipart transcription request body.
+func transcriptionForm(t *testing.T, model string, audio []byte) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if model != "" {
+		mw.WriteField("model", model)
+	}
+	fw, _ := mw.CreateFormFile("file", "speech.mp3")
+	fw.Write(audio)
+	mw.WriteField("response_format", "json")
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String(), mw.FormDataContentType()
+}
+
+func waitForRecord(t *testing.T, st *store.Store, agent string) store.Record {
+	t.Helper()
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.QueryRecentRequests(1, agent)
+	}
+	if len(records) != 1 {
+		t.Fatalf("no record for %s", agent)
+	}
+	return records[0]
+}
+
+func TestHandleAudioTranscriptions(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	var sent, url, sentType string
+	stubUpstream(p, "application/json", `{"text":"hello","usage":{"type":"duration","seconds":90}}`, &sent)
+	inner := p.client.Transport
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		url, sentType = r.URL.String(), r.Header.Get("Content-Type")
+		return inner.RoundTrip(r)
+	})
+
+	body, contentType := transcriptionForm(t, "whisper-1", []byte("ID3 fake audio"))
+	req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Agent-Name", "transcriber")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if url != "https://api.openai.com/v1/audio/transcriptions" || sent != body || sentType != contentType {
+		t.Errorf("upstream = %s %s", url, sentType)
+	}
+	if got := w.Header().Get("X-Cost-USD"); got != "0.009000" {
+		t.Errorf("X-Cost-USD = %q, want 0.009000", got)
+	}
+	if got := w.Header().Get("X-Audio-Seconds"); got != "90.0" {
+		t.Errorf("X-Audio-Seconds = %q, want 90.0", got)
+	}
+	if rec := waitForRecord(t, st, "transcriber"); rec.RequestType != store.RequestTypeAudio || rec.Model != "whisper-1" || rec.CostUSD < 0.0089 {
+		t.Errorf("recorded = %+v", rec)
+	}
+}
+
+func TestHandleAudioTranscriptionsRewritesModel(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	var sent string
+	stubUpstream(p, "text/plain", "hello", &sent)
+
+	// Two seconds of audio at the assumed bitrate.
+	audio := bytes.Repeat([]byte{0}, 2*assumedAudioBytesPerSecond)
+	body, contentType := transcriptionForm(t, "ollama/whisper", audio)
+	req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	_, params, _ := strings.Cut(contentType, "boundary=")
+	model, fileBytes, err := parseAudioForm([]byte(sent), params)
+	if err != nil || model != "whisper" || fileBytes != int64(len(audio)) {
+		t.Errorf("upstream form: model %q, %d bytes, err %v", model, fileBytes, err)
+	}
+	if got := w.Header().Get("X-Audio-Seconds"); got != "2.0" {
+		t.Errorf("X-Audio-Seconds = %q, want 2.0", got)
+	}
+}
+
+func TestHandleAudioSpeech(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	var sent, url string
+	stubUpstream(p, "audio/mpeg", "MP3DATA", &sent)
+	inner := p.client.Transport
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		url = r.URL.String()
+		return inner.RoundTrip(r)
+	})
+
+	body := `{"model":"tts-1-hd","voice":"alloy","input":"` + strings.Repeat("a", 1000) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/audio/speech", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "narrator")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "MP3DATA" || w.Header().Get("Content-Type") != "audio/mpeg" {
+		t.Fatalf("status = %d, type = %s, body = %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if url != "https://api.openai.com/v1/audio/speech" || sent != body {
+		t.Errorf("upstream = %s %s", url, sent)
+	}
+	if got := w.Header().Get("X-Cost-USD"); got != "0.030000" {
+		t.Errorf("X-Cost-USD = %q, want 0.030000", got)
+	}
+	if got := w.Header().Get("X-Audio-Characters"); got != "1000" {
+		t.Errorf("X-Audio-Characters = %q, want 1000", got)
+	}
+	if rec := waitForRecord(t, st, "narrator"); rec.RequestType != store.RequestTypeAudio || rec.CostUSD < 0.0299 {
+		t.Errorf("recorded = %+v", rec)
+	}
+}
+
+func TestHandleAudioRejects(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	if err := st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "budget-agent", Model: "gpt-4o", Provider: "openai",
+		InputTokens: 100, OutputTokens: 50, CostUSD: 20.00, DurationMS: 100, StatusCode: 200,
+	}); err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+	var sent string
+	stubUpstream(p, "application/json", `{"text":""}`, &sent)
+
+	form, formType := transcriptionForm(t, "whisper-1", []byte("x"))
+	noModel, noModelType := transcriptionForm(t, "", []byte("x"))
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		agent       string
+		want        int
+	}{
+		{"wrong method", http.MethodGet, "/v1/audio/transcriptions", "", "", "", http.StatusMethodNotAllowed},
+		{"not multipart", http.MethodPost, "/v1/audio/transcriptions", "application/json", `{"model":"whisper-1"}`, "", http.StatusBadRequest},
+		{"missing model", http.MethodPost, "/v1/audio/transcriptions", noModelType, noModel, "", http.StatusBadRequest},
+		{"transcription over budget", http.MethodPost, "/v1/audio/transcriptions", formType, form, "budget-agent", http.StatusTooManyRequests},
+		{"speech invalid JSON", http.MethodPost, "/v1/audio/speech", "", `{`, "", http.StatusBadRequest},
+		{"speech unsupported provider", http.MethodPost, "/v1/audio/speech", "", `{"model":"claude-sonnet-4-20250514","input":"hi"}`, "", http.StatusBadRequest},
+		{"speech over budget", http.MethodPost, "/v1/audio/speech", "", `{"model":"tts-1","input":"hi"}`, "budget-agent", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.agent != "" {
+				req.Header.Set("X-Agent-Name", tt.agent)
+			}
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
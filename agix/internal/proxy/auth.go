@@ -0,0 +1,175 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/jwtauth"
+)
+
+// WithAPIKeys checks agix-issued keys on /v1/* and takes the agent name
+// from the key. With required set, requests without a key are rejected;
+// otherwise they fall back to X-Agent-Name.
+func WithAPIKeys(k *apikeys.Keys, required bool) Option {
+	return func(p *Proxy) {
+		p.apiKeys = k
+		p.requireKeys = required
+	}
+}
+
+// WithInternalToken lets requests carrying token in apikeys.InternalHeader,
+// which agix sends to itself, through key checks.
+func WithInternalToken(token string) Option {
+	return func(p *Proxy) { p.internalToken = token }
+}
+
+// WithJWT validates bearer JWTs with v and maps the claims named in cfg
+// to the agent, project and budget group.
+func WithJWT(v *jwtauth.Verifier, cfg config.JWTConfig) Option {
+	return func(p *Proxy) {
+		p.jwt = v
+		p.jwtClaims = cfg
+	}
+}
+
+// ownAuthPaths authenticate callers themselves (data API keys, webhook
+// signatures, the admin token) and skip API key checks.
+var ownAuthPaths = append([]string{"/v1/data/", "/v1/webhooks/"}, legacyAdminPaths...)
+
+// identity is who a verified credential says the caller is.
+type identity struct {
+	agent       string
+	project     string
+	budgetGroup string
+	// ownsProject is set when the credential decides the project, so the
+	// caller's X-Project is dropped even if project is empty.
+	ownsProject bool
+}
+
+type budgetGroupKey struct{}
+
+// budgetGroup returns the budget group a JWT assigned to the request, or "".
+func budgetGroup(ctx context.Context) string {
+	g, _ := ctx.Value(budgetGroupKey{}).(string)
+	return g
+}
+
+// budgetFor returns the budget that applies to agent: its own entry in
+// budgets, else the entry for its budget group.
+func (p *Proxy) budgetFor(agent, group string) (config.Budget, bool) {
+	budgets := p.hot.Load().Budgets
+	for _, key := range []string{agent, group} {
+		// An agent named like the global or a provider budget does not
+		// get it as its own.
+		if a, _, _ := config.BudgetScope(key); key == "" || a != key {
+			continue
+		}
+		if b, ok := budgets[key]; ok {
+			return b, true
+		}
+	}
+	return config.Budget{}, false
+}
+
+// authenticate resolves the caller's agent from its API key or JWT. It
+// returns the request to serve, with X-Agent-Name (and X-Project, from a
+// JWT) set from the credential, or false after writing a 401 or 403.
+func (p *Proxy) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if p.apiKeys == nil && p.jwt == nil {
+		return r, true
+	}
+	if !strings.HasPrefix(r.URL.Path, "/v1/") && !strings.HasPrefix(r.URL.Path, agentPathPrefix) {
+		return r, true
+	}
+	if apikeys.IsInternal(r, p.internalToken) {
+		return r, true
+	}
+	for _, prefix := range ownAuthPaths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return r, true
+		}
+	}
+
+	var id identity
+	var secret, token string
+	if p.apiKeys != nil {
+		secret = apikeys.FromRequest(r)
+	}
+	if secret == "" && p.jwt != nil {
+		token = jwtauth.FromRequest(r)
+	}
+	switch {
+	case secret != "":
+		key, err := p.apiKeys.Authenticate(secret)
+		if err != nil {
+			if !errors.Is(err, apikeys.ErrUnknownKey) && !errors.Is(err, apikeys.ErrRevoked) {
+				log.Printf("ERROR: authenticate API key: %v", err)
+				http.Error(w, `{"error":"cannot verify API key"}`, http.StatusServiceUnavailable)
+				return nil, false
+			}
+			unauthorized(w, err.Error())
+			return nil, false
+		}
+		id.agent = key.Agent
+	case token != "":
+		claims, err := p.jwt.Verify(r.Context(), token)
+		if err != nil {
+			unauthorized(w, err.Error())
+			return nil, false
+		}
+		claim := p.jwtClaims.AgentClaim
+		if claim == "" {
+			claim = "sub"
+		}
+		id.agent = claims.String(claim)
+		if !validAgentName(id.agent) {
+			unauthorized(w, fmt.Sprintf("token claim %s is not a valid agent name", claim))
+			return nil, false
+		}
+		if c := p.jwtClaims.ProjectClaim; c != "" {
+			id.project, id.ownsProject = claims.String(c), true
+		}
+		if c := p.jwtClaims.BudgetGroupClaim; c != "" {
+			id.budgetGroup = claims.String(c)
+		}
+	case p.requireKeys:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="agix"`)
+		http.Error(w, `{"error":"missing agix API key or token (Authorization: Bearer ...)"}`, http.StatusUnauthorized)
+		return nil, false
+	default:
+		return r, true
+	}
+
+	// A per-agent base URL must not be used to act as another agent
+	if name, _, ok := splitAgentPath(r.URL.Path); ok && name != id.agent {
+		http.Error(w, fmt.Sprintf(`{"error":"credential belongs to agent %q, not %q"}`, id.agent, name), http.StatusForbidden)
+		return nil, false
+	}
+
+	ctx := r.Context()
+	if id.budgetGroup != "" {
+		ctx = context.WithValue(ctx, budgetGroupKey{}, id.budgetGroup)
+	}
+	r2 := r.Clone(ctx)
+	r2.Header.Set("X-Agent-Name", id.agent)
+	if id.ownsProject {
+		r2.Header.Del("X-Project")
+	}
+	if id.project != "" {
+		r2.Header.Set("X-Project", id.project)
+	}
+	r2.Header.Del("Authorization")
+	r2.Header.Del("x-api-key")
+	return r2, true
+}
+
+func unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="agix", error="invalid_token"`)
+	http.Error(w, fmt.Sprintf(`{"error":%q}`, msg), http.StatusUnauthorized)
+}
@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/jwtauth"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	p, st := newTestProxy(t)
+	keys, err := apikeys.New(st.DB(), st.Dialect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithAPIKeys(keys, false)(p)
+
+	// budget-agent is over its daily limit, so attribution to it shows up as a 429.
+	if err := st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "budget-agent", Model: "gpt-4o", Provider: "openai",
+		InputTokens: 100, OutputTokens: 50, CostUSD: 20.00, DurationMS: 100, StatusCode: 200,
+	}); err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+	budgetKey, _, _ := keys.Create("budget-agent")
+	otherKey, _, _ := keys.Create("other-agent")
+	revokedKey, revoked, _ := keys.Create("budget-agent")
+	keys.Revoke(revoked.Prefix)
+
+	body := `{"model":"llama-3-70b","messages":[{"role":"user","content":"hello"}]}`
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		agent      string
+		require    bool
+		wantStatus int
+	}{
+		{"key sets agent", "/v1/chat/completions", budgetKey, "", false, http.StatusTooManyRequests},
+		{"key overrides header", "/v1/chat/completions", otherKey, "budget-agent", false, http.StatusBadGateway},
+		{"x-api-key", "/v1/messages", "x:" + budgetKey, "", false, http.StatusTooManyRequests},
+		{"no key falls back to header", "/v1/chat/completions", "", "budget-agent", false, http.StatusTooManyRequests},
+		{"no key when required", "/v1/chat/completions", "", "budget-agent", true, http.StatusUnauthorized},
+		{"unknown key", "/v1/chat/completions", "agix-nope", "", false, http.StatusUnauthorized},
+		{"revoked key", "/v1/chat/completions", revokedKey, "", false, http.StatusUnauthorized},
+		{"agent path matches key", "/agents/budget-agent/v1/chat/completions", budgetKey, "", true, http.StatusTooManyRequests},
+		{"agent path of another agent", "/agents/budget-agent/v1/chat/completions", otherKey, "", true, http.StatusForbidden},
+		{"health is open", "/health", "", "", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.requireKeys = tt.require
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body))
+			if tt.path == "/health" {
+				req = httptest.NewRequest(http.MethodGet, tt.path, nil)
+			}
+			if k, ok := strings.CutPrefix(tt.key, "x:"); ok {
+				req.Header.Set("x-api-key", k)
+				req.Header.Set("anthropic-version", "2023-06-01")
+			} else if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			if tt.agent != "" {
+				req.Header.Set("X-Agent-Name", tt.agent)
+			}
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+
+	// Requests agix sends to itself pass without a key, with this
+	// gateway's token only.
+	p.requireKeys = true
+	p.internalToken = "gateway-token"
+	for token, want := range map[string]int{"gateway-token": http.StatusTooManyRequests, "other-process": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("X-Agent-Name", "budget-agent")
+		apikeys.MarkInternal(req, token)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("internal request with %s token: status = %d, want %d", token, w.Code, want)
+		}
+	}
+}
+
+func TestJWTAuth(t *testing.T) {
+	p, _ := newTestProxy(t)
+	cfg := config.JWTConfig{Secret: "s3cret", Audience: "agix", ProjectClaim: "agix.project", BudgetGroupClaim: "tier"}
+	v, err := jwtauth.New(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithJWT(v, cfg)(p)
+	p.requireKeys = true
+	p.initialHot.Budgets["free"] = config.Budget{DailyLimitUSD: 1}
+
+	token := func(claims map[string]any) string {
+		claims["aud"] = "agix"
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		h, _ := json.Marshal(map[string]string{"alg": "HS256"})
+		c, _ := json.Marshal(claims)
+		signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write([]byte(signed))
+		return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer "+token(map[string]any{
+		"sub": "indexer", "tier": "free", "agix": map[string]any{"project": "search"},
+	}))
+	req.Header.Set("X-Agent-Name", "someone-else")
+	w := httptest.NewRecorder()
+	got, ok := p.authenticate(w, req)
+	if !ok {
+		t.Fatalf("authenticate rejected a valid token: %d %s", w.Code, w.Body.String())
+	}
+	if got.Header.Get("X-Agent-Name") != "indexer" || got.Header.Get("X-Project") != "search" || got.Header.Get("Authorization") != "" {
+		t.Errorf("headers = %v", got.Header)
+	}
+	if g := budgetGroup(got.Context()); g != "free" {
+		t.Errorf("budget group = %q, want free", g)
+	}
+	if b, ok := p.budgetFor("indexer", "free"); !ok || b.DailyLimitUSD != 1 {
+		t.Errorf("budgetFor(indexer, free) = %+v, %v", b, ok)
+	}
+	if b, ok := p.budgetFor("budget-agent", "free"); !ok || b.DailyLimitUSD != 10 {
+		t.Errorf("agent budget should win over group budget, got %+v, %v", b, ok)
+	}
+
+	// With a project claim configured the caller cannot pick the project,
+	// even when the token carries none.
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer "+token(map[string]any{"sub": "indexer"}))
+	req.Header.Set("X-Project", "billing")
+	w = httptest.NewRecorder()
+	got, ok = p.authenticate(w, req)
+	if !ok {
+		t.Fatalf("authenticate rejected a valid token: %d %s", w.Code, w.Body.String())
+	}
+	if project := got.Header.Get("X-Project"); project != "" {
+		t.Errorf("X-Project = %q, want the caller's header dropped", project)
+	}
+
+	for name, tok := range map[string]string{
+		"bad signature": token(map[string]any{"sub": "indexer"}) + "x",
+		"bad agent":     token(map[string]any{"sub": "not an agent"}),
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		req.Header.Set("Authorization", "Bearer "+tok)
+		w := httptest.NewRecorder()
+		if _, ok := p.authenticate(w, req); ok || w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: ok=%v status=%d, want 401", name, ok, w.Code)
+		}
+	}
+}
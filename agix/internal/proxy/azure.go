@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultAzureAPIVersion is used when azure.api_version is unset.
+const defaultAzureAPIVersion = "2024-10-21"
+
+// azureUpstream returns the Azure OpenAI URL and headers for model
+// "azure/{deployment}". Azure speaks the OpenAI chat format, routed by
+// deployment in the URL rather than by the model field.
+func (p *Proxy) azureUpstream(model string, body []byte, headers map[string]string) (string, map[string]string, []byte, error) {
+	apiKey := p.key("azure")
+	if apiKey == "" {
+		return "", nil, nil, fmt.Errorf("Azure OpenAI API key not configured")
+	}
+	resource := p.cfg.Azure.Resource
+	if resource == "" {
+		return "", nil, nil, fmt.Errorf("Azure OpenAI resource not configured (azure.resource)")
+	}
+	deployment := strings.TrimPrefix(model, "azure/")
+	if deployment == "" || strings.Contains(deployment, "/") {
+		return "", nil, nil, fmt.Errorf("invalid Azure model %q, want azure/{deployment}", model)
+	}
+	version := p.cfg.Azure.APIVersion
+	if version == "" {
+		version = defaultAzureAPIVersion
+	}
+
+	headers["api-key"] = apiKey
+	u := fmt.Sprintf("https://%s.openai.azure.com/openai/deployments/%s/chat/completions?api-version=%s",
+		resource, url.PathEscape(deployment), url.QueryEscape(version))
+	return u, headers, body, nil
+}
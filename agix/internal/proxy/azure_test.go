@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/pricing"
+)
+
+func TestAzureUpstream(t *testing.T) {
+	p, _ := newTestProxy(t)
+	headers := map[string]string{}
+
+	if _, _, _, err := p.azureUpstream("azure/prod", nil, headers); err == nil {
+		t.Error("expected error without an Azure key")
+	}
+	p.initialHot.Keys["azure"] = "az-key"
+	if _, _, _, err := p.azureUpstream("azure/prod", nil, headers); err == nil {
+		t.Error("expected error without azure.resource")
+	}
+	p.cfg.Azure = config.AzureConfig{Resource: "myco"}
+
+	tests := []struct {
+		model   string
+		version string
+		wantURL string
+		wantErr bool
+	}{
+		{"azure/prod-gpt4o", "", "https://myco.openai.azure.com/openai/deployments/prod-gpt4o/chat/completions?api-version=2024-10-21", false},
+		{"azure/mini", "2025-01-01-preview", "https://myco.openai.azure.com/openai/deployments/mini/chat/completions?api-version=2025-01-01-preview", false},
+		{"azure/", "", "", true},
+		{"azure/a/b", "", "", true},
+	}
+	for _, tt := range tests {
+		p.cfg.Azure.APIVersion = tt.version
+		url, h, _, err := p.azureUpstream(tt.model, nil, map[string]string{})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("azureUpstream(%q) error = %v, wantErr %v", tt.model, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if url != tt.wantURL || h["api-key"] != "az-key" || h["Authorization"] != "" {
+			t.Errorf("azureUpstream(%q) = %s %v", tt.model, url, h)
+		}
+	}
+}
+
+func TestAzureChatCompletion(t *testing.T) {
+	p, _ := newTestProxy(t)
+	p.initialHot.Keys["azure"] = "az-key"
+	p.cfg.Azure = config.AzureConfig{Resource: "myco", Deployments: map[string]string{"prod-gpt4o": "gpt-4o"}}
+	pricing.SetAliases(map[string]string{"azure/prod-gpt4o": "gpt-4o"})
+	t.Cleanup(func() { pricing.SetAliases(nil) })
+
+	var gotURL, gotKey string
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		gotURL, gotKey = r.URL.String(), r.Header.Get("api-key")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body: io.NopCloser(strings.NewReader(
+				`{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":1000000,"completion_tokens":0}}`)),
+			Request: r,
+		}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"azure/prod-gpt4o","messages":[{"role":"user","content":"hi"}]}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(gotURL, "https://myco.openai.azure.com/openai/deployments/prod-gpt4o/chat/completions") || gotKey != "az-key" {
+		t.Errorf("upstream = %s (api-key %q)", gotURL, gotKey)
+	}
+	// 1M input tokens at gpt-4o list price
+	if got := w.Header().Get("X-Cost-USD"); got != "2.500000" {
+		t.Errorf("X-Cost-USD = %s, want 2.500000", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	var models struct {
+		Data []struct {
+			ID      string `json:"id"`
+			OwnedBy string `json:"owned_by"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &models)
+	found := false
+	for _, m := range models.Data {
+		found = found || (m.ID == "azure/prod-gpt4o" && m.OwnedBy == "azure")
+	}
+	if !found {
+		t.Error("/v1/models does not list the Azure deployment")
+	}
+}
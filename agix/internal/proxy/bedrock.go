@@ -0,0 +1,278 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Bedrock support: agents request "bedrock/{modelId}" in OpenAI format. The
+// request is converted to the Converse API and signed with SigV4; the
+// Converse response is converted back to an OpenAI chat completion, so the
+// rest of the pipeline treats Bedrock like any OpenAI-format provider.
+// Converse streams use AWS's binary event-stream framing, so streaming
+// requests are served from a single Converse call and replayed as SSE.
+
+// bedrockRegion returns the configured region, falling back to the AWS
+// environment variables.
+func (p *Proxy) bedrockRegion() string {
+	if r := p.cfg.Bedrock.Region; r != "" {
+		return r
+	}
+	if r := os.Getenv("AWS_REGION"); r != "" {
+		return r
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// bedrockCredentials returns the configured credentials, falling back to
+// the AWS environment variables.
+func (p *Proxy) bedrockCredentials() awsCredentials {
+	c := p.cfg.Bedrock
+	if c.AccessKeyID != "" {
+		return awsCredentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken}
+	}
+	return awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// bedrockUpstream returns the Converse URL and request body for model
+// "bedrock/{modelId}". Signing happens in signBedrock, once the body is
+// final.
+func (p *Proxy) bedrockUpstream(model string, body []byte, headers map[string]string) (string, map[string]string, []byte, error) {
+	region := p.bedrockRegion()
+	if region == "" {
+		return "", nil, nil, fmt.Errorf("Bedrock region not configured (bedrock.region or AWS_REGION)")
+	}
+	if creds := p.bedrockCredentials(); creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return "", nil, nil, fmt.Errorf("Bedrock credentials not configured (bedrock.access_key_id or AWS_ACCESS_KEY_ID)")
+	}
+	modelID := strings.TrimPrefix(model, "bedrock/")
+	if modelID == "" {
+		return "", nil, nil, fmt.Errorf("invalid Bedrock model %q, want bedrock/{modelId}", model)
+	}
+	converse, err := convertToConverseFormat(body)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("convert to Bedrock format: %w", err)
+	}
+	u := fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com/model/%s/converse", region, awsURIEncode(modelID, true))
+	return u, headers, converse, nil
+}
+
+// signBedrock signs an upstream Bedrock request once its body is final.
+func (p *Proxy) signBedrock(req *http.Request, body []byte) {
+	signV4(req, body, p.bedrockCredentials(), p.bedrockRegion(), "bedrock", time.Now())
+}
+
+type converseContent struct {
+	Text string `json:"text"`
+}
+
+type converseMessage struct {
+	Role    string            `json:"role"`
+	Content []converseContent `json:"content"`
+}
+
+type converseRequest struct {
+	Messages        []converseMessage  `json:"messages"`
+	System          []converseContent  `json:"system,omitempty"`
+	InferenceConfig *converseInference `json:"inferenceConfig,omitempty"`
+}
+
+type converseInference struct {
+	MaxTokens     int      `json:"maxTokens,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+// convertToConverseFormat converts an OpenAI chat request to a Converse
+// request. System messages become the system prompt; consecutive messages
+// with the same role are merged, since Converse requires alternating roles.
+func convertToConverseFormat(body []byte) ([]byte, error) {
+	var req struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+		MaxTokens           int             `json:"max_tokens"`
+		MaxCompletionTokens int             `json:"max_completion_tokens"`
+		Temperature         *float64        `json:"temperature"`
+		TopP                *float64        `json:"top_p"`
+		Stop                json.RawMessage `json:"stop"`
+		ResponseFormat      json.RawMessage `json:"response_format"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+
+	var out converseRequest
+	for _, m := range req.Messages {
+		texts, err := messageTexts(m.Content)
+		if err != nil {
+			return nil, err
+		}
+		switch m.Role {
+		case "system", "developer":
+			out.System = append(out.System, texts...)
+		case "user", "assistant":
+			if n := len(out.Messages); n > 0 && out.Messages[n-1].Role == m.Role {
+				out.Messages[n-1].Content = append(out.Messages[n-1].Content, texts...)
+			} else {
+				out.Messages = append(out.Messages, converseMessage{Role: m.Role, Content: texts})
+			}
+		default:
+			return nil, fmt.Errorf("unsupported message role %q", m.Role)
+		}
+	}
+
+	if instr := responseFormatInstruction(req.ResponseFormat); instr != "" {
+		out.System = append(out.System, converseContent{Text: instr})
+	}
+
+	inf := converseInference{
+		MaxTokens:   max(req.MaxTokens, req.MaxCompletionTokens),
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+	}
+	if len(req.Stop) > 0 {
+		var one string
+		if json.Unmarshal(req.Stop, &one) == nil {
+			inf.StopSequences = []string{one}
+		} else {
+			json.Unmarshal(req.Stop, &inf.StopSequences)
+		}
+	}
+	if inf.MaxTokens > 0 || inf.Temperature != nil || inf.TopP != nil || len(inf.StopSequences) > 0 {
+		out.InferenceConfig = &inf
+	}
+	return json.Marshal(out)
+}
+
+// messageTexts returns the text blocks of an OpenAI message content, which
+// is either a string or an array of content parts.
+func messageTexts(content json.RawMessage) ([]converseContent, error) {
+	var s string
+	if err := json.Unmarshal(content, &s); err == nil {
+		return []converseContent{{Text: s}}, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return nil, fmt.Errorf("unsupported message content")
+	}
+	var out []converseContent
+	for _, part := range parts {
+		if part.Type != "text" {
+			return nil, fmt.Errorf("unsupported content part %q", part.Type)
+		}
+		out = append(out, converseContent{Text: part.Text})
+	}
+	return out, nil
+}
+
+// converseFinishReason maps a Converse stopReason to the OpenAI value.
+func converseFinishReason(stop string) string {
+	switch stop {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "content_filtered", "guardrail_intervened":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// bedrockResponse rewrites a Converse response as an OpenAI chat completion,
+// or as an SSE stream of chat chunks when stream is set. Error responses
+// pass through unchanged.
+func bedrockResponse(resp *http.Response, model string, stream bool) (*http.Response, error) {
+	if resp.StatusCode >= 400 {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read Bedrock response: %w", err)
+	}
+
+	var cr struct {
+		Output struct {
+			Message struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"message"`
+		} `json:"output"`
+		StopReason string `json:"stopReason"`
+		Usage      struct {
+			InputTokens  int `json:"inputTokens"`
+			OutputTokens int `json:"outputTokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &cr); err != nil {
+		return nil, fmt.Errorf("decode Bedrock response: %w", err)
+	}
+	var text strings.Builder
+	for _, c := range cr.Output.Message.Content {
+		text.WriteString(c.Text)
+	}
+
+	id := "chatcmpl-bedrock-" + resp.Header.Get("X-Amzn-Requestid")
+	created := time.Now().Unix()
+	finish := converseFinishReason(cr.StopReason)
+	usage := map[string]int{
+		"prompt_tokens":     cr.Usage.InputTokens,
+		"completion_tokens": cr.Usage.OutputTokens,
+		"total_tokens":      cr.Usage.InputTokens + cr.Usage.OutputTokens,
+	}
+
+	var out []byte
+	if stream {
+		chunk := func(v map[string]any) string {
+			v["id"], v["object"], v["created"], v["model"] = id, "chat.completion.chunk", created, model
+			b, _ := json.Marshal(v)
+			return "data: " + string(b) + "\n\n"
+		}
+		out = []byte(chunk(map[string]any{"choices": []any{map[string]any{
+			"index": 0, "delta": map[string]string{"role": "assistant", "content": text.String()}, "finish_reason": nil,
+		}}}) + chunk(map[string]any{"choices": []any{map[string]any{
+			"index": 0, "delta": map[string]string{}, "finish_reason": finish,
+		}}}) + chunk(map[string]any{"choices": []any{}, "usage": usage}) + "data: [DONE]\n\n")
+		resp.Header.Set("Content-Type", "text/event-stream")
+	} else {
+		out, _ = json.Marshal(map[string]any{
+			"id": id, "object": "chat.completion", "created": created, "model": model,
+			"choices": []any{map[string]any{
+				"index": 0, "message": map[string]string{"role": "assistant", "content": text.String()}, "finish_reason": finish,
+			}},
+			"usage": usage,
+		})
+		resp.Header.Set("Content-Type", "application/json")
+	}
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(out))
+	resp.Body = io.NopCloser(bytes.NewReader(out))
+	return resp, nil
+}
+
+// isStreaming reports whether an OpenAI chat request body asks for a stream.
+func isStreaming(body []byte) bool {
+	var req struct {
+		Stream bool `json:"stream"`
+	}
+	json.Unmarshal(body, &req)
+	return req.Stream
+}
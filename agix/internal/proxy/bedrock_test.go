@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/pricing"
+)
+
+func TestSignV4(t *testing.T) {
+	// Example request from the AWS Signature Version 4 documentation.
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %s", got)
+	}
+}
+
+func TestConvertToConverseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "system and params",
+			body: `{"model":"bedrock/x","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hi"}],"max_tokens":100,"temperature":0.2,"stop":"END"}`,
+			want: `{"messages":[{"role":"user","content":[{"text":"Hi"}]}],"system":[{"text":"Be brief."}],"inferenceConfig":{"maxTokens":100,"temperature":0.2,"stopSequences":["END"]}}`,
+		},
+		{
+			name: "content parts and merged roles",
+			body: `{"messages":[{"role":"user","content":[{"type":"text","text":"a"}]},{"role":"user","content":"b"},{"role":"assistant","content":"c"}]}`,
+			want: `{"messages":[{"role":"user","content":[{"text":"a"},{"text":"b"}]},{"role":"assistant","content":[{"text":"c"}]}]}`,
+		},
+		{name: "image part", body: `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"x"}}]}]}`, wantErr: true},
+		{name: "tool role", body: `{"messages":[{"role":"tool","content":"r"}]}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertToConverseFormat([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBedrockChatCompletion(t *testing.T) {
+	const converse = `{"output":{"message":{"role":"assistant","content":[{"text":"Hello"},{"text":" there"}]}},` +
+		`"stopReason":"end_turn","usage":{"inputTokens":1000000,"outputTokens":5,"totalTokens":1000005}}`
+
+	tests := []struct {
+		name   string
+		stream bool
+	}{
+		{"non-streaming", false},
+		{"streaming", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			p.cfg.Bedrock = config.BedrockConfig{
+				Region: "us-west-2", AccessKeyID: "AKID", SecretAccessKey: "secret",
+				Models: map[string]string{"anthropic.claude-3-5-sonnet-20241022-v2:0": "claude-sonnet-4-5-20250929"},
+			}
+			pricing.SetAliases(map[string]string{"bedrock/anthropic.claude-3-5-sonnet-20241022-v2:0": "claude-sonnet-4-5-20250929"})
+			t.Cleanup(func() { pricing.SetAliases(nil) })
+
+			var gotURL, gotAuth, gotBody string
+			p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				gotURL, gotAuth, gotBody = r.URL.String(), r.Header.Get("Authorization"), string(b)
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(converse)),
+					Request:    r,
+				}, nil
+			})
+
+			body := `{"model":"bedrock/anthropic.claude-3-5-sonnet-20241022-v2:0","messages":[{"role":"user","content":"Hi"}]`
+			if tt.stream {
+				body += `,"stream":true`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body+"}"))
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if gotURL != "https://bedrock-runtime.us-west-2.amazonaws.com/model/anthropic.claude-3-5-sonnet-20241022-v2%3A0/converse" {
+				t.Errorf("upstream URL = %s", gotURL)
+			}
+			if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(gotAuth, "/us-west-2/bedrock/aws4_request") {
+				t.Errorf("Authorization = %s", gotAuth)
+			}
+			if gotBody != `{"messages":[{"role":"user","content":[{"text":"Hi"}]}]}` {
+				t.Errorf("upstream body = %s", gotBody)
+			}
+
+			if tt.stream {
+				out := w.Body.String()
+				if !strings.Contains(out, `"content":"Hello there"`) || !strings.HasSuffix(out, "data: [DONE]\n\n") {
+					t.Errorf("stream = %s", out)
+				}
+				return
+			}
+			var resp struct {
+				Choices []struct {
+					Message      struct{ Content string } `json:"message"`
+					FinishReason string                   `json:"finish_reason"`
+				} `json:"choices"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "Hello there" || resp.Choices[0].FinishReason != "stop" {
+				t.Errorf("response = %s", w.Body.String())
+			}
+			if got := w.Header().Get("X-Input-Tokens"); got != "1000000" {
+				t.Errorf("X-Input-Tokens = %s", got)
+			}
+			// Priced as the mapped base model: 1M input tokens of Sonnet
+			if got := w.Header().Get("X-Cost-USD"); got != "3.000075" {
+				t.Errorf("X-Cost-USD = %s, want 3.000075", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+// BudgetSaver persists a budget changed through the admin API: it stores
+// budget under key in the budgets config, or deletes key when budget is
+// nil.
+type BudgetSaver func(key string, budget *config.Budget) error
+
+// ErrBudgetInInclude is returned by a BudgetSaver for a budget defined in
+// a config.d file, which the main config file cannot override.
+var ErrBudgetInInclude = errors.New("budget is defined in config.d, change it there")
+
+// WithBudgetSaver persists changes made through /admin/budgets. Without
+// one they last until the next config reload.
+func WithBudgetSaver(s BudgetSaver) Option {
+	return func(p *Proxy) { p.saveBudget = s }
+}
+
+// adminBudget is a budget as the admin API reads and writes it.
+type adminBudget struct {
+	DailyLimitUSD     float64             `json:"daily_limit_usd,omitempty"`
+	WeeklyLimitUSD    float64             `json:"weekly_limit_usd,omitempty"`
+	MonthlyLimitUSD   float64             `json:"monthly_limit_usd,omitempty"`
+	Rolling           []adminRollingLimit `json:"rolling,omitempty"`
+	AlertAtPercent    float64             `json:"alert_at_percent,omitempty"`
+	AlertWebhook      string              `json:"alert_webhook,omitempty"`
+	AlertDestinations []string            `json:"alert_destinations,omitempty"`
+	Rollover          bool                `json:"rollover,omitempty"`
+	Credits           bool                `json:"credits,omitempty"`
+	OnExceed          string              `json:"on_exceed,omitempty"`
+	FallbackModel     string              `json:"fallback_model,omitempty"`
+	Spend             *adminBudgetSpend   `json:"spend,omitempty"`
+}
+
+type adminRollingLimit struct {
+	Window   string  `json:"window"`
+	LimitUSD float64 `json:"limit_usd"`
+}
+
+// adminBudgetSpend is what the budget's scope spent so far.
+type adminBudgetSpend struct {
+	DailyUSD   float64 `json:"daily_usd"`
+	MonthlyUSD float64 `json:"monthly_usd"`
+}
+
+func toAdminBudget(b config.Budget) adminBudget {
+	ab := adminBudget{
+		DailyLimitUSD:     b.DailyLimitUSD,
+		WeeklyLimitUSD:    b.WeeklyLimitUSD,
+		MonthlyLimitUSD:   b.MonthlyLimitUSD,
+		AlertAtPercent:    b.AlertAtPercent,
+		AlertWebhook:      b.AlertWebhook,
+		AlertDestinations: b.AlertDestinations,
+		Rollover:          b.Rollover,
+		Credits:           b.Credits,
+		OnExceed:          b.OnExceed,
+		FallbackModel:     b.FallbackModel,
+	}
+	for _, rl := range b.Rolling {
+		ab.Rolling = append(ab.Rolling, adminRollingLimit(rl))
+	}
+	return ab
+}
+
+func (ab adminBudget) config() config.Budget {
+	b := config.Budget{
+		DailyLimitUSD:     ab.DailyLimitUSD,
+		WeeklyLimitUSD:    ab.WeeklyLimitUSD,
+		MonthlyLimitUSD:   ab.MonthlyLimitUSD,
+		AlertAtPercent:    ab.AlertAtPercent,
+		AlertWebhook:      ab.AlertWebhook,
+		AlertDestinations: ab.AlertDestinations,
+		Rollover:          ab.Rollover,
+		Credits:           ab.Credits,
+		OnExceed:          ab.OnExceed,
+		FallbackModel:     ab.FallbackModel,
+	}
+	for _, rl := range ab.Rolling {
+		b.Rolling = append(b.Rolling, config.RollingLimit(rl))
+	}
+	return b
+}
+
+// validBudgetKey reports whether key names an agent, the global budget or
+// a provider's or model's budget.
+func validBudgetKey(key string) bool {
+	agent, provider, model := config.BudgetScope(key)
+	switch {
+	case key == config.GlobalBudget:
+		return true
+	case provider != "":
+		return validAgentName(provider)
+	case model != "":
+		return !strings.ContainsAny(model, " \t\n")
+	}
+	return agent != "providers" && agent != "models" && validAgentName(agent)
+}
+
+// validateBudget rejects budgets agix doctor would warn about.
+func validateBudget(b config.Budget) error {
+	if b.DailyLimitUSD < 0 || b.WeeklyLimitUSD < 0 || b.MonthlyLimitUSD < 0 {
+		return errors.New("limits must not be negative")
+	}
+	if b.AlertAtPercent < 0 || b.AlertAtPercent > 100 {
+		return errors.New("alert_at_percent must be within [0, 100]")
+	}
+	switch b.OnExceed {
+	case "", config.OnExceedBlock:
+	case config.OnExceedDowngrade:
+		if b.FallbackModel == "" {
+			return errors.New("on_exceed downgrade needs a fallback_model")
+		}
+	default:
+		return fmt.Errorf("on_exceed %q is not block or downgrade", b.OnExceed)
+	}
+	for _, rl := range b.Rolling {
+		if _, err := rl.Duration(); err != nil {
+			return err
+		}
+		if rl.LimitUSD <= 0 {
+			return fmt.Errorf("rolling window %s has no limit_usd", rl.Window)
+		}
+	}
+	return nil
+}
+
+// handleAdminBudgets serves the budgets: GET /admin/budgets lists them
+// with their scope's spend today and this month, GET, PUT and DELETE
+// /admin/budgets/{key} read, create or replace, and remove one. A key is
+// an agent name, "global", "provider:<name>" or "model:<name>". Changes
+// apply to the next request and are saved to the config file.
+func (p *Proxy) handleAdminBudgets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	key := adminTail(r.URL.Path, "budgets")
+	if r.URL.Path == adminPrefix+"budgets" {
+		key = ""
+	}
+	budgets := p.hot.Load().Budgets
+	now := time.Now().UTC()
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		list := make([]map[string]any, 0, len(budgets))
+		for _, k := range slices.Sorted(maps.Keys(budgets)) {
+			list = append(list, map[string]any{"key": k, "budget": p.adminBudgetWithSpend(k, budgets[k], now)})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"budgets": list})
+
+	case key != "" && r.Method == http.MethodGet:
+		b, ok := budgets[key]
+		if !ok {
+			http.Error(w, `{"error":"no budget for this key"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"key": key, "budget": p.adminBudgetWithSpend(key, b, now)})
+
+	case key != "" && r.Method == http.MethodPut:
+		if !validBudgetKey(key) {
+			http.Error(w, `{"error":"invalid budget key"}`, http.StatusBadRequest)
+			return
+		}
+		var ab adminBudget
+		if err := json.NewDecoder(r.Body).Decode(&ab); err != nil {
+			http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+			return
+		}
+		b := ab.config()
+		if err := validateBudget(b); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+		if !p.changeBudget(w, key, &b) {
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"key": key, "budget": toAdminBudget(b)})
+
+	case key != "" && r.Method == http.MethodDelete:
+		if _, ok := budgets[key]; !ok {
+			http.Error(w, `{"error":"no budget for this key"}`, http.StatusNotFound)
+			return
+		}
+		if !p.changeBudget(w, key, nil) {
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"key": key, "deleted": true})
+
+	default:
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+func (p *Proxy) adminBudgetWithSpend(key string, b config.Budget, now time.Time) adminBudget {
+	ab := toAdminBudget(b)
+	sc := budgetScope(key)
+	daily, _ := p.dailySpend(sc, now)
+	monthly, _ := p.monthlySpend(sc, now)
+	ab.Spend = &adminBudgetSpend{DailyUSD: daily, MonthlyUSD: monthly}
+	return ab
+}
+
+// changeBudget saves and applies budget under key, or removes key when
+// budget is nil. It writes the error response itself.
+func (p *Proxy) changeBudget(w http.ResponseWriter, key string, budget *config.Budget) bool {
+	p.budgetMu.Lock()
+	defer p.budgetMu.Unlock()
+
+	if p.saveBudget != nil {
+		if err := p.saveBudget(key, budget); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrBudgetInInclude) {
+				status = http.StatusConflict
+			}
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), status)
+			return false
+		}
+	}
+
+	h := *p.hot.Load()
+	h.Budgets = maps.Clone(h.Budgets)
+	if h.Budgets == nil {
+		h.Budgets = config.Budgets{}
+	}
+	if budget == nil {
+		delete(h.Budgets, key)
+	} else {
+		h.Budgets[key] = *budget
+	}
+	p.Reload(h)
+	return true
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+func TestAdminBudgetsAPI(t *testing.T) {
+	p, _ := newTestProxy(t)
+	p.adminToken = "admin-secret"
+	saved := map[string]*config.Budget{}
+	p.saveBudget = func(key string, b *config.Budget) error {
+		if key == "from-include" {
+			return ErrBudgetInInclude
+		}
+		saved[key] = b
+		return nil
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, "/admin/budgets/new-agent", `{"daily_limit_usd":0.5,"rolling":[{"window":"7d","limit_usd":3}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if b := saved["new-agent"]; b == nil || b.DailyLimitUSD != 0.5 || len(b.Rolling) != 1 {
+		t.Fatalf("saved = %+v", b)
+	}
+	if b, ok := p.budgetFor("new-agent", ""); !ok || b.DailyLimitUSD != 0.5 {
+		t.Errorf("budget in effect = %+v, %v", b, ok)
+	}
+
+	w = do(http.MethodPut, "/admin/budgets/model:gpt-4o", `{"daily_limit_usd":50,"on_exceed":"downgrade","fallback_model":"gpt-4o-mini"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT model status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodGet, "/admin/budgets", "")
+	var list struct {
+		Budgets []struct {
+			Key    string      `json:"key"`
+			Budget adminBudget `json:"budget"`
+		} `json:"budgets"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("GET list: %v: %s", err, w.Body.String())
+	}
+	var keys []string
+	for _, b := range list.Budgets {
+		keys = append(keys, b.Key)
+		if b.Budget.Spend == nil {
+			t.Errorf("%s: no spend", b.Key)
+		}
+	}
+	if got := strings.Join(keys, ","); got != "budget-agent,model:gpt-4o,new-agent" {
+		t.Errorf("keys = %s", got)
+	}
+
+	for _, tt := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/admin/budgets/missing", "", http.StatusNotFound},
+		{http.MethodPut, "/admin/budgets/bad%20name", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/budgets/providers", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/budgets/a1", `{"daily_limit_usd":-1}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/budgets/a1", `{"on_exceed":"downgrade"}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/budgets/from-include", `{"daily_limit_usd":1}`, http.StatusConflict},
+		{http.MethodDelete, "/admin/budgets/missing", "", http.StatusNotFound},
+		{http.MethodPost, "/admin/budgets", `{}`, http.StatusMethodNotAllowed},
+	} {
+		if w := do(tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+		}
+	}
+
+	if w := do(http.MethodDelete, "/admin/budgets/new-agent", ""); w.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d", w.Code)
+	}
+	if b, ok := saved["new-agent"]; !ok || b != nil {
+		t.Errorf("DELETE not saved: %+v", b)
+	}
+	if _, ok := p.budgetFor("new-agent", ""); ok {
+		t.Error("deleted budget still in effect")
+	}
+}
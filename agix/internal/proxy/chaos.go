@@ -0,0 +1,34 @@
+package proxy
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/agent-platform/agix/internal/chaos"
+)
+
+// injectFault applies chaos mode to one upstream call made for r. For
+// requests that opted in with the X-Chaos header it may sleep first and
+// may return a synthetic error response in place of the real call. A nil
+// response and error mean the call should go ahead.
+func (p *Proxy) injectFault(r, upstreamReq *http.Request, provider string) (*http.Response, error) {
+	if p.chaos == nil || r.Header.Get(chaos.Header) == "" {
+		return nil, nil
+	}
+	o := p.chaos.Roll(provider)
+	if o.Delay > 0 {
+		t := time.NewTimer(o.Delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+		}
+	}
+	if o.StatusCode == 0 {
+		return nil, nil
+	}
+	log.Printf("CHAOS: injected %d for %s (request %s)", o.StatusCode, provider, requestIDFrom(r))
+	return chaos.Response(upstreamReq, o.StatusCode), nil
+}
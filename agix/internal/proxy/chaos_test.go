@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/chaos"
+	"github.com/agent-platform/agix/internal/failover"
+)
+
+func TestChaosInjection(t *testing.T) {
+	tests := []struct {
+		name       string
+		chaos      bool // send the X-Chaos header
+		failover   bool
+		wantStatus int
+		wantCalls  int
+		wantFault  string
+	}{
+		{"no header", false, false, http.StatusOK, 1, ""},
+		{"injected", true, false, http.StatusServiceUnavailable, 0, "server_error"},
+		{"failover to a healthy provider", true, true, http.StatusOK, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			WithChaos(chaos.New(map[string]chaos.Fault{"openai": {ServerError: 1}}))(p)
+			if tt.failover {
+				p.failover = failover.New(failover.Config{MaxRetries: 1, Chains: map[string][]string{"gpt-4o": {"deepseek-chat"}}})
+			}
+
+			calls := 0
+			p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				calls++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"hi"}}]}`)),
+					Request:    r,
+				}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+				strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+			if tt.chaos {
+				req.Header.Set(chaos.Header, "1")
+			}
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if got := w.Header().Get(chaos.InjectedHeader); got != tt.wantFault {
+				t.Errorf("%s = %q, want %q", chaos.InjectedHeader, got, tt.wantFault)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/agix/internal/pricing"
+)
+
+// Legacy /v1/completions support: prompt-style requests are rewritten to chat
+// format and served by handleChatCompletions, so routing, budgets, caching
+// and usage tracking apply unchanged; the chat response (OpenAI or Anthropic)
+// is converted back to text_completion format on the way out. Models without
+// a chat endpoint (gpt-3.5-turbo-instruct and friends) are passed through to
+// the provider's completions API instead.
+
+// legacyOnlyFields are completions parameters with no chat equivalent.
+var legacyOnlyFields = []string{"prompt", "suffix", "echo", "best_of", "logprobs"}
+
+// completionToChat converts a legacy completions body to a chat completions
+// body. It also returns the prompt, for echo.
+func completionToChat(body []byte) (chat []byte, prompt string, echo bool, err error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, "", false, fmt.Errorf("invalid JSON in request body")
+	}
+
+	raw, ok := fields["prompt"]
+	if !ok {
+		return nil, "", false, fmt.Errorf("prompt field is required")
+	}
+	if err := json.Unmarshal(raw, &prompt); err != nil {
+		var prompts []string
+		if json.Unmarshal(raw, &prompts) != nil {
+			return nil, "", false, fmt.Errorf("prompt must be a string or an array of strings")
+		}
+		if len(prompts) != 1 {
+			return nil, "", false, fmt.Errorf("only a single prompt is supported, got %d", len(prompts))
+		}
+		prompt = prompts[0]
+	}
+	if v, ok := fields["echo"]; ok {
+		json.Unmarshal(v, &echo)
+	}
+
+	for _, f := range legacyOnlyFields {
+		delete(fields, f)
+	}
+	fields["messages"], _ = json.Marshal([]map[string]string{{"role": "user", "content": prompt}})
+	chat, err = json.Marshal(fields)
+	return chat, prompt, echo, err
+}
+
+type completionChoice struct {
+	Text         string  `json:"text"`
+	Index        int     `json:"index"`
+	Logprobs     any     `json:"logprobs"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+type completionResponse struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"`
+	Created int64              `json:"created"`
+	Model   string             `json:"model"`
+	Choices []completionChoice `json:"choices"`
+	Usage   *completionUsage   `json:"usage,omitempty"`
+	// AgixUsage carries the usage trailer through, when requested.
+	AgixUsage json.RawMessage `json:"agix_usage,omitempty"`
+}
+
+type completionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// chatToCompletion converts a non-streaming chat response, in OpenAI or
+// Anthropic format, to a text_completion response.
+func chatToCompletion(body []byte, prompt string, echo bool) ([]byte, error) {
+	var resp struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+		Model   string `json:"model"`
+		// OpenAI
+		Choices []struct {
+			Index   int `json:"index"`
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason *string `json:"finish_reason"`
+		} `json:"choices"`
+		// Anthropic
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			InputTokens      int `json:"input_tokens"`
+			OutputTokens     int `json:"output_tokens"`
+		} `json:"usage"`
+		AgixUsage json.RawMessage `json:"agix_usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decode chat response: %w", err)
+	}
+
+	out := completionResponse{
+		ID:        resp.ID,
+		Object:    "text_completion",
+		Created:   resp.Created,
+		Model:     resp.Model,
+		AgixUsage: resp.AgixUsage,
+	}
+	if out.Created == 0 {
+		out.Created = time.Now().Unix()
+	}
+	prefix := ""
+	if echo {
+		prefix = prompt
+	}
+	if resp.Choices != nil {
+		for _, c := range resp.Choices {
+			out.Choices = append(out.Choices, completionChoice{Text: prefix + c.Message.Content, Index: c.Index, FinishReason: c.FinishReason})
+		}
+	} else {
+		var text strings.Builder
+		for _, c := range resp.Content {
+			if c.Type == "text" {
+				text.WriteString(c.Text)
+			}
+		}
+		out.Choices = []completionChoice{{Text: prefix + text.String(), FinishReason: anthropicFinishReason(resp.StopReason)}}
+	}
+
+	in, outTokens := resp.Usage.PromptTokens, resp.Usage.CompletionTokens
+	if in == 0 && outTokens == 0 {
+		in, outTokens = resp.Usage.InputTokens, resp.Usage.OutputTokens
+	}
+	if in > 0 || outTokens > 0 {
+		out.Usage = &completionUsage{PromptTokens: in, CompletionTokens: outTokens, TotalTokens: in + outTokens}
+	}
+	return json.Marshal(out)
+}
+
+// anthropicFinishReason maps an Anthropic stop_reason to the OpenAI value.
+func anthropicFinishReason(stop string) *string {
+	var reason string
+	switch stop {
+	case "":
+		return nil
+	case "max_tokens":
+		reason = "length"
+	default:
+		reason = "stop"
+	}
+	return &reason
+}
+
+// chatChunkToCompletion converts one streamed chat chunk to a completion
+// chunk. ok is false for chunks with nothing to forward (Anthropic
+// bookkeeping events).
+func chatChunkToCompletion(data []byte) (out []byte, ok bool) {
+	var chunk struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Index int `json:"index"`
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+			FinishReason *string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage json.RawMessage `json:"usage"`
+		// Anthropic
+		Type  string `json:"type"`
+		Delta struct {
+			Type       string `json:"type"`
+			Text       string `json:"text"`
+			StopReason string `json:"stop_reason"`
+		} `json:"delta"`
+	}
+	if json.Unmarshal(data, &chunk) != nil {
+		return nil, false
+	}
+
+	resp := map[string]any{"object": "text_completion", "created": chunk.Created, "model": chunk.Model}
+	if chunk.ID != "" {
+		resp["id"] = chunk.ID
+	}
+	if chunk.Created == 0 {
+		resp["created"] = time.Now().Unix()
+	}
+	switch chunk.Type {
+	case "":
+		choices := make([]completionChoice, 0, len(chunk.Choices))
+		for _, c := range chunk.Choices {
+			choices = append(choices, completionChoice{Text: c.Delta.Content, Index: c.Index, FinishReason: c.FinishReason})
+		}
+		resp["choices"] = choices
+		if len(chunk.Usage) > 0 && string(chunk.Usage) != "null" {
+			resp["usage"] = chunk.Usage
+		}
+	case "content_block_delta":
+		if chunk.Delta.Type != "text_delta" {
+			return nil, false
+		}
+		resp["choices"] = []completionChoice{{Text: chunk.Delta.Text}}
+	case "message_delta":
+		resp["choices"] = []completionChoice{{FinishReason: anthropicFinishReason(chunk.Delta.StopReason)}}
+	default:
+		return nil, false
+	}
+	out, err := json.Marshal(resp)
+	return out, err == nil
+}
+
+// completionWriter converts the chat response written by
+// handleChatCompletions to completions format.
+type completionWriter struct {
+	sseConvertWriter
+	prompt string
+	echo   bool
+	echoed bool
+}
+
+func newCompletionWriter(w http.ResponseWriter, prompt string, echo bool) *completionWriter {
+	cw := &completionWriter{prompt: prompt, echo: echo}
+	cw.sseConvertWriter = sseConvertWriter{w: w, line: cw.writeLine, body: func(body []byte) ([]byte, error) {
+		return chatToCompletion(body, cw.prompt, cw.echo)
+	}}
+	return cw
+}
+
+func (cw *completionWriter) writeLine(line string) {
+	data, isData := strings.CutPrefix(line, "data: ")
+	switch {
+	case strings.HasPrefix(line, "event: "):
+		// Anthropic event names; completions streams carry data lines only.
+	case !isData || data == "[DONE]":
+		io.WriteString(cw.w, line+"\n")
+	default:
+		out, ok := chatChunkToCompletion([]byte(data))
+		if !ok {
+			return
+		}
+		if cw.echo && !cw.echoed {
+			cw.echoed = true
+			first, _ := json.Marshal(map[string]any{"object": "text_completion", "created": time.Now().Unix(),
+				"choices": []completionChoice{{Text: cw.prompt}}})
+			fmt.Fprintf(cw.w, "data: %s\n\n", first)
+		}
+		fmt.Fprintf(cw.w, "data: %s\n", out)
+	}
+}
+
+// handleCompletions serves the legacy POST /v1/completions endpoint.
+func (p *Proxy) handleCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	var req struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(body, &req) == nil && pricing.IsCompletionModel(req.Model) {
+		p.passthroughCompletions(w, r, body, req.Model)
+		return
+	}
+
+	chat, prompt, echo, err := completionToChat(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	r2 := r.Clone(r.Context())
+	r2.Body = io.NopCloser(bytes.NewReader(chat))
+	r2.ContentLength = int64(len(chat))
+	cw := newCompletionWriter(w, prompt, echo)
+	p.handleChatCompletions(cw, r2)
+	cw.finish()
+}
+
+// passthroughCompletions forwards a completions request for a
+// completions-only model unchanged, with the same rate limits, budgets,
+// traces and usage recording as chat requests.
+func (p *Proxy) passthroughCompletions(w http.ResponseWriter, r *http.Request, body []byte, model string) {
+	requestID := requestIDFor(r)
+	w.Header().Set("X-Request-ID", requestID)
+	r = p.withArchive(withRequestID(r, requestID))
+
+	provider := pricing.ProviderForModel(model)
+	agentName := r.Header.Get("X-Agent-Name")
+	upstreamURL, upstreamHeaders, upstreamBody, err := p.openAIEndpoint(provider, model, "/completions", body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	upstreamBody = requestStreamUsage(upstreamBody)
+
+	tr := p.newTrace()
+	if tr != nil {
+		tr.AgentName = agentName
+		tr.Model = model
+		tr.RequestID = requestID
+		w.Header().Set("X-Trace-ID", tr.ID)
+		defer p.persistTrace(tr)
+	}
+
+	budget, ok := p.admit(w, r, agentName, provider, model, estimateUpstreamTokens(upstreamBody), tr)
+	if !ok {
+		return
+	}
+	p.auditContent(r, "request", model, agentName, body)
+	archiveRequest(r, body)
+
+	if err := p.checkQuota(r.Context(), provider, model, estimateUpstreamTokens(upstreamBody)); err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+
+	sp := tr.StartSpan("upstream")
+	start := time.Now()
+	upstreamReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, upstreamURL, bytes.NewReader(upstreamBody))
+	if err != nil {
+		sp.End()
+		http.Error(w, `{"error":"failed to create upstream request"}`, http.StatusInternalServerError)
+		return
+	}
+	for k, v := range upstreamHeaders {
+		upstreamReq.Header.Set(k, v)
+	}
+	resp, err := p.client.Do(upstreamReq)
+	if err != nil {
+		sp.Set("provider", provider).End()
+		writeUpstreamError(w, err)
+		return
+	}
+	defer resp.Body.Close()
+	p.providerLimits.Observe(provider, model, resp.Header, time.Now())
+	p.observeKey(provider, upstreamReq, resp)
+	duration := time.Since(start)
+	sp.Set("provider", provider).Set("status", resp.StatusCode).End()
+
+	if isStreaming(body) {
+		p.handleStreamingResponse(w, r, resp, model, provider, agentName, start, duration, budget)
+		return
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read upstream response"}`, http.StatusBadGateway)
+		return
+	}
+	p.writeNonStreamingResponse(w, r, resp, respBody, model, provider, agentName, start, duration, budget, "", "")
+}
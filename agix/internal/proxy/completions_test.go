@@ -0,0 +1,260 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// stubUpstream makes p answer every upstream call with body, recording the
+// last upstream request body.
+func stubUpstream(p *Proxy, contentType, body string, sent *string) {
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(r.Body)
+		*sent = string(b)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {contentType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+}
+
+func TestCompletionToChat(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantMsgs string
+		wantEcho bool
+		wantErr  bool
+	}{
+		{"string prompt", `{"model":"gpt-4o","prompt":"Say hi","max_tokens":5,"logprobs":2}`, `[{"content":"Say hi","role":"user"}]`, false, false},
+		{"single-element array", `{"model":"gpt-4o","prompt":["Say hi"],"echo":true}`, `[{"content":"Say hi","role":"user"}]`, true, false},
+		{"multiple prompts", `{"model":"gpt-4o","prompt":["a","b"]}`, "", false, true},
+		{"missing prompt", `{"model":"gpt-4o"}`, "", false, true},
+		{"bad prompt type", `{"model":"gpt-4o","prompt":42}`, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, _, echo, err := completionToChat([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("completionToChat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var fields map[string]json.RawMessage
+			json.Unmarshal(chat, &fields)
+			if string(fields["messages"]) != tt.wantMsgs {
+				t.Errorf("messages = %s, want %s", fields["messages"], tt.wantMsgs)
+			}
+			for _, f := range legacyOnlyFields {
+				if _, ok := fields[f]; ok {
+					t.Errorf("legacy field %q not removed", f)
+				}
+			}
+			if echo != tt.wantEcho {
+				t.Errorf("echo = %v, want %v", echo, tt.wantEcho)
+			}
+		})
+	}
+}
+
+func TestChatToCompletion(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		echo       bool
+		wantText   string
+		wantFinish string
+		wantTokens int
+	}{
+		{"openai", `{"id":"c1","created":1,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2}}`, false, "Hi!", "stop", 5},
+		{"openai echo", `{"choices":[{"index":0,"message":{"content":" there"},"finish_reason":"length"}]}`, true, "Say hi there", "length", 0},
+		{"anthropic", `{"id":"m1","model":"claude-sonnet-4-6","content":[{"type":"thinking","thinking":"..."},{"type":"text","text":"Hello"}],"stop_reason":"max_tokens","usage":{"input_tokens":4,"output_tokens":1}}`, false, "Hello", "length", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := chatToCompletion([]byte(tt.body), "Say hi", tt.echo)
+			if err != nil {
+				t.Fatalf("chatToCompletion() error: %v", err)
+			}
+			var resp completionResponse
+			json.Unmarshal(out, &resp)
+			if resp.Object != "text_completion" || len(resp.Choices) != 1 {
+				t.Fatalf("response = %s", out)
+			}
+			c := resp.Choices[0]
+			if c.Text != tt.wantText || c.FinishReason == nil || *c.FinishReason != tt.wantFinish {
+				t.Errorf("choice = %+v, want text %q finish %q", c, tt.wantText, tt.wantFinish)
+			}
+			if tt.wantTokens > 0 && (resp.Usage == nil || resp.Usage.TotalTokens != tt.wantTokens) {
+				t.Errorf("usage = %+v, want total %d", resp.Usage, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestCompletionsEndpoint(t *testing.T) {
+	p, st := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "application/json",
+		`{"id":"c1","created":1,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2}}`, &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"model":"gpt-4o","prompt":"Say hi","max_tokens":5}`))
+	req.Header.Set("X-Agent-Name", "legacy-agent")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(sent, `"messages":[{"content":"Say hi","role":"user"}]`) || strings.Contains(sent, "prompt") {
+		t.Errorf("upstream body = %s", sent)
+	}
+	var resp completionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Choices) != 1 || resp.Choices[0].Text != "Hi!" {
+		t.Errorf("response = %s", w.Body.String())
+	}
+	if w.Header().Get("X-Input-Tokens") != "3" || w.Header().Get("X-Output-Tokens") != "2" {
+		t.Errorf("usage headers = %s/%s", w.Header().Get("X-Input-Tokens"), w.Header().Get("X-Output-Tokens"))
+	}
+
+	// Usage is recorded against the agent like any chat request; the async
+	// writer flushes within a second.
+	var records []store.Record
+	var err error
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, err = st.QueryRequestsByRequestID(w.Header().Get("X-Request-ID"))
+	}
+	if err != nil || len(records) != 1 || records[0].AgentName != "legacy-agent" || records[0].InputTokens != 3 {
+		t.Errorf("recorded = %+v, %v", records, err)
+	}
+}
+
+func TestCompletionsEndpointStreaming(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "text/event-stream", strings.Join([]string{
+		`data: {"id":"c1","created":1,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant"}}]}`, "",
+		`data: {"id":"c1","created":1,"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"Hi"}}]}`, "",
+		`data: {"id":"c1","created":1,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`, "",
+		"data: [DONE]", "",
+	}, "\n"), &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"model":"gpt-4o","prompt":"Say hi","stream":true}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `"text":"Hi"`) || !strings.Contains(body, `"object":"text_completion"`) {
+		t.Errorf("stream = %d %s", w.Code, body)
+	}
+	if strings.Contains(body, `"delta"`) || !strings.HasSuffix(body, "data: [DONE]\n") {
+		t.Errorf("stream not converted:\n%s", body)
+	}
+}
+
+func TestCompletionsPassthrough(t *testing.T) {
+	p, st := newTestProxy(t)
+	var sent, url string
+	stubUpstream(p, "application/json",
+		`{"id":"cmpl-1","object":"text_completion","model":"gpt-3.5-turbo-instruct","choices":[{"text":"Hi!","index":0,"logprobs":null,"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2}}`, &sent)
+	inner := p.client.Transport
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		url = r.URL.String()
+		return inner.RoundTrip(r)
+	})
+
+	body := `{"model":"gpt-3.5-turbo-instruct","prompt":["a","b"],"suffix":"!","best_of":2}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "instruct-agent")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	// Completions-only models skip the chat conversion, so features it
+	// rejects (multiple prompts, suffix, best_of) reach the provider.
+	if url != "https://api.openai.com/v1/completions" || sent != body {
+		t.Errorf("upstream = %s %s", url, sent)
+	}
+	if !strings.Contains(w.Body.String(), `"text":"Hi!"`) || w.Header().Get("X-Output-Tokens") != "2" {
+		t.Errorf("response = %s, headers %v", w.Body.String(), w.Header())
+	}
+
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.QueryRequestsByRequestID(w.Header().Get("X-Request-ID"))
+	}
+	if len(records) != 1 || records[0].AgentName != "instruct-agent" || records[0].OutputTokens != 2 || records[0].CostUSD <= 0 {
+		t.Errorf("recorded = %+v", records)
+	}
+}
+
+func TestCompletionsPassthroughStreaming(t *testing.T) {
+	p, st := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "text/event-stream", strings.Join([]string{
+		`data: {"id":"cmpl-1","object":"text_completion","choices":[{"text":"Hi","index":0,"finish_reason":null}]}`, "",
+		`data: {"id":"cmpl-1","object":"text_completion","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1}}`, "",
+		"data: [DONE]", "",
+	}, "\n"), &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"model":"gpt-3.5-turbo-instruct","prompt":"Say hi","stream":true}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if !strings.Contains(sent, `"stream_options":{"include_usage":true}`) {
+		t.Errorf("upstream body = %s", sent)
+	}
+	if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, `"text":"Hi"`) || !strings.HasSuffix(body, "data: [DONE]\n") {
+		t.Errorf("stream = %d %s", w.Code, body)
+	}
+
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.QueryRequestsByRequestID(w.Header().Get("X-Request-ID"))
+	}
+	if len(records) != 1 || records[0].InputTokens != 3 || records[0].TTFTMS == 0 {
+		t.Errorf("recorded = %+v", records)
+	}
+}
+
+func TestCompletionsEndpointErrors(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/completions", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"model":"gpt-4o"}`))
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "prompt") {
+		t.Errorf("missing prompt = %d %s", w.Code, w.Body.String())
+	}
+
+	// Errors from the chat pipeline pass through unconverted.
+	req = httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"model":"llama-3-70b","prompt":"hi"}`))
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusBadGateway || strings.Contains(w.Body.String(), "text_completion") {
+		t.Errorf("unsupported provider = %d %s", w.Code, w.Body.String())
+	}
+}
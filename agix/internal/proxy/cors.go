@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// corsRequestHeaders are the request headers browsers may always send: the
+// standard ones plus every header agix reads.
+var corsRequestHeaders = []string{
+	"Authorization", "Content-Type",
+	"X-Agent-Name", "X-Session-ID", "X-Trace-ID", "X-Request-ID",
+	"X-Force-Model", "X-No-Route", "X-No-Experiment", "X-Usage-Trailer",
+	"X-Failover", "X-Max-Retries",
+	"X-Priority", "X-Queue-Callback", "X-Chaos", "X-Request-Tags", "X-Project",
+}
+
+// corsExposedHeaders are the agix response headers scripts may read.
+var corsExposedHeaders = []string{
+	"X-Request-ID", "X-Trace-ID", "X-Cost-USD", "X-Input-Tokens", "X-Output-Tokens",
+	"X-Cache", "X-Budget-Daily-Percent", "X-Budget-Monthly-Percent",
+	"X-Firewall-Warning", "X-Quality-Warning", "X-Queue-Id", "X-Chaos-Injected",
+}
+
+// handleCORS adds CORS headers for allowed origins and answers preflight
+// requests. It returns true if the request has been fully handled.
+func (p *Proxy) handleCORS(w http.ResponseWriter, r *http.Request) bool {
+	c := p.cfg.CORS
+	origin := r.Header.Get("Origin")
+	if !c.Enabled || origin == "" {
+		return false
+	}
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+	if !originAllowed(c.AllowedOrigins, origin) {
+		if preflight {
+			http.Error(w, `{"error":"origin not allowed"}`, http.StatusForbidden)
+			return true
+		}
+		// Serve the request without CORS headers; the browser blocks the
+		// response.
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+	if !preflight {
+		h.Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		return false
+	}
+
+	maxAge := c.MaxAge
+	if maxAge <= 0 {
+		maxAge = 600
+	}
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	headers := append(slices.Clone(corsRequestHeaders), c.AllowedHeaders...)
+	h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	h.Set("Access-Control-Max-Age", strconv.Itoa(maxAge))
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// originAllowed reports whether origin matches one of the allowed patterns:
+// "*", an exact origin, or "scheme://host:*" for any port on a host.
+func originAllowed(allowed []string, origin string) bool {
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(a, origin) {
+			return true
+		}
+		base, ok := strings.CutSuffix(a, ":*")
+		if !ok || len(origin) < len(base) || !strings.EqualFold(origin[:len(base)], base) {
+			continue
+		}
+		rest := origin[len(base):]
+		if rest == "" {
+			return true
+		}
+		if port, ok := strings.CutPrefix(rest, ":"); ok {
+			if _, err := strconv.Atoi(port); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
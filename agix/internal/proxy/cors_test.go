@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://app.example.com", "http://localhost:*"}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"https://evil.example.com", false},
+		{"http://localhost", true},
+		{"http://localhost:3000", true},
+		{"http://localhost:abc", false},
+		{"http://localhost.evil.com", false},
+		{"http://localhost:3000.evil.com", false},
+		{"https://localhost:3000", false},
+	}
+	for _, tt := range tests {
+		if got := originAllowed(allowed, tt.origin); got != tt.want {
+			t.Errorf("originAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+	if !originAllowed([]string{"*"}, "https://anything.dev") {
+		t.Error("* should allow any origin")
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		origin     string
+		wantStatus int
+		wantAllow  string
+	}{
+		{"allowed origin", true, "http://localhost:5173", http.StatusNoContent, "http://localhost:5173"},
+		{"disallowed origin", true, "https://evil.example.com", http.StatusForbidden, ""},
+		{"disabled", false, "http://localhost:5173", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			p.cfg.CORS.Enabled = tt.enabled
+			p.cfg.CORS.AllowedOrigins = []string{"http://localhost:*"}
+			p.cfg.CORS.AllowedHeaders = []string{"X-Custom"}
+
+			req := httptest.NewRequest(http.MethodOptions, "/v1/chat/completions", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "x-agent-name, x-session-id")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantStatus == http.StatusNoContent {
+				headers := w.Header().Get("Access-Control-Allow-Headers")
+				for _, h := range []string{"X-Agent-Name", "X-Session-ID", "Authorization", "X-Custom"} {
+					if !strings.Contains(headers, h) {
+						t.Errorf("Allow-Headers %q missing %s", headers, h)
+					}
+				}
+				if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+					t.Errorf("Max-Age = %q, want 600", got)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	p, _ := newTestProxy(t)
+	p.cfg.CORS.Enabled = true
+	p.cfg.CORS.AllowedOrigins = []string{"https://app.example.com"}
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+	if !strings.Contains(w.Header().Get("Access-Control-Expose-Headers"), "X-Cost-USD") {
+		t.Errorf("Expose-Headers = %q, want X-Cost-USD", w.Header().Get("Access-Control-Expose-Headers"))
+	}
+}
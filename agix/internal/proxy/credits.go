@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/credits"
+)
+
+// creditGrantsShown is how many recent grants GET /admin/credits/{agent} lists.
+const creditGrantsShown = 20
+
+// handleCredits serves the prepaid credit API:
+//
+//	GET  /admin/credits/{agent}  balance and recent grants
+//	POST /admin/credits/{agent}  top up: {"amount_usd": 50, "note": "..."}
+func (p *Proxy) handleCredits(w http.ResponseWriter, r *http.Request) {
+	if p.credits == nil {
+		http.Error(w, `{"error":"credits not enabled"}`, http.StatusNotFound)
+		return
+	}
+	agent := adminTail(r.URL.Path, "credits")
+	if agent == "" || strings.Contains(agent, "/") {
+		http.Error(w, `{"error":"agent name required"}`, http.StatusBadRequest)
+		return
+	}
+
+	var grant *credits.Grant
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			AmountUSD float64 `json:"amount_usd"`
+			Note      string  `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+			return
+		}
+		g, err := p.credits.TopUp(agent, req.AmountUSD, "api", req.Note)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusBadRequest)
+			return
+		}
+		grant = g
+	default:
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	bal, err := p.credits.Balance(agent)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	out := struct {
+		credits.Balance
+		Grant  *credits.Grant  `json:"grant,omitempty"`
+		Grants []credits.Grant `json:"grants,omitempty"`
+	}{Balance: bal, Grant: grant}
+	status := http.StatusCreated
+	if grant == nil {
+		status = http.StatusOK
+		if out.Grants, err = p.credits.Grants(agent, creditGrantsShown); err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,222 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/credits"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func newCreditProxy(t *testing.T) (*Proxy, *store.Store, *credits.Ledger) {
+	t.Helper()
+	p, st := newTestProxy(t)
+	ledger, err := credits.New(st)
+	if err != nil {
+		t.Fatalf("credits.New: %v", err)
+	}
+	p.credits = ledger
+	p.cfg.Budgets["credit-agent"] = config.Budget{Credits: true}
+	return p, st, ledger
+}
+
+func TestCheckBudgetCredits(t *testing.T) {
+	p, st, ledger := newCreditProxy(t)
+
+	if err := p.checkBudget("credit-agent", "", "openai", ""); err == nil || !strings.Contains(err.Error(), "prepaid credit exhausted") {
+		t.Fatalf("checkBudget() without credit = %v, want credit exhausted", err)
+	}
+
+	if _, err := ledger.TopUp("credit-agent", 1, "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.checkBudget("credit-agent", "", "openai", ""); err != nil {
+		t.Fatalf("checkBudget() with credit = %v", err)
+	}
+
+	st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "credit-agent", Model: "gpt-4o", Provider: "openai",
+		CostUSD: 1.5, StatusCode: 200,
+	})
+	if err := p.checkBudget("credit-agent", "", "openai", ""); err == nil {
+		t.Fatal("checkBudget() after spending the credit succeeded, want error")
+	}
+
+	snap := p.snapshotBudget("credit-agent", "", "openai", "")
+	h := http.Header{}
+	p.reportBudget(h, snap, 0.25)
+	if got := h.Get("X-Credit-Remaining-USD"); got != "-0.7500" {
+		t.Errorf("X-Credit-Remaining-USD = %q, want -0.7500", got)
+	}
+}
+
+func TestCheckBudgetRollover(t *testing.T) {
+	now := time.Now().UTC()
+	if now.Day() == 1 {
+		t.Skip("nothing to roll over on the first day of the month")
+	}
+	p, st := newTestProxy(t)
+	p.cfg.Budgets["budget-agent"] = config.Budget{DailyLimitUSD: 10, Rollover: true}
+
+	// $12 today is over the plain daily limit but within today's allowance,
+	// as nothing was spent earlier in the month.
+	st.Insert(&store.Record{
+		Timestamp: now, AgentName: "budget-agent", Model: "gpt-4o", Provider: "openai",
+		CostUSD: 12, StatusCode: 200,
+	})
+	if err := p.checkBudget("budget-agent", "", "openai", ""); err != nil {
+		t.Fatalf("checkBudget() with rollover = %v", err)
+	}
+
+	p.cfg.Budgets["budget-agent"] = config.Budget{DailyLimitUSD: 10}
+	if err := p.checkBudget("budget-agent", "", "openai", ""); err == nil {
+		t.Fatal("checkBudget() without rollover succeeded, want daily limit error")
+	}
+}
+
+func TestCheckBudgetGlobalAndProvider(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.cfg.Budgets[config.ProviderBudget("openai")] = config.Budget{DailyLimitUSD: 5}
+	p.cfg.Budgets[config.GlobalBudget] = config.Budget{DailyLimitUSD: 8}
+
+	st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "agent-a", Model: "gpt-4o", Provider: "openai",
+		CostUSD: 6, StatusCode: 200,
+	})
+	if err := p.checkBudget("agent-b", "", "openai", ""); err == nil || !strings.HasPrefix(err.Error(), "provider openai ") {
+		t.Errorf("checkBudget(openai) = %v, want provider budget error", err)
+	}
+	if err := p.checkBudget("agent-b", "", "anthropic", ""); err != nil {
+		t.Errorf("checkBudget(anthropic) = %v, want nil", err)
+	}
+
+	// Requests without an agent name count against the global budget too.
+	st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), Model: "claude-sonnet-4", Provider: "anthropic",
+		CostUSD: 3, StatusCode: 200,
+	})
+	if err := p.checkBudget("", "", "anthropic", ""); err == nil || !strings.HasPrefix(err.Error(), "gateway ") {
+		t.Errorf("checkBudget() over the global budget = %v, want gateway budget error", err)
+	}
+
+	snap := p.snapshotBudget("", "", "openai", "")
+	h := http.Header{}
+	p.reportBudget(h, snap, 0)
+	if len(h) != 0 {
+		t.Errorf("gateway budgets reported to the caller: %v", h)
+	}
+}
+
+func TestCheckBudgetModel(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.cfg.Budgets[config.ModelBudget("gpt-4o")] = config.Budget{DailyLimitUSD: 50}
+
+	st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "agent-a", Model: "gpt-4o", Provider: "openai",
+		CostUSD: 50, StatusCode: 200,
+	})
+	if err := p.checkBudget("agent-b", "", "openai", "gpt-4o"); err == nil || !strings.HasPrefix(err.Error(), "model gpt-4o daily limit") {
+		t.Errorf("checkBudget(gpt-4o) = %v, want model budget error", err)
+	}
+	// A cheaper model of the same provider is still allowed.
+	if err := p.checkBudget("agent-b", "", "openai", "gpt-4o-mini"); err != nil {
+		t.Errorf("checkBudget(gpt-4o-mini) = %v, want nil", err)
+	}
+}
+
+func TestCheckBudgetWeeklyAndRolling(t *testing.T) {
+	p, st := newTestProxy(t)
+	now := time.Now().UTC()
+	st.Insert(&store.Record{
+		Timestamp: now.AddDate(0, 0, -10), AgentName: "window-agent", Model: "gpt-4o", Provider: "openai",
+		CostUSD: 40, StatusCode: 200,
+	})
+	st.Insert(&store.Record{
+		Timestamp: now, AgentName: "window-agent", Model: "gpt-4o", Provider: "openai",
+		CostUSD: 6, StatusCode: 200,
+	})
+
+	tests := []struct {
+		name    string
+		budget  config.Budget
+		wantErr string
+	}{
+		{"weekly under", config.Budget{WeeklyLimitUSD: 10}, ""},
+		{"weekly over", config.Budget{WeeklyLimitUSD: 5}, "weekly limit of $5.00"},
+		{"last 7 days under", config.Budget{Rolling: []config.RollingLimit{{Window: "7d", LimitUSD: 10}}}, ""},
+		{"last 30 days over", config.Budget{Rolling: []config.RollingLimit{{Window: "7d", LimitUSD: 10}, {Window: "30d", LimitUSD: 40}}}, "for the last 30d"},
+		{"last hour over", config.Budget{Rolling: []config.RollingLimit{{Window: "1h", LimitUSD: 5}}}, "for the last 1h"},
+	}
+	for _, tt := range tests {
+		p.cfg.Budgets["window-agent"] = tt.budget
+		err := p.checkBudget("window-agent", "", "openai", "gpt-4o")
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: checkBudget() = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	p.cfg.Budgets["window-agent"] = config.Budget{WeeklyLimitUSD: 10, Rolling: []config.RollingLimit{{Window: "30d", LimitUSD: 92}}}
+	h := http.Header{}
+	p.reportBudget(h, p.snapshotBudget("window-agent", "", "openai", "gpt-4o"), 0)
+	if h.Get("X-Budget-Weekly-Percent") != "60.0" || h.Get("X-Budget-Rolling-Percent") != "50.0" {
+		t.Errorf("headers = %v, want weekly 60%% and rolling 50%%", h)
+	}
+}
+
+func TestCreditsAPI(t *testing.T) {
+	p, _, _ := newCreditProxy(t)
+	p.adminToken = "admin-secret"
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/admin/credits/credit-agent", `{"amount_usd": 25, "note": "Q3 research"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, body %s", w.Code, w.Body)
+	}
+
+	w = do(http.MethodGet, "/admin/credits/credit-agent", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, body %s", w.Code, w.Body)
+	}
+	var got struct {
+		GrantedUSD   float64         `json:"granted_usd"`
+		RemainingUSD float64         `json:"remaining_usd"`
+		Grants       []credits.Grant `json:"grants"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.GrantedUSD != 25 || got.RemainingUSD != 25 || len(got.Grants) != 1 || got.Grants[0].Note != "Q3 research" {
+		t.Errorf("GET = %+v, want one $25 grant", got)
+	}
+
+	for _, tt := range []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"zero amount", http.MethodPost, "/admin/credits/credit-agent", `{"amount_usd": 0}`, http.StatusBadRequest},
+		{"bad JSON", http.MethodPost, "/admin/credits/credit-agent", `{`, http.StatusBadRequest},
+		{"no agent", http.MethodGet, "/admin/credits/", "", http.StatusBadRequest},
+		{"wrong method", http.MethodDelete, "/admin/credits/credit-agent", "", http.StatusMethodNotAllowed},
+	} {
+		if w := do(tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+
+	p.credits = nil
+	if w := do(http.MethodGet, "/admin/credits/credit-agent", ""); w.Code != http.StatusNotFound {
+		t.Errorf("without a ledger: status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/pricing"
+)
+
+// Custom providers are OpenAI-compatible servers registered under
+// `providers:` in config.yaml. They share the OpenAI request and response
+// handling; only the URL, key and model name differ.
+
+// apiFormat returns the wire format spoken by provider. Custom providers
+// speak the OpenAI format; built-in providers are their own format.
+func apiFormat(provider string) string {
+	if pricing.IsCustomProvider(provider) {
+		return "openai"
+	}
+	return provider
+}
+
+// customUpstream returns the URL and body for a model served by the custom
+// provider named provider. A matched prefix ending in "/" is stripped, so
+// "groq/llama-3.1-8b-instant" reaches Groq as "llama-3.1-8b-instant".
+func (p *Proxy) customUpstream(provider, model string, body []byte, headers map[string]string) (string, map[string]string, []byte, error) {
+	for _, pc := range p.cfg.Providers {
+		if pc.Name != provider {
+			continue
+		}
+		apiKey := p.key(pc.Name)
+		if apiKey != "" {
+			headers["Authorization"] = "Bearer " + apiKey
+		}
+		if _, prefix, ok := pricing.CustomProvider(model); ok && strings.HasSuffix(prefix, "/") {
+			body = replaceModel(body, model[len(prefix):])
+		}
+		return strings.TrimRight(pc.BaseURL, "/") + "/chat/completions", headers, requestStreamUsage(body), nil
+	}
+	return "", nil, nil, fmt.Errorf("provider %q not configured", provider)
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/pricing"
+)
+
+func TestCustomProviderChatCompletion(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     string
+		wantURL   string
+		wantAuth  string
+		wantModel string
+	}{
+		{"prefix stripped", "groq/llama-3.1-8b-instant", "https://api.groq.com/openai/v1/chat/completions", "Bearer gsk-test", "llama-3.1-8b-instant"},
+		{"prefix kept", "mistral-large-latest", "https://api.mistral.ai/v1/chat/completions", "Bearer mistral-key", "mistral-large-latest"},
+		{"no key", "vllm/qwen2.5-7b", "http://gpu-box:8000/v1/chat/completions", "", "qwen2.5-7b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			p.cfg.Providers = []config.ProviderConfig{
+				{Name: "groq", BaseURL: "https://api.groq.com/openai/v1", APIKey: "gsk-test"},
+				{Name: "mistral", BaseURL: "https://api.mistral.ai/v1/", Prefixes: []string{"mistral-"}},
+				{Name: "vllm", BaseURL: "http://gpu-box:8000/v1"},
+			}
+			p.cfg.Keys["mistral"] = "mistral-key"
+			p.initialHot.Keys = ProviderKeys(p.cfg)
+			pricing.SetProviders(map[string]string{"groq/": "groq", "mistral-": "mistral", "vllm/": "vllm"})
+			t.Cleanup(func() { pricing.SetProviders(nil) })
+
+			var gotURL, gotAuth string
+			var gotBody struct {
+				Model string `json:"model"`
+			}
+			p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				json.Unmarshal(b, &gotBody)
+				gotURL, gotAuth = r.URL.String(), r.Header.Get("Authorization")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"hi"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`)),
+					Request:    r,
+				}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+				strings.NewReader(`{"model":"`+tt.model+`","messages":[{"role":"user","content":"hi"}]}`))
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if gotURL != tt.wantURL || gotAuth != tt.wantAuth || gotBody.Model != tt.wantModel {
+				t.Errorf("upstream = %s auth=%q model=%q, want %s auth=%q model=%q",
+					gotURL, gotAuth, gotBody.Model, tt.wantURL, tt.wantAuth, tt.wantModel)
+			}
+			if got := w.Header().Get("X-Input-Tokens"); got != "12" {
+				t.Errorf("X-Input-Tokens = %s, want usage parsed in OpenAI format", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Page sizes for GET /v1/data/requests.
+const (
+	defaultDataPageSize = 100
+	maxDataPageSize     = 1000
+)
+
+// dataRecord is a request row as served by the data API.
+type dataRecord struct {
+	ID               int64   `json:"id"`
+	Timestamp        string  `json:"timestamp"`
+	RequestID        string  `json:"request_id,omitempty"`
+	AgentName        string  `json:"agent_name"`
+	Model            string  `json:"model"`
+	Provider         string  `json:"provider"`
+	RequestType      string  `json:"request_type"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	ReasoningTokens  int     `json:"reasoning_tokens"`   // included in output_tokens
+	CacheWriteTokens int     `json:"cache_write_tokens"` // not included in input_tokens
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	DurationMS       int64   `json:"duration_ms"`
+	TTFTMS           int64   `json:"ttft_ms,omitempty"`
+	StatusCode       int     `json:"status_code"`
+	FailoverFrom     string  `json:"failover_from,omitempty"`
+	OriginalModel    string  `json:"original_model,omitempty"`
+}
+
+// handleDataAPI serves read-only usage data to external consumers such as
+// BI tools, authenticated with a data_api key:
+//
+//	GET /v1/data/requests  request rows, oldest first, paged with ?cursor=
+//	GET /v1/data/stats     usage totals ?group_by=agent|model|provider|day
+//
+// Both accept since and until (RFC 3339 or YYYY-MM-DD, default the last 30
+// days), agent, model and provider filters. A key scoped to agents only
+// sees those agents' rows.
+func (p *Proxy) handleDataAPI(w http.ResponseWriter, r *http.Request) {
+	if len(p.cfg.DataAPI.Keys) == 0 {
+		http.Error(w, `{"error":"data API not enabled"}`, http.StatusNotFound)
+		return
+	}
+	key := p.dataAPIKey(r)
+	if key == nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="agix data API"`)
+		http.Error(w, `{"error":"invalid or missing data API key"}`, http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	f, err := dataFilter(r, key)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errAgentOutOfScope) {
+			status = http.StatusForbidden
+		}
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), status)
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/v1/data/") {
+	case "requests":
+		p.serveDataRequests(w, r, f)
+	case "stats":
+		p.serveDataStats(w, r, f)
+	default:
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	}
+}
+
+// dataAPIKey returns the configured key matching the request's bearer
+// token, or nil.
+func (p *Proxy) dataAPIKey(r *http.Request) *config.DataAPIKey {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil
+	}
+	for i, k := range p.cfg.DataAPI.Keys {
+		if k.Key != "" && subtle.ConstantTimeCompare([]byte(token), []byte(k.Key)) == 1 {
+			return &p.cfg.DataAPI.Keys[i]
+		}
+	}
+	return nil
+}
+
+var errAgentOutOfScope = errors.New("agent not readable with this key")
+
+// dataFilter builds the store filter for a data API request, restricted to
+// the agents key may read.
+func dataFilter(r *http.Request, key *config.DataAPIKey) (store.RequestFilter, error) {
+	q := r.URL.Query()
+	now := time.Now().UTC()
+	f := store.RequestFilter{
+		Since:    now.AddDate(0, 0, -30),
+		Until:    now,
+		Model:    q.Get("model"),
+		Provider: q.Get("provider"),
+	}
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		v := q.Get(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", v); err != nil {
+				return f, fmt.Errorf("%s: want RFC 3339 or YYYY-MM-DD", param.name)
+			}
+			if param.name == "until" {
+				t = t.AddDate(0, 0, 1).Add(-time.Second) // through the end of that day
+			}
+		}
+		*param.dst = t
+	}
+
+	agent := q.Get("agent")
+	switch {
+	case agent != "" && len(key.Agents) > 0 && !slices.Contains(key.Agents, agent):
+		return f, errAgentOutOfScope
+	case agent != "":
+		f.Agents = []string{agent}
+	default:
+		f.Agents = key.Agents
+	}
+	return f, nil
+}
+
+func (p *Proxy) serveDataRequests(w http.ResponseWriter, r *http.Request, f store.RequestFilter) {
+	q := r.URL.Query()
+	limit := defaultDataPageSize
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, `{"error":"limit must be a positive integer"}`, http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxDataPageSize)
+	}
+	var after int64
+	if v := q.Get("cursor"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, `{"error":"invalid cursor"}`, http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+
+	// Fetch one extra row to learn whether another page follows.
+	records, err := p.store.QueryRequestPage(f, after, limit+1)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	out := struct {
+		Data       []dataRecord `json:"data"`
+		NextCursor string       `json:"next_cursor,omitempty"`
+	}{Data: []dataRecord{}}
+	if len(records) > limit {
+		records = records[:limit]
+		out.NextCursor = strconv.FormatInt(records[limit-1].ID, 10)
+	}
+	for _, rec := range records {
+		out.Data = append(out.Data, dataRecord{
+			ID:               rec.ID,
+			Timestamp:        rec.Timestamp.Format(time.RFC3339),
+			RequestID:        rec.RequestID,
+			AgentName:        rec.AgentName,
+			Model:            rec.Model,
+			Provider:         rec.Provider,
+			RequestType:      rec.RequestType,
+			InputTokens:      rec.InputTokens,
+			OutputTokens:     rec.OutputTokens,
+			ReasoningTokens:  rec.ReasoningTokens,
+			CacheWriteTokens: rec.CacheWriteTokens,
+			CacheReadTokens:  rec.CacheReadTokens,
+			CostUSD:          rec.CostUSD,
+			DurationMS:       rec.DurationMS,
+			TTFTMS:           rec.TTFTMS,
+			StatusCode:       rec.StatusCode,
+			FailoverFrom:     rec.FailoverFrom,
+			OriginalModel:    rec.OriginalModel,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+func (p *Proxy) serveDataStats(w http.ResponseWriter, r *http.Request, f store.RequestFilter) {
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = "agent"
+	}
+	if !slices.Contains([]string{"agent", "model", "provider", "day"}, groupBy) {
+		http.Error(w, `{"error":"group_by must be agent, model, provider or day"}`, http.StatusBadRequest)
+		return
+	}
+	totals, err := p.store.QueryUsageTotals(f, groupBy)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	out := struct {
+		GroupBy string              `json:"group_by"`
+		Since   string              `json:"since"`
+		Until   string              `json:"until"`
+		Data    []store.UsageTotals `json:"data"`
+	}{GroupBy: groupBy, Since: f.Since.UTC().Format(time.RFC3339), Until: f.Until.UTC().Format(time.RFC3339), Data: totals}
+	if out.Data == nil {
+		out.Data = []store.UsageTotals{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestDataAPI(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.cfg.DataAPI = config.DataAPIConfig{Keys: []config.DataAPIKey{
+		{Name: "bi", Key: "bi-key"},
+		{Name: "support", Key: "support-key", Agents: []string{"support"}},
+	}}
+	now := time.Now().UTC()
+	for i, agent := range []string{"support", "billing", "support"} {
+		st.Insert(&store.Record{
+			Timestamp: now.Add(-time.Duration(i) * time.Minute), AgentName: agent, Model: "gpt-4o", Provider: "openai",
+			InputTokens: 10, OutputTokens: 5, CostUSD: 0.5, StatusCode: 200,
+		})
+	}
+
+	get := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+	type page struct {
+		Data       []dataRecord `json:"data"`
+		NextCursor string       `json:"next_cursor"`
+	}
+
+	// Page through everything two rows at a time.
+	var seen []string
+	cursor := ""
+	for range 3 {
+		w := get("/v1/data/requests?limit=2&cursor="+cursor, "bi-key")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET requests = %d %s", w.Code, w.Body)
+		}
+		var pg page
+		json.Unmarshal(w.Body.Bytes(), &pg)
+		for _, r := range pg.Data {
+			seen = append(seen, r.AgentName)
+		}
+		if cursor = pg.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if len(seen) != 3 || cursor != "" {
+		t.Errorf("paged agents = %v (cursor %q), want all 3 rows", seen, cursor)
+	}
+
+	// A scoped key only sees its own agents.
+	var pg page
+	json.Unmarshal(get("/v1/data/requests", "support-key").Body.Bytes(), &pg)
+	if len(pg.Data) != 2 || pg.Data[0].AgentName != "support" || pg.Data[1].AgentName != "support" {
+		t.Errorf("scoped requests = %+v, want the 2 support rows", pg.Data)
+	}
+	var stats struct {
+		Data []store.UsageTotals `json:"data"`
+	}
+	json.Unmarshal(get("/v1/data/stats?group_by=agent", "support-key").Body.Bytes(), &stats)
+	if len(stats.Data) != 1 || stats.Data[0].Key != "support" || stats.Data[0].CostUSD != 1 {
+		t.Errorf("scoped stats = %+v, want support only", stats.Data)
+	}
+
+	for _, tt := range []struct {
+		name, path, key string
+		want            int
+	}{
+		{"no key", "/v1/data/requests", "", http.StatusUnauthorized},
+		{"wrong key", "/v1/data/requests", "nope", http.StatusUnauthorized},
+		{"agent out of scope", "/v1/data/requests?agent=billing", "support-key", http.StatusForbidden},
+		{"agent in scope", "/v1/data/requests?agent=support", "support-key", http.StatusOK},
+		{"bad since", "/v1/data/stats?since=yesterday", "bi-key", http.StatusBadRequest},
+		{"bad group", "/v1/data/stats?group_by=status", "bi-key", http.StatusBadRequest},
+		{"bad limit", "/v1/data/requests?limit=0", "bi-key", http.StatusBadRequest},
+		{"unknown resource", "/v1/data/traces", "bi-key", http.StatusNotFound},
+	} {
+		if w := get(tt.path, tt.key); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+
+	p.cfg.DataAPI = config.DataAPIConfig{}
+	if w := get("/v1/data/requests", "bi-key"); w.Code != http.StatusNotFound {
+		t.Errorf("without keys: status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/agent-platform/agix/internal/tags"
+)
+
+type budgetDowngradeKey struct{}
+
+// downgradeTag marks the records of requests a budget in downgrade mode
+// sent to its fallback model.
+const downgradeTag = "budget"
+
+// withBudgetDowngrade returns r marked as downgraded by a budget.
+func withBudgetDowngrade(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), budgetDowngradeKey{}, true))
+}
+
+func budgetDowngraded(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	d, _ := r.Context().Value(budgetDowngradeKey{}).(bool)
+	return d
+}
+
+// tagDowngraded adds budget=downgraded to canonical tags.
+func tagDowngraded(canonical string) string {
+	t := tags.Tags{}
+	if canonical != "" {
+		t = tags.Decode(canonical)
+	}
+	t[downgradeTag] = "downgraded"
+	return t.String()
+}
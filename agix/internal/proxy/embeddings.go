@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// handleEmbeddings proxies POST /v1/embeddings unchanged and records input
+// tokens and cost with request type "embedding". Rate limits and budgets
+// apply as for chat requests; the chat pipeline (firewall, cache, routing,
+// failover) does not.
+func (p *Proxy) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := requestIDFor(r)
+	w.Header().Set("X-Request-ID", requestID)
+	r = withRequestID(r, requestID)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, `{"error":"invalid JSON in request body"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Model == "" {
+		http.Error(w, `{"error":"model field is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	provider := pricing.ProviderForModel(req.Model)
+	agentName := r.Header.Get("X-Agent-Name")
+
+	upstreamURL, upstreamHeaders, upstreamBody, err := p.openAIEndpoint(provider, req.Model, "/embeddings", body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	budget, ok := p.admit(w, r, agentName, provider, req.Model, len(upstreamBody)/4, nil)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	resp, respBody, err := p.sendRaw(r, provider, req.Model, upstreamURL, upstreamHeaders, upstreamBody)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	duration := time.Since(start)
+
+	var parsed struct {
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
+	}
+	json.Unmarshal(respBody, &parsed)
+	inputTokens := parsed.Usage.PromptTokens
+	cost := pricing.CalculateCost(req.Model, inputTokens, 0)
+
+	p.recordUsage(r, &store.Record{
+		Timestamp:   start,
+		AgentName:   agentName,
+		Model:       req.Model,
+		Provider:    provider,
+		InputTokens: inputTokens,
+		CostUSD:     cost,
+		DurationMS:  duration.Milliseconds(),
+		StatusCode:  resp.StatusCode,
+		RequestID:   requestID,
+		RequestType: store.RequestTypeEmbedding,
+	})
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("X-Cost-USD", fmt.Sprintf("%.6f", cost))
+	w.Header().Set("X-Input-Tokens", fmt.Sprintf("%d", inputTokens))
+	p.reportBudget(w.Header(), budget, cost)
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
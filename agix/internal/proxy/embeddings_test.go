@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestHandleEmbeddings(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	var sent, url string
+	stubUpstream(p, "application/json", `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":1000,"total_tokens":1000}}`, &sent)
+	inner := p.client.Transport
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		url = r.URL.String()
+		return inner.RoundTrip(r)
+	})
+
+	body := `{"model":"text-embedding-3-small","input":"hello"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/embeddings", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "embed-agent")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if url != "https://api.openai.com/v1/embeddings" {
+		t.Errorf("upstream URL = %q", url)
+	}
+	if sent != body {
+		t.Errorf("upstream body = %s, want unchanged", sent)
+	}
+	if !strings.Contains(w.Body.String(), `"embedding":[0.1,0.2]`) {
+		t.Errorf("response = %s", w.Body.String())
+	}
+	if got := w.Header().Get("X-Input-Tokens"); got != "1000" {
+		t.Errorf("X-Input-Tokens = %q, want 1000", got)
+	}
+	if got := w.Header().Get("X-Cost-USD"); got != "0.000020" {
+		t.Errorf("X-Cost-USD = %q, want 0.000020", got)
+	}
+
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.QueryRecentRequests(1, "embed-agent")
+	}
+	if len(records) != 1 {
+		t.Fatalf("recorded = %+v", records)
+	}
+	r := records[0]
+	if r.RequestType != store.RequestTypeEmbedding || r.InputTokens != 1000 || r.OutputTokens != 0 || r.CostUSD <= 0 {
+		t.Errorf("record = %+v", r)
+	}
+	if r.RequestID == "" || r.RequestID != w.Header().Get("X-Request-ID") {
+		t.Errorf("RequestID = %q, header %q", r.RequestID, w.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestHandleEmbeddingsRejects(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	if err := st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "budget-agent", Model: "gpt-4o", Provider: "openai",
+		InputTokens: 100, OutputTokens: 50, CostUSD: 20.00, DurationMS: 100, StatusCode: 200,
+	}); err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+	var sent string
+	stubUpstream(p, "application/json", `{"data":[]}`, &sent)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		agent  string
+		want   int
+	}{
+		{"wrong method", http.MethodGet, "", "", http.StatusMethodNotAllowed},
+		{"missing model", http.MethodPost, `{"input":"hi"}`, "", http.StatusBadRequest},
+		{"unsupported provider", http.MethodPost, `{"model":"claude-sonnet-4-20250514","input":"hi"}`, "", http.StatusBadRequest},
+		{"over budget", http.MethodPost, `{"model":"text-embedding-3-small","input":"hi"}`, "budget-agent", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/embeddings", strings.NewReader(tt.body))
+			if tt.agent != "" {
+				req.Header.Set("X-Agent-Name", tt.agent)
+			}
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+	if sent != "" {
+		t.Errorf("rejected requests reached upstream: %s", sent)
+	}
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/failover"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestFailoverOverrideHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+		wantCalls  int
+	}{
+		{"default chain", nil, http.StatusOK, 2},
+		{"off", map[string]string{"X-Failover": "off"}, http.StatusServiceUnavailable, 1},
+		{"zero retries", map[string]string{"X-Max-Retries": "0"}, http.StatusServiceUnavailable, 1},
+		{"chain not permitted", map[string]string{"X-Failover": "cheap"}, http.StatusBadRequest, 0},
+		{"retries not permitted", map[string]string{"X-Max-Retries": "5"}, http.StatusBadRequest, 0},
+		{"permitted chain", map[string]string{"X-Agent-Name": "batch", "X-Failover": "cheap"}, http.StatusOK, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			p.failover = failover.New(failover.Config{
+				MaxRetries: 1,
+				Chains: map[string][]string{
+					"gpt-4o": {"gpt-4o-mini"},
+					"cheap":  {"deepseek-chat"},
+				},
+				Agents: map[string]failover.AgentPolicy{"batch": {Chains: []string{"cheap"}}},
+			})
+
+			calls := 0
+			p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				calls++
+				status := http.StatusOK
+				if calls == 1 {
+					status = http.StatusServiceUnavailable
+				}
+				return &http.Response{
+					StatusCode: status,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"hi"}}]}`)),
+					Request:    r,
+				}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+				strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestFailoverSkipsModelsOverBudget(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.failover = failover.New(failover.Config{
+		MaxRetries: 2,
+		Chains:     map[string][]string{"gpt-4o": {"gpt-4o-mini", "deepseek-chat"}},
+	})
+	p.cfg.Budgets[config.ModelBudget("gpt-4o-mini")] = config.Budget{DailyLimitUSD: 1}
+	st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "app", Model: "gpt-4o-mini", Provider: "openai",
+		CostUSD: 2, StatusCode: 200,
+	})
+
+	var models []string
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		models = append(models, body.Model)
+		status := http.StatusOK
+		if len(models) == 1 {
+			status = http.StatusServiceUnavailable
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"hi"}}]}`)),
+			Request:    r,
+		}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+	req.Header.Set("X-Agent-Name", "app")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Join(models, ",") != "gpt-4o,deepseek-chat" {
+		t.Errorf("upstream models = %v, want gpt-4o then deepseek-chat", models)
+	}
+}
@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// defaultImageModel is the model OpenAI uses when a generation request
+// doesn't name one.
+const defaultImageModel = "dall-e-2"
+
+// imageRequest holds the fields of an image generation request that affect
+// its price.
+type imageRequest struct {
+	Model   string `json:"model"`
+	Size    string `json:"size"`
+	Quality string `json:"quality"`
+}
+
+// handleImageGenerations proxies POST /v1/images/generations unchanged and
+// records the per-image cost (by model, size and quality) with request type
+// "image", so image-heavy agents count against their budgets. Rate limits
+// and budgets apply as for chat requests.
+func (p *Proxy) handleImageGenerations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := requestIDFor(r)
+	w.Header().Set("X-Request-ID", requestID)
+	r = withRequestID(r, requestID)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req imageRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, `{"error":"invalid JSON in request body"}`, http.StatusBadRequest)
+		return
+	}
+	model := req.Model
+	if model == "" {
+		model = defaultImageModel
+	}
+	provider := pricing.ProviderForModel(model)
+	agentName := r.Header.Get("X-Agent-Name")
+
+	upstreamURL, upstreamHeaders, upstreamBody, err := p.openAIEndpoint(provider, model, "/images/generations", body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	budget, ok := p.admit(w, r, agentName, provider, model, 0, nil)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	resp, respBody, err := p.sendRaw(r, provider, model, upstreamURL, upstreamHeaders, upstreamBody)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	duration := time.Since(start)
+
+	// Bill the images actually returned; gpt-image models also report tokens.
+	var parsed struct {
+		Data  []json.RawMessage `json:"data"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	json.Unmarshal(respBody, &parsed)
+	var cost float64
+	if resp.StatusCode < 400 {
+		cost = pricing.ImageCost(model, req.Size, req.Quality, len(parsed.Data))
+	}
+
+	p.recordUsage(r, &store.Record{
+		Timestamp:    start,
+		AgentName:    agentName,
+		Model:        model,
+		Provider:     provider,
+		InputTokens:  parsed.Usage.InputTokens,
+		OutputTokens: parsed.Usage.OutputTokens,
+		CostUSD:      cost,
+		DurationMS:   duration.Milliseconds(),
+		StatusCode:   resp.StatusCode,
+		RequestID:    requestID,
+		RequestType:  store.RequestTypeImage,
+	})
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("X-Cost-USD", fmt.Sprintf("%.6f", cost))
+	w.Header().Set("X-Images", fmt.Sprintf("%d", len(parsed.Data)))
+	p.reportBudget(w.Header(), budget, cost)
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestHandleImageGenerations(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	var sent, url string
+	stubUpstream(p, "application/json", `{"created":1,"data":[{"url":"https://img/1"},{"url":"https://img/2"}]}`, &sent)
+	inner := p.client.Transport
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		url = r.URL.String()
+		return inner.RoundTrip(r)
+	})
+
+	body := `{"model":"dall-e-3","prompt":"a cat","n":2,"size":"1792x1024","quality":"hd"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "artist")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if url != "https://api.openai.com/v1/images/generations" || sent != body {
+		t.Errorf("upstream = %s %s", url, sent)
+	}
+	if got := w.Header().Get("X-Cost-USD"); got != "0.240000" {
+		t.Errorf("X-Cost-USD = %q, want 0.240000", got)
+	}
+	if got := w.Header().Get("X-Images"); got != "2" {
+		t.Errorf("X-Images = %q, want 2", got)
+	}
+
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.QueryRecentRequests(1, "artist")
+	}
+	if len(records) != 1 || records[0].RequestType != store.RequestTypeImage || records[0].CostUSD < 0.239 || records[0].Model != "dall-e-3" {
+		t.Errorf("recorded = %+v", records)
+	}
+}
+
+func TestHandleImageGenerationsRejects(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	if err := st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "budget-agent", Model: "gpt-4o", Provider: "openai",
+		InputTokens: 100, OutputTokens: 50, CostUSD: 20.00, DurationMS: 100, StatusCode: 200,
+	}); err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+	var sent string
+	stubUpstream(p, "application/json", `{"data":[]}`, &sent)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		agent  string
+		want   int
+	}{
+		{"wrong method", http.MethodGet, "", "", http.StatusMethodNotAllowed},
+		{"invalid JSON", http.MethodPost, `{`, "", http.StatusBadRequest},
+		{"unsupported provider", http.MethodPost, `{"model":"claude-sonnet-4-20250514","prompt":"hi"}`, "", http.StatusBadRequest},
+		{"over budget", http.MethodPost, `{"prompt":"hi"}`, "budget-agent", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/images/generations", strings.NewReader(tt.body))
+			if tt.agent != "" {
+				req.Header.Set("X-Agent-Name", tt.agent)
+			}
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+	if sent != "" {
+		t.Errorf("rejected requests reached upstream: %s", sent)
+	}
+}
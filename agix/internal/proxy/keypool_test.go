@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/keypool"
+)
+
+func TestKeyPoolQuarantine(t *testing.T) {
+	p, _ := newTestProxy(t)
+	pool, err := keypool.New("openai", config.KeyPoolConfig{
+		Keys: []config.PoolKey{{Key: "sk-limited-0001"}, {Key: "sk-healthy-0002"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.initialHot.KeyPools = map[string]*keypool.Pool{"openai": pool}
+
+	var used []string
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		used = append(used, key)
+		status, body := http.StatusOK, `{"choices":[{"message":{"content":"hi"}}],"usage":{"prompt_tokens":1,"completion_tokens":1}}`
+		if key == "sk-limited-0001" {
+			status, body = http.StatusTooManyRequests, `{"error":{"message":"rate limited"}}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+
+	for range 3 {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+		p.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	want := "sk-limited-0001,sk-healthy-0002,sk-healthy-0002"
+	if got := strings.Join(used, ","); got != want {
+		t.Errorf("keys used = %s, want %s (limited key skipped after its 429)", got, want)
+	}
+
+	w := httptest.NewRecorder()
+	admin := httptest.NewRequest(http.MethodGet, "/admin/key-pools", nil)
+	admin.RemoteAddr = "127.0.0.1:1234"
+	p.ServeHTTP(w, admin)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":429`) || strings.Contains(w.Body.String(), "sk-limited") {
+		t.Errorf("GET /admin/key-pools = %d %s", w.Code, w.Body.String())
+	}
+}
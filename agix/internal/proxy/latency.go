@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// streamsOutput reports whether an SSE data payload carries generated output
+// (text, reasoning or tool call deltas), as opposed to role announcements,
+// usage or other bookkeeping. The first such chunk marks time to first token.
+func streamsOutput(provider string, data []byte) bool {
+	if apiFormat(provider) == "anthropic" {
+		var event struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(data, &event)
+		return event.Type == "content_block_delta"
+	}
+	var chunk struct {
+		Choices []struct {
+			Text  string `json:"text"` // legacy completions
+			Delta struct {
+				Content          string          `json:"content"`
+				ReasoningContent string          `json:"reasoning_content"`
+				Reasoning        string          `json:"reasoning"`
+				ToolCalls        json.RawMessage `json:"tool_calls"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	json.Unmarshal(data, &chunk)
+	for _, c := range chunk.Choices {
+		d := c.Delta
+		if c.Text != "" || d.Content != "" || d.ReasoningContent != "" || d.Reasoning != "" || len(d.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ttftMillis converts a measured time to first token for storage, where
+// zero means "not streamed". Sub-millisecond values (cache hits, local
+// models) round up so they still count.
+func ttftMillis(ttft time.Duration) int64 {
+	if ttft <= 0 {
+		return 0
+	}
+	return max(ttft.Milliseconds(), 1)
+}
+
+// tokensPerSec is the output rate between the first token and the end of
+// the stream, or zero if it can't be measured.
+func tokensPerSec(outputTokens int, ttft, elapsed time.Duration) float64 {
+	gen := elapsed - ttft
+	if ttft <= 0 || outputTokens <= 1 || gen <= 0 {
+		return 0
+	}
+	// The first token arrived at ttft; the rest were generated after it.
+	return float64(outputTokens-1) / gen.Seconds()
+}
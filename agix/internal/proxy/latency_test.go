@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestStreamsOutput(t *testing.T) {
+	tests := []struct {
+		provider string
+		data     string
+		want     bool
+	}{
+		{"openai", `{"choices":[{"delta":{"role":"assistant"}}]}`, false},
+		{"openai", `{"choices":[{"delta":{"content":"Hi"}}]}`, true},
+		{"deepseek", `{"choices":[{"delta":{"reasoning_content":"hmm"}}]}`, true},
+		{"openai", `{"choices":[{"delta":{"tool_calls":[{"index":0}]}}]}`, true},
+		{"openai", `{"choices":[{"text":"Hi"}]}`, true},
+		{"openai", `{"choices":[],"usage":{"prompt_tokens":3}}`, false},
+		{"anthropic", `{"type":"message_start","message":{}}`, false},
+		{"anthropic", `{"type":"content_block_delta","delta":{"type":"text_delta","text":"Hi"}}`, true},
+	}
+	for _, tt := range tests {
+		if got := streamsOutput(tt.provider, []byte(tt.data)); got != tt.want {
+			t.Errorf("streamsOutput(%s, %s) = %v, want %v", tt.provider, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestTokensPerSec(t *testing.T) {
+	if got := tokensPerSec(101, time.Second, 3*time.Second); got != 50 {
+		t.Errorf("tokensPerSec() = %v, want 50", got)
+	}
+	if got := tokensPerSec(1, time.Second, 3*time.Second); got != 0 {
+		t.Errorf("tokensPerSec() of a single token = %v, want 0", got)
+	}
+	if got := tokensPerSec(100, 0, 3*time.Second); got != 0 {
+		t.Errorf("tokensPerSec() without a first token = %v, want 0", got)
+	}
+}
+
+func TestStreamingResponseRecordsTTFT(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	sse := strings.Join([]string{
+		`data: {"choices":[{"delta":{"role":"assistant"}}]}`, "",
+		`data: {"choices":[{"delta":{"content":"Hi"}}]}`, "",
+		`data: {"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5}}`, "",
+		"data: [DONE]", "",
+	}, "\n")
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       io.NopCloser(strings.NewReader(sse)),
+	}
+	// Pretend the request started 200ms ago, so the first token is late.
+	start := time.Now().Add(-200 * time.Millisecond)
+	p.handleStreamingResponse(httptest.NewRecorder(), nil, resp, "gpt-4o", "openai", "stream-agent", start, 0, nil)
+
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.QueryRecentRequests(1, "stream-agent")
+	}
+	if len(records) != 1 {
+		t.Fatalf("recorded = %+v", records)
+	}
+	if r := records[0]; r.TTFTMS < 200 || r.TTFTMS > r.DurationMS {
+		t.Errorf("TTFTMS = %d, want between 200 and DurationMS %d", r.TTFTMS, r.DurationMS)
+	}
+}
@@ -0,0 +1,353 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Anthropic /v1/messages support: Messages API requests are rewritten to chat
+// format and served by handleChatCompletions, so budgets, the firewall,
+// caching and routing apply unchanged. Claude models answer in Anthropic
+// format already and pass through; responses from every other provider are
+// converted from OpenAI format to an Anthropic message on the way out.
+
+// messagesToChat converts a Messages API body to a chat completions body.
+// Text and image content are supported.
+func messagesToChat(body []byte) ([]byte, error) {
+	var req struct {
+		Model    string          `json:"model"`
+		System   json.RawMessage `json:"system"`
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+		MaxTokens     int             `json:"max_tokens"`
+		Temperature   *float64        `json:"temperature"`
+		TopP          *float64        `json:"top_p"`
+		StopSequences []string        `json:"stop_sequences"`
+		Stream        bool            `json:"stream"`
+		Thinking      json.RawMessage `json:"thinking"`
+		Tools         json.RawMessage `json:"tools"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid JSON in request body")
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages field is required")
+	}
+	if len(req.Tools) > 0 && string(req.Tools) != "null" {
+		return nil, fmt.Errorf("tools are not supported on /v1/messages")
+	}
+
+	messages := make([]map[string]any, 0, len(req.Messages)+1)
+	if len(req.System) > 0 && string(req.System) != "null" {
+		system, err := messageText(req.System)
+		if err != nil {
+			return nil, fmt.Errorf("system: %w", err)
+		}
+		messages = append(messages, map[string]any{"role": "system", "content": system})
+	}
+	for i, m := range req.Messages {
+		if m.Role != "user" && m.Role != "assistant" {
+			return nil, fmt.Errorf("messages[%d]: unsupported role %q", i, m.Role)
+		}
+		content, err := anthropicContentToOpenAI(m.Content)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		messages = append(messages, map[string]any{"role": m.Role, "content": content})
+	}
+
+	chat := map[string]any{"model": req.Model, "messages": messages}
+	if req.MaxTokens > 0 {
+		chat["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		chat["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		chat["top_p"] = *req.TopP
+	}
+	if len(req.StopSequences) > 0 {
+		chat["stop"] = req.StopSequences
+	}
+	if req.Stream {
+		chat["stream"] = true
+	}
+	if len(req.Thinking) > 0 && string(req.Thinking) != "null" {
+		chat["thinking"] = req.Thinking
+	}
+	return json.Marshal(chat)
+}
+
+// messageText flattens Anthropic content, a string or a list of text
+// blocks, to a string.
+func messageText(raw json.RawMessage) (string, error) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, nil
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return "", fmt.Errorf("content must be a string or an array of content blocks")
+	}
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Type != "text" {
+			return "", fmt.Errorf("content block type %q is not supported", b.Type)
+		}
+		parts = append(parts, b.Text)
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+type anthropicContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicMessage struct {
+	ID           string             `json:"id"`
+	Type         string             `json:"type"`
+	Role         string             `json:"role"`
+	Model        string             `json:"model"`
+	Content      []anthropicContent `json:"content"`
+	StopReason   *string            `json:"stop_reason"`
+	StopSequence *string            `json:"stop_sequence"`
+	Usage        anthropicUsage     `json:"usage"`
+	// AgixUsage carries the usage trailer through, when requested.
+	AgixUsage json.RawMessage `json:"agix_usage,omitempty"`
+}
+
+// chatToMessage converts a non-streaming chat response to an Anthropic
+// message. Responses already in Anthropic format are returned unchanged.
+func chatToMessage(body []byte) ([]byte, error) {
+	var resp struct {
+		Type    string `json:"type"`
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+		AgixUsage json.RawMessage `json:"agix_usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decode chat response: %w", err)
+	}
+	if resp.Type == "message" {
+		return body, nil
+	}
+
+	msg := anthropicMessage{
+		ID:         resp.ID,
+		Type:       "message",
+		Role:       "assistant",
+		Model:      resp.Model,
+		Content:    []anthropicContent{},
+		StopReason: openAIStopReason(""),
+		Usage:      anthropicUsage{InputTokens: resp.Usage.PromptTokens, OutputTokens: resp.Usage.CompletionTokens},
+		AgixUsage:  resp.AgixUsage,
+	}
+	if len(resp.Choices) > 0 {
+		c := resp.Choices[0]
+		if c.Message.Content != "" {
+			msg.Content = append(msg.Content, anthropicContent{Type: "text", Text: c.Message.Content})
+		}
+		msg.StopReason = openAIStopReason(c.FinishReason)
+	}
+	return json.Marshal(msg)
+}
+
+// openAIStopReason maps an OpenAI finish_reason to the Anthropic stop_reason.
+func openAIStopReason(finish string) *string {
+	var reason string
+	switch finish {
+	case "length":
+		reason = "max_tokens"
+	case "tool_calls", "function_call":
+		reason = "tool_use"
+	default:
+		reason = "end_turn"
+	}
+	return &reason
+}
+
+// messagesWriter converts the chat response written by handleChatCompletions
+// to Messages API format. OpenAI SSE chunks are converted to Anthropic
+// stream events; Anthropic streams, recognized by their event lines, pass
+// through.
+type messagesWriter struct {
+	sseConvertWriter
+	model  string
+	native bool
+
+	// state of a converted stream
+	started    bool
+	done       bool
+	id         string
+	stopReason *string
+	usage      anthropicUsage
+}
+
+func newMessagesWriter(w http.ResponseWriter, model string) *messagesWriter {
+	mw := &messagesWriter{model: model}
+	mw.sseConvertWriter = sseConvertWriter{w: w, line: mw.writeLine, body: chatToMessage, end: func() {
+		if !mw.native && mw.started {
+			mw.stop()
+		}
+	}}
+	return mw
+}
+
+func (mw *messagesWriter) acceptsAnthropicStream() {}
+
+func (mw *messagesWriter) writeLine(line string) {
+	if strings.HasPrefix(line, "event: ") {
+		mw.native = true
+	}
+	data, isData := strings.CutPrefix(line, "data: ")
+	switch {
+	case mw.native:
+		io.WriteString(mw.w, line+"\n")
+	case line == "":
+		// Converted events carry their own separators.
+	case !isData:
+		io.WriteString(mw.w, line+"\n\n")
+	case data == "[DONE]":
+		mw.stop()
+	default:
+		mw.convertChunk([]byte(data))
+	}
+}
+
+// convertChunk emits the Anthropic events for one OpenAI stream chunk.
+func (mw *messagesWriter) convertChunk(data []byte) {
+	var chunk struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+			FinishReason *string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if json.Unmarshal(data, &chunk) != nil {
+		return
+	}
+	if chunk.Model != "" {
+		mw.model = chunk.Model
+	}
+	if chunk.ID != "" && mw.id == "" {
+		mw.id = chunk.ID
+	}
+	mw.start()
+	if chunk.Usage != nil {
+		mw.usage = anthropicUsage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+	}
+	for _, c := range chunk.Choices {
+		if c.Delta.Content != "" {
+			mw.event("content_block_delta", map[string]any{
+				"index": 0,
+				"delta": map[string]string{"type": "text_delta", "text": c.Delta.Content},
+			})
+		}
+		if c.FinishReason != nil {
+			mw.stopReason = openAIStopReason(*c.FinishReason)
+		}
+	}
+}
+
+// start emits message_start and opens the single text block.
+func (mw *messagesWriter) start() {
+	if mw.started {
+		return
+	}
+	mw.started = true
+	mw.event("message_start", map[string]any{"message": anthropicMessage{
+		ID:      mw.id,
+		Type:    "message",
+		Role:    "assistant",
+		Model:   mw.model,
+		Content: []anthropicContent{},
+	}})
+	mw.event("content_block_start", map[string]any{"index": 0, "content_block": anthropicContent{Type: "text"}})
+}
+
+// stop closes the text block and the message.
+func (mw *messagesWriter) stop() {
+	if mw.done {
+		return
+	}
+	mw.start()
+	mw.done = true
+	if mw.stopReason == nil {
+		mw.stopReason = openAIStopReason("")
+	}
+	mw.event("content_block_stop", map[string]any{"index": 0})
+	mw.event("message_delta", map[string]any{
+		"delta": map[string]any{"stop_reason": mw.stopReason, "stop_sequence": nil},
+		"usage": mw.usage,
+	})
+	mw.event("message_stop", map[string]any{})
+}
+
+func (mw *messagesWriter) event(name string, payload map[string]any) {
+	payload["type"] = name
+	data, _ := json.Marshal(payload)
+	fmt.Fprintf(mw.w, "event: %s\ndata: %s\n\n", name, data)
+}
+
+// handleMessages serves the Anthropic-compatible POST /v1/messages endpoint.
+func (p *Proxy) handleMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	chat, err := messagesToChat(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Model string `json:"model"`
+	}
+	json.Unmarshal(body, &req)
+
+	r2 := r.Clone(r.Context())
+	r2.Body = io.NopCloser(bytes.NewReader(chat))
+	r2.ContentLength = int64(len(chat))
+	mw := newMessagesWriter(w, req.Model)
+	p.handleChatCompletions(mw, r2)
+	mw.finish()
+}
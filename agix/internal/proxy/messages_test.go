@@ -0,0 +1,172 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMessagesToChat(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantMsgs string
+		wantErr  bool
+	}{
+		{"string content", `{"model":"gpt-4o","max_tokens":10,"messages":[{"role":"user","content":"Hi"}]}`, `[{"content":"Hi","role":"user"}]`, false},
+		{
+			"system and text blocks",
+			`{"model":"gpt-4o","system":[{"type":"text","text":"Be brief."}],"messages":[{"role":"user","content":[{"type":"text","text":"a"},{"type":"text","text":"b"}]}]}`,
+			`[{"content":"Be brief.","role":"system"},{"content":"a\nb","role":"user"}]`, false,
+		},
+		{"image block without source", `{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"image","source":{}}]}]}`, "", true},
+		{
+			"base64 image block",
+			`{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"text","text":"What is it?"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBOR"}}]}]}`,
+			`[{"content":[{"text":"What is it?","type":"text"},{"image_url":{"url":"data:image/png;base64,iVBOR"},"type":"image_url"}],"role":"user"}]`, false,
+		},
+		{"tools", `{"model":"gpt-4o","tools":[{"name":"x"}],"messages":[{"role":"user","content":"Hi"}]}`, "", true},
+		{"missing messages", `{"model":"gpt-4o"}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, err := messagesToChat([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("messagesToChat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var fields map[string]json.RawMessage
+			json.Unmarshal(chat, &fields)
+			if string(fields["messages"]) != tt.wantMsgs {
+				t.Errorf("messages = %s, want %s", fields["messages"], tt.wantMsgs)
+			}
+		})
+	}
+
+	chat, _ := messagesToChat([]byte(`{"model":"gpt-4o","max_tokens":5,"stop_sequences":["END"],"temperature":0,"messages":[{"role":"user","content":"Hi"}]}`))
+	var fields map[string]json.RawMessage
+	json.Unmarshal(chat, &fields)
+	if string(fields["max_tokens"]) != "5" || string(fields["stop"]) != `["END"]` || string(fields["temperature"]) != "0" {
+		t.Errorf("parameters not mapped: %s", chat)
+	}
+}
+
+func TestChatToMessage(t *testing.T) {
+	out, err := chatToMessage([]byte(`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi!"},"finish_reason":"length"}],"usage":{"prompt_tokens":3,"completion_tokens":2}}`))
+	if err != nil {
+		t.Fatalf("chatToMessage() error: %v", err)
+	}
+	var msg anthropicMessage
+	json.Unmarshal(out, &msg)
+	if msg.Type != "message" || msg.Role != "assistant" || len(msg.Content) != 1 || msg.Content[0].Text != "Hi!" {
+		t.Errorf("message = %s", out)
+	}
+	if msg.StopReason == nil || *msg.StopReason != "max_tokens" || msg.Usage.InputTokens != 3 || msg.Usage.OutputTokens != 2 {
+		t.Errorf("stop_reason/usage = %s", out)
+	}
+
+	native := `{"id":"m1","type":"message","role":"assistant","model":"claude-sonnet-4-6","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn","usage":{"input_tokens":4,"output_tokens":1}}`
+	if out, _ := chatToMessage([]byte(native)); string(out) != native {
+		t.Errorf("Anthropic response changed: %s", out)
+	}
+}
+
+func TestMessagesEndpoint(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "application/json",
+		`{"id":"c1","created":1,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2}}`, &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"gpt-4o","max_tokens":16,"system":"Be brief.","messages":[{"role":"user","content":"Say hi"}]}`))
+	req.Header.Set("X-Agent-Name", "sdk-agent")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(sent, `{"content":"Be brief.","role":"system"}`) {
+		t.Errorf("upstream body = %s", sent)
+	}
+	var msg anthropicMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &msg); err != nil || msg.Type != "message" || msg.Content[0].Text != "Hi!" {
+		t.Errorf("response = %s", w.Body.String())
+	}
+	if w.Header().Get("X-Input-Tokens") != "3" {
+		t.Errorf("X-Input-Tokens = %q", w.Header().Get("X-Input-Tokens"))
+	}
+}
+
+func TestMessagesEndpointAnthropic(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var sent string
+	native := `{"id":"m1","type":"message","role":"assistant","model":"claude-sonnet-4-6","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn","usage":{"input_tokens":4,"output_tokens":1}}`
+	stubUpstream(p, "application/json", native, &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"claude-sonnet-4-6","max_tokens":16,"stop_sequences":["END"],"messages":[{"role":"user","content":"Say hi"}]}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != native {
+		t.Errorf("response = %d %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(sent, `"stop_sequences":["END"]`) || !strings.Contains(sent, `"max_tokens":16`) {
+		t.Errorf("upstream body = %s", sent)
+	}
+}
+
+func TestMessagesEndpointStreaming(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "text/event-stream", strings.Join([]string{
+		`data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant"}}]}`, "",
+		`data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"Hi"}}]}`, "",
+		`data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`, "",
+		`data: {"id":"c1","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1}}`, "",
+		"data: [DONE]", "",
+	}, "\n"), &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"gpt-4o","max_tokens":16,"stream":true,"messages":[{"role":"user","content":"Say hi"}]}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	var events []string
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, name)
+		}
+	}
+	want := "message_start content_block_start content_block_delta content_block_stop message_delta message_stop"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events = %s, want %s\n%s", got, want, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"text":"Hi"`) || !strings.Contains(body, `"stop_reason":"end_turn"`) || !strings.Contains(body, `"output_tokens":1`) {
+		t.Errorf("stream not converted:\n%s", body)
+	}
+	if strings.Contains(body, "[DONE]") {
+		t.Errorf("OpenAI terminator forwarded:\n%s", body)
+	}
+}
+
+func TestMessagesEndpointErrors(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/messages", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"image"}]}]}`))
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "image") {
+		t.Errorf("image block = %d %s", w.Code, w.Body.String())
+	}
+}
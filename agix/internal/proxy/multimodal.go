@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Multimodal content: OpenAI messages carry images as image_url parts (an
+// http(s) URL or a base64 data URL), Anthropic messages as image blocks
+// with a url or base64 source. The converters below map one to the other so
+// images survive the format translation in both directions.
+
+// openAIPart is one element of an OpenAI content array.
+type openAIPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	ImageURL struct {
+		URL string `json:"url"`
+	} `json:"image_url"`
+}
+
+// anthropicBlock is one element of an Anthropic content array.
+type anthropicBlock struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Source struct {
+		Type      string `json:"type"`
+		MediaType string `json:"media_type"`
+		Data      string `json:"data"`
+		URL       string `json:"url"`
+	} `json:"source"`
+}
+
+// openAIContentToAnthropic converts OpenAI message content, a string or an
+// array of text and image_url parts, to Anthropic content. Strings stay
+// strings; arrays become content blocks.
+func openAIContentToAnthropic(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, nil
+	}
+	var parts []openAIPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content parts")
+	}
+	blocks := make([]map[string]any, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			blocks = append(blocks, map[string]any{"type": "text", "text": part.Text})
+		case "image_url":
+			source, err := anthropicImageSource(part.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, map[string]any{"type": "image", "source": source})
+		default:
+			return nil, fmt.Errorf("content part type %q is not supported for Anthropic models", part.Type)
+		}
+	}
+	return blocks, nil
+}
+
+// anthropicImageSource converts an image_url to an Anthropic image source:
+// data URLs become base64 sources, anything else a url source.
+func anthropicImageSource(url string) (map[string]any, error) {
+	if url == "" {
+		return nil, fmt.Errorf("image_url.url is required")
+	}
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return map[string]any{"type": "url", "url": url}, nil
+	}
+	mediaType, data, ok := strings.Cut(rest, ";base64,")
+	if !ok || mediaType == "" {
+		return nil, fmt.Errorf("image data URL must be base64-encoded with a media type")
+	}
+	return map[string]any{"type": "base64", "media_type": mediaType, "data": data}, nil
+}
+
+// anthropicContentToOpenAI converts Anthropic message content, a string or
+// an array of text and image blocks, to OpenAI content. Text-only content is
+// flattened to a string, as most OpenAI-compatible providers expect.
+func anthropicContentToOpenAI(raw json.RawMessage) (any, error) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, nil
+	}
+	var blocks []anthropicBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content blocks")
+	}
+	parts := make([]map[string]any, 0, len(blocks))
+	var texts []string
+	textOnly := true
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			texts = append(texts, b.Text)
+			parts = append(parts, map[string]any{"type": "text", "text": b.Text})
+		case "image":
+			var url string
+			switch b.Source.Type {
+			case "base64":
+				url = "data:" + b.Source.MediaType + ";base64," + b.Source.Data
+			case "url":
+				url = b.Source.URL
+			default:
+				return nil, fmt.Errorf("image source type %q is not supported", b.Source.Type)
+			}
+			textOnly = false
+			parts = append(parts, map[string]any{"type": "image_url", "image_url": map[string]string{"url": url}})
+		default:
+			return nil, fmt.Errorf("content block type %q is not supported", b.Type)
+		}
+	}
+	if textOnly {
+		return strings.Join(texts, "\n"), nil
+	}
+	return parts, nil
+}
+
+// contentText returns the text of OpenAI or Anthropic content, joining the
+// text parts of an array and skipping images.
+func contentText(content any) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []map[string]any:
+		var texts []string
+		for _, part := range c {
+			if text, ok := part["text"].(string); ok && part["type"] == "text" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOpenAIContentToAnthropic(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"string", `"hello"`, `"hello"`, false},
+		{"null", `null`, `""`, false},
+		{
+			"text and URL image",
+			`[{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"https://example.com/cat.png","detail":"high"}}]`,
+			`[{"text":"What is this?","type":"text"},{"source":{"type":"url","url":"https://example.com/cat.png"},"type":"image"}]`, false,
+		},
+		{
+			"base64 image",
+			`[{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,/9j/4AAQ"}}]`,
+			`[{"source":{"data":"/9j/4AAQ","media_type":"image/jpeg","type":"base64"},"type":"image"}]`, false,
+		},
+		{"data URL without base64", `[{"type":"image_url","image_url":{"url":"data:image/png,abc"}}]`, "", true},
+		{"missing URL", `[{"type":"image_url","image_url":{}}]`, "", true},
+		{"audio part", `[{"type":"input_audio","input_audio":{"data":"x","format":"wav"}}]`, "", true},
+		{"bad content", `42`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := openAIContentToAnthropic(json.RawMessage(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openAIContentToAnthropic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if out, _ := json.Marshal(got); string(out) != tt.want {
+				t.Errorf("content = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicContentToOpenAI(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"string", `"hello"`, `"hello"`, false},
+		{"text blocks flattened", `[{"type":"text","text":"a"},{"type":"text","text":"b"}]`, `"a\nb"`, false},
+		{
+			"base64 image",
+			`[{"type":"text","text":"Describe"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBOR"}}]`,
+			`[{"text":"Describe","type":"text"},{"image_url":{"url":"data:image/png;base64,iVBOR"},"type":"image_url"}]`, false,
+		},
+		{
+			"URL image",
+			`[{"type":"image","source":{"type":"url","url":"https://example.com/cat.png"}}]`,
+			`[{"image_url":{"url":"https://example.com/cat.png"},"type":"image_url"}]`, false,
+		},
+		{"unknown source", `[{"type":"image","source":{"type":"file","file_id":"f1"}}]`, "", true},
+		{"tool result", `[{"type":"tool_result","tool_use_id":"t1"}]`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := anthropicContentToOpenAI(json.RawMessage(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("anthropicContentToOpenAI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if out, _ := json.Marshal(got); string(out) != tt.want {
+				t.Errorf("content = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertToAnthropicFormatImages(t *testing.T) {
+	body := `{"model":"claude-sonnet-4-5","messages":[` +
+		`{"role":"system","content":[{"type":"text","text":"Be brief."}]},` +
+		`{"role":"user","content":[{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBOR"}}]}]}`
+	out, err := convertToAnthropicFormat([]byte(body))
+	if err != nil {
+		t.Fatalf("convertToAnthropicFormat() error: %v", err)
+	}
+	var req struct {
+		System   string `json:"system"`
+		Messages []struct {
+			Content []anthropicBlock `json:"content"`
+		} `json:"messages"`
+	}
+	json.Unmarshal(out, &req)
+	if req.System != "Be brief." || len(req.Messages) != 1 {
+		t.Fatalf("converted = %s", out)
+	}
+	blocks := req.Messages[0].Content
+	if len(blocks) != 2 || blocks[1].Type != "image" || blocks[1].Source.Type != "base64" || blocks[1].Source.MediaType != "image/png" || blocks[1].Source.Data != "iVBOR" {
+		t.Errorf("content = %+v", blocks)
+	}
+
+	if _, err := convertToAnthropicFormat([]byte(`{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":[{"type":"input_audio"}]}]}`)); err == nil {
+		t.Error("unsupported content part converted without error")
+	}
+}
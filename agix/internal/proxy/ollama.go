@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultOllamaBaseURL is used when ollama.base_url is unset.
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// ollamaUpstream returns the URL and body for model "ollama/{name}". Ollama
+// serves the OpenAI chat format and needs no API key; the prefix is stripped
+// so Ollama sees its own model name (e.g. "llama3.1:8b").
+func (p *Proxy) ollamaUpstream(model string, body []byte, headers map[string]string) (string, map[string]string, []byte, error) {
+	base := strings.TrimRight(p.cfg.Ollama.BaseURL, "/")
+	if base == "" {
+		base = defaultOllamaBaseURL
+	}
+	body = replaceModel(body, strings.TrimPrefix(model, "ollama/"))
+	return base + "/v1/chat/completions", headers, requestStreamUsage(body), nil
+}
+
+// requestStreamUsage asks for a usage chunk at the end of streamed
+// responses, which Ollama only sends when stream_options.include_usage is
+// set.
+func requestStreamUsage(body []byte) []byte {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body
+	}
+	if string(raw["stream"]) != "true" || raw["stream_options"] != nil {
+		return body
+	}
+	raw["stream_options"] = json.RawMessage(`{"include_usage":true}`)
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// estimateOllamaUsage estimates token counts for Ollama responses that
+// don't report usage (older servers, or streams without a usage chunk):
+// the prompt from the upstream request's messages, the output from the
+// completion text.
+func estimateOllamaUsage(resp *http.Response, completion string) (inputTokens, outputTokens int) {
+	if resp != nil && resp.Request != nil && resp.Request.GetBody != nil {
+		if rc, err := resp.Request.GetBody(); err == nil {
+			body, _ := io.ReadAll(rc)
+			rc.Close()
+			var req struct {
+				Messages []struct {
+					Content json.RawMessage `json:"content"`
+				} `json:"messages"`
+			}
+			json.Unmarshal(body, &req)
+			for _, m := range req.Messages {
+				var text string
+				if json.Unmarshal(m.Content, &text) != nil {
+					text = string(m.Content)
+				}
+				inputTokens += estimateTokens(text)
+			}
+		}
+	}
+	return inputTokens, estimateTokens(completion)
+}
+
+// openAICompletionText returns the message text of a non-streaming OpenAI
+// format response.
+func openAICompletionText(body []byte) string {
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	json.Unmarshal(body, &resp)
+	var text strings.Builder
+	for _, c := range resp.Choices {
+		text.WriteString(c.Message.Content)
+	}
+	return text.String()
+}
+
+// openAIDeltaText returns the delta text of one streamed OpenAI format chunk.
+func openAIDeltaText(data []byte) string {
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	json.Unmarshal(data, &chunk)
+	var text strings.Builder
+	for _, c := range chunk.Choices {
+		text.WriteString(c.Delta.Content)
+	}
+	return text.String()
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaUpstream(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	tests := []struct {
+		name     string
+		baseURL  string
+		body     string
+		wantURL  string
+		wantBody string
+	}{
+		{"default base URL", "", `{"model":"ollama/llama3.1:8b"}`,
+			"http://localhost:11434/v1/chat/completions", `{"model":"llama3.1:8b"}`},
+		{"custom base URL", "http://gpu-box:11434/", `{"model":"ollama/qwen2.5"}`,
+			"http://gpu-box:11434/v1/chat/completions", `{"model":"qwen2.5"}`},
+		{"stream asks for usage", "", `{"model":"ollama/qwen2.5","stream":true}`,
+			"http://localhost:11434/v1/chat/completions", `{"model":"qwen2.5","stream":true,"stream_options":{"include_usage":true}}`},
+		{"stream_options kept", "", `{"model":"ollama/qwen2.5","stream":true,"stream_options":{"include_usage":false}}`,
+			"http://localhost:11434/v1/chat/completions", `{"model":"qwen2.5","stream":true,"stream_options":{"include_usage":false}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.cfg.Ollama.BaseURL = tt.baseURL
+			var req struct{ Model string }
+			json.Unmarshal([]byte(tt.body), &req)
+			url, h, body, err := p.buildUpstreamRequest("ollama", req.Model, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("buildUpstreamRequest: %v", err)
+			}
+			if url != tt.wantURL {
+				t.Errorf("url = %s, want %s", url, tt.wantURL)
+			}
+			if h["Authorization"] != "" {
+				t.Errorf("unexpected Authorization header %q", h["Authorization"])
+			}
+			var got, want any
+			json.Unmarshal(body, &got)
+			json.Unmarshal([]byte(tt.wantBody), &want)
+			gj, _ := json.Marshal(got)
+			wj, _ := json.Marshal(want)
+			if string(gj) != string(wj) {
+				t.Errorf("body = %s, want %s", gj, wj)
+			}
+		})
+	}
+}
+
+func TestOllamaUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		stream      bool
+		resp        string
+		wantInput   string
+		wantOutput  string
+	}{
+		{"reported", "application/json", false,
+			`{"choices":[{"message":{"content":"hello there"}}],"usage":{"prompt_tokens":42,"completion_tokens":7}}`, "42", "7"},
+		{"estimated", "application/json", false,
+			`{"choices":[{"message":{"content":"one two three four five six seven eight nine ten"}}]}`, "6", "13"},
+		{"estimated stream", "text/event-stream", true,
+			"data: {\"choices\":[{\"delta\":{\"content\":\"one two three four five \"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"six seven eight nine ten\"}}]}\n\ndata: [DONE]\n", "6", "13"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			var sent string
+			stubUpstream(p, tt.contentType, tt.resp, &sent)
+
+			body := `{"model":"ollama/llama3.1","messages":[{"role":"user","content":"why is the sky blue"}]`
+			if tt.stream {
+				body += `,"stream":true`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body+"}"))
+			req.Header.Set("X-Usage-Trailer", "1")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if !strings.Contains(sent, `"model":"llama3.1"`) {
+				t.Errorf("upstream body = %s, want the ollama/ prefix stripped", sent)
+			}
+			var trailer usageTrailer
+			if tt.stream {
+				_, rest, _ := strings.Cut(w.Body.String(), ": "+usageTrailerField+" ")
+				line, _, _ := strings.Cut(rest, "\n")
+				json.Unmarshal([]byte(line), &trailer)
+			} else {
+				var got struct {
+					Usage usageTrailer `json:"agix_usage"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &got)
+				trailer = got.Usage
+				if h := w.Header().Get("X-Input-Tokens"); h != tt.wantInput {
+					t.Errorf("X-Input-Tokens = %s, want %s", h, tt.wantInput)
+				}
+			}
+			if got := fmt.Sprintf("%d/%d", trailer.InputTokens, trailer.OutputTokens); got != tt.wantInput+"/"+tt.wantOutput {
+				t.Errorf("usage = %s, want %s/%s", got, tt.wantInput, tt.wantOutput)
+			}
+			if trailer.CostUSD != 0 {
+				t.Errorf("cost = %v, want 0 for local models", trailer.CostUSD)
+			}
+		})
+	}
+}
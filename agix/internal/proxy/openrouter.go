@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// openRouterURL is OpenRouter's OpenAI-compatible chat endpoint.
+const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
+
+// openRouterUpstream returns the URL, headers and body for model
+// "openrouter/{vendor}/{model}". The prefix is stripped so OpenRouter sees
+// its own model ID (e.g. "anthropic/claude-sonnet-4"), and usage accounting
+// is requested so responses carry the billed cost.
+func (p *Proxy) openRouterUpstream(model string, body []byte, headers map[string]string) (string, map[string]string, []byte, error) {
+	apiKey := p.key("openrouter")
+	if apiKey == "" {
+		return "", nil, nil, fmt.Errorf("OpenRouter API key not configured")
+	}
+	id := strings.TrimPrefix(model, "openrouter/")
+	if id == "" {
+		return "", nil, nil, fmt.Errorf("invalid OpenRouter model %q, want openrouter/{model}", model)
+	}
+
+	headers["Authorization"] = "Bearer " + apiKey
+	// App attribution, shown in OpenRouter's rankings and activity log
+	if ref := p.cfg.OpenRouter.Referer; ref != "" {
+		headers["HTTP-Referer"] = ref
+	}
+	if title := p.cfg.OpenRouter.Title; title != "" {
+		headers["X-Title"] = title
+	}
+	return openRouterURL, headers, requestUsageAccounting(replaceModel(body, id)), nil
+}
+
+// requestUsageAccounting sets usage.include so OpenRouter reports the cost
+// of the request in the usage object (the final chunk when streaming).
+func requestUsageAccounting(body []byte) []byte {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body
+	}
+	if raw["usage"] != nil {
+		return body
+	}
+	raw["usage"] = json.RawMessage(`{"include":true}`)
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// upstreamCost returns the cost in USD reported by the provider in a
+// response body or stream chunk. Only OpenRouter reports one; for other
+// providers cost is computed from the pricing table.
+func upstreamCost(provider string, data []byte) (float64, bool) {
+	if provider != "openrouter" {
+		return 0, false
+	}
+	var resp struct {
+		Usage *struct {
+			Cost *float64 `json:"cost"`
+		} `json:"usage"`
+	}
+	if json.Unmarshal(data, &resp) != nil || resp.Usage == nil || resp.Usage.Cost == nil {
+		return 0, false
+	}
+	return *resp.Usage.Cost, true
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenRouterUpstream(t *testing.T) {
+	p, _ := newTestProxy(t)
+	if _, _, _, err := p.openRouterUpstream("openrouter/openai/gpt-4o", []byte(`{}`), map[string]string{}); err == nil {
+		t.Error("expected error without an OpenRouter key")
+	}
+	p.initialHot.Keys["openrouter"] = "or-key"
+	p.cfg.OpenRouter.Referer = "https://myapp.example.com"
+	p.cfg.OpenRouter.Title = "My App"
+
+	url, h, body, err := p.buildUpstreamRequest("openrouter", "openrouter/anthropic/claude-sonnet-4",
+		[]byte(`{"model":"openrouter/anthropic/claude-sonnet-4","messages":[]}`))
+	if err != nil {
+		t.Fatalf("buildUpstreamRequest: %v", err)
+	}
+	if url != openRouterURL {
+		t.Errorf("url = %s", url)
+	}
+	if h["Authorization"] != "Bearer or-key" || h["HTTP-Referer"] != "https://myapp.example.com" || h["X-Title"] != "My App" {
+		t.Errorf("headers = %v", h)
+	}
+	var got struct {
+		Model string `json:"model"`
+		Usage struct {
+			Include bool `json:"include"`
+		} `json:"usage"`
+	}
+	json.Unmarshal(body, &got)
+	if got.Model != "anthropic/claude-sonnet-4" || !got.Usage.Include {
+		t.Errorf("body = %s, want prefix stripped and usage.include set", body)
+	}
+}
+
+func TestUpstreamCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		data     string
+		want     float64
+		wantOK   bool
+	}{
+		{"openrouter cost", "openrouter", `{"usage":{"prompt_tokens":10,"completion_tokens":5,"cost":0.0123}}`, 0.0123, true},
+		{"openrouter zero cost", "openrouter", `{"usage":{"cost":0}}`, 0, true},
+		{"openrouter without cost", "openrouter", `{"usage":{"prompt_tokens":10}}`, 0, false},
+		{"openrouter chunk without usage", "openrouter", `{"choices":[{"delta":{"content":"hi"}}]}`, 0, false},
+		{"other provider", "openai", `{"usage":{"cost":1.5}}`, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := upstreamCost(tt.provider, []byte(tt.data))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("upstreamCost = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestOpenRouterReportedCost(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		stream      bool
+		resp        string
+	}{
+		{"non-streaming", "application/json", false,
+			`{"choices":[{"message":{"content":"hi"}}],"usage":{"prompt_tokens":1000000,"completion_tokens":10,"cost":0.042}}`},
+		{"streaming", "text/event-stream", true,
+			"data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1000000,\"completion_tokens\":10,\"cost\":0.042}}\n\ndata: [DONE]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			p.initialHot.Keys["openrouter"] = "or-key"
+			p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {tt.contentType}},
+					Body:       io.NopCloser(strings.NewReader(tt.resp)),
+					Request:    r,
+				}, nil
+			})
+
+			body := `{"model":"openrouter/openai/gpt-4o","messages":[{"role":"user","content":"hi"}]`
+			if tt.stream {
+				body += `,"stream":true`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body+"}"))
+			req.Header.Set("X-Usage-Trailer", "1")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+
+			// The trailer carries the cost OpenRouter billed, not a
+			// recomputation from the pricing table.
+			var trailer usageTrailer
+			if tt.stream {
+				_, rest, _ := strings.Cut(w.Body.String(), ": "+usageTrailerField+" ")
+				line, _, _ := strings.Cut(rest, "\n")
+				json.Unmarshal([]byte(line), &trailer)
+			} else {
+				var got struct {
+					Usage usageTrailer `json:"agix_usage"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &got)
+				trailer = got.Usage
+				if h := w.Header().Get("X-Cost-USD"); h != "0.042000" {
+					t.Errorf("X-Cost-USD = %s, want 0.042000", h)
+				}
+			}
+			if trailer.CostUSD != 0.042 || trailer.InputTokens != 1000000 {
+				t.Errorf("trailer = %+v, want cost 0.042 and 1000000 input tokens", trailer)
+			}
+		})
+	}
+}
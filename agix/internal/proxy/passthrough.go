@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/trace"
+)
+
+// Endpoints other than chat completions (embeddings, images, audio, legacy
+// completions) are forwarded without going through the chat pipeline. They
+// share its rate limits, budgets and usage recording through the helpers
+// below.
+
+// openAIEndpoint builds the upstream request for an OpenAI-format endpoint
+// such as "/embeddings". Providers speaking the chat completions dialect
+// serve these next to /chat/completions, so the chat URL is reused.
+func (p *Proxy) openAIEndpoint(provider, model, endpoint string, body []byte) (string, map[string]string, []byte, error) {
+	switch {
+	case provider == "openai", provider == "azure", provider == "ollama", pricing.IsCustomProvider(provider):
+	default:
+		return "", nil, nil, fmt.Errorf("%s is not supported for provider %q", strings.TrimPrefix(endpoint, "/"), provider)
+	}
+	url, headers, body, err := p.buildUpstreamRequestRaw(provider, model, body)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return strings.Replace(url, "/chat/completions", endpoint, 1), headers, body, nil
+}
+
+// admit applies the agent's rate limit and the budgets to a request served
+// outside the chat pipeline, estimated to use tokens tokens. It writes the
+// 429 itself and returns false if the request is rejected.
+func (p *Proxy) admit(w http.ResponseWriter, r *http.Request, agentName, provider, model string, tokens int, tr *trace.Trace) (*budgetSnapshot, bool) {
+	if !p.rateLimit(w, r, agentName, tokens, tr) {
+		return nil, false
+	}
+
+	sp := tr.StartSpan("budget_check")
+	group := budgetGroup(r.Context())
+	budget := p.snapshotBudget(agentName, group, provider, model)
+	if err := p.checkBudget(agentName, group, provider, model); err != nil {
+		sp.Set("passed", false).End()
+		p.reportBudget(w.Header(), budget, 0)
+		http.Error(w, fmt.Sprintf(`{"error":"budget exceeded: %s"}`, err.Error()), http.StatusTooManyRequests)
+		return nil, false
+	}
+	sp.Set("passed", true).End()
+	return budget, true
+}
+
+// sendRaw posts body to an upstream built by openAIEndpoint and reads the
+// whole response.
+func (p *Proxy) sendRaw(r *http.Request, provider, model, url string, headers map[string]string, body []byte) (*http.Response, []byte, error) {
+	if err := p.checkQuota(r.Context(), provider, model, len(body)/4); err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("create upstream request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	p.providerLimits.Observe(provider, model, resp.Header, time.Now())
+	p.observeKey(provider, req, resp)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read upstream response: %w", err)
+	}
+	return resp, respBody, nil
+}
@@ -0,0 +1,30 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// projectFor returns the project a request is charged to: a valid X-Project
+// header, else the agent's entry in projects (exact name, then the longest
+// "prefix*" match), else "". The header uses the same character set as
+// X-Request-ID.
+func (p *Proxy) projectFor(r *http.Request, agent string) string {
+	if project := r.Header.Get("X-Project"); validRequestID(project) {
+		return project
+	}
+	if agent == "" {
+		return ""
+	}
+	if project, ok := p.cfg.Projects[agent]; ok {
+		return project
+	}
+	best, project := -1, ""
+	for pattern, name := range p.cfg.Projects {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(agent, prefix) && len(prefix) > best {
+			best, project = len(prefix), name
+		}
+	}
+	return project
+}
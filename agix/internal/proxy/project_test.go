@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+func TestProjectFor(t *testing.T) {
+	p := &Proxy{cfg: &config.Config{Projects: map[string]string{
+		"support-bot": "support",
+		"ci-*":        "platform",
+		"ci-search-*": "search",
+	}}}
+
+	tests := []struct {
+		agent, header, want string
+	}{
+		{"support-bot", "", "support"},
+		{"support-bot", "checkout", "checkout"},    // header wins
+		{"support-bot", "bad project!", "support"}, // invalid header ignored
+		{"ci-build", "", "platform"},
+		{"ci-search-nightly", "", "search"}, // longest prefix
+		{"other", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+		if tt.header != "" {
+			r.Header.Set("X-Project", tt.header)
+		}
+		if got := p.projectFor(r, tt.agent); got != tt.want {
+			t.Errorf("projectFor(%q, X-Project %q) = %q, want %q", tt.agent, tt.header, got, tt.want)
+		}
+	}
+}
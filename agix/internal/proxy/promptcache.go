@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/agent-platform/agix/internal/pricing"
+)
+
+// Anthropic prompt caching: large system prompts are marked with a
+// cache_control breakpoint, and the cache tokens Anthropic reports are
+// recorded and priced apart from regular input tokens.
+
+// defaultCacheMinTokens is Anthropic's minimum cacheable prompt length for
+// most models; shorter prefixes are silently not cached.
+const defaultCacheMinTokens = 1024
+
+// injectCacheControl turns a string system prompt of an Anthropic request
+// into a text block with an ephemeral cache_control breakpoint, when prompt
+// caching is enabled and the prompt is large enough. A system prompt that
+// is already a block array was laid out by the caller and is left alone.
+func (p *Proxy) injectCacheControl(body []byte) []byte {
+	if !p.cfg.PromptCaching.Enabled {
+		return body
+	}
+	var req map[string]json.RawMessage
+	if json.Unmarshal(body, &req) != nil {
+		return body
+	}
+	var system string
+	if json.Unmarshal(req["system"], &system) != nil || system == "" {
+		return body
+	}
+	minTokens := p.cfg.PromptCaching.MinTokens
+	if minTokens <= 0 {
+		minTokens = defaultCacheMinTokens
+	}
+	if estimateTokens(system) < minTokens {
+		return body
+	}
+	blocks, err := json.Marshal([]map[string]any{{
+		"type":          "text",
+		"text":          system,
+		"cache_control": map[string]string{"type": "ephemeral"},
+	}})
+	if err != nil {
+		return body
+	}
+	req["system"] = blocks
+	out, err := json.Marshal(req)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// promptCacheUsage is the prompt-cache share of an Anthropic usage object.
+type promptCacheUsage struct {
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// extractCacheUsage returns the prompt-cache tokens reported in an
+// Anthropic response body or stream event: usage for messages and
+// message_delta events, message.usage for message_start. Other providers
+// report none.
+func extractCacheUsage(provider string, body []byte) (writeTokens, readTokens int) {
+	if apiFormat(provider) != "anthropic" {
+		return 0, 0
+	}
+	var resp struct {
+		Usage   *promptCacheUsage `json:"usage"`
+		Message *struct {
+			Usage *promptCacheUsage `json:"usage"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return 0, 0
+	}
+	u := resp.Usage
+	if u == nil && resp.Message != nil {
+		u = resp.Message.Usage
+	}
+	if u == nil {
+		return 0, 0
+	}
+	return u.CacheCreationInputTokens, u.CacheReadInputTokens
+}
+
+// usageCost prices a response's regular and prompt-cache tokens.
+func usageCost(model string, inputTokens, outputTokens, cacheWrite, cacheRead int) float64 {
+	return pricing.CalculateCost(model, inputTokens, outputTokens) + pricing.CacheCost(model, cacheWrite, cacheRead)
+}
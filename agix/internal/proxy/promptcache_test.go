@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+var longSystemPrompt = strings.Repeat("You are a careful assistant. ", 250) // ~1300 estimated tokens
+
+func TestInjectCacheControl(t *testing.T) {
+	anthBody := func(system string) []byte {
+		b, _ := json.Marshal(map[string]any{"model": "claude-sonnet-4-20250514", "system": system, "max_tokens": 10})
+		return b
+	}
+	tests := []struct {
+		name   string
+		cfg    config.PromptCachingConfig
+		body   []byte
+		cached bool
+	}{
+		{"disabled", config.PromptCachingConfig{}, anthBody(longSystemPrompt), false},
+		{"large system prompt", config.PromptCachingConfig{Enabled: true}, anthBody(longSystemPrompt), true},
+		{"small system prompt", config.PromptCachingConfig{Enabled: true}, anthBody("Be brief."), false},
+		{"custom threshold", config.PromptCachingConfig{Enabled: true, MinTokens: 2}, anthBody("Be brief."), true},
+		{"no system prompt", config.PromptCachingConfig{Enabled: true}, []byte(`{"model":"claude-sonnet-4-20250514","max_tokens":10}`), false},
+		{"block array", config.PromptCachingConfig{Enabled: true, MinTokens: 1}, []byte(`{"system":[{"type":"text","text":"Be brief."}]}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			p.cfg.PromptCaching = tt.cfg
+			out := p.injectCacheControl(tt.body)
+
+			var req struct {
+				System []struct {
+					Text         string            `json:"text"`
+					CacheControl map[string]string `json:"cache_control"`
+				} `json:"system"`
+			}
+			json.Unmarshal(out, &req)
+			cached := len(req.System) == 1 && req.System[0].CacheControl["type"] == "ephemeral"
+			if cached != tt.cached {
+				t.Errorf("injectCacheControl() = %s, want cached %v", out, tt.cached)
+			}
+			if !tt.cached && !json.Valid(out) {
+				t.Errorf("injectCacheControl() = %s, invalid JSON", out)
+			}
+		})
+	}
+}
+
+func TestExtractCacheUsage(t *testing.T) {
+	tests := []struct {
+		provider    string
+		body        string
+		write, read int
+	}{
+		{"anthropic", `{"usage":{"input_tokens":10,"cache_creation_input_tokens":2000,"cache_read_input_tokens":0}}`, 2000, 0},
+		{"anthropic", `{"type":"message_start","message":{"usage":{"input_tokens":10,"cache_read_input_tokens":1500}}}`, 0, 1500},
+		{"anthropic", `{"type":"message_delta","usage":{"output_tokens":5}}`, 0, 0},
+		{"openai", `{"usage":{"cache_read_input_tokens":1500}}`, 0, 0},
+	}
+	for _, tt := range tests {
+		if w, r := extractCacheUsage(tt.provider, []byte(tt.body)); w != tt.write || r != tt.read {
+			t.Errorf("extractCacheUsage(%s, %s) = %d, %d, want %d, %d", tt.provider, tt.body, w, r, tt.write, tt.read)
+		}
+	}
+}
+
+func TestPromptCacheCost(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.cfg.PromptCaching = config.PromptCachingConfig{Enabled: true}
+
+	var sent string
+	stubUpstream(p, "application/json", `{"type":"message","role":"assistant","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn",`+
+		`"usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":2000,"cache_read_input_tokens":0}}`, &sent)
+
+	body, _ := json.Marshal(map[string]any{
+		"model":    "claude-sonnet-4-20250514",
+		"messages": []map[string]string{{"role": "system", "content": longSystemPrompt}, {"role": "user", "content": "Hi"}},
+	})
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(string(body))))
+
+	if !strings.Contains(sent, `"cache_control":{"type":"ephemeral"}`) {
+		t.Errorf("upstream request has no cache_control breakpoint: %s", sent)
+	}
+	// $3/$15 per 1M: 100 input + 50 output + 2000 cache writes at 1.25×
+	if got := w.Header().Get("X-Cost-USD"); got != "0.008550" {
+		t.Errorf("X-Cost-USD = %q, want 0.008550", got)
+	}
+	r := waitForRecord(t, st, "")
+	if r.InputTokens != 100 || r.CacheWriteTokens != 2000 || r.CacheReadTokens != 0 {
+		t.Errorf("recorded tokens = %d input, %d cache write, %d cache read", r.InputTokens, r.CacheWriteTokens, r.CacheReadTokens)
+	}
+}
+
+func TestPromptCacheCostStreaming(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	sse := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"usage":{"input_tokens":100,"output_tokens":1,"cache_creation_input_tokens":0,"cache_read_input_tokens":2000}}}`, "",
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`, "",
+		`event: message_delta`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":50}}`, "",
+	}, "\n")
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       io.NopCloser(strings.NewReader(sse)),
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	p.handleStreamingResponse(httptest.NewRecorder(), req, resp, "claude-sonnet-4-20250514", "anthropic", "", time.Now(), 0, nil)
+
+	r := waitForRecord(t, st, "")
+	if r.CacheReadTokens != 2000 || r.CacheWriteTokens != 0 {
+		t.Errorf("recorded cache tokens = %d write, %d read, want 0, 2000", r.CacheWriteTokens, r.CacheReadTokens)
+	}
+	// 100 input + 50 output + 2000 cache reads at 0.1×
+	if want := 0.0003 + 0.00075 + 0.0006; r.CostUSD < want-1e-9 || r.CostUSD > want+1e-9 {
+		t.Errorf("CostUSD = %v, want %v", r.CostUSD, want)
+	}
+}
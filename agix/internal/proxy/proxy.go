@@ -4,35 +4,59 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"math/rand"
 
+	"github.com/agent-platform/agix/internal/admission"
+	"github.com/agent-platform/agix/internal/apikeys"
+	"github.com/agent-platform/agix/internal/jwtauth"
+	"github.com/agent-platform/agix/internal/archive"
 	"github.com/agent-platform/agix/internal/audit"
 	"github.com/agent-platform/agix/internal/alert"
 	"github.com/agent-platform/agix/internal/cache"
+	"github.com/agent-platform/agix/internal/chaos"
+	"github.com/agent-platform/agix/internal/clickhouse"
 	"github.com/agent-platform/agix/internal/compressor"
 	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/credits"
 	"github.com/agent-platform/agix/internal/experiment"
 	"github.com/agent-platform/agix/internal/failover"
 	"github.com/agent-platform/agix/internal/promptinject"
 	"github.com/agent-platform/agix/internal/responsepolicy"
 	"github.com/agent-platform/agix/internal/firewall"
+	"github.com/agent-platform/agix/internal/ha"
+	"github.com/agent-platform/agix/internal/inspect"
+	"github.com/agent-platform/agix/internal/loadshed"
+	"github.com/agent-platform/agix/internal/modelcatalog"
+	"github.com/agent-platform/agix/internal/otlp"
+	"github.com/agent-platform/agix/internal/outagequeue"
+	"github.com/agent-platform/agix/internal/penalty"
 	"github.com/agent-platform/agix/internal/pricing"
+	"github.com/agent-platform/agix/internal/providerlimits"
 	"github.com/agent-platform/agix/internal/qualitygate"
 	"github.com/agent-platform/agix/internal/ratelimit"
+	"github.com/agent-platform/agix/internal/redis"
 	"github.com/agent-platform/agix/internal/router"
 	"github.com/agent-platform/agix/internal/session"
 	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/tags"
 	"github.com/agent-platform/agix/internal/toolmgr"
 	"github.com/agent-platform/agix/internal/trace"
+	"github.com/agent-platform/agix/internal/transform"
 	"github.com/agent-platform/agix/internal/webhook"
 )
 
@@ -41,25 +65,50 @@ type Proxy struct {
 	cfg         *config.Config
 	store       *store.Store
 	toolMgr     *toolmgr.Manager
-	rateLimiter *ratelimit.Limiter
+	pacer       *ratelimit.Pacer
 	failover    *failover.Failover
-	router      *router.Router
 	alerter     *alert.Alerter
-	firewall    *firewall.Firewall
 	qualityGate *qualitygate.Gate
 	cache       *cache.Cache
 	compressor  *compressor.Compressor
 	experiments    *experiment.Manager
-	promptInjector *promptinject.Injector
 	sessionMgr     *session.Manager
+	credits        *credits.Ledger
 	auditLogger    *audit.Logger
 	responsePolicy *responsepolicy.Policy
 	webhookHandler *webhook.Handler
+	shedder        *loadshed.Shedder
+	admission      *admission.Queue
+	chaos          *chaos.Injector
+	elector        *ha.Elector
+	outageQueue    *outagequeue.Queue
+	analytics      *clickhouse.Sink
+	archiver       *archive.Archiver
+	traceExporter  *otlp.Exporter
+	modelCatalog   *modelcatalog.Catalog
+	penaltyBox     *penalty.Box
+	transformer    *transform.Transformer
+	summarizer     *compressor.Summarizer
+	providerLimits *providerlimits.Tracker
+	quota          *upstreamQuota
 	auditCfg       config.AuditConfig
+	apiKeys        *apikeys.Keys
+	requireKeys    bool
+	jwt            *jwtauth.Verifier
+	internalToken  string
+	adminToken     string
+	jwtClaims      config.JWTConfig
+	sharedSpend    *redis.Spend
+	saveBudget     BudgetSaver
+	budgetMu       sync.Mutex // serializes /admin/budgets changes
 	tracingEnabled bool
 	sampleRate     float64
 	client         *http.Client
 	mux         *http.ServeMux
+
+	// hot holds what Reload can swap; options fill initialHot.
+	hot        atomic.Pointer[Hot]
+	initialHot Hot
 }
 
 // Option configures a Proxy.
@@ -72,7 +121,12 @@ func WithToolManager(m *toolmgr.Manager) Option {
 
 // WithRateLimiter sets the per-agent rate limiter.
 func WithRateLimiter(l *ratelimit.Limiter) Option {
-	return func(p *Proxy) { p.rateLimiter = l }
+	return func(p *Proxy) { p.initialHot.RateLimiter = l }
+}
+
+// WithPacer sets the per-provider outgoing traffic pacer.
+func WithPacer(pc *ratelimit.Pacer) Option {
+	return func(p *Proxy) { p.pacer = pc }
 }
 
 // WithFailover sets the multi-provider failover handler.
@@ -82,7 +136,7 @@ func WithFailover(f *failover.Failover) Option {
 
 // WithRouter sets the smart routing handler.
 func WithRouter(r *router.Router) Option {
-	return func(p *Proxy) { p.router = r }
+	return func(p *Proxy) { p.initialHot.Router = r }
 }
 
 // WithAlerter sets the budget alerter.
@@ -92,7 +146,7 @@ func WithAlerter(a *alert.Alerter) Option {
 
 // WithFirewall sets the prompt firewall.
 func WithFirewall(f *firewall.Firewall) Option {
-	return func(p *Proxy) { p.firewall = f }
+	return func(p *Proxy) { p.initialHot.Firewall = f }
 }
 
 // WithQualityGate sets the response quality gate.
@@ -117,7 +171,7 @@ func WithExperiments(m *experiment.Manager) Option {
 
 // WithPromptInjector sets the prompt template injector.
 func WithPromptInjector(inj *promptinject.Injector) Option {
-	return func(p *Proxy) { p.promptInjector = inj }
+	return func(p *Proxy) { p.initialHot.PromptInjector = inj }
 }
 
 // WithAuditLogger sets the audit logger and config.
@@ -133,6 +187,12 @@ func WithSessionManager(sm *session.Manager) Option {
 	return func(p *Proxy) { p.sessionMgr = sm }
 }
 
+// WithCredits enables prepaid credit for budgets with credits: true and
+// the /admin/credits/ API.
+func WithCredits(l *credits.Ledger) Option {
+	return func(p *Proxy) { p.credits = l }
+}
+
 // WithResponsePolicy sets the response policy layer.
 func WithResponsePolicy(pol *responsepolicy.Policy) Option {
 	return func(p *Proxy) { p.responsePolicy = pol }
@@ -143,6 +203,64 @@ func WithWebhookHandler(h *webhook.Handler) Option {
 	return func(p *Proxy) { p.webhookHandler = h }
 }
 
+// WithShedder sets the overload load shedder.
+func WithShedder(s *loadshed.Shedder) Option {
+	return func(p *Proxy) { p.shedder = s }
+}
+
+// WithChaos enables fault injection for requests with the X-Chaos header.
+func WithChaos(in *chaos.Injector) Option {
+	return func(p *Proxy) { p.chaos = in }
+}
+
+// WithElector enables active-standby mode: only the leader serves traffic.
+func WithElector(e *ha.Elector) Option {
+	return func(p *Proxy) { p.elector = e }
+}
+
+// WithOutageQueue enables queueing of opt-in requests when every model in
+// their failover chain is down.
+func WithOutageQueue(q *outagequeue.Queue) Option {
+	return func(p *Proxy) { p.outageQueue = q }
+}
+
+// WithAnalyticsSink streams every usage record to ClickHouse as well.
+func WithAnalyticsSink(s *clickhouse.Sink) Option {
+	return func(p *Proxy) { p.analytics = s }
+}
+
+// WithArchiver stores full chat request and response bodies in the
+// archive, keyed from the request record.
+func WithArchiver(a *archive.Archiver) Option {
+	return func(p *Proxy) { p.archiver = a }
+}
+
+// WithTraceExporter sends sampled traces to an OpenTelemetry collector.
+func WithTraceExporter(e *otlp.Exporter) Option {
+	return func(p *Proxy) { p.traceExporter = e }
+}
+
+// WithModelCatalog merges live provider model lists and metadata into
+// GET /v1/models.
+func WithModelCatalog(c *modelcatalog.Catalog) Option {
+	return func(p *Proxy) { p.modelCatalog = c }
+}
+
+// WithPenaltyBox throttles agents with sustained error or abuse patterns.
+func WithPenaltyBox(b *penalty.Box) Option {
+	return func(p *Proxy) { p.penaltyBox = b }
+}
+
+// WithTransformer sets the per-provider request/response transforms.
+func WithTransformer(t *transform.Transformer) Option {
+	return func(p *Proxy) { p.transformer = t }
+}
+
+// WithSummarizer enables POST /v1/summarize.
+func WithSummarizer(s *compressor.Summarizer) Option {
+	return func(p *Proxy) { p.summarizer = s }
+}
+
 // WithTracing enables per-request tracing with the given sample rate (0.0-1.0).
 func WithTracing(enabled bool, sampleRate float64) Option {
 	return func(p *Proxy) {
@@ -168,49 +286,102 @@ func New(cfg *config.Config, st *store.Store, opts ...Option) *Proxy {
 				}).DialContext,
 			},
 		},
-		mux: http.NewServeMux(),
+		mux:            http.NewServeMux(),
+		providerLimits: providerlimits.New(),
 	}
 	for _, opt := range opts {
 		opt(p)
 	}
+	p.initialHot.Budgets = cfg.Budgets
+	p.initialHot.Keys = ProviderKeys(cfg)
+	p.hot.Store(&p.initialHot)
 	p.mux.HandleFunc("/v1/chat/completions", p.handleChatCompletions)
+	p.mux.HandleFunc("/v1/completions", p.handleCompletions)
+	p.mux.HandleFunc("/v1/messages", p.handleMessages)
+	p.mux.HandleFunc("/v1/responses", p.handleResponses)
+	p.mux.HandleFunc("/v1/embeddings", p.handleEmbeddings)
+	p.mux.HandleFunc("/v1/images/generations", p.handleImageGenerations)
+	p.mux.HandleFunc("/v1/audio/transcriptions", p.handleAudioTranscriptions)
+	p.mux.HandleFunc("/v1/audio/speech", p.handleAudioSpeech)
+	p.mux.HandleFunc("/v1/summarize", p.handleSummarize)
+	p.mux.HandleFunc("/v1/truncate", p.handleTruncate)
 	p.mux.HandleFunc("/v1/models", p.handleModels)
-	p.mux.HandleFunc("/v1/sessions/", p.handleSessions)
 	p.mux.HandleFunc("/v1/webhooks/", p.handleWebhooks)
+	p.mux.HandleFunc("/v1/providers/", p.handleProviderLimits)
+	p.mux.HandleFunc("/v1/queue/", p.handleQueue)
+	p.mux.HandleFunc("/v1/data/", p.handleDataAPI)
+	p.mux.HandleFunc("/v1/tools/", p.handleToolCall)
 	p.mux.HandleFunc("/health", p.handleHealth)
+	p.mux.HandleFunc(agentPathPrefix, p.handleAgentPath)
+	p.registerAdmin()
 	return p
 }
 
 // ServeHTTP implements http.Handler.
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	p.mux.ServeHTTP(w, r)
+	if p.handleCORS(w, r) {
+		return
+	}
+	if p.standby() && r.URL.Path != "/health" {
+		http.Error(w, `{"error":"standby instance, not serving traffic"}`, http.StatusServiceUnavailable)
+		return
+	}
+	r, ok := p.authenticate(w, r)
+	if !ok {
+		return
+	}
+	p.mux.ServeHTTP(w, p.withTokenSlot(r))
+}
+
+// standby reports whether HA is enabled and this instance is not the leader.
+func (p *Proxy) standby() bool {
+	return p.elector != nil && !p.elector.IsLeader()
 }
 
 func (p *Proxy) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	if p.standby() {
+		// Not ready: load balancers should route to the leader
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, `{"status":"standby"}`)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{"status":"ok"}`)
 }
 
 func (p *Proxy) handleModels(w http.ResponseWriter, r *http.Request) {
-	models := pricing.ListModels()
-	type modelEntry struct {
-		ID      string `json:"id"`
-		Object  string `json:"object"`
-		OwnedBy string `json:"owned_by"`
-	}
+	type modelEntry = modelcatalog.Model
 	type response struct {
 		Object string       `json:"object"`
 		Data   []modelEntry `json:"data"`
 	}
-	resp := response{Object: "list"}
-	for _, m := range models {
-		resp.Data = append(resp.Data, modelEntry{
+	var static []modelEntry
+	for _, m := range pricing.ListModels() {
+		static = append(static, modelEntry{
 			ID:      m,
 			Object:  "model",
 			OwnedBy: pricing.ProviderForModel(m),
 		})
 	}
+	for deployment := range p.cfg.Azure.Deployments {
+		static = append(static, modelEntry{ID: "azure/" + deployment, Object: "model", OwnedBy: "azure"})
+	}
+	for _, m := range p.cfg.Ollama.Models {
+		static = append(static, modelEntry{ID: "ollama/" + m, Object: "model", OwnedBy: "ollama"})
+	}
+	for id := range p.cfg.Bedrock.Models {
+		static = append(static, modelEntry{ID: "bedrock/" + id, Object: "model", OwnedBy: "bedrock"})
+	}
+	for _, m := range p.cfg.OpenRouter.Models {
+		static = append(static, modelEntry{ID: "openrouter/" + m, Object: "model", OwnedBy: "openrouter"})
+	}
+	for _, pc := range p.cfg.Providers {
+		for _, m := range pc.Models {
+			static = append(static, modelEntry{ID: m, Object: "model", OwnedBy: pc.Name})
+		}
+	}
+	resp := response{Object: "list", Data: p.modelCatalog.Merge(static)}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
@@ -234,11 +405,15 @@ func (p *Proxy) newTrace() *trace.Trace {
 	return trace.New()
 }
 
-// persistTrace stores a completed trace in the background.
+// persistTrace stores a completed trace in the background and hands it to
+// the OTLP exporter, if any.
 func (p *Proxy) persistTrace(t *trace.Trace) {
 	if t == nil {
 		return
 	}
+	if p.traceExporter != nil {
+		p.traceExporter.Export(t)
+	}
 	spans := t.Spans()
 	spansJSON, err := json.Marshal(spans)
 	if err != nil {
@@ -246,7 +421,7 @@ func (p *Proxy) persistTrace(t *trace.Trace) {
 		return
 	}
 	go func() {
-		if err := p.store.InsertTrace(t.ID, t.AgentName, t.Model, t.Timestamp, spansJSON); err != nil {
+		if err := p.store.InsertTrace(t.ID, t.RequestID, t.AgentName, t.Model, t.Timestamp, spansJSON); err != nil {
 			log.Printf("ERROR: persist trace %s: %v", t.ID, err)
 		}
 	}()
@@ -257,6 +432,22 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
 		return
 	}
+	// Use one config for the whole request, even if it is reloaded meanwhile
+	hot := p.hot.Load()
+
+	// One ID ties the requests rows, trace and audit events to the response
+	requestID := requestIDFor(r)
+	w.Header().Set("X-Request-ID", requestID)
+	r = p.withArchive(withRequestID(r, requestID))
+
+	// Shed load before reading the body so an overloaded gateway stays up
+	if p.shedder != nil {
+		release, ok := p.shed(w, r)
+		if !ok {
+			return
+		}
+		defer release()
+	}
 
 	// Read request body
 	body, err := io.ReadAll(r.Body)
@@ -281,38 +472,85 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 	provider := pricing.ProviderForModel(req.Model)
 	agentName := r.Header.Get("X-Agent-Name")
 
+	if _, err := p.failoverPlan(r, req.Model); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// Payload capture (nil unless enabled alongside content audit)
+	var capture *inspect.Capture
+	if p.payloadCaptureEnabled() {
+		capture = inspect.NewCapture(requestID, body)
+	}
+
 	// Create trace (nil if disabled or not sampled)
 	tr := p.newTrace()
 	if tr != nil {
 		tr.AgentName = agentName
 		tr.Model = req.Model
+		tr.RequestID = requestID
 		w.Header().Set("X-Trace-ID", tr.ID)
 		defer p.persistTrace(tr)
 	}
 
-	// Check rate limit before budget
-	if p.rateLimiter != nil && agentName != "" {
-		sp := tr.StartSpan("rate_limit")
-		result := p.rateLimiter.Allow(agentName)
+	// Penalized agents are throttled before anything else
+	if p.penaltyBox != nil && agentName != "" {
+		sp := tr.StartSpan("penalty_box")
+		result, err := p.penaltyBox.Check(agentName)
+		if err != nil {
+			log.Printf("WARN: penalty check failed: %v", err)
+		}
 		sp.Set("allowed", result.Allowed).End()
 		if !result.Allowed {
 			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(result.RetryAfter.Seconds())))
-			http.Error(w, fmt.Sprintf(`{"error":"rate limited: %s"}`, result.Err.Error()), http.StatusTooManyRequests)
+			http.Error(w, fmt.Sprintf(`{"error":"penalty box: %s (until %s)"}`, result.Penalty.Reason, result.Penalty.ExpiresAt.Format(time.RFC3339)), http.StatusTooManyRequests)
 			return
 		}
+		p.penaltyBox.ObserveRequest(agentName, body)
 	}
 
-	// Check budget before proxying + compute alert status
-	var budgetHeaders map[string]string
-	if agentName != "" {
-		sp := tr.StartSpan("budget_check")
-		if err := p.checkBudget(agentName); err != nil {
+	// Check rate limit before budget
+	if !p.rateLimit(w, r, agentName, estimateUpstreamTokens(body), tr) {
+		return
+	}
+
+	// Check budget before proxying; the snapshot reports status on every response path
+	group := budgetGroup(r.Context())
+	var budget *budgetSnapshot
+	var downgradedFrom, checkedModel string
+	checkBudget := func(spanName string) bool {
+		sp := tr.StartSpan(spanName)
+		model, snap, err := p.budgetedModel(agentName, group, req.Model)
+		budget, checkedModel = snap, model
+		// A budget in downgrade mode sends the request to its fallback
+		// model instead of rejecting it.
+		downgraded := model != req.Model
+		if downgraded {
+			downgradedFrom = req.Model
+			req.Model = model
+			provider = pricing.ProviderForModel(model)
+			body = replaceModel(body, model)
+			capture.Record("budget_downgrade", body)
+		}
+		if err != nil {
 			sp.Set("passed", false).End()
+			// Still report so escalation levels at or above 100% fire.
+			p.reportBudget(w.Header(), budget, 0)
 			http.Error(w, fmt.Sprintf(`{"error":"budget exceeded: %s"}`, err.Error()), http.StatusTooManyRequests)
-			return
+			return false
 		}
-		sp.Set("passed", true).End()
-		budgetHeaders = p.computeBudgetAlert(agentName)
+		sp.Set("passed", true)
+		if downgraded {
+			sp.Set("downgraded_from", downgradedFrom)
+			r = withBudgetDowngrade(r)
+			w.Header().Set("X-Budget-Downgraded", downgradedFrom)
+			log.Printf("BUDGET: %s → %s (budget exceeded, downgraded)", downgradedFrom, req.Model)
+		}
+		sp.End()
+		return true
+	}
+	if !checkBudget("budget_check") {
+		return
 	}
 
 	// Session override (after budget check, before firewall)
@@ -323,6 +561,11 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			log.Printf("WARN: session override lookup failed: %v", err)
 		}
+		if so != nil && so.CostExceeded() {
+			sp.Set("cost_exceeded", true).End()
+			http.Error(w, fmt.Sprintf(`{"error":"session budget exceeded: spent $%.4f of $%.4f"}`, so.SpentUSD, *so.MaxSessionCostUSD), http.StatusTooManyRequests)
+			return
+		}
 		if so != nil {
 			body = session.Apply(body, so)
 			if err := json.Unmarshal(body, &req); err != nil {
@@ -331,6 +574,7 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			provider = pricing.ProviderForModel(req.Model)
+			capture.Record("session_override", body)
 			sp.Set("session_id", sessionID).Set("model", so.Model)
 			log.Printf("SESSION: override applied for session %s", sessionID)
 		}
@@ -338,17 +582,20 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Firewall scan (after budget check, before routing)
-	if p.firewall != nil {
+	if hot.Firewall != nil {
 		sp := tr.StartSpan("firewall")
-		result := p.firewall.Scan(req.Messages)
+		result := hot.Firewall.Scan(req.Messages)
 		sp.Set("blocked", result.Blocked).Set("warnings", len(result.Warnings)).End()
 		if result.Blocked {
-			p.auditFirewall(audit.EventFirewallBlock, agentName, result, string(req.Messages))
+			p.auditFirewall(audit.EventFirewallBlock, agentName, requestID, result, string(req.Messages))
+			if p.penaltyBox != nil {
+				p.penaltyBox.ObserveFirewallBlock(agentName)
+			}
 			http.Error(w, fmt.Sprintf(`{"error":"firewall: %s"}`, result.Message), http.StatusForbidden)
 			return
 		}
 		if len(result.Warnings) > 0 {
-			p.auditFirewall(audit.EventFirewallWarn, agentName, result, string(req.Messages))
+			p.auditFirewall(audit.EventFirewallWarn, agentName, requestID, result, string(req.Messages))
 		}
 		for _, warning := range result.Warnings {
 			w.Header().Add("X-Firewall-Warning", warning)
@@ -356,9 +603,10 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Prompt template injection (after firewall, before cache)
-	if p.promptInjector != nil {
+	if hot.PromptInjector != nil {
 		sp := tr.StartSpan("prompt_inject")
-		body = p.promptInjector.Inject(body, agentName)
+		body = hot.PromptInjector.Inject(body, agentName)
+		capture.Record("prompt_inject", body)
 		sp.Set("agent", agentName).End()
 		if err := json.Unmarshal(body, &req); err != nil {
 			http.Error(w, `{"error":"failed to re-parse request after prompt injection"}`, http.StatusInternalServerError)
@@ -374,35 +622,41 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 		if result.Hit {
 			w.Header().Set("X-Cache", "HIT")
 			w.Header().Set("Content-Type", "application/json")
-			for k, v := range budgetHeaders {
-				w.Header().Set(k, v)
+			p.reportBudget(w.Header(), budget, 0)
+			settleTokens(r, 0) // served without calling upstream
+			respBody := result.Response
+			if p.wantsUsageTrailer(r, agentName) {
+				respBody = appendUsageTrailer(respBody, usageTrailer{Model: req.Model, Cached: true, RequestID: requestID})
 			}
 			w.WriteHeader(http.StatusOK)
-			w.Write(result.Response)
+			w.Write(respBody)
 			log.Printf("CACHE: %s hit (%s)", result.Method, req.Model)
 			return
 		}
 		w.Header().Set("X-Cache", "MISS")
 	}
 
-	// Smart routing (opt-out via X-Force-Model header)
-	var originalModel string
-	if p.router != nil && r.Header.Get("X-Force-Model") == "" {
+	// Smart routing (opt-out via X-Force-Model / X-No-Route headers or
+	// routing.exclude_agents); a downgraded request keeps its fallback model
+	originalModel := downgradedFrom
+	if hot.Router != nil && originalModel == "" && r.Header.Get("X-Force-Model") == "" && r.Header.Get("X-No-Route") == "" &&
+		!hot.Router.Excludes(agentName) {
 		sp := tr.StartSpan("routing")
-		routedModel, tier := p.router.Route(req.Model, req.Messages)
+		routedModel, tier := hot.Router.Route(req.Model, req.Messages)
 		if routedModel != req.Model {
 			originalModel = req.Model
 			sp.Set("from", originalModel).Set("to", routedModel).Set("tier", tier)
 			req.Model = routedModel
 			provider = pricing.ProviderForModel(routedModel)
 			body = replaceModel(body, routedModel)
+			capture.Record("routing", body)
 			log.Printf("ROUTE: %s → %s (tier match)", originalModel, routedModel)
 		}
 		sp.End()
 	}
 
 	// Experiment routing (after smart routing, if no routing change occurred)
-	if p.experiments != nil && originalModel == "" && agentName != "" {
+	if p.experiments != nil && originalModel == "" && agentName != "" && r.Header.Get("X-No-Experiment") == "" {
 		sp := tr.StartSpan("experiment")
 		assignment := p.experiments.Assign(agentName, req.Model)
 		if assignment != nil && assignment.Model != req.Model {
@@ -410,6 +664,7 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 			req.Model = assignment.Model
 			provider = pricing.ProviderForModel(assignment.Model)
 			body = replaceModel(body, assignment.Model)
+			capture.Record("experiment", body)
 			sp.Set("name", assignment.ExperimentName).Set("variant", assignment.Variant)
 			log.Printf("EXPERIMENT: %s → %s (experiment %q, variant %q)",
 				originalModel, assignment.Model, assignment.ExperimentName, assignment.Variant)
@@ -417,8 +672,15 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 		sp.End()
 	}
 
-	// Context compression (before upstream request)
-	if p.compressor != nil {
+	// The session override, routing or an experiment may have picked
+	// another model; its own and its provider's budgets apply as well.
+	if req.Model != checkedModel && !checkBudget("budget_recheck") {
+		return
+	}
+
+	// Context compression (before upstream request). Internal agents are
+	// skipped so the compressor's own summary calls never recurse.
+	if p.compressor != nil && !store.IsInternalAgent(agentName) {
 		sp := tr.StartSpan("compression")
 		compressed := p.compressor.Compress(req.Messages)
 		wasCompressed := string(compressed) != string(req.Messages)
@@ -432,30 +694,51 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 					body = newBody
 				}
 			}
+			capture.Record("compression", body)
 		}
 	}
 
 	// Content audit: log request body (opt-in)
-	p.auditContent("request", req.Model, agentName, body)
+	p.auditContent(r, "request", req.Model, agentName, body)
+	archiveRequest(r, body)
 
 	// Check if we have tools for this agent
 	var agentTools []toolmgr.ToolEntry
 	if p.toolMgr != nil {
 		agentTools = p.toolMgr.ToolsForAgent(agentName)
+		if p.toolMgr.ClientToolsMode(agentName) == config.ClientToolsSkip && len(clientToolNames(body)) > 0 {
+			agentTools = nil // the agent brought its own tools; leave the request alone
+		}
 	}
 
 	if len(agentTools) > 0 {
 		// Tool-enhanced path: inject tools, force non-streaming, run tool loop
-		p.handleToolEnhancedRequest(w, r, body, req.Model, provider, agentName, agentTools, tr)
+		p.handleToolEnhancedRequest(w, r, body, req.Model, provider, agentName, agentTools, tr, capture, budget)
 		return
 	}
 
+	if provider == "anthropic" {
+		if anthBody, err := convertToAnthropicFormat(body); err == nil {
+			capture.Record("provider_format", anthBody)
+		}
+	}
+	p.logCapture(capture, req.Model, agentName)
+
 	sp := tr.StartSpan("upstream")
 	start := time.Now()
 	resp, actualModel, actualProvider, failoverFrom, err := p.doUpstreamRequest(r, body, req.Model, provider)
+	if p.queueable(r, req.Stream) && (err != nil || failover.IsRetryable(resp.StatusCode)) {
+		// Every model in the chain is down: park the request instead of losing it
+		if resp != nil {
+			resp.Body.Close()
+		}
+		sp.Set("provider", actualProvider).Set("queued", true).End()
+		p.enqueueOutage(w, r, body, req.Model, agentName)
+		return
+	}
 	if err != nil {
 		sp.Set("provider", provider).End()
-		http.Error(w, fmt.Sprintf(`{"error":"upstream request failed: %s"}`, err.Error()), http.StatusBadGateway)
+		writeUpstreamError(w, err)
 		return
 	}
 	defer resp.Body.Close()
@@ -467,46 +750,62 @@ func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 	}
 	sp.End()
 
-	// Add budget alert headers before writing response
-	for k, v := range budgetHeaders {
-		w.Header().Set(k, v)
-	}
-
 	if req.Stream {
-		p.handleStreamingResponse(w, resp, actualModel, actualProvider, agentName, start, duration, failoverFrom, originalModel)
+		p.handleStreamingResponse(w, r, resp, actualModel, actualProvider, agentName, start, duration, budget, failoverFrom, originalModel)
 	} else {
-		p.handleNonStreamingResponseWithGate(w, r, resp, body, actualModel, actualProvider, agentName, start, duration, failoverFrom, originalModel)
+		p.handleNonStreamingResponseWithGate(w, r, resp, body, actualModel, actualProvider, agentName, start, duration, budget, failoverFrom, originalModel)
 	}
 }
 
+// failoverPlan resolves the failover chain and retry count for model,
+// applying the request's X-Failover and X-Max-Retries overrides.
+func (p *Proxy) failoverPlan(r *http.Request, model string) (failover.Plan, error) {
+	chain := r.Header.Get("X-Failover")
+	if p.failover == nil {
+		if chain != "" && chain != failover.Off {
+			return failover.Plan{}, fmt.Errorf("unknown failover chain %q", chain)
+		}
+		return failover.Plan{}, nil
+	}
+	return p.failover.Plan(r.Header.Get("X-Agent-Name"), model, chain, r.Header.Get("X-Max-Retries"))
+}
+
 // doUpstreamRequest sends the request to the upstream provider, with failover on 5xx.
 // Returns the response, actual model/provider used, and failover_from (empty if no failover).
 func (p *Proxy) doUpstreamRequest(r *http.Request, body []byte, model, provider string) (*http.Response, string, string, string, error) {
 	resp, err := p.sendToProvider(r, body, model, provider)
-	if err != nil {
+	// A model out of provider quota moves on to the failover chain
+	var qe *providerlimits.QuotaError
+	if err != nil && (p.failover == nil || !errors.As(err, &qe)) {
 		return nil, model, provider, "", err
 	}
 
 	// Check if we should failover
-	if p.failover == nil || !failover.IsRetryable(resp.StatusCode) {
+	if p.failover == nil || err == nil && !failover.IsRetryable(resp.StatusCode) {
 		return resp, model, provider, "", nil
 	}
 
-	chain := p.failover.FallbackModels(model)
-	if len(chain) == 0 {
-		return resp, model, provider, "", nil
+	// Overrides were validated when the request arrived
+	plan, _ := p.failoverPlan(r, model)
+	chain, maxRetries := plan.Chain, plan.MaxRetries
+	if maxRetries == 0 {
+		return resp, model, provider, "", err
 	}
 
 	originalModel := model
-	maxRetries := p.failover.MaxRetries()
-	if maxRetries > len(chain) {
-		maxRetries = len(chain)
-	}
+	agentName, group := r.Header.Get("X-Agent-Name"), budgetGroup(r.Context())
 
 	for i := 0; i < maxRetries; i++ {
-		resp.Body.Close()
 		fallbackModel := chain[i]
 		fallbackProvider := failover.ResolveProvider(fallbackModel)
+		// A fallback over its own or its provider's budget is passed over
+		if err := p.checkBudget(agentName, group, fallbackProvider, fallbackModel); err != nil {
+			log.Printf("FAILOVER: skipping %s (budget exceeded: %v)", fallbackModel, err)
+			continue
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
 
 		// Re-encode body with new model
 		fallbackBody := replaceModel(body, fallbackModel)
@@ -535,6 +834,13 @@ func (p *Proxy) sendToProvider(r *http.Request, body []byte, model, provider str
 	if err != nil {
 		return nil, err
 	}
+	upstreamBody = p.transformer.Request(provider, upstreamBody)
+	if err := p.checkQuota(r.Context(), provider, model, estimateUpstreamTokens(upstreamBody)); err != nil {
+		return nil, err
+	}
+	if err := p.pacer.Wait(r.Context(), provider, estimateUpstreamTokens(upstreamBody)); err != nil {
+		return nil, err
+	}
 
 	upstreamReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, upstreamURL, bytes.NewReader(upstreamBody))
 	if err != nil {
@@ -543,8 +849,55 @@ func (p *Proxy) sendToProvider(r *http.Request, body []byte, model, provider str
 	for k, v := range upstreamHeaders {
 		upstreamReq.Header.Set(k, v)
 	}
+	if provider == "bedrock" {
+		p.signBedrock(upstreamReq, upstreamBody)
+	}
+	if resp, err := p.injectFault(r, upstreamReq, provider); resp != nil || err != nil {
+		return resp, err
+	}
+
+	resp, err := p.client.Do(upstreamReq)
+	if err != nil {
+		return nil, err
+	}
+	p.providerLimits.Observe(provider, model, resp.Header, time.Now())
+	p.observeKey(provider, upstreamReq, resp)
+	if provider == "bedrock" {
+		return bedrockResponse(resp, model, isStreaming(body))
+	}
+	return resp, nil
+}
+
+// writeUpstreamError reports a failed upstream call: 429 with Retry-After
+// when the provider pacer held the request too long or the model is out of
+// provider quota, 502 otherwise.
+func writeUpstreamError(w http.ResponseWriter, err error) int {
+	var pe *ratelimit.PaceError
+	if errors.As(err, &pe) {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(pe.RetryAfter.Seconds()))))
+		http.Error(w, fmt.Sprintf(`{"error":"rate limited: %s"}`, pe.Error()), http.StatusTooManyRequests)
+		return http.StatusTooManyRequests
+	}
+	var qe *providerlimits.QuotaError
+	if errors.As(err, &qe) {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(qe.RetryAfter.Seconds()))))
+		http.Error(w, fmt.Sprintf(`{"error":"rate limited: %s"}`, qe.Error()), http.StatusTooManyRequests)
+		return http.StatusTooManyRequests
+	}
+	http.Error(w, fmt.Sprintf(`{"error":"upstream request failed: %s"}`, err.Error()), http.StatusBadGateway)
+	return http.StatusBadGateway
+}
 
-	return p.client.Do(upstreamReq)
+// estimateUpstreamTokens approximates the tokens a request counts against a
+// provider's token ceiling: the prompt (about 4 bytes per token) plus the
+// requested output limit, which providers reserve up front.
+func estimateUpstreamTokens(body []byte) int {
+	var req struct {
+		MaxTokens           int `json:"max_tokens"`
+		MaxCompletionTokens int `json:"max_completion_tokens"`
+	}
+	json.Unmarshal(body, &req)
+	return len(body)/4 + max(req.MaxTokens, req.MaxCompletionTokens)
 }
 
 // replaceModel replaces the model field in the request body.
@@ -562,6 +915,58 @@ func replaceModel(body []byte, newModel string) []byte {
 	return out
 }
 
+// adaptReasoningParams translates reasoning-related parameters to what the
+// provider accepts for model:
+//   - OpenAI reasoning models reject max_tokens; it becomes max_completion_tokens.
+//   - OpenAI non-reasoning models reject reasoning_effort; it is dropped.
+//   - DeepSeek only knows max_tokens and has no reasoning_effort.
+func adaptReasoningParams(provider, model string, body []byte) []byte {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body
+	}
+
+	changed := false
+	rename := func(from, to string) {
+		v, ok := raw[from]
+		if !ok {
+			return
+		}
+		if _, exists := raw[to]; !exists {
+			raw[to] = v
+		}
+		delete(raw, from)
+		changed = true
+	}
+	drop := func(key string) {
+		if _, ok := raw[key]; ok {
+			delete(raw, key)
+			changed = true
+		}
+	}
+
+	switch provider {
+	case "openai", "azure":
+		if pricing.IsReasoningModel(model) {
+			rename("max_tokens", "max_completion_tokens")
+		} else {
+			drop("reasoning_effort")
+		}
+	case "deepseek":
+		rename("max_completion_tokens", "max_tokens")
+		drop("reasoning_effort")
+	}
+
+	if !changed {
+		return body
+	}
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
 func (p *Proxy) buildUpstreamRequest(provider, model string, originalBody []byte) (string, map[string]string, []byte, error) {
 	headers := map[string]string{
 		"Content-Type": "application/json",
@@ -569,16 +974,16 @@ func (p *Proxy) buildUpstreamRequest(provider, model string, originalBody []byte
 
 	switch provider {
 	case "openai":
-		apiKey, ok := p.cfg.Keys["openai"]
-		if !ok || apiKey == "" {
+		apiKey := p.key("openai")
+		if apiKey == "" {
 			return "", nil, nil, fmt.Errorf("OpenAI API key not configured")
 		}
 		headers["Authorization"] = "Bearer " + apiKey
-		return "https://api.openai.com/v1/chat/completions", headers, originalBody, nil
+		return "https://api.openai.com/v1/chat/completions", headers, adaptReasoningParams(provider, model, originalBody), nil
 
 	case "anthropic":
-		apiKey, ok := p.cfg.Keys["anthropic"]
-		if !ok || apiKey == "" {
+		apiKey := p.key("anthropic")
+		if apiKey == "" {
 			return "", nil, nil, fmt.Errorf("Anthropic API key not configured")
 		}
 		// Convert OpenAI format to Anthropic format
@@ -588,17 +993,32 @@ func (p *Proxy) buildUpstreamRequest(provider, model string, originalBody []byte
 		}
 		headers["x-api-key"] = apiKey
 		headers["anthropic-version"] = "2023-06-01"
-		return "https://api.anthropic.com/v1/messages", headers, anthBody, nil
+		return "https://api.anthropic.com/v1/messages", headers, p.injectCacheControl(anthBody), nil
 
 	case "deepseek":
-		apiKey, ok := p.cfg.Keys["deepseek"]
-		if !ok || apiKey == "" {
+		apiKey := p.key("deepseek")
+		if apiKey == "" {
 			return "", nil, nil, fmt.Errorf("DeepSeek API key not configured")
 		}
 		headers["Authorization"] = "Bearer " + apiKey
-		return "https://api.deepseek.com/chat/completions", headers, originalBody, nil
+		return "https://api.deepseek.com/chat/completions", headers, adaptReasoningParams(provider, model, originalBody), nil
+
+	case "azure":
+		return p.azureUpstream(model, adaptReasoningParams(provider, model, originalBody), headers)
+
+	case "ollama":
+		return p.ollamaUpstream(model, originalBody, headers)
+
+	case "openrouter":
+		return p.openRouterUpstream(model, originalBody, headers)
+
+	case "bedrock":
+		return p.bedrockUpstream(model, originalBody, headers)
 
 	default:
+		if pricing.IsCustomProvider(provider) {
+			return p.customUpstream(provider, model, originalBody, headers)
+		}
 		return "", nil, nil, fmt.Errorf("unsupported provider for model %q", model)
 	}
 }
@@ -608,12 +1028,18 @@ func convertToAnthropicFormat(body []byte) ([]byte, error) {
 	var openaiReq struct {
 		Model       string `json:"model"`
 		Messages    []struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
 		} `json:"messages"`
 		Stream      bool    `json:"stream"`
 		MaxTokens   int     `json:"max_tokens,omitempty"`
+		MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
 		Temperature float64 `json:"temperature,omitempty"`
+		Thinking        json.RawMessage `json:"thinking,omitempty"`
+		ReasoningEffort string          `json:"reasoning_effort,omitempty"`
+		TopP            float64         `json:"top_p,omitempty"`
+		Stop            json.RawMessage `json:"stop,omitempty"`
+		ResponseFormat  json.RawMessage `json:"response_format,omitempty"`
 	}
 
 	if err := json.Unmarshal(body, &openaiReq); err != nil {
@@ -622,22 +1048,37 @@ func convertToAnthropicFormat(body []byte) ([]byte, error) {
 
 	// Separate system message from user/assistant messages
 	var system string
-	var messages []struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	}
+	var messages []map[string]any
 
-	for _, msg := range openaiReq.Messages {
+	for i, msg := range openaiReq.Messages {
+		content, err := openAIContentToAnthropic(msg.Content)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
 		if msg.Role == "system" {
-			system = msg.Content
+			system = contentText(content)
 		} else {
-			messages = append(messages, msg)
+			messages = append(messages, map[string]any{"role": msg.Role, "content": content})
 		}
 	}
 
+	// Anthropic has no response_format; ask for the format in the prompt.
+	if instr := responseFormatInstruction(openaiReq.ResponseFormat); instr != "" {
+		if system != "" {
+			system += "\n\n"
+		}
+		system += instr
+	}
+
+	thinking, thinkingBudget := anthropicThinking(openaiReq.Thinking, openaiReq.ReasoningEffort)
+
 	maxTokens := openaiReq.MaxTokens
 	if maxTokens == 0 {
-		maxTokens = 4096
+		maxTokens = openaiReq.MaxCompletionTokens
+	}
+	if maxTokens == 0 {
+		// max_tokens must exceed the thinking budget
+		maxTokens = 4096 + thinkingBudget
 	}
 
 	anthReq := map[string]any{
@@ -651,15 +1092,33 @@ func convertToAnthropicFormat(body []byte) ([]byte, error) {
 	if openaiReq.Stream {
 		anthReq["stream"] = true
 	}
-	if openaiReq.Temperature > 0 {
+	if thinking != nil {
+		// Extended thinking is incompatible with temperature changes
+		anthReq["thinking"] = thinking
+	} else if openaiReq.Temperature > 0 {
 		anthReq["temperature"] = openaiReq.Temperature
 	}
+	if openaiReq.TopP > 0 {
+		anthReq["top_p"] = openaiReq.TopP
+	}
+	// stop is a string or a list of strings
+	var stop []string
+	if json.Unmarshal(openaiReq.Stop, &stop) != nil {
+		var s string
+		if json.Unmarshal(openaiReq.Stop, &s) == nil && s != "" {
+			stop = []string{s}
+		}
+	}
+	if len(stop) > 0 {
+		anthReq["stop_sequences"] = stop
+	}
 
 	return json.Marshal(anthReq)
 }
 
-// handleNonStreamingResponseWithGate wraps non-streaming responses with quality gate checks.
-func (p *Proxy) handleNonStreamingResponseWithGate(w http.ResponseWriter, r *http.Request, resp *http.Response, reqBody []byte, model, provider, agentName string, start time.Time, duration time.Duration, failoverFrom, originalModel string) {
+// handleNonStreamingResponseWithGate wraps non-streaming responses with
+// response policy and quality gate checks.
+func (p *Proxy) handleNonStreamingResponseWithGate(w http.ResponseWriter, r *http.Request, resp *http.Response, reqBody []byte, model, provider, agentName string, start time.Time, duration time.Duration, budget *budgetSnapshot, failoverFrom, originalModel string) {
 	// Extract messages for cache store
 	var reqMessages json.RawMessage
 	var reqParsed struct {
@@ -671,19 +1130,6 @@ func (p *Proxy) handleNonStreamingResponseWithGate(w http.ResponseWriter, r *htt
 		}
 	}
 
-	if p.qualityGate == nil {
-		respBody, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			http.Error(w, `{"error":"failed to read upstream response"}`, http.StatusBadGateway)
-			return
-		}
-		p.writeNonStreamingResponse(w, resp, respBody, model, provider, agentName, start, duration, failoverFrom, originalModel)
-		p.cacheStore(model, reqMessages, respBody)
-		return
-	}
-
-	// Read the response body to check quality
 	respBody, err := io.ReadAll(resp.Body)
 	resp.Body.Close()
 	if err != nil {
@@ -691,10 +1137,54 @@ func (p *Proxy) handleNonStreamingResponseWithGate(w http.ResponseWriter, r *htt
 		return
 	}
 
-	issue := p.qualityGate.Check(respBody)
+	// Response language and banned phrases
+	if v := p.checkResponsePolicy(resp, respBody, agentName); v != nil {
+		switch v.Action {
+		case responsepolicy.ActionReject:
+			log.Printf("RESPONSE_POLICY: reject - %s", v.Message)
+			p.recordResponse(r, resp, respBody, model, provider, agentName, start, duration, failoverFrom, originalModel)
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, "response policy: "+v.Message), http.StatusUnprocessableEntity)
+			return
+		case responsepolicy.ActionRetry:
+			// Retries restate the rules in the system message; the discarded
+			// responses are still recorded, as they were paid for.
+			retryReq := p.responsePolicy.RetryBody(reqBody, agentName)
+			for attempt := 1; v != nil && attempt <= p.responsePolicy.MaxRetries(); attempt++ {
+				log.Printf("RESPONSE_POLICY: retry - %s (attempt %d/%d)", v.Message, attempt, p.responsePolicy.MaxRetries())
+				p.recordResponse(r, resp, respBody, model, provider, agentName, start, duration, failoverFrom, originalModel)
+				start = time.Now()
+				retryResp, retryModel, retryProvider, retryFO, err := p.doUpstreamRequest(r, retryReq, model, provider)
+				if err != nil {
+					writeUpstreamError(w, err)
+					return
+				}
+				retryBody, err := io.ReadAll(retryResp.Body)
+				retryResp.Body.Close()
+				if err != nil {
+					http.Error(w, `{"error":"failed to read upstream response"}`, http.StatusBadGateway)
+					return
+				}
+				resp, respBody, model, provider, failoverFrom = retryResp, retryBody, retryModel, retryProvider, retryFO
+				duration = time.Since(start)
+				v = p.checkResponsePolicy(resp, respBody, agentName)
+			}
+		}
+		if v != nil {
+			w.Header().Set("X-Response-Policy-Violation", v.Message)
+			reqMessages = nil // don't cache it
+		}
+	}
+
+	if p.qualityGate == nil {
+		p.writeNonStreamingResponse(w, r, resp, respBody, model, provider, agentName, start, duration, budget, failoverFrom, originalModel)
+		p.cacheStore(model, reqMessages, respBody)
+		return
+	}
+
+	issue := p.qualityIssue(reqBody, respBody)
 	if issue == nil {
 		// Quality OK — write response directly
-		p.writeNonStreamingResponse(w, resp, respBody, model, provider, agentName, start, duration, failoverFrom, originalModel)
+		p.writeNonStreamingResponse(w, r, resp, respBody, model, provider, agentName, start, duration, budget, failoverFrom, originalModel)
 		p.cacheStore(model, reqMessages, respBody)
 		return
 	}
@@ -702,13 +1192,13 @@ func (p *Proxy) handleNonStreamingResponseWithGate(w http.ResponseWriter, r *htt
 	switch issue.Action {
 	case qualitygate.ActionWarn:
 		w.Header().Set("X-Quality-Warning", issue.Message)
-		p.writeNonStreamingResponse(w, resp, respBody, model, provider, agentName, start, duration, failoverFrom, originalModel)
+		p.writeNonStreamingResponse(w, r, resp, respBody, model, provider, agentName, start, duration, budget, failoverFrom, originalModel)
 		p.cacheStore(model, reqMessages, respBody)
 		return
 
 	case qualitygate.ActionReject:
 		log.Printf("QUALITY: reject - %s", issue.Message)
-		http.Error(w, fmt.Sprintf(`{"error":"quality gate: %s"}`, issue.Message), http.StatusUnprocessableEntity)
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, "quality gate: "+issue.Message), http.StatusUnprocessableEntity)
 		return
 
 	case qualitygate.ActionRetry:
@@ -718,7 +1208,7 @@ func (p *Proxy) handleNonStreamingResponseWithGate(w http.ResponseWriter, r *htt
 			retryStart := time.Now()
 			retryResp, retryModel, retryProvider, retryFO, err := p.doUpstreamRequest(r, reqBody, model, provider)
 			if err != nil {
-				http.Error(w, fmt.Sprintf(`{"error":"upstream request failed: %s"}`, err.Error()), http.StatusBadGateway)
+				writeUpstreamError(w, err)
 				return
 			}
 			retryBody, err := io.ReadAll(retryResp.Body)
@@ -729,9 +1219,9 @@ func (p *Proxy) handleNonStreamingResponseWithGate(w http.ResponseWriter, r *htt
 			}
 			retryDuration := time.Since(retryStart)
 
-			retryIssue := p.qualityGate.Check(retryBody)
+			retryIssue := p.qualityIssue(reqBody, retryBody)
 			if retryIssue == nil {
-				p.writeNonStreamingResponse(w, retryResp, retryBody, retryModel, retryProvider, agentName, retryStart, retryDuration, retryFO, originalModel)
+				p.writeNonStreamingResponse(w, r, retryResp, retryBody, retryModel, retryProvider, agentName, retryStart, retryDuration, budget, retryFO, originalModel)
 				p.cacheStore(model, reqMessages, retryBody)
 				return
 			}
@@ -739,12 +1229,30 @@ func (p *Proxy) handleNonStreamingResponseWithGate(w http.ResponseWriter, r *htt
 		}
 		// All retries exhausted, return last response with warning
 		w.Header().Set("X-Quality-Warning", issue.Message)
-		p.writeNonStreamingResponse(w, resp, respBody, model, provider, agentName, start, duration, failoverFrom, originalModel)
+		p.writeNonStreamingResponse(w, r, resp, respBody, model, provider, agentName, start, duration, budget, failoverFrom, originalModel)
 		return
 	}
 
 	// Fallback: return response as-is
-	p.writeNonStreamingResponse(w, resp, respBody, model, provider, agentName, start, duration, failoverFrom, originalModel)
+	p.writeNonStreamingResponse(w, r, resp, respBody, model, provider, agentName, start, duration, budget, failoverFrom, originalModel)
+}
+
+// qualityIssue runs the quality gate checks, then validates the answer
+// against the request's response_format.
+func (p *Proxy) qualityIssue(reqBody, respBody []byte) *qualitygate.Issue {
+	if issue := p.qualityGate.Check(respBody); issue != nil {
+		return issue
+	}
+	return p.qualityGate.CheckSchema(reqBody, respBody)
+}
+
+// checkResponsePolicy checks a successful response against the agent's
+// language and banned phrase rules.
+func (p *Proxy) checkResponsePolicy(resp *http.Response, respBody []byte, agentName string) *responsepolicy.Violation {
+	if p.responsePolicy == nil || resp.StatusCode >= 400 {
+		return nil
+	}
+	return p.responsePolicy.Check(respBody, agentName)
 }
 
 // cacheStore stores a response in the cache if enabled.
@@ -756,25 +1264,13 @@ func (p *Proxy) cacheStore(model string, messages json.RawMessage, respBody []by
 }
 
 // writeNonStreamingResponse writes a non-streaming response from an already-read body.
-func (p *Proxy) writeNonStreamingResponse(w http.ResponseWriter, resp *http.Response, respBody []byte, model, provider, agentName string, start time.Time, duration time.Duration, failoverFrom, originalModel string) {
-	p.auditContent("response", model, agentName, respBody)
-	inputTokens, outputTokens := extractUsage(provider, respBody)
-	cost := pricing.CalculateCost(model, inputTokens, outputTokens)
+func (p *Proxy) writeNonStreamingResponse(w http.ResponseWriter, r *http.Request, resp *http.Response, respBody []byte, model, provider, agentName string, start time.Time, duration time.Duration, budget *budgetSnapshot, failoverFrom, originalModel string) {
+	inputTokens, outputTokens, cost := p.recordResponse(r, resp, respBody, model, provider, agentName, start, duration, failoverFrom, originalModel)
 
-	record := &store.Record{
-		Timestamp:     start,
-		AgentName:     agentName,
-		Model:         model,
-		Provider:      provider,
-		InputTokens:   inputTokens,
-		OutputTokens:  outputTokens,
-		CostUSD:       cost,
-		DurationMS:    duration.Milliseconds(),
-		StatusCode:    resp.StatusCode,
-		FailoverFrom:  failoverFrom,
-		OriginalModel: originalModel,
+	if provider == "anthropic" && p.cfg.Thinking.Strip {
+		respBody = stripThinking(respBody)
 	}
-	p.store.InsertAsync(record)
+	respBody = p.transformer.Response(provider, respBody)
 
 	// Apply response policy (redaction, truncation, format validation)
 	if p.responsePolicy != nil {
@@ -784,19 +1280,62 @@ func (p *Proxy) writeNonStreamingResponse(w http.ResponseWriter, resp *http.Resp
 			w.Header().Set("X-Response-Policy", strings.Join(applied, ", "))
 		}
 	}
+	if resp.StatusCode < 400 && p.wantsUsageTrailer(r, agentName) {
+		respBody = appendUsageTrailer(respBody, usageTrailer{
+			Model: model, InputTokens: inputTokens, OutputTokens: outputTokens, CostUSD: cost, RequestID: requestIDFrom(r),
+		})
+	}
 
 	for k, vv := range resp.Header {
 		for _, v := range vv {
 			w.Header().Add(k, v)
 		}
 	}
+	w.Header().Del("Content-Length") // body may have been rewritten above
 	w.Header().Set("X-Cost-USD", fmt.Sprintf("%.6f", cost))
 	w.Header().Set("X-Input-Tokens", fmt.Sprintf("%d", inputTokens))
 	w.Header().Set("X-Output-Tokens", fmt.Sprintf("%d", outputTokens))
+	p.reportBudget(w.Header(), budget, cost)
 	w.WriteHeader(resp.StatusCode)
 	w.Write(respBody)
 }
 
+// recordResponse audits and records the usage of a non-streaming response,
+// returning its token counts and cost.
+func (p *Proxy) recordResponse(r *http.Request, resp *http.Response, respBody []byte, model, provider, agentName string, start time.Time, duration time.Duration, failoverFrom, originalModel string) (inputTokens, outputTokens int, cost float64) {
+	p.auditContent(r, "response", model, agentName, respBody)
+	archiveResponse(r, respBody)
+	inputTokens, outputTokens = extractUsage(provider, respBody)
+	if provider == "ollama" && inputTokens == 0 && outputTokens == 0 && resp.StatusCode < 400 {
+		inputTokens, outputTokens = estimateOllamaUsage(resp, openAICompletionText(respBody)+" "+openAIReasoningText(respBody))
+	}
+	cacheWrite, cacheRead := extractCacheUsage(provider, respBody)
+	cost = usageCost(model, inputTokens, outputTokens, cacheWrite, cacheRead)
+	if c, ok := upstreamCost(provider, respBody); ok {
+		cost = c
+	}
+
+	record := &store.Record{
+		Timestamp:       start,
+		AgentName:       agentName,
+		Model:           model,
+		Provider:        provider,
+		InputTokens:     inputTokens,
+		OutputTokens:    outputTokens,
+		CostUSD:         cost,
+		DurationMS:      duration.Milliseconds(),
+		StatusCode:      resp.StatusCode,
+		FailoverFrom:    failoverFrom,
+		OriginalModel:   originalModel,
+		ReasoningTokens: responseReasoningTokens(provider, respBody, outputTokens),
+		RequestID:       requestIDFrom(r),
+		CacheWriteTokens: cacheWrite,
+		CacheReadTokens:  cacheRead,
+	}
+	p.recordUsage(r, record)
+	return inputTokens, outputTokens, cost
+}
+
 // handleNonStreamingResponse handles a non-streaming response.
 // Optional extra args: [0] = failoverFrom, [1] = originalModel.
 func (p *Proxy) handleNonStreamingResponse(w http.ResponseWriter, resp *http.Response, model, provider, agentName string, start time.Time, duration time.Duration, extra ...string) {
@@ -808,7 +1347,8 @@ func (p *Proxy) handleNonStreamingResponse(w http.ResponseWriter, resp *http.Res
 
 	// Extract usage from response
 	inputTokens, outputTokens := extractUsage(provider, respBody)
-	cost := pricing.CalculateCost(model, inputTokens, outputTokens)
+	cacheWrite, cacheRead := extractCacheUsage(provider, respBody)
+	cost := usageCost(model, inputTokens, outputTokens, cacheWrite, cacheRead)
 
 	// Record to store
 	var foFrom, origModel string
@@ -819,17 +1359,20 @@ func (p *Proxy) handleNonStreamingResponse(w http.ResponseWriter, resp *http.Res
 		origModel = extra[1]
 	}
 	record := &store.Record{
-		Timestamp:     start,
-		AgentName:     agentName,
-		Model:         model,
-		Provider:      provider,
-		InputTokens:   inputTokens,
-		OutputTokens:  outputTokens,
-		CostUSD:       cost,
-		DurationMS:    duration.Milliseconds(),
-		StatusCode:    resp.StatusCode,
-		FailoverFrom:  foFrom,
-		OriginalModel: origModel,
+		Timestamp:       start,
+		AgentName:       agentName,
+		Model:           model,
+		Provider:        provider,
+		InputTokens:     inputTokens,
+		OutputTokens:    outputTokens,
+		CostUSD:         cost,
+		DurationMS:      duration.Milliseconds(),
+		StatusCode:      resp.StatusCode,
+		FailoverFrom:    foFrom,
+		OriginalModel:   origModel,
+		ReasoningTokens: responseReasoningTokens(provider, respBody, outputTokens),
+		CacheWriteTokens: cacheWrite,
+		CacheReadTokens:  cacheRead,
 	}
 	p.store.InsertAsync(record)
 
@@ -848,8 +1391,10 @@ func (p *Proxy) handleNonStreamingResponse(w http.ResponseWriter, resp *http.Res
 }
 
 // handleStreamingResponse handles a streaming SSE response.
+// Budget headers reflect spend before the stream; alerts are re-evaluated
+// with the stream's cost once it completes.
 // Optional extra args: [0] = failoverFrom, [1] = originalModel.
-func (p *Proxy) handleStreamingResponse(w http.ResponseWriter, resp *http.Response, model, provider, agentName string, start time.Time, duration time.Duration, extra ...string) {
+func (p *Proxy) handleStreamingResponse(w http.ResponseWriter, r *http.Request, resp *http.Response, model, provider, agentName string, start time.Time, duration time.Duration, budget *budgetSnapshot, extra ...string) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		http.Error(w, `{"error":"streaming not supported"}`, http.StatusInternalServerError)
@@ -862,18 +1407,83 @@ func (p *Proxy) handleStreamingResponse(w http.ResponseWriter, resp *http.Respon
 			w.Header().Add(k, v)
 		}
 	}
+	p.reportBudget(w.Header(), budget, 0)
 	w.WriteHeader(resp.StatusCode)
 
-	var totalInput, totalOutput int
+	var totalInput, totalOutput, totalReasoning int
+	var cacheWrite, cacheRead int  // Anthropic prompt-cache tokens
+	var ttft time.Duration         // time to the first output chunk
+	var completion strings.Builder // Ollama output text, for usage estimates
+	var reasoning strings.Builder  // reasoning text, for servers that don't count it
+	reportedCost := -1.0           // cost reported by the provider, if any
+	streamCost := func() float64 {
+		if reportedCost >= 0 {
+			return reportedCost
+		}
+		return usageCost(model, totalInput, totalOutput, cacheWrite, cacheRead)
+	}
 	scanner := bufio.NewScanner(resp.Body)
 	// Increase buffer for large SSE events
 	scanner.Buffer(make([]byte, 0, 256*1024), 256*1024)
 
+	// Anthropic thinking blocks are tallied and optionally stripped
+	var thinking *thinkingFilter
+	if provider == "anthropic" {
+		thinking = newThinkingFilter(p.cfg.Thinking.Strip)
+	}
+
+	// Anthropic streams are translated to OpenAI chunks unless the client
+	// asked for the Anthropic format.
+	var translate *anthropicToOpenAIStream
+	if _, native := w.(anthropicStreamWriter); provider == "anthropic" && !native && resp.StatusCode < 400 {
+		translate = newAnthropicToOpenAIStream(model)
+	}
+
+	// The usage trailer goes before [DONE], which many clients stop reading
+	// at, or at the end of streams that have no [DONE] (Anthropic).
+	wantTrailer := resp.StatusCode < 400 && p.wantsUsageTrailer(r, agentName)
+	estimateUsage := func() {
+		if provider == "ollama" && totalInput == 0 && totalOutput == 0 && resp.StatusCode < 400 {
+			totalInput, totalOutput = estimateOllamaUsage(resp, completion.String()+" "+reasoning.String())
+		}
+	}
+	writeTrailer := func() {
+		estimateUsage()
+		fmt.Fprint(w, usageTrailer{
+			Model:        model,
+			InputTokens:  totalInput,
+			OutputTokens: totalOutput,
+			CostUSD:      streamCost(),
+			RequestID:    requestIDFrom(r),
+		}.sseComment())
+		wantTrailer = false
+	}
+
+	forward := func(line string) {
+		out := []string{line}
+		if translate != nil {
+			out = translate.Convert(line)
+		}
+		for _, l := range out {
+			if wantTrailer && l == "data: [DONE]" {
+				writeTrailer()
+			}
+			fmt.Fprintf(w, "%s\n", l)
+		}
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
+		archiveStreamLine(r, line)
 
 		// Forward line to client
-		fmt.Fprintf(w, "%s\n", line)
+		if thinking != nil {
+			for _, out := range thinking.Feed(line) {
+				forward(out)
+			}
+		} else {
+			forward(line)
+		}
 		flusher.Flush()
 
 		// Parse SSE data lines for usage
@@ -882,6 +1492,15 @@ func (p *Proxy) handleStreamingResponse(w http.ResponseWriter, resp *http.Respon
 			if data == "[DONE]" {
 				continue
 			}
+			if ttft == 0 && streamsOutput(provider, []byte(data)) {
+				ttft = time.Since(start)
+			}
+			if provider == "ollama" {
+				completion.WriteString(openAIDeltaText([]byte(data)))
+			}
+			if apiFormat(provider) != "anthropic" {
+				reasoning.WriteString(openAIDeltaReasoning([]byte(data)))
+			}
 			input, output := extractStreamUsage(provider, []byte(data))
 			if input > 0 {
 				totalInput = input
@@ -889,14 +1508,45 @@ func (p *Proxy) handleStreamingResponse(w http.ResponseWriter, resp *http.Respon
 			if output > 0 {
 				totalOutput = output
 			}
+			if cw, cr := extractCacheUsage(provider, []byte(data)); cw > 0 || cr > 0 {
+				cacheWrite, cacheRead = cw, cr
+			}
+			if reasoning := extractReasoningTokens(provider, []byte(data)); reasoning > 0 {
+				totalReasoning = reasoning
+			}
+			if c, ok := upstreamCost(provider, []byte(data)); ok {
+				reportedCost = c
+			}
 		}
 	}
 
+	if thinking != nil {
+		for _, out := range thinking.Flush() {
+			forward(out)
+		}
+		flusher.Flush()
+		totalReasoning = min(thinking.ReasoningTokens(), totalOutput)
+	}
+	if translate != nil {
+		for _, l := range translate.Finish() {
+			forward(l)
+		}
+		flusher.Flush()
+	}
+	estimateUsage()
+	if totalReasoning == 0 && reasoning.Len() > 0 {
+		totalReasoning = estimateReasoningTokens(reasoning.String(), totalOutput)
+	}
+	if wantTrailer {
+		writeTrailer()
+		flusher.Flush()
+	}
+
 	// Content audit: log response (streaming — no body captured, log summary)
-	p.auditContent("response", model, agentName, []byte(fmt.Sprintf(`{"streaming":true,"input_tokens":%d,"output_tokens":%d}`, totalInput, totalOutput)))
+	p.auditContent(r, "response", model, agentName, []byte(fmt.Sprintf(`{"streaming":true,"input_tokens":%d,"output_tokens":%d}`, totalInput, totalOutput)))
 
 	elapsed := time.Since(start)
-	cost := pricing.CalculateCost(model, totalInput, totalOutput)
+	cost := streamCost()
 
 	// Record to store
 	var foFrom, origModel string
@@ -907,25 +1557,32 @@ func (p *Proxy) handleStreamingResponse(w http.ResponseWriter, resp *http.Respon
 		origModel = extra[1]
 	}
 	record := &store.Record{
-		Timestamp:     start,
-		AgentName:     agentName,
-		Model:         model,
-		Provider:      provider,
-		InputTokens:   totalInput,
-		OutputTokens:  totalOutput,
-		CostUSD:       cost,
-		DurationMS:    elapsed.Milliseconds(),
-		StatusCode:    resp.StatusCode,
-		FailoverFrom:  foFrom,
-		OriginalModel: origModel,
-	}
-	p.store.InsertAsync(record)
+		Timestamp:       start,
+		AgentName:       agentName,
+		Model:           model,
+		Provider:        provider,
+		InputTokens:     totalInput,
+		OutputTokens:    totalOutput,
+		CostUSD:         cost,
+		DurationMS:      elapsed.Milliseconds(),
+		StatusCode:      resp.StatusCode,
+		FailoverFrom:    foFrom,
+		OriginalModel:   origModel,
+		ReasoningTokens: totalReasoning,
+		RequestID:       requestIDFrom(r),
+		TTFTMS:          ttftMillis(ttft),
+		TokensPerSec:    tokensPerSec(totalOutput, ttft, elapsed),
+		CacheWriteTokens: cacheWrite,
+		CacheReadTokens:  cacheRead,
+	}
+	p.recordUsage(r, record)
+	p.reportBudget(nil, budget, cost)
 }
 
 // extractUsage extracts token usage from a non-streaming response.
 func extractUsage(provider string, body []byte) (inputTokens, outputTokens int) {
-	switch provider {
-	case "openai", "deepseek":
+	switch apiFormat(provider) {
+	case "openai", "azure", "deepseek", "ollama", "openrouter", "bedrock":
 		var resp struct {
 			Usage struct {
 				PromptTokens     int `json:"prompt_tokens"`
@@ -949,10 +1606,31 @@ func extractUsage(provider string, body []byte) (inputTokens, outputTokens int)
 	return 0, 0
 }
 
+// extractReasoningTokens returns the reasoning tokens reported in a response
+// body or stream chunk. They are already included in the output token count.
+func extractReasoningTokens(provider string, body []byte) int {
+	switch apiFormat(provider) {
+	case "openai", "azure", "deepseek", "ollama", "openrouter", "bedrock":
+		var resp struct {
+			Usage *struct {
+				CompletionTokensDetails struct {
+					ReasoningTokens int `json:"reasoning_tokens"`
+				} `json:"completion_tokens_details"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal(body, &resp); err == nil && resp.Usage != nil {
+			return resp.Usage.CompletionTokensDetails.ReasoningTokens
+		}
+	case "anthropic":
+		return anthropicThinkingTokens(body)
+	}
+	return 0
+}
+
 // extractStreamUsage extracts token usage from a single SSE data chunk.
 func extractStreamUsage(provider string, data []byte) (inputTokens, outputTokens int) {
-	switch provider {
-	case "openai", "deepseek":
+	switch apiFormat(provider) {
+	case "openai", "azure", "deepseek", "ollama", "openrouter", "bedrock":
 		var chunk struct {
 			Usage *struct {
 				PromptTokens     int `json:"prompt_tokens"`
@@ -989,32 +1667,93 @@ func extractStreamUsage(provider string, data []byte) (inputTokens, outputTokens
 }
 
 // handleToolEnhancedRequest runs the tool execution loop: inject tools → send to LLM → execute tool calls → repeat.
-func (p *Proxy) handleToolEnhancedRequest(w http.ResponseWriter, r *http.Request, body []byte, model, provider, agentName string, tools []toolmgr.ToolEntry, tr *trace.Trace) {
+func (p *Proxy) handleToolEnhancedRequest(w http.ResponseWriter, r *http.Request, body []byte, model, provider, agentName string, tools []toolmgr.ToolEntry, tr *trace.Trace, capture *inspect.Capture, budget *budgetSnapshot) {
 	start := time.Now()
 
 	// Force stream=false for tool-enhanced requests (agent is unaware of tools)
 	body = forceNonStreaming(body)
 
-	// Inject tool definitions into the request body
+	// Inject tool definitions into the request body. In merge mode the
+	// agent's own tools stay, and calls to them go back to the agent.
+	clientTools := map[string]bool{}
+	if p.toolMgr.ClientToolsMode(agentName) == config.ClientToolsMerge {
+		for _, name := range clientToolNames(body) {
+			clientTools[name] = true
+		}
+	}
+	clientBody := body
 	body = injectTools(body, tools, provider)
+	if len(clientTools) > 0 {
+		body = mergeClientTools(body, clientBody)
+	}
+	capture.Record("tool_injection", body)
+	p.logCapture(capture, model, agentName)
 
 	maxIter := p.cfg.Tools.MaxIterations
 	if maxIter <= 0 {
 		maxIter = 10
 	}
-
-	var totalInput, totalOutput int
+	maxRepeat := p.cfg.Tools.MaxRepeatCalls
+	if maxRepeat <= 0 {
+		maxRepeat = 3
+	}
+	repeats := map[string]int{} // identical tool calls seen so far, by toolCallKey
+
+	var totalInput, totalOutput, totalReasoning, upstreamCalls int
+	var cacheWrite, cacheRead int // Anthropic prompt-cache tokens
+	var reportedCost float64 // summed provider-reported cost, if every iteration reported one
+	costReported := true
+
+	// recordSpent stores the usage of every upstream call made so far and
+	// returns its cost. Every exit records, so a tool loop that fails after
+	// some iterations still counts against the agent's budget.
+	recordSpent := func(statusCode int) float64 {
+		if upstreamCalls == 0 {
+			return 0
+		}
+		cost := usageCost(model, totalInput, totalOutput, cacheWrite, cacheRead)
+		if costReported {
+			cost = reportedCost
+		}
+		p.recordUsage(r, &store.Record{
+			Timestamp:       start,
+			AgentName:       agentName,
+			Model:           model,
+			Provider:        provider,
+			InputTokens:     totalInput,
+			OutputTokens:    totalOutput,
+			CostUSD:         cost,
+			DurationMS:      time.Since(start).Milliseconds(),
+			StatusCode:      statusCode,
+			ReasoningTokens: totalReasoning,
+			RequestID:       requestIDFrom(r),
+			CacheWriteTokens: cacheWrite,
+			CacheReadTokens:  cacheRead,
+		})
+		return cost
+	}
 
 	for i := 0; i < maxIter; i++ {
 		// Build upstream request
 		upstreamURL, upstreamHeaders, upstreamBody, err := p.buildUpstreamRequestRaw(provider, model, body)
 		if err != nil {
+			recordSpent(http.StatusBadGateway)
 			http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusBadGateway)
 			return
 		}
+		upstreamBody = p.transformer.Request(provider, upstreamBody)
+		if err := p.checkQuota(r.Context(), provider, model, estimateUpstreamTokens(upstreamBody)); err != nil {
+			recordSpent(writeUpstreamError(w, err))
+			return
+		}
+		if err := p.pacer.Wait(r.Context(), provider, estimateUpstreamTokens(upstreamBody)); err != nil {
+			recordSpent(writeUpstreamError(w, err))
+			return
+		}
 
 		upstreamReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, upstreamURL, bytes.NewReader(upstreamBody))
 		if err != nil {
+			recordSpent(http.StatusInternalServerError)
 			http.Error(w, `{"error":"failed to create upstream request"}`, http.StatusInternalServerError)
 			return
 		}
@@ -1022,72 +1761,106 @@ func (p *Proxy) handleToolEnhancedRequest(w http.ResponseWriter, r *http.Request
 			upstreamReq.Header.Set(k, v)
 		}
 
-		resp, err := p.client.Do(upstreamReq)
+		resp, err := p.injectFault(r, upstreamReq, provider)
+		if resp == nil && err == nil {
+			resp, err = p.client.Do(upstreamReq)
+			if err == nil {
+				p.observeKey(provider, upstreamReq, resp)
+			}
+		}
 		if err != nil {
-			http.Error(w, fmt.Sprintf(`{"error":"upstream request failed: %s"}`, err.Error()), http.StatusBadGateway)
+			recordSpent(writeUpstreamError(w, err))
 			return
 		}
+		p.providerLimits.Observe(provider, model, resp.Header, time.Now())
 
 		respBody, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		if err != nil {
+			recordSpent(http.StatusBadGateway)
 			http.Error(w, `{"error":"failed to read upstream response"}`, http.StatusBadGateway)
 			return
 		}
+		upstreamCalls++
+		archiveResponse(r, respBody)
 
 		// Accumulate tokens
 		input, output := extractUsage(provider, respBody)
 		totalInput += input
 		totalOutput += output
+		totalReasoning += responseReasoningTokens(provider, respBody, output)
+		write, read := extractCacheUsage(provider, respBody)
+		cacheWrite += write
+		cacheRead += read
+		if c, ok := upstreamCost(provider, respBody); ok {
+			reportedCost += c
+		} else {
+			costReported = false
+		}
 
 		// Check if there are tool calls
 		toolCalls := extractToolCalls(provider, respBody)
-		if len(toolCalls) == 0 {
-			// No tool calls — return final response to the agent
-			// Strip tool-related fields from the response so agent is unaware
-			finalBody := stripToolCalls(provider, respBody)
-			cost := pricing.CalculateCost(model, totalInput, totalOutput)
-			duration := time.Since(start)
+		clientCalls := slices.ContainsFunc(toolCalls, func(tc toolCall) bool { return clientTools[tc.Name] })
+		if len(toolCalls) == 0 || clientCalls {
+			// No tool calls, or calls the agent runs itself — return the response
+			// to the agent. Strip MCP tool calls so the agent is unaware of them;
+			// any made in the same turn as an agent call are dropped, and the
+			// model can repeat them after the agent answers.
+			finalBody := filterToolCalls(provider, respBody, func(name string) bool { return clientTools[name] })
+			if provider == "anthropic" && p.cfg.Thinking.Strip {
+				finalBody = stripThinking(finalBody)
+			}
+			finalBody = p.transformer.Response(provider, finalBody)
+			cost := recordSpent(resp.StatusCode)
 
-			record := &store.Record{
-				Timestamp:    start,
-				AgentName:    agentName,
-				Model:        model,
-				Provider:     provider,
-				InputTokens:  totalInput,
-				OutputTokens: totalOutput,
-				CostUSD:      cost,
-				DurationMS:   duration.Milliseconds(),
-				StatusCode:   resp.StatusCode,
+			if resp.StatusCode < 400 && p.wantsUsageTrailer(r, agentName) {
+				finalBody = appendUsageTrailer(finalBody, usageTrailer{
+					Model: model, InputTokens: totalInput, OutputTokens: totalOutput, CostUSD: cost, RequestID: requestIDFrom(r),
+				})
 			}
-			p.store.InsertAsync(record)
 
 			for k, vv := range resp.Header {
 				for _, v := range vv {
 					w.Header().Add(k, v)
 				}
 			}
+			w.Header().Del("Content-Length") // finalBody was rewritten
 			w.Header().Set("X-Cost-USD", fmt.Sprintf("%.6f", cost))
 			w.Header().Set("X-Input-Tokens", fmt.Sprintf("%d", totalInput))
 			w.Header().Set("X-Output-Tokens", fmt.Sprintf("%d", totalOutput))
+			p.reportBudget(w.Header(), budget, cost)
 			w.WriteHeader(resp.StatusCode)
 			w.Write(finalBody)
 			return
 		}
 
+		// A model that keeps issuing the same call is stuck; stop it instead
+		// of burning the remaining iterations.
+		for _, tc := range toolCalls {
+			key := toolCallKey(tc)
+			repeats[key]++
+			if repeats[key] >= maxRepeat {
+				p.auditToolLoop(tc, agentName, requestIDFrom(r), repeats[key], i+1)
+				recordSpent(http.StatusLoopDetected)
+				writeToolLoopError(w, tc.Name, repeats[key], i+1)
+				return
+			}
+		}
+
 		// Execute tool calls via MCP
 		for _, tc := range toolCalls {
 			sp := tr.StartSpan("tool_call")
 			sp.Set("name", tc.Name).Set("iteration", i+1)
 			sp.End()
 		}
-		results := p.executeMCPTools(toolCalls, agentName)
+		results := p.executeMCPTools(toolCalls, agentName, requestIDFrom(r))
 
 		// Append assistant message + tool results to the conversation
 		body = appendToolResults(body, provider, respBody, toolCalls, results)
 	}
 
 	// Exceeded max iterations
+	recordSpent(http.StatusInternalServerError)
 	http.Error(w, fmt.Sprintf(`{"error":"tool execution exceeded max iterations (%d)"}`, maxIter), http.StatusInternalServerError)
 }
 
@@ -1098,6 +1871,29 @@ type toolCall struct {
 	Arguments map[string]any `json:"arguments"`
 }
 
+// toolCallKey identifies a call by tool name and arguments. json.Marshal
+// sorts map keys, so equal arguments give equal keys.
+func toolCallKey(tc toolCall) string {
+	args, _ := json.Marshal(tc.Arguments)
+	return tc.Name + "\x00" + string(args)
+}
+
+// writeToolLoopError rejects a request whose tool loop was stopped for
+// repeating the same call, with enough detail for the agent to tell it
+// apart from an upstream failure.
+func writeToolLoopError(w http.ResponseWriter, tool string, repeats, iteration int) {
+	body, _ := json.Marshal(map[string]any{
+		"error":     fmt.Sprintf("tool loop detected: %s called %d times with identical arguments", tool, repeats),
+		"type":      "tool_loop",
+		"tool":      tool,
+		"repeats":   repeats,
+		"iteration": iteration,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusLoopDetected)
+	w.Write(body)
+}
+
 // forceNonStreaming sets stream=false in the request body.
 func forceNonStreaming(body []byte) []byte {
 	var raw map[string]json.RawMessage
@@ -1167,10 +1963,74 @@ func injectTools(body []byte, tools []toolmgr.ToolEntry, provider string) []byte
 	return out
 }
 
+// toolName returns the name of a tool definition in OpenAI
+// ({"type":"function","function":{"name":...}}) or Anthropic ({"name":...})
+// format.
+func toolName(raw json.RawMessage) string {
+	var t struct {
+		Name     string `json:"name"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	json.Unmarshal(raw, &t)
+	if t.Function.Name != "" {
+		return t.Function.Name
+	}
+	return t.Name
+}
+
+// clientToolNames returns the names of the tools an agent sent in its
+// request.
+func clientToolNames(body []byte) []string {
+	var req struct {
+		Tools []json.RawMessage `json:"tools"`
+	}
+	json.Unmarshal(body, &req)
+	var names []string
+	for _, t := range req.Tools {
+		if name := toolName(t); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// mergeClientTools puts the tools the agent sent in clientBody back in
+// front of the MCP tools injected into body. An MCP tool with the same name
+// as one of the agent's is dropped, so the agent's definition wins.
+func mergeClientTools(body, clientBody []byte) []byte {
+	var raw map[string]json.RawMessage
+	var injected []json.RawMessage
+	if json.Unmarshal(body, &raw) != nil || json.Unmarshal(raw["tools"], &injected) != nil {
+		return body
+	}
+	var client struct {
+		Tools []json.RawMessage `json:"tools"`
+	}
+	json.Unmarshal(clientBody, &client)
+	names := make(map[string]bool, len(client.Tools))
+	for _, t := range client.Tools {
+		names[toolName(t)] = true
+	}
+	merged := slices.Clone(client.Tools)
+	for _, t := range injected {
+		if !names[toolName(t)] {
+			merged = append(merged, t)
+		}
+	}
+	raw["tools"], _ = json.Marshal(merged)
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
 // extractToolCalls extracts tool calls from an LLM response.
 func extractToolCalls(provider string, respBody []byte) []toolCall {
-	switch provider {
-	case "openai", "deepseek":
+	switch apiFormat(provider) {
+	case "openai", "azure", "deepseek", "ollama", "openrouter", "bedrock":
 		return extractOpenAIToolCalls(respBody)
 	case "anthropic":
 		return extractAnthropicToolCalls(respBody)
@@ -1252,7 +2112,7 @@ func extractAnthropicToolCalls(body []byte) []toolCall {
 // executeMCPTools executes tool calls via the tool manager concurrently.
 // Different MCP servers are called in parallel; same-server calls are naturally
 // serialized by the per-client mutex in the MCP client.
-func (p *Proxy) executeMCPTools(calls []toolCall, agentName string) []string {
+func (p *Proxy) executeMCPTools(calls []toolCall, agentName, requestID string) []string {
 	results := make([]string, len(calls))
 	var wg sync.WaitGroup
 	wg.Add(len(calls))
@@ -1269,7 +2129,7 @@ func (p *Proxy) executeMCPTools(calls []toolCall, agentName string) []string {
 			} else {
 				results[i] = text
 			}
-			p.auditToolCall(tc, agentName, status, duration)
+			p.auditToolCall(tc, agentName, requestID, status, duration)
 		}(i, tc)
 	}
 	wg.Wait()
@@ -1278,8 +2138,8 @@ func (p *Proxy) executeMCPTools(calls []toolCall, agentName string) []string {
 
 // appendToolResults appends the assistant response and tool results to the conversation.
 func appendToolResults(body []byte, provider string, respBody []byte, calls []toolCall, results []string) []byte {
-	switch provider {
-	case "openai", "deepseek":
+	switch apiFormat(provider) {
+	case "openai", "azure", "deepseek", "ollama", "openrouter", "bedrock":
 		return appendOpenAIToolResults(body, respBody, calls, results)
 	case "anthropic":
 		return appendAnthropicToolResults(body, respBody, calls, results)
@@ -1386,16 +2246,26 @@ func appendAnthropicToolResults(body []byte, respBody []byte, calls []toolCall,
 
 // stripToolCalls removes tool-related fields from the final response so the agent is unaware.
 func stripToolCalls(provider string, respBody []byte) []byte {
-	switch provider {
-	case "openai", "deepseek":
-		return stripOpenAIToolCalls(respBody)
+	return filterToolCalls(provider, respBody, nil)
+}
+
+// filterToolCalls removes the tool calls keep rejects from a response (all
+// of them if keep is nil). The finish reason says "stop" only if none are
+// left.
+func filterToolCalls(provider string, respBody []byte, keep func(name string) bool) []byte {
+	if keep == nil {
+		keep = func(string) bool { return false }
+	}
+	switch apiFormat(provider) {
+	case "openai", "azure", "deepseek", "ollama", "openrouter", "bedrock":
+		return filterOpenAIToolCalls(respBody, keep)
 	case "anthropic":
-		return stripAnthropicToolCalls(respBody)
+		return filterAnthropicToolCalls(respBody, keep)
 	}
 	return respBody
 }
 
-func stripOpenAIToolCalls(body []byte) []byte {
+func filterOpenAIToolCalls(body []byte, keep func(name string) bool) []byte {
 	var resp map[string]json.RawMessage
 	if err := json.Unmarshal(body, &resp); err != nil {
 		return body
@@ -1406,17 +2276,37 @@ func stripOpenAIToolCalls(body []byte) []byte {
 		return body
 	}
 
-	// Update finish_reason to "stop"
-	choices[0]["finish_reason"] = json.RawMessage(`"stop"`)
-
-	// Remove tool_calls from the message
+	// Drop the tool_calls keep rejects from the message
 	var message map[string]json.RawMessage
+	var kept []json.RawMessage
 	if err := json.Unmarshal(choices[0]["message"], &message); err == nil {
-		delete(message, "tool_calls")
+		var calls []json.RawMessage
+		json.Unmarshal(message["tool_calls"], &calls)
+		for _, call := range calls {
+			var c struct {
+				Function struct {
+					Name string `json:"name"`
+				} `json:"function"`
+			}
+			json.Unmarshal(call, &c)
+			if keep(c.Function.Name) {
+				kept = append(kept, call)
+			}
+		}
+		if len(kept) > 0 {
+			message["tool_calls"], _ = json.Marshal(kept)
+		} else {
+			delete(message, "tool_calls")
+		}
 		msgData, _ := json.Marshal(message)
 		choices[0]["message"] = msgData
 	}
 
+	// Update finish_reason to "stop" unless the agent has calls to run
+	if len(kept) == 0 {
+		choices[0]["finish_reason"] = json.RawMessage(`"stop"`)
+	}
+
 	choicesData, _ := json.Marshal(choices)
 	resp["choices"] = choicesData
 
@@ -1424,24 +2314,26 @@ func stripOpenAIToolCalls(body []byte) []byte {
 	return out
 }
 
-func stripAnthropicToolCalls(body []byte) []byte {
+func filterAnthropicToolCalls(body []byte, keep func(name string) bool) []byte {
 	var resp map[string]json.RawMessage
 	if err := json.Unmarshal(body, &resp); err != nil {
 		return body
 	}
 
-	// Update stop_reason to "end_turn"
-	resp["stop_reason"] = json.RawMessage(`"end_turn"`)
-
-	// Filter out tool_use blocks from content
+	// Filter out the tool_use blocks keep rejects from content
+	kept := 0
 	var content []map[string]json.RawMessage
 	if err := json.Unmarshal(resp["content"], &content); err == nil {
 		var filtered []map[string]json.RawMessage
 		for _, block := range content {
-			var blockType string
+			var blockType, name string
 			json.Unmarshal(block["type"], &blockType)
+			json.Unmarshal(block["name"], &name)
 			if blockType != "tool_use" {
 				filtered = append(filtered, block)
+			} else if keep(name) {
+				filtered = append(filtered, block)
+				kept++
 			}
 		}
 		if filtered == nil {
@@ -1451,6 +2343,11 @@ func stripAnthropicToolCalls(body []byte) []byte {
 		resp["content"] = contentData
 	}
 
+	// Update stop_reason to "end_turn" unless the agent has calls to run
+	if kept == 0 {
+		resp["stop_reason"] = json.RawMessage(`"end_turn"`)
+	}
+
 	out, _ := json.Marshal(resp)
 	return out
 }
@@ -1464,56 +2361,219 @@ func (p *Proxy) buildUpstreamRequestRaw(provider, model string, body []byte) (st
 
 	switch provider {
 	case "openai":
-		apiKey, ok := p.cfg.Keys["openai"]
-		if !ok || apiKey == "" {
+		apiKey := p.key("openai")
+		if apiKey == "" {
 			return "", nil, nil, fmt.Errorf("OpenAI API key not configured")
 		}
 		headers["Authorization"] = "Bearer " + apiKey
-		return "https://api.openai.com/v1/chat/completions", headers, body, nil
+		return "https://api.openai.com/v1/chat/completions", headers, adaptReasoningParams(provider, model, body), nil
 
 	case "anthropic":
-		apiKey, ok := p.cfg.Keys["anthropic"]
-		if !ok || apiKey == "" {
+		apiKey := p.key("anthropic")
+		if apiKey == "" {
 			return "", nil, nil, fmt.Errorf("Anthropic API key not configured")
 		}
 		headers["x-api-key"] = apiKey
 		headers["anthropic-version"] = "2023-06-01"
-		return "https://api.anthropic.com/v1/messages", headers, body, nil
+		return "https://api.anthropic.com/v1/messages", headers, p.injectCacheControl(body), nil
 
 	case "deepseek":
-		apiKey, ok := p.cfg.Keys["deepseek"]
-		if !ok || apiKey == "" {
+		apiKey := p.key("deepseek")
+		if apiKey == "" {
 			return "", nil, nil, fmt.Errorf("DeepSeek API key not configured")
 		}
 		headers["Authorization"] = "Bearer " + apiKey
-		return "https://api.deepseek.com/chat/completions", headers, body, nil
+		return "https://api.deepseek.com/chat/completions", headers, adaptReasoningParams(provider, model, body), nil
+
+	case "azure":
+		return p.azureUpstream(model, adaptReasoningParams(provider, model, body), headers)
+
+	case "ollama":
+		return p.ollamaUpstream(model, body, headers)
+
+	case "openrouter":
+		return p.openRouterUpstream(model, body, headers)
+
+	case "bedrock":
+		return "", nil, nil, fmt.Errorf("MCP tool injection is not supported for Bedrock models")
 
 	default:
+		if pricing.IsCustomProvider(provider) {
+			return p.customUpstream(provider, model, body, headers)
+		}
 		return "", nil, nil, fmt.Errorf("unsupported provider for model %q", model)
 	}
 }
 
-func (p *Proxy) checkBudget(agentName string) error {
-	budget, ok := p.cfg.Budgets[agentName]
+// recordUsage stores a usage record and charges its cost to the request's
+// session, so session spend caps see it on the next request. Its tokens
+// replace the estimate held against the agent's token rate limits.
+func (p *Proxy) recordUsage(r *http.Request, record *store.Record) {
+	if record.ArchiveKey == "" {
+		record.ArchiveKey = p.archiveExchange(r)
+	}
+	if record.Tags == "" && r != nil {
+		record.Tags = requestTags(r)
+	}
+	if budgetDowngraded(r) {
+		record.Tags = tagDowngraded(record.Tags)
+	}
+	if record.Project == "" && r != nil {
+		record.Project = p.projectFor(r, record.AgentName)
+	}
+	p.store.InsertAsync(record)
+	settleTokens(r, record.InputTokens+record.OutputTokens)
+	p.addSharedSpend(record)
+	if p.analytics != nil {
+		p.analytics.Send(record)
+	}
+	if p.penaltyBox != nil {
+		p.penaltyBox.ObserveOutcome(record.AgentName, record.StatusCode)
+	}
+	if p.sessionMgr == nil || r == nil || record.CostUSD <= 0 {
+		return
+	}
+	if sessionID := r.Header.Get("X-Session-ID"); sessionID != "" {
+		if err := p.sessionMgr.AddCost(sessionID, record.CostUSD); err != nil {
+			log.Printf("WARN: %v", err)
+		}
+	}
+}
+
+// requestTags returns the canonical X-Request-Tags of r. Malformed pairs
+// are dropped rather than failing a request that already reached upstream.
+func requestTags(r *http.Request) string {
+	h := r.Header.Get(tags.Header)
+	if h == "" {
+		return ""
+	}
+	t, err := tags.Parse(h)
+	if err != nil {
+		log.Printf("WARN: %v", err)
+	}
+	return t.String()
+}
+
+// budgetedModel checks the budgets of a request for model. When a budget
+// in downgrade mode is spent, the request moves to its fallback model,
+// which is checked in turn. It returns the model to send the request to
+// and its budget snapshot.
+func (p *Proxy) budgetedModel(agentName, group, model string) (string, *budgetSnapshot, error) {
+	provider := pricing.ProviderForModel(model)
+	err := p.checkBudget(agentName, group, provider, model)
+	if be, ok := err.(*budgetError); ok && be.fallback != "" && be.fallback != model {
+		model = be.fallback
+		provider = pricing.ProviderForModel(model)
+		err = p.checkBudget(agentName, group, provider, model)
+	}
+	return model, p.snapshotBudget(agentName, group, provider, model), err
+}
+
+// checkBudget applies the budgets a request counts against: the global
+// budget and its provider's and model's budgets, which hold with or
+// without an agent name, then its agent's own. An exceeded budget is
+// returned as a *budgetError.
+func (p *Proxy) checkBudget(agentName, group, provider, model string) error {
+	budgets := p.hot.Load().Budgets
+	now := time.Now().UTC()
+
+	if b, ok := budgets[config.GlobalBudget]; ok {
+		if err := p.checkScope(spendScope{}, b, model, now); err != nil {
+			return err
+		}
+	}
+	if b, ok := budgets[config.ProviderBudget(provider)]; ok && provider != "" {
+		if err := p.checkScope(spendScope{provider: provider}, b, model, now); err != nil {
+			return err
+		}
+	}
+	if b, ok := budgets[config.ModelBudget(model)]; ok && model != "" {
+		if err := p.checkScope(spendScope{model: model}, b, model, now); err != nil {
+			return err
+		}
+	}
+
+	budget, ok := p.budgetFor(agentName, group)
 	if !ok {
 		return nil // No budget configured
 	}
+	if err := p.checkScope(spendScope{agent: agentName}, budget, model, now); err != nil {
+		return err
+	}
 
-	now := time.Now().UTC()
+	if budget.Credits && p.credits != nil && !fallsBackTo(budget, model) {
+		bal, err := p.credits.Balance(agentName)
+		if err != nil {
+			log.Printf("WARN: failed to check credit balance: %v", err)
+			return nil
+		}
+		if bal.RemainingUSD <= 0 {
+			return exceeded(budget, fmt.Sprintf("prepaid credit exhausted (granted $%.2f, spent $%.2f)", bal.GrantedUSD, bal.SpentUSD))
+		}
+	}
+
+	return nil
+}
+
+// budgetError is a request over budget. fallback is the model to
+// downgrade the request to instead of rejecting it, when the budget
+// allows that.
+type budgetError struct {
+	msg      string
+	fallback string
+}
+
+func (e *budgetError) Error() string { return e.msg }
+
+func exceeded(budget config.Budget, msg string) *budgetError {
+	e := &budgetError{msg: msg}
+	if budget.Downgrades() {
+		e.fallback = budget.FallbackModel
+	}
+	return e
+}
+
+// fallsBackTo reports whether budget downgrades requests to model. Such a
+// budget no longer holds back requests already for its fallback model.
+func fallsBackTo(budget config.Budget, model string) bool {
+	return budget.Downgrades() && budget.FallbackModel == model
+}
+
+// checkScope checks one of the budgets of a request for model.
+func (p *Proxy) checkScope(sc spendScope, budget config.Budget, model string, now time.Time) error {
+	if fallsBackTo(budget, model) {
+		return nil
+	}
+	if err := p.checkSpend(sc, budget, now); err != nil {
+		return exceeded(budget, sc.errorPrefix()+err.Error())
+	}
+	return nil
+}
 
+// checkSpend checks the scope's spend today and this month against budget.
+func (p *Proxy) checkSpend(sc spendScope, budget config.Budget, now time.Time) error {
 	if budget.DailyLimitUSD > 0 {
-		dailySpend, err := p.store.QueryAgentDailySpend(agentName, now)
+		dailySpend, err := p.dailySpend(sc, now)
 		if err != nil {
 			log.Printf("WARN: failed to check daily budget: %v", err)
 			return nil // Allow on error
 		}
-		if dailySpend >= budget.DailyLimitUSD {
-			return fmt.Errorf("daily limit of $%.2f reached (spent $%.2f)", budget.DailyLimitUSD, dailySpend)
+		limit := budget.DailyLimitUSD
+		if budget.Rollover {
+			monthlySpend, err := p.monthlySpend(sc, now)
+			if err != nil {
+				log.Printf("WARN: failed to check daily budget rollover: %v", err)
+			} else {
+				limit = budget.DailyAllowance(now, monthlySpend-dailySpend)
+			}
+		}
+		if dailySpend >= limit {
+			return fmt.Errorf("daily limit of $%.2f reached (spent $%.2f)", limit, dailySpend)
 		}
 	}
 
 	if budget.MonthlyLimitUSD > 0 {
-		monthlySpend, err := p.store.QueryAgentMonthlySpend(agentName, now.Year(), now.Month())
+		monthlySpend, err := p.monthlySpend(sc, now)
 		if err != nil {
 			log.Printf("WARN: failed to check monthly budget: %v", err)
 			return nil
@@ -1523,57 +2583,197 @@ func (p *Proxy) checkBudget(agentName string) error {
 		}
 	}
 
+	if budget.WeeklyLimitUSD > 0 {
+		weeklySpend, err := p.weeklySpend(sc, now)
+		if err != nil {
+			log.Printf("WARN: failed to check weekly budget: %v", err)
+			return nil
+		}
+		if weeklySpend >= budget.WeeklyLimitUSD {
+			return fmt.Errorf("weekly limit of $%.2f reached (spent $%.2f)", budget.WeeklyLimitUSD, weeklySpend)
+		}
+	}
+
+	for _, rl := range budget.Rolling {
+		window, err := rl.Duration()
+		if err != nil || rl.LimitUSD <= 0 {
+			continue // reported by agix doctor
+		}
+		spend, err := p.rollingSpend(sc, window, now)
+		if err != nil {
+			log.Printf("WARN: failed to check rolling budget: %v", err)
+			return nil
+		}
+		if spend >= rl.LimitUSD {
+			return fmt.Errorf("limit of $%.2f for the last %s reached (spent $%.2f)", rl.LimitUSD, rl.Window, spend)
+		}
+	}
 	return nil
 }
 
-// computeBudgetAlert computes budget status and fires webhook alerts if needed.
-// Returns headers to add to the response.
-func (p *Proxy) computeBudgetAlert(agentName string) map[string]string {
-	budget, ok := p.cfg.Budgets[agentName]
-	if !ok {
-		return nil
-	}
+// budgetSnapshot is an agent's spend at admission time. It lets every
+// response path (cache hit, streaming, tool loop, plain) report budget
+// status and alert without re-querying the store.
+type budgetSnapshot struct {
+	agent   string // or the budgets key of a gateway budget
+	budget  config.Budget
+	daily   float64
+	monthly float64
+	weekly  float64
+	// rolling is the spend in each of budget.Rolling's windows.
+	rolling []float64
+	// dailyLimit is the daily limit in effect, including rolled-over budget.
+	dailyLimit float64
+	// credit is the prepaid credit left, when the budget uses credits.
+	credit *float64
+	// gateway are the global, provider and model budgets the request
+	// also counts against. They alert, but set no response headers.
+	gateway []*budgetSnapshot
+	// noAgent is set when only gateway budgets apply.
+	noAgent bool
+}
 
+// snapshotBudget loads current spend for agentName and for the global,
+// provider and model budgets. Returns nil if no budget applies to the request.
+func (p *Proxy) snapshotBudget(agentName, group, provider, model string) *budgetSnapshot {
+	budgets := p.hot.Load().Budgets
 	now := time.Now().UTC()
-	var dailySpend, monthlySpend float64
 
+	var gateway []*budgetSnapshot
+	if b, ok := budgets[config.GlobalBudget]; ok {
+		gateway = append(gateway, p.snapshotSpend(config.GlobalBudget, spendScope{}, b, now))
+	}
+	if b, ok := budgets[config.ProviderBudget(provider)]; ok && provider != "" {
+		gateway = append(gateway, p.snapshotSpend(config.ProviderBudget(provider), spendScope{provider: provider}, b, now))
+	}
+	if b, ok := budgets[config.ModelBudget(model)]; ok && model != "" {
+		gateway = append(gateway, p.snapshotSpend(config.ModelBudget(model), spendScope{model: model}, b, now))
+	}
+
+	budget, ok := p.budgetFor(agentName, group)
+	if !ok {
+		if len(gateway) == 0 {
+			return nil
+		}
+		return &budgetSnapshot{gateway: gateway, noAgent: true}
+	}
+	snap := p.snapshotSpend(agentName, spendScope{agent: agentName}, budget, now)
+	if budget.Credits && p.credits != nil {
+		if bal, err := p.credits.Balance(agentName); err == nil {
+			snap.credit = &bal.RemainingUSD
+		}
+	}
+	snap.gateway = gateway
+	return snap
+}
+
+// snapshotSpend loads the scope's spend under budget.
+func (p *Proxy) snapshotSpend(name string, sc spendScope, budget config.Budget, now time.Time) *budgetSnapshot {
+	snap := &budgetSnapshot{agent: name, budget: budget, dailyLimit: budget.DailyLimitUSD}
 	if budget.DailyLimitUSD > 0 {
-		spend, err := p.store.QueryAgentDailySpend(agentName, now)
+		spend, err := p.dailySpend(sc, now)
 		if err == nil {
-			dailySpend = spend
+			snap.daily = spend
 		}
 	}
-	if budget.MonthlyLimitUSD > 0 {
-		spend, err := p.store.QueryAgentMonthlySpend(agentName, now.Year(), now.Month())
+	if budget.MonthlyLimitUSD > 0 || budget.Rollover {
+		spend, err := p.monthlySpend(sc, now)
 		if err == nil {
-			monthlySpend = spend
+			snap.monthly = spend
+			snap.dailyLimit = budget.DailyAllowance(now, spend-snap.daily)
+		}
+	}
+	if budget.WeeklyLimitUSD > 0 {
+		if spend, err := p.weeklySpend(sc, now); err == nil {
+			snap.weekly = spend
+		}
+	}
+	if len(budget.Rolling) > 0 {
+		snap.rolling = make([]float64, len(budget.Rolling))
+		for i, rl := range budget.Rolling {
+			if window, err := rl.Duration(); err == nil {
+				snap.rolling[i], _ = p.rollingSpend(sc, window, now)
+			}
 		}
 	}
+	return snap
+}
+
+// reportBudget computes budget status including cost spent by the current
+// request, sets X-Budget-* headers on h and fires webhook alerts if needed.
+// A nil h skips headers (used once a streaming response has started).
+// A nil snapshot is a no-op.
+func (p *Proxy) reportBudget(h http.Header, snap *budgetSnapshot, cost float64) {
+	if snap == nil {
+		return
+	}
+	for _, g := range snap.gateway {
+		p.reportBudget(nil, g, cost)
+	}
+	if snap.noAgent {
+		return
+	}
+	budget := snap.budget
+	dailySpend := snap.daily + cost
+	monthlySpend := snap.monthly + cost
 
-	bs := alert.ComputeBudgetStatus(dailySpend, budget.DailyLimitUSD, monthlySpend, budget.MonthlyLimitUSD, budget.AlertAtPercent)
-	headers := alert.FormatHeaders(bs)
+	weeklySpend := snap.weekly + cost
 
-	// Fire webhook if alert threshold reached
-	if bs.Alert && p.alerter != nil && budget.AlertWebhook != "" {
+	bs := alert.ComputeBudgetStatus(dailySpend, snap.dailyLimit, monthlySpend, budget.MonthlyLimitUSD, budget.AlertAtPercent)
+	if budget.WeeklyLimitUSD > 0 {
+		bs.WeeklyPercent = weeklySpend / budget.WeeklyLimitUSD * 100
+	}
+	for i, rl := range budget.Rolling {
+		if rl.LimitUSD > 0 && i < len(snap.rolling) {
+			bs.RollingPercent = max(bs.RollingPercent, (snap.rolling[i]+cost)/rl.LimitUSD*100)
+		}
+	}
+	bs.Alert = budget.AlertAtPercent > 0 && bs.Peak() >= budget.AlertAtPercent
+	if h != nil {
+		for k, v := range alert.FormatHeaders(bs) {
+			h.Set(k, v)
+		}
+		if snap.credit != nil {
+			h.Set("X-Credit-Remaining-USD", fmt.Sprintf("%.4f", *snap.credit-cost))
+		}
+	}
+
+	// Fire webhooks for the highest escalation level reached
+	if p.alerter != nil && (budget.AlertWebhook != "" || len(budget.AlertDestinations) > 0) {
+		now := time.Now().UTC()
 		payload := alert.WebhookPayload{
-			Agent:          agentName,
+			Agent:          snap.agent,
 			DailySpend:     dailySpend,
-			DailyLimit:     budget.DailyLimitUSD,
+			DailyLimit:     snap.dailyLimit,
 			DailyPercent:   bs.DailyPercent,
 			MonthlySpend:   monthlySpend,
 			MonthlyLimit:   budget.MonthlyLimitUSD,
 			MonthlyPercent: bs.MonthlyPercent,
+			WeeklyPercent:  bs.WeeklyPercent,
+			RollingPercent: bs.RollingPercent,
 			Timestamp:      now.Format(time.RFC3339),
 		}
-		p.alerter.SendWebhook(budget.AlertWebhook, agentName, payload)
-		log.Printf("ALERT: budget alert for %s (daily: %.1f%%, monthly: %.1f%%)", agentName, bs.DailyPercent, bs.MonthlyPercent)
+		if budget.WeeklyLimitUSD > 0 {
+			payload.WeeklySpend, payload.WeeklyLimit = weeklySpend, budget.WeeklyLimitUSD
+		}
+		level, sent := p.alerter.Notify(alert.Notification{
+			Agent:          snap.agent,
+			Percent:        bs.Peak(),
+			AlertAtPercent: budget.AlertAtPercent,
+			Webhook:        budget.AlertWebhook,
+			Destinations:   budget.AlertDestinations,
+			Payload:        payload,
+			Now:            now,
+		})
+		if len(sent) > 0 {
+			log.Printf("ALERT: %s budget alert for %s (daily: %.1f%%, monthly: %.1f%%) → %d destination(s)",
+				level.Name, snap.agent, bs.DailyPercent, bs.MonthlyPercent, len(sent))
+		}
 	}
-
-	return headers
 }
 
 // auditFirewall logs a firewall event.
-func (p *Proxy) auditFirewall(eventType, agentName string, result firewall.Result, rawMessages string) {
+func (p *Proxy) auditFirewall(eventType, agentName, requestID string, result firewall.Result, rawMessages string) {
 	if p.auditLogger == nil {
 		return
 	}
@@ -1582,7 +2782,7 @@ func (p *Proxy) auditFirewall(eventType, agentName string, result firewall.Resul
 		if len(excerpt) > 200 {
 			excerpt = excerpt[:200]
 		}
-		p.auditLogger.Log(eventType, agentName, audit.FirewallDetails{
+		p.auditLogger.LogRequest(eventType, agentName, requestID, audit.FirewallDetails{
 			Rule:     mr.Name,
 			Category: mr.Category,
 			Excerpt:  excerpt,
@@ -1591,7 +2791,7 @@ func (p *Proxy) auditFirewall(eventType, agentName string, result firewall.Resul
 }
 
 // auditToolCall logs a tool execution event.
-func (p *Proxy) auditToolCall(tc toolCall, agentName, status string, duration time.Duration) {
+func (p *Proxy) auditToolCall(tc toolCall, agentName, requestID, status string, duration time.Duration) {
 	if p.auditLogger == nil {
 		return
 	}
@@ -1618,18 +2818,131 @@ func (p *Proxy) auditToolCall(tc toolCall, agentName, status string, duration ti
 			details.Args = string(argsJSON)
 		}
 	}
-	p.auditLogger.Log(audit.EventToolCall, agentName, details)
+	p.auditLogger.LogRequest(audit.EventToolCall, agentName, requestID, details)
 }
 
-// handleSessions handles REST API for session overrides: GET/PUT/DELETE /v1/sessions/{id}
+// auditToolLoop logs a tool loop stopped for repeating tc.
+func (p *Proxy) auditToolLoop(tc toolCall, agentName, requestID string, repeats, iteration int) {
+	if p.auditLogger == nil {
+		return
+	}
+	details := audit.ToolLoopDetails{Tool: tc.Name, Repeats: repeats, Iteration: iteration}
+	if p.auditCfg.ContentLog {
+		if argsJSON, err := json.Marshal(tc.Arguments); err == nil {
+			details.Args = string(argsJSON)
+		}
+	}
+	p.auditLogger.LogRequest(audit.EventToolLoop, agentName, requestID, details)
+}
+
+// handleProviderLimits serves GET /v1/providers/{name}/limits: the
+// rate-limit headers the provider reported on recent upstream responses.
+// Providers that are neither configured nor observed are not found.
+func (p *Proxy) handleProviderLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/v1/providers/")
+	name, suffix, _ := strings.Cut(rest, "/")
+	if name == "" || suffix != "limits" {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	limits := p.providerLimits.Limits(name)
+	if len(limits) == 0 && !p.configured(name) {
+		http.Error(w, fmt.Sprintf(`{"error":"unknown provider %q"}`, name), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Provider string                    `json:"provider"`
+		Models   []providerlimits.Snapshot `json:"models"`
+	}{Provider: name, Models: limits})
+}
+
+// queueable reports whether a failed request may be parked in the outage
+// queue. Only non-streaming requests that opt in with X-Queue-Callback qualify.
+func (p *Proxy) queueable(r *http.Request, stream bool) bool {
+	return p.outageQueue != nil && !stream && r.Header.Get("X-Queue-Callback") != ""
+}
+
+// enqueueOutage stores the request for retry and answers 202 with its queue ID.
+func (p *Proxy) enqueueOutage(w http.ResponseWriter, r *http.Request, body []byte, model, agentName string) {
+	callback := r.Header.Get("X-Queue-Callback")
+	if u, err := url.Parse(callback); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, `{"error":"invalid X-Queue-Callback URL"}`, http.StatusBadRequest)
+		return
+	}
+	priority, err := loadshed.ParsePriority(strings.ToLower(strings.TrimSpace(r.Header.Get("X-Priority"))))
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	id, err := p.outageQueue.Enqueue(agentName, model, priority, body, callback)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		http.Error(w, `{"error":"all providers unavailable and request could not be queued"}`, http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Queue-Id", strconv.FormatInt(id, 10))
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, `{"queue_id":%d,"status":"queued"}`, id)
+}
+
+// handleQueue serves GET /v1/queue/{id}: the state of a queued request and,
+// once completed, the upstream response. Only the agent that queued the
+// request can see it.
+func (p *Proxy) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if p.outageQueue == nil {
+		http.Error(w, `{"error":"outage queue not enabled"}`, http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/v1/queue/"), 10, 64)
+	if err != nil {
+		http.Error(w, `{"error":"invalid queue id"}`, http.StatusBadRequest)
+		return
+	}
+
+	q, err := p.store.QueryQueuedRequest(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":"store: %s"}`, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	// Queue IDs are sequential; another agent's request is not found
+	if q == nil || q.AgentName != r.Header.Get("X-Agent-Name") {
+		http.Error(w, `{"error":"queued request not found"}`, http.StatusNotFound)
+		return
+	}
+
+	out := struct {
+		*store.QueuedRequest
+		Response json.RawMessage `json:"response,omitempty"`
+	}{QueuedRequest: q}
+	if json.Valid([]byte(q.Response)) {
+		out.Response = json.RawMessage(q.Response)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// handleSessions handles REST API for session overrides: GET/PUT/DELETE /admin/sessions/{id}
 func (p *Proxy) handleSessions(w http.ResponseWriter, r *http.Request) {
 	if p.sessionMgr == nil {
 		http.Error(w, `{"error":"session overrides not enabled"}`, http.StatusNotFound)
 		return
 	}
 
-	// Extract session ID from path: /v1/sessions/{id}
-	id := strings.TrimPrefix(r.URL.Path, "/v1/sessions/")
+	// Extract session ID from path: /admin/sessions/{id}
+	id := adminTail(r.URL.Path, "sessions")
 	if id == "" {
 		http.Error(w, `{"error":"session id required"}`, http.StatusBadRequest)
 		return
@@ -1739,14 +3052,37 @@ func (p *Proxy) handleWebhooks(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, `{"execution_id":%d,"status":"pending"}`, execID)
 }
 
+// payloadCaptureEnabled reports whether per-stage payload capture is active.
+// Capture stores full request bodies, so it is only honored with content_log.
+func (p *Proxy) payloadCaptureEnabled() bool {
+	return p.auditLogger != nil && p.auditCfg.ContentLog && p.auditCfg.PayloadCapture
+}
+
+// logCapture writes the recorded pipeline stages as a payload_capture event.
+func (p *Proxy) logCapture(c *inspect.Capture, model, agentName string) {
+	if c == nil || p.auditLogger == nil {
+		return
+	}
+	p.auditLogger.LogRequest(audit.EventPayloadCapture, agentName, c.RequestID, audit.PayloadCaptureDetails{
+		RequestID: c.RequestID,
+		Model:     model,
+		Stages:    c.Stages(),
+	})
+}
+
 // auditContent logs request/response body if content_log is enabled.
-func (p *Proxy) auditContent(direction, model, agentName string, body []byte) {
+func (p *Proxy) auditContent(r *http.Request, direction, model, agentName string, body []byte) {
 	if p.auditLogger == nil || !p.auditCfg.ContentLog {
 		return
 	}
-	p.auditLogger.Log(audit.EventContentLog, agentName, audit.ContentLogDetails{
+	var sessionID string
+	if r != nil {
+		sessionID = r.Header.Get("X-Session-ID") // lets legal holds cover a session
+	}
+	p.auditLogger.LogRequest(audit.EventContentLog, agentName, requestIDFrom(r), audit.ContentLogDetails{
 		Direction: direction,
 		Model:     model,
+		SessionID: sessionID,
 		Body:      string(body),
 	})
 }
@@ -1,7 +1,9 @@
 package proxy
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
@@ -9,10 +11,25 @@ import (
 	"testing"
 	"time"
 
+	"github.com/agent-platform/agix/internal/admission"
+	"github.com/agent-platform/agix/internal/alert"
+	"github.com/agent-platform/agix/internal/audit"
+	"github.com/agent-platform/agix/internal/compressor"
 	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/experiment"
+	"github.com/agent-platform/agix/internal/ha"
+	"github.com/agent-platform/agix/internal/loadshed"
 	"github.com/agent-platform/agix/internal/mcp"
+	"github.com/agent-platform/agix/internal/outagequeue"
+	"github.com/agent-platform/agix/internal/penalty"
+	"github.com/agent-platform/agix/internal/promptinject"
+	"github.com/agent-platform/agix/internal/ratelimit"
+	"github.com/agent-platform/agix/internal/responsepolicy"
+	"github.com/agent-platform/agix/internal/router"
+	"github.com/agent-platform/agix/internal/session"
 	"github.com/agent-platform/agix/internal/store"
 	"github.com/agent-platform/agix/internal/toolmgr"
+	"github.com/agent-platform/agix/internal/transform"
 )
 
 func newTestProxy(t *testing.T) (*Proxy, *store.Store) {
@@ -84,9 +101,10 @@ func TestModelsEndpoint(t *testing.T) {
 	var resp struct {
 		Object string `json:"object"`
 		Data   []struct {
-			ID      string `json:"id"`
-			Object  string `json:"object"`
-			OwnedBy string `json:"owned_by"`
+			ID            string `json:"id"`
+			Object        string `json:"object"`
+			OwnedBy       string `json:"owned_by"`
+			ContextWindow int    `json:"context_window"`
 		} `json:"data"`
 	}
 
@@ -113,6 +131,9 @@ func TestModelsEndpoint(t *testing.T) {
 		if m.OwnedBy == "" {
 			t.Error("model owned_by is empty")
 		}
+		if m.ID == "gpt-4o" && m.ContextWindow != 128000 {
+			t.Errorf("gpt-4o context_window = %d, want 128000", m.ContextWindow)
+		}
 	}
 }
 
@@ -319,6 +340,90 @@ func TestExtractUsageDeepSeek(t *testing.T) {
 	}
 }
 
+func TestExtractReasoningTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		body     string
+		want     int
+	}{
+		{name: "openai o-series", provider: "openai", body: `{"usage":{"prompt_tokens":10,"completion_tokens":500,"completion_tokens_details":{"reasoning_tokens":448}}}`, want: 448},
+		{name: "deepseek reasoner", provider: "deepseek", body: `{"usage":{"completion_tokens":90,"completion_tokens_details":{"reasoning_tokens":60}}}`, want: 60},
+		{name: "no details", provider: "openai", body: `{"usage":{"prompt_tokens":10,"completion_tokens":5}}`, want: 0},
+		{name: "stream chunk without usage", provider: "openai", body: `{"choices":[{"delta":{"content":"hi"}}]}`, want: 0},
+		{name: "anthropic", provider: "anthropic", body: `{"usage":{"output_tokens":5}}`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractReasoningTokens(tt.provider, []byte(tt.body)); got != tt.want {
+				t.Errorf("extractReasoningTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptReasoningParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		model    string
+		input    string
+		want     map[string]any // expected fields; nil value = must be absent
+	}{
+		{
+			name:     "o-series max_tokens becomes max_completion_tokens",
+			provider: "openai", model: "o3",
+			input: `{"model":"o3","max_tokens":1000,"reasoning_effort":"high"}`,
+			want:  map[string]any{"max_completion_tokens": 1000.0, "max_tokens": nil, "reasoning_effort": "high"},
+		},
+		{
+			name:     "o-series keeps explicit max_completion_tokens",
+			provider: "openai", model: "o4-mini",
+			input: `{"model":"o4-mini","max_tokens":1000,"max_completion_tokens":500}`,
+			want:  map[string]any{"max_completion_tokens": 500.0, "max_tokens": nil},
+		},
+		{
+			name:     "gpt-4o drops reasoning_effort",
+			provider: "openai", model: "gpt-4o",
+			input: `{"model":"gpt-4o","max_tokens":1000,"reasoning_effort":"low"}`,
+			want:  map[string]any{"max_tokens": 1000.0, "reasoning_effort": nil},
+		},
+		{
+			name:     "deepseek max_completion_tokens becomes max_tokens",
+			provider: "deepseek", model: "deepseek-reasoner",
+			input: `{"model":"deepseek-reasoner","max_completion_tokens":800,"reasoning_effort":"medium"}`,
+			want:  map[string]any{"max_tokens": 800.0, "max_completion_tokens": nil, "reasoning_effort": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]any
+			if err := json.Unmarshal(adaptReasoningParams(tt.provider, tt.model, []byte(tt.input)), &got); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			for k, want := range tt.want {
+				v, ok := got[k]
+				if want == nil {
+					if ok {
+						t.Errorf("%s = %v, want absent", k, v)
+					}
+					continue
+				}
+				if v != want {
+					t.Errorf("%s = %v, want %v", k, v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAdaptReasoningParamsUnchanged(t *testing.T) {
+	body := []byte(`{"model":"gpt-4o","max_tokens":10}`)
+	if got := adaptReasoningParams("openai", "gpt-4o", body); string(got) != string(body) {
+		t.Errorf("body rewritten without changes: %s", got)
+	}
+}
+
 func TestExtractUsageUnknownProvider(t *testing.T) {
 	input, output := extractUsage("unknown", []byte(`{"usage":{"prompt_tokens":100}}`))
 	if input != 0 || output != 0 {
@@ -452,6 +557,15 @@ func TestConvertToAnthropicFormat(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "max_completion_tokens used when max_tokens absent",
+			input: `{"model":"claude-opus-4-6","messages":[{"role":"user","content":"hello"}],"max_completion_tokens":2048}`,
+			check: func(t *testing.T, result map[string]any) {
+				if result["max_tokens"].(float64) != 2048 {
+					t.Errorf("max_tokens = %v, want 2048", result["max_tokens"])
+				}
+			},
+		},
 		{
 			name:  "default max_tokens when 0",
 			input: `{"model":"claude-opus-4-6","messages":[{"role":"user","content":"hello"}]}`,
@@ -479,6 +593,19 @@ func TestConvertToAnthropicFormat(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "stop and top_p mapped",
+			input: `{"model":"claude-opus-4-6","messages":[{"role":"user","content":"hello"}],"stop":"END","top_p":0.9}`,
+			check: func(t *testing.T, result map[string]any) {
+				stop, _ := result["stop_sequences"].([]any)
+				if len(stop) != 1 || stop[0] != "END" {
+					t.Errorf("stop_sequences = %v, want [END]", result["stop_sequences"])
+				}
+				if result["top_p"] != 0.9 {
+					t.Errorf("top_p = %v, want 0.9", result["top_p"])
+				}
+			},
+		},
 		{
 			name:    "malformed JSON",
 			input:   `{bad json`,
@@ -601,7 +728,7 @@ func TestCheckBudgetNoBudgetConfigured(t *testing.T) {
 	p, _ := newTestProxy(t)
 
 	// Agent without budget should pass
-	err := p.checkBudget("no-budget-agent")
+	err := p.checkBudget("no-budget-agent", "", "openai", "")
 	if err != nil {
 		t.Errorf("checkBudget() for unconfigured agent returned error: %v", err)
 	}
@@ -619,7 +746,7 @@ func TestCheckBudgetUnderLimit(t *testing.T) {
 		t.Fatalf("Insert() error: %v", err)
 	}
 
-	err := p.checkBudget("budget-agent")
+	err := p.checkBudget("budget-agent", "", "openai", "")
 	if err != nil {
 		t.Errorf("checkBudget() under limit returned error: %v", err)
 	}
@@ -975,3 +1102,870 @@ func TestTracingHeaderAbsentWhenDisabled(t *testing.T) {
 		t.Errorf("X-Trace-ID header should be absent when tracing disabled, got %q", traceID)
 	}
 }
+
+func TestPayloadCaptureRecordsStages(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	st, err := store.New(dbPath)
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	auditCfg := config.AuditConfig{Enabled: true, ContentLog: true, PayloadCapture: true}
+	cfg := &config.Config{
+		Port:    8080,
+		Keys:    map[string]string{},
+		Budgets: map[string]config.Budget{},
+		Audit:   auditCfg,
+	}
+	logger := audit.New(st.DB(), true, st.Dialect())
+	inj := promptinject.New(promptinject.Config{Global: "Be concise."})
+
+	p := New(cfg, st, WithAuditLogger(logger, auditCfg), WithPromptInjector(inj))
+
+	body := `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	requestID := w.Header().Get("X-Request-ID")
+	if requestID == "" {
+		t.Fatal("X-Request-ID header missing when payload capture enabled")
+	}
+
+	logger.Close()
+	_, details, err := logger.QueryPayloadCapture(requestID)
+	if err != nil {
+		t.Fatalf("QueryPayloadCapture() error: %v", err)
+	}
+	if details == nil {
+		t.Fatal("no payload capture recorded")
+	}
+	if len(details.Stages) != 2 {
+		t.Fatalf("len(Stages) = %d, want 2 (original + prompt_inject)", len(details.Stages))
+	}
+	if details.Stages[0].Body != body {
+		t.Errorf("original stage body = %q, want %q", details.Stages[0].Body, body)
+	}
+	if details.Stages[1].Stage != "prompt_inject" {
+		t.Errorf("second stage = %q, want prompt_inject", details.Stages[1].Stage)
+	}
+}
+
+func TestPayloadCaptureDisabledWithoutContentLog(t *testing.T) {
+	p, st := newTestProxy(t)
+	auditCfg := config.AuditConfig{Enabled: true, PayloadCapture: true}
+	logger := audit.New(st.DB(), true, st.Dialect())
+	WithAuditLogger(logger, auditCfg)(p)
+
+	body := `{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	logger.Close()
+	_, details, err := logger.QueryPayloadCapture(w.Header().Get("X-Request-ID"))
+	if err != nil {
+		t.Fatalf("QueryPayloadCapture() error: %v", err)
+	}
+	if details != nil {
+		t.Error("payload captured although content_log is off")
+	}
+}
+
+func TestReportBudgetIncludesRequestCost(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	// $7 spent today; budget-agent has a $10 daily limit
+	if err := st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "budget-agent", Model: "gpt-4o", Provider: "openai",
+		InputTokens: 100, OutputTokens: 50, CostUSD: 7.00, DurationMS: 100, StatusCode: 200,
+	}); err != nil {
+		t.Fatalf("Insert() error: %v", err)
+	}
+
+	snap := p.snapshotBudget("budget-agent", "", "openai", "")
+	if snap == nil {
+		t.Fatal("snapshotBudget() = nil for agent with budget")
+	}
+
+	tests := []struct {
+		name        string
+		cost        float64
+		wantDaily   string
+		wantMonthly string
+	}{
+		{name: "admission", cost: 0, wantDaily: "70.0", wantMonthly: "7.0"},
+		{name: "after request", cost: 1.5, wantDaily: "85.0", wantMonthly: "8.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			p.reportBudget(h, snap, tt.cost)
+			if got := h.Get("X-Budget-Daily-Percent"); got != tt.wantDaily {
+				t.Errorf("X-Budget-Daily-Percent = %q, want %q", got, tt.wantDaily)
+			}
+			if got := h.Get("X-Budget-Monthly-Percent"); got != tt.wantMonthly {
+				t.Errorf("X-Budget-Monthly-Percent = %q, want %q", got, tt.wantMonthly)
+			}
+		})
+	}
+}
+
+func TestSnapshotBudgetNoBudget(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	snap := p.snapshotBudget("no-budget-agent", "", "openai", "")
+	if snap != nil {
+		t.Fatalf("snapshotBudget() = %+v, want nil", snap)
+	}
+	// nil snapshot must be a no-op
+	h := http.Header{}
+	p.reportBudget(h, snap, 1.0)
+	if len(h) != 0 {
+		t.Errorf("headers = %v, want none", h)
+	}
+}
+
+func TestReportBudgetAlertsOnRequestCost(t *testing.T) {
+	received := make(chan alert.WebhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload alert.WebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer srv.Close()
+
+	p, _ := newTestProxy(t)
+	p.cfg.Budgets["budget-agent"] = config.Budget{DailyLimitUSD: 10, AlertAtPercent: 80, AlertWebhook: srv.URL}
+	WithAlerter(alert.NewAlerter(time.Hour))(p)
+
+	// No prior spend: only this request's cost crosses the threshold.
+	snap := p.snapshotBudget("budget-agent", "", "openai", "")
+	p.reportBudget(nil, snap, 9.0)
+
+	select {
+	case payload := <-received:
+		if payload.DailySpend != 9.0 {
+			t.Errorf("DailySpend = %v, want 9.0", payload.DailySpend)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook not called for spend crossing the alert threshold")
+	}
+}
+
+func TestWriteNonStreamingResponseBudgetHeaders(t *testing.T) {
+	p, _ := newTestProxy(t)
+	snap := p.snapshotBudget("budget-agent", "", "openai", "")
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	respBody := []byte(`{"usage":{"prompt_tokens":1000000,"completion_tokens":0}}`)
+	w := httptest.NewRecorder()
+	p.writeNonStreamingResponse(w, nil, resp, respBody, "gpt-4o", "openai", "budget-agent", time.Now(), 0, snap, "", "")
+
+	if w.Header().Get("X-Budget-Daily-Percent") == "" {
+		t.Error("X-Budget-Daily-Percent missing from non-streaming response")
+	}
+}
+
+func TestStreamingResponseBudgetHeaders(t *testing.T) {
+	p, _ := newTestProxy(t)
+	snap := p.snapshotBudget("budget-agent", "", "openai", "")
+	snap.daily = 5.0
+
+	sse := "data: {\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5}}\n\ndata: [DONE]\n"
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       io.NopCloser(strings.NewReader(sse)),
+	}
+	w := httptest.NewRecorder()
+	p.handleStreamingResponse(w, nil, resp, "gpt-4o", "openai", "budget-agent", time.Now(), 0, snap)
+
+	if got := w.Header().Get("X-Budget-Daily-Percent"); got != "50.0" {
+		t.Errorf("X-Budget-Daily-Percent = %q, want %q", got, "50.0")
+	}
+}
+
+func TestLoadSheddingRejectsWhenOverloaded(t *testing.T) {
+	p, _ := newTestProxy(t)
+	shedder := loadshed.New(loadshed.Config{
+		Enabled:     true,
+		MaxInFlight: 1,
+		Priorities:  map[string]loadshed.Priority{"vip": loadshed.PriorityHigh},
+	}, nil)
+	WithShedder(shedder)(p)
+
+	// Occupy the only in-flight slot
+	release, _ := shedder.Admit("other")
+	defer release()
+
+	body := `{"model":"unknown-model","messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "batch")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if ra := w.Header().Get("Retry-After"); ra != "5" {
+		t.Errorf("Retry-After = %q, want 5", ra)
+	}
+
+	// High priority traffic passes the shedder (and fails later on the unknown model)
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "vip")
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code == http.StatusServiceUnavailable {
+		t.Error("high priority request was shed")
+	}
+	if n := shedder.InFlight(); n != 1 {
+		t.Errorf("InFlight() = %d after request completed, want 1", n)
+	}
+}
+
+func TestAdmissionQueueWaitsForSlot(t *testing.T) {
+	p, _ := newTestProxy(t)
+	shedder := loadshed.New(loadshed.Config{Enabled: true, MaxInFlight: 1}, nil)
+	WithShedder(shedder)(p)
+	WithAdmissionQueue(admission.New(admission.Config{MaxWait: 2 * time.Second}))(p)
+
+	release, _ := shedder.Admit("other")
+	go func() {
+		for p.admission.Len() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		release()
+		p.admission.Release()
+	}()
+
+	body := `{"model":"unknown-model","messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "batch")
+	req.Header.Set("X-Priority", "low")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	// Queued until the slot was freed, then failed later on the unknown model
+	if w.Code == http.StatusServiceUnavailable {
+		t.Fatalf("queued request was shed: %s", w.Body)
+	}
+	if n := shedder.InFlight(); n != 0 {
+		t.Errorf("InFlight() = %d after request completed, want 0", n)
+	}
+}
+
+func TestPenaltyBoxRejectsPenalizedAgent(t *testing.T) {
+	p, st := newTestProxy(t)
+	box, err := penalty.New(penalty.Config{FirewallBlocksPerHour: 1}, st, nil)
+	if err != nil {
+		t.Fatalf("penalty.New: %v", err)
+	}
+	WithPenaltyBox(box)(p)
+	if box.ObserveFirewallBlock("attacker") == nil {
+		t.Fatal("agent was not penalized")
+	}
+
+	body := `{"model":"unknown-model","messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "attacker")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "penalty box: 1 firewall blocks in the last hour") {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After not set")
+	}
+
+	// Other agents are unaffected.
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Agent-Name", "bystander")
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code == http.StatusTooManyRequests {
+		t.Errorf("bystander got 429: %s", w.Body.String())
+	}
+}
+
+// heldLocker is an ha.Locker whose lock is always held by another instance.
+type heldLocker struct{}
+
+func (heldLocker) TryLock(ctx context.Context) (bool, error) { return false, nil }
+func (heldLocker) Check(ctx context.Context) error           { return nil }
+func (heldLocker) Unlock(ctx context.Context) error          { return nil }
+
+func TestStandbyNotReady(t *testing.T) {
+	p, _ := newTestProxy(t)
+	elector := ha.New(heldLocker{}, time.Hour)
+	elector.Start()
+	defer elector.Close()
+	WithElector(elector)(p)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{name: "health reports standby", method: http.MethodGet, path: "/health", wantStatus: http.StatusServiceUnavailable},
+		{name: "traffic rejected", method: http.MethodPost, path: "/v1/chat/completions", wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"standby"`) {
+		t.Errorf("health body = %q, want standby status", w.Body.String())
+	}
+}
+
+func TestProviderLimitsEndpoint(t *testing.T) {
+	p, _ := newTestProxy(t)
+	p.providerLimits.Observe("openai", "gpt-4o", http.Header{
+		"X-Ratelimit-Limit-Requests":     {"500"},
+		"X-Ratelimit-Remaining-Requests": {"42"},
+		"X-Ratelimit-Reset-Requests":     {"1s"},
+	}, time.Now())
+	p.providerLimits.Observe("openrouter", "openrouter/meta-llama/llama-3-70b", http.Header{
+		"X-Ratelimit-Limit-Requests":     {"200"},
+		"X-Ratelimit-Remaining-Requests": {"42"},
+	}, time.Now())
+	p.cfg.Providers = []config.ProviderConfig{{Name: "together", BaseURL: "https://api.together.xyz/v1"}}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantModels int
+	}{
+		{name: "observed provider", method: http.MethodGet, path: "/v1/providers/openai/limits", wantStatus: http.StatusOK, wantModels: 1},
+		{name: "no observations yet", method: http.MethodGet, path: "/v1/providers/anthropic/limits", wantStatus: http.StatusOK, wantModels: 0},
+		{name: "observed without a key", method: http.MethodGet, path: "/v1/providers/openrouter/limits", wantStatus: http.StatusOK, wantModels: 1},
+		{name: "custom provider", method: http.MethodGet, path: "/v1/providers/together/limits", wantStatus: http.StatusOK, wantModels: 0},
+		{name: "unconfigured provider", method: http.MethodGet, path: "/v1/providers/azure/limits", wantStatus: http.StatusNotFound},
+		{name: "unknown provider", method: http.MethodGet, path: "/v1/providers/acme/limits", wantStatus: http.StatusNotFound},
+		{name: "unknown resource", method: http.MethodGet, path: "/v1/providers/openai/usage", wantStatus: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodPost, path: "/v1/providers/openai/limits", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Provider string `json:"provider"`
+				Models   []struct {
+					Model    string `json:"model"`
+					Requests struct {
+						Remaining int64 `json:"remaining"`
+					} `json:"requests"`
+				} `json:"models"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("parse response: %v", err)
+			}
+			if len(resp.Models) != tt.wantModels {
+				t.Fatalf("len(models) = %d, want %d", len(resp.Models), tt.wantModels)
+			}
+			if tt.wantModels > 0 && resp.Models[0].Requests.Remaining != 42 {
+				t.Errorf("remaining = %d, want 42", resp.Models[0].Requests.Remaining)
+			}
+		})
+	}
+}
+
+func TestOutageQueueEnqueueAndStatus(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.outageQueue = outagequeue.New(outagequeue.Config{Enabled: true}, st, nil, nil)
+
+	tests := []struct {
+		name       string
+		callback   string
+		priority   string
+		wantStatus int
+	}{
+		{name: "queued", callback: "https://example.com/done", priority: "high", wantStatus: http.StatusAccepted},
+		{name: "invalid callback", callback: "ftp://example.com", wantStatus: http.StatusBadRequest},
+		{name: "invalid priority", callback: "https://example.com/done", priority: "urgent", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			req.Header.Set("X-Queue-Callback", tt.callback)
+			req.Header.Set("X-Priority", tt.priority)
+			if !p.queueable(req, false) || p.queueable(req, true) {
+				t.Fatal("only non-streaming opt-in requests should be queueable")
+			}
+
+			w := httptest.NewRecorder()
+			p.enqueueOutage(w, req, []byte(`{"model":"gpt-4o"}`), "gpt-4o", "batch-agent")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+
+			id := w.Header().Get("X-Queue-Id")
+			for _, agent := range []string{"", "other-agent"} {
+				sw := httptest.NewRecorder()
+				get := httptest.NewRequest(http.MethodGet, "/v1/queue/"+id, nil)
+				get.Header.Set("X-Agent-Name", agent)
+				p.ServeHTTP(sw, get)
+				if sw.Code != http.StatusNotFound {
+					t.Errorf("GET /v1/queue/%s as %q status = %d, want 404", id, agent, sw.Code)
+				}
+			}
+			sw := httptest.NewRecorder()
+			get := httptest.NewRequest(http.MethodGet, "/v1/queue/"+id, nil)
+			get.Header.Set("X-Agent-Name", "batch-agent")
+			p.ServeHTTP(sw, get)
+			if sw.Code != http.StatusOK {
+				t.Fatalf("GET /v1/queue/%s status = %d", id, sw.Code)
+			}
+			var got struct {
+				Status   string `json:"status"`
+				Model    string `json:"model"`
+				Priority int    `json:"priority"`
+			}
+			json.Unmarshal(sw.Body.Bytes(), &got)
+			if got.Status != "queued" || got.Model != "gpt-4o" || got.Priority != loadshed.PriorityHigh.Rank() {
+				t.Errorf("queued request = %+v", got)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/queue/999", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown id status = %d, want 404", w.Code)
+	}
+}
+
+func TestOutageQueueNotQueueableWhenDisabled(t *testing.T) {
+	p, _ := newTestProxy(t)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("X-Queue-Callback", "https://example.com/done")
+	if p.queueable(req, false) {
+		t.Error("requests should not be queueable without an outage queue")
+	}
+}
+
+func TestWriteNonStreamingResponseAppliesTransform(t *testing.T) {
+	p, _ := newTestProxy(t)
+	tf, err := transform.New(map[string]transform.Provider{
+		"openai": {Response: transform.Rules{Drop: []string{"system_fingerprint"}}},
+	})
+	if err != nil {
+		t.Fatalf("transform.New() error: %v", err)
+	}
+	p.transformer = tf
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Length": {"999"}}}
+	respBody := []byte(`{"system_fingerprint":"fp_1","usage":{"prompt_tokens":10,"completion_tokens":5}}`)
+	w := httptest.NewRecorder()
+	p.writeNonStreamingResponse(w, nil, resp, respBody, "gpt-4o", "openai", "", time.Now(), 0, nil, "", "")
+
+	if strings.Contains(w.Body.String(), "system_fingerprint") {
+		t.Errorf("response transform not applied: %s", w.Body.String())
+	}
+	if w.Header().Get("Content-Length") != "" {
+		t.Error("stale upstream Content-Length forwarded after rewrite")
+	}
+}
+
+func TestProviderPacerRejectsBeyondMaxWait(t *testing.T) {
+	p, _ := newTestProxy(t)
+	p.pacer = ratelimit.NewPacer(map[string]ratelimit.ProviderLimit{
+		"openai": {RequestsPerMinute: 1, MaxWait: time.Second},
+	})
+	// Use up the only request slot so the next one would wait ~60s
+	if err := p.pacer.Wait(context.Background(), "openai", 0); err != nil {
+		t.Fatalf("Wait() error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+	req.Header.Set("X-Agent-Name", "paced-agent")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429: %s", w.Code, w.Body.String())
+	}
+	if ra := w.Header().Get("Retry-After"); ra != "60" {
+		t.Errorf("Retry-After = %q, want 60", ra)
+	}
+}
+
+func TestEstimateUpstreamTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"prompt only", `{"messages":"12345678"}`, 23 / 4},
+		{"max_tokens", `{"max_tokens":100}`, 18/4 + 100},
+		{"max_completion_tokens", `{"max_completion_tokens":50}`, 28/4 + 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateUpstreamTokens([]byte(tt.body)); got != tt.want {
+				t.Errorf("estimateUpstreamTokens(%s) = %d, want %d", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressionSkipsInternalAgents(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var summarized []string
+	p.compressor = compressor.New(compressor.Config{Enabled: true, ThresholdTokens: 1, KeepRecent: 1},
+		func(model string, msgs []compressor.Message) (string, error) {
+			summarized = append(summarized, model)
+			return "summary", nil
+		})
+	// Reject every request at the pacer so nothing reaches the real upstream
+	p.pacer = ratelimit.NewPacer(map[string]ratelimit.ProviderLimit{
+		"openai": {RequestsPerMinute: 1, MaxWait: time.Second},
+	})
+	if err := p.pacer.Wait(context.Background(), "openai", 0); err != nil {
+		t.Fatalf("Wait() error: %v", err)
+	}
+
+	body := `{"model":"gpt-4o","messages":[{"role":"user","content":"one two three"},{"role":"assistant","content":"four five"},{"role":"user","content":"six"}]}`
+	for _, agent := range []string{store.AgentCompressor, "app"} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("X-Agent-Name", agent)
+		p.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(summarized) != 1 {
+		t.Errorf("summarize calls = %d, want 1 (internal agent must be skipped)", len(summarized))
+	}
+}
+
+func TestRoutingAndExperimentExclusion(t *testing.T) {
+	p, _ := newTestProxy(t)
+	p.initialHot.Router = router.New(router.Config{
+		Enabled:       true,
+		Tiers:         map[string]router.TierConfig{"simple": {MaxMessages: 3}},
+		ModelMap:      map[string]map[string]string{"gpt-4o": {"simple": "claude-haiku-4-5-20251001"}},
+		ExcludeAgents: []string{"eval-runner"},
+	})
+	p.experiments = experiment.New([]experiment.Config{
+		{Name: "exp", Enabled: true, ControlModel: "gpt-4o", VariantModel: "deepseek-chat", TrafficPct: 100},
+	})
+	// Reject every request at the pacer; the error names the provider the
+	// request would have been sent to.
+	limits := map[string]ratelimit.ProviderLimit{}
+	for _, prov := range []string{"openai", "anthropic", "deepseek"} {
+		limits[prov] = ratelimit.ProviderLimit{RequestsPerMinute: 1, MaxWait: time.Second}
+	}
+	p.pacer = ratelimit.NewPacer(limits)
+	for prov := range limits {
+		if err := p.pacer.Wait(context.Background(), prov, 0); err != nil {
+			t.Fatalf("Wait() error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		agent    string
+		headers  map[string]string
+		provider string
+	}{
+		{"routed", "app", nil, "anthropic"},
+		{"no route", "app", map[string]string{"X-No-Route": "1"}, "deepseek"},
+		{"no route or experiment", "app", map[string]string{"X-No-Route": "1", "X-No-Experiment": "1"}, "openai"},
+		{"excluded agent", "eval-runner", nil, "deepseek"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+				strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+			req.Header.Set("X-Agent-Name", tt.agent)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "provider "+tt.provider+" ") {
+				t.Errorf("got %d %s, want request paced at %s", w.Code, w.Body.String(), tt.provider)
+			}
+		})
+	}
+}
+
+func TestSessionCostCap(t *testing.T) {
+	p, st := newTestProxy(t)
+	mgr, err := session.New(st.DB(), time.Hour, st.Dialect())
+	if err != nil {
+		t.Fatalf("session.New() error: %v", err)
+	}
+	defer mgr.Close()
+	p.sessionMgr = mgr
+
+	limit := 0.01
+	if err := mgr.Set(&session.Override{SessionID: "demo", MaxSessionCostUSD: &limit}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	var sent string
+	stubUpstream(p, "application/json", `{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":1000,"completion_tokens":1000}}`, &sent)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`))
+		req.Header.Set("X-Session-ID", "demo")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	// gpt-4o costs $0.0125 for this usage, so the first request crosses the cap.
+	if w := send(); w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, body = %s", w.Code, w.Body.String())
+	}
+	o, err := mgr.Get("demo")
+	if err != nil || o == nil || o.SpentUSD <= 0.01 {
+		t.Fatalf("session after first request = %+v, err %v", o, err)
+	}
+	w := send()
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "session budget exceeded") {
+		t.Errorf("second request status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBudgetDowngrade(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.cfg.Budgets["budget-agent"] = config.Budget{DailyLimitUSD: 1, OnExceed: config.OnExceedDowngrade, FallbackModel: "gpt-4o-mini"}
+	st.Insert(&store.Record{
+		Timestamp: time.Now().UTC(), AgentName: "budget-agent", Model: "gpt-4o", Provider: "openai",
+		CostUSD: 2, StatusCode: 200,
+	})
+	var sent string
+	stubUpstream(p, "application/json", `{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":10,"completion_tokens":10}}`, &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`))
+	req.Header.Set("X-Agent-Name", "budget-agent")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(sent, `"gpt-4o-mini"`) {
+		t.Errorf("upstream request = %s, want gpt-4o-mini", sent)
+	}
+	if got := w.Header().Get("X-Budget-Downgraded"); got != "gpt-4o" {
+		t.Errorf("X-Budget-Downgraded = %q, want gpt-4o", got)
+	}
+
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) < 2 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.ExportCSV(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2", len(records))
+	}
+	if rec := records[1]; rec.Model != "gpt-4o-mini" || rec.Tags != "budget=downgraded" {
+		t.Errorf("record = model %q, tags %q", rec.Model, rec.Tags)
+	}
+
+	// Without downgrade the same budget blocks.
+	p.cfg.Budgets["budget-agent"] = config.Budget{DailyLimitUSD: 1}
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`))
+	req.Header.Set("X-Agent-Name", "budget-agent")
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("blocking budget status = %d, want 429", w.Code)
+	}
+}
+
+func TestBudgetRecheckedAfterRouting(t *testing.T) {
+	const routed = "claude-haiku-4-5-20251001"
+	downgrade := config.Budget{DailyLimitUSD: 1, OnExceed: config.OnExceedDowngrade, FallbackModel: "deepseek-chat"}
+	tests := []struct {
+		name           string
+		key            string
+		budget         config.Budget
+		wantStatus     int
+		wantModel      string
+		wantDowngraded string
+	}{
+		{"provider budget", config.ProviderBudget("anthropic"), config.Budget{DailyLimitUSD: 1}, http.StatusTooManyRequests, "", ""},
+		{"provider budget downgrade", config.ProviderBudget("anthropic"), downgrade, http.StatusOK, "deepseek-chat", routed},
+		{"provider budget left", config.ProviderBudget("anthropic"), config.Budget{DailyLimitUSD: 5}, http.StatusOK, routed, ""},
+		{"model budget", config.ModelBudget(routed), config.Budget{DailyLimitUSD: 1}, http.StatusTooManyRequests, "", ""},
+		{"model budget downgrade", config.ModelBudget(routed), downgrade, http.StatusOK, "deepseek-chat", routed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, st := newTestProxy(t)
+			p.initialHot.Router = router.New(router.Config{
+				Enabled:  true,
+				Tiers:    map[string]router.TierConfig{"simple": {MaxMessages: 3}},
+				ModelMap: map[string]map[string]string{"gpt-4o": {"simple": routed}},
+			})
+			p.cfg.Budgets[tt.key] = tt.budget
+			st.Insert(&store.Record{
+				Timestamp: time.Now().UTC(), AgentName: "app", Model: routed, Provider: "anthropic",
+				CostUSD: 2, StatusCode: 200,
+			})
+			var sent string
+			stubUpstream(p, "application/json", `{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":10,"completion_tokens":10}}`, &sent)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+				strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`))
+			req.Header.Set("X-Agent-Name", "app")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantModel == "" {
+				if sent != "" {
+					t.Errorf("upstream request = %s, want none", sent)
+				}
+				return
+			}
+			if !strings.Contains(sent, `"`+tt.wantModel+`"`) {
+				t.Errorf("upstream request = %s, want %s", sent, tt.wantModel)
+			}
+			if got := w.Header().Get("X-Budget-Downgraded"); got != tt.wantDowngraded {
+				t.Errorf("X-Budget-Downgraded = %q, want %q", got, tt.wantDowngraded)
+			}
+		})
+	}
+}
+
+func TestResponsePolicyEnforcement(t *testing.T) {
+	english := `{"choices":[{"message":{"role":"assistant","content":"Your order has shipped and will arrive soon."},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":10}}`
+	chinese := `{"choices":[{"message":{"role":"assistant","content":"您的订单已经发货，很快就会送达。"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":10}}`
+
+	tests := []struct {
+		name        string
+		action      string
+		replies     []string
+		wantStatus  int
+		wantCalls   int
+		wantRecords int
+		wantBody    string
+		wantHeader  string
+	}{
+		{"retry succeeds", responsepolicy.ActionRetry, []string{english, chinese}, http.StatusOK, 2, 2, "订单", ""},
+		{"retry exhausted", responsepolicy.ActionRetry, []string{english, english}, http.StatusOK, 2, 2, "shipped", "response is not in Chinese"},
+		{"reject", responsepolicy.ActionReject, []string{english}, http.StatusUnprocessableEntity, 1, 1, "response policy", ""},
+		{"warn", responsepolicy.ActionWarn, []string{english}, http.StatusOK, 1, 1, "shipped", "response is not in Chinese"},
+		{"compliant", responsepolicy.ActionRetry, []string{chinese}, http.StatusOK, 1, 1, "订单", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, st := newTestProxy(t)
+			pol, err := responsepolicy.New(responsepolicy.Config{
+				Enabled: true,
+				Agents:  map[string]responsepolicy.AgentPolicy{"support-zh": {Language: "zh", OnViolation: tt.action}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			WithResponsePolicy(pol)(p)
+
+			var sent []string
+			p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				sent = append(sent, string(b))
+				reply := tt.replies[min(len(sent), len(tt.replies))-1]
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(reply)),
+					Request:    r,
+				}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"我的订单呢？"}]}`))
+			req.Header.Set("X-Agent-Name", "support-zh")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("response = %d %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("X-Response-Policy-Violation"); got != tt.wantHeader {
+				t.Errorf("X-Response-Policy-Violation = %q, want %q", got, tt.wantHeader)
+			}
+			if len(sent) != tt.wantCalls {
+				t.Fatalf("upstream calls = %d, want %d", len(sent), tt.wantCalls)
+			}
+			if tt.wantCalls > 1 && !strings.Contains(sent[1], "Respond only in Chinese.") {
+				t.Errorf("retry request = %s", sent[1])
+			}
+
+			var records []store.Record
+			for deadline := time.Now().Add(3 * time.Second); len(records) < tt.wantRecords && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+				records, _ = st.QueryRecentRequests(10, "support-zh")
+			}
+			if len(records) != tt.wantRecords {
+				t.Errorf("records = %d, want %d", len(records), tt.wantRecords)
+			}
+		})
+	}
+}
+
+func TestRequestTagsRecorded(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":1}}`)),
+			Request:    r,
+		}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+	req.Header.Set("X-Request-Tags", "ticket=ABC-123, team=platform, bad tag")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var records []store.Record
+	for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		records, _ = st.ExportCSV(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	}
+	if len(records) != 1 || records[0].Tags != "team=platform,ticket=ABC-123" {
+		t.Fatalf("records = %+v, want tags team=platform,ticket=ABC-123", records)
+	}
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/agent-platform/agix/internal/providerlimits"
+)
+
+// upstreamQuota decides what to do with requests to a model whose
+// provider reports its quota nearly used up.
+type upstreamQuota struct {
+	minPercent float64       // act below this share of a limit
+	maxWait    time.Duration // hold requests this long for a reset, refuse beyond
+}
+
+// WithUpstreamQuota makes the proxy act on the rate limit headers
+// providers return: a request that would leave a model below minPercent of
+// its quota is held until the quota resets, if that is within maxWait, and
+// is otherwise refused with a *providerlimits.QuotaError, which moves it
+// to the next model in the failover chain.
+func WithUpstreamQuota(minPercent float64, maxWait time.Duration) Option {
+	return func(p *Proxy) { p.quota = &upstreamQuota{minPercent: minPercent, maxWait: maxWait} }
+}
+
+// checkQuota applies the upstream quota to a request of about tokens
+// tokens to model.
+func (p *Proxy) checkQuota(ctx context.Context, provider, model string, tokens int) error {
+	if p.quota == nil {
+		return nil
+	}
+	wait, ok := p.providerLimits.Take(provider, model, tokens, p.quota.minPercent, time.Now())
+	if ok {
+		return nil
+	}
+	if wait > p.quota.maxWait {
+		log.Printf("QUOTA: %s (%s) below %.0f%% of its provider quota for %s", model, provider, p.quota.minPercent, wait.Round(time.Second))
+		return &providerlimits.QuotaError{Provider: provider, Model: model, RetryAfter: wait}
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// The reset restores the quota; count this request against it.
+	p.providerLimits.Take(provider, model, tokens, p.quota.minPercent, time.Now())
+	return nil
+}
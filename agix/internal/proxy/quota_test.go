@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/failover"
+)
+
+func TestUpstreamQuota(t *testing.T) {
+	p, _ := newTestProxy(t)
+	WithUpstreamQuota(5, time.Second)(p)
+	p.failover = failover.New(failover.Config{
+		MaxRetries: 1,
+		Chains:     map[string][]string{"gpt-4o": {"gpt-4o-mini"}},
+	})
+
+	var models []string
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(r.Body)
+		model := "gpt-4o"
+		if strings.Contains(string(body), "gpt-4o-mini") {
+			model = "gpt-4o-mini"
+		}
+		models = append(models, model)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header: http.Header{
+				"Content-Type":                   {"application/json"},
+				"X-Ratelimit-Limit-Requests":     {"100"},
+				"X-Ratelimit-Remaining-Requests": {"1"},
+				"X-Ratelimit-Reset-Requests":     {"1m"},
+			},
+			Body:    io.NopCloser(strings.NewReader(`{"choices":[{"message":{"content":"hi"}}]}`)),
+			Request: r,
+		}, nil
+	})
+
+	send := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	// The first response reports gpt-4o nearly out of quota
+	send(nil)
+	if w := send(nil); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 from the failover model: %s", w.Code, w.Body)
+	}
+	if got := strings.Join(models, ","); got != "gpt-4o,gpt-4o-mini" {
+		t.Errorf("upstream models = %s, want gpt-4o skipped on the second request", got)
+	}
+
+	// Without a failover model the request is refused until the reset
+	w := send(map[string]string{"X-Failover": "off"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429: %s", w.Code, w.Body)
+	}
+	if ra := w.Header().Get("Retry-After"); ra != "60" {
+		t.Errorf("Retry-After = %q, want 60", ra)
+	}
+}
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/ratelimit"
+	"github.com/agent-platform/agix/internal/trace"
+)
+
+type tokenSlotKey struct{}
+
+// tokenSlot holds a request's token reservation from admission until its
+// usage is recorded.
+type tokenSlot struct {
+	mu  sync.Mutex
+	res *ratelimit.Reservation
+}
+
+// withTokenSlot returns r carrying a slot for its token reservation when
+// rate limits are on.
+func (p *Proxy) withTokenSlot(r *http.Request) *http.Request {
+	if p.hot.Load().RateLimiter == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), tokenSlotKey{}, &tokenSlot{}))
+}
+
+// settleTokens trues up the request's token reservation with usage
+// reported by the provider.
+func settleTokens(r *http.Request, tokens int) {
+	if r == nil {
+		return
+	}
+	if s, _ := r.Context().Value(tokenSlotKey{}).(*tokenSlot); s != nil {
+		s.mu.Lock()
+		res := s.res
+		s.mu.Unlock()
+		res.Settle(tokens)
+	}
+}
+
+// rateLimit applies the gateway-wide and the agent's request and token
+// limits to a request estimated to use tokens tokens. It writes the 429
+// itself and returns false if the request is rejected.
+func (p *Proxy) rateLimit(w http.ResponseWriter, r *http.Request, agentName string, tokens int, tr *trace.Trace) bool {
+	rl := p.hot.Load().RateLimiter
+	if rl == nil {
+		return true
+	}
+	sp := tr.StartSpan("rate_limit")
+	result, res := rl.Reserve(agentName, tokens)
+	if !result.Allowed && p.admission != nil {
+		start := time.Now()
+		err := p.admission.Wait(r.Context(), p.admission.Priority(agentName, r.Header.Get("X-Priority")), result.RetryAfter,
+			func() (bool, time.Duration) {
+				result, res = rl.Reserve(agentName, tokens)
+				return result.Allowed, result.RetryAfter
+			})
+		sp.Set("queued_ms", time.Since(start).Milliseconds())
+		if err != nil {
+			result.Err = fmt.Errorf("%w (%v)", result.Err, err)
+		}
+	}
+	sp.Set("allowed", result.Allowed).End()
+	if !result.Allowed {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(result.RetryAfter.Seconds())))
+		http.Error(w, fmt.Sprintf(`{"error":"rate limited: %s"}`, result.Err.Error()), http.StatusTooManyRequests)
+		return false
+	}
+	if s, _ := r.Context().Value(tokenSlotKey{}).(*tokenSlot); s != nil {
+		s.mu.Lock()
+		s.res = res
+		s.mu.Unlock()
+	}
+	return true
+}
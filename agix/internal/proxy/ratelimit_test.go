@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/ratelimit"
+)
+
+func TestTokenRateLimit(t *testing.T) {
+	p, _ := newTestProxy(t)
+	p.initialHot.RateLimiter = ratelimit.New(map[string]ratelimit.Limit{"tpm-agent": {TokensPerMinute: 1000}})
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body: io.NopCloser(strings.NewReader(
+				`{"choices":[{"message":{"content":"hi"}}],"usage":{"prompt_tokens":500,"completion_tokens":490}}`)),
+			Request: r,
+		}, nil
+	})
+	send := func(extra string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]`+extra+`}`))
+		req.Header.Set("X-Agent-Name", "tpm-agent")
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send(`,"max_tokens":5000`); rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "tokens per minute") {
+		t.Errorf("request estimated over the limit = %d %s, want 429", rec.Code, rec.Body.String())
+	}
+	if rec := send(""); rec.Code != http.StatusOK {
+		t.Fatalf("first request = %d %s", rec.Code, rec.Body.String())
+	}
+	// The estimate was about 20 tokens; the response reported 990.
+	rec := send("")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request = %d, want 429 after true-up to 990 tokens", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("429 without Retry-After")
+	}
+}
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Reasoning models bill their thinking as output tokens, so cost is right
+// whenever output tokens are. Not every server reports how many of them were
+// reasoning, though: Ollama, vLLM and other DeepSeek-compatible servers
+// return the reasoning text (reasoning_content, or reasoning on OpenRouter)
+// without completion_tokens_details. For those the count is estimated from
+// the text.
+
+// responseReasoningTokens returns the reasoning tokens of a non-streaming
+// response: the reported count, or else an estimate from its reasoning
+// text, capped at outputTokens.
+func responseReasoningTokens(provider string, body []byte, outputTokens int) int {
+	if n := extractReasoningTokens(provider, body); n > 0 {
+		return n
+	}
+	if apiFormat(provider) == "anthropic" {
+		return 0
+	}
+	return estimateReasoningTokens(openAIReasoningText(body), outputTokens)
+}
+
+// estimateReasoningTokens estimates the tokens of reasoning text. They are
+// part of the output, so the estimate never exceeds outputTokens when that
+// is known.
+func estimateReasoningTokens(text string, outputTokens int) int {
+	n := estimateTokens(text)
+	if outputTokens > 0 {
+		n = min(n, outputTokens)
+	}
+	return n
+}
+
+// openAIReasoningText returns the reasoning text of a non-streaming OpenAI
+// format response.
+func openAIReasoningText(body []byte) string {
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				ReasoningContent string `json:"reasoning_content"`
+				Reasoning        string `json:"reasoning"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	json.Unmarshal(body, &resp)
+	var text strings.Builder
+	for _, c := range resp.Choices {
+		text.WriteString(c.Message.ReasoningContent)
+		text.WriteString(c.Message.Reasoning)
+	}
+	return text.String()
+}
+
+// openAIDeltaReasoning returns the reasoning text of one streamed OpenAI
+// format chunk.
+func openAIDeltaReasoning(data []byte) string {
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				ReasoningContent string `json:"reasoning_content"`
+				Reasoning        string `json:"reasoning"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	json.Unmarshal(data, &chunk)
+	var text strings.Builder
+	for _, c := range chunk.Choices {
+		text.WriteString(c.Delta.ReasoningContent)
+		text.WriteString(c.Delta.Reasoning)
+	}
+	return text.String()
+}
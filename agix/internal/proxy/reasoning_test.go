@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseReasoningTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		body     string
+		output   int
+		want     int
+	}{
+		{"reported count wins", "deepseek", `{"choices":[{"message":{"reasoning_content":"one two three"}}],"usage":{"completion_tokens_details":{"reasoning_tokens":60}}}`, 90, 60},
+		{"estimated from reasoning_content", "deepseek", `{"choices":[{"message":{"content":"42","reasoning_content":"one two three four five six seven eight nine ten"}}]}`, 90, 13},
+		{"estimated from reasoning", "openrouter", `{"choices":[{"message":{"reasoning":"one two three four five six seven eight nine ten"}}]}`, 90, 13},
+		{"capped at output", "ollama", `{"choices":[{"message":{"reasoning_content":"one two three four five six seven eight nine ten"}}]}`, 5, 5},
+		{"no reasoning", "openai", `{"choices":[{"message":{"content":"hi"}}]}`, 5, 0},
+		{"anthropic not estimated", "anthropic", `{"content":[{"type":"text","text":"hi"}]}`, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := responseReasoningTokens(tt.provider, []byte(tt.body), tt.output); got != tt.want {
+				t.Errorf("responseReasoningTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamingReasoningEstimate(t *testing.T) {
+	p, st := newTestProxy(t)
+
+	sse := strings.Join([]string{
+		`data: {"choices":[{"delta":{"reasoning_content":"one two three four five "}}]}`, "",
+		`data: {"choices":[{"delta":{"reasoning_content":"six seven eight nine ten"}}]}`, "",
+		`data: {"choices":[{"delta":{"content":"42"}}]}`, "",
+		`data: [DONE]`, "",
+	}, "\n")
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       io.NopCloser(strings.NewReader(sse)),
+	}
+	p.handleStreamingResponse(httptest.NewRecorder(), nil, resp, "ollama/deepseek-r1:8b", "ollama", "r1-agent", time.Now(), 0, nil)
+
+	r := waitForRecord(t, st, "r1-agent")
+	// 10 reasoning words and 1 content word at 1.3 tokens per word
+	if r.ReasoningTokens != 13 {
+		t.Errorf("ReasoningTokens = %d, want 13", r.ReasoningTokens)
+	}
+	if r.OutputTokens != 14 {
+		t.Errorf("OutputTokens = %d, want 14 (reasoning included)", r.OutputTokens)
+	}
+}
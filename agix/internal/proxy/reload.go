@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/firewall"
+	"github.com/agent-platform/agix/internal/keypool"
+	"github.com/agent-platform/agix/internal/promptinject"
+	"github.com/agent-platform/agix/internal/ratelimit"
+	"github.com/agent-platform/agix/internal/router"
+)
+
+// Hot is the part of the proxy's configuration that can change while it
+// serves traffic. A nil component is disabled.
+type Hot struct {
+	RateLimiter    *ratelimit.Limiter
+	Firewall       *firewall.Firewall
+	Router         *router.Router
+	PromptInjector *promptinject.Injector
+	Budgets        config.Budgets
+	// Keys are provider API keys by provider name, with secret references
+	// already resolved. See ProviderKeys.
+	Keys map[string]string
+	// KeyPools hand out a provider's keys in turn and take precedence
+	// over Keys.
+	KeyPools map[string]*keypool.Pool
+}
+
+// Reload swaps in h. Requests already in flight, including open streams,
+// finish with the components they started with.
+func (p *Proxy) Reload(h Hot) {
+	p.hot.Store(&h)
+}
+
+// ProviderKeys returns cfg's API keys by provider name. A custom
+// provider's inline api_key takes precedence over keys.<name>.
+func ProviderKeys(cfg *config.Config) map[string]string {
+	keys := make(map[string]string, len(cfg.Keys)+len(cfg.Providers))
+	for name, key := range cfg.Keys {
+		keys[name] = key
+	}
+	for _, pc := range cfg.Providers {
+		if pc.APIKey != "" {
+			keys[pc.Name] = pc.APIKey
+		}
+	}
+	return keys
+}
+
+// WithKeyPools sets the provider key pools.
+func WithKeyPools(pools map[string]*keypool.Pool) Option {
+	return func(p *Proxy) { p.initialHot.KeyPools = pools }
+}
+
+// key returns the API key for provider, or "" if none is configured.
+func (p *Proxy) key(provider string) string {
+	h := p.hot.Load()
+	if pool := h.KeyPools[provider]; pool != nil {
+		return pool.Pick()
+	}
+	return h.Keys[provider]
+}
+
+// configured reports whether provider has a key, or, for providers that
+// need none, an endpoint set up.
+func (p *Proxy) configured(provider string) bool {
+	h := p.hot.Load()
+	if h.KeyPools[provider] != nil || h.Keys[provider] != "" {
+		return true
+	}
+	switch provider {
+	case "ollama":
+		return p.cfg.Ollama.BaseURL != "" || len(p.cfg.Ollama.Models) > 0
+	case "bedrock":
+		return p.bedrockRegion() != ""
+	}
+	for _, pc := range p.cfg.Providers {
+		if pc.Name == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// observeKey tells provider's key pool, if it has one, how the provider
+// answered req, so keys that are rejected or rate-limited are set aside.
+func (p *Proxy) observeKey(provider string, req *http.Request, resp *http.Response) {
+	pool := p.hot.Load().KeyPools[provider]
+	if pool == nil {
+		return
+	}
+	key, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		key = req.Header.Get("x-api-key")
+	}
+	if key == "" {
+		key = req.Header.Get("api-key")
+	}
+	pool.Report(key, resp.StatusCode, resp.Header)
+}
+
+// Hot returns the components currently in use.
+func (p *Proxy) Hot() Hot {
+	return *p.hot.Load()
+}
@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/ratelimit"
+)
+
+func TestReloadRateLimits(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`))
+		req.Header.Set("X-Agent-Name", "reload-agent")
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	hot := p.Hot()
+	hot.RateLimiter = ratelimit.New(map[string]ratelimit.Limit{"reload-agent": {RequestsPerMinute: 1}})
+	p.Reload(hot)
+	send()
+	if code := send(); code != http.StatusTooManyRequests {
+		t.Fatalf("second request after reload = %d, want 429", code)
+	}
+
+	hot.RateLimiter = nil
+	p.Reload(hot)
+	if code := send(); code == http.StatusTooManyRequests {
+		t.Error("request still rate limited after limits were removed")
+	}
+	if _, ok := p.Hot().Budgets["budget-agent"]; !ok {
+		t.Error("reload dropped budgets")
+	}
+}
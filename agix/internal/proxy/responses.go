@@ -0,0 +1,698 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAI Responses API (/v1/responses) support: requests are rewritten to
+// chat format and served by handleChatCompletions, so the whole pipeline and
+// usage tracking apply unchanged. The chat response (OpenAI or Anthropic) is
+// converted to a response object, and streams to Responses API events, on
+// the way out. The gateway keeps no conversation state, so
+// previous_response_id is rejected; clients send the full input each turn.
+
+// responsesToChat converts a Responses API body to a chat completions body.
+func responsesToChat(body []byte) ([]byte, error) {
+	var req struct {
+		Model              string            `json:"model"`
+		Input              json.RawMessage   `json:"input"`
+		Instructions       string            `json:"instructions"`
+		MaxOutputTokens    int               `json:"max_output_tokens"`
+		Temperature        *float64          `json:"temperature"`
+		TopP               *float64          `json:"top_p"`
+		Stream             bool              `json:"stream"`
+		Tools              []json.RawMessage `json:"tools"`
+		ToolChoice         json.RawMessage   `json:"tool_choice"`
+		ParallelToolCalls  *bool             `json:"parallel_tool_calls"`
+		PreviousResponseID string            `json:"previous_response_id"`
+		Reasoning          struct {
+			Effort string `json:"effort"`
+		} `json:"reasoning"`
+		Text struct {
+			Format json.RawMessage `json:"format"`
+		} `json:"text"`
+		User string `json:"user"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid JSON in request body")
+	}
+	if req.PreviousResponseID != "" {
+		return nil, fmt.Errorf("previous_response_id is not supported; send the full conversation in input")
+	}
+	if len(req.Input) == 0 {
+		return nil, fmt.Errorf("input field is required")
+	}
+
+	var messages []map[string]any
+	if req.Instructions != "" {
+		messages = append(messages, map[string]any{"role": "system", "content": req.Instructions})
+	}
+	input, err := responsesInputToMessages(req.Input)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, input...)
+
+	chat := map[string]any{"model": req.Model, "messages": messages}
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]any, 0, len(req.Tools))
+		for i, raw := range req.Tools {
+			var t struct {
+				Type        string          `json:"type"`
+				Name        string          `json:"name"`
+				Description string          `json:"description"`
+				Parameters  json.RawMessage `json:"parameters"`
+				Strict      *bool           `json:"strict"`
+			}
+			json.Unmarshal(raw, &t)
+			if t.Type != "function" {
+				return nil, fmt.Errorf("tools[%d]: tool type %q is not supported", i, t.Type)
+			}
+			fn := map[string]any{"name": t.Name}
+			if t.Description != "" {
+				fn["description"] = t.Description
+			}
+			if len(t.Parameters) > 0 {
+				fn["parameters"] = t.Parameters
+			}
+			if t.Strict != nil {
+				fn["strict"] = *t.Strict
+			}
+			tools = append(tools, map[string]any{"type": "function", "function": fn})
+		}
+		chat["tools"] = tools
+	}
+	if len(req.ToolChoice) > 0 && string(req.ToolChoice) != "null" {
+		var choice struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(req.ToolChoice, &choice) == nil && choice.Type == "function" {
+			chat["tool_choice"] = map[string]any{"type": "function", "function": map[string]string{"name": choice.Name}}
+		} else {
+			chat["tool_choice"] = req.ToolChoice // "auto", "none" or "required"
+		}
+	}
+	if req.ParallelToolCalls != nil {
+		chat["parallel_tool_calls"] = *req.ParallelToolCalls
+	}
+	if req.MaxOutputTokens > 0 {
+		chat["max_tokens"] = req.MaxOutputTokens
+	}
+	if req.Temperature != nil {
+		chat["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		chat["top_p"] = *req.TopP
+	}
+	if req.Reasoning.Effort != "" {
+		chat["reasoning_effort"] = req.Reasoning.Effort
+	}
+	if rf := responsesTextFormat(req.Text.Format); rf != nil {
+		chat["response_format"] = rf
+	}
+	if req.User != "" {
+		chat["user"] = req.User
+	}
+	if req.Stream {
+		chat["stream"] = true
+	}
+	return json.Marshal(chat)
+}
+
+// responsesInputToMessages converts Responses API input, a string or a list
+// of items, to chat messages. Consecutive function calls become one
+// assistant message with several tool_calls.
+func responsesInputToMessages(raw json.RawMessage) ([]map[string]any, error) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return []map[string]any{{"role": "user", "content": s}}, nil
+	}
+	var items []struct {
+		Type      string          `json:"type"`
+		Role      string          `json:"role"`
+		Content   json.RawMessage `json:"content"`
+		CallID    string          `json:"call_id"`
+		Name      string          `json:"name"`
+		Arguments string          `json:"arguments"`
+		Output    json.RawMessage `json:"output"`
+	}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("input must be a string or an array of items")
+	}
+
+	var messages []map[string]any
+	for i, item := range items {
+		switch item.Type {
+		case "", "message":
+			role := item.Role
+			if role == "developer" {
+				role = "system"
+			}
+			if role != "user" && role != "assistant" && role != "system" {
+				return nil, fmt.Errorf("input[%d]: unsupported role %q", i, item.Role)
+			}
+			text, err := responsesContentText(item.Content)
+			if err != nil {
+				return nil, fmt.Errorf("input[%d]: %w", i, err)
+			}
+			messages = append(messages, map[string]any{"role": role, "content": text})
+		case "function_call":
+			call := map[string]any{
+				"id":       item.CallID,
+				"type":     "function",
+				"function": map[string]string{"name": item.Name, "arguments": item.Arguments},
+			}
+			if n := len(messages); n > 0 && messages[n-1]["tool_calls"] != nil {
+				prev := messages[n-1]
+				prev["tool_calls"] = append(prev["tool_calls"].([]map[string]any), call)
+				continue
+			}
+			messages = append(messages, map[string]any{"role": "assistant", "content": nil, "tool_calls": []map[string]any{call}})
+		case "function_call_output":
+			var output string
+			if json.Unmarshal(item.Output, &output) != nil {
+				output = string(item.Output)
+			}
+			messages = append(messages, map[string]any{"role": "tool", "tool_call_id": item.CallID, "content": output})
+		case "reasoning":
+			// Reasoning items from earlier turns can't be replayed through
+			// chat completions; the model reasons afresh.
+		default:
+			return nil, fmt.Errorf("input[%d]: item type %q is not supported", i, item.Type)
+		}
+	}
+	return messages, nil
+}
+
+// responsesContentText flattens message content, a string or a list of text
+// parts, to a string.
+func responsesContentText(raw json.RawMessage) (string, error) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", fmt.Errorf("content must be a string or an array of content parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.Type != "input_text" && p.Type != "output_text" && p.Type != "text" {
+			return "", fmt.Errorf("content part type %q is not supported", p.Type)
+		}
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// responseItem is one entry of a response's output: an assistant message or
+// a function call.
+type responseItem struct {
+	typ       string // "message" or "function_call"
+	id        string
+	text      string
+	callID    string
+	name      string
+	arguments string
+	done      bool
+}
+
+func (it *responseItem) MarshalJSON() ([]byte, error) {
+	status := "in_progress"
+	if it.done {
+		status = "completed"
+	}
+	if it.typ == "function_call" {
+		return json.Marshal(map[string]any{
+			"type": "function_call", "id": it.id, "call_id": it.callID,
+			"name": it.name, "arguments": it.arguments, "status": status,
+		})
+	}
+	content := []map[string]any{}
+	if it.done {
+		content = append(content, outputTextPart(it.text))
+	}
+	return json.Marshal(map[string]any{
+		"type": "message", "id": it.id, "status": status, "role": "assistant", "content": content,
+	})
+}
+
+func outputTextPart(text string) map[string]any {
+	return map[string]any{"type": "output_text", "text": text, "annotations": []any{}}
+}
+
+// responseBuilder assembles a response object from chat output, emitting
+// Responses API stream events as it goes when emit is set. Output pieces
+// are keyed by their position in the source format, e.g. "text" or an
+// OpenAI tool call index.
+type responseBuilder struct {
+	id        string
+	model     string
+	createdAt int64
+	emit      func(event string, payload map[string]any)
+
+	items      []*responseItem
+	byKey      map[string]*responseItem
+	incomplete string // incomplete_details.reason, if any
+	usage      struct{ input, output, reasoning int }
+	agixUsage  json.RawMessage
+	seq        int
+}
+
+func newResponseBuilder(requestID, model string, emit func(string, map[string]any)) *responseBuilder {
+	return &responseBuilder{
+		id:        "resp_" + requestID,
+		model:     model,
+		createdAt: time.Now().Unix(),
+		emit:      emit,
+		byKey:     make(map[string]*responseItem),
+	}
+}
+
+func (b *responseBuilder) event(name string, payload map[string]any) {
+	if b.emit == nil {
+		return
+	}
+	payload["type"] = name
+	payload["sequence_number"] = b.seq
+	b.seq++
+	b.emit(name, payload)
+}
+
+func (b *responseBuilder) outputIndex(it *responseItem) int {
+	for i, x := range b.items {
+		if x == it {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *responseBuilder) add(key string, it *responseItem) *responseItem {
+	b.items = append(b.items, it)
+	b.byKey[key] = it
+	b.event("response.output_item.added", map[string]any{"output_index": len(b.items) - 1, "item": it})
+	return it
+}
+
+// textDelta appends assistant text under key.
+func (b *responseBuilder) textDelta(key, delta string) {
+	if delta == "" {
+		return
+	}
+	it := b.byKey[key]
+	if it == nil {
+		it = b.add(key, &responseItem{typ: "message", id: fmt.Sprintf("msg_%s_%d", strings.TrimPrefix(b.id, "resp_"), len(b.items))})
+		b.event("response.content_part.added", map[string]any{
+			"item_id": it.id, "output_index": b.outputIndex(it), "content_index": 0, "part": outputTextPart(""),
+		})
+	}
+	it.text += delta
+	b.event("response.output_text.delta", map[string]any{
+		"item_id": it.id, "output_index": b.outputIndex(it), "content_index": 0, "delta": delta,
+	})
+}
+
+// callStart opens a function call under key.
+func (b *responseBuilder) callStart(key, callID, name string) {
+	if b.byKey[key] != nil {
+		return
+	}
+	b.add(key, &responseItem{typ: "function_call", id: "fc_" + callID, callID: callID, name: name})
+}
+
+// argsDelta appends function call arguments under key.
+func (b *responseBuilder) argsDelta(key, delta string) {
+	it := b.byKey[key]
+	if it == nil || delta == "" {
+		return
+	}
+	it.arguments += delta
+	b.event("response.function_call_arguments.delta", map[string]any{
+		"item_id": it.id, "output_index": b.outputIndex(it), "delta": delta,
+	})
+}
+
+// finishReason records why generation stopped, in either format.
+func (b *responseBuilder) finishReason(reason string) {
+	if reason == "length" || reason == "max_tokens" {
+		b.incomplete = "max_output_tokens"
+	}
+}
+
+// complete closes all open items and emits response.completed.
+func (b *responseBuilder) complete() {
+	for i, it := range b.items {
+		if it.done {
+			continue
+		}
+		if it.typ == "function_call" {
+			b.event("response.function_call_arguments.done", map[string]any{
+				"item_id": it.id, "output_index": i, "arguments": it.arguments,
+			})
+		} else {
+			b.event("response.output_text.done", map[string]any{
+				"item_id": it.id, "output_index": i, "content_index": 0, "text": it.text,
+			})
+			b.event("response.content_part.done", map[string]any{
+				"item_id": it.id, "output_index": i, "content_index": 0, "part": outputTextPart(it.text),
+			})
+		}
+		it.done = true
+		b.event("response.output_item.done", map[string]any{"output_index": i, "item": it})
+	}
+	b.event("response.completed", map[string]any{"response": b.response()})
+}
+
+// response returns the response object in its current state.
+func (b *responseBuilder) response() map[string]any {
+	status := "completed"
+	var incomplete any
+	if b.incomplete != "" {
+		status = "incomplete"
+		incomplete = map[string]string{"reason": b.incomplete}
+	}
+	output := b.items
+	if output == nil {
+		output = []*responseItem{}
+	}
+	resp := map[string]any{
+		"id":                 b.id,
+		"object":             "response",
+		"created_at":         b.createdAt,
+		"status":             status,
+		"model":              b.model,
+		"output":             output,
+		"error":              nil,
+		"incomplete_details": incomplete,
+		"usage": map[string]any{
+			"input_tokens":          b.usage.input,
+			"input_tokens_details":  map[string]int{"cached_tokens": 0},
+			"output_tokens":         b.usage.output,
+			"output_tokens_details": map[string]int{"reasoning_tokens": b.usage.reasoning},
+			"total_tokens":          b.usage.input + b.usage.output,
+		},
+	}
+	if len(b.agixUsage) > 0 {
+		resp[usageTrailerField] = b.agixUsage
+	}
+	return resp
+}
+
+// chatToResponse converts a non-streaming chat response, in OpenAI or
+// Anthropic format, to a response object.
+func chatToResponse(body []byte, b *responseBuilder) ([]byte, error) {
+	var resp struct {
+		Model string `json:"model"`
+		// OpenAI
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		// Anthropic
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			ID    string          `json:"id"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			PromptTokens            int `json:"prompt_tokens"`
+			CompletionTokens        int `json:"completion_tokens"`
+			CompletionTokensDetails struct {
+				ReasoningTokens int `json:"reasoning_tokens"`
+			} `json:"completion_tokens_details"`
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+		AgixUsage json.RawMessage `json:"agix_usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decode chat response: %w", err)
+	}
+	if resp.Model != "" {
+		b.model = resp.Model
+	}
+	b.agixUsage = resp.AgixUsage
+
+	if resp.Choices != nil {
+		if len(resp.Choices) > 0 {
+			c := resp.Choices[0]
+			b.textDelta("text", c.Message.Content)
+			for i, tc := range c.Message.ToolCalls {
+				key := fmt.Sprintf("call:%d", i)
+				b.callStart(key, tc.ID, tc.Function.Name)
+				b.argsDelta(key, tc.Function.Arguments)
+			}
+			b.finishReason(c.FinishReason)
+		}
+		b.usage.input, b.usage.output = resp.Usage.PromptTokens, resp.Usage.CompletionTokens
+		b.usage.reasoning = resp.Usage.CompletionTokensDetails.ReasoningTokens
+	} else {
+		for i, c := range resp.Content {
+			key := fmt.Sprintf("block:%d", i)
+			switch c.Type {
+			case "text":
+				b.textDelta("text", c.Text)
+			case "tool_use":
+				b.callStart(key, c.ID, c.Name)
+				b.argsDelta(key, string(c.Input))
+			}
+		}
+		b.finishReason(resp.StopReason)
+		b.usage.input, b.usage.output = resp.Usage.InputTokens, resp.Usage.OutputTokens
+	}
+	for _, it := range b.items {
+		it.done = true
+	}
+	return json.Marshal(b.response())
+}
+
+// responsesWriter converts the chat response written by
+// handleChatCompletions to Responses API format. SSE streams, OpenAI or
+// Anthropic, are converted line by line.
+type responsesWriter struct {
+	sseConvertWriter
+	builder *responseBuilder
+
+	started   bool
+	completed bool
+}
+
+func newResponsesWriter(w http.ResponseWriter, model string) *responsesWriter {
+	rw := &responsesWriter{}
+	rw.builder = newResponseBuilder("", model, func(event string, payload map[string]any) {
+		data, _ := json.Marshal(payload)
+		fmt.Fprintf(rw.w, "event: %s\ndata: %s\n\n", event, data)
+	})
+	rw.sseConvertWriter = sseConvertWriter{w: w, line: rw.writeLine, body: rw.convertBody, end: func() {
+		if rw.started {
+			rw.complete()
+		}
+	}}
+	return rw
+}
+
+// name sets the response ID from X-Request-ID, which the chat handler has
+// set before writing anything.
+func (rw *responsesWriter) name() {
+	rw.builder.id = "resp_" + rw.w.Header().Get("X-Request-ID")
+}
+
+// convertBody converts a non-streaming chat response.
+func (rw *responsesWriter) convertBody(body []byte) ([]byte, error) {
+	rw.name()
+	rw.builder.emit = nil
+	return chatToResponse(body, rw.builder)
+}
+
+func (rw *responsesWriter) writeLine(line string) {
+	rw.name()
+	data, isData := strings.CutPrefix(line, "data: ")
+	switch {
+	case line == "", strings.HasPrefix(line, "event: "):
+		// Converted events carry their own names and separators.
+	case !isData:
+		io.WriteString(rw.w, line+"\n\n")
+	case data == "[DONE]":
+		rw.complete()
+	default:
+		rw.convertChunk([]byte(data))
+	}
+}
+
+// start emits response.created before the first output.
+func (rw *responsesWriter) start() {
+	if rw.started {
+		return
+	}
+	rw.started = true
+	resp := rw.builder.response()
+	resp["status"] = "in_progress"
+	rw.builder.event("response.created", map[string]any{"response": resp})
+}
+
+func (rw *responsesWriter) complete() {
+	if rw.completed {
+		return
+	}
+	rw.start()
+	rw.completed = true
+	rw.builder.complete()
+}
+
+// convertChunk emits the events for one OpenAI chunk or Anthropic event.
+func (rw *responsesWriter) convertChunk(data []byte) {
+	var chunk struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Delta struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					Index    int    `json:"index"`
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"delta"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *struct {
+			PromptTokens            int `json:"prompt_tokens"`
+			CompletionTokens        int `json:"completion_tokens"`
+			CompletionTokensDetails struct {
+				ReasoningTokens int `json:"reasoning_tokens"`
+			} `json:"completion_tokens_details"`
+			OutputTokens int `json:"output_tokens"` // Anthropic message_delta
+		} `json:"usage"`
+		// Anthropic
+		Type    string `json:"type"`
+		Index   int    `json:"index"`
+		Message struct {
+			Model string `json:"model"`
+			Usage struct {
+				InputTokens int `json:"input_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+		ContentBlock struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"content_block"`
+		Delta struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			PartialJSON string `json:"partial_json"`
+			StopReason  string `json:"stop_reason"`
+		} `json:"delta"`
+	}
+	if json.Unmarshal(data, &chunk) != nil {
+		return
+	}
+	b := rw.builder
+	if chunk.Model != "" {
+		b.model = chunk.Model
+	}
+
+	if chunk.Type == "" {
+		rw.start()
+		for _, c := range chunk.Choices {
+			b.textDelta("text", c.Delta.Content)
+			for _, tc := range c.Delta.ToolCalls {
+				key := fmt.Sprintf("call:%d", tc.Index)
+				if tc.ID != "" {
+					b.callStart(key, tc.ID, tc.Function.Name)
+				}
+				b.argsDelta(key, tc.Function.Arguments)
+			}
+			b.finishReason(c.FinishReason)
+		}
+		if chunk.Usage != nil {
+			b.usage.input, b.usage.output = chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens
+			b.usage.reasoning = chunk.Usage.CompletionTokensDetails.ReasoningTokens
+		}
+		return
+	}
+
+	key := fmt.Sprintf("block:%d", chunk.Index)
+	switch chunk.Type {
+	case "message_start":
+		if chunk.Message.Model != "" {
+			b.model = chunk.Message.Model
+		}
+		b.usage.input = chunk.Message.Usage.InputTokens
+		rw.start()
+	case "content_block_start":
+		if chunk.ContentBlock.Type == "tool_use" {
+			b.callStart(key, chunk.ContentBlock.ID, chunk.ContentBlock.Name)
+		}
+	case "content_block_delta":
+		switch chunk.Delta.Type {
+		case "text_delta":
+			b.textDelta("text", chunk.Delta.Text)
+		case "input_json_delta":
+			b.argsDelta(key, chunk.Delta.PartialJSON)
+		}
+	case "message_delta":
+		b.finishReason(chunk.Delta.StopReason)
+		if chunk.Usage != nil {
+			b.usage.output = chunk.Usage.OutputTokens
+		}
+	case "message_stop":
+		rw.complete()
+	}
+}
+
+// handleResponses serves the OpenAI Responses API endpoint, POST /v1/responses.
+func (p *Proxy) handleResponses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+
+	chat, err := responsesToChat(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Model string `json:"model"`
+	}
+	json.Unmarshal(body, &req)
+
+	r2 := r.Clone(r.Context())
+	r2.Body = io.NopCloser(bytes.NewReader(chat))
+	r2.ContentLength = int64(len(chat))
+	rw := newResponsesWriter(w, req.Model)
+	p.handleChatCompletions(rw, r2)
+	rw.finish()
+}
@@ -0,0 +1,249 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponsesToChat(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantMsgs string
+		wantErr  bool
+	}{
+		{"string input", `{"model":"gpt-4o","instructions":"Be brief.","input":"Hi"}`, `[{"content":"Be brief.","role":"system"},{"content":"Hi","role":"user"}]`, false},
+		{
+			"message items",
+			`{"model":"gpt-4o","input":[{"role":"developer","content":"Rules"},{"type":"message","role":"user","content":[{"type":"input_text","text":"a"},{"type":"input_text","text":"b"}]}]}`,
+			`[{"content":"Rules","role":"system"},{"content":"a\nb","role":"user"}]`, false,
+		},
+		{
+			"function calls",
+			`{"model":"gpt-4o","input":[{"role":"user","content":"Weather?"},` +
+				`{"type":"function_call","call_id":"c1","name":"weather","arguments":"{\"city\":\"Paris\"}"},` +
+				`{"type":"function_call","call_id":"c2","name":"weather","arguments":"{\"city\":\"Rome\"}"},` +
+				`{"type":"function_call_output","call_id":"c1","output":"sunny"},{"type":"reasoning","summary":[]}]}`,
+			`[{"content":"Weather?","role":"user"},` +
+				`{"content":null,"role":"assistant","tool_calls":[{"function":{"arguments":"{\"city\":\"Paris\"}","name":"weather"},"id":"c1","type":"function"},{"function":{"arguments":"{\"city\":\"Rome\"}","name":"weather"},"id":"c2","type":"function"}]},` +
+				`{"content":"sunny","role":"tool","tool_call_id":"c1"}]`, false,
+		},
+		{"previous response", `{"model":"gpt-4o","input":"Hi","previous_response_id":"resp_1"}`, "", true},
+		{"image part", `{"model":"gpt-4o","input":[{"role":"user","content":[{"type":"input_image","image_url":"x"}]}]}`, "", true},
+		{"built-in tool", `{"model":"gpt-4o","input":"Hi","tools":[{"type":"web_search"}]}`, "", true},
+		{"missing input", `{"model":"gpt-4o"}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, err := responsesToChat([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("responsesToChat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var fields map[string]json.RawMessage
+			json.Unmarshal(chat, &fields)
+			if string(fields["messages"]) != tt.wantMsgs {
+				t.Errorf("messages = %s, want %s", fields["messages"], tt.wantMsgs)
+			}
+		})
+	}
+
+	chat, _ := responsesToChat([]byte(`{"model":"o3","input":"Hi","max_output_tokens":64,"reasoning":{"effort":"low"},` +
+		`"tools":[{"type":"function","name":"weather","parameters":{"type":"object"}}],"tool_choice":{"type":"function","name":"weather"}}`))
+	var fields map[string]json.RawMessage
+	json.Unmarshal(chat, &fields)
+	if string(fields["max_tokens"]) != "64" || string(fields["reasoning_effort"]) != `"low"` {
+		t.Errorf("parameters not mapped: %s", chat)
+	}
+	if string(fields["tools"]) != `[{"function":{"name":"weather","parameters":{"type":"object"}},"type":"function"}]` ||
+		string(fields["tool_choice"]) != `{"function":{"name":"weather"},"type":"function"}` {
+		t.Errorf("tools not mapped: %s", chat)
+	}
+}
+
+// decodeResponse unmarshals a response object for assertions.
+func decodeResponse(t *testing.T, data []byte) (resp struct {
+	ID                string `json:"id"`
+	Object            string `json:"object"`
+	Status            string `json:"status"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+	Output []struct {
+		Type    string `json:"type"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		CallID    string `json:"call_id"`
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"output"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}) {
+	t.Helper()
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("decode response: %v\n%s", err, data)
+	}
+	return resp
+}
+
+func TestChatToResponse(t *testing.T) {
+	out, err := chatToResponse([]byte(`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Checking.",`+
+		`"tool_calls":[{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{}"}}]},"finish_reason":"tool_calls"}],`+
+		`"usage":{"prompt_tokens":3,"completion_tokens":2}}`), newResponseBuilder("r1", "gpt-4o", nil))
+	if err != nil {
+		t.Fatalf("chatToResponse() error: %v", err)
+	}
+	resp := decodeResponse(t, out)
+	if resp.ID != "resp_r1" || resp.Object != "response" || resp.Status != "completed" || len(resp.Output) != 2 {
+		t.Fatalf("response = %s", out)
+	}
+	if resp.Output[0].Type != "message" || resp.Output[0].Content[0].Text != "Checking." {
+		t.Errorf("message item = %+v", resp.Output[0])
+	}
+	if c := resp.Output[1]; c.Type != "function_call" || c.CallID != "call_1" || c.Name != "weather" || c.Arguments != "{}" {
+		t.Errorf("function_call item = %+v", c)
+	}
+	if resp.Usage.InputTokens != 3 || resp.Usage.OutputTokens != 2 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+
+	out, _ = chatToResponse([]byte(`{"id":"m1","type":"message","model":"claude-sonnet-4-6","content":[{"type":"thinking","thinking":"..."},{"type":"text","text":"Hello"}],`+
+		`"stop_reason":"max_tokens","usage":{"input_tokens":4,"output_tokens":1}}`), newResponseBuilder("r2", "claude-sonnet-4-6", nil))
+	resp = decodeResponse(t, out)
+	if resp.Status != "incomplete" || resp.IncompleteDetails == nil || resp.IncompleteDetails.Reason != "max_output_tokens" {
+		t.Errorf("truncated response = %s", out)
+	}
+	if len(resp.Output) != 1 || resp.Output[0].Content[0].Text != "Hello" || resp.Usage.InputTokens != 4 {
+		t.Errorf("anthropic response = %s", out)
+	}
+}
+
+func TestResponsesEndpoint(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "application/json",
+		`{"id":"c1","created":1,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2}}`, &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(`{"model":"gpt-4o","input":"Say hi","max_output_tokens":16}`))
+	req.Header.Set("X-Agent-Name", "codex")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(sent, `"messages":[{"content":"Say hi","role":"user"}]`) || strings.Contains(sent, "input") {
+		t.Errorf("upstream body = %s", sent)
+	}
+	resp := decodeResponse(t, w.Body.Bytes())
+	if resp.ID != "resp_"+w.Header().Get("X-Request-ID") || len(resp.Output) != 1 || resp.Output[0].Content[0].Text != "Hi!" {
+		t.Errorf("response = %s", w.Body.String())
+	}
+	if w.Header().Get("X-Input-Tokens") != "3" {
+		t.Errorf("X-Input-Tokens = %q", w.Header().Get("X-Input-Tokens"))
+	}
+}
+
+// sseEvents returns the event names of an SSE body, in order.
+func sseEvents(body string) []string {
+	var events []string
+	for _, line := range strings.Split(body, "\n") {
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, name)
+		}
+	}
+	return events
+}
+
+func TestResponsesEndpointStreaming(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "text/event-stream", strings.Join([]string{
+		`data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"On it."}}]}`, "",
+		`data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"weather","arguments":""}}]}}]}`, "",
+		`data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`, "",
+		`data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`, "",
+		`data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`, "",
+		`data: {"id":"c1","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":7}}`, "",
+		"data: [DONE]", "",
+	}, "\n"), &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(`{"model":"gpt-4o","input":"Weather in Paris?","stream":true,"tools":[{"type":"function","name":"weather"}]}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	want := strings.Join([]string{
+		"response.created",
+		"response.output_item.added", "response.content_part.added", "response.output_text.delta",
+		"response.output_item.added", "response.function_call_arguments.delta", "response.function_call_arguments.delta",
+		"response.output_text.done", "response.content_part.done", "response.output_item.done",
+		"response.function_call_arguments.done", "response.output_item.done",
+		"response.completed",
+	}, " ")
+	if got := strings.Join(sseEvents(w.Body.String()), " "); got != want {
+		t.Fatalf("events =\n%s\nwant\n%s\n%s", got, want, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"arguments":"{\"city\":\"Paris\"}"`) || !strings.Contains(body, `"output_tokens":7`) {
+		t.Errorf("stream missing arguments or usage:\n%s", body)
+	}
+	if strings.Contains(body, "[DONE]") || strings.Contains(body, "chat.completion") {
+		t.Errorf("chat stream leaked through:\n%s", body)
+	}
+}
+
+func TestResponsesEndpointStreamingAnthropic(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "text/event-stream", strings.Join([]string{
+		"event: message_start", `data: {"type":"message_start","message":{"id":"m1","model":"claude-sonnet-4-6","usage":{"input_tokens":5}}}`, "",
+		"event: content_block_start", `data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`, "",
+		"event: content_block_delta", `data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`, "",
+		"event: content_block_stop", `data: {"type":"content_block_stop","index":0}`, "",
+		"event: message_delta", `data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}`, "",
+		"event: message_stop", `data: {"type":"message_stop"}`, "",
+	}, "\n"), &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(`{"model":"claude-sonnet-4-6","input":"Say hi","stream":true}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	events := sseEvents(w.Body.String())
+	if len(events) == 0 || events[0] != "response.created" || events[len(events)-1] != "response.completed" {
+		t.Fatalf("events = %v\n%s", events, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"delta":"Hello"`) || !strings.Contains(body, `"input_tokens":5`) || !strings.Contains(body, `"output_tokens":2`) {
+		t.Errorf("stream not converted:\n%s", body)
+	}
+	if strings.Contains(body, "message_start") {
+		t.Errorf("Anthropic events leaked through:\n%s", body)
+	}
+}
+
+func TestResponsesEndpointErrors(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/responses", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(`{"model":"gpt-4o","input":"Hi","previous_response_id":"resp_1"}`))
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "previous_response_id") {
+		t.Errorf("previous_response_id = %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"log"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/redis"
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// WithSharedSpend makes budget checks read agents' spend from
+// accumulators shared by every gateway instance, so a cost recorded by
+// one instance counts on the others at once instead of when the database
+// catches up.
+func WithSharedSpend(s *redis.Spend) Option {
+	return func(p *Proxy) { p.sharedSpend = s }
+}
+
+// spendScope is whose spend a budget caps: one agent, one provider, one
+// model or, with none set, all traffic.
+type spendScope struct {
+	agent    string
+	provider string
+	model    string
+}
+
+// budgetScope returns the scope of the budget under key in budgets.
+func budgetScope(key string) spendScope {
+	agent, provider, model := config.BudgetScope(key)
+	return spendScope{agent: agent, provider: provider, model: model}
+}
+
+// sharedKey names the scope in the shared accumulators.
+func (sc spendScope) sharedKey() string {
+	switch {
+	case sc.agent != "":
+		return "agent:" + sc.agent
+	case sc.provider != "":
+		return config.ProviderBudget(sc.provider)
+	case sc.model != "":
+		return config.ModelBudget(sc.model)
+	}
+	return config.GlobalBudget
+}
+
+// errorPrefix names the scope in budget errors. An agent's own budget is
+// not named.
+func (sc spendScope) errorPrefix() string {
+	switch {
+	case sc.agent != "":
+		return ""
+	case sc.provider != "":
+		return "provider " + sc.provider + " "
+	case sc.model != "":
+		return "model " + sc.model + " "
+	}
+	return "gateway "
+}
+
+func (sc spendScope) filter() store.SpendFilter {
+	return store.SpendFilter{Agent: sc.agent, Provider: sc.provider, Model: sc.model}
+}
+
+// dailySpend returns the scope's spend on now's UTC day.
+func (p *Proxy) dailySpend(sc spendScope, now time.Time) (float64, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	query := func() (float64, error) { return p.store.QuerySpend(sc.filter(), day, day.AddDate(0, 0, 1)) }
+	if p.sharedSpend == nil {
+		return query()
+	}
+	return p.periodSpend(query,
+		func() (float64, bool, error) { return p.sharedSpend.Daily(sc.sharedKey(), now) },
+		func(v float64) (float64, error) { return p.sharedSpend.SeedDaily(sc.sharedKey(), now, v) })
+}
+
+// monthlySpend returns the scope's spend in now's UTC month.
+func (p *Proxy) monthlySpend(sc spendScope, now time.Time) (float64, error) {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	query := func() (float64, error) { return p.store.QuerySpend(sc.filter(), month, month.AddDate(0, 1, 0)) }
+	if p.sharedSpend == nil {
+		return query()
+	}
+	return p.periodSpend(query,
+		func() (float64, bool, error) { return p.sharedSpend.Monthly(sc.sharedKey(), now.Year(), now.Month()) },
+		func(v float64) (float64, error) {
+			return p.sharedSpend.SeedMonthly(sc.sharedKey(), now.Year(), now.Month(), v)
+		})
+}
+
+// weeklySpend returns the scope's spend in now's week, which starts on
+// Monday UTC. It is read from the database.
+func (p *Proxy) weeklySpend(sc spendScope, now time.Time) (float64, error) {
+	week := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	week = week.AddDate(0, 0, -(int(week.Weekday())+6)%7)
+	return p.store.QuerySpend(sc.filter(), week, week.AddDate(0, 0, 7))
+}
+
+// rollingSpend returns the scope's spend in the window before now. It is
+// read from the database.
+func (p *Proxy) rollingSpend(sc spendScope, window time.Duration, now time.Time) (float64, error) {
+	// Timestamps are stored to the second: end the window after now's
+	// second so requests recorded in it count.
+	return p.store.QuerySpend(sc.filter(), now.Add(-window), now.Add(time.Second))
+}
+
+// periodSpend reads a period's spend from the shared accumulator, seeding
+// it from the database when it is not tracked yet.
+func (p *Proxy) periodSpend(query func() (float64, error), get func() (float64, bool, error), seed func(float64) (float64, error)) (float64, error) {
+	v, ok, err := get()
+	if err == nil && ok {
+		return v, nil
+	}
+	spend, dbErr := query()
+	if err != nil || dbErr != nil {
+		logSharedSpend(err)
+		return spend, dbErr
+	}
+	// Not tracked yet: start from the database, or from whatever another
+	// instance seeded first.
+	if v, err = seed(spend); err != nil {
+		logSharedSpend(err)
+		return spend, nil
+	}
+	return v, nil
+}
+
+func logSharedSpend(err error) {
+	if err != nil {
+		log.Printf("WARN: shared spend: %v", err)
+	}
+}
+
+// addSharedSpend adds a recorded cost to the shared accumulators of every
+// scope it counts against.
+func (p *Proxy) addSharedSpend(record *store.Record) {
+	if p.sharedSpend == nil || record.CostUSD <= 0 {
+		return
+	}
+	scopes := []string{
+		spendScope{}.sharedKey(),
+		spendScope{provider: record.Provider}.sharedKey(),
+		spendScope{model: record.Model}.sharedKey(),
+	}
+	if record.AgentName != "" {
+		scopes = append(scopes, spendScope{agent: record.AgentName}.sharedKey())
+	}
+	logSharedSpend(p.sharedSpend.Add(record.Timestamp, record.CostUSD, scopes...))
+}
@@ -0,0 +1,255 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// anthropicStreamWriter is implemented by response writers whose client
+// asked for the Anthropic format (POST /v1/messages). Anthropic streams
+// reach them as-is; everyone else gets them translated to OpenAI chunks.
+type anthropicStreamWriter interface {
+	acceptsAnthropicStream()
+}
+
+// anthropicToOpenAIStream translates an Anthropic Messages event stream to
+// OpenAI chat completion chunks, one SSE line at a time, so a chat
+// completions client streaming from a Claude model (directly, or after
+// routing or failover) sees the format it asked for.
+type anthropicToOpenAIStream struct {
+	id      string
+	model   string
+	created int64
+
+	inputTokens  int
+	outputTokens int
+	finish       *string
+	tools        map[int]int // content block index → tool call index
+	started      bool
+	done         bool
+}
+
+func newAnthropicToOpenAIStream(model string) *anthropicToOpenAIStream {
+	return &anthropicToOpenAIStream{model: model, created: time.Now().Unix(), tools: make(map[int]int)}
+}
+
+// Convert returns the OpenAI SSE lines for one Anthropic stream line. Event
+// names, blank separators and bookkeeping events produce nothing.
+func (s *anthropicToOpenAIStream) Convert(line string) []string {
+	data, ok := strings.CutPrefix(line, "data: ")
+	if !ok {
+		if strings.HasPrefix(line, ":") {
+			return []string{line, ""} // comments pass through
+		}
+		return nil
+	}
+	var event struct {
+		Type    string `json:"type"`
+		Index   int    `json:"index"`
+		Message struct {
+			ID    string `json:"id"`
+			Model string `json:"model"`
+			Usage struct {
+				InputTokens int `json:"input_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+		ContentBlock struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"content_block"`
+		Delta struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			Thinking    string `json:"thinking"`
+			PartialJSON string `json:"partial_json"`
+			StopReason  string `json:"stop_reason"`
+		} `json:"delta"`
+		Usage struct {
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal([]byte(data), &event) != nil {
+		return nil
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message.ID != "" {
+			s.id = event.Message.ID
+		}
+		if event.Message.Model != "" {
+			s.model = event.Message.Model
+		}
+		s.inputTokens = event.Message.Usage.InputTokens
+		s.started = true
+		return s.chunk(map[string]any{"role": "assistant", "content": ""}, nil)
+	case "content_block_start":
+		if event.ContentBlock.Type != "tool_use" {
+			return nil
+		}
+		n := len(s.tools)
+		s.tools[event.Index] = n
+		return s.chunk(map[string]any{"tool_calls": []map[string]any{{
+			"index":    n,
+			"id":       event.ContentBlock.ID,
+			"type":     "function",
+			"function": map[string]string{"name": event.ContentBlock.Name, "arguments": ""},
+		}}}, nil)
+	case "content_block_delta":
+		switch event.Delta.Type {
+		case "text_delta":
+			return s.chunk(map[string]any{"content": event.Delta.Text}, nil)
+		case "thinking_delta":
+			return s.chunk(map[string]any{"reasoning_content": event.Delta.Thinking}, nil)
+		case "input_json_delta":
+			n, ok := s.tools[event.Index]
+			if !ok {
+				return nil
+			}
+			return s.chunk(map[string]any{"tool_calls": []map[string]any{{
+				"index":    n,
+				"function": map[string]string{"arguments": event.Delta.PartialJSON},
+			}}}, nil)
+		}
+	case "message_delta":
+		s.outputTokens = event.Usage.OutputTokens
+		if event.Delta.StopReason == "tool_use" {
+			reason := "tool_calls"
+			s.finish = &reason
+		} else {
+			s.finish = anthropicFinishReason(event.Delta.StopReason)
+		}
+		return s.chunk(map[string]any{}, s.finish)
+	case "message_stop":
+		return s.Finish()
+	case "error":
+		out, _ := json.Marshal(map[string]json.RawMessage{"error": event.Error})
+		return []string{"data: " + string(out), ""}
+	}
+	return nil
+}
+
+// Finish ends the translated stream with a usage chunk and [DONE], if
+// message_stop has not already done so.
+func (s *anthropicToOpenAIStream) Finish() []string {
+	if s.done || !s.started {
+		return nil
+	}
+	s.done = true
+	usage, _ := json.Marshal(map[string]any{
+		"id": s.id, "object": "chat.completion.chunk", "created": s.created, "model": s.model,
+		"choices": []any{},
+		"usage": map[string]int{
+			"prompt_tokens":     s.inputTokens,
+			"completion_tokens": s.outputTokens,
+			"total_tokens":      s.inputTokens + s.outputTokens,
+		},
+	})
+	return []string{"data: " + string(usage), "", "data: [DONE]", ""}
+}
+
+// chunk renders one chat.completion.chunk with a single choice.
+func (s *anthropicToOpenAIStream) chunk(delta map[string]any, finish *string) []string {
+	out, _ := json.Marshal(map[string]any{
+		"id": s.id, "object": "chat.completion.chunk", "created": s.created, "model": s.model,
+		"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finish}},
+	})
+	return []string{"data: " + string(out), ""}
+}
+
+// sseConvertWriter converts the chat response written by
+// handleChatCompletions to another API's format. SSE streams are passed to
+// line one complete line at a time; other bodies are buffered and, unless
+// they are errors, converted by body in finish.
+type sseConvertWriter struct {
+	w    http.ResponseWriter
+	line func(line string)
+	body func(body []byte) ([]byte, error)
+	end  func() // optional, after the last line of a stream
+
+	status    int
+	streaming bool
+	buf       bytes.Buffer
+}
+
+func (cw *sseConvertWriter) Header() http.Header { return cw.w.Header() }
+
+func (cw *sseConvertWriter) WriteHeader(status int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	cw.streaming = status < 400 && strings.HasPrefix(cw.w.Header().Get("Content-Type"), "text/event-stream")
+	if cw.streaming {
+		cw.w.Header().Del("Content-Length")
+		cw.w.WriteHeader(status)
+	}
+}
+
+func (cw *sseConvertWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	cw.buf.Write(b)
+	if cw.streaming {
+		cw.flushLines()
+	}
+	return len(b), nil
+}
+
+// Flush implements http.Flusher so streaming responses pass through.
+func (cw *sseConvertWriter) Flush() {
+	if f, ok := cw.w.(http.Flusher); ok && cw.streaming {
+		f.Flush()
+	}
+}
+
+// flushLines converts and forwards every complete SSE line in the buffer.
+func (cw *sseConvertWriter) flushLines() {
+	for {
+		i := bytes.IndexByte(cw.buf.Bytes(), '\n')
+		if i < 0 {
+			return
+		}
+		line := string(cw.buf.Next(i + 1))
+		cw.line(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// finish writes the converted non-streaming response, or the remainder of a
+// stream.
+func (cw *sseConvertWriter) finish() {
+	if cw.streaming {
+		if cw.buf.Len() > 0 {
+			cw.line(cw.buf.String())
+			cw.buf.Reset()
+		}
+		if cw.end != nil {
+			cw.end()
+		}
+		return
+	}
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	body := cw.buf.Bytes()
+	if cw.status < 400 {
+		converted, err := cw.body(body)
+		if err != nil {
+			cw.w.Header().Set("Content-Type", "application/json")
+			cw.w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprintf(cw.w, `{"error":%q}`, err.Error())
+			return
+		}
+		body = converted
+	}
+	cw.w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+	cw.w.WriteHeader(cw.status)
+	cw.w.Write(body)
+}
@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var anthropicToolStream = strings.Join([]string{
+	"event: message_start",
+	`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-6","usage":{"input_tokens":12,"output_tokens":1}}}`, "",
+	"event: content_block_start",
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`, "",
+	"event: ping",
+	`data: {"type":"ping"}`, "",
+	"event: content_block_delta",
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking."}}`, "",
+	"event: content_block_stop",
+	`data: {"type":"content_block_stop","index":0}`, "",
+	"event: content_block_start",
+	`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`, "",
+	"event: content_block_delta",
+	`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`, "",
+	"event: content_block_delta",
+	`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`, "",
+	"event: content_block_stop",
+	`data: {"type":"content_block_stop","index":1}`, "",
+	"event: message_delta",
+	`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":9}}`, "",
+	"event: message_stop",
+	`data: {"type":"message_stop"}`, "",
+}, "\n")
+
+// openAIChunks decodes the data lines of an OpenAI stream, stopping at [DONE].
+func openAIChunks(t *testing.T, stream string) (chunks []map[string]any, done bool) {
+	t.Helper()
+	for _, line := range strings.Split(stream, "\n") {
+		if strings.HasPrefix(line, "event: ") {
+			t.Errorf("Anthropic event line forwarded: %s", line)
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk map[string]any
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("bad chunk %s: %v", data, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, done
+}
+
+func TestAnthropicToOpenAIStream(t *testing.T) {
+	s := newAnthropicToOpenAIStream("claude")
+	var out []string
+	for _, line := range strings.Split(anthropicToolStream, "\n") {
+		out = append(out, s.Convert(line)...)
+	}
+	out = append(out, s.Finish()...)
+	chunks, done := openAIChunks(t, strings.Join(out, "\n"))
+
+	if !done {
+		t.Error("stream not terminated with [DONE]")
+	}
+	// role, text, tool call start, two argument deltas, finish, usage
+	if len(chunks) != 7 {
+		t.Fatalf("chunks = %d, want 7:\n%s", len(chunks), strings.Join(out, "\n"))
+	}
+	for _, c := range chunks {
+		if c["id"] != "msg_1" || c["model"] != "claude-sonnet-4-6" || c["object"] != "chat.completion.chunk" {
+			t.Errorf("chunk header = %v", c)
+		}
+	}
+	delta := func(i int) map[string]any {
+		return chunks[i]["choices"].([]any)[0].(map[string]any)["delta"].(map[string]any)
+	}
+	if delta(0)["role"] != "assistant" || delta(1)["content"] != "Checking." {
+		t.Errorf("text deltas = %v, %v", delta(0), delta(1))
+	}
+	call := delta(2)["tool_calls"].([]any)[0].(map[string]any)
+	if call["id"] != "toolu_1" || call["index"] != 0.0 || call["function"].(map[string]any)["name"] != "get_weather" {
+		t.Errorf("tool call start = %v", call)
+	}
+	var args string
+	for _, i := range []int{3, 4} {
+		args += delta(i)["tool_calls"].([]any)[0].(map[string]any)["function"].(map[string]any)["arguments"].(string)
+	}
+	if args != `{"city":"Paris"}` {
+		t.Errorf("arguments = %s", args)
+	}
+	if got := chunks[5]["choices"].([]any)[0].(map[string]any)["finish_reason"]; got != "tool_calls" {
+		t.Errorf("finish_reason = %v", got)
+	}
+	usage := chunks[6]["usage"].(map[string]any)
+	if usage["prompt_tokens"] != 12.0 || usage["completion_tokens"] != 9.0 {
+		t.Errorf("usage = %v", usage)
+	}
+	if extra := s.Finish(); extra != nil {
+		t.Errorf("Finish after message_stop = %v", extra)
+	}
+}
+
+func TestStreamingAnthropicToChatClient(t *testing.T) {
+	p, st := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "text/event-stream", anthropicThinkingStream, &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"claude-sonnet-4-6","stream":true,"messages":[{"role":"user","content":"6*7?"}]}`))
+	req.Header.Set("X-Agent-Name", "sse-agent")
+	req.Header.Set("X-Usage-Trailer", "1")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	chunks, done := openAIChunks(t, body)
+	if !done || len(chunks) == 0 {
+		t.Fatalf("not an OpenAI stream:\n%s", body)
+	}
+	var text, reasoning string
+	for _, c := range chunks {
+		for _, choice := range c["choices"].([]any) {
+			d := choice.(map[string]any)["delta"].(map[string]any)
+			text += str(d["content"])
+			reasoning += str(d["reasoning_content"])
+		}
+	}
+	if text != "42" || reasoning != "Let me think about this problem" {
+		t.Errorf("content = %q, reasoning = %q", text, reasoning)
+	}
+	if i, j := strings.Index(body, ": "+usageTrailerField), strings.Index(body, "data: [DONE]"); i < 0 || i > j {
+		t.Errorf("usage trailer missing or after [DONE]:\n%s", body)
+	}
+
+	rec := waitForRecord(t, st, "sse-agent")
+	if rec.InputTokens != 20 || rec.Provider != "anthropic" {
+		t.Errorf("recorded = %+v", rec)
+	}
+}
+
+func TestStreamingAnthropicToMessagesClient(t *testing.T) {
+	p, _ := newTestProxy(t)
+	var sent string
+	stubUpstream(p, "text/event-stream", anthropicToolStream, &sent)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"claude-sonnet-4-6","max_tokens":64,"stream":true,"messages":[{"role":"user","content":"Weather in Paris?"}]}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if got := w.Body.String(); got != anthropicToolStream {
+		t.Errorf("native stream altered:\n%s", got)
+	}
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
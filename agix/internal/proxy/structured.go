@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// responseFormatInstruction turns an OpenAI response_format into a system
+// prompt instruction, for providers without a native equivalent (Anthropic,
+// Bedrock Converse). It returns "" for plain text or an unknown format.
+// The quality gate's on_schema_mismatch check catches answers that ignore it.
+func responseFormatInstruction(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var rf struct {
+		Type       string `json:"type"`
+		JSONSchema struct {
+			Name        string          `json:"name"`
+			Description string          `json:"description"`
+			Schema      json.RawMessage `json:"schema"`
+		} `json:"json_schema"`
+	}
+	if json.Unmarshal(raw, &rf) != nil {
+		return ""
+	}
+	const only = "with no other text before or after it and no code fences"
+	switch rf.Type {
+	case "json_object":
+		return "Respond with a single valid JSON object, " + only + "."
+	case "json_schema":
+		s := "Respond with a single valid JSON value, " + only + ", that conforms to "
+		if rf.JSONSchema.Name != "" {
+			s += fmt.Sprintf("the JSON schema %q", rf.JSONSchema.Name)
+		} else {
+			s += "this JSON schema"
+		}
+		if rf.JSONSchema.Description != "" {
+			s += " (" + rf.JSONSchema.Description + ")"
+		}
+		if len(rf.JSONSchema.Schema) > 0 {
+			s += ":\n" + string(rf.JSONSchema.Schema)
+		}
+		return s
+	}
+	return ""
+}
+
+// responsesTextFormat converts the Responses API text.format to a chat
+// completions response_format. It returns nil for plain text.
+func responsesTextFormat(raw json.RawMessage) map[string]any {
+	var f struct {
+		Type        string          `json:"type"`
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Schema      json.RawMessage `json:"schema"`
+		Strict      *bool           `json:"strict"`
+	}
+	if json.Unmarshal(raw, &f) != nil {
+		return nil
+	}
+	switch f.Type {
+	case "json_object":
+		return map[string]any{"type": "json_object"}
+	case "json_schema":
+		js := map[string]any{"name": f.Name}
+		if f.Description != "" {
+			js["description"] = f.Description
+		}
+		if len(f.Schema) > 0 {
+			js["schema"] = f.Schema
+		}
+		if f.Strict != nil {
+			js["strict"] = *f.Strict
+		}
+		return map[string]any{"type": "json_schema", "json_schema": js}
+	}
+	return nil
+}
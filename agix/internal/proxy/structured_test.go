@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/qualitygate"
+)
+
+const weatherFormat = `{"type":"json_schema","json_schema":{"name":"weather","schema":{"type":"object","properties":{"temp":{"type":"number"}},"required":["temp"]}}}`
+
+func TestResponseFormatToAnthropic(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantSystem []string // substrings; nil for no system prompt
+	}{
+		{"json_schema", `{"model":"claude-sonnet-4-20250514","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Weather?"}],"response_format":` + weatherFormat + `}`,
+			[]string{"Be brief.\n\nRespond with a single valid JSON value", `schema "weather"`, `"required":["temp"]`}},
+		{"json_object", `{"model":"claude-sonnet-4-20250514","messages":[{"role":"user","content":"Weather?"}],"response_format":{"type":"json_object"}}`,
+			[]string{"Respond with a single valid JSON object"}},
+		{"text", `{"model":"claude-sonnet-4-20250514","messages":[{"role":"user","content":"Weather?"}],"response_format":{"type":"text"}}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := convertToAnthropicFormat([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var req struct {
+				System         string          `json:"system"`
+				ResponseFormat json.RawMessage `json:"response_format"`
+			}
+			json.Unmarshal(out, &req)
+			if req.ResponseFormat != nil {
+				t.Errorf("response_format forwarded to Anthropic: %s", out)
+			}
+			if tt.wantSystem == nil && req.System != "" {
+				t.Errorf("system = %q, want none", req.System)
+			}
+			for _, want := range tt.wantSystem {
+				if !strings.Contains(req.System, want) {
+					t.Errorf("system = %q, want it to contain %q", req.System, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseFormatToConverse(t *testing.T) {
+	out, err := convertToConverseFormat([]byte(`{"messages":[{"role":"user","content":"Weather?"}],"response_format":` + weatherFormat + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req converseRequest
+	json.Unmarshal(out, &req)
+	if len(req.System) != 1 || !strings.Contains(req.System[0].Text, `schema "weather"`) {
+		t.Errorf("system = %+v, want the response format instruction", req.System)
+	}
+}
+
+func TestResponsesTextFormat(t *testing.T) {
+	body := `{"model":"gpt-4o","input":"Weather?","text":{"format":{"type":"json_schema","name":"weather","strict":true,"schema":{"type":"object"}}}}`
+	out, err := responsesToChat([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chat struct {
+		ResponseFormat json.RawMessage `json:"response_format"`
+	}
+	json.Unmarshal(out, &chat)
+	want := `{"json_schema":{"name":"weather","schema":{"type":"object"},"strict":true},"type":"json_schema"}`
+	if string(chat.ResponseFormat) != want {
+		t.Errorf("response_format = %s, want %s", chat.ResponseFormat, want)
+	}
+
+	out, _ = responsesToChat([]byte(`{"model":"gpt-4o","input":"hi","text":{"format":{"type":"text"}}}`))
+	if strings.Contains(string(out), "response_format") {
+		t.Errorf("text format produced response_format: %s", out)
+	}
+}
+
+func TestQualityGateSchemaMismatch(t *testing.T) {
+	prose := `{"choices":[{"message":{"role":"assistant","content":"It is 21 degrees."},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`
+	valid := `{"choices":[{"message":{"role":"assistant","content":"{\"temp\": 21}"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`
+
+	tests := []struct {
+		name       string
+		action     qualitygate.ActionType
+		replies    []string
+		wantStatus int
+		wantCalls  int
+		wantBody   string
+	}{
+		{"retry succeeds", qualitygate.ActionRetry, []string{prose, valid}, http.StatusOK, 2, "temp"},
+		{"reject", qualitygate.ActionReject, []string{prose}, http.StatusUnprocessableEntity, 1, "quality gate: response does not match response_format"},
+		{"valid", qualitygate.ActionRetry, []string{valid}, http.StatusOK, 1, "temp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			WithQualityGate(qualitygate.New(qualitygate.Config{Enabled: true, OnSchemaMismatch: tt.action}))(p)
+
+			calls := 0
+			p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				calls++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(tt.replies[min(calls, len(tt.replies))-1])),
+					Request:    r,
+				}, nil
+			})
+
+			body := `{"model":"gpt-4o","messages":[{"role":"user","content":"Weather?"}],"response_format":` + weatherFormat + `}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("response = %d %s", w.Code, w.Body.String())
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
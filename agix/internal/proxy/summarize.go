@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/agent-platform/agix/internal/compressor"
+)
+
+// defaultSummarizeMaxTokens bounds the estimated size of a conversation
+// accepted by /v1/summarize when summarizer.max_input_tokens is unset.
+const defaultSummarizeMaxTokens = 100000
+
+type summarizeRequest struct {
+	Messages []compressor.Message `json:"messages"`
+	Method   string               `json:"method"` // auto (default), llm or extractive
+	Model    string               `json:"model"`  // overrides summarizer.model for llm
+}
+
+// handleSummarize serves POST /v1/summarize: the compressor's summary of a
+// messages array. LLM summaries go back through the gateway as agent
+// _gateway/summarizer, so they are priced, recorded and budgeted there.
+func (p *Proxy) handleSummarize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	if p.summarizer == nil {
+		http.Error(w, `{"error":"summarizer is disabled; set summarizer.enabled: true"}`, http.StatusNotFound)
+		return
+	}
+	requestID := requestIDFor(r)
+	w.Header().Set("X-Request-ID", requestID)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req summarizeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, `{"error":"invalid request: messages must be an array of {role, content} with string content"}`, http.StatusBadRequest)
+		return
+	}
+	if len(req.Messages) == 0 {
+		http.Error(w, `{"error":"messages field is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	maxTokens := p.cfg.Summarizer.MaxInputTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultSummarizeMaxTokens
+	}
+	if tokens := len(body) / 4; tokens > maxTokens {
+		http.Error(w, fmt.Sprintf(`{"error":"conversation too large: ~%d tokens (max %d)"}`, tokens, maxTokens), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	switch req.Method {
+	case "", compressor.MethodAuto, compressor.MethodExtractive:
+	case compressor.MethodLLM:
+		if p.summarizer.Model() == "" {
+			http.Error(w, `{"error":"llm summaries need summarizer.model or compression.summary_model"}`, http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, "unknown method "+req.Method+" (want auto, llm or extractive)"), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := p.summarizer.Summarize(req.Messages, req.Method, req.Model)
+	if err != nil {
+		log.Printf("SUMMARIZE: %s: %v", requestID, err)
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, "summary failed: "+err.Error()), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/compressor"
+)
+
+func TestSummarizeEndpoint(t *testing.T) {
+	p, _ := newTestProxy(t)
+	llm := func(model string, _ []compressor.Message) (string, error) {
+		return "summary from " + model, nil
+	}
+	WithSummarizer(compressor.NewSummarizer("gpt-4o-mini", llm))(p)
+
+	messages := `[{"role":"user","content":"hello"},{"role":"assistant","content":"hi there"}]`
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantText   string
+	}{
+		{"llm", http.MethodPost, `{"messages":` + messages + `}`, http.StatusOK, "summary from gpt-4o-mini"},
+		{"extractive", http.MethodPost, `{"method":"extractive","messages":` + messages + `}`, http.StatusOK, "[user]: hello"},
+		{"model override", http.MethodPost, `{"model":"gpt-4o","messages":` + messages + `}`, http.StatusOK, "summary from gpt-4o"},
+		{"unknown method", http.MethodPost, `{"method":"magic","messages":` + messages + `}`, http.StatusBadRequest, ""},
+		{"no messages", http.MethodPost, `{"messages":[]}`, http.StatusBadRequest, ""},
+		{"non-string content", http.MethodPost, `{"messages":[{"role":"user","content":[{"type":"text"}]}]}`, http.StatusBadRequest, ""},
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/summarize", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantText == "" {
+				if tt.wantStatus >= 400 && !json.Valid(w.Body.Bytes()) {
+					t.Errorf("error body is not JSON: %s", w.Body.String())
+				}
+				return
+			}
+			var got compressor.Summary
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || !strings.Contains(got.Summary, tt.wantText) {
+				t.Errorf("response = %s, want summary containing %q", w.Body.String(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestSummarizeEndpointLimits(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/summarize", strings.NewReader(`{"messages":[{"role":"user","content":"hi"}]}`))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("disabled summarizer status = %d, want 404", w.Code)
+	}
+
+	WithSummarizer(compressor.NewSummarizer("", nil))(p)
+	req = httptest.NewRequest(http.MethodPost, "/v1/summarize", strings.NewReader(`{"method":"llm","messages":[{"role":"user","content":"hi"}]}`))
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("llm without model status = %d, want 400", w.Code)
+	}
+
+	p.cfg.Summarizer.MaxInputTokens = 10
+	req = httptest.NewRequest(http.MethodPost, "/v1/summarize", strings.NewReader(`{"messages":[{"role":"user","content":"`+strings.Repeat("word ", 100)+`"}]}`))
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized status = %d, want 413", w.Code)
+	}
+}
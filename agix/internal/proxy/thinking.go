@@ -0,0 +1,229 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// thinkingBudgets maps OpenAI-style reasoning_effort to an Anthropic
+// extended-thinking budget when the agent does not send "thinking" itself.
+var thinkingBudgets = map[string]int{
+	"low":    1024,
+	"medium": 4096,
+	"high":   16384,
+}
+
+// anthropicThinking resolves the thinking configuration for an Anthropic
+// request: an explicit "thinking" object is passed through, otherwise
+// reasoning_effort is translated. Returns nil and 0 when thinking is off.
+func anthropicThinking(thinking json.RawMessage, reasoningEffort string) (json.RawMessage, int) {
+	if len(thinking) > 0 && string(thinking) != "null" {
+		var cfg struct {
+			Type         string `json:"type"`
+			BudgetTokens int    `json:"budget_tokens"`
+		}
+		if err := json.Unmarshal(thinking, &cfg); err != nil || cfg.Type != "enabled" {
+			return thinking, 0
+		}
+		return thinking, cfg.BudgetTokens
+	}
+
+	budget, ok := thinkingBudgets[reasoningEffort]
+	if !ok {
+		return nil, 0
+	}
+	out, _ := json.Marshal(map[string]any{"type": "enabled", "budget_tokens": budget})
+	return out, budget
+}
+
+// isThinkingBlock reports whether an Anthropic content block type carries
+// extended-thinking content.
+func isThinkingBlock(blockType string) bool {
+	return blockType == "thinking" || blockType == "redacted_thinking"
+}
+
+// stripThinking removes thinking blocks from an Anthropic message response.
+func stripThinking(body []byte) []byte {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return body
+	}
+	var content []map[string]json.RawMessage
+	if err := json.Unmarshal(resp["content"], &content); err != nil {
+		return body
+	}
+
+	filtered := []map[string]json.RawMessage{}
+	for _, block := range content {
+		var blockType string
+		json.Unmarshal(block["type"], &blockType)
+		if !isThinkingBlock(blockType) {
+			filtered = append(filtered, block)
+		}
+	}
+	if len(filtered) == len(content) {
+		return body
+	}
+
+	contentData, _ := json.Marshal(filtered)
+	resp["content"] = contentData
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// anthropicThinkingTokens estimates the tokens spent on thinking in an
+// Anthropic message response. Anthropic bills thinking as output tokens
+// but does not report it separately, so the estimate is capped at the
+// reported output tokens.
+func anthropicThinkingTokens(body []byte) int {
+	var resp struct {
+		Content []struct {
+			Type     string `json:"type"`
+			Thinking string `json:"thinking"`
+		} `json:"content"`
+		Usage struct {
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0
+	}
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "thinking" {
+			text.WriteString(block.Thinking)
+			text.WriteString(" ")
+		}
+	}
+	tokens := estimateTokens(text.String())
+	if resp.Usage.OutputTokens > 0 {
+		tokens = min(tokens, resp.Usage.OutputTokens)
+	}
+	return tokens
+}
+
+// estimateTokens approximates the token count of a string using word count * 1.3.
+func estimateTokens(s string) int {
+	words := len(strings.Fields(s))
+	return int(float64(words) * 1.3)
+}
+
+// thinkingFilter processes an Anthropic SSE stream line by line. It tallies
+// thinking text for reasoning-token accounting and, when strip is set,
+// drops thinking blocks and renumbers the remaining content block indexes
+// so clients see a contiguous content array.
+type thinkingFilter struct {
+	strip    bool
+	removed  map[int]bool
+	pending  []string // lines of the current SSE event (strip mode only)
+	thinking strings.Builder
+}
+
+func newThinkingFilter(strip bool) *thinkingFilter {
+	return &thinkingFilter{strip: strip, removed: make(map[int]bool)}
+}
+
+// Feed consumes one SSE line and returns the lines to forward to the client.
+func (f *thinkingFilter) Feed(line string) []string {
+	if !f.strip {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			f.inspect(data)
+		}
+		return []string{line}
+	}
+
+	if line != "" {
+		f.pending = append(f.pending, line)
+		return nil
+	}
+	lines := f.flushEvent()
+	if lines == nil {
+		return nil
+	}
+	return append(lines, "")
+}
+
+// Flush returns any buffered lines at the end of the stream.
+func (f *thinkingFilter) Flush() []string {
+	return f.flushEvent()
+}
+
+// ReasoningTokens estimates the thinking tokens seen so far.
+func (f *thinkingFilter) ReasoningTokens() int {
+	return estimateTokens(f.thinking.String())
+}
+
+// flushEvent decides whether the buffered event is forwarded, dropped or rewritten.
+func (f *thinkingFilter) flushEvent() []string {
+	lines := f.pending
+	f.pending = nil
+	for i, line := range lines {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		keep, rewritten := f.inspect(data)
+		if !keep {
+			return nil
+		}
+		lines[i] = "data: " + rewritten
+	}
+	return lines
+}
+
+// inspect examines one SSE data payload. It reports whether the event should
+// be kept and returns the (possibly renumbered) payload.
+func (f *thinkingFilter) inspect(data string) (bool, string) {
+	var event struct {
+		Type         string `json:"type"`
+		Index        *int   `json:"index"`
+		ContentBlock *struct {
+			Type string `json:"type"`
+		} `json:"content_block"`
+		Delta *struct {
+			Type     string `json:"type"`
+			Thinking string `json:"thinking"`
+		} `json:"delta"`
+	}
+	if err := json.Unmarshal([]byte(data), &event); err != nil || event.Index == nil {
+		return true, data
+	}
+	idx := *event.Index
+
+	if event.Delta != nil && event.Delta.Type == "thinking_delta" {
+		f.thinking.WriteString(event.Delta.Thinking)
+	}
+	if !f.strip {
+		return true, data
+	}
+
+	if event.Type == "content_block_start" && event.ContentBlock != nil && isThinkingBlock(event.ContentBlock.Type) {
+		f.removed[idx] = true
+	}
+	if f.removed[idx] {
+		return false, ""
+	}
+
+	shift := 0
+	for r := range f.removed {
+		if r < idx {
+			shift++
+		}
+	}
+	if shift == 0 {
+		return true, data
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return true, data
+	}
+	raw["index"], _ = json.Marshal(idx - shift)
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return true, data
+	}
+	return true, string(out)
+}
@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConvertToAnthropicFormatThinking(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantBudget    float64 // 0 = no thinking
+		wantMaxTokens float64
+		wantTemp      bool
+	}{
+		{
+			name:          "explicit thinking passed through",
+			input:         `{"model":"claude-opus-4-6","messages":[],"max_tokens":20000,"thinking":{"type":"enabled","budget_tokens":8000},"temperature":0.5}`,
+			wantBudget:    8000,
+			wantMaxTokens: 20000,
+		},
+		{
+			name:          "reasoning_effort translated, max_tokens above budget",
+			input:         `{"model":"claude-opus-4-6","messages":[],"reasoning_effort":"high"}`,
+			wantBudget:    16384,
+			wantMaxTokens: 4096 + 16384,
+		},
+		{
+			name:          "no thinking keeps temperature",
+			input:         `{"model":"claude-opus-4-6","messages":[],"temperature":0.5}`,
+			wantMaxTokens: 4096,
+			wantTemp:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := convertToAnthropicFormat([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("convertToAnthropicFormat() error: %v", err)
+			}
+			var got struct {
+				MaxTokens   float64  `json:"max_tokens"`
+				Temperature *float64 `json:"temperature"`
+				Thinking    *struct {
+					Type         string  `json:"type"`
+					BudgetTokens float64 `json:"budget_tokens"`
+				} `json:"thinking"`
+			}
+			json.Unmarshal(out, &got)
+
+			if tt.wantBudget == 0 {
+				if got.Thinking != nil {
+					t.Errorf("thinking = %+v, want none", got.Thinking)
+				}
+			} else if got.Thinking == nil || got.Thinking.BudgetTokens != tt.wantBudget {
+				t.Errorf("thinking = %+v, want budget %v", got.Thinking, tt.wantBudget)
+			}
+			if got.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("max_tokens = %v, want %v", got.MaxTokens, tt.wantMaxTokens)
+			}
+			if (got.Temperature != nil) != tt.wantTemp {
+				t.Errorf("temperature present = %v, want %v", got.Temperature != nil, tt.wantTemp)
+			}
+		})
+	}
+}
+
+const anthropicThinkingResponse = `{"id":"msg_1","type":"message","content":[` +
+	`{"type":"thinking","thinking":"Let me work through this step by step carefully","signature":"abc"},` +
+	`{"type":"text","text":"The answer is 42."}],` +
+	`"usage":{"input_tokens":20,"output_tokens":40}}`
+
+func TestStripThinking(t *testing.T) {
+	out := stripThinking([]byte(anthropicThinkingResponse))
+
+	var resp struct {
+		Content []struct {
+			Type string `json:"type"`
+		} `json:"content"`
+		Usage struct {
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.Content) != 1 || resp.Content[0].Type != "text" {
+		t.Errorf("content = %+v, want only the text block", resp.Content)
+	}
+	if resp.Usage.OutputTokens != 40 {
+		t.Errorf("usage changed: output_tokens = %d, want 40", resp.Usage.OutputTokens)
+	}
+
+	plain := []byte(`{"content":[{"type":"text","text":"hi"}]}`)
+	if got := stripThinking(plain); string(got) != string(plain) {
+		t.Errorf("response without thinking rewritten: %s", got)
+	}
+}
+
+func TestAnthropicThinkingTokens(t *testing.T) {
+	// 9 words * 1.3 = 11
+	if got := extractReasoningTokens("anthropic", []byte(anthropicThinkingResponse)); got != 11 {
+		t.Errorf("extractReasoningTokens() = %d, want 11", got)
+	}
+	capped := `{"content":[{"type":"thinking","thinking":"one two three four five six seven eight"}],"usage":{"output_tokens":4}}`
+	if got := anthropicThinkingTokens([]byte(capped)); got != 4 {
+		t.Errorf("anthropicThinkingTokens() = %d, want capped at 4", got)
+	}
+}
+
+const anthropicThinkingStream = `event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":20,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me think "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"about this problem"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"42"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_stop
+data: {"type":"message_stop"}
+`
+
+func runThinkingFilter(f *thinkingFilter) string {
+	var out []string
+	for _, line := range strings.Split(anthropicThinkingStream, "\n") {
+		out = append(out, f.Feed(line)...)
+	}
+	out = append(out, f.Flush()...)
+	return strings.Join(out, "\n")
+}
+
+func TestThinkingFilterStrip(t *testing.T) {
+	f := newThinkingFilter(true)
+	got := runThinkingFilter(f)
+
+	if strings.Contains(got, "thinking") {
+		t.Errorf("stripped stream still contains thinking events:\n%s", got)
+	}
+	if strings.Contains(got, `"index":1`) {
+		t.Errorf("text block not renumbered to index 0:\n%s", got)
+	}
+	if !strings.Contains(got, `"text":"42"`) || !strings.Contains(got, "event: message_stop") {
+		t.Errorf("non-thinking events missing:\n%s", got)
+	}
+	// "Let me think about this problem" = 6 words * 1.3
+	if n := f.ReasoningTokens(); n != 7 {
+		t.Errorf("ReasoningTokens() = %d, want 7", n)
+	}
+}
+
+func TestThinkingFilterPreserve(t *testing.T) {
+	f := newThinkingFilter(false)
+	got := runThinkingFilter(f)
+
+	if got != anthropicThinkingStream {
+		t.Errorf("preserve mode altered the stream:\n%s", got)
+	}
+	if n := f.ReasoningTokens(); n != 7 {
+		t.Errorf("ReasoningTokens() = %d, want 7", n)
+	}
+}
@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// toolCallResult is the response of POST /v1/tools/{name}/call.
+type toolCallResult struct {
+	Tool       string `json:"tool"`
+	Server     string `json:"server"`
+	Agent      string `json:"agent,omitempty"`
+	Result     string `json:"result"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// handleToolCall serves POST /v1/tools/{name}/call: it runs one MCP tool
+// through the tool manager without an LLM round trip, so operators can test
+// MCP servers. The agent's allow/deny rules apply and the call is audited
+// like any other. Only loopback clients may use it, since it executes tools
+// directly.
+func (p *Proxy) handleToolCall(w http.ResponseWriter, r *http.Request) {
+	if p.toolMgr == nil {
+		http.Error(w, `{"error":"no MCP tools configured"}`, http.StatusNotFound)
+		return
+	}
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/v1/tools/"), "/call")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	if !isLoopback(r.RemoteAddr) {
+		http.Error(w, `{"error":"tool calls are only accepted from localhost"}`, http.StatusForbidden)
+		return
+	}
+
+	requestID := requestIDFor(r)
+	w.Header().Set("X-Request-ID", requestID)
+
+	var req struct {
+		Arguments map[string]any `json:"arguments"`
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, `{"error":"arguments must be a JSON object"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	server := p.toolMgr.ServerForTool(name)
+	if server == "" {
+		http.Error(w, fmt.Sprintf(`{"error":"unknown tool %q"}`, name), http.StatusNotFound)
+		return
+	}
+	agentName := r.Header.Get("X-Agent-Name")
+	allowed := false
+	for _, t := range p.toolMgr.ToolsForAgent(agentName) {
+		if t.Name == name {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		http.Error(w, fmt.Sprintf(`{"error":"tool %q is not allowed for agent %q"}`, name, agentName), http.StatusForbidden)
+		return
+	}
+
+	tc := toolCall{Name: name, Arguments: req.Arguments}
+	start := time.Now()
+	text, err := p.toolMgr.CallTool(name, req.Arguments)
+	duration := time.Since(start)
+	result := toolCallResult{Tool: name, Server: server, Agent: agentName, Result: text, DurationMS: duration.Milliseconds()}
+	status := "ok"
+	if err != nil {
+		status = "error"
+		result.Error = err.Error()
+	}
+	p.auditToolCall(tc, agentName, requestID, status, duration)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// isLoopback reports whether a request's remote address is on this host.
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
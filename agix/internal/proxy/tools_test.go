@@ -0,0 +1,309 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/audit"
+	"github.com/agent-platform/agix/internal/config"
+	"github.com/agent-platform/agix/internal/mcp"
+	"github.com/agent-platform/agix/internal/store"
+	"github.com/agent-platform/agix/internal/toolmgr"
+)
+
+// echoMCPServer answers every tools/call with the call's arguments as text.
+func echoMCPServer(t *testing.T) *mcp.Client {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	t.Cleanup(func() { serverW.Close(); clientW.Close() })
+	go func() {
+		scanner := bufio.NewScanner(serverR)
+		for scanner.Scan() {
+			var req struct {
+				ID     int64 `json:"id"`
+				Params struct {
+					Arguments json.RawMessage `json:"arguments"`
+				} `json:"params"`
+			}
+			json.Unmarshal(scanner.Bytes(), &req)
+			text, _ := json.Marshal(string(req.Params.Arguments))
+			fmt.Fprintf(serverW, `{"jsonrpc":"2.0","id":%d,"result":{"content":[{"type":"text","text":%s}]}}`+"\n", req.ID, text)
+		}
+	}()
+	return mcp.NewClientFromIO("fs", clientR, clientW)
+}
+
+func TestHandleToolCall(t *testing.T) {
+	p, _ := newTestProxy(t)
+	mgr := toolmgr.NewFromClients(map[string]*mcp.Client{"fs": echoMCPServer(t)}, map[string]config.AgentTools{
+		"restricted": {Deny: []string{"read_file"}},
+	})
+	mgr.SetTools([]toolmgr.ToolEntry{{Tool: mcp.Tool{Name: "read_file"}, Server: "fs"}})
+	p.toolMgr = mgr
+
+	call := func(path, agent, remote, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.RemoteAddr = remote
+		if agent != "" {
+			req.Header.Set("X-Agent-Name", agent)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("/v1/tools/read_file/call", "ops", "127.0.0.1:5000", `{"arguments":{"path":"/tmp/x"}}`)
+	var result toolCallResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if result.Server != "fs" || result.Result != `{"path":"/tmp/x"}` || result.Error != "" {
+		t.Errorf("result = %+v", result)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		agent  string
+		remote string
+		body   string
+		want   int
+	}{
+		{"denied for agent", "/v1/tools/read_file/call", "restricted", "127.0.0.1:5000", "", http.StatusForbidden},
+		{"remote client", "/v1/tools/read_file/call", "", "10.0.0.8:5000", "", http.StatusForbidden},
+		{"unknown tool", "/v1/tools/rm_rf/call", "", "[::1]:5000", "", http.StatusNotFound},
+		{"bad arguments", "/v1/tools/read_file/call", "", "127.0.0.1:5000", `{"arguments":[1]}`, http.StatusBadRequest},
+		{"bad path", "/v1/tools/read_file", "", "127.0.0.1:5000", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := call(tt.path, tt.agent, tt.remote, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestToolLoopRepeatDetection(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.cfg.Tools.MaxIterations = 6
+	mgr := toolmgr.NewFromClients(map[string]*mcp.Client{"fs": echoMCPServer(t)}, nil)
+	mgr.SetTools([]toolmgr.ToolEntry{{Tool: mcp.Tool{Name: "read_file"}, Server: "fs"}})
+	p.toolMgr = mgr
+	logger := audit.New(st.DB(), true, st.Dialect())
+	WithAuditLogger(logger, config.AuditConfig{Enabled: true})(p)
+
+	// The model asks for the same file on every turn unless vary is set.
+	var calls int
+	vary := false
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		path := "/tmp/x"
+		if vary {
+			path = fmt.Sprintf("/tmp/%d", calls)
+		}
+		args, _ := json.Marshal(fmt.Sprintf(`{"path":%q}`, path))
+		body := fmt.Sprintf(`{"choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[{"id":"call_%d","type":"function","function":{"name":"read_file","arguments":%s}}]}}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`, calls, args)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"read it"}]}`))
+		req.Header.Set("X-Agent-Name", "stuck-agent")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send()
+	if w.Code != http.StatusLoopDetected {
+		t.Fatalf("status = %d, want %d (body %s)", w.Code, http.StatusLoopDetected, w.Body.String())
+	}
+	var resp struct {
+		Type    string `json:"type"`
+		Tool    string `json:"tool"`
+		Repeats int    `json:"repeats"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Type != "tool_loop" || resp.Tool != "read_file" || resp.Repeats != 3 {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	if calls != 3 {
+		t.Errorf("upstream calls = %d, want 3", calls)
+	}
+
+	logger.Close()
+	events, err := logger.QueryRecent(10, audit.EventToolLoop, "stuck-agent")
+	if err != nil || len(events) != 1 || events[0].RequestID != w.Header().Get("X-Request-ID") {
+		t.Errorf("tool_loop events = %+v, err %v", events, err)
+	}
+
+	// Different arguments each turn are progress, not a loop.
+	calls, vary = 0, true
+	p.auditLogger = nil
+	if w := send(); w.Code != http.StatusInternalServerError || calls != 6 {
+		t.Errorf("varying calls: status = %d after %d upstream calls, want 500 after 6", w.Code, calls)
+	}
+}
+
+func TestToolLoopRecordsPartialUsage(t *testing.T) {
+	p, st := newTestProxy(t)
+	p.cfg.Tools.MaxIterations = 4
+	mgr := toolmgr.NewFromClients(map[string]*mcp.Client{"fs": echoMCPServer(t)}, nil)
+	mgr.SetTools([]toolmgr.ToolEntry{{Tool: mcp.Tool{Name: "read_file"}, Server: "fs"}})
+	p.toolMgr = mgr
+
+	// Every turn asks for a new file; failAt makes that upstream call fail.
+	var calls, failAt int
+	p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == failAt {
+			return nil, fmt.Errorf("connection reset")
+		}
+		args, _ := json.Marshal(fmt.Sprintf(`{"path":"/tmp/%d"}`, calls))
+		body := fmt.Sprintf(`{"choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[{"id":"call_%d","type":"function","function":{"name":"read_file","arguments":%s}}]}}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`, calls, args)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+
+	tests := []struct {
+		name       string
+		failAt     int
+		wantStatus int
+		wantInput  int
+	}{
+		{"max iterations", 0, http.StatusInternalServerError, 40},
+		{"upstream fails mid-loop", 3, http.StatusBadGateway, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, failAt = 0, tt.failAt
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"read"}]}`))
+			req.Header.Set("X-Agent-Name", "looping-agent")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			var records []store.Record
+			for deadline := time.Now().Add(3 * time.Second); len(records) == 0 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+				records, _ = st.QueryRequestsByRequestID(w.Header().Get("X-Request-ID"))
+			}
+			if len(records) != 1 {
+				t.Fatalf("records = %d, want 1", len(records))
+			}
+			if rec := records[0]; rec.StatusCode != tt.wantStatus || rec.InputTokens != tt.wantInput || rec.CostUSD <= 0 {
+				t.Errorf("recorded = %+v", rec)
+			}
+		})
+	}
+}
+
+func TestClientTools(t *testing.T) {
+	weatherTool := `{"type":"function","function":{"name":"get_weather","parameters":{"type":"object"}}}`
+	reqBody := `{"model":"gpt-4o","messages":[{"role":"user","content":"weather?"}],"tools":[` + weatherTool + `]}`
+	// The model calls the agent's tool and an MCP tool in the same turn.
+	upstreamResp := `{"choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[` +
+		`{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{}"}},` +
+		`{"id":"call_2","type":"function","function":{"name":"read_file","arguments":"{\"path\":\"/tmp/x\"}"}}]}}],` +
+		`"usage":{"prompt_tokens":10,"completion_tokens":5}}`
+
+	tests := []struct {
+		mode      string
+		wantTools []string
+		wantCalls []string
+	}{
+		{config.ClientToolsMerge, []string{"get_weather", "read_file"}, []string{"get_weather"}},
+		{config.ClientToolsSkip, []string{"get_weather"}, []string{"get_weather", "read_file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			mgr := toolmgr.NewFromClients(map[string]*mcp.Client{"fs": echoMCPServer(t)}, map[string]config.AgentTools{
+				"weather-agent": {ClientTools: tt.mode},
+			})
+			mgr.SetTools([]toolmgr.ToolEntry{{Tool: mcp.Tool{Name: "read_file"}, Server: "fs"}})
+			p.toolMgr = mgr
+
+			var sent []string
+			p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(r.Body)
+				sent = append(sent, string(b))
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(upstreamResp)), Request: r}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(reqBody))
+			req.Header.Set("X-Agent-Name", "weather-agent")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK || len(sent) != 1 {
+				t.Fatalf("status = %d after %d upstream calls, body = %s", w.Code, len(sent), w.Body.String())
+			}
+			if got := clientToolNames([]byte(sent[0])); strings.Join(got, ",") != strings.Join(tt.wantTools, ",") {
+				t.Errorf("upstream tools = %v, want %v", got, tt.wantTools)
+			}
+			var names []string
+			for _, tc := range extractToolCalls("openai", w.Body.Bytes()) {
+				names = append(names, tc.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("calls returned to agent = %v, want %v", names, tt.wantCalls)
+			}
+		})
+	}
+
+	t.Run(config.ClientToolsReplace, func(t *testing.T) {
+		p, _ := newTestProxy(t)
+		mgr := toolmgr.NewFromClients(map[string]*mcp.Client{"fs": echoMCPServer(t)}, map[string]config.AgentTools{
+			"weather-agent": {ClientTools: config.ClientToolsReplace},
+		})
+		mgr.SetTools([]toolmgr.ToolEntry{{Tool: mcp.Tool{Name: "read_file"}, Server: "fs"}})
+		p.toolMgr = mgr
+
+		var first string
+		p.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(r.Body)
+			body := `{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"done"}}]}`
+			if first == "" {
+				first, body = string(b), upstreamResp
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(reqBody))
+		req.Header.Set("X-Agent-Name", "weather-agent")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+
+		if got := clientToolNames([]byte(first)); len(got) != 1 || got[0] != "read_file" {
+			t.Errorf("upstream tools = %v, want [read_file]", got)
+		}
+		if w.Code != http.StatusOK || len(extractToolCalls("openai", w.Body.Bytes())) != 0 {
+			t.Errorf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestMergeClientTools(t *testing.T) {
+	client := []byte(`{"tools":[{"type":"function","function":{"name":"read_file","description":"mine"}},{"type":"function","function":{"name":"get_weather"}}]}`)
+	injected := injectTools(client, []toolmgr.ToolEntry{
+		{Tool: mcp.Tool{Name: "read_file", Description: "mcp"}, Server: "fs"},
+		{Tool: mcp.Tool{Name: "write_file"}, Server: "fs"},
+	}, "openai")
+
+	merged := mergeClientTools(injected, client)
+	if got := strings.Join(clientToolNames(merged), ","); got != "read_file,get_weather,write_file" {
+		t.Errorf("merged tools = %s", got)
+	}
+	if !strings.Contains(string(merged), `"mine"`) || strings.Contains(string(merged), `"mcp"`) {
+		t.Errorf("agent's read_file should win: %s", merged)
+	}
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/agent-platform/agix/internal/compressor"
+)
+
+type truncateRequest struct {
+	Messages  []compressor.Message `json:"messages"`
+	MaxTokens int                  `json:"max_tokens"` // defaults to compression.threshold_tokens
+}
+
+// handleTruncate serves POST /v1/truncate: a messages array trimmed to a
+// token budget by dropping the oldest conversation messages, counted the
+// way the compressor counts them. Agents can keep their context under the
+// compression threshold without approximating the gateway's count.
+func (p *Proxy) handleTruncate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req truncateRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, `{"error":"invalid request: messages must be an array of {role, content} with string content"}`, http.StatusBadRequest)
+		return
+	}
+	if len(req.Messages) == 0 {
+		http.Error(w, `{"error":"messages field is required"}`, http.StatusBadRequest)
+		return
+	}
+	if req.MaxTokens == 0 && p.compressor != nil {
+		req.MaxTokens = p.compressor.Threshold()
+	}
+	if req.MaxTokens <= 0 {
+		http.Error(w, `{"error":"max_tokens must be a positive integer (no compression threshold to default to)"}`, http.StatusBadRequest)
+		return
+	}
+
+	out, err := compressor.Truncate(req.Messages, req.MaxTokens)
+	if errors.Is(err, compressor.ErrCannotFit) {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/compressor"
+)
+
+func TestTruncateEndpoint(t *testing.T) {
+	p, _ := newTestProxy(t)
+
+	long := strings.TrimSpace(strings.Repeat("word ", 10)) // ~13 tokens
+	messages := `[{"role":"system","content":"` + long + `"},{"role":"user","content":"` + long + `"},` +
+		`{"role":"assistant","content":"` + long + `"},{"role":"user","content":"` + long + `"}]`
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantKept   int
+	}{
+		{"trims", http.MethodPost, `{"max_tokens":30,"messages":` + messages + `}`, http.StatusOK, 2},
+		{"fits", http.MethodPost, `{"max_tokens":1000,"messages":` + messages + `}`, http.StatusOK, 4},
+		{"cannot fit", http.MethodPost, `{"max_tokens":20,"messages":` + messages + `}`, http.StatusUnprocessableEntity, 0},
+		{"no budget", http.MethodPost, `{"messages":` + messages + `}`, http.StatusBadRequest, 0},
+		{"negative budget", http.MethodPost, `{"max_tokens":-1,"messages":` + messages + `}`, http.StatusBadRequest, 0},
+		{"no messages", http.MethodPost, `{"max_tokens":10,"messages":[]}`, http.StatusBadRequest, 0},
+		{"non-string content", http.MethodPost, `{"max_tokens":10,"messages":[{"role":"user","content":[{"type":"text"}]}]}`, http.StatusBadRequest, 0},
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/truncate", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if tt.body != "" && !json.Valid(w.Body.Bytes()) {
+					t.Errorf("error body is not JSON: %s", w.Body.String())
+				}
+				return
+			}
+			var got compressor.Truncation
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || len(got.Messages) != tt.wantKept {
+				t.Errorf("response = %s, want %d messages kept", w.Body.String(), tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestTruncateEndpointDefaultsToCompressionThreshold(t *testing.T) {
+	p, _ := newTestProxy(t)
+	WithCompressor(compressor.New(compressor.Config{Enabled: true, ThresholdTokens: 30}, nil))(p)
+
+	long := strings.TrimSpace(strings.Repeat("word ", 10))
+	body := `{"messages":[{"role":"user","content":"` + long + `"},{"role":"assistant","content":"` + long + `"},{"role":"user","content":"` + long + `"}]}`
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/truncate", strings.NewReader(body)))
+
+	var got compressor.Truncation
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.MaxTokens != 30 || got.Dropped != 1 {
+		t.Errorf("response = %s, want max_tokens 30 and 1 dropped", w.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// usageTrailerField is the top-level field added to JSON responses and the
+// name of the SSE comment added to streams.
+const usageTrailerField = "agix_usage"
+
+// usageTrailer is the usage summary returned in the response body when the
+// agent asks for it (usage_trailer config or X-Usage-Trailer header).
+type usageTrailer struct {
+	Model        string  `json:"model"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	Cached       bool    `json:"cached,omitempty"`
+	RequestID    string  `json:"request_id,omitempty"`
+}
+
+// wantsUsageTrailer reports whether the response to r should carry a usage
+// trailer.
+func (p *Proxy) wantsUsageTrailer(r *http.Request, agentName string) bool {
+	if r != nil && r.Header.Get("X-Usage-Trailer") != "" {
+		return true
+	}
+	ut := p.cfg.UsageTrailer
+	return ut.Enabled && (len(ut.Agents) == 0 || slices.Contains(ut.Agents, agentName))
+}
+
+// appendUsageTrailer adds the trailer as a top-level field of a JSON object
+// body. Bodies that are not JSON objects are returned unchanged.
+func appendUsageTrailer(body []byte, t usageTrailer) []byte {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body
+	}
+	tj, err := json.Marshal(t)
+	if err != nil {
+		return body
+	}
+	raw[usageTrailerField] = tj
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// sseComment renders the trailer as an SSE comment line, which SSE clients
+// that don't know about it ignore.
+func (t usageTrailer) sseComment() string {
+	tj, _ := json.Marshal(t)
+	return ": " + usageTrailerField + " " + string(tj) + "\n\n"
+}
@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+func TestWantsUsageTrailer(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.UsageTrailerConfig
+		header string
+		agent  string
+		want   bool
+	}{
+		{"disabled", config.UsageTrailerConfig{}, "", "a1", false},
+		{"header opt-in", config.UsageTrailerConfig{}, "1", "a1", true},
+		{"enabled for all", config.UsageTrailerConfig{Enabled: true}, "", "a1", true},
+		{"enabled for listed agent", config.UsageTrailerConfig{Enabled: true, Agents: []string{"a1"}}, "", "a1", true},
+		{"enabled for other agent", config.UsageTrailerConfig{Enabled: true, Agents: []string{"a2"}}, "", "a1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Proxy{cfg: &config.Config{UsageTrailer: tt.cfg}}
+			r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tt.header != "" {
+				r.Header.Set("X-Usage-Trailer", tt.header)
+			}
+			if got := p.wantsUsageTrailer(r, tt.agent); got != tt.want {
+				t.Errorf("wantsUsageTrailer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendUsageTrailer(t *testing.T) {
+	body := appendUsageTrailer([]byte(`{"id":"c1","choices":[]}`), usageTrailer{Model: "gpt-4o", InputTokens: 10, OutputTokens: 5, CostUSD: 0.0001})
+	var got struct {
+		ID    string       `json:"id"`
+		Usage usageTrailer `json:"agix_usage"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.ID != "c1" || got.Usage.Model != "gpt-4o" || got.Usage.OutputTokens != 5 {
+		t.Errorf("body = %s", body)
+	}
+
+	if out := appendUsageTrailer([]byte(`not json`), usageTrailer{}); string(out) != "not json" {
+		t.Errorf("non-JSON body changed: %s", out)
+	}
+}
+
+func TestUsageTrailerNonStreaming(t *testing.T) {
+	p, _ := newTestProxy(t)
+	p.cfg.UsageTrailer.Enabled = true
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	respBody := []byte(`{"id":"c1","usage":{"prompt_tokens":1000,"completion_tokens":500}}`)
+	w := httptest.NewRecorder()
+	p.writeNonStreamingResponse(w, nil, resp, respBody, "gpt-4o", "openai", "", time.Now(), 0, nil, "", "")
+
+	var got struct {
+		Usage *usageTrailer `json:"agix_usage"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Usage == nil || got.Usage.Model != "gpt-4o" || got.Usage.InputTokens != 1000 || got.Usage.CostUSD <= 0 {
+		t.Errorf("agix_usage = %+v, want gpt-4o with 1000 input tokens and a cost", got.Usage)
+	}
+}
+
+func TestUsageTrailerStreaming(t *testing.T) {
+	tests := []struct {
+		name string
+		sse  string
+	}{
+		{"before done", "data: {\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5}}\n\ndata: [DONE]\n"},
+		{"at end without done", "data: {\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5}}\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			p.cfg.UsageTrailer.Enabled = true
+
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"text/event-stream"}},
+				Body:       io.NopCloser(strings.NewReader(tt.sse)),
+			}
+			w := httptest.NewRecorder()
+			p.handleStreamingResponse(w, nil, resp, "gpt-4o", "openai", "", time.Now(), 0, nil)
+
+			out := w.Body.String()
+			idx := strings.Index(out, ": agix_usage ")
+			if idx < 0 {
+				t.Fatalf("no usage trailer in stream:\n%s", out)
+			}
+			if done := strings.Index(out, "data: [DONE]"); done >= 0 && done < idx {
+				t.Errorf("trailer written after [DONE]:\n%s", out)
+			}
+			line := strings.TrimPrefix(strings.SplitN(out[idx:], "\n", 2)[0], ": agix_usage ")
+			var got usageTrailer
+			if err := json.Unmarshal([]byte(line), &got); err != nil {
+				t.Fatalf("unmarshal trailer %q: %v", line, err)
+			}
+			if got.InputTokens != 10 || got.OutputTokens != 5 || got.Model != "gpt-4o" {
+				t.Errorf("trailer = %+v", got)
+			}
+		})
+	}
+}
@@ -21,11 +21,14 @@ type Config struct {
 	OnEmpty      ActionType `yaml:"on_empty"`
 	OnTruncated  ActionType `yaml:"on_truncated"`
 	OnRefusal    ActionType `yaml:"on_refusal"`
+	// OnSchemaMismatch enables validating answers against the request's
+	// response_format (json_object or json_schema). Empty leaves it off.
+	OnSchemaMismatch ActionType `yaml:"on_schema_mismatch"`
 }
 
 // Issue describes a detected quality problem.
 type Issue struct {
-	Type    string     // "empty", "truncated", "refusal"
+	Type    string     // "empty", "truncated", "refusal", "schema"
 	Action  ActionType
 	Message string
 }
@@ -0,0 +1,322 @@
+package qualitygate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// CheckSchema validates the answer in respBody against the response_format
+// of the chat request reqBody: a JSON object for "json_object", and the
+// supplied schema for "json_schema". It returns nil when the mode is off
+// (on_schema_mismatch unset), the request asks for no structured output, or
+// the answer matches.
+func (g *Gate) CheckSchema(reqBody, respBody []byte) *Issue {
+	if g.cfg.OnSchemaMismatch == "" {
+		return nil
+	}
+	schema, ok := requestedSchema(reqBody)
+	if !ok {
+		return nil
+	}
+	text, ok := responseText(respBody)
+	if !ok {
+		return nil // not a completion, e.g. an upstream error
+	}
+	mismatch := func(msg string) *Issue {
+		return &Issue{Type: "schema", Action: g.cfg.OnSchemaMismatch, Message: "response does not match response_format: " + msg}
+	}
+
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return mismatch("not valid JSON")
+	}
+	if dec.More() {
+		return mismatch("trailing data after JSON value")
+	}
+	if err := validate(schema, schema, v, "$"); err != nil {
+		return mismatch(err.Error())
+	}
+	return nil
+}
+
+// requestedSchema returns the JSON schema a chat request's response_format
+// asks for. json_object is treated as the schema {"type": "object"}.
+func requestedSchema(reqBody []byte) (map[string]any, bool) {
+	var req struct {
+		ResponseFormat *struct {
+			Type       string `json:"type"`
+			JSONSchema struct {
+				Schema map[string]any `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if json.Unmarshal(reqBody, &req) != nil || req.ResponseFormat == nil {
+		return nil, false
+	}
+	switch req.ResponseFormat.Type {
+	case "json_object":
+		return map[string]any{"type": "object"}, true
+	case "json_schema":
+		if s := req.ResponseFormat.JSONSchema.Schema; s != nil {
+			return s, true
+		}
+		return map[string]any{}, true
+	}
+	return nil, false
+}
+
+// responseText returns the answer text of an OpenAI chat completion or an
+// Anthropic message.
+func responseText(respBody []byte) (string, bool) {
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content *string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if json.Unmarshal(respBody, &resp) != nil {
+		return "", false
+	}
+	if len(resp.Choices) > 0 && resp.Choices[0].Message.Content != nil {
+		return *resp.Choices[0].Message.Content, true
+	}
+	var sb strings.Builder
+	found := false
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+			found = true
+		}
+	}
+	return sb.String(), found
+}
+
+// validate checks v, decoded with UseNumber, against the subset of JSON
+// Schema used for structured outputs: type, enum, const, properties,
+// required, additionalProperties, items, anyOf/oneOf/allOf, local $ref and
+// the usual length, size and range bounds. Unknown keywords are ignored.
+func validate(root, schema map[string]any, v any, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := resolveRef(root, ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return validate(root, target, v, path)
+	}
+
+	if t, ok := schema["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []any:
+			for _, x := range t {
+				if s, ok := x.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+		if !slices.ContainsFunc(types, func(t string) bool { return hasType(v, t) }) {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), typeName(v))
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return jsonEqual(e, v) }) {
+		return fmt.Errorf("%s: value is not one of the allowed values", path)
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, v) {
+		return fmt.Errorf("%s: value does not equal const", path)
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		subs, ok := schema[key].([]any)
+		if !ok {
+			continue
+		}
+		matched := slices.ContainsFunc(subs, func(s any) bool {
+			sub, ok := s.(map[string]any)
+			return ok && validate(root, sub, v, path) == nil
+		})
+		if !matched {
+			return fmt.Errorf("%s: value matches none of %s", path, key)
+		}
+	}
+	if subs, ok := schema["allOf"].([]any); ok {
+		for _, s := range subs {
+			if sub, ok := s.(map[string]any); ok {
+				if err := validate(root, sub, v, path); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		return validateObject(root, schema, v, path)
+	case []any:
+		if n, ok := number(schema["minItems"]); ok && float64(len(v)) < n {
+			return fmt.Errorf("%s: expected at least %v items, got %d", path, n, len(v))
+		}
+		if n, ok := number(schema["maxItems"]); ok && float64(len(v)) > n {
+			return fmt.Errorf("%s: expected at most %v items, got %d", path, n, len(v))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validate(root, items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := float64(len([]rune(v)))
+		if min, ok := number(schema["minLength"]); ok && n < min {
+			return fmt.Errorf("%s: expected at least %v characters", path, min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && n > max {
+			return fmt.Errorf("%s: expected at most %v characters", path, max)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if min, ok := number(schema["minimum"]); ok && f < min {
+			return fmt.Errorf("%s: %s is below the minimum %v", path, v, min)
+		}
+		if max, ok := number(schema["maximum"]); ok && f > max {
+			return fmt.Errorf("%s: %s is above the maximum %v", path, v, max)
+		}
+	}
+	return nil
+}
+
+func validateObject(root, schema, obj map[string]any, path string) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := obj[name]; !present {
+					return fmt.Errorf("%s: missing required property %q", path, name)
+				}
+			}
+		}
+	}
+	props, _ := schema["properties"].(map[string]any)
+	// Check properties in a stable order so the reported error is too.
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		p := path + "." + name
+		if sub, ok := props[name].(map[string]any); ok {
+			if err := validate(root, sub, obj[name], p); err != nil {
+				return err
+			}
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				return fmt.Errorf("%s: unexpected property", p)
+			}
+		case map[string]any:
+			if err := validate(root, extra, obj[name], p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveRef resolves a local reference such as "#/$defs/item".
+func resolveRef(root map[string]any, ref string) (map[string]any, error) {
+	rest, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	node := root
+	for _, part := range strings.Split(strings.TrimPrefix(rest, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		next, ok := node[part].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+		node = next
+	}
+	return node, nil
+}
+
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		f, err := v.Float64()
+		return t == "integer" && err == nil && f == math.Trunc(f)
+	}
+	return false
+}
+
+func typeName(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case json.Number:
+		if hasType(v, "integer") {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// number reads a numeric schema keyword, which json.Unmarshal decodes as
+// float64.
+func number(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+// jsonEqual compares a schema value (numbers as float64) with an instance
+// value (numbers as json.Number) by their JSON encoding.
+func jsonEqual(schemaValue, v any) bool {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		s, isNum := schemaValue.(float64)
+		return err == nil && isNum && f == s
+	}
+	a, err1 := json.Marshal(schemaValue)
+	b, err2 := json.Marshal(v)
+	return err1 == nil && err2 == nil && bytes.Equal(a, b)
+}
@@ -0,0 +1,98 @@
+package qualitygate
+
+import (
+	"strings"
+	"testing"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"status": {"enum": ["pending", "shipped"]},
+		"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/item"}},
+		"note": {"type": ["string", "null"], "maxLength": 10}
+	},
+	"required": ["id", "status", "items"],
+	"additionalProperties": false,
+	"$defs": {
+		"item": {
+			"type": "object",
+			"properties": {"sku": {"type": "string"}, "qty": {"type": "integer"}},
+			"required": ["sku", "qty"]
+		}
+	}
+}`
+
+func schemaRequest(format string) []byte {
+	return []byte(`{"model":"gpt-4o","messages":[],"response_format":` + format + `}`)
+}
+
+func TestCheckSchema(t *testing.T) {
+	g := New(Config{Enabled: true, OnSchemaMismatch: ActionRetry})
+	jsonSchema := schemaRequest(`{"type":"json_schema","json_schema":{"name":"order","schema":` + orderSchema + `}}`)
+
+	tests := []struct {
+		name    string
+		req     []byte
+		content string
+		want    string // substring of the issue message; "" for no issue
+	}{
+		{"valid", jsonSchema, `{"id": 7, "status": "shipped", "items": [{"sku": "A1", "qty": 2}], "note": null}`, ""},
+		{"not JSON", jsonSchema, "Sure! Here is the order: {}", "not valid JSON"},
+		{"code fence", jsonSchema, "```json\n{}\n```", "not valid JSON"},
+		{"trailing text", jsonSchema, `{"id": 1} thanks`, "trailing data"},
+		{"missing required", jsonSchema, `{"id": 7, "status": "shipped"}`, `$: missing required property "items"`},
+		{"wrong type", jsonSchema, `{"id": "7", "status": "shipped", "items": [{"sku": "A1", "qty": 2}]}`, "$.id: expected integer, got string"},
+		{"not an integer", jsonSchema, `{"id": 7.5, "status": "shipped", "items": [{"sku": "A1", "qty": 2}]}`, "$.id: expected integer, got number"},
+		{"below minimum", jsonSchema, `{"id": 0, "status": "shipped", "items": [{"sku": "A1", "qty": 2}]}`, "below the minimum"},
+		{"enum", jsonSchema, `{"id": 7, "status": "lost", "items": [{"sku": "A1", "qty": 2}]}`, "$.status: value is not one of"},
+		{"ref", jsonSchema, `{"id": 7, "status": "shipped", "items": [{"sku": "A1"}]}`, `$.items[0]: missing required property "qty"`},
+		{"min items", jsonSchema, `{"id": 7, "status": "shipped", "items": []}`, "at least 1 items"},
+		{"extra property", jsonSchema, `{"id": 7, "status": "shipped", "items": [{"sku": "A1", "qty": 2}], "x": 1}`, "$.x: unexpected property"},
+		{"max length", jsonSchema, `{"id": 7, "status": "shipped", "items": [{"sku": "A1", "qty": 2}], "note": "leave at the door"}`, "$.note: expected at most 10 characters"},
+		{"json_object ok", schemaRequest(`{"type":"json_object"}`), `{"anything": true}`, ""},
+		{"json_object array", schemaRequest(`{"type":"json_object"}`), `[1, 2]`, "expected object, got array"},
+		{"text format", schemaRequest(`{"type":"text"}`), "plain prose", ""},
+		{"no response_format", []byte(`{"model":"gpt-4o"}`), "plain prose", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := g.CheckSchema(tt.req, makeResponse(tt.content, "stop"))
+			if tt.want == "" {
+				if issue != nil {
+					t.Errorf("CheckSchema() = %+v, want nil", issue)
+				}
+				return
+			}
+			if issue == nil {
+				t.Fatalf("CheckSchema() = nil, want issue containing %q", tt.want)
+			}
+			if issue.Type != "schema" || issue.Action != ActionRetry || !strings.Contains(issue.Message, tt.want) {
+				t.Errorf("CheckSchema() = %+v, want a schema issue containing %q", issue, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSchema_AnthropicResponse(t *testing.T) {
+	g := New(Config{Enabled: true, OnSchemaMismatch: ActionReject})
+	req := schemaRequest(`{"type":"json_object"}`)
+
+	ok := []byte(`{"type":"message","content":[{"type":"text","text":"{\"a\": 1}"}]}`)
+	if issue := g.CheckSchema(req, ok); issue != nil {
+		t.Errorf("CheckSchema(valid Anthropic message) = %+v", issue)
+	}
+	bad := []byte(`{"type":"message","content":[{"type":"text","text":"Here you go"}]}`)
+	if issue := g.CheckSchema(req, bad); issue == nil || issue.Action != ActionReject {
+		t.Errorf("CheckSchema(invalid Anthropic message) = %+v, want reject", issue)
+	}
+}
+
+func TestCheckSchema_OffByDefault(t *testing.T) {
+	g := New(Config{Enabled: true})
+	req := schemaRequest(`{"type":"json_object"}`)
+	if issue := g.CheckSchema(req, makeResponse("not json", "stop")); issue != nil {
+		t.Errorf("CheckSchema() without on_schema_mismatch = %+v, want nil", issue)
+	}
+}
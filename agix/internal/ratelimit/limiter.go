@@ -2,6 +2,7 @@ package ratelimit
 
 import (
 	"fmt"
+	"log"
 	"sync"
 	"time"
 )
@@ -10,28 +11,64 @@ import (
 type Limit struct {
 	RequestsPerMinute int `yaml:"requests_per_minute"`
 	RequestsPerHour   int `yaml:"requests_per_hour"`
+	TokensPerMinute   int `yaml:"tokens_per_minute"`
+	TokensPerDay      int `yaml:"tokens_per_day"`
 }
 
+// All is the key under which a Limiter's limits map holds a limit on all
+// requests combined, whatever their agent.
+const All = "*"
+
 // Limiter enforces per-agent rate limits using a sliding window counter.
 type Limiter struct {
 	limits  map[string]Limit
+	global  Limit
 	mu      sync.Mutex
 	windows map[string]*window
+	all     *window // requests of every agent, for the global limit
+	now     func() time.Time
+
+	shared       Shared // counts instead of windows when set
+	sharedWarned time.Time
 }
 
 type window struct {
 	timestamps []time.Time
+	// Token usage in one-second buckets for the last minute and
+	// one-minute buckets for the last day; allocated on first use.
+	minute, day *ring
 }
 
-// New creates a Limiter from the given per-agent limits.
-// Returns nil if limits is nil or empty.
+// New creates a Limiter from the given per-agent limits, plus a limit on
+// all agents combined under All. Returns nil if limits is nil or empty.
 func New(limits map[string]Limit) *Limiter {
 	if len(limits) == 0 {
 		return nil
 	}
-	return &Limiter{
-		limits:  limits,
+	l := &Limiter{
 		windows: make(map[string]*window),
+		all:     &window{},
+		now:     time.Now,
+	}
+	l.setLimits(limits)
+	return l
+}
+
+// SetLimits replaces the per-agent limits. Request history is kept, so a
+// config reload does not give every agent a fresh window.
+func (l *Limiter) SetLimits(limits map[string]Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setLimits(limits)
+}
+
+func (l *Limiter) setLimits(limits map[string]Limit) {
+	l.global = limits[All]
+	l.limits = make(map[string]Limit, len(limits))
+	for agent, limit := range limits {
+		if agent != All {
+			l.limits[agent] = limit
+		}
 	}
 }
 
@@ -44,35 +81,87 @@ type Result struct {
 
 // Allow checks whether the agent is within its rate limits.
 func (l *Limiter) Allow(agent string) Result {
+	result, _ := l.Reserve(agent, 0)
+	return result
+}
+
+// Reserve is Allow for a request expected to use tokens tokens. If it is
+// allowed, the estimate counts against the token limits until the
+// returned reservation is settled with the actual usage. The reservation
+// is nil when no token limit applies. The global limit counts requests
+// without an agent too.
+func (l *Limiter) Reserve(agent string, tokens int) (Result, *Reservation) {
+	l.mu.Lock()
+	shared, global, limit := l.shared, l.global, l.limits[agent]
+	now := l.now()
+	l.mu.Unlock()
 	if agent == "" {
-		return Result{Allowed: true}
+		limit = Limit{}
 	}
-
-	limit, ok := l.limits[agent]
-	if !ok {
-		return Result{Allowed: true}
+	if shared != nil {
+		result, res, err := l.reserveShared(shared, now, agent, tokens, global, limit)
+		if err == nil {
+			return result, res
+		}
+		l.warnShared(err)
 	}
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	var windows []*window
+	if l.global != (Limit{}) {
+		if r := l.all.check(l.global, now, tokens, "gateway rate limit exceeded"); r != nil {
+			return *r, nil
+		}
+		windows = append(windows, l.all)
+	}
+	if limit, ok := l.limits[agent]; ok && agent != "" {
+		w := l.getWindow(agent)
+		if r := w.check(limit, now, tokens, "rate limit exceeded"); r != nil {
+			return *r, nil
+		}
+		windows = append(windows, w)
+	}
+
+	// Record this request
+	var res *Reservation
+	for _, w := range windows {
+		w.timestamps = append(w.timestamps, now)
+		if w.minute == nil {
+			continue
+		}
+		w.minute.add(now, tokens)
+		w.day.add(now, tokens)
+		if res == nil {
+			res = &Reservation{l: l, at: now, estimate: tokens}
+		}
+		res.windows = append(res.windows, w)
+	}
+	return Result{Allowed: true}, res
+}
 
-	now := time.Now()
-	w := l.getWindow(agent)
+// check returns the denial for a request against limit, or nil if it is
+// allowed. prefix starts the error message.
+func (w *window) check(limit Limit, now time.Time, tokens int, prefix string) *Result {
 	w.evict(now, time.Hour) // keep only last hour of data
 
+	deny := func(retryAfter time.Duration, format string, args ...any) *Result {
+		if retryAfter < time.Second {
+			retryAfter = time.Second
+		}
+		return &Result{
+			Allowed:    false,
+			RetryAfter: retryAfter,
+			Err:        fmt.Errorf(prefix+": "+format, args...),
+		}
+	}
+
 	// Check per-minute limit
 	if limit.RequestsPerMinute > 0 {
 		count := w.countSince(now, time.Minute)
 		if count >= limit.RequestsPerMinute {
-			retryAfter := w.oldestSince(now, time.Minute).Add(time.Minute).Sub(now)
-			if retryAfter < time.Second {
-				retryAfter = time.Second
-			}
-			return Result{
-				Allowed:    false,
-				RetryAfter: retryAfter,
-				Err:        fmt.Errorf("rate limit exceeded: %d requests per minute (limit %d)", count, limit.RequestsPerMinute),
-			}
+			return deny(w.oldestSince(now, time.Minute).Add(time.Minute).Sub(now),
+				"%d requests per minute (limit %d)", count, limit.RequestsPerMinute)
 		}
 	}
 
@@ -80,22 +169,85 @@ func (l *Limiter) Allow(agent string) Result {
 	if limit.RequestsPerHour > 0 {
 		count := w.countSince(now, time.Hour)
 		if count >= limit.RequestsPerHour {
-			retryAfter := w.oldestSince(now, time.Hour).Add(time.Hour).Sub(now)
-			if retryAfter < time.Second {
-				retryAfter = time.Second
-			}
-			return Result{
-				Allowed:    false,
-				RetryAfter: retryAfter,
-				Err:        fmt.Errorf("rate limit exceeded: %d requests per hour (limit %d)", count, limit.RequestsPerHour),
-			}
+			return deny(w.oldestSince(now, time.Hour).Add(time.Hour).Sub(now),
+				"%d requests per hour (limit %d)", count, limit.RequestsPerHour)
 		}
 	}
 
-	// Record this request
-	w.timestamps = append(w.timestamps, now)
+	// Check token limits
+	if limit.TokensPerMinute <= 0 && limit.TokensPerDay <= 0 {
+		return nil
+	}
+	if w.minute == nil {
+		w.minute, w.day = newRing(time.Second, 60), newRing(time.Minute, 24*60)
+	}
+	for _, c := range []struct {
+		ring  *ring
+		limit int
+		per   string
+	}{
+		{w.minute, limit.TokensPerMinute, "minute"},
+		{w.day, limit.TokensPerDay, "day"},
+	} {
+		if c.limit <= 0 {
+			continue
+		}
+		used := c.ring.sum(now)
+		if used < c.limit && used+tokens <= c.limit {
+			continue
+		}
+		if tokens > c.limit {
+			return deny(c.ring.span(), "request needs about %d tokens, more than the limit of %d tokens per %s", tokens, c.limit, c.per)
+		}
+		return deny(c.ring.waitFor(now, used+tokens-c.limit), "%d tokens per %s (limit %d)", used, c.per, c.limit)
+	}
+	return nil
+}
 
-	return Result{Allowed: true}
+// Reservation is a request's estimated token usage, held against the
+// token limits that applied to it.
+type Reservation struct {
+	l        *Limiter
+	windows  []*window
+	at       time.Time
+	estimate int
+	settled  bool
+
+	// Shared token counters holding the estimate, if counted there.
+	shared     Shared
+	sharedKeys []string
+	sharedTTLs []time.Duration
+}
+
+// Settle records tokens actually used. The first call replaces the
+// estimate; later calls, for further upstream calls made by the same
+// request, add to it. A nil reservation ignores it.
+func (r *Reservation) Settle(tokens int) {
+	if r == nil {
+		return
+	}
+	r.l.mu.Lock()
+	if !r.settled {
+		tokens -= r.estimate
+		r.settled = true
+	}
+	if r.shared == nil {
+		for _, w := range r.windows {
+			w.minute.add(r.at, tokens)
+			w.day.add(r.at, tokens)
+		}
+		r.l.mu.Unlock()
+		return
+	}
+	r.l.mu.Unlock()
+
+	deltas := make([]int64, len(r.sharedKeys))
+	for i := range deltas {
+		deltas[i] = int64(tokens)
+	}
+	if _, err := r.shared.IncrBy(r.sharedKeys, deltas, r.sharedTTLs); err != nil {
+		log.Printf("WARN: shared rate limits: true-up failed: %v", err)
+	}
 }
 
 func (l *Limiter) getWindow(agent string) *window {
@@ -141,3 +293,62 @@ func (w *window) oldestSince(now time.Time, d time.Duration) time.Time {
 	}
 	return now
 }
+
+// ring sums token counts over a sliding window of fixed-width buckets.
+type ring struct {
+	width  time.Duration
+	bucket []int64 // bucket number held by each slot
+	count  []int
+}
+
+func newRing(width time.Duration, n int) *ring {
+	return &ring{width: width, bucket: make([]int64, n), count: make([]int, n)}
+}
+
+func (r *ring) bucketOf(t time.Time) int64 { return t.UnixNano() / int64(r.width) }
+
+// span is the length of the window.
+func (r *ring) span() time.Duration { return r.width * time.Duration(len(r.bucket)) }
+
+// add counts n at time t. Times that have left the window are ignored.
+func (r *ring) add(t time.Time, n int) {
+	b := r.bucketOf(t)
+	i := b % int64(len(r.bucket))
+	switch {
+	case r.bucket[i] > b:
+		return
+	case r.bucket[i] < b:
+		r.bucket[i], r.count[i] = b, 0
+	}
+	r.count[i] += n
+}
+
+// sum returns the total counted within the window ending at now.
+func (r *ring) sum(now time.Time) int {
+	nb := r.bucketOf(now)
+	total := 0
+	for i, b := range r.bucket {
+		if b > nb-int64(len(r.bucket)) && b <= nb {
+			total += r.count[i]
+		}
+	}
+	return total
+}
+
+// waitFor returns how long until at least excess has left the window.
+func (r *ring) waitFor(now time.Time, excess int) time.Duration {
+	nb := r.bucketOf(now)
+	n := int64(len(r.bucket))
+	freed := 0
+	for b := nb - n + 1; b <= nb; b++ {
+		i := b % n
+		if r.bucket[i] != b {
+			continue
+		}
+		freed += r.count[i]
+		if freed >= excess {
+			return time.Unix(0, (b+n)*int64(r.width)).Sub(now)
+		}
+	}
+	return r.span()
+}
@@ -1,6 +1,9 @@
 package ratelimit
 
 import (
+	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -90,6 +93,21 @@ func TestAllow_IndependentAgents(t *testing.T) {
 	}
 }
 
+func TestSetLimits_KeepsHistory(t *testing.T) {
+	l := New(map[string]Limit{"agent1": {RequestsPerMinute: 5}})
+	for i := 0; i < 2; i++ {
+		l.Allow("agent1")
+	}
+
+	l.SetLimits(map[string]Limit{"agent1": {RequestsPerMinute: 2}})
+	if r := l.Allow("agent1"); r.Allowed {
+		t.Error("expected deny: lowered limit should count earlier requests")
+	}
+	if r := l.Allow("agent2"); !r.Allowed {
+		t.Error("expected allow for agent dropped from limits")
+	}
+}
+
 func TestWindow_Evict(t *testing.T) {
 	w := &window{
 		timestamps: []time.Time{
@@ -103,3 +121,174 @@ func TestWindow_Evict(t *testing.T) {
 		t.Errorf("expected 2 timestamps after evict, got %d", len(w.timestamps))
 	}
 }
+
+func TestReserve_TokensPerMinute(t *testing.T) {
+	l := New(map[string]Limit{"agent1": {TokensPerMinute: 1000}})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	r, res := l.Reserve("agent1", 600)
+	if !r.Allowed || res == nil {
+		t.Fatal("first 600-token request should be allowed")
+	}
+	if r, _ := l.Reserve("agent1", 600); r.Allowed {
+		t.Error("second 600-token request should be denied on the estimate")
+	}
+
+	// The response reports fewer tokens than estimated
+	res.Settle(300)
+	now = now.Add(10 * time.Second)
+	if r, _ := l.Reserve("agent1", 600); !r.Allowed {
+		t.Error("request should fit once the first is trued up to 300")
+	}
+	r, _ = l.Reserve("agent1", 200)
+	if r.Allowed {
+		t.Fatal("request over 1000 tokens per minute should be denied")
+	}
+	// 300 tokens leave the window 60s after the first request
+	if r.RetryAfter != 50*time.Second {
+		t.Errorf("RetryAfter = %v, want 50s", r.RetryAfter)
+	}
+
+	now = now.Add(51 * time.Second)
+	if r, _ := l.Reserve("agent1", 200); !r.Allowed {
+		t.Error("request should be allowed after the first left the window")
+	}
+	if r, _ := l.Reserve("agent1", 5000); r.Allowed || r.Err == nil {
+		t.Error("request larger than the limit itself should be denied")
+	}
+}
+
+func TestReserve_TokensPerDay(t *testing.T) {
+	l := New(map[string]Limit{"agent1": {TokensPerDay: 1000}})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	_, res := l.Reserve("agent1", 100)
+	res.Settle(700)
+	res.Settle(250) // a second upstream call for the same request
+	now = now.Add(time.Hour)
+	if r, _ := l.Reserve("agent1", 100); r.Allowed {
+		t.Error("request should be denied with 950 of 1000 tokens used today")
+	}
+	now = now.Add(23 * time.Hour)
+	if r, _ := l.Reserve("agent1", 100); !r.Allowed {
+		t.Error("request should be allowed a day later")
+	}
+}
+
+func TestReserve_NoTokenLimit(t *testing.T) {
+	l := New(map[string]Limit{"agent1": {RequestsPerMinute: 5}})
+	r, res := l.Reserve("agent1", 1_000_000)
+	if !r.Allowed || res != nil {
+		t.Errorf("Reserve = %+v, %v; want allowed without a reservation", r, res)
+	}
+	res.Settle(10) // nil-safe
+}
+
+func TestReserve_Global(t *testing.T) {
+	l := New(map[string]Limit{
+		All:      {RequestsPerMinute: 3, TokensPerMinute: 1000},
+		"agent1": {RequestsPerMinute: 1},
+	})
+
+	if r := l.Allow("agent1"); !r.Allowed {
+		t.Fatal("agent1 first request should be allowed")
+	}
+	if r := l.Allow("agent1"); r.Allowed {
+		t.Error("agent1 second request should be denied by its own limit")
+	}
+	if r := l.Allow("agent2"); !r.Allowed {
+		t.Error("agent2 should be allowed: the denied request did not count globally")
+	}
+	if r := l.Allow(""); !r.Allowed {
+		t.Error("request without agent should be allowed")
+	}
+	r := l.Allow("agent3")
+	if r.Allowed {
+		t.Fatal("fourth request across agents should be denied by the global limit")
+	}
+	if !strings.Contains(r.Err.Error(), "gateway") {
+		t.Errorf("Err = %v, want it to name the gateway-wide limit", r.Err)
+	}
+
+	l.SetLimits(map[string]Limit{All: {TokensPerMinute: 1000}})
+	r, res := l.Reserve("agent4", 900)
+	if !r.Allowed || res == nil {
+		t.Fatal("900 tokens should fit the global token limit")
+	}
+	if r, _ := l.Reserve("agent5", 200); r.Allowed {
+		t.Error("another agent should be denied once the global tokens are used")
+	}
+	res.Settle(100)
+	if r, _ := l.Reserve("agent5", 200); !r.Allowed {
+		t.Error("request should fit after the global reservation was trued up")
+	}
+}
+
+// memShared is a Shared kept in memory; fail makes it unavailable.
+type memShared struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	fail   bool
+}
+
+func (m *memShared) IncrBy(keys []string, deltas []int64, _ []time.Duration) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail {
+		return nil, errors.New("connection refused")
+	}
+	if m.counts == nil {
+		m.counts = map[string]int64{}
+	}
+	out := make([]int64, len(keys))
+	for i, k := range keys {
+		m.counts[k] += deltas[i]
+		out[i] = m.counts[k]
+	}
+	return out, nil
+}
+
+func TestReserve_Shared(t *testing.T) {
+	store := &memShared{}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limits := map[string]Limit{"agent1": {RequestsPerMinute: 2, TokensPerMinute: 1000}}
+	a, b := New(limits), New(limits)
+	for _, l := range []*Limiter{a, b} {
+		l.now = func() time.Time { return now }
+		l.SetShared(store)
+	}
+
+	// Two instances count against one limit
+	if r := a.Allow("agent1"); !r.Allowed {
+		t.Fatal("first request should be allowed")
+	}
+	_, res := b.Reserve("agent1", 400)
+	if res == nil {
+		t.Fatal("second request should be allowed with a reservation")
+	}
+	if r := a.Allow("agent1"); r.Allowed {
+		t.Error("third request across instances should be denied")
+	}
+
+	// Settling moves the shared token count to the reported usage
+	res.Settle(900)
+	now = now.Add(2 * time.Minute)
+	if r, _ := a.Reserve("agent1", 200); !r.Allowed {
+		t.Error("request should be allowed once the buckets rolled over")
+	}
+	if r, _ := b.Reserve("agent1", 900); r.Allowed {
+		t.Error("900 more tokens should be denied with 200 already used")
+	}
+
+	// Without the store each instance counts on its own
+	store.fail = true
+	if r := a.Allow("agent1"); !r.Allowed {
+		t.Error("local fallback should allow the first local request")
+	}
+	a.Allow("agent1")
+	if r := a.Allow("agent1"); r.Allowed {
+		t.Error("local fallback should still enforce the limit")
+	}
+}
@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProviderLimit defines the outgoing request and token ceilings for one
+// upstream provider.
+type ProviderLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+	Burst             time.Duration // how much unused capacity may accumulate; default 1s
+	MaxWait           time.Duration // longest a request is held before being rejected; default 30s
+}
+
+// PaceError is returned when a request would have to wait longer than the
+// provider's MaxWait.
+type PaceError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *PaceError) Error() string {
+	return fmt.Sprintf("provider %s rate ceiling reached, retry after %s", e.Provider, e.RetryAfter.Round(time.Second))
+}
+
+// Pacer smooths outgoing traffic per provider with token buckets, delaying
+// requests instead of letting bursts run into provider 429s. Unlike Limiter
+// it is keyed by provider, not agent.
+type Pacer struct {
+	mu      sync.Mutex
+	limits  map[string]ProviderLimit
+	buckets map[string]*providerBuckets
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+type providerBuckets struct {
+	requests *bucket
+	tokens   *bucket
+}
+
+// bucket is a token bucket that allows debt: a reservation always succeeds
+// and the caller waits until the balance is back to zero.
+type bucket struct {
+	rate     float64 // units per second
+	capacity float64
+	balance  float64
+	last     time.Time
+}
+
+// NewPacer creates a Pacer from per-provider limits.
+// Returns nil if limits is empty.
+func NewPacer(limits map[string]ProviderLimit) *Pacer {
+	if len(limits) == 0 {
+		return nil
+	}
+	p := &Pacer{
+		limits:  make(map[string]ProviderLimit, len(limits)),
+		buckets: make(map[string]*providerBuckets, len(limits)),
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+	for name, l := range limits {
+		if l.Burst <= 0 {
+			l.Burst = time.Second
+		}
+		if l.MaxWait <= 0 {
+			l.MaxWait = 30 * time.Second
+		}
+		p.limits[name] = l
+	}
+	return p
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Wait blocks until provider has capacity for one request of the given
+// estimated tokens. It returns a *PaceError without waiting if the delay
+// would exceed MaxWait, or the context error if ctx ends first.
+func (p *Pacer) Wait(ctx context.Context, provider string, tokens int) error {
+	if p == nil {
+		return nil
+	}
+	limit, ok := p.limits[provider]
+	if !ok {
+		return nil
+	}
+
+	p.mu.Lock()
+	now := p.now()
+	b := p.bucketsFor(provider, limit, now)
+	var wait time.Duration
+	if b.requests != nil {
+		wait = max(wait, b.requests.reserve(now, 1))
+	}
+	if b.tokens != nil {
+		wait = max(wait, b.tokens.reserve(now, float64(tokens)))
+	}
+	if wait > limit.MaxWait {
+		// Give the capacity back: the request is not sent
+		if b.requests != nil {
+			b.requests.balance += 1
+		}
+		if b.tokens != nil {
+			b.tokens.balance += float64(tokens)
+		}
+		p.mu.Unlock()
+		return &PaceError{Provider: provider, RetryAfter: wait}
+	}
+	p.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	return p.sleep(ctx, wait)
+}
+
+func (p *Pacer) bucketsFor(provider string, limit ProviderLimit, now time.Time) *providerBuckets {
+	b, ok := p.buckets[provider]
+	if ok {
+		return b
+	}
+	b = &providerBuckets{
+		requests: newBucket(limit.RequestsPerMinute, limit.Burst, now),
+		tokens:   newBucket(limit.TokensPerMinute, limit.Burst, now),
+	}
+	p.buckets[provider] = b
+	return b
+}
+
+// newBucket returns a full bucket refilled at perMinute, holding at most
+// burst worth of capacity (never less than one unit). Returns nil if
+// perMinute is 0.
+func newBucket(perMinute int, burst time.Duration, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	rate := float64(perMinute) / 60
+	capacity := max(1, rate*burst.Seconds())
+	return &bucket{rate: rate, capacity: capacity, balance: capacity, last: now}
+}
+
+// reserve takes n units and returns how long until the balance is
+// non-negative again.
+func (b *bucket) reserve(now time.Time, n float64) time.Duration {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.balance = min(b.capacity, b.balance+elapsed*b.rate)
+		b.last = now
+	}
+	b.balance -= n
+	if b.balance >= 0 {
+		return 0
+	}
+	return time.Duration(-b.balance / b.rate * float64(time.Second))
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock drives a Pacer without real sleeps.
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func newTestPacer(limits map[string]ProviderLimit) (*Pacer, *fakeClock) {
+	p := NewPacer(limits)
+	c := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	p.now = func() time.Time { return c.now }
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		c.slept = append(c.slept, d)
+		c.now = c.now.Add(d)
+		return nil
+	}
+	return p, c
+}
+
+func TestNewPacer_NilOnEmpty(t *testing.T) {
+	if p := NewPacer(nil); p != nil {
+		t.Error("expected nil pacer for nil limits")
+	}
+	var p *Pacer
+	if err := p.Wait(context.Background(), "openai", 100); err != nil {
+		t.Errorf("nil pacer Wait() = %v, want nil", err)
+	}
+}
+
+func TestPacer_UnconfiguredProvider(t *testing.T) {
+	p, c := newTestPacer(map[string]ProviderLimit{"openai": {RequestsPerMinute: 1}})
+	for i := 0; i < 5; i++ {
+		if err := p.Wait(context.Background(), "anthropic", 1000); err != nil {
+			t.Fatalf("Wait() error: %v", err)
+		}
+	}
+	if len(c.slept) != 0 {
+		t.Errorf("slept %v for unconfigured provider", c.slept)
+	}
+}
+
+func TestPacer_SpacesRequests(t *testing.T) {
+	// 120 rpm = one request every 500ms; 1s burst allows two back to back
+	p, c := newTestPacer(map[string]ProviderLimit{"openai": {RequestsPerMinute: 120}})
+
+	for i := 0; i < 5; i++ {
+		if err := p.Wait(context.Background(), "openai", 0); err != nil {
+			t.Fatalf("Wait() error: %v", err)
+		}
+	}
+	want := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}
+	if len(c.slept) != len(want) {
+		t.Fatalf("slept %v, want %v", c.slept, want)
+	}
+	for i := range want {
+		if c.slept[i] != want[i] {
+			t.Errorf("slept[%d] = %v, want %v", i, c.slept[i], want[i])
+		}
+	}
+}
+
+func TestPacer_Tokens(t *testing.T) {
+	// 60k tpm = 1000 tokens/s; burst 1s holds 1000 tokens
+	p, c := newTestPacer(map[string]ProviderLimit{"anthropic": {TokensPerMinute: 60000}})
+
+	if err := p.Wait(context.Background(), "anthropic", 1000); err != nil {
+		t.Fatalf("Wait() error: %v", err)
+	}
+	if err := p.Wait(context.Background(), "anthropic", 3000); err != nil {
+		t.Fatalf("Wait() error: %v", err)
+	}
+	if len(c.slept) != 1 || c.slept[0] != 3*time.Second {
+		t.Errorf("slept %v, want [3s]", c.slept)
+	}
+}
+
+func TestPacer_MaxWait(t *testing.T) {
+	p, c := newTestPacer(map[string]ProviderLimit{
+		"openai": {RequestsPerMinute: 6, MaxWait: 15 * time.Second},
+	})
+
+	// First is free, second waits 10s, third would wait 20s
+	for i := 0; i < 2; i++ {
+		if err := p.Wait(context.Background(), "openai", 0); err != nil {
+			t.Fatalf("Wait() %d error: %v", i, err)
+		}
+	}
+	c.now = c.now.Add(-10 * time.Second) // as if both were admitted at once
+	err := p.Wait(context.Background(), "openai", 0)
+	var pe *PaceError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Wait() = %v, want PaceError", err)
+	}
+	if pe.Provider != "openai" || pe.RetryAfter != 20*time.Second {
+		t.Errorf("PaceError = %+v, want openai retry after 20s", pe)
+	}
+
+	// The rejected request returned its capacity
+	c.now = c.now.Add(5 * time.Second)
+	if err := p.Wait(context.Background(), "openai", 0); err != nil {
+		t.Errorf("Wait() after rejection = %v, want nil", err)
+	}
+}
+
+func TestPacer_ContextCancel(t *testing.T) {
+	p := NewPacer(map[string]ProviderLimit{"openai": {RequestsPerMinute: 1, MaxWait: time.Hour}})
+	p.Wait(context.Background(), "openai", 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Wait(ctx, "openai", 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() = %v, want context.Canceled", err)
+	}
+}
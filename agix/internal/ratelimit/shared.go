@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Shared is a counter store shared by gateway instances, such as Redis,
+// so that limits hold across all of them rather than per process.
+type Shared interface {
+	// IncrBy adds deltas[i] to keys[i], which expires after ttls[i], and
+	// returns the new values.
+	IncrBy(keys []string, deltas []int64, ttls []time.Duration) ([]int64, error)
+}
+
+// SetShared makes the limiter count in s. While s fails, the limiter
+// falls back to its own windows.
+func (l *Limiter) SetShared(s Shared) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.shared = s
+}
+
+// sharedCounter is one limit counted in fixed buckets of width. A
+// window's count is the current bucket plus the previous one weighted by
+// how much of it still overlaps the window.
+type sharedCounter struct {
+	key    string
+	width  time.Duration
+	limit  int
+	cost   int64
+	what   string // "requests per minute", ...
+	tokens bool
+	prefix string
+}
+
+func sharedCounters(scope, prefix string, limit Limit, tokens int) []sharedCounter {
+	var cs []sharedCounter
+	for _, c := range []sharedCounter{
+		{key: "rpm", width: time.Minute, limit: limit.RequestsPerMinute, cost: 1, what: "requests per minute"},
+		{key: "rph", width: time.Hour, limit: limit.RequestsPerHour, cost: 1, what: "requests per hour"},
+		{key: "tpm", width: time.Minute, limit: limit.TokensPerMinute, cost: int64(tokens), what: "tokens per minute", tokens: true},
+		{key: "tpd", width: 24 * time.Hour, limit: limit.TokensPerDay, cost: int64(tokens), what: "tokens per day", tokens: true},
+	} {
+		if c.limit > 0 {
+			c.key = "rl:" + scope + ":" + c.key
+			c.prefix = prefix
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+func (c sharedCounter) bucketKey(t time.Time, offset int64) string {
+	return fmt.Sprintf("%s:%d", c.key, t.UnixNano()/int64(c.width)+offset)
+}
+
+func (l *Limiter) reserveShared(s Shared, now time.Time, agent string, tokens int, global, limit Limit) (Result, *Reservation, error) {
+	counters := sharedCounters(All, "gateway rate limit exceeded", global, tokens)
+	counters = append(counters, sharedCounters(agent, "rate limit exceeded", limit, tokens)...)
+	if len(counters) == 0 {
+		return Result{Allowed: true}, nil, nil
+	}
+
+	// Add to the current buckets and read the previous ones in one trip.
+	keys := make([]string, 0, 2*len(counters))
+	deltas := make([]int64, 0, 2*len(counters))
+	ttls := make([]time.Duration, 0, 2*len(counters))
+	for _, c := range counters {
+		keys = append(keys, c.bucketKey(now, 0), c.bucketKey(now, -1))
+		deltas = append(deltas, c.cost, 0)
+		ttls = append(ttls, 2*c.width, 2*c.width)
+	}
+	values, err := s.IncrBy(keys, deltas, ttls)
+	if err != nil {
+		return Result{}, nil, err
+	}
+
+	for i, c := range counters {
+		cur, prev := values[2*i]-c.cost, values[2*i+1]
+		elapsed := float64(now.UnixNano()%int64(c.width)) / float64(c.width)
+		used := float64(cur) + float64(prev)*(1-elapsed)
+		if used < float64(c.limit) && used+float64(c.cost) <= float64(c.limit) {
+			continue
+		}
+
+		// Take this request back out of every counter.
+		var curKeys []string
+		var undo []int64
+		var curTTLs []time.Duration
+		for j, d := range counters {
+			if d.cost != 0 {
+				curKeys, undo, curTTLs = append(curKeys, keys[2*j]), append(undo, -d.cost), append(curTTLs, ttls[2*j])
+			}
+		}
+		if len(curKeys) > 0 {
+			if _, err := s.IncrBy(curKeys, undo, curTTLs); err != nil {
+				log.Printf("WARN: shared rate limits: %v", err)
+			}
+		}
+
+		var retryAfter time.Duration
+		var err error
+		switch {
+		case c.cost > int64(c.limit):
+			retryAfter = c.width
+			err = fmt.Errorf("%s: request needs about %d tokens, more than the limit of %d %s", c.prefix, c.cost, c.limit, c.what)
+		default:
+			// Room appears as the previous bucket's weight decays, or
+			// at the latest when the current bucket becomes previous.
+			retryAfter = time.Duration((1 - elapsed) * float64(c.width))
+			if room := float64(int64(c.limit) - cur - c.cost); room >= 0 && prev > 0 {
+				retryAfter = time.Duration((1 - room/float64(prev) - elapsed) * float64(c.width))
+			}
+			err = fmt.Errorf("%s: %d %s (limit %d)", c.prefix, int64(used), c.what, c.limit)
+		}
+		if retryAfter < time.Second {
+			retryAfter = time.Second
+		}
+		return Result{Allowed: false, RetryAfter: retryAfter, Err: err}, nil, nil
+	}
+
+	var res *Reservation
+	for i, c := range counters {
+		if !c.tokens {
+			continue
+		}
+		if res == nil {
+			res = &Reservation{l: l, at: now, estimate: tokens, shared: s}
+		}
+		res.sharedKeys = append(res.sharedKeys, keys[2*i])
+		res.sharedTTLs = append(res.sharedTTLs, ttls[2*i])
+	}
+	return Result{Allowed: true}, res, nil
+}
+
+// warnShared logs a failing shared store at most once a minute.
+func (l *Limiter) warnShared(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now := l.now(); now.Sub(l.sharedWarned) >= time.Minute {
+		l.sharedWarned = now
+		log.Printf("WARN: shared rate limits unavailable, counting locally: %v", err)
+	}
+}
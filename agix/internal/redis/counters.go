@@ -0,0 +1,165 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// IncrBy adds deltas[i] to the integer at keys[i] and sets it to expire
+// after ttls[i], all in one round trip, and returns the new values. It
+// implements ratelimit.Shared.
+func (c *Client) IncrBy(keys []string, deltas []int64, ttls []time.Duration) ([]int64, error) {
+	cmds := make([][]string, 0, 2*len(keys))
+	for i, k := range keys {
+		k = c.prefix + k
+		cmds = append(cmds,
+			[]string{"INCRBY", k, strconv.FormatInt(deltas[i], 10)},
+			[]string{"PEXPIRE", k, strconv.FormatInt(ttls[i].Milliseconds(), 10)})
+	}
+	replies, err := c.Pipeline(cmds)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]int64, len(keys))
+	for i := range keys {
+		switch r := replies[2*i].(type) {
+		case int64:
+			values[i] = r
+		case Error:
+			return nil, r
+		default:
+			return nil, fmt.Errorf("redis: INCRBY returned %T", r)
+		}
+	}
+	return values, nil
+}
+
+// Spend accumulates spend per UTC day and month for budget scopes, such
+// as an agent, so every gateway instance sees costs the others recorded
+// without waiting for the database. A period starts out untracked; the
+// first reader seeds it from the database.
+type Spend struct {
+	c *Client
+}
+
+// NewSpend returns spend accumulators stored through c.
+func NewSpend(c *Client) *Spend {
+	return &Spend{c: c}
+}
+
+func dayKey(scope string, t time.Time) string {
+	return "spend:" + scope + ":d:" + t.UTC().Format("2006-01-02")
+}
+
+func monthKey(scope string, year int, month time.Month) string {
+	return fmt.Sprintf("spend:%s:m:%04d-%02d", scope, year, month)
+}
+
+// Periods outlive themselves a little so late readers near midnight
+// still find them.
+const (
+	dayTTL   = 48 * time.Hour
+	monthTTL = 33 * 24 * time.Hour
+)
+
+// Daily returns scope's spend on day's UTC date and whether it is
+// tracked.
+func (s *Spend) Daily(scope string, day time.Time) (float64, bool, error) {
+	return s.get(dayKey(scope, day))
+}
+
+// Monthly returns scope's spend in the month and whether it is tracked.
+func (s *Spend) Monthly(scope string, year int, month time.Month) (float64, bool, error) {
+	return s.get(monthKey(scope, year, month))
+}
+
+// SeedDaily starts tracking scope's spend on day at usd, unless another
+// instance already did, and returns the tracked value.
+func (s *Spend) SeedDaily(scope string, day time.Time, usd float64) (float64, error) {
+	return s.seed(dayKey(scope, day), usd, dayTTL)
+}
+
+// SeedMonthly is SeedDaily for a month.
+func (s *Spend) SeedMonthly(scope string, year int, month time.Month, usd float64) (float64, error) {
+	return s.seed(monthKey(scope, year, month), usd, monthTTL)
+}
+
+// Add adds usd spent at t to the tracked day and month of each scope.
+// Untracked periods are left alone: their seed, read from the database,
+// will include the cost.
+func (s *Spend) Add(t time.Time, usd float64, scopes ...string) error {
+	t = t.UTC()
+	var keys []string
+	var exists [][]string
+	for _, scope := range scopes {
+		for _, k := range []string{dayKey(scope, t), monthKey(scope, t.Year(), t.Month())} {
+			keys = append(keys, s.c.prefix+k)
+			exists = append(exists, []string{"EXISTS", s.c.prefix + k})
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	// EXISTS then INCRBYFLOAT: a period seeded between the two misses
+	// this cost until it expires. Rare, and only ever an undercount.
+	replies, err := s.c.Pipeline(exists)
+	if err != nil {
+		return err
+	}
+	amount := strconv.FormatFloat(usd, 'f', -1, 64)
+	var incr [][]string
+	for i, k := range keys {
+		if n, _ := replies[i].(int64); n == 1 {
+			incr = append(incr, []string{"INCRBYFLOAT", k, amount})
+		}
+	}
+	if len(incr) == 0 {
+		return nil
+	}
+	replies, err = s.c.Pipeline(incr)
+	if err != nil {
+		return err
+	}
+	for _, r := range replies {
+		if e, ok := r.(Error); ok {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *Spend) get(key string) (float64, bool, error) {
+	r, err := s.c.Do("GET", s.c.prefix+key)
+	if err != nil || r == nil {
+		return 0, false, err
+	}
+	str, _ := r.(string)
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("redis: %s holds %q", key, str)
+	}
+	return v, true, nil
+}
+
+func (s *Spend) seed(key string, usd float64, ttl time.Duration) (float64, error) {
+	k := s.c.prefix + key
+	replies, err := s.c.Pipeline([][]string{
+		{"SET", k, strconv.FormatFloat(usd, 'f', -1, 64), "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10)},
+		{"GET", k},
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range replies {
+		if e, ok := r.(Error); ok {
+			return 0, e
+		}
+	}
+	str, _ := replies[1].(string)
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return usd, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,216 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer speaks enough RESP for the commands this package sends.
+type fakeServer struct {
+	ln       net.Listener
+	password string
+
+	mu   sync.Mutex
+	data map[string]string
+	cmds []string
+}
+
+func newFakeServer(t *testing.T, password string) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln, password: password, data: map[string]string{}}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeServer) url() string {
+	if s.password != "" {
+		return "redis://:" + s.password + "@" + s.ln.Addr().String() + "/2"
+	}
+	return "redis://" + s.ln.Addr().String()
+}
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	authed := s.password == ""
+	for {
+		v, err := readReply(r)
+		if err != nil {
+			return
+		}
+		items, _ := v.([]any)
+		args := make([]string, len(items))
+		for i, it := range items {
+			args[i], _ = it.(string)
+		}
+		if len(args) == 0 {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		if cmd == "AUTH" {
+			authed = args[len(args)-1] == s.password
+			if !authed {
+				io.WriteString(c, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+		} else if !authed {
+			io.WriteString(c, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		io.WriteString(c, s.exec(cmd, args[1:]))
+	}
+}
+
+func (s *fakeServer) exec(cmd string, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cmds = append(s.cmds, cmd)
+	bulk := func(v string, ok bool) string {
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	}
+	switch cmd {
+	case "PING", "AUTH", "SELECT", "PEXPIRE":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.data[args[0]]
+		return bulk(v, ok)
+	case "EXISTS":
+		_, ok := s.data[args[0]]
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "SET":
+		if _, ok := s.data[args[0]]; ok && len(args) > 2 && args[2] == "NX" {
+			return "$-1\r\n"
+		}
+		s.data[args[0]] = args[1]
+		return "+OK\r\n"
+	case "INCRBY":
+		n, _ := strconv.ParseInt(s.data[args[0]], 10, 64)
+		d, _ := strconv.ParseInt(args[1], 10, 64)
+		s.data[args[0]] = strconv.FormatInt(n+d, 10)
+		return fmt.Sprintf(":%d\r\n", n+d)
+	case "INCRBYFLOAT":
+		n, _ := strconv.ParseFloat(s.data[args[0]], 64)
+		d, _ := strconv.ParseFloat(args[1], 64)
+		v := strconv.FormatFloat(n+d, 'f', -1, 64)
+		s.data[args[0]] = v
+		return bulk(v, true)
+	}
+	return "-ERR unknown command '" + cmd + "'\r\n"
+}
+
+func TestNew(t *testing.T) {
+	c, err := New("rediss://u:p@cache.internal/3", "agix:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.addr != "cache.internal:6379" || c.user != "u" || c.password != "p" || c.db != 3 || c.tls == nil {
+		t.Errorf("client = %+v", c)
+	}
+	for _, bad := range []string{"http://localhost", "redis://", "redis://localhost/x"} {
+		if _, err := New(bad, ""); err == nil {
+			t.Errorf("New(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	s := newFakeServer(t, "secret")
+	c, err := New(s.url(), "agix:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if _, err := c.Do("NOPE"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("Do(NOPE) err = %v, want the error reply", err)
+	}
+
+	got, err := c.IncrBy([]string{"a", "b"}, []int64{5, 0}, []time.Duration{time.Minute, time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != 5 || got[1] != 0 {
+		t.Errorf("IncrBy = %v, want [5 0]", got)
+	}
+	got, _ = c.IncrBy([]string{"a"}, []int64{-2}, []time.Duration{time.Minute})
+	if got[0] != 3 {
+		t.Errorf("IncrBy = %v, want [3]", got)
+	}
+	s.mu.Lock()
+	if _, ok := s.data["agix:a"]; !ok {
+		t.Error("keys should carry the prefix")
+	}
+	if got := strings.Join(s.cmds[:3], ","); got != "AUTH,SELECT,PING" {
+		t.Errorf("commands = %s, want AUTH and SELECT on connect", got)
+	}
+	s.mu.Unlock()
+
+	bad, _ := New("redis://:wrong@"+s.ln.Addr().String(), "")
+	if err := bad.Ping(); err == nil {
+		t.Error("Ping with a wrong password succeeded")
+	}
+}
+
+func TestSpend(t *testing.T) {
+	s := newFakeServer(t, "")
+	c, _ := New(s.url(), "agix:")
+	defer c.Close()
+	sp := NewSpend(c)
+	day := time.Date(2026, 3, 14, 23, 0, 0, 0, time.UTC)
+
+	// Untracked periods ignore costs; the seed from the database has them
+	if err := sp.Add(day, 1.5, "bot"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := sp.Daily("bot", day); ok || err != nil {
+		t.Errorf("Daily = tracked %v, %v; want untracked", ok, err)
+	}
+
+	if v, _ := sp.SeedDaily("bot", day, 2); v != 2 {
+		t.Errorf("SeedDaily = %v, want 2", v)
+	}
+	if v, _ := sp.SeedDaily("bot", day, 7); v != 2 {
+		t.Errorf("second SeedDaily = %v, want the first seed 2", v)
+	}
+	sp.SeedMonthly("bot", 2026, time.March, 10)
+	if err := sp.Add(day, 0.25, "bot", "global"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := sp.Daily("bot", day); !ok || v != 2.25 {
+		t.Errorf("Daily = %v, %v; want 2.25", v, ok)
+	}
+	if v, ok, _ := sp.Monthly("bot", 2026, time.March); !ok || v != 10.25 {
+		t.Errorf("Monthly = %v, %v; want 10.25", v, ok)
+	}
+	if _, ok, _ := sp.Daily("bot", day.Add(2*time.Hour)); ok {
+		t.Error("the next day should start untracked")
+	}
+}
@@ -0,0 +1,118 @@
+package responsepolicy
+
+import (
+	"regexp"
+	"unicode"
+)
+
+// Languages are recognized by writing system, which is cheap and needs no
+// model, but cannot tell apart languages sharing a script: "fr" accepts any
+// Latin-script answer, English included.
+
+// script is a writing system. Alphabetic scripts are counted in words, the
+// others (CJK, Thai) in characters, so a Chinese answer quoting an English
+// product name still reads as Chinese.
+type script struct {
+	name    string
+	table   []*unicode.RangeTable
+	perRune bool
+}
+
+var scripts = []script{
+	{"latin", []*unicode.RangeTable{unicode.Latin}, false},
+	{"cyrillic", []*unicode.RangeTable{unicode.Cyrillic}, false},
+	{"greek", []*unicode.RangeTable{unicode.Greek}, false},
+	{"arabic", []*unicode.RangeTable{unicode.Arabic}, false},
+	{"hebrew", []*unicode.RangeTable{unicode.Hebrew}, false},
+	{"devanagari", []*unicode.RangeTable{unicode.Devanagari}, false},
+	{"thai", []*unicode.RangeTable{unicode.Thai}, true},
+	{"han", []*unicode.RangeTable{unicode.Han}, true},
+	{"kana", []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana}, true},
+	{"hangul", []*unicode.RangeTable{unicode.Hangul}, true},
+}
+
+// language lists the scripts a language is written in.
+type language struct {
+	name    string
+	scripts []string
+	// required must appear: Japanese is told from Chinese by its kana.
+	required string
+}
+
+var languages = map[string]language{
+	"en": {"English", []string{"latin"}, ""},
+	"fr": {"French", []string{"latin"}, ""},
+	"de": {"German", []string{"latin"}, ""},
+	"es": {"Spanish", []string{"latin"}, ""},
+	"pt": {"Portuguese", []string{"latin"}, ""},
+	"it": {"Italian", []string{"latin"}, ""},
+	"nl": {"Dutch", []string{"latin"}, ""},
+	"pl": {"Polish", []string{"latin"}, ""},
+	"tr": {"Turkish", []string{"latin"}, ""},
+	"vi": {"Vietnamese", []string{"latin"}, ""},
+	"id": {"Indonesian", []string{"latin"}, ""},
+	"ru": {"Russian", []string{"cyrillic"}, ""},
+	"uk": {"Ukrainian", []string{"cyrillic"}, ""},
+	"el": {"Greek", []string{"greek"}, ""},
+	"ar": {"Arabic", []string{"arabic"}, ""},
+	"fa": {"Persian", []string{"arabic"}, ""},
+	"he": {"Hebrew", []string{"hebrew"}, ""},
+	"hi": {"Hindi", []string{"devanagari"}, ""},
+	"th": {"Thai", []string{"thai"}, ""},
+	"zh": {"Chinese", []string{"han"}, ""},
+	"ja": {"Japanese", []string{"kana", "han"}, "kana"},
+	"ko": {"Korean", []string{"hangul", "han"}, ""},
+}
+
+// minLanguageUnits is the shortest text, in words or CJK characters, whose
+// language is checked; "OK" or a bare number passes in any language.
+const minLanguageUnits = 3
+
+// codeRe matches fenced code blocks, inline code and URLs, which are in
+// English whatever the answer's language.
+var codeRe = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`|https?://\\S+")
+
+// inLanguage reports whether most of text is written in the scripts of
+// lang, a key of languages.
+func inLanguage(text, lang string) bool {
+	l := languages[lang]
+	units, total := scriptUnits(codeRe.ReplaceAllString(text, " "))
+	if total < minLanguageUnits {
+		return true
+	}
+	if l.required != "" && units[l.required] == 0 {
+		return false
+	}
+	n := 0
+	for _, s := range l.scripts {
+		n += units[s]
+	}
+	return 2*n >= total
+}
+
+// scriptUnits counts text per script: words of alphabetic scripts and
+// characters of the others. Letters of other scripts are ignored.
+func scriptUnits(text string) (map[string]int, int) {
+	units := make(map[string]int)
+	total := 0
+	prev := "" // script of the word being read
+	for _, r := range text {
+		cur := ""
+		if unicode.IsLetter(r) {
+			for _, s := range scripts {
+				if unicode.In(r, s.table...) {
+					cur = s.name
+					if s.perRune || cur != prev {
+						units[cur]++
+						total++
+					}
+					break
+				}
+			}
+		}
+		if !unicode.IsMark(r) {
+			prev = cur
+		}
+	}
+	return units, total
+}
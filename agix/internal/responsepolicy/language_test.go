@@ -0,0 +1,34 @@
+package responsepolicy
+
+import "testing"
+
+func TestInLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		lang string
+		want bool
+	}{
+		{"chinese", "您好，您的订单已经发货，预计三天内送达。", "zh", true},
+		{"english for chinese agent", "Hello, your order has shipped and should arrive in three days.", "zh", false},
+		{"chinese with product names", "请在 Settings 页面打开 Dark Mode，然后重新启动 Chrome 浏览器即可。", "zh", true},
+		{"chinese with code", "运行以下命令：\n```\ngo build ./... && go test ./... -run TestSomethingLong\n```\n然后查看 `internal/proxy/proxy.go` 的输出结果。", "zh", true},
+		{"japanese", "ご注文の商品は発送されました。", "ja", true},
+		{"chinese is not japanese", "您的订单已经发货。", "ja", false},
+		{"japanese is not chinese", "ご注文の商品はすでに発送されましたので、もう少々お待ちください。", "zh", false},
+		{"korean", "주문하신 상품이 발송되었습니다.", "ko", true},
+		{"russian", "Ваш заказ отправлен и прибудет через три дня.", "ru", true},
+		{"english for russian agent", "Your order has shipped.", "ru", false},
+		{"hindi", "आपका ऑर्डर भेज दिया गया है।", "hi", true},
+		{"short answers pass", "OK 42", "zh", true},
+		{"french shares latin", "Votre commande a été expédiée.", "fr", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inLanguage(tt.text, tt.lang); got != tt.want {
+				units, total := scriptUnits(tt.text)
+				t.Errorf("inLanguage(%q, %s) = %v, want %v (units %v of %d)", tt.text, tt.lang, got, tt.want, units, total)
+			}
+		})
+	}
+}
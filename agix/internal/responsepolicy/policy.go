@@ -14,6 +14,10 @@ type Config struct {
 	RedactPatterns []RedactRuleConfig     `yaml:"redact_patterns"`
 	MaxOutputChars int                    `yaml:"max_output_chars"`
 	ForceFormat    string                 `yaml:"force_format"`
+	Language       string                 `yaml:"language"`       // e.g. "zh"; responses in another script are violations
+	BannedPhrases  []string               `yaml:"banned_phrases"` // case-insensitive
+	OnViolation    string                 `yaml:"on_violation"`   // retry (default), reject or warn
+	MaxRetries     int                    `yaml:"max_retries"`    // for on_violation: retry; default 1
 	Agents         map[string]AgentPolicy `yaml:"agents"`
 }
 
@@ -29,6 +33,24 @@ type AgentPolicy struct {
 	RedactPatterns []RedactRuleConfig `yaml:"redact_patterns"`
 	MaxOutputChars int                `yaml:"max_output_chars"`
 	ForceFormat    string             `yaml:"force_format"`
+	Language       string             `yaml:"language"`
+	BannedPhrases  []string           `yaml:"banned_phrases"` // added to the global list
+	OnViolation    string             `yaml:"on_violation"`
+}
+
+// Actions taken when a response breaks the language or banned phrase rules.
+const (
+	ActionRetry  = "retry"
+	ActionReject = "reject"
+	ActionWarn   = "warn"
+)
+
+// Violation describes a response that breaks the language or banned phrase
+// rules.
+type Violation struct {
+	Rule    string // "language" or "banned_phrase"
+	Message string
+	Action  string
 }
 
 // redactRule is a compiled redaction rule.
@@ -43,6 +65,10 @@ type Policy struct {
 	rules          []redactRule
 	maxOutputChars int
 	forceFormat    string
+	language       string
+	banned         []string
+	onViolation    string
+	maxRetries     int
 	agents         map[string]*agentPolicy
 }
 
@@ -50,6 +76,9 @@ type agentPolicy struct {
 	rules          []redactRule
 	maxOutputChars int
 	forceFormat    string
+	language       string
+	banned         []string
+	onViolation    string
 }
 
 // New creates a new Policy from config. Returns nil if disabled.
@@ -69,21 +98,54 @@ func New(cfg Config) (*Policy, error) {
 		if err != nil {
 			return nil, fmt.Errorf("compile redact patterns for agent %q: %w", name, err)
 		}
+		if err := validateEnforcement(ap.Language, ap.OnViolation); err != nil {
+			return nil, fmt.Errorf("agent %q: %w", name, err)
+		}
 		agents[name] = &agentPolicy{
 			rules:          agentRules,
 			maxOutputChars: ap.MaxOutputChars,
 			forceFormat:    ap.ForceFormat,
+			language:       ap.Language,
+			banned:         ap.BannedPhrases,
+			onViolation:    ap.OnViolation,
 		}
 	}
 
+	if err := validateEnforcement(cfg.Language, cfg.OnViolation); err != nil {
+		return nil, err
+	}
+	onViolation := cfg.OnViolation
+	if onViolation == "" {
+		onViolation = ActionRetry
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 1
+	}
+
 	return &Policy{
 		rules:          rules,
 		maxOutputChars: cfg.MaxOutputChars,
 		forceFormat:    cfg.ForceFormat,
+		language:       cfg.Language,
+		banned:         cfg.BannedPhrases,
+		onViolation:    onViolation,
+		maxRetries:     maxRetries,
 		agents:         agents,
 	}, nil
 }
 
+func validateEnforcement(lang, onViolation string) error {
+	if _, ok := languages[lang]; lang != "" && !ok {
+		return fmt.Errorf("unknown language %q", lang)
+	}
+	switch onViolation {
+	case "", ActionRetry, ActionReject, ActionWarn:
+		return nil
+	}
+	return fmt.Errorf("on_violation must be retry, reject or warn, got %q", onViolation)
+}
+
 func compileRules(configs []RedactRuleConfig) ([]redactRule, error) {
 	var rules []redactRule
 	for _, rc := range configs {
@@ -163,6 +225,103 @@ func (p *Policy) Apply(respBody []byte, agentName string) ([]byte, []string) {
 	return result, applied
 }
 
+// MaxRetries returns how often a violating response is retried.
+func (p *Policy) MaxRetries() int {
+	return p.maxRetries
+}
+
+// enforcement returns the language, banned phrases and violation action in
+// effect for an agent.
+func (p *Policy) enforcement(agentName string) (lang string, banned []string, action string) {
+	lang, banned, action = p.language, p.banned, p.onViolation
+	if ap, ok := p.agents[agentName]; ok && agentName != "" {
+		if ap.language != "" {
+			lang = ap.language
+		}
+		banned = append(append([]string(nil), banned...), ap.banned...)
+		if ap.onViolation != "" {
+			action = ap.onViolation
+		}
+	}
+	return lang, banned, action
+}
+
+// Check reports whether a response breaks the language or banned phrase
+// rules for the agent. Responses without text content (tool calls) pass.
+func (p *Policy) Check(respBody []byte, agentName string) *Violation {
+	lang, banned, action := p.enforcement(agentName)
+	if lang == "" && len(banned) == 0 {
+		return nil
+	}
+	content := extractContent(respBody)
+	if content == "" {
+		return nil
+	}
+	lower := strings.ToLower(content)
+	for _, phrase := range banned {
+		if phrase != "" && strings.Contains(lower, strings.ToLower(phrase)) {
+			return &Violation{Rule: "banned_phrase", Message: fmt.Sprintf("response contains banned phrase %q", phrase), Action: action}
+		}
+	}
+	if lang != "" && !inLanguage(content, lang) {
+		return &Violation{Rule: "language", Message: "response is not in " + languages[lang].name, Action: action}
+	}
+	return nil
+}
+
+// RetryBody returns the chat request with an instruction restating the
+// agent's language and banned phrases, added to the system message, for
+// retrying a violating response.
+func (p *Policy) RetryBody(reqBody []byte, agentName string) []byte {
+	lang, banned, _ := p.enforcement(agentName)
+	var notes []string
+	if lang != "" {
+		notes = append(notes, fmt.Sprintf("Respond only in %s.", languages[lang].name))
+	}
+	if len(banned) > 0 {
+		quoted := make([]string, len(banned))
+		for i, phrase := range banned {
+			quoted[i] = fmt.Sprintf("%q", phrase)
+		}
+		notes = append(notes, "Never use these phrases: "+strings.Join(quoted, ", ")+".")
+	}
+	if len(notes) == 0 {
+		return reqBody
+	}
+	return addSystemNote(reqBody, strings.Join(notes, " "))
+}
+
+// addSystemNote appends note to the leading system message of a chat
+// request, inserting one if there is none.
+func addSystemNote(body []byte, note string) []byte {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body
+	}
+	var messages []map[string]json.RawMessage
+	if err := json.Unmarshal(raw["messages"], &messages); err != nil {
+		return body
+	}
+
+	var role, content string
+	if len(messages) > 0 {
+		json.Unmarshal(messages[0]["role"], &role)
+	}
+	if role == "system" && json.Unmarshal(messages[0]["content"], &content) == nil {
+		messages[0]["content"], _ = json.Marshal(content + "\n\n" + note)
+	} else {
+		noteJSON, _ := json.Marshal(note)
+		sys := map[string]json.RawMessage{"role": json.RawMessage(`"system"`), "content": noteJSON}
+		messages = append([]map[string]json.RawMessage{sys}, messages...)
+	}
+	raw["messages"], _ = json.Marshal(messages)
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
 // extractContent extracts the text content from an LLM response body.
 // Supports both OpenAI and Anthropic response formats.
 func extractContent(body []byte) string {
@@ -286,3 +286,92 @@ func TestReplaceContent_InvalidJSON(t *testing.T) {
 		t.Error("expected body unchanged for invalid JSON")
 	}
 }
+
+func TestNew_InvalidEnforcement(t *testing.T) {
+	tests := []Config{
+		{Enabled: true, Language: "xx"},
+		{Enabled: true, OnViolation: "drop"},
+		{Enabled: true, Agents: map[string]AgentPolicy{"a": {Language: "klingon"}}},
+	}
+	for _, cfg := range tests {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v): expected error", cfg)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p, err := New(Config{
+		Enabled:       true,
+		BannedPhrases: []string{"As an AI"},
+		Agents: map[string]AgentPolicy{
+			"support-zh": {Language: "zh", BannedPhrases: []string{"亲"}, OnViolation: ActionReject},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		agent    string
+		wantRule string
+		action   string
+	}{
+		{"clean", openaiResponse("Your order has shipped."), "other", "", ""},
+		{"global phrase", openaiResponse("as an ai language model, I cannot"), "other", "banned_phrase", ActionRetry},
+		{"wrong language", openaiResponse("Your order has shipped and will arrive soon."), "support-zh", "language", ActionReject},
+		{"agent phrase", anthropicResponse("亲，您的订单已经发货了。"), "support-zh", "banned_phrase", ActionReject},
+		{"right language", anthropicResponse("您的订单已经发货了。"), "support-zh", "", ""},
+		{"no text", []byte(`{"choices":[{"message":{"tool_calls":[{"id":"1"}]}}]}`), "support-zh", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := p.Check(tt.body, tt.agent)
+			if tt.wantRule == "" {
+				if v != nil {
+					t.Errorf("Check = %+v, want nil", v)
+				}
+				return
+			}
+			if v == nil || v.Rule != tt.wantRule || v.Action != tt.action {
+				t.Errorf("Check = %+v, want rule %s action %s", v, tt.wantRule, tt.action)
+			}
+		})
+	}
+	if p.MaxRetries() != 1 {
+		t.Errorf("MaxRetries = %d, want default 1", p.MaxRetries())
+	}
+}
+
+func TestRetryBody(t *testing.T) {
+	p, err := New(Config{Enabled: true, Language: "ja", BannedPhrases: []string{"Certainly!"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := `Respond only in Japanese. Never use these phrases: "Certainly!".`
+
+	withSystem := p.RetryBody([]byte(`{"model":"gpt-4o","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"hi"}]}`), "")
+	var req struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(withSystem, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Model != "gpt-4o" || len(req.Messages) != 2 || req.Messages[0].Content != "Be brief.\n\n"+note {
+		t.Errorf("RetryBody = %s", withSystem)
+	}
+
+	withoutSystem := p.RetryBody([]byte(`{"messages":[{"role":"user","content":"hi"}]}`), "")
+	if err := json.Unmarshal(withoutSystem, &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[0].Content != note {
+		t.Errorf("RetryBody = %s", withoutSystem)
+	}
+}
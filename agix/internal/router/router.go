@@ -17,12 +17,14 @@ type Config struct {
 	Enabled  bool                          `yaml:"enabled"`
 	Tiers    map[string]TierConfig         `yaml:"tiers"`
 	ModelMap map[string]map[string]string   `yaml:"model_map"`
+	ExcludeAgents []string                 `yaml:"exclude_agents"` // never routed
 }
 
 // Router selects cheaper models for simple requests.
 type Router struct {
 	tiers    map[string]TierConfig
 	modelMap map[string]map[string]string
+	excluded map[string]bool
 }
 
 // New creates a Router from config. Returns nil if not enabled or empty.
@@ -30,12 +32,22 @@ func New(cfg Config) *Router {
 	if !cfg.Enabled || len(cfg.Tiers) == 0 || len(cfg.ModelMap) == 0 {
 		return nil
 	}
+	excluded := make(map[string]bool, len(cfg.ExcludeAgents))
+	for _, a := range cfg.ExcludeAgents {
+		excluded[a] = true
+	}
 	return &Router{
 		tiers:    cfg.Tiers,
 		modelMap: cfg.ModelMap,
+		excluded: excluded,
 	}
 }
 
+// Excludes reports whether agentName is exempt from routing.
+func (r *Router) Excludes(agentName string) bool {
+	return r.excluded[agentName]
+}
+
 // Route returns the routed model for the given request.
 // Returns the original model if no routing applies.
 // Also returns the tier name matched (empty if none).
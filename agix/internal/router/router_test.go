@@ -146,3 +146,18 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+func TestExcludes(t *testing.T) {
+	r := New(Config{
+		Enabled:       true,
+		Tiers:         map[string]TierConfig{"simple": {MaxMessages: 3}},
+		ModelMap:      map[string]map[string]string{"gpt-4o": {"simple": "gpt-4o-mini"}},
+		ExcludeAgents: []string{"eval-runner"},
+	})
+	if !r.Excludes("eval-runner") {
+		t.Error("eval-runner should be excluded")
+	}
+	if r.Excludes("app") {
+		t.Error("app should not be excluded")
+	}
+}
@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Keychain reads generic passwords from the OS keychain: the login
+// keychain on macOS (security) and the Secret Service on Linux
+// (secret-tool, stored with attribute service=<item>).
+type Keychain struct{}
+
+// keychainCommand returns the command printing the password for item.
+// Tests replace it.
+var keychainCommand = func(ctx context.Context, item string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.CommandContext(ctx, "security", "find-generic-password", "-s", item, "-w"), nil
+	case "linux":
+		return exec.CommandContext(ctx, "secret-tool", "lookup", "service", item), nil
+	}
+	return nil, fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+}
+
+// Lookup returns the password stored for item.
+func (Keychain) Lookup(ctx context.Context, item string) (string, error) {
+	if item == "" {
+		return "", fmt.Errorf("want keychain:<item>")
+	}
+	cmd, err := keychainCommand(ctx, item)
+	if err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", cmd.Path, msg)
+		}
+		return "", fmt.Errorf("%s: %w", cmd.Path, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
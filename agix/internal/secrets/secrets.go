@@ -0,0 +1,151 @@
+// Package secrets resolves provider API keys kept outside config.yaml. A
+// key written as vault:kv/path#field is read from HashiCorp Vault and one
+// written as keychain:item from the OS keychain; any other value is used
+// as is.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+// Reference prefixes.
+const (
+	VaultPrefix    = "vault:"
+	KeychainPrefix = "keychain:"
+)
+
+// lookupTimeout bounds one backend lookup.
+const lookupTimeout = 10 * time.Second
+
+// Backend fetches the secret a reference (without its prefix) names.
+type Backend interface {
+	Lookup(ctx context.Context, ref string) (string, error)
+}
+
+// Resolver maps reference prefixes to backends.
+type Resolver struct {
+	backends map[string]Backend
+}
+
+// NewResolver returns a Resolver for Vault (configured from VAULT_ADDR,
+// VAULT_TOKEN and VAULT_NAMESPACE) and the OS keychain.
+func NewResolver() *Resolver {
+	return &Resolver{backends: map[string]Backend{
+		VaultPrefix:    NewVault(),
+		KeychainPrefix: Keychain{},
+	}}
+}
+
+// IsRef reports whether value names a secret rather than holding one.
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, VaultPrefix) || strings.HasPrefix(value, KeychainPrefix)
+}
+
+// Resolve returns the secret value names, or value itself if it is not a
+// reference.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	for prefix, b := range r.backends {
+		ref, ok := strings.CutPrefix(value, prefix)
+		if !ok {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+		defer cancel()
+		secret, err := b.Lookup(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", value, err)
+		}
+		if secret == "" {
+			return "", fmt.Errorf("%s: empty secret", value)
+		}
+		return secret, nil
+	}
+	return value, nil
+}
+
+// ResolveKeys replaces references in cfg.Keys, key_pools, custom provider
+// api_key fields, auth.jwt.secret, admin.token and redis.url with the
+// secrets they name. Each reference is fetched once. A provider with a key pool but no
+// keys.<provider> gets the pool's first key there, for the code that only
+// needs one key. cfg must not be saved afterwards, or the secrets would
+// be written to disk.
+func (r *Resolver) ResolveKeys(ctx context.Context, cfg *config.Config) error {
+	fetched := map[string]string{}
+	resolve := func(value string) (string, error) {
+		if !IsRef(value) {
+			return value, nil
+		}
+		if s, ok := fetched[value]; ok {
+			return s, nil
+		}
+		s, err := r.Resolve(ctx, value)
+		if err != nil {
+			return "", err
+		}
+		fetched[value] = s
+		return s, nil
+	}
+
+	keys := make(map[string]string, len(cfg.Keys))
+	for name, value := range cfg.Keys {
+		s, err := resolve(value)
+		if err != nil {
+			return fmt.Errorf("keys.%s: %w", name, err)
+		}
+		keys[name] = s
+	}
+	pools := make(map[string]config.KeyPoolConfig, len(cfg.KeyPools))
+	for name, kp := range cfg.KeyPools {
+		resolved := make([]config.PoolKey, len(kp.Keys))
+		for i, k := range kp.Keys {
+			s, err := resolve(k.Key)
+			if err != nil {
+				return fmt.Errorf("key_pools.%s.keys[%d]: %w", name, i, err)
+			}
+			k.Key = s
+			resolved[i] = k
+		}
+		kp.Keys = resolved
+		pools[name] = kp
+		if keys[name] == "" && len(resolved) > 0 {
+			keys[name] = resolved[0].Key
+		}
+	}
+	providers := make([]config.ProviderConfig, len(cfg.Providers))
+	for i, pc := range cfg.Providers {
+		s, err := resolve(pc.APIKey)
+		if err != nil {
+			return fmt.Errorf("providers.%s.api_key: %w", pc.Name, err)
+		}
+		pc.APIKey = s
+		providers[i] = pc
+	}
+	jwtSecret, err := resolve(cfg.Auth.JWT.Secret)
+	if err != nil {
+		return fmt.Errorf("auth.jwt.secret: %w", err)
+	}
+	adminToken, err := resolve(cfg.Admin.Token)
+	if err != nil {
+		return fmt.Errorf("admin.token: %w", err)
+	}
+	redisURL, err := resolve(cfg.Redis.URL)
+	if err != nil {
+		return fmt.Errorf("redis.url: %w", err)
+	}
+	cfg.Keys = keys
+	if cfg.KeyPools != nil {
+		cfg.KeyPools = pools
+	}
+	if cfg.Providers != nil {
+		cfg.Providers = providers
+	}
+	cfg.Auth.JWT.Secret = jwtSecret
+	cfg.Admin.Token = adminToken
+	cfg.Redis.URL = redisURL
+	return nil
+}
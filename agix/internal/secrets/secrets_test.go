@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+func newTestVault(t *testing.T) *Vault {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/data/agix/openai": // KV v2
+			w.Write([]byte(`{"data":{"data":{"api_key":"sk-vault"},"metadata":{"version":3}}}`))
+		case "/v1/secret/agix": // KV v1
+			w.Write([]byte(`{"data":{"anthropic":"sk-ant-vault"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &Vault{Address: srv.URL, Token: "root", Client: srv.Client()}
+}
+
+func TestVaultLookup(t *testing.T) {
+	v := newTestVault(t)
+	ctx := context.Background()
+
+	if got, err := v.Lookup(ctx, "kv/agix/openai#api_key"); err != nil || got != "sk-vault" {
+		t.Errorf("KV v2 lookup = %q, %v", got, err)
+	}
+	if got, err := v.Lookup(ctx, "secret/agix#anthropic"); err != nil || got != "sk-ant-vault" {
+		t.Errorf("KV v1 lookup = %q, %v", got, err)
+	}
+	for _, ref := range []string{"kv/agix/openai#missing", "kv/agix/nope#api_key", "kv/agix/openai", "kv#api_key"} {
+		if _, err := v.Lookup(ctx, ref); err == nil {
+			t.Errorf("Lookup(%q) succeeded", ref)
+		}
+	}
+}
+
+func TestResolveKeys(t *testing.T) {
+	orig := keychainCommand
+	keychainCommand = func(ctx context.Context, item string) (*exec.Cmd, error) {
+		return exec.CommandContext(ctx, "echo", "kc-"+item), nil
+	}
+	t.Cleanup(func() { keychainCommand = orig })
+
+	r := &Resolver{backends: map[string]Backend{VaultPrefix: newTestVault(t), KeychainPrefix: Keychain{}}}
+	cfg := &config.Config{
+		Keys: map[string]string{
+			"openai":   "vault:kv/agix/openai#api_key",
+			"deepseek": "keychain:agix-deepseek",
+			"plain":    "sk-plain",
+		},
+		Providers: []config.ProviderConfig{{Name: "groq", APIKey: "keychain:groq"}},
+		KeyPools: map[string]config.KeyPoolConfig{
+			"anthropic": {Keys: []config.PoolKey{{Key: "keychain:ant-1"}, {Key: "sk-ant-2"}}},
+		},
+	}
+	if err := r.ResolveKeys(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	// A pooled provider without keys.<name> gets its first pool key there.
+	want := map[string]string{"openai": "sk-vault", "deepseek": "kc-agix-deepseek", "plain": "sk-plain", "anthropic": "kc-ant-1"}
+	for k, v := range want {
+		if cfg.Keys[k] != v {
+			t.Errorf("Keys[%s] = %q, want %q", k, cfg.Keys[k], v)
+		}
+	}
+	if cfg.Providers[0].APIKey != "kc-groq" {
+		t.Errorf("provider key = %q", cfg.Providers[0].APIKey)
+	}
+	if pool := cfg.KeyPools["anthropic"].Keys; pool[0].Key != "kc-ant-1" || pool[1].Key != "sk-ant-2" {
+		t.Errorf("pool keys = %+v", pool)
+	}
+
+	cfg.Keys = map[string]string{"openai": "vault:kv/agix/gone#api_key"}
+	if err := r.ResolveKeys(context.Background(), cfg); err == nil {
+		t.Error("ResolveKeys succeeded with a missing secret")
+	}
+}
@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Vault reads fields from a HashiCorp Vault KV secrets engine, version 2
+// or 1. A reference is mount/path#field, e.g. kv/agix/openai#api_key.
+type Vault struct {
+	Address   string
+	Token     string
+	Namespace string
+	Client    *http.Client
+}
+
+// NewVault configures Vault the way the vault CLI does: VAULT_ADDR,
+// VAULT_TOKEN (else ~/.vault-token) and VAULT_NAMESPACE.
+func NewVault() *Vault {
+	v := &Vault{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Client:    http.DefaultClient,
+	}
+	if v.Address == "" {
+		v.Address = "https://127.0.0.1:8200"
+	}
+	if v.Token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				v.Token = strings.TrimSpace(string(data))
+			}
+		}
+	}
+	return v
+}
+
+// Lookup reads one field of a secret.
+func (v *Vault) Lookup(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	mount, rest, ok2 := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || field == "" || !ok2 || rest == "" {
+		return "", fmt.Errorf("want vault:<mount>/<path>#<field>")
+	}
+	if v.Token == "" {
+		return "", fmt.Errorf("no Vault token (set VAULT_TOKEN)")
+	}
+
+	// KV v2 nests the fields under data.data; fall back to v1 on 404.
+	var v2 struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	found, err := v.get(ctx, mount+"/data/"+rest, &v2)
+	if err != nil {
+		return "", err
+	}
+	data := v2.Data.Data
+	if !found {
+		var v1 struct {
+			Data map[string]any `json:"data"`
+		}
+		if found, err = v.get(ctx, path, &v1); err != nil {
+			return "", err
+		}
+		if !found {
+			return "", fmt.Errorf("secret %s not found", path)
+		}
+		data = v1.Data
+	}
+
+	s, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("secret %s has no string field %q", path, field)
+	}
+	return s, nil
+}
+
+// get reads /v1/path into out. It returns false on 404.
+func (v *Vault) get(ctx context.Context, path string, out any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(v.Address, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("vault: HTTP %d reading %s", resp.StatusCode, path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("vault: decode %s: %w", path, err)
+	}
+	return true, nil
+}
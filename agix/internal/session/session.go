@@ -17,7 +17,18 @@ type Override struct {
 	Model       string   `json:"model,omitempty"`
 	Temperature *float64 `json:"temperature,omitempty"`
 	MaxTokens   *int     `json:"max_tokens,omitempty"`
-	ExpiresAt   time.Time `json:"expires_at"`
+	// MaxSessionCostUSD caps the cumulative cost of the session's requests.
+	// The cap is soft: the request that crosses it completes, later ones are refused.
+	MaxSessionCostUSD *float64 `json:"max_session_cost_usd,omitempty"`
+	// SpentUSD is the cost accrued so far; it is maintained by the proxy and
+	// ignored on Set.
+	SpentUSD  float64   `json:"spent_usd"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CostExceeded reports whether the session has reached its spend cap.
+func (o *Override) CostExceeded() bool {
+	return o.MaxSessionCostUSD != nil && o.SpentUSD >= *o.MaxSessionCostUSD
 }
 
 // Manager manages session-level config overrides.
@@ -66,7 +77,23 @@ func createTable(db *sql.DB, dialect store.Dialect) error {
 				return err
 			}
 		}
-		return nil
+		return addCostColumns(db, dialect)
+	}
+	if dialect == store.DialectMySQL {
+		_, err := db.Exec(`CREATE TABLE IF NOT EXISTS session_overrides (
+			session_id  VARCHAR(255) PRIMARY KEY,
+			agent_name  VARCHAR(255) NOT NULL DEFAULT '',
+			model       VARCHAR(255),
+			temperature DOUBLE,
+			max_tokens  INT,
+			created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			expires_at  DATETIME NOT NULL,
+			INDEX idx_session_expires (expires_at)
+		)`)
+		if err != nil {
+			return err
+		}
+		return addCostColumns(db, dialect)
 	}
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS session_overrides (
@@ -80,7 +107,30 @@ func createTable(db *sql.DB, dialect store.Dialect) error {
 		);
 		CREATE INDEX IF NOT EXISTS idx_session_expires ON session_overrides(expires_at);
 	`)
-	return err
+	if err != nil {
+		return err
+	}
+	return addCostColumns(db, dialect)
+}
+
+// addCostColumns adds the spend cap columns, which postdate the table.
+func addCostColumns(db *sql.DB, dialect store.Dialect) error {
+	float := "REAL"
+	if dialect != store.DialectSQLite {
+		float = "DOUBLE PRECISION"
+	}
+	for _, c := range []struct{ column, definition string }{
+		{"max_session_cost_usd", float},
+		{"spent_usd", float + " NOT NULL DEFAULT 0"},
+	} {
+		if store.ColumnExists(db, "session_overrides", c.column, dialect) {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE session_overrides ADD COLUMN %s %s", c.column, c.definition)); err != nil {
+			return fmt.Errorf("add %s column: %w", c.column, err)
+		}
+	}
+	return nil
 }
 
 // ph returns the placeholder for position n (1-indexed).
@@ -98,8 +148,11 @@ func (m *Manager) rebind(query string) string {
 
 // nowExpr returns the SQL expression for "current time" for the dialect.
 func (m *Manager) nowExpr() string {
-	if m.dialect == store.DialectPostgres {
+	switch m.dialect {
+	case store.DialectPostgres:
 		return "NOW()"
+	case store.DialectMySQL:
+		return "UTC_TIMESTAMP()"
 	}
 	return "datetime('now')"
 }
@@ -107,7 +160,7 @@ func (m *Manager) nowExpr() string {
 // Get retrieves a non-expired session override.
 func (m *Manager) Get(sessionID string) (*Override, error) {
 	query := fmt.Sprintf(`
-		SELECT session_id, agent_name, model, temperature, max_tokens, expires_at
+		SELECT session_id, agent_name, model, temperature, max_tokens, max_session_cost_usd, spent_usd, expires_at
 		FROM session_overrides
 		WHERE session_id = %s AND expires_at > %s
 	`, m.ph(1), m.nowExpr())
@@ -117,9 +170,10 @@ func (m *Manager) Get(sessionID string) (*Override, error) {
 	var model sql.NullString
 	var temp sql.NullFloat64
 	var maxTok sql.NullInt64
+	var maxCost sql.NullFloat64
 	var expiresStr string
 
-	err := row.Scan(&o.SessionID, &o.AgentName, &model, &temp, &maxTok, &expiresStr)
+	err := row.Scan(&o.SessionID, &o.AgentName, &model, &temp, &maxTok, &maxCost, &o.SpentUSD, &expiresStr)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -138,12 +192,18 @@ func (m *Manager) Get(sessionID string) (*Override, error) {
 		v := int(maxTok.Int64)
 		o.MaxTokens = &v
 	}
+	if maxCost.Valid {
+		v := maxCost.Float64
+		o.MaxSessionCostUSD = &v
+	}
 	o.ExpiresAt, _ = time.Parse("2006-01-02 15:04:05", expiresStr)
 
 	return &o, nil
 }
 
 // Set upserts a session override. If ExpiresAt is zero, uses defaultTTL from now.
+// Spend accrued by an existing session is kept, so raising a cap takes effect
+// without resetting it; Delete starts the session over.
 func (m *Manager) Set(o *Override) error {
 	expiresAt := o.ExpiresAt
 	if expiresAt.IsZero() {
@@ -163,23 +223,40 @@ func (m *Manager) Set(o *Override) error {
 		model = &o.Model
 	}
 
-	var query string
-	if m.dialect == store.DialectPostgres {
-		query = `INSERT INTO session_overrides (session_id, agent_name, model, temperature, max_tokens, expires_at)
-			VALUES (?, ?, ?, ?, ?, ?::timestamp)
-			ON CONFLICT (session_id) DO UPDATE SET agent_name = EXCLUDED.agent_name, model = EXCLUDED.model,
-				temperature = EXCLUDED.temperature, max_tokens = EXCLUDED.max_tokens, expires_at = EXCLUDED.expires_at`
-	} else {
-		query = `INSERT OR REPLACE INTO session_overrides (session_id, agent_name, model, temperature, max_tokens, expires_at)
-			VALUES (?, ?, ?, ?, ?, datetime(?))`
-	}
-	_, err := m.db.Exec(m.rebind(query), o.SessionID, o.AgentName, model, temp, maxTok, expiresAt.UTC().Format("2006-01-02 15:04:05"))
+	expires := "datetime(?)"
+	upsert := `ON CONFLICT (session_id) DO UPDATE SET agent_name = EXCLUDED.agent_name, model = EXCLUDED.model,
+			temperature = EXCLUDED.temperature, max_tokens = EXCLUDED.max_tokens,
+			max_session_cost_usd = EXCLUDED.max_session_cost_usd, expires_at = EXCLUDED.expires_at`
+	switch m.dialect {
+	case store.DialectPostgres:
+		expires = "?::timestamp"
+	case store.DialectMySQL:
+		expires = "?"
+		upsert = `ON DUPLICATE KEY UPDATE agent_name = VALUES(agent_name), model = VALUES(model),
+			temperature = VALUES(temperature), max_tokens = VALUES(max_tokens),
+			max_session_cost_usd = VALUES(max_session_cost_usd), expires_at = VALUES(expires_at)`
+	}
+	query := `INSERT INTO session_overrides (session_id, agent_name, model, temperature, max_tokens, max_session_cost_usd, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ` + expires + `)
+		` + upsert
+	_, err := m.db.Exec(m.rebind(query), o.SessionID, o.AgentName, model, temp, maxTok, o.MaxSessionCostUSD, expiresAt.UTC().Format("2006-01-02 15:04:05"))
 	if err != nil {
 		return fmt.Errorf("upsert session override: %w", err)
 	}
 	return nil
 }
 
+// AddCost adds cost to the session's accrued spend. Unknown or expired
+// sessions are left alone.
+func (m *Manager) AddCost(sessionID string, cost float64) error {
+	query := fmt.Sprintf(`UPDATE session_overrides SET spent_usd = spent_usd + %s
+		WHERE session_id = %s AND expires_at > %s`, m.ph(1), m.ph(2), m.nowExpr())
+	if _, err := m.db.Exec(query, cost, sessionID); err != nil {
+		return fmt.Errorf("add session cost: %w", err)
+	}
+	return nil
+}
+
 // Delete removes a session override.
 func (m *Manager) Delete(sessionID string) error {
 	_, err := m.db.Exec(m.rebind(`DELETE FROM session_overrides WHERE session_id = ?`), sessionID)
@@ -202,7 +279,7 @@ func (m *Manager) CleanExpired() (int64, error) {
 // ListActive returns all non-expired session overrides.
 func (m *Manager) ListActive() ([]Override, error) {
 	query := fmt.Sprintf(`
-		SELECT session_id, agent_name, model, temperature, max_tokens, expires_at
+		SELECT session_id, agent_name, model, temperature, max_tokens, max_session_cost_usd, spent_usd, expires_at
 		FROM session_overrides
 		WHERE expires_at > %s
 		ORDER BY expires_at ASC
@@ -219,9 +296,10 @@ func (m *Manager) ListActive() ([]Override, error) {
 		var model sql.NullString
 		var temp sql.NullFloat64
 		var maxTok sql.NullInt64
+		var maxCost sql.NullFloat64
 		var expiresStr string
 
-		if err := rows.Scan(&o.SessionID, &o.AgentName, &model, &temp, &maxTok, &expiresStr); err != nil {
+		if err := rows.Scan(&o.SessionID, &o.AgentName, &model, &temp, &maxTok, &maxCost, &o.SpentUSD, &expiresStr); err != nil {
 			return nil, fmt.Errorf("scan session override: %w", err)
 		}
 		if model.Valid {
@@ -235,6 +313,10 @@ func (m *Manager) ListActive() ([]Override, error) {
 			v := int(maxTok.Int64)
 			o.MaxTokens = &v
 		}
+		if maxCost.Valid {
+			v := maxCost.Float64
+			o.MaxSessionCostUSD = &v
+		}
 		o.ExpiresAt, _ = time.Parse("2006-01-02 15:04:05", expiresStr)
 		overrides = append(overrides, o)
 	}
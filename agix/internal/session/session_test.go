@@ -321,3 +321,45 @@ func TestListActive(t *testing.T) {
 		t.Errorf("ListActive returned %d, want 2", len(list))
 	}
 }
+
+func TestSessionCostCap(t *testing.T) {
+	db := testDB(t)
+	mgr, err := New(db, time.Hour, store.DialectSQLite)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer mgr.Close()
+
+	if err := mgr.Set(&Override{SessionID: "demo", MaxSessionCostUSD: float64Ptr(2)}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	for _, cost := range []float64{1.25, 0.5} {
+		if err := mgr.AddCost("demo", cost); err != nil {
+			t.Fatalf("AddCost: %v", err)
+		}
+	}
+	got, err := mgr.Get("demo")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.SpentUSD != 1.75 || got.CostExceeded() {
+		t.Fatalf("after 1.75 spent: %+v, exceeded %v", got, got.CostExceeded())
+	}
+
+	// Updating the override keeps the accrued spend.
+	if err := mgr.Set(&Override{SessionID: "demo", Model: "gpt-4o-mini", MaxSessionCostUSD: float64Ptr(1.5)}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got, _ = mgr.Get("demo")
+	if got.SpentUSD != 1.75 || !got.CostExceeded() {
+		t.Errorf("after lowering cap: %+v, exceeded %v", got, got.CostExceeded())
+	}
+
+	// Unknown sessions are ignored.
+	if err := mgr.AddCost("missing", 1); err != nil {
+		t.Errorf("AddCost(missing): %v", err)
+	}
+	if o, _ := mgr.Get("missing"); o != nil {
+		t.Errorf("AddCost created session %+v", o)
+	}
+}
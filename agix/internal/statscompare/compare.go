@@ -0,0 +1,213 @@
+package statscompare
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Range is a named time range, inclusive of both ends.
+type Range struct {
+	Label string    `json:"label"`
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+// ParseRange resolves a range expression relative to now (UTC). Accepted:
+// "today", "yesterday", "this week", "last week", "this month",
+// "last month", "Nd" (last N days), "YYYY-MM", "YYYY-MM-DD" and
+// "YYYY-MM-DD..YYYY-MM-DD". Weeks start on Monday.
+func ParseRange(expr string, now time.Time) (Range, error) {
+	now = now.UTC()
+	s := strings.ToLower(strings.Join(strings.Fields(expr), " "))
+	r := Range{Label: expr}
+
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	monthStart := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	endOf := func(start time.Time, days, months int) time.Time {
+		return start.AddDate(0, months, days).Add(-time.Second)
+	}
+
+	switch s {
+	case "today":
+		r.Since, r.Until = today, now
+	case "yesterday":
+		r.Since, r.Until = today.AddDate(0, 0, -1), today.Add(-time.Second)
+	case "this week":
+		r.Since, r.Until = weekStart, now
+	case "last week":
+		r.Since, r.Until = weekStart.AddDate(0, 0, -7), weekStart.Add(-time.Second)
+	case "this month":
+		r.Since, r.Until = monthStart, now
+	case "last month":
+		r.Since, r.Until = monthStart.AddDate(0, -1, 0), monthStart.Add(-time.Second)
+	default:
+		if n, ok := strings.CutSuffix(s, "d"); ok {
+			if days, err := strconv.Atoi(n); err == nil && days > 0 {
+				r.Since, r.Until = now.AddDate(0, 0, -days), now
+				return r, nil
+			}
+		}
+		if from, to, ok := strings.Cut(s, ".."); ok {
+			start, err1 := time.Parse("2006-01-02", from)
+			end, err2 := time.Parse("2006-01-02", to)
+			if err1 != nil || err2 != nil || end.Before(start) {
+				return r, fmt.Errorf("invalid range %q (want YYYY-MM-DD..YYYY-MM-DD)", expr)
+			}
+			r.Since, r.Until = start, endOf(end, 1, 0)
+			return r, nil
+		}
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			r.Since, r.Until = t, endOf(t, 1, 0)
+			return r, nil
+		}
+		if t, err := time.Parse("2006-01", s); err == nil {
+			r.Since, r.Until = t, endOf(t, 0, 1)
+			return r, nil
+		}
+		return r, fmt.Errorf("unknown range %q", expr)
+	}
+	return r, nil
+}
+
+// Metrics summarizes one side of a comparison.
+type Metrics struct {
+	Requests      int     `json:"requests"`
+	CostUSD       float64 `json:"cost_usd"`
+	AvgDurationMS float64 `json:"avg_duration_ms"`
+	ErrorRate     float64 `json:"error_rate"` // 0..1
+}
+
+// Delta is the change from A to B. Percentages are nil when A is zero.
+type Delta struct {
+	RequestsPct     *float64 `json:"requests_pct"`
+	CostPct         *float64 `json:"cost_pct"`
+	AvgDurationPct  *float64 `json:"avg_duration_pct"`
+	ErrorRatePoints float64  `json:"error_rate_points"` // percentage points
+}
+
+// Row compares one agent, model or the total across the two ranges.
+type Row struct {
+	Key   string  `json:"key"`
+	A     Metrics `json:"a"`
+	B     Metrics `json:"b"`
+	Delta Delta   `json:"delta"`
+}
+
+// Comparison is the result of comparing two ranges.
+type Comparison struct {
+	A      Range `json:"a"`
+	B      Range `json:"b"`
+	Total  Row   `json:"total"`
+	Agents []Row `json:"agents"`
+	Models []Row `json:"models"`
+}
+
+// Compare computes per-agent and per-model deltas between ranges a and b.
+func Compare(st *store.Store, a, b Range) (*Comparison, error) {
+	c := &Comparison{A: a, B: b, Total: Row{Key: "Total"}}
+
+	var totalA, totalB []store.GroupMetrics
+	for _, group := range []string{"agent", "model"} {
+		ma, err := st.QueryGroupMetrics(group, a.Since, a.Until)
+		if err != nil {
+			return nil, err
+		}
+		mb, err := st.QueryGroupMetrics(group, b.Since, b.Until)
+		if err != nil {
+			return nil, err
+		}
+		rows := joinRows(ma, mb)
+		if group == "agent" {
+			c.Agents = rows
+			totalA, totalB = ma, mb
+		} else {
+			c.Models = rows
+		}
+	}
+
+	c.Total.A = summarize(totalA)
+	c.Total.B = summarize(totalB)
+	c.Total.Delta = delta(c.Total.A, c.Total.B)
+	return c, nil
+}
+
+// joinRows pairs up groups from both ranges, sorted by B cost then A cost.
+func joinRows(a, b []store.GroupMetrics) []Row {
+	byKey := make(map[string]*Row)
+	var order []string
+	add := func(gs []store.GroupMetrics, side func(*Row) *Metrics) {
+		for _, g := range gs {
+			row, ok := byKey[g.Key]
+			if !ok {
+				row = &Row{Key: g.Key}
+				byKey[g.Key] = row
+				order = append(order, g.Key)
+			}
+			*side(row) = metrics(g)
+		}
+	}
+	add(a, func(r *Row) *Metrics { return &r.A })
+	add(b, func(r *Row) *Metrics { return &r.B })
+
+	rows := make([]Row, 0, len(order))
+	for _, k := range order {
+		row := byKey[k]
+		row.Delta = delta(row.A, row.B)
+		rows = append(rows, *row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].B.CostUSD != rows[j].B.CostUSD {
+			return rows[i].B.CostUSD > rows[j].B.CostUSD
+		}
+		return rows[i].A.CostUSD > rows[j].A.CostUSD
+	})
+	return rows
+}
+
+func metrics(g store.GroupMetrics) Metrics {
+	m := Metrics{Requests: g.Requests, CostUSD: g.CostUSD, AvgDurationMS: g.AvgDurationMS}
+	if g.Requests > 0 {
+		m.ErrorRate = float64(g.Errors) / float64(g.Requests)
+	}
+	return m
+}
+
+// summarize combines groups into overall metrics, weighting latency by volume.
+func summarize(gs []store.GroupMetrics) Metrics {
+	var total store.GroupMetrics
+	var durationSum float64
+	for _, g := range gs {
+		total.Requests += g.Requests
+		total.CostUSD += g.CostUSD
+		total.Errors += g.Errors
+		durationSum += g.AvgDurationMS * float64(g.Requests)
+	}
+	if total.Requests > 0 {
+		total.AvgDurationMS = durationSum / float64(total.Requests)
+	}
+	return metrics(total)
+}
+
+func delta(a, b Metrics) Delta {
+	return Delta{
+		RequestsPct:     pct(float64(a.Requests), float64(b.Requests)),
+		CostPct:         pct(a.CostUSD, b.CostUSD),
+		AvgDurationPct:  pct(a.AvgDurationMS, b.AvgDurationMS),
+		ErrorRatePoints: (b.ErrorRate - a.ErrorRate) * 100,
+	}
+}
+
+func pct(a, b float64) *float64 {
+	if a == 0 {
+		return nil
+	}
+	v := (b - a) / a * 100
+	return &v
+}
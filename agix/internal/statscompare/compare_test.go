@@ -0,0 +1,120 @@
+package statscompare
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestParseRange(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+	endOfDay := func(m time.Month, d int) time.Time { return day(m, d).Add(24*time.Hour - time.Second) }
+
+	tests := []struct {
+		expr      string
+		wantSince time.Time
+		wantUntil time.Time
+	}{
+		{"today", day(3, 4), now},
+		{"yesterday", day(3, 3), endOfDay(3, 3)},
+		{"this week", day(3, 2), now},
+		{"Last  Week", day(2, 23), endOfDay(3, 1)},
+		{"this month", day(3, 1), now},
+		{"last month", day(2, 1), endOfDay(2, 28)},
+		{"7d", now.AddDate(0, 0, -7), now},
+		{"2026-01", day(1, 1), endOfDay(1, 31)},
+		{"2026-02-10", day(2, 10), endOfDay(2, 10)},
+		{"2026-02-10..2026-02-16", day(2, 10), endOfDay(2, 16)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := ParseRange(tt.expr, now)
+			if err != nil {
+				t.Fatalf("ParseRange() error: %v", err)
+			}
+			if !r.Since.Equal(tt.wantSince) || !r.Until.Equal(tt.wantUntil) {
+				t.Errorf("ParseRange(%q) = %s..%s, want %s..%s", tt.expr, r.Since, r.Until, tt.wantSince, tt.wantUntil)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "last year", "0d", "2026-02-16..2026-02-10", "2026-13"} {
+		if _, err := ParseRange(bad, now); err == nil {
+			t.Errorf("ParseRange(%q) expected error", bad)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New() error: %v", err)
+	}
+	defer st.Close()
+
+	weekA := time.Date(2026, 2, 24, 12, 0, 0, 0, time.UTC)
+	weekB := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	records := []*store.Record{
+		{Timestamp: weekA, AgentName: "bot", Model: "gpt-4o", Provider: "openai", CostUSD: 1.0, DurationMS: 1000, StatusCode: 200},
+		{Timestamp: weekA, AgentName: "bot", Model: "gpt-4o", Provider: "openai", CostUSD: 1.0, DurationMS: 3000, StatusCode: 500},
+		{Timestamp: weekA, AgentName: "old", Model: "gpt-4o-mini", Provider: "openai", CostUSD: 0.5, DurationMS: 500, StatusCode: 200},
+		{Timestamp: weekB, AgentName: "bot", Model: "gpt-4o", Provider: "openai", CostUSD: 3.0, DurationMS: 1000, StatusCode: 200},
+		{Timestamp: weekB, AgentName: "new", Model: "gpt-4o-mini", Provider: "openai", CostUSD: 0.25, DurationMS: 500, StatusCode: 200},
+	}
+	for _, r := range records {
+		if err := st.Insert(r); err != nil {
+			t.Fatalf("Insert() error: %v", err)
+		}
+	}
+
+	now := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	a, _ := ParseRange("last week", now)
+	b, _ := ParseRange("this week", now)
+	c, err := Compare(st, a, b)
+	if err != nil {
+		t.Fatalf("Compare() error: %v", err)
+	}
+
+	if c.Total.A.Requests != 3 || c.Total.B.Requests != 2 {
+		t.Errorf("total requests = %d → %d, want 3 → 2", c.Total.A.Requests, c.Total.B.Requests)
+	}
+	if math.Abs(c.Total.A.AvgDurationMS-1500) > 1e-9 {
+		t.Errorf("total A latency = %v, want 1500 (volume-weighted)", c.Total.A.AvgDurationMS)
+	}
+
+	rows := map[string]Row{}
+	for _, r := range c.Agents {
+		rows[r.Key] = r
+	}
+	if len(rows) != 3 {
+		t.Fatalf("agents = %v, want bot, old and new", c.Agents)
+	}
+	if c.Agents[0].Key != "bot" {
+		t.Errorf("first agent = %q, want bot (highest B cost)", c.Agents[0].Key)
+	}
+
+	bot := rows["bot"]
+	if bot.Delta.CostPct == nil || math.Abs(*bot.Delta.CostPct-50) > 1e-9 {
+		t.Errorf("bot cost delta = %v, want +50%%", bot.Delta.CostPct)
+	}
+	if bot.Delta.AvgDurationPct == nil || math.Abs(*bot.Delta.AvgDurationPct+50) > 1e-9 {
+		t.Errorf("bot latency delta = %v, want -50%%", bot.Delta.AvgDurationPct)
+	}
+	if math.Abs(bot.Delta.ErrorRatePoints+50) > 1e-9 {
+		t.Errorf("bot error rate delta = %v, want -50pp", bot.Delta.ErrorRatePoints)
+	}
+	if rows["new"].Delta.RequestsPct != nil {
+		t.Error("new agent should have no percentage delta")
+	}
+	if p := rows["old"].Delta.RequestsPct; p == nil || *p != -100 {
+		t.Errorf("old agent requests delta = %v, want -100%%", p)
+	}
+	if len(c.Models) != 2 {
+		t.Errorf("models = %d, want 2", len(c.Models))
+	}
+}
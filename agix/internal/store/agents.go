@@ -0,0 +1,22 @@
+package store
+
+import "strings"
+
+// InternalAgentPrefix marks usage generated by the gateway itself rather
+// than by a client agent.
+const InternalAgentPrefix = "_gateway/"
+
+// Agent names for LLM calls the gateway makes on its own behalf. They are
+// recorded like any other agent, so they show up in stats and can be given
+// budgets.
+const (
+	AgentCompressor = InternalAgentPrefix + "compressor"
+	AgentEmbeddings = InternalAgentPrefix + "cache-embeddings"
+	AgentWebhook    = InternalAgentPrefix + "webhook"
+	AgentSummarizer = InternalAgentPrefix + "summarizer"
+)
+
+// IsInternalAgent reports whether name is a gateway-internal agent.
+func IsInternalAgent(name string) bool {
+	return strings.HasPrefix(name, InternalAgentPrefix)
+}
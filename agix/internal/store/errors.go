@@ -0,0 +1,110 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// nonSuccess matches responses outside 2xx, including 0 for requests that
+// never got an upstream response.
+const nonSuccess = "(status_code < 200 OR status_code >= 300)"
+
+// StatusCount is the number of non-2xx responses with one status code from
+// one provider and model.
+type StatusCount struct {
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	StatusCode int    `json:"status_code"`
+	Count      int    `json:"count"`
+}
+
+// QueryStatusCodes returns non-2xx responses grouped by provider, model and
+// status code, most frequent first.
+func (s *Store) QueryStatusCodes(since, until time.Time) ([]StatusCount, error) {
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT provider, model, status_code, COUNT(*)
+		 FROM requests
+		 WHERE timestamp >= ? AND timestamp <= ? AND `+nonSuccess+`
+		 GROUP BY provider, model, status_code
+		 ORDER BY COUNT(*) DESC, provider, model, status_code`),
+		fmtTime(since), fmtTime(until),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query status codes: %w", err)
+	}
+	defer rows.Close()
+
+	var results []StatusCount
+	for rows.Next() {
+		var c StatusCount
+		if err := rows.Scan(&c.Provider, &c.Model, &c.StatusCode, &c.Count); err != nil {
+			return nil, fmt.Errorf("scan status codes: %w", err)
+		}
+		results = append(results, c)
+	}
+	return results, rows.Err()
+}
+
+// ErrorRate is the share of non-2xx responses from one provider in one
+// time bucket.
+type ErrorRate struct {
+	Bucket   string  `json:"bucket"` // YYYY-MM-DD or YYYY-MM-DDTHH (UTC)
+	Provider string  `json:"provider"`
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	Rate     float64 `json:"error_rate"` // 0-1
+}
+
+// QueryErrorRates returns per-provider error rates in "hour" or "day"
+// buckets, oldest first, so a degrading provider shows as a rising rate.
+func (s *Store) QueryErrorRates(bucket string, since, until time.Time) ([]ErrorRate, error) {
+	var n int
+	switch bucket {
+	case "hour":
+		n = 13
+	case "day":
+		n = 10
+	default:
+		return nil, fmt.Errorf("unknown bucket %q (want hour or day)", bucket)
+	}
+	// SQLite and MySQL store timestamps as RFC 3339 text; PostgreSQL has a
+	// real timestamp column.
+	expr := fmt.Sprintf("substr(timestamp, 1, %d)", n)
+	switch s.dialect {
+	case DialectPostgres:
+		format := "YYYY-MM-DD"
+		if bucket == "hour" {
+			format = `YYYY-MM-DD"T"HH24`
+		}
+		expr = fmt.Sprintf("to_char(timestamp, '%s')", format)
+	case DialectMySQL:
+		expr = fmt.Sprintf("LEFT(timestamp, %d)", n)
+	}
+
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT `+expr+` AS bucket, provider, COUNT(*),
+			COALESCE(SUM(CASE WHEN `+nonSuccess+` THEN 1 ELSE 0 END), 0)
+		 FROM requests
+		 WHERE timestamp >= ? AND timestamp <= ?
+		 GROUP BY `+expr+`, provider
+		 ORDER BY bucket, provider`),
+		fmtTime(since), fmtTime(until),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query error rates: %w", err)
+	}
+	defer rows.Close()
+
+	var results []ErrorRate
+	for rows.Next() {
+		var e ErrorRate
+		if err := rows.Scan(&e.Bucket, &e.Provider, &e.Requests, &e.Errors); err != nil {
+			return nil, fmt.Errorf("scan error rates: %w", err)
+		}
+		if e.Requests > 0 {
+			e.Rate = float64(e.Errors) / float64(e.Requests)
+		}
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// StreamingStats summarizes time to first token and output rate of
+// streaming responses for one model.
+type StreamingStats struct {
+	Model           string  `json:"model"`
+	Streams         int     `json:"streams"`
+	TTFTP50MS       int64   `json:"ttft_p50_ms"`
+	TTFTP95MS       int64   `json:"ttft_p95_ms"`
+	TokensPerSecP50 float64 `json:"tokens_per_sec_p50"`
+	TokensPerSecP95 float64 `json:"tokens_per_sec_p95"`
+}
+
+// QueryStreamingStats returns per-model TTFT and tokens/sec percentiles for
+// successful streaming responses, busiest models first. Percentiles are
+// computed here because SQLite has no percentile aggregate.
+func (s *Store) QueryStreamingStats(since, until time.Time) ([]StreamingStats, error) {
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT model, ttft_ms, tokens_per_sec
+		 FROM requests
+		 WHERE timestamp >= ? AND timestamp <= ? AND ttft_ms > 0 AND status_code < 400`),
+		fmtTime(since), fmtTime(until),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query streaming stats: %w", err)
+	}
+	defer rows.Close()
+
+	ttft := make(map[string][]int64)
+	rate := make(map[string][]float64)
+	for rows.Next() {
+		var model string
+		var ms int64
+		var tps float64
+		if err := rows.Scan(&model, &ms, &tps); err != nil {
+			return nil, fmt.Errorf("scan streaming stats: %w", err)
+		}
+		ttft[model] = append(ttft[model], ms)
+		// Responses with a single chunk have no measurable rate.
+		if tps > 0 {
+			rate[model] = append(rate[model], tps)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]StreamingStats, 0, len(ttft))
+	for model, ms := range ttft {
+		slices.Sort(ms)
+		tps := rate[model]
+		slices.Sort(tps)
+		results = append(results, StreamingStats{
+			Model:           model,
+			Streams:         len(ms),
+			TTFTP50MS:       percentile(ms, 50),
+			TTFTP95MS:       percentile(ms, 95),
+			TokensPerSecP50: percentile(tps, 50),
+			TokensPerSecP95: percentile(tps, 95),
+		})
+	}
+	slices.SortFunc(results, func(a, b StreamingStats) int {
+		if a.Streams != b.Streams {
+			return b.Streams - a.Streams
+		}
+		return strings.Compare(a.Model, b.Model)
+	})
+	return results, nil
+}
+
+// LatencyStats summarizes end-to-end request latency for one group.
+type LatencyStats struct {
+	Key      string  `json:"key"`
+	Requests int     `json:"requests"`
+	AvgMS    float64 `json:"avg_ms"`
+	P50MS    int64   `json:"p50_ms"`
+	P95MS    int64   `json:"p95_ms"`
+	P99MS    int64   `json:"p99_ms"`
+	MaxMS    int64   `json:"max_ms"`
+}
+
+// QueryLatencyStats returns duration percentiles grouped by "model",
+// "provider" or "agent", busiest first. Failed requests are included: a
+// provider timing out is exactly the tail the averages hide.
+func (s *Store) QueryLatencyStats(groupBy string, since, until time.Time) ([]LatencyStats, error) {
+	var key string
+	switch groupBy {
+	case "model":
+		key = "model"
+	case "provider":
+		key = "provider"
+	case "agent":
+		key = "CASE WHEN agent_name = '' THEN '(unknown)' ELSE agent_name END"
+	default:
+		return nil, fmt.Errorf("unknown group %q (want model, provider or agent)", groupBy)
+	}
+
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT `+key+`, duration_ms
+		 FROM requests
+		 WHERE timestamp >= ? AND timestamp <= ?`),
+		fmtTime(since), fmtTime(until),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query latency stats: %w", err)
+	}
+	defer rows.Close()
+
+	durations := make(map[string][]int64)
+	for rows.Next() {
+		var k string
+		var ms int64
+		if err := rows.Scan(&k, &ms); err != nil {
+			return nil, fmt.Errorf("scan latency stats: %w", err)
+		}
+		durations[k] = append(durations[k], ms)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]LatencyStats, 0, len(durations))
+	for k, ms := range durations {
+		slices.Sort(ms)
+		var sum int64
+		for _, d := range ms {
+			sum += d
+		}
+		results = append(results, LatencyStats{
+			Key:      k,
+			Requests: len(ms),
+			AvgMS:    float64(sum) / float64(len(ms)),
+			P50MS:    percentile(ms, 50),
+			P95MS:    percentile(ms, 95),
+			P99MS:    percentile(ms, 99),
+			MaxMS:    ms[len(ms)-1],
+		})
+	}
+	slices.SortFunc(results, func(a, b LatencyStats) int {
+		if a.Requests != b.Requests {
+			return b.Requests - a.Requests
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return results, nil
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted values, or
+// zero if there are none.
+func percentile[T int64 | float64](sorted []T, p int) T {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	return sorted[max(rank, 1)-1]
+}
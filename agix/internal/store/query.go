@@ -0,0 +1,155 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RequestFilter selects rows of the requests table for read-only
+// consumers. Zero fields match everything.
+type RequestFilter struct {
+	Since, Until time.Time
+	Agents       []string // any of these agents
+	Model        string
+	Provider     string
+}
+
+// where returns the filter's WHERE clause and its arguments.
+func (f RequestFilter) where() (string, []any) {
+	var conds []string
+	var args []any
+	if !f.Since.IsZero() {
+		conds = append(conds, "timestamp >= ?")
+		args = append(args, fmtTime(f.Since))
+	}
+	if !f.Until.IsZero() {
+		conds = append(conds, "timestamp <= ?")
+		args = append(args, fmtTime(f.Until))
+	}
+	if len(f.Agents) > 0 {
+		conds = append(conds, "agent_name IN (?"+strings.Repeat(", ?", len(f.Agents)-1)+")")
+		for _, a := range f.Agents {
+			args = append(args, a)
+		}
+	}
+	if f.Model != "" {
+		conds = append(conds, "model = ?")
+		args = append(args, f.Model)
+	}
+	if f.Provider != "" {
+		conds = append(conds, "provider = ?")
+		args = append(args, f.Provider)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// QueryRequestPage returns up to limit requests matching f with an ID
+// greater than afterID, oldest first. Passing the last ID of a page as the
+// next afterID pages through the table without skipping or repeating rows
+// as new requests arrive.
+func (s *Store) QueryRequestPage(f RequestFilter, afterID int64, limit int) ([]Record, error) {
+	where, args := f.where()
+	if where == "" {
+		where = " WHERE id > ?"
+	} else {
+		where += " AND id > ?"
+	}
+	args = append(args, afterID, limit)
+
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT id, timestamp, agent_name, model, provider, input_tokens, output_tokens, cost_usd, duration_ms, status_code, failover_from, original_model, reasoning_tokens, request_id, ttft_ms, tokens_per_sec, request_type, cache_write_tokens, cache_read_tokens
+		 FROM requests`+where+`
+		 ORDER BY id ASC
+		 LIMIT ?`),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query request page: %w", err)
+	}
+	defer rows.Close()
+
+	var results []Record
+	for rows.Next() {
+		var r Record
+		var ts string
+		if err := rows.Scan(&r.ID, &ts, &r.AgentName, &r.Model, &r.Provider, &r.InputTokens, &r.OutputTokens, &r.CostUSD, &r.DurationMS, &r.StatusCode, &r.FailoverFrom, &r.OriginalModel, &r.ReasoningTokens, &r.RequestID, &r.TTFTMS, &r.TokensPerSec, &r.RequestType, &r.CacheWriteTokens, &r.CacheReadTokens); err != nil {
+			return nil, fmt.Errorf("scan record: %w", err)
+		}
+		r.Timestamp, _ = time.Parse(timeFormat, ts)
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// UsageTotals sums the requests of one group.
+type UsageTotals struct {
+	Key              string  `json:"key"`
+	Requests         int     `json:"requests"`
+	InputTokens      int64   `json:"input_tokens"`
+	OutputTokens     int64   `json:"output_tokens"`
+	CacheWriteTokens int64   `json:"cache_write_tokens"`
+	CacheReadTokens  int64   `json:"cache_read_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	AvgDurationMS    float64 `json:"avg_duration_ms"`
+	Errors           int     `json:"errors"` // responses with status >= 400
+}
+
+// QueryUsageTotals returns usage totals of the requests matching f,
+// grouped by "agent", "model", "provider" or "day" (UTC), ordered by key.
+func (s *Store) QueryUsageTotals(f RequestFilter, groupBy string) ([]UsageTotals, error) {
+	var key string
+	switch groupBy {
+	case "agent":
+		key = "agent_name"
+	case "model":
+		key = "model"
+	case "provider":
+		key = "provider"
+	case "day":
+		key = "date(timestamp)"
+		switch s.dialect {
+		case DialectPostgres:
+			key = "to_char(timestamp, 'YYYY-MM-DD')"
+		case DialectMySQL:
+			key = "LEFT(timestamp, 10)"
+		}
+	default:
+		return nil, fmt.Errorf("unknown group %q (want agent, model, provider or day)", groupBy)
+	}
+
+	where, args := f.where()
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT
+			`+key+`,
+			COUNT(*),
+			COALESCE(SUM(input_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(cache_write_tokens), 0),
+			COALESCE(SUM(cache_read_tokens), 0),
+			COALESCE(SUM(cost_usd), 0),
+			COALESCE(AVG(duration_ms), 0),
+			COALESCE(SUM(CASE WHEN status_code >= 400 THEN 1 ELSE 0 END), 0)
+		 FROM requests`+where+`
+		 GROUP BY `+key+`
+		 ORDER BY `+key),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query usage by %s: %w", groupBy, err)
+	}
+	defer rows.Close()
+
+	var results []UsageTotals
+	for rows.Next() {
+		var u UsageTotals
+		if err := rows.Scan(&u.Key, &u.Requests, &u.InputTokens, &u.OutputTokens, &u.CacheWriteTokens, &u.CacheReadTokens, &u.CostUSD, &u.AvgDurationMS, &u.Errors); err != nil {
+			return nil, fmt.Errorf("scan usage by %s: %w", groupBy, err)
+		}
+		results = append(results, u)
+	}
+	return results, rows.Err()
+}
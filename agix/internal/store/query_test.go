@@ -0,0 +1,79 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryRequestPage(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, agent := range []string{"a", "b", "a", "a", "c"} {
+		if err := s.Insert(&Record{
+			Timestamp: now.Add(time.Duration(i) * time.Minute), AgentName: agent, Model: "gpt-4o", Provider: "openai",
+			InputTokens: 10, OutputTokens: 5, CostUSD: 0.01, StatusCode: 200,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := RequestFilter{Agents: []string{"a", "b"}}
+	page, err := s.QueryRequestPage(f, 0, 2)
+	if err != nil {
+		t.Fatalf("QueryRequestPage() error: %v", err)
+	}
+	if len(page) != 2 || page[0].AgentName != "a" || page[1].AgentName != "b" || page[0].ID >= page[1].ID {
+		t.Fatalf("first page = %+v, want a then b in ID order", page)
+	}
+	page, err = s.QueryRequestPage(f, page[1].ID, 2)
+	if err != nil {
+		t.Fatalf("QueryRequestPage() error: %v", err)
+	}
+	if len(page) != 2 || page[0].AgentName != "a" || page[1].AgentName != "a" {
+		t.Fatalf("second page = %+v, want two rows of a", page)
+	}
+	if page, _ = s.QueryRequestPage(f, page[1].ID, 2); len(page) != 0 {
+		t.Errorf("third page = %+v, want empty", page)
+	}
+
+	f = RequestFilter{Since: now.Add(90 * time.Second), Until: now.Add(3 * time.Minute)}
+	if page, _ = s.QueryRequestPage(f, 0, 10); len(page) != 2 {
+		t.Errorf("time-filtered page = %+v, want 2 rows", page)
+	}
+}
+
+func TestQueryUsageTotals(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().UTC()
+	for _, r := range []Record{
+		{AgentName: "a", Model: "gpt-4o", Provider: "openai", InputTokens: 100, OutputTokens: 10, CostUSD: 1, StatusCode: 200},
+		{AgentName: "a", Model: "claude-sonnet-4-20250514", Provider: "anthropic", InputTokens: 50, CacheReadTokens: 1000, CostUSD: 2, StatusCode: 500},
+		{AgentName: "b", Model: "gpt-4o", Provider: "openai", InputTokens: 10, CostUSD: 4, StatusCode: 200},
+	} {
+		r.Timestamp = now
+		if err := s.Insert(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	totals, err := s.QueryUsageTotals(RequestFilter{Agents: []string{"a"}}, "provider")
+	if err != nil {
+		t.Fatalf("QueryUsageTotals() error: %v", err)
+	}
+	if len(totals) != 2 || totals[0].Key != "anthropic" || totals[0].CacheReadTokens != 1000 || totals[0].Errors != 1 ||
+		totals[1].Key != "openai" || totals[1].InputTokens != 100 || totals[1].CostUSD != 1 {
+		t.Errorf("totals by provider = %+v", totals)
+	}
+
+	totals, err = s.QueryUsageTotals(RequestFilter{}, "day")
+	if err != nil {
+		t.Fatalf("QueryUsageTotals(day) error: %v", err)
+	}
+	if len(totals) != 1 || totals[0].Key != now.Format("2006-01-02") || totals[0].Requests != 3 || totals[0].CostUSD != 7 {
+		t.Errorf("totals by day = %+v", totals)
+	}
+
+	if _, err := s.QueryUsageTotals(RequestFilter{}, "status"); err == nil {
+		t.Error("QueryUsageTotals(status) succeeded, want error")
+	}
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// spill is an append-only JSON-lines file of records the database would
+// not take. The batch writer replays it once writes succeed again, so an
+// outage delays cost accounting instead of losing rows.
+//
+// Replay renames the file aside first, so records spilled while a replay
+// runs land in a fresh file. A crash between committing a replay and
+// removing the renamed file replays it again on the next start.
+type spill struct {
+	mu      sync.Mutex
+	path    string
+	pending bool
+}
+
+func newSpill(path string) *spill {
+	sp := &spill{path: path}
+	for _, p := range []string{path, sp.replayPath()} {
+		if _, err := os.Stat(p); err == nil {
+			sp.pending = true
+		}
+	}
+	return sp
+}
+
+func (sp *spill) replayPath() string { return sp.path + ".replay" }
+
+// append writes records to the spill file and syncs it.
+func (sp *spill) append(records []*Record) error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	f, err := os.OpenFile(sp.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	sp.pending = true
+	return f.Close()
+}
+
+// hasPending reports whether spilled records are waiting for replay.
+func (sp *spill) hasPending() bool {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.pending
+}
+
+// take moves the spill file aside for replay and returns its records. A
+// file left over from an interrupted replay is taken first.
+func (sp *spill) take() ([]*Record, error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if _, err := os.Stat(sp.replayPath()); errors.Is(err, os.ErrNotExist) {
+		if err := os.Rename(sp.path, sp.replayPath()); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				sp.pending = false
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(sp.replayPath())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*Record
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		r := new(Record)
+		if err := json.Unmarshal(sc.Bytes(), r); err != nil {
+			// A torn last line from a crash mid-append; keep the rest.
+			log.Printf("WARN: spill %s line %d: %v (skipped)", sp.replayPath(), line, err)
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, sc.Err()
+}
+
+// done removes the replayed file. pending stays set if records were
+// spilled during the replay.
+func (sp *spill) done() error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if err := os.Remove(sp.replayPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if _, err := os.Stat(sp.path); errors.Is(err, os.ErrNotExist) {
+		sp.pending = false
+	}
+	return nil
+}
+
+// replaySpill inserts spilled records. On failure they stay on disk for
+// the next attempt.
+func (s *Store) replaySpill() error {
+	records, err := s.spill.take()
+	if err != nil {
+		return fmt.Errorf("read spill file: %w", err)
+	}
+	if len(records) > 0 {
+		if err := s.insertBatch(records); err != nil {
+			return err
+		}
+	}
+	if err := s.spill.done(); err != nil {
+		return fmt.Errorf("remove spill file: %w", err)
+	}
+	if len(records) > 0 {
+		log.Printf("INFO: replayed %d spilled records", len(records))
+	}
+	return nil
+}
@@ -22,6 +22,46 @@ type Record struct {
 	StatusCode    int
 	FailoverFrom  string
 	OriginalModel string
+	// ReasoningTokens is the share of OutputTokens spent on hidden reasoning.
+	ReasoningTokens int
+	// RequestID correlates the row with its trace, audit events and the
+	// X-Request-ID response header.
+	RequestID string
+	// TTFTMS is the time to the first streamed token and TokensPerSec the
+	// output rate after it. Both are zero for non-streaming responses.
+	TTFTMS       int64
+	TokensPerSec float64
+	// RequestType is the kind of API call; empty means RequestTypeChat.
+	RequestType string
+	// CacheWriteTokens and CacheReadTokens are Anthropic prompt-cache input
+	// tokens, billed apart from (and not included in) InputTokens.
+	CacheWriteTokens int
+	CacheReadTokens  int
+	// ArchiveKey points to the gzipped request/response bodies in the
+	// archive, if archiving is enabled.
+	ArchiveKey string
+	// Tags are the caller's X-Request-Tags in canonical form
+	// (key=value pairs sorted by key, comma-separated).
+	Tags string
+	// Project is the project the request is charged to: the X-Project
+	// header, else the agent's entry in projects, else empty.
+	Project string
+}
+
+// Request types recorded in the requests table.
+const (
+	RequestTypeChat      = "chat"
+	RequestTypeEmbedding = "embedding"
+	RequestTypeImage     = "image"
+	RequestTypeAudio     = "audio"
+)
+
+// requestType returns the type stored for r.
+func (r *Record) requestType() string {
+	if r.RequestType == "" {
+		return RequestTypeChat
+	}
+	return r.RequestType
 }
 
 // Stats represents aggregated statistics.
@@ -54,12 +94,53 @@ type ModelStats struct {
 	CostUSD      float64
 }
 
+// GroupMetrics holds volume, cost, latency and errors for one agent or
+// model over a period.
+type GroupMetrics struct {
+	Key           string  `json:"key"`
+	Requests      int     `json:"requests"`
+	CostUSD       float64 `json:"cost_usd"`
+	AvgDurationMS float64 `json:"avg_duration_ms"`
+	Errors        int     `json:"errors"` // responses with status >= 400
+}
+
 // Store provides access to the database (SQLite or PostgreSQL).
 type Store struct {
 	db       *sql.DB
 	dialect  Dialect
 	recordCh chan *Record
 	done     chan struct{}
+
+	// spill keeps records the database rejected; nil drops them.
+	spill      *spill
+	spillRetry time.Duration
+
+	// content holds the bulky tables (traces, audit events, cache
+	// entries). It is db unless a content DSN is configured.
+	content        *sql.DB
+	contentDialect Dialect
+}
+
+// Option configures a Store.
+type Option func(*options)
+
+type options struct {
+	contentDSN string
+	spillFile  string
+}
+
+// WithContentDSN keeps traces, audit events and cache entries in a separate
+// database so the requests table stays small and fast. An empty DSN or one
+// equal to the primary DSN keeps everything in one database.
+func WithContentDSN(dsn string) Option {
+	return func(o *options) { o.contentDSN = dsn }
+}
+
+// WithSpillFile appends records that cannot be written (database locked or
+// unreachable) to path and replays them once writes succeed again. Only
+// one process may use a given file.
+func WithSpillFile(path string) Option {
+	return func(o *options) { o.spillFile = path }
 }
 
 const createTableSQLite = `
@@ -104,6 +185,29 @@ CREATE INDEX IF NOT EXISTS idx_audit_events_timestamp ON audit_events(timestamp)
 CREATE INDEX IF NOT EXISTS idx_audit_events_type ON audit_events(event_type);
 CREATE INDEX IF NOT EXISTS idx_audit_events_agent ON audit_events(agent_name);
 
+CREATE VIRTUAL TABLE IF NOT EXISTS audit_content_fts USING fts5(body);
+
+CREATE TRIGGER IF NOT EXISTS audit_content_fts_insert AFTER INSERT ON audit_events
+WHEN new.event_type = 'content_log' AND json_valid(new.details)
+BEGIN
+	INSERT INTO audit_content_fts(rowid, body) VALUES (new.id, COALESCE(json_extract(new.details, '$.body'), ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_content_fts_delete AFTER DELETE ON audit_events
+WHEN old.event_type = 'content_log'
+BEGIN
+	DELETE FROM audit_content_fts WHERE rowid = old.id;
+END;
+
+CREATE TABLE IF NOT EXISTS legal_holds (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME NOT NULL,
+	kind      TEXT NOT NULL,
+	value     TEXT NOT NULL,
+	reason    TEXT NOT NULL DEFAULT '',
+	UNIQUE(kind, value)
+);
+
 CREATE TABLE IF NOT EXISTS webhook_executions (
 	id            INTEGER PRIMARY KEY AUTOINCREMENT,
 	timestamp     DATETIME NOT NULL DEFAULT (datetime('now')),
@@ -118,6 +222,25 @@ CREATE TABLE IF NOT EXISTS webhook_executions (
 
 CREATE INDEX IF NOT EXISTS idx_webhook_executions_name ON webhook_executions(webhook_name);
 CREATE INDEX IF NOT EXISTS idx_webhook_executions_timestamp ON webhook_executions(timestamp);
+
+CREATE TABLE IF NOT EXISTS queued_requests (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp       DATETIME NOT NULL DEFAULT (datetime('now')),
+	agent_name      TEXT NOT NULL DEFAULT '',
+	model           TEXT NOT NULL,
+	priority        INTEGER NOT NULL DEFAULT 0,
+	body            TEXT NOT NULL DEFAULT '',
+	callback_url    TEXT NOT NULL DEFAULT '',
+	status          TEXT NOT NULL DEFAULT 'queued',
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at DATETIME NOT NULL,
+	status_code     INTEGER NOT NULL DEFAULT 0,
+	response        TEXT NOT NULL DEFAULT '',
+	error           TEXT NOT NULL DEFAULT '',
+	callback_code   INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_queued_requests_due ON queued_requests(status, next_attempt_at);
 `
 
 // postgresCreateStatements are executed one at a time (PostgreSQL cannot run
@@ -135,11 +258,14 @@ var postgresCreateStatements = []string{
 		duration_ms   BIGINT NOT NULL DEFAULT 0,
 		status_code   INTEGER NOT NULL DEFAULT 200,
 		failover_from  TEXT NOT NULL DEFAULT '',
-		original_model TEXT NOT NULL DEFAULT ''
+		original_model TEXT NOT NULL DEFAULT '',
+		reasoning_tokens INTEGER NOT NULL DEFAULT 0
 	)`,
 	`CREATE INDEX IF NOT EXISTS idx_requests_timestamp ON requests(timestamp)`,
 	`CREATE INDEX IF NOT EXISTS idx_requests_agent ON requests(agent_name)`,
 	`CREATE INDEX IF NOT EXISTS idx_requests_model ON requests(model)`,
+	`CREATE INDEX IF NOT EXISTS idx_requests_failover_from ON requests(failover_from)`,
+	`CREATE INDEX IF NOT EXISTS idx_requests_original_model ON requests(original_model)`,
 	`CREATE TABLE IF NOT EXISTS traces (
 		id         BIGSERIAL PRIMARY KEY,
 		trace_id   TEXT NOT NULL UNIQUE,
@@ -160,6 +286,16 @@ var postgresCreateStatements = []string{
 	`CREATE INDEX IF NOT EXISTS idx_audit_events_timestamp ON audit_events(timestamp)`,
 	`CREATE INDEX IF NOT EXISTS idx_audit_events_type ON audit_events(event_type)`,
 	`CREATE INDEX IF NOT EXISTS idx_audit_events_agent ON audit_events(agent_name)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_events_content_fts ON audit_events
+		USING GIN (to_tsvector('simple', (details::jsonb)->>'body')) WHERE event_type = 'content_log'`,
+	`CREATE TABLE IF NOT EXISTS legal_holds (
+		id        BIGSERIAL PRIMARY KEY,
+		timestamp TIMESTAMP NOT NULL,
+		kind      TEXT NOT NULL,
+		value     TEXT NOT NULL,
+		reason    TEXT NOT NULL DEFAULT '',
+		UNIQUE(kind, value)
+	)`,
 	`CREATE TABLE IF NOT EXISTS webhook_executions (
 		id            BIGSERIAL PRIMARY KEY,
 		timestamp     TIMESTAMP NOT NULL DEFAULT NOW(),
@@ -173,46 +309,197 @@ var postgresCreateStatements = []string{
 	)`,
 	`CREATE INDEX IF NOT EXISTS idx_webhook_executions_name ON webhook_executions(webhook_name)`,
 	`CREATE INDEX IF NOT EXISTS idx_webhook_executions_timestamp ON webhook_executions(timestamp)`,
+	`CREATE TABLE IF NOT EXISTS queued_requests (
+		id              BIGSERIAL PRIMARY KEY,
+		timestamp       TIMESTAMP NOT NULL DEFAULT NOW(),
+		agent_name      TEXT NOT NULL DEFAULT '',
+		model           TEXT NOT NULL,
+		priority        INTEGER NOT NULL DEFAULT 0,
+		body            TEXT NOT NULL DEFAULT '',
+		callback_url    TEXT NOT NULL DEFAULT '',
+		status          TEXT NOT NULL DEFAULT 'queued',
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMP NOT NULL,
+		status_code     INTEGER NOT NULL DEFAULT 0,
+		response        TEXT NOT NULL DEFAULT '',
+		error           TEXT NOT NULL DEFAULT '',
+		callback_code   INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_queued_requests_due ON queued_requests(status, next_attempt_at)`,
+}
+
+// mysqlCreateStatements is the MySQL schema, executed one statement at a
+// time. It already has every column the migrations add. Timestamps are
+// stored as text in the same format as SQLite, so range filters compare
+// strings; identifiers are VARCHAR so they can be indexed.
+var mysqlCreateStatements = []string{
+	`CREATE TABLE IF NOT EXISTS requests (
+		id                 BIGINT AUTO_INCREMENT PRIMARY KEY,
+		timestamp          VARCHAR(32) NOT NULL,
+		agent_name         VARCHAR(255) NOT NULL DEFAULT '',
+		model              VARCHAR(255) NOT NULL,
+		provider           VARCHAR(255) NOT NULL,
+		input_tokens       INT NOT NULL DEFAULT 0,
+		output_tokens      INT NOT NULL DEFAULT 0,
+		cost_usd           DOUBLE NOT NULL DEFAULT 0,
+		duration_ms        BIGINT NOT NULL DEFAULT 0,
+		status_code        INT NOT NULL DEFAULT 200,
+		failover_from      VARCHAR(255) NOT NULL DEFAULT '',
+		original_model     VARCHAR(255) NOT NULL DEFAULT '',
+		reasoning_tokens   INT NOT NULL DEFAULT 0,
+		request_id         VARCHAR(255) NOT NULL DEFAULT '',
+		ttft_ms            BIGINT NOT NULL DEFAULT 0,
+		tokens_per_sec     DOUBLE NOT NULL DEFAULT 0,
+		request_type       VARCHAR(32) NOT NULL DEFAULT 'chat',
+		cache_write_tokens INT NOT NULL DEFAULT 0,
+		cache_read_tokens  INT NOT NULL DEFAULT 0,
+		archive_key        VARCHAR(255) NOT NULL DEFAULT '',
+		tags               VARCHAR(2048) NOT NULL DEFAULT '',
+		project            VARCHAR(255) NOT NULL DEFAULT '',
+		INDEX idx_requests_timestamp (timestamp),
+		INDEX idx_requests_agent (agent_name),
+		INDEX idx_requests_model (model),
+		INDEX idx_requests_failover_from (failover_from),
+		INDEX idx_requests_original_model (original_model),
+		INDEX idx_requests_request_id (request_id),
+		INDEX idx_requests_project (project)
+	)`,
+	`CREATE TABLE IF NOT EXISTS traces (
+		id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+		trace_id   VARCHAR(255) NOT NULL UNIQUE,
+		agent_name VARCHAR(255) NOT NULL DEFAULT '',
+		model      VARCHAR(255) NOT NULL DEFAULT '',
+		timestamp  VARCHAR(32) NOT NULL,
+		spans      MEDIUMTEXT NOT NULL DEFAULT ('[]'),
+		request_id VARCHAR(255) NOT NULL DEFAULT '',
+		INDEX idx_traces_timestamp (timestamp),
+		INDEX idx_traces_request_id (request_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS audit_events (
+		id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+		timestamp  VARCHAR(32) NOT NULL,
+		event_type VARCHAR(64) NOT NULL,
+		agent_name VARCHAR(255) NOT NULL DEFAULT '',
+		details    MEDIUMTEXT NOT NULL DEFAULT ('{}'),
+		request_id VARCHAR(255) NOT NULL DEFAULT '',
+		INDEX idx_audit_events_timestamp (timestamp),
+		INDEX idx_audit_events_type (event_type),
+		INDEX idx_audit_events_agent (agent_name),
+		INDEX idx_audit_events_request_id (request_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS legal_holds (
+		id        BIGINT AUTO_INCREMENT PRIMARY KEY,
+		timestamp VARCHAR(32) NOT NULL,
+		kind      VARCHAR(32) NOT NULL,
+		value     VARCHAR(255) NOT NULL,
+		reason    TEXT NOT NULL DEFAULT (''),
+		UNIQUE(kind, value)
+	)`,
+	`CREATE TABLE IF NOT EXISTS webhook_executions (
+		id            BIGINT AUTO_INCREMENT PRIMARY KEY,
+		timestamp     VARCHAR(32) NOT NULL,
+		webhook_name  VARCHAR(255) NOT NULL,
+		status        VARCHAR(32) NOT NULL DEFAULT 'pending',
+		payload       MEDIUMTEXT NOT NULL DEFAULT (''),
+		result        MEDIUMTEXT NOT NULL DEFAULT (''),
+		error         TEXT NOT NULL DEFAULT (''),
+		duration_ms   BIGINT NOT NULL DEFAULT 0,
+		callback_code INT NOT NULL DEFAULT 0,
+		INDEX idx_webhook_executions_name (webhook_name),
+		INDEX idx_webhook_executions_timestamp (timestamp)
+	)`,
+	`CREATE TABLE IF NOT EXISTS queued_requests (
+		id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+		timestamp       VARCHAR(32) NOT NULL,
+		agent_name      VARCHAR(255) NOT NULL DEFAULT '',
+		model           VARCHAR(255) NOT NULL,
+		priority        INT NOT NULL DEFAULT 0,
+		body            MEDIUMTEXT NOT NULL DEFAULT (''),
+		callback_url    TEXT NOT NULL DEFAULT (''),
+		status          VARCHAR(32) NOT NULL DEFAULT 'queued',
+		attempts        INT NOT NULL DEFAULT 0,
+		next_attempt_at VARCHAR(32) NOT NULL,
+		status_code     INT NOT NULL DEFAULT 0,
+		response        MEDIUMTEXT NOT NULL DEFAULT (''),
+		error           TEXT NOT NULL DEFAULT (''),
+		callback_code   INT NOT NULL DEFAULT 0,
+		INDEX idx_queued_requests_due (status, next_attempt_at)
+	)`,
 }
 
 // New creates a new Store and initializes the schema.
-func New(dsn string) (*Store, error) {
+func New(dsn string, opts ...Option) (*Store, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	db, dialect, err := openSchema(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		db:             db,
+		dialect:        dialect,
+		recordCh:       make(chan *Record, 256),
+		done:           make(chan struct{}),
+		content:        db,
+		contentDialect: dialect,
+		spillRetry:     10 * time.Second,
+	}
+	if o.spillFile != "" {
+		s.spill = newSpill(o.spillFile)
+	}
+	if o.contentDSN != "" && o.contentDSN != dsn {
+		// The content database gets the full schema so migrations stay in
+		// one place; tables it does not use stay empty.
+		s.content, s.contentDialect, err = openSchema(o.contentDSN)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("content database: %w", err)
+		}
+	}
+	go s.batchWriter()
+	return s, nil
+}
+
+// openSchema opens dsn and creates or migrates the schema.
+func openSchema(dsn string) (*sql.DB, Dialect, error) {
 	db, dialect, err := OpenDB(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("open database: %w", err)
+		return nil, dialect, fmt.Errorf("open database: %w", err)
 	}
 
 	if err := createSchema(db, dialect); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("create schema: %w", err)
+		return nil, dialect, fmt.Errorf("create schema: %w", err)
 	}
 
 	if err := migrateSchema(db, dialect); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("migrate schema: %w", err)
+		return nil, dialect, fmt.Errorf("migrate schema: %w", err)
 	}
-
-	s := &Store{
-		db:       db,
-		dialect:  dialect,
-		recordCh: make(chan *Record, 256),
-		done:     make(chan struct{}),
-	}
-	go s.batchWriter()
-	return s, nil
+	return db, dialect, nil
 }
 
 func createSchema(db *sql.DB, dialect Dialect) error {
-	if dialect == DialectPostgres {
-		for _, stmt := range postgresCreateStatements {
-			if _, err := db.Exec(stmt); err != nil {
-				return fmt.Errorf("exec DDL: %w", err)
-			}
+	var stmts []string
+	switch dialect {
+	case DialectPostgres:
+		stmts = postgresCreateStatements
+	case DialectMySQL:
+		stmts = mysqlCreateStatements
+	default:
+		_, err := db.Exec(createTableSQLite)
+		return err
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("exec DDL: %w", err)
 		}
-		return nil
 	}
-	_, err := db.Exec(createTableSQLite)
-	return err
+	return nil
 }
 
 // Dialect returns the dialect used by this store.
@@ -225,25 +512,71 @@ func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
-// Close flushes pending async writes and closes the database connection.
+// ContentDB returns the database holding traces, audit events and cache
+// entries. It is DB unless a content DSN is configured.
+func (s *Store) ContentDB() *sql.DB {
+	return s.content
+}
+
+// ContentDialect returns the dialect of ContentDB.
+func (s *Store) ContentDialect() Dialect {
+	return s.contentDialect
+}
+
+// Close flushes pending async writes and closes the database connections.
 func (s *Store) Close() error {
 	close(s.recordCh)
 	<-s.done
+	if s.content != s.db {
+		s.content.Close()
+	}
 	return s.db.Close()
 }
 
 // InsertAsync queues a record for asynchronous batch insertion.
-// If the channel is full, it falls back to a synchronous insert.
+// If the channel is full, it falls back to a synchronous insert, and if
+// that fails too the record is spilled to disk.
 func (s *Store) InsertAsync(r *Record) {
 	select {
 	case s.recordCh <- r:
 	default:
 		if err := s.Insert(r); err != nil {
 			log.Printf("ERROR: async fallback insert failed: %v", err)
+			s.spillRecords([]*Record{r})
 		}
 	}
 }
 
+// write inserts a batch, spilling it to disk if the database rejects it.
+func (s *Store) write(records []*Record) {
+	if err := s.insertBatch(records); err != nil {
+		log.Printf("ERROR: batch insert of %d records: %v", len(records), err)
+		s.spillRecords(records)
+	}
+}
+
+func (s *Store) spillRecords(records []*Record) {
+	if s.spill == nil {
+		return
+	}
+	if err := s.spill.append(records); err != nil {
+		log.Printf("ERROR: spill %d records to %s: %v (records lost)", len(records), s.spill.path, err)
+		return
+	}
+	log.Printf("WARN: spilled %d records to %s; they are replayed when the database recovers", len(records), s.spill.path)
+}
+
+// Spilled reports whether records are waiting on disk for the database to
+// recover.
+func (s *Store) Spilled() bool {
+	return s.spill != nil && s.spill.hasPending()
+}
+
+// Backlog returns the number of records queued for async insertion.
+func (s *Store) Backlog() int {
+	return len(s.recordCh)
+}
+
 // batchWriter drains the record channel, flushing in batches of up to 50
 // or after 1 second of inactivity.
 func (s *Store) batchWriter() {
@@ -253,60 +586,74 @@ func (s *Store) batchWriter() {
 	buf := make([]*Record, 0, maxBatch)
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
+	var nextReplay time.Time
 
 	for {
 		select {
 		case r, ok := <-s.recordCh:
 			if !ok {
-				// Channel closed — flush remaining records.
+				// Channel closed — flush remaining records and make a
+				// last attempt at anything spilled.
 				if len(buf) > 0 {
-					s.insertBatch(buf)
+					s.write(buf)
+				}
+				if s.Spilled() {
+					if err := s.replaySpill(); err != nil {
+						log.Printf("ERROR: replay spilled records: %v", err)
+					}
 				}
 				return
 			}
 			buf = append(buf, r)
 			if len(buf) >= maxBatch {
-				s.insertBatch(buf)
+				s.write(buf)
 				buf = buf[:0]
 			}
-		case <-ticker.C:
+		case now := <-ticker.C:
 			if len(buf) > 0 {
-				s.insertBatch(buf)
+				s.write(buf)
 				buf = buf[:0]
 			}
+			if s.Spilled() && !now.Before(nextReplay) {
+				if err := s.replaySpill(); err != nil {
+					log.Printf("ERROR: replay spilled records: %v", err)
+				}
+				nextReplay = now.Add(s.spillRetry)
+			}
 		}
 	}
 }
 
-const insertRequestSQL = `INSERT INTO requests (timestamp, agent_name, model, provider, input_tokens, output_tokens, cost_usd, duration_ms, status_code, failover_from, original_model)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+const insertRequestSQL = `INSERT INTO requests (timestamp, agent_name, model, provider, input_tokens, output_tokens, cost_usd, duration_ms, status_code, failover_from, original_model, reasoning_tokens, request_id, ttft_ms, tokens_per_sec, request_type, cache_write_tokens, cache_read_tokens, archive_key, tags, project)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-// insertBatch inserts multiple records in a single transaction.
-func (s *Store) insertBatch(records []*Record) {
+// insertBatch inserts multiple records in a single transaction. Either
+// all of them are written or none are.
+func (s *Store) insertBatch(records []*Record) error {
 	tx, err := s.db.Begin()
 	if err != nil {
-		log.Printf("ERROR: begin batch tx: %v", err)
-		return
+		return fmt.Errorf("begin batch tx: %w", err)
 	}
 
 	stmt, err := tx.Prepare(Rebind(s.dialect, insertRequestSQL))
 	if err != nil {
-		log.Printf("ERROR: prepare batch stmt: %v", err)
 		tx.Rollback()
-		return
+		return fmt.Errorf("prepare batch stmt: %w", err)
 	}
 	defer stmt.Close()
 
 	for _, r := range records {
 		ts := fmtTime(r.Timestamp)
-		if _, err := stmt.Exec(ts, r.AgentName, r.Model, r.Provider, r.InputTokens, r.OutputTokens, r.CostUSD, r.DurationMS, r.StatusCode, r.FailoverFrom, r.OriginalModel); err != nil {
-			log.Printf("ERROR: batch insert record: %v", err)
+		if _, err := stmt.Exec(ts, r.AgentName, r.Model, r.Provider, r.InputTokens, r.OutputTokens, r.CostUSD, r.DurationMS, r.StatusCode, r.FailoverFrom, r.OriginalModel, r.ReasoningTokens, r.RequestID, r.TTFTMS, r.TokensPerSec, r.requestType(), r.CacheWriteTokens, r.CacheReadTokens, r.ArchiveKey, r.Tags, r.Project); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("batch insert record: %w", err)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		log.Printf("ERROR: commit batch tx: %v", err)
+		return fmt.Errorf("commit batch tx: %w", err)
 	}
+	return nil
 }
 
 // Insert records a new API call.
@@ -314,7 +661,7 @@ func (s *Store) Insert(r *Record) error {
 	ts := fmtTime(r.Timestamp)
 	_, err := s.db.Exec(
 		Rebind(s.dialect, insertRequestSQL),
-		ts, r.AgentName, r.Model, r.Provider, r.InputTokens, r.OutputTokens, r.CostUSD, r.DurationMS, r.StatusCode, r.FailoverFrom, r.OriginalModel,
+		ts, r.AgentName, r.Model, r.Provider, r.InputTokens, r.OutputTokens, r.CostUSD, r.DurationMS, r.StatusCode, r.FailoverFrom, r.OriginalModel, r.ReasoningTokens, r.RequestID, r.TTFTMS, r.TokensPerSec, r.requestType(), r.CacheWriteTokens, r.CacheReadTokens, r.ArchiveKey, r.Tags, r.Project,
 	)
 	if err != nil {
 		return fmt.Errorf("insert record: %w", err)
@@ -322,10 +669,70 @@ func (s *Store) Insert(r *Record) error {
 	return nil
 }
 
+// columnMigration is a column added to an existing table.
+type columnMigration struct{ column, definition string }
+
+// requestColumnMigrations returns the requests columns that postdate both
+// dialects' DDL: streaming latency, request type, prompt cache, archive,
+// tag and project. MySQL gets the VARCHAR types of its DDL, since it cannot
+// index TEXT columns or give them literal defaults.
+func requestColumnMigrations(dialect Dialect) []columnMigration {
+	integer, float, text, tags, kind := "INTEGER", "REAL", "TEXT", "TEXT", "TEXT"
+	switch dialect {
+	case DialectPostgres:
+		float = "DOUBLE PRECISION"
+	case DialectMySQL:
+		integer, float = "INT", "DOUBLE"
+		text, tags, kind = "VARCHAR(255)", "VARCHAR(2048)", "VARCHAR(32)"
+	}
+	return []columnMigration{
+		{"ttft_ms", "BIGINT NOT NULL DEFAULT 0"},
+		{"tokens_per_sec", float + " NOT NULL DEFAULT 0"},
+		{"request_type", kind + " NOT NULL DEFAULT 'chat'"},
+		{"cache_write_tokens", integer + " NOT NULL DEFAULT 0"},
+		{"cache_read_tokens", integer + " NOT NULL DEFAULT 0"},
+		{"archive_key", text + " NOT NULL DEFAULT ''"},
+		{"tags", tags + " NOT NULL DEFAULT ''"},
+		{"project", text + " NOT NULL DEFAULT ''"},
+	}
+}
+
 // migrateSchema adds columns that may not exist in older databases.
 func migrateSchema(db *sql.DB, dialect Dialect) error {
-	// PostgreSQL DDL already includes these columns, so migration is only needed for SQLite.
-	if dialect == DialectPostgres {
+	text := "TEXT"
+	if dialect == DialectMySQL {
+		text = "VARCHAR(255)"
+	}
+
+	// Request IDs correlate requests, traces and audit events. Databases
+	// created before them lack the column in every dialect.
+	for _, table := range []string{"requests", "traces", "audit_events"} {
+		if !ColumnExists(db, table, "request_id", dialect) {
+			stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN request_id %s NOT NULL DEFAULT ''", table, text)
+			if _, err := db.Exec(stmt); err != nil {
+				return fmt.Errorf("add column %s.request_id: %w", table, err)
+			}
+		}
+		if err := CreateIndex(db, dialect, "idx_"+table+"_request_id", table, "request_id"); err != nil {
+			return fmt.Errorf("create index: %w", err)
+		}
+	}
+
+	for _, m := range requestColumnMigrations(dialect) {
+		if !ColumnExists(db, "requests", m.column, dialect) {
+			stmt := fmt.Sprintf("ALTER TABLE requests ADD COLUMN %s %s", m.column, m.definition)
+			if _, err := db.Exec(stmt); err != nil {
+				return fmt.Errorf("add column %s: %w", m.column, err)
+			}
+		}
+	}
+	if err := CreateIndex(db, dialect, "idx_requests_project", "requests", "project"); err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+
+	// PostgreSQL and MySQL DDL already include the remaining columns, so
+	// migration is only needed for SQLite.
+	if dialect != DialectSQLite {
 		return nil
 	}
 
@@ -335,20 +742,56 @@ func migrateSchema(db *sql.DB, dialect Dialect) error {
 	}{
 		{"failover_from", "TEXT NOT NULL DEFAULT ''"},
 		{"original_model", "TEXT NOT NULL DEFAULT ''"},
+		{"reasoning_tokens", "INTEGER NOT NULL DEFAULT 0"},
 	}
 
 	for _, m := range migrations {
-		if !columnExists(db, "requests", m.column, dialect) {
+		if !ColumnExists(db, "requests", m.column, dialect) {
 			stmt := fmt.Sprintf("ALTER TABLE requests ADD COLUMN %s %s", m.column, m.definition)
 			if _, err := db.Exec(stmt); err != nil {
 				return fmt.Errorf("add column %s: %w", m.column, err)
 			}
 		}
 	}
+
+	// Indexes on migrated columns can only be created once the columns exist.
+	indexes := []string{
+		`CREATE INDEX IF NOT EXISTS idx_requests_failover_from ON requests(failover_from)`,
+		`CREATE INDEX IF NOT EXISTS idx_requests_original_model ON requests(original_model)`,
+	}
+	for _, stmt := range indexes {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("create index: %w", err)
+		}
+	}
+
+	// Index content logged before the full-text table existed.
+	var indexed int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM audit_content_fts`).Scan(&indexed); err != nil {
+		return fmt.Errorf("count audit search index: %w", err)
+	}
+	if indexed == 0 {
+		_, err := db.Exec(`INSERT INTO audit_content_fts(rowid, body)
+			SELECT id, COALESCE(json_extract(details, '$.body'), '') FROM audit_events
+			WHERE event_type = 'content_log' AND json_valid(details)`)
+		if err != nil {
+			return fmt.Errorf("backfill audit search index: %w", err)
+		}
+	}
 	return nil
 }
 
-func columnExists(db *sql.DB, table, column string, dialect Dialect) bool {
+// ColumnExists reports whether table has the given column. Packages that own
+// their tables use it to add columns to existing databases.
+func ColumnExists(db *sql.DB, table, column string, dialect Dialect) bool {
+	if dialect == DialectMySQL {
+		var n int
+		err := db.QueryRow(
+			`SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`,
+			table, column,
+		).Scan(&n)
+		return err == nil && n > 0
+	}
 	if dialect == DialectPostgres {
 		var exists bool
 		err := db.QueryRow(
@@ -441,6 +884,96 @@ func (s *Store) QueryStatsByAgent(since, until time.Time) ([]AgentStats, error)
 	return results, rows.Err()
 }
 
+// ProjectStats represents per-project statistics.
+type ProjectStats struct {
+	Project      string  `json:"project"`
+	Agents       int     `json:"agents"`
+	Requests     int     `json:"requests"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// UnassignedProject is reported for requests with no project.
+const UnassignedProject = "(unassigned)"
+
+// QueryStatsByProject returns stats grouped by project, most expensive
+// first.
+func (s *Store) QueryStatsByProject(since, until time.Time) ([]ProjectStats, error) {
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT
+			CASE WHEN project = '' THEN '`+UnassignedProject+`' ELSE project END,
+			COUNT(DISTINCT agent_name),
+			COUNT(*),
+			COALESCE(SUM(input_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(cost_usd), 0)
+		 FROM requests
+		 WHERE timestamp >= ? AND timestamp <= ?
+		 GROUP BY project
+		 ORDER BY SUM(cost_usd) DESC`),
+		fmtTime(since), fmtTime(until),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query project stats: %w", err)
+	}
+	defer rows.Close()
+
+	var results []ProjectStats
+	for rows.Next() {
+		var p ProjectStats
+		if err := rows.Scan(&p.Project, &p.Agents, &p.Requests, &p.InputTokens, &p.OutputTokens, &p.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan project stats: %w", err)
+		}
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
+
+// TagStats is usage for one combination of tags, agent and model.
+type TagStats struct {
+	Tags         string
+	AgentName    string
+	Model        string
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+}
+
+// QueryTagStats returns usage grouped by tags, agent and model, for
+// filtering and grouping by tag in Go (tags are stored as one string).
+func (s *Store) QueryTagStats(since, until time.Time) ([]TagStats, error) {
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT
+			tags,
+			agent_name,
+			model,
+			COUNT(*),
+			COALESCE(SUM(input_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(cost_usd), 0)
+		 FROM requests
+		 WHERE timestamp >= ? AND timestamp <= ?
+		 GROUP BY tags, agent_name, model`),
+		fmtTime(since), fmtTime(until),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query tag stats: %w", err)
+	}
+	defer rows.Close()
+
+	var results []TagStats
+	for rows.Next() {
+		var t TagStats
+		if err := rows.Scan(&t.Tags, &t.AgentName, &t.Model, &t.Requests, &t.InputTokens, &t.OutputTokens, &t.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan tag stats: %w", err)
+		}
+		results = append(results, t)
+	}
+	return results, rows.Err()
+}
+
 // QueryStatsByModel returns stats grouped by model.
 func (s *Store) QueryStatsByModel(since, until time.Time) ([]ModelStats, error) {
 	rows, err := s.db.Query(
@@ -473,9 +1006,50 @@ func (s *Store) QueryStatsByModel(since, until time.Time) ([]ModelStats, error)
 	return results, rows.Err()
 }
 
+// QueryGroupMetrics returns metrics grouped by "agent" or "model".
+func (s *Store) QueryGroupMetrics(groupBy string, since, until time.Time) ([]GroupMetrics, error) {
+	var key string
+	switch groupBy {
+	case "agent":
+		key = "CASE WHEN agent_name = '' THEN '(unknown)' ELSE agent_name END"
+	case "model":
+		key = "model"
+	default:
+		return nil, fmt.Errorf("unknown group %q (want agent or model)", groupBy)
+	}
+
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT
+			`+key+`,
+			COUNT(*),
+			COALESCE(SUM(cost_usd), 0),
+			COALESCE(AVG(duration_ms), 0),
+			COALESCE(SUM(CASE WHEN status_code >= 400 THEN 1 ELSE 0 END), 0)
+		 FROM requests
+		 WHERE timestamp >= ? AND timestamp <= ?
+		 GROUP BY `+key+`
+		 ORDER BY SUM(cost_usd) DESC`),
+		fmtTime(since), fmtTime(until),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query %s metrics: %w", groupBy, err)
+	}
+	defer rows.Close()
+
+	var results []GroupMetrics
+	for rows.Next() {
+		var g GroupMetrics
+		if err := rows.Scan(&g.Key, &g.Requests, &g.CostUSD, &g.AvgDurationMS, &g.Errors); err != nil {
+			return nil, fmt.Errorf("scan %s metrics: %w", groupBy, err)
+		}
+		results = append(results, g)
+	}
+	return results, rows.Err()
+}
+
 // QueryRecentRequests returns the most recent N requests.
 func (s *Store) QueryRecentRequests(limit int, agentFilter string) ([]Record, error) {
-	query := `SELECT id, timestamp, agent_name, model, provider, input_tokens, output_tokens, cost_usd, duration_ms, status_code
+	query := `SELECT id, timestamp, agent_name, model, provider, input_tokens, output_tokens, cost_usd, duration_ms, status_code, reasoning_tokens, request_id, ttft_ms, tokens_per_sec, request_type, cache_write_tokens, cache_read_tokens
 		 FROM requests`
 	args := []any{}
 
@@ -497,7 +1071,7 @@ func (s *Store) QueryRecentRequests(limit int, agentFilter string) ([]Record, er
 	for rows.Next() {
 		var r Record
 		var ts string
-		if err := rows.Scan(&r.ID, &ts, &r.AgentName, &r.Model, &r.Provider, &r.InputTokens, &r.OutputTokens, &r.CostUSD, &r.DurationMS, &r.StatusCode); err != nil {
+		if err := rows.Scan(&r.ID, &ts, &r.AgentName, &r.Model, &r.Provider, &r.InputTokens, &r.OutputTokens, &r.CostUSD, &r.DurationMS, &r.StatusCode, &r.ReasoningTokens, &r.RequestID, &r.TTFTMS, &r.TokensPerSec, &r.RequestType, &r.CacheWriteTokens, &r.CacheReadTokens); err != nil {
 			return nil, fmt.Errorf("scan record: %w", err)
 		}
 		r.Timestamp, _ = time.Parse("2006-01-02T15:04:05Z", ts)
@@ -506,11 +1080,43 @@ func (s *Store) QueryRecentRequests(limit int, agentFilter string) ([]Record, er
 	return results, rows.Err()
 }
 
+// QueryRequestsByRequestID returns the rows recorded for one request ID,
+// oldest first. A request can produce several rows, e.g. one per tool-loop
+// round.
+func (s *Store) QueryRequestsByRequestID(requestID string) ([]Record, error) {
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT id, timestamp, agent_name, model, provider, input_tokens, output_tokens, cost_usd, duration_ms, status_code, failover_from, original_model, reasoning_tokens, request_id, ttft_ms, tokens_per_sec, request_type, cache_write_tokens, cache_read_tokens, archive_key
+		 FROM requests
+		 WHERE request_id = ?
+		 ORDER BY id ASC`),
+		requestID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query requests by request ID: %w", err)
+	}
+	defer rows.Close()
+
+	var results []Record
+	for rows.Next() {
+		var r Record
+		var ts string
+		if err := rows.Scan(&r.ID, &ts, &r.AgentName, &r.Model, &r.Provider, &r.InputTokens, &r.OutputTokens, &r.CostUSD, &r.DurationMS, &r.StatusCode, &r.FailoverFrom, &r.OriginalModel, &r.ReasoningTokens, &r.RequestID, &r.TTFTMS, &r.TokensPerSec, &r.RequestType, &r.CacheWriteTokens, &r.CacheReadTokens, &r.ArchiveKey); err != nil {
+			return nil, fmt.Errorf("scan record: %w", err)
+		}
+		r.Timestamp, _ = time.Parse(timeFormat, ts)
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
 // QueryDailyCosts returns daily cost totals for the given period.
 func (s *Store) QueryDailyCosts(since, until time.Time) ([]DailyCost, error) {
 	dateExpr := "date(timestamp)"
-	if s.dialect == DialectPostgres {
+	switch s.dialect {
+	case DialectPostgres:
 		dateExpr = "timestamp::date"
+	case DialectMySQL:
+		dateExpr = "LEFT(timestamp, 10)"
 	}
 	query := fmt.Sprintf(`SELECT
 			%s as day,
@@ -540,6 +1146,60 @@ func (s *Store) QueryDailyCosts(since, until time.Time) ([]DailyCost, error) {
 	return results, rows.Err()
 }
 
+// ModelChange represents aggregated requests whose model was changed by the
+// gateway (via failover or routing), grouped by requested → actual model.
+type ModelChange struct {
+	FromModel    string  `json:"from_model"`
+	ToModel      string  `json:"to_model"`
+	Requests     int     `json:"requests"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// QueryFailoverStats returns requests served by a fallback model, grouped by
+// the model that failed and the model that answered.
+func (s *Store) QueryFailoverStats(since, until time.Time) ([]ModelChange, error) {
+	return s.queryModelChanges("failover_from", since, until)
+}
+
+// QueryRoutingStats returns requests whose model was rewritten by smart routing
+// or experiments, grouped by the requested model and the model actually used.
+func (s *Store) QueryRoutingStats(since, until time.Time) ([]ModelChange, error) {
+	return s.queryModelChanges("original_model", since, until)
+}
+
+// queryModelChanges aggregates requests where fromColumn is set. fromColumn is
+// always a constant supplied by the caller, never user input.
+func (s *Store) queryModelChanges(fromColumn string, since, until time.Time) ([]ModelChange, error) {
+	query := fmt.Sprintf(`SELECT
+			%[1]s,
+			model,
+			COUNT(*),
+			COALESCE(SUM(input_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(cost_usd), 0)
+		 FROM requests
+		 WHERE %[1]s != '' AND timestamp >= ? AND timestamp <= ?
+		 GROUP BY %[1]s, model
+		 ORDER BY COUNT(*) DESC`, fromColumn)
+	rows, err := s.db.Query(Rebind(s.dialect, query), fmtTime(since), fmtTime(until))
+	if err != nil {
+		return nil, fmt.Errorf("query model changes: %w", err)
+	}
+	defer rows.Close()
+
+	var results []ModelChange
+	for rows.Next() {
+		var mc ModelChange
+		if err := rows.Scan(&mc.FromModel, &mc.ToModel, &mc.Requests, &mc.InputTokens, &mc.OutputTokens, &mc.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan model change: %w", err)
+		}
+		results = append(results, mc)
+	}
+	return results, rows.Err()
+}
+
 // DailyCost represents aggregated costs for a single day.
 type DailyCost struct {
 	Date     string  `json:"date"`
@@ -551,8 +1211,11 @@ type DailyCost struct {
 func (s *Store) QueryAgentDailySpend(agent string, day time.Time) (float64, error) {
 	dateStr := day.Format("2006-01-02")
 	dateExpr := "date(timestamp)"
-	if s.dialect == DialectPostgres {
+	switch s.dialect {
+	case DialectPostgres:
 		dateExpr = "timestamp::date"
+	case DialectMySQL:
+		dateExpr = "LEFT(timestamp, 10)"
 	}
 	query := fmt.Sprintf(`SELECT COALESCE(SUM(cost_usd), 0) FROM requests
 		 WHERE agent_name = ? AND %s = ?`, dateExpr)
@@ -580,9 +1243,56 @@ func (s *Store) QueryAgentMonthlySpend(agent string, year int, month time.Month)
 	return cost, nil
 }
 
+// SpendFilter selects the requests QuerySpend adds up. Empty fields match
+// every request.
+type SpendFilter struct {
+	Agent    string
+	Provider string
+	Model    string
+}
+
+// QuerySpend returns the total spend of the requests matching f in
+// [from, to).
+func (s *Store) QuerySpend(f SpendFilter, from, to time.Time) (float64, error) {
+	query := `SELECT COALESCE(SUM(cost_usd), 0) FROM requests WHERE timestamp >= ? AND timestamp < ?`
+	args := []any{fmtTime(from), fmtTime(to)}
+	if f.Agent != "" {
+		query += ` AND agent_name = ?`
+		args = append(args, f.Agent)
+	}
+	if f.Provider != "" {
+		query += ` AND provider = ?`
+		args = append(args, f.Provider)
+	}
+	if f.Model != "" {
+		query += ` AND model = ?`
+		args = append(args, f.Model)
+	}
+	var cost float64
+	if err := s.db.QueryRow(Rebind(s.dialect, query), args...).Scan(&cost); err != nil {
+		return 0, fmt.Errorf("query spend: %w", err)
+	}
+	return cost, nil
+}
+
+// QueryAgentSpendSince returns the total spend for an agent since t.
+func (s *Store) QueryAgentSpendSince(agent string, t time.Time) (float64, error) {
+	row := s.db.QueryRow(
+		Rebind(s.dialect, `SELECT COALESCE(SUM(cost_usd), 0) FROM requests
+		 WHERE agent_name = ? AND timestamp >= ?`),
+		agent, fmtTime(t),
+	)
+	var cost float64
+	if err := row.Scan(&cost); err != nil {
+		return 0, fmt.Errorf("query agent spend: %w", err)
+	}
+	return cost, nil
+}
+
 // TraceRecord represents a stored request trace.
 type TraceRecord struct {
 	TraceID   string `json:"trace_id"`
+	RequestID string `json:"request_id,omitempty"`
 	AgentName string `json:"agent_name"`
 	Model     string `json:"model"`
 	Timestamp time.Time `json:"timestamp"`
@@ -590,10 +1300,10 @@ type TraceRecord struct {
 }
 
 // InsertTrace stores a trace record.
-func (s *Store) InsertTrace(traceID, agentName, model string, timestamp time.Time, spansJSON []byte) error {
-	_, err := s.db.Exec(
-		Rebind(s.dialect, `INSERT INTO traces (trace_id, agent_name, model, timestamp, spans) VALUES (?, ?, ?, ?, ?)`),
-		traceID, agentName, model, fmtTime(timestamp), string(spansJSON),
+func (s *Store) InsertTrace(traceID, requestID, agentName, model string, timestamp time.Time, spansJSON []byte) error {
+	_, err := s.content.Exec(
+		Rebind(s.contentDialect, `INSERT INTO traces (trace_id, request_id, agent_name, model, timestamp, spans) VALUES (?, ?, ?, ?, ?, ?)`),
+		traceID, requestID, agentName, model, fmtTime(timestamp), string(spansJSON),
 	)
 	if err != nil {
 		return fmt.Errorf("insert trace: %w", err)
@@ -603,13 +1313,24 @@ func (s *Store) InsertTrace(traceID, agentName, model string, timestamp time.Tim
 
 // QueryTrace returns a single trace by its trace ID.
 func (s *Store) QueryTrace(traceID string) (*TraceRecord, error) {
-	row := s.db.QueryRow(
-		Rebind(s.dialect, `SELECT trace_id, agent_name, model, timestamp, spans FROM traces WHERE trace_id = ?`),
-		traceID,
+	return s.queryTrace("trace_id", traceID)
+}
+
+// QueryTraceByRequestID returns the trace recorded for a request ID, or nil
+// if the request was not traced.
+func (s *Store) QueryTraceByRequestID(requestID string) (*TraceRecord, error) {
+	return s.queryTrace("request_id", requestID)
+}
+
+func (s *Store) queryTrace(column, value string) (*TraceRecord, error) {
+	row := s.content.QueryRow(
+		Rebind(s.contentDialect, `SELECT trace_id, request_id, agent_name, model, timestamp, spans FROM traces WHERE `+column+` = ?
+		 ORDER BY timestamp DESC LIMIT 1`),
+		value,
 	)
 	var tr TraceRecord
 	var ts, spans string
-	if err := row.Scan(&tr.TraceID, &tr.AgentName, &tr.Model, &ts, &spans); err != nil {
+	if err := row.Scan(&tr.TraceID, &tr.RequestID, &tr.AgentName, &tr.Model, &ts, &spans); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -622,7 +1343,7 @@ func (s *Store) QueryTrace(traceID string) (*TraceRecord, error) {
 
 // QueryRecentTraces returns the most recent N traces, optionally filtered by agent.
 func (s *Store) QueryRecentTraces(limit int, agentFilter string) ([]TraceRecord, error) {
-	query := `SELECT trace_id, agent_name, model, timestamp, spans FROM traces`
+	query := `SELECT trace_id, request_id, agent_name, model, timestamp, spans FROM traces`
 	args := []any{}
 
 	if agentFilter != "" {
@@ -633,7 +1354,7 @@ func (s *Store) QueryRecentTraces(limit int, agentFilter string) ([]TraceRecord,
 	query += ` ORDER BY timestamp DESC LIMIT ?`
 	args = append(args, limit)
 
-	rows, err := s.db.Query(Rebind(s.dialect, query), args...)
+	rows, err := s.content.Query(Rebind(s.contentDialect, query), args...)
 	if err != nil {
 		return nil, fmt.Errorf("query recent traces: %w", err)
 	}
@@ -643,7 +1364,7 @@ func (s *Store) QueryRecentTraces(limit int, agentFilter string) ([]TraceRecord,
 	for rows.Next() {
 		var tr TraceRecord
 		var ts, spans string
-		if err := rows.Scan(&tr.TraceID, &tr.AgentName, &tr.Model, &ts, &spans); err != nil {
+		if err := rows.Scan(&tr.TraceID, &tr.RequestID, &tr.AgentName, &tr.Model, &ts, &spans); err != nil {
 			return nil, fmt.Errorf("scan trace: %w", err)
 		}
 		tr.Timestamp, _ = time.Parse(timeFormat, ts)
@@ -733,10 +1454,135 @@ func (s *Store) QueryWebhookExecutions(limit int, nameFilter string) ([]WebhookE
 	return results, rows.Err()
 }
 
+// QueuedRequest is a chat completion parked while its providers were down,
+// waiting to be retried.
+type QueuedRequest struct {
+	ID            int64     `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	AgentName     string    `json:"agent_name"`
+	Model         string    `json:"model"`
+	Priority      int       `json:"priority"`
+	Body          string    `json:"-"`
+	CallbackURL   string    `json:"callback_url"`
+	Status        string    `json:"status"` // queued, completed, failed, expired
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	StatusCode    int       `json:"status_code"`
+	Response      string    `json:"-"`
+	Error         string    `json:"error"`
+	CallbackCode  int       `json:"callback_code"`
+}
+
+const queuedRequestColumns = `id, timestamp, agent_name, model, priority, body, callback_url, status, attempts, next_attempt_at, status_code, response, error, callback_code`
+
+// InsertQueuedRequest persists a request for later retry and returns its ID.
+func (s *Store) InsertQueuedRequest(q *QueuedRequest) (int64, error) {
+	args := []any{fmtTime(q.Timestamp), q.AgentName, q.Model, q.Priority, q.Body, q.CallbackURL, "queued", fmtTime(q.NextAttemptAt)}
+	if s.dialect == DialectPostgres {
+		var id int64
+		err := s.db.QueryRow(
+			`INSERT INTO queued_requests (timestamp, agent_name, model, priority, body, callback_url, status, next_attempt_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+			args...,
+		).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("insert queued request: %w", err)
+		}
+		return id, nil
+	}
+	result, err := s.db.Exec(
+		`INSERT INTO queued_requests (timestamp, agent_name, model, priority, body, callback_url, status, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		args...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert queued request: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// QueryDueQueuedRequests returns queued requests whose next attempt is due,
+// highest priority first, then oldest first.
+func (s *Store) QueryDueQueuedRequests(now time.Time, limit int) ([]QueuedRequest, error) {
+	rows, err := s.db.Query(
+		Rebind(s.dialect, `SELECT `+queuedRequestColumns+` FROM queued_requests
+		 WHERE status = 'queued' AND next_attempt_at <= ?
+		 ORDER BY priority DESC, id ASC LIMIT ?`),
+		fmtTime(now), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query due queued requests: %w", err)
+	}
+	defer rows.Close()
+
+	var results []QueuedRequest
+	for rows.Next() {
+		q, err := scanQueuedRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *q)
+	}
+	return results, rows.Err()
+}
+
+// QueryQueuedRequest returns a queued request by ID, or nil if not found.
+func (s *Store) QueryQueuedRequest(id int64) (*QueuedRequest, error) {
+	row := s.db.QueryRow(Rebind(s.dialect, `SELECT `+queuedRequestColumns+` FROM queued_requests WHERE id = ?`), id)
+	q, err := scanQueuedRequest(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// UpdateQueuedRequest records the outcome of a retry attempt.
+func (s *Store) UpdateQueuedRequest(q *QueuedRequest) error {
+	_, err := s.db.Exec(
+		Rebind(s.dialect, `UPDATE queued_requests SET status = ?, attempts = ?, next_attempt_at = ?, status_code = ?, response = ?, error = ?, callback_code = ? WHERE id = ?`),
+		q.Status, q.Attempts, fmtTime(q.NextAttemptAt), q.StatusCode, q.Response, q.Error, q.CallbackCode, q.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("update queued request: %w", err)
+	}
+	return nil
+}
+
+// CountQueuedRequests returns the number of requests still waiting for retry.
+func (s *Store) CountQueuedRequests() (int, error) {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM queued_requests WHERE status = 'queued'`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count queued requests: %w", err)
+	}
+	return n, nil
+}
+
+func scanQueuedRequest(row interface{ Scan(...any) error }) (*QueuedRequest, error) {
+	var q QueuedRequest
+	var ts, next string
+	err := row.Scan(&q.ID, &ts, &q.AgentName, &q.Model, &q.Priority, &q.Body, &q.CallbackURL, &q.Status, &q.Attempts, &next, &q.StatusCode, &q.Response, &q.Error, &q.CallbackCode)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan queued request: %w", err)
+	}
+	q.Timestamp, _ = time.Parse(timeFormat, ts)
+	q.NextAttemptAt, _ = time.Parse(timeFormat, next)
+	return &q, nil
+}
+
+// UpdateCost overwrites the recorded cost of request id, e.g. when costs
+// are recomputed against historical prices.
+func (s *Store) UpdateCost(id int64, costUSD float64) error {
+	if _, err := s.db.Exec(Rebind(s.dialect, `UPDATE requests SET cost_usd = ? WHERE id = ?`), costUSD, id); err != nil {
+		return fmt.Errorf("update cost: %w", err)
+	}
+	return nil
+}
+
 // ExportCSV returns all records in the time range for CSV export.
 func (s *Store) ExportCSV(since, until time.Time) ([]Record, error) {
 	rows, err := s.db.Query(
-		Rebind(s.dialect, `SELECT id, timestamp, agent_name, model, provider, input_tokens, output_tokens, cost_usd, duration_ms, status_code
+		Rebind(s.dialect, `SELECT id, timestamp, agent_name, model, provider, input_tokens, output_tokens, cost_usd, duration_ms, status_code, reasoning_tokens, cache_write_tokens, cache_read_tokens, tags, project
 		 FROM requests
 		 WHERE timestamp >= ? AND timestamp <= ?
 		 ORDER BY timestamp ASC`),
@@ -751,7 +1597,7 @@ func (s *Store) ExportCSV(since, until time.Time) ([]Record, error) {
 	for rows.Next() {
 		var r Record
 		var ts string
-		if err := rows.Scan(&r.ID, &ts, &r.AgentName, &r.Model, &r.Provider, &r.InputTokens, &r.OutputTokens, &r.CostUSD, &r.DurationMS, &r.StatusCode); err != nil {
+		if err := rows.Scan(&r.ID, &ts, &r.AgentName, &r.Model, &r.Provider, &r.InputTokens, &r.OutputTokens, &r.CostUSD, &r.DurationMS, &r.StatusCode, &r.ReasoningTokens, &r.CacheWriteTokens, &r.CacheReadTokens, &r.Tags, &r.Project); err != nil {
 			return nil, fmt.Errorf("scan export record: %w", err)
 		}
 		r.Timestamp, _ = time.Parse("2006-01-02T15:04:05Z", ts)
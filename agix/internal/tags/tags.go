@@ -0,0 +1,211 @@
+// Package tags handles caller-supplied request tags. Agents send them in an
+// X-Request-Tags header (ticket=ABC-123,team=platform); the gateway stores
+// them on the request row in a canonical sorted form so stats and exports
+// can filter and group by them.
+package tags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+// Header is the request header carrying tags.
+const Header = "X-Request-Tags"
+
+// Limits keep a tagged row small and the column indexable.
+const (
+	MaxTags   = 10
+	maxKeyLen = 64
+	maxValLen = 128
+)
+
+// None is reported when grouping by a key a request did not set.
+const None = "(none)"
+
+// Tags maps tag keys to values.
+type Tags map[string]string
+
+// Parse decodes a comma-separated list of key=value pairs. Keys and values
+// may contain letters, digits and -_.:/@. Valid pairs are returned even
+// when others are rejected, so one malformed tag does not drop the rest.
+func Parse(s string) (Tags, error) {
+	t := Tags{}
+	var bad []string
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || !valid(k, maxKeyLen) || !valid(v, maxValLen) {
+			bad = append(bad, pair)
+			continue
+		}
+		if _, dup := t[k]; !dup && len(t) >= MaxTags {
+			bad = append(bad, pair)
+			continue
+		}
+		t[k] = v
+	}
+	if len(bad) > 0 {
+		return t, fmt.Errorf("invalid request tags %q (want key=value, at most %d)", strings.Join(bad, ","), MaxTags)
+	}
+	return t, nil
+}
+
+func valid(s string, max int) bool {
+	if s == "" || len(s) > max {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:/@", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the canonical stored form: pairs sorted by key.
+func (t Tags) String() string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + t[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Decode reads tags stored by String. It never fails: a row written by the
+// gateway is already valid.
+func Decode(s string) Tags {
+	t := Tags{}
+	for _, pair := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			t[k] = v
+		}
+	}
+	return t
+}
+
+// Match reports whether t has every pair in filter.
+func (t Tags) Match(filter Tags) bool {
+	for k, v := range filter {
+		if t[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseFilter parses --tag flags (each key=value) into a filter.
+func ParseFilter(flags []string) (Tags, error) {
+	filter := Tags{}
+	for _, f := range flags {
+		t, err := Parse(f)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range t {
+			filter[k] = v
+		}
+	}
+	return filter, nil
+}
+
+// FilterRecords returns the records whose tags match filter.
+func FilterRecords(records []store.Record, filter Tags) []store.Record {
+	if len(filter) == 0 {
+		return records
+	}
+	var out []store.Record
+	for _, r := range records {
+		if Decode(r.Tags).Match(filter) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Stats is usage rolled up to one group.
+type Stats struct {
+	Key          string  `json:"key"`
+	Requests     int     `json:"requests"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// Rollup aggregates rows matching filter, most expensive first. by is
+// "agent", "model", "tag:<key>", or "" for a single total.
+func Rollup(rows []store.TagStats, filter Tags, by string) ([]Stats, error) {
+	key, err := grouper(by)
+	if err != nil {
+		return nil, err
+	}
+	groups := map[string]*Stats{}
+	var out []*Stats
+	for _, r := range rows {
+		t := Decode(r.Tags)
+		if !t.Match(filter) {
+			continue
+		}
+		k := key(r, t)
+		s, ok := groups[k]
+		if !ok {
+			s = &Stats{Key: k}
+			groups[k] = s
+			out = append(out, s)
+		}
+		s.Requests += r.Requests
+		s.InputTokens += r.InputTokens
+		s.OutputTokens += r.OutputTokens
+		s.CostUSD += r.CostUSD
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].CostUSD != out[j].CostUSD {
+			return out[i].CostUSD > out[j].CostUSD
+		}
+		return out[i].Key < out[j].Key
+	})
+	result := make([]Stats, len(out))
+	for i, s := range out {
+		result[i] = *s
+	}
+	return result, nil
+}
+
+func grouper(by string) (func(store.TagStats, Tags) string, error) {
+	switch by {
+	case "":
+		return func(store.TagStats, Tags) string { return "total" }, nil
+	case "agent":
+		return func(r store.TagStats, _ Tags) string {
+			if r.AgentName == "" {
+				return "(unknown)"
+			}
+			return r.AgentName
+		}, nil
+	case "model":
+		return func(r store.TagStats, _ Tags) string { return r.Model }, nil
+	}
+	if key, ok := strings.CutPrefix(by, "tag:"); ok && key != "" {
+		return func(_ store.TagStats, t Tags) string {
+			if v, ok := t[key]; ok {
+				return v
+			}
+			return None
+		}, nil
+	}
+	return nil, fmt.Errorf("cannot group tagged stats by %q (want agent, model or tag:<key>)", by)
+}
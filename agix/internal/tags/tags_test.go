@@ -0,0 +1,95 @@
+package tags
+
+import (
+	"testing"
+
+	"github.com/agent-platform/agix/internal/store"
+)
+
+func TestParse(t *testing.T) {
+	got, err := Parse(" team=platform , ticket=ABC-123,team=infra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := got.String(); s != "team=infra,ticket=ABC-123" {
+		t.Errorf("String() = %q", s)
+	}
+
+	got, err = Parse("team=platform,bad,note=has space,=x")
+	if err == nil {
+		t.Error("Parse accepted malformed pairs")
+	}
+	if s := got.String(); s != "team=platform" {
+		t.Errorf("valid pairs = %q, want team=platform", s)
+	}
+
+	if got, err := Parse(""); err != nil || len(got) != 0 {
+		t.Errorf("Parse(\"\") = %v, %v", got, err)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	h := ""
+	for i := 0; i < MaxTags+2; i++ {
+		h += string(rune('a'+i)) + "=1,"
+	}
+	got, err := Parse(h)
+	if err == nil || len(got) != MaxTags {
+		t.Errorf("Parse(%d tags) kept %d, err %v", MaxTags+2, len(got), err)
+	}
+}
+
+func TestRollup(t *testing.T) {
+	rows := []store.TagStats{
+		{Tags: "team=platform,ticket=A", AgentName: "bot", Model: "gpt-4o", Requests: 2, CostUSD: 0.5},
+		{Tags: "team=platform", AgentName: "ci", Model: "gpt-4o-mini", Requests: 1, CostUSD: 0.1},
+		{Tags: "team=search", AgentName: "bot", Model: "gpt-4o", Requests: 3, CostUSD: 0.3},
+		{Tags: "", AgentName: "", Model: "gpt-4o", Requests: 4, CostUSD: 0.2},
+	}
+
+	byTeam, err := Rollup(rows, nil, "tag:team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Stats{
+		{Key: "platform", Requests: 3, CostUSD: 0.6},
+		{Key: "search", Requests: 3, CostUSD: 0.3},
+		{Key: None, Requests: 4, CostUSD: 0.2},
+	}
+	if len(byTeam) != len(want) {
+		t.Fatalf("by team = %+v", byTeam)
+	}
+	for i := range want {
+		if byTeam[i].Key != want[i].Key || byTeam[i].Requests != want[i].Requests {
+			t.Errorf("by team[%d] = %+v, want %+v", i, byTeam[i], want[i])
+		}
+	}
+
+	byAgent, err := Rollup(rows, Tags{"team": "platform"}, "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byAgent) != 2 || byAgent[0].Key != "bot" || byAgent[1].Key != "ci" {
+		t.Errorf("platform by agent = %+v", byAgent)
+	}
+
+	total, _ := Rollup(rows, Tags{"ticket": "A"}, "")
+	if len(total) != 1 || total[0].Requests != 2 {
+		t.Errorf("ticket=A total = %+v", total)
+	}
+
+	if _, err := Rollup(rows, nil, "day"); err == nil {
+		t.Error("Rollup by day succeeded")
+	}
+}
+
+func TestFilterRecords(t *testing.T) {
+	records := []store.Record{{ID: 1, Tags: "team=platform"}, {ID: 2}, {ID: 3, Tags: "env=prod,team=platform"}}
+	got := FilterRecords(records, Tags{"team": "platform"})
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Errorf("FilterRecords = %+v", got)
+	}
+	if got := FilterRecords(records, nil); len(got) != 3 {
+		t.Errorf("FilterRecords(nil) = %d records", len(got))
+	}
+}
@@ -0,0 +1,123 @@
+// Package tlsserver builds the gateway's HTTPS configuration: the server
+// certificate, reloaded when its files change, and optional client
+// certificate verification (mTLS).
+package tlsserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+// checkInterval is how often the certificate files are checked for
+// changes; renewals apply to new connections within this interval.
+const checkInterval = 30 * time.Second
+
+// Config returns the tls.Config for tc. Relative paths are resolved
+// against baseDir.
+func Config(tc config.TLSConfig, baseDir string) (*tls.Config, error) {
+	if tc.CertFile == "" || tc.KeyFile == "" {
+		return nil, fmt.Errorf("tls: cert_file and key_file are both required")
+	}
+	abs := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	}
+
+	certs := &reloader{certFile: abs(tc.CertFile), keyFile: abs(tc.KeyFile)}
+	if err := certs.load(); err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.get,
+	}
+	switch tc.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("tls.min_version: want 1.2 or 1.3, got %q", tc.MinVersion)
+	}
+
+	if tc.ClientCAFile == "" {
+		if tc.ClientAuth != "" {
+			return nil, fmt.Errorf("tls.client_auth needs client_ca_file")
+		}
+		return cfg, nil
+	}
+	pem, err := os.ReadFile(abs(tc.ClientCAFile))
+	if err != nil {
+		return nil, fmt.Errorf("tls.client_ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("tls.client_ca_file: no certificates in %s", tc.ClientCAFile)
+	}
+	cfg.ClientCAs = pool
+	switch tc.ClientAuth {
+	case "", "require":
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	case "optional":
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("tls.client_auth: want require or optional, got %q", tc.ClientAuth)
+	}
+	return cfg, nil
+}
+
+// reloader serves a certificate and reloads it when its files change.
+type reloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time // newer of the two files' mtimes at load
+	checked time.Time
+}
+
+func (r *reloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = r.latestModTime()
+	r.checked = time.Now()
+	return nil
+}
+
+func (r *reloader) latestModTime() time.Time {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		if fi, err := os.Stat(path); err == nil && fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest
+}
+
+func (r *reloader) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) >= checkInterval {
+		r.checked = time.Now()
+		if r.latestModTime().After(r.modTime) {
+			// A half-written renewal fails to parse; keep the old
+			// certificate and try again on the next check.
+			prev, prevMod := r.cert, r.modTime
+			if err := r.load(); err != nil {
+				r.cert, r.modTime = prev, prevMod
+			}
+		}
+	}
+	return r.cert, nil
+}
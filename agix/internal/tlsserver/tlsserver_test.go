@@ -0,0 +1,175 @@
+package tlsserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-platform/agix/internal/config"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// issue creates a certificate for cn signed by parent, or self-signed if
+// parent is nil.
+func issue(t *testing.T, cn string, serial int64, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) {
+	t.Helper()
+	keyDER, _ := x509.MarshalECPrivateKey(c.key)
+	os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o644)
+	os.WriteFile(filepath.Join(dir, name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+}
+
+func (c *testCert) tls() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "agix test CA", 1, nil, true)
+	ca.write(t, dir, "ca")
+	issue(t, "agix", 2, ca, false).write(t, dir, "server")
+	client := issue(t, "code-reviewer", 3, ca, false)
+	stranger := issue(t, "stranger", 4, nil, false)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(t *testing.T, tc config.TLSConfig, cert *testCert) error {
+		t.Helper()
+		cfg, err := Config(tc, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// httptest's StartTLS would install its own certificate
+		ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), ErrorLog: log.New(io.Discard, "", 0)}
+		go srv.Serve(ln)
+		defer srv.Close()
+
+		clientTLS := &tls.Config{RootCAs: roots}
+		if cert != nil {
+			clientTLS.Certificates = []tls.Certificate{cert.tls()}
+		}
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+		resp, err := c.Get("https://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	base := config.TLSConfig{CertFile: "server.pem", KeyFile: "server-key.pem"}
+	if err := get(t, base, nil); err != nil {
+		t.Errorf("TLS without client auth: %v", err)
+	}
+
+	required := base
+	required.ClientCAFile = "ca.pem"
+	if err := get(t, required, client); err != nil {
+		t.Errorf("mTLS with a valid client certificate: %v", err)
+	}
+	if err := get(t, required, nil); err == nil {
+		t.Error("mTLS accepted a client without a certificate")
+	}
+	if err := get(t, required, stranger); err == nil {
+		t.Error("mTLS accepted a certificate from another CA")
+	}
+
+	optional := required
+	optional.ClientAuth = "optional"
+	if err := get(t, optional, nil); err != nil {
+		t.Errorf("optional client auth without a certificate: %v", err)
+	}
+
+	for _, bad := range []config.TLSConfig{
+		{CertFile: "server.pem"},
+		{CertFile: "server.pem", KeyFile: "server-key.pem", MinVersion: "1.1"},
+		{CertFile: "server.pem", KeyFile: "server-key.pem", ClientAuth: "require"},
+		{CertFile: "server.pem", KeyFile: "server-key.pem", ClientCAFile: "server-key.pem"},
+	} {
+		if _, err := Config(bad, dir); err == nil {
+			t.Errorf("Config(%+v) succeeded", bad)
+		}
+	}
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "agix test CA", 1, nil, true)
+	issue(t, "agix", 2, ca, false).write(t, dir, "server")
+
+	r := &reloader{certFile: filepath.Join(dir, "server.pem"), keyFile: filepath.Join(dir, "server-key.pem")}
+	if err := r.load(); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := r.get(nil)
+
+	issue(t, "agix", 5, ca, false).write(t, dir, "server")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(r.certFile, future, future)
+
+	if got, _ := r.get(nil); got != first {
+		t.Error("certificate reloaded before the check interval")
+	}
+	r.checked = time.Now().Add(-checkInterval)
+	if got, _ := r.get(nil); got.Leaf == nil || got.Leaf.SerialNumber.Int64() != 5 {
+		t.Error("certificate not reloaded after renewal")
+	}
+
+	// A broken renewal keeps the current certificate.
+	os.WriteFile(r.keyFile, []byte("garbage"), 0o600)
+	future = future.Add(time.Minute)
+	os.Chtimes(r.keyFile, future, future)
+	r.checked = time.Now().Add(-checkInterval)
+	if got, _ := r.get(nil); got == nil || got.Leaf.SerialNumber.Int64() != 5 {
+		t.Error("broken renewal replaced the certificate")
+	}
+}
@@ -114,6 +114,17 @@ func (m *Manager) ToolsForAgent(agentName string) []ToolEntry {
 	return m.tools
 }
 
+// ClientToolsMode returns how tools the agent sends itself combine with
+// MCP tools: config.ClientToolsMerge unless the agent is configured
+// otherwise.
+func (m *Manager) ClientToolsMode(agentName string) string {
+	switch mode := m.agents[agentName].ClientTools; mode {
+	case config.ClientToolsReplace, config.ClientToolsSkip:
+		return mode
+	}
+	return config.ClientToolsMerge
+}
+
 func (m *Manager) filterAllow(allow []string) []ToolEntry {
 	set := make(map[string]bool, len(allow))
 	for _, name := range allow {
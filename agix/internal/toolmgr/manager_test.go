@@ -172,3 +172,22 @@ func TestAllowNonExistentTool(t *testing.T) {
 		t.Errorf("ToolsForAgent with nonexistent allow = %d tools, want 0", len(tools))
 	}
 }
+
+func TestClientToolsMode(t *testing.T) {
+	m := NewFromClients(nil, map[string]config.AgentTools{
+		"replacer": {ClientTools: config.ClientToolsReplace},
+		"skipper":  {ClientTools: config.ClientToolsSkip},
+		"typo":     {ClientTools: "mrege"},
+	})
+	tests := map[string]string{
+		"replacer": config.ClientToolsReplace,
+		"skipper":  config.ClientToolsSkip,
+		"typo":     config.ClientToolsMerge,
+		"unlisted": config.ClientToolsMerge,
+	}
+	for agent, want := range tests {
+		if got := m.ClientToolsMode(agent); got != want {
+			t.Errorf("ClientToolsMode(%q) = %q, want %q", agent, got, want)
+		}
+	}
+}
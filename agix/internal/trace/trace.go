@@ -10,6 +10,7 @@ import (
 // Trace collects spans for a single request pipeline.
 type Trace struct {
 	ID        string    `json:"trace_id"`
+	RequestID string    `json:"request_id,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 	AgentName string    `json:"agent_name"`
 	Model     string    `json:"model"`
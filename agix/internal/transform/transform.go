@@ -0,0 +1,171 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Rules are declarative edits to the top-level fields of a JSON body.
+// They run in order: rename, drop, defaults, set.
+type Rules struct {
+	Rename   map[string]string // old field → new field; an existing new field wins
+	Drop     []string
+	Defaults map[string]any // added only when the field is absent
+	Set      map[string]any // always overwritten
+}
+
+func (r Rules) empty() bool {
+	return len(r.Rename) == 0 && len(r.Drop) == 0 && len(r.Defaults) == 0 && len(r.Set) == 0
+}
+
+// Provider holds the transforms for one upstream provider. Request rules
+// apply to the provider-native upstream body; response rules apply to
+// non-streaming response bodies before they reach the agent.
+type Provider struct {
+	Request  Rules
+	Response Rules
+}
+
+// Transformer applies per-provider request/response transforms.
+type Transformer struct {
+	request  map[string]compiled
+	response map[string]compiled
+}
+
+// compiled holds Rules with values pre-encoded as JSON.
+type compiled struct {
+	rename   [][2]string
+	drop     []string
+	defaults map[string]json.RawMessage
+	set      map[string]json.RawMessage
+}
+
+// New creates a Transformer. Returns nil if no transforms are configured.
+func New(cfg map[string]Provider) (*Transformer, error) {
+	t := &Transformer{
+		request:  make(map[string]compiled),
+		response: make(map[string]compiled),
+	}
+	for provider, pc := range cfg {
+		if !pc.Request.empty() {
+			c, err := compile(pc.Request)
+			if err != nil {
+				return nil, fmt.Errorf("%s request: %w", provider, err)
+			}
+			t.request[provider] = c
+		}
+		if !pc.Response.empty() {
+			c, err := compile(pc.Response)
+			if err != nil {
+				return nil, fmt.Errorf("%s response: %w", provider, err)
+			}
+			t.response[provider] = c
+		}
+	}
+	if len(t.request) == 0 && len(t.response) == 0 {
+		return nil, nil
+	}
+	return t, nil
+}
+
+func compile(r Rules) (compiled, error) {
+	c := compiled{
+		drop:     r.Drop,
+		defaults: make(map[string]json.RawMessage, len(r.Defaults)),
+		set:      make(map[string]json.RawMessage, len(r.Set)),
+	}
+	for from, to := range r.Rename {
+		if from == "" || to == "" {
+			return c, fmt.Errorf("rename %q → %q: field names must be non-empty", from, to)
+		}
+		c.rename = append(c.rename, [2]string{from, to})
+	}
+	// Deterministic order for chained renames
+	sort.Slice(c.rename, func(i, j int) bool { return c.rename[i][0] < c.rename[j][0] })
+
+	for k, v := range r.Defaults {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return c, fmt.Errorf("default %q: %w", k, err)
+		}
+		c.defaults[k] = data
+	}
+	for k, v := range r.Set {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return c, fmt.Errorf("set %q: %w", k, err)
+		}
+		c.set[k] = data
+	}
+	return c, nil
+}
+
+// Request applies the provider's request transforms to an upstream body.
+func (t *Transformer) Request(provider string, body []byte) []byte {
+	if t == nil {
+		return body
+	}
+	c, ok := t.request[provider]
+	if !ok {
+		return body
+	}
+	return c.apply(body)
+}
+
+// Response applies the provider's response transforms to a response body.
+func (t *Transformer) Response(provider string, body []byte) []byte {
+	if t == nil {
+		return body
+	}
+	c, ok := t.response[provider]
+	if !ok {
+		return body
+	}
+	return c.apply(body)
+}
+
+func (c compiled) apply(body []byte) []byte {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body
+	}
+
+	changed := false
+	for _, r := range c.rename {
+		v, ok := raw[r[0]]
+		if !ok {
+			continue
+		}
+		if _, exists := raw[r[1]]; !exists {
+			raw[r[1]] = v
+		}
+		delete(raw, r[0])
+		changed = true
+	}
+	for _, k := range c.drop {
+		if _, ok := raw[k]; ok {
+			delete(raw, k)
+			changed = true
+		}
+	}
+	for k, v := range c.defaults {
+		if _, ok := raw[k]; !ok {
+			raw[k] = v
+			changed = true
+		}
+	}
+	for k, v := range c.set {
+		raw[k] = v
+		changed = true
+	}
+
+	if !changed {
+		return body
+	}
+	out, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return out
+}
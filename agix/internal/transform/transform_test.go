@@ -0,0 +1,115 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNewEmpty(t *testing.T) {
+	tr, err := New(map[string]Provider{"anthropic": {}})
+	if err != nil || tr != nil {
+		t.Errorf("New() = %v, %v; want nil, nil", tr, err)
+	}
+	// nil Transformer passes bodies through
+	body := []byte(`{"a":1}`)
+	if got := tr.Request("anthropic", body); string(got) != string(body) {
+		t.Errorf("nil Request() = %s", got)
+	}
+}
+
+func TestNewInvalidRename(t *testing.T) {
+	_, err := New(map[string]Provider{"openai": {Request: Rules{Rename: map[string]string{"a": ""}}}})
+	if err == nil {
+		t.Error("expected error for empty rename target")
+	}
+}
+
+func TestRequest(t *testing.T) {
+	tr, err := New(map[string]Provider{
+		"anthropic": {Request: Rules{
+			Drop:     []string{"frequency_penalty", "presence_penalty"},
+			Defaults: map[string]any{"temperature": 0.7},
+		}},
+		"deepseek": {Request: Rules{
+			Rename: map[string]string{"max_completion_tokens": "max_tokens"},
+			Set:    map[string]any{"stream_options": map[string]any{"include_usage": true}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		in       string
+		want     string
+	}{
+		{
+			name:     "drop unsupported and inject default",
+			provider: "anthropic",
+			in:       `{"model":"claude-sonnet-4-6","frequency_penalty":0.5}`,
+			want:     `{"model":"claude-sonnet-4-6","temperature":0.7}`,
+		},
+		{
+			name:     "default does not override",
+			provider: "anthropic",
+			in:       `{"temperature":0.1}`,
+			want:     `{"temperature":0.1}`,
+		},
+		{
+			name:     "rename and set",
+			provider: "deepseek",
+			in:       `{"max_completion_tokens":100,"stream_options":{"include_usage":false}}`,
+			want:     `{"max_tokens":100,"stream_options":{"include_usage":true}}`,
+		},
+		{
+			name:     "rename keeps existing target",
+			provider: "deepseek",
+			in:       `{"max_completion_tokens":100,"max_tokens":50}`,
+			want:     `{"max_tokens":50,"stream_options":{"include_usage":true}}`,
+		},
+		{
+			name:     "provider without transforms",
+			provider: "openai",
+			in:       `{"frequency_penalty":0.5}`,
+			want:     `{"frequency_penalty":0.5}`,
+		},
+		{
+			name:     "invalid JSON passes through",
+			provider: "anthropic",
+			in:       `not json`,
+			want:     `not json`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tr.Request(tt.provider, []byte(tt.in))
+			if !jsonEqual(got, []byte(tt.want)) {
+				t.Errorf("Request() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponse(t *testing.T) {
+	tr, _ := New(map[string]Provider{
+		"openai": {Response: Rules{Drop: []string{"system_fingerprint"}}},
+	})
+	got := tr.Response("openai", []byte(`{"id":"x","system_fingerprint":"fp_1"}`))
+	if !jsonEqual(got, []byte(`{"id":"x"}`)) {
+		t.Errorf("Response() = %s", got)
+	}
+	if got := tr.Request("openai", []byte(`{"system_fingerprint":"fp_1"}`)); !jsonEqual(got, []byte(`{"system_fingerprint":"fp_1"}`)) {
+		t.Errorf("response rules applied to request: %s", got)
+	}
+}
+
+func jsonEqual(a, b []byte) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -97,7 +97,7 @@ func StatusColor(code int) string {
 // BudgetStatusColor returns a color-coded budget status.
 func BudgetStatusColor(status string) string {
 	switch status {
-	case "DAILY LIMIT", "MONTHLY LIMIT":
+	case "DAILY LIMIT", "MONTHLY LIMIT", "NO CREDIT":
 		return colorize(Red, status)
 	case "WARN":
 		return colorize(Yellow, status)
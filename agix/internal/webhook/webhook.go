@@ -13,6 +13,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/agent-platform/agix/internal/apikeys"
 	"github.com/agent-platform/agix/internal/config"
 	"github.com/agent-platform/agix/internal/store"
 )
@@ -23,6 +24,8 @@ type Handler struct {
 	proxyCfg *config.Config
 	store    *store.Store
 	client   *http.Client
+	gateway  string // base URL webhook prompts are sent through
+	internal string // token marking prompts as the gateway's own
 }
 
 // New creates a new webhook Handler.
@@ -31,12 +34,25 @@ func New(cfg config.WebhookConfig, proxyCfg *config.Config, st *store.Store) *Ha
 		cfg:      cfg,
 		proxyCfg: proxyCfg,
 		store:    st,
+		gateway:  fmt.Sprintf("http://localhost:%d", proxyCfg.Port),
 		client: &http.Client{
 			Timeout: 5 * time.Minute,
 		},
 	}
 }
 
+// SetGateway changes the base URL prompts are sent through, for when the
+// public listener is not plain HTTP on localhost.
+func (h *Handler) SetGateway(url string) {
+	h.gateway = url
+}
+
+// SetInternalToken sets the token that lets prompts through the
+// gateway's key checks, see apikeys.MarkInternal.
+func (h *Handler) SetInternalToken(token string) {
+	h.internal = token
+}
+
 // Definitions returns the configured webhook definitions.
 func (h *Handler) Definitions() map[string]config.WebhookDefinition {
 	return h.cfg.Definitions
@@ -121,13 +137,14 @@ func (h *Handler) sendToLLM(model, prompt string) (string, error) {
 	}
 
 	// Send through localhost proxy so routing/budget/tracking all apply
-	url := fmt.Sprintf("http://localhost:%d/v1/chat/completions", h.proxyCfg.Port)
+	url := h.gateway + "/v1/chat/completions"
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Agent-Name", "webhook")
+	req.Header.Set("X-Agent-Name", store.AgentWebhook)
+	apikeys.MarkInternal(req, h.internal)
 
 	resp, err := h.client.Do(req)
 	if err != nil {
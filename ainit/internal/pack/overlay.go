@@ -0,0 +1,91 @@
+package pack
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Overlay is an fs.FS that serves files from a template pack in preference
+// to the base (embedded) templates. The pack's root corresponds to prefix in
+// the base, e.g. pack "agents/coder.md" overrides base "templates/agents/coder.md".
+type Overlay struct {
+	Pack   fs.FS
+	Base   fs.FS
+	Prefix string
+}
+
+// packPath maps a base path to the pack, reporting false if it lies outside
+// the prefix.
+func (o *Overlay) packPath(name string) (string, bool) {
+	if name == o.Prefix {
+		return ".", true
+	}
+	rest, ok := strings.CutPrefix(name, o.Prefix+"/")
+	return rest, ok
+}
+
+// Open implements fs.FS.
+func (o *Overlay) Open(name string) (fs.File, error) {
+	if p, ok := o.packPath(name); ok {
+		if f, err := o.Pack.Open(p); err == nil {
+			info, statErr := f.Stat()
+			if statErr == nil && !info.IsDir() {
+				return f, nil
+			}
+			f.Close()
+		}
+	}
+	return o.Base.Open(name)
+}
+
+// ReadDir implements fs.ReadDirFS, merging pack and base entries. Pack
+// entries win on name clashes.
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	byName := make(map[string]fs.DirEntry)
+	base, baseErr := fs.ReadDir(o.Base, name)
+	for _, e := range base {
+		byName[e.Name()] = e
+	}
+	var packErr error = fs.ErrNotExist
+	if p, ok := o.packPath(name); ok {
+		var entries []fs.DirEntry
+		entries, packErr = fs.ReadDir(o.Pack, p)
+		for _, e := range entries {
+			byName[e.Name()] = e
+		}
+	}
+	if baseErr != nil && packErr != nil {
+		if errors.Is(baseErr, fs.ErrNotExist) {
+			return nil, packErr
+		}
+		return nil, baseErr
+	}
+
+	out := make([]fs.DirEntry, 0, len(byName))
+	for _, e := range byName {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
+
+// Overrides lists the pack files that replace or add to the base templates,
+// as base paths.
+func (o *Overlay) Overrides() ([]string, error) {
+	var out []string
+	err := fs.WalkDir(o.Pack, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && path != "." {
+			return fs.SkipDir
+		}
+		if d.Type().IsRegular() {
+			out = append(out, o.Prefix+"/"+path)
+		}
+		return nil
+	})
+	return out, err
+}
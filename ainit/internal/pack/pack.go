@@ -0,0 +1,204 @@
+// Package pack fetches external template packs from git and overlays them
+// on the embedded templates.
+package pack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ref identifies a template pack: a git source and an optional version
+// (tag, branch or full commit SHA) written as source@version.
+type Ref struct {
+	Source  string
+	Version string // empty = default branch, refetched on every run
+}
+
+// ParseRef parses "github.com/org/repo@v1.2.0". Sources without a scheme
+// are fetched over https; URLs, scp-style git addresses and local paths are
+// used as-is.
+func ParseRef(s string) (Ref, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Ref{}, fmt.Errorf("empty template pack")
+	}
+	ref := Ref{Source: s}
+	// The version separator is the last "@" after the final "/", so that
+	// git@host:org/repo stays intact.
+	if i := strings.LastIndex(s, "@"); i > strings.LastIndex(s, "/") {
+		ref.Source, ref.Version = s[:i], s[i+1:]
+		if ref.Version == "" {
+			return Ref{}, fmt.Errorf("template pack %q: empty version after @", s)
+		}
+	}
+	return ref, nil
+}
+
+// URL returns the git URL to clone.
+func (r Ref) URL() string {
+	switch {
+	case strings.Contains(r.Source, "://"),
+		strings.HasPrefix(r.Source, "git@"),
+		strings.HasPrefix(r.Source, "/"),
+		strings.HasPrefix(r.Source, "."):
+		return r.Source
+	default:
+		return "https://" + r.Source
+	}
+}
+
+// String returns the ref in source@version form.
+func (r Ref) String() string {
+	if r.Version == "" {
+		return r.Source
+	}
+	return r.Source + "@" + r.Version
+}
+
+// cacheKey turns the ref into a single directory name.
+func (r Ref) cacheKey() string {
+	version := r.Version
+	if version == "" {
+		version = "HEAD"
+	}
+	name := strings.NewReplacer("://", "_", "/", "_", ":", "_", "@", "_", "\\", "_").Replace(strings.TrimSuffix(r.Source, ".git"))
+	return strings.TrimLeft(name, "._") + "@" + version
+}
+
+// Fetch returns a directory holding the pack, cloning it into cacheDir if
+// needed. Pinned versions are reused from the cache unless refresh is set;
+// unpinned packs are always refetched.
+func Fetch(ref Ref, cacheDir string, refresh bool) (string, error) {
+	dir := filepath.Join(cacheDir, ref.cacheKey())
+	if _, err := os.Stat(dir); err == nil && ref.Version != "" && !refresh {
+		return dir, nil
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("create cache dir: %w", err)
+	}
+	tmp, err := os.MkdirTemp(cacheDir, ".fetch-")
+	if err != nil {
+		return "", fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	// clone --branch takes branches and tags only; a commit is fetched
+	// into the shallow clone and checked out.
+	if isCommit(ref.Version) {
+		if err := git("", "clone", "--quiet", "--depth", "1", "--no-checkout", ref.URL(), tmp); err != nil {
+			return "", fmt.Errorf("git clone %s: %w", ref, err)
+		}
+		if err := git(tmp, "fetch", "--quiet", "--depth", "1", "origin", ref.Version); err != nil {
+			return "", fmt.Errorf("git fetch %s: %w", ref, err)
+		}
+		if err := git(tmp, "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+			return "", fmt.Errorf("git checkout %s: %w", ref, err)
+		}
+	} else {
+		args := []string{"clone", "--quiet", "--depth", "1"}
+		if ref.Version != "" {
+			args = append(args, "--branch", ref.Version)
+		}
+		if err := git("", append(args, ref.URL(), tmp)...); err != nil {
+			return "", fmt.Errorf("git clone %s: %w", ref, err)
+		}
+	}
+	if err := os.RemoveAll(filepath.Join(tmp, ".git")); err != nil {
+		return "", fmt.Errorf("remove .git: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("clear cached pack: %w", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return "", fmt.Errorf("store pack in cache: %w", err)
+	}
+	return dir, nil
+}
+
+// isCommit reports whether version is a full commit SHA, which git can
+// fetch directly.
+func isCommit(version string) bool {
+	if len(version) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(version)
+	return err == nil
+}
+
+// git runs a git command in dir without prompting for credentials.
+func git(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Checksum returns "sha256:<hex>" over the pack's file paths and contents,
+// independent of file modes and timestamps.
+func Checksum(fsys fs.FS) (string, error) {
+	var paths []string
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("walk pack: %w", err)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		f, err := fsys.Open(p)
+		if err != nil {
+			return "", fmt.Errorf("open %s: %w", p, err)
+		}
+		fmt.Fprintf(h, "%s\x00", p)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", p, err)
+		}
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify checks the pack against an expected checksum. An empty expected
+// value always passes.
+func Verify(fsys fs.FS, expected string) (string, error) {
+	sum, err := Checksum(fsys)
+	if err != nil {
+		return "", err
+	}
+	if expected != "" && !strings.EqualFold(sum, expected) {
+		return sum, fmt.Errorf("checksum mismatch: got %s, want %s", sum, expected)
+	}
+	return sum, nil
+}
+
+// Root returns the pack's template root: its templates/ directory if it has
+// one, otherwise the repository root.
+func Root(dir string) fs.FS {
+	if info, err := os.Stat(filepath.Join(dir, "templates")); err == nil && info.IsDir() {
+		return os.DirFS(filepath.Join(dir, "templates"))
+	}
+	return os.DirFS(dir)
+}
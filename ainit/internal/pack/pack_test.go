@@ -0,0 +1,208 @@
+package pack
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		wantSource  string
+		wantVersion string
+		wantURL     string
+		wantErr     bool
+	}{
+		{"unpinned", "github.com/org/claude-templates", "github.com/org/claude-templates", "", "https://github.com/org/claude-templates", false},
+		{"pinned tag", "github.com/org/claude-templates@v1.2.0", "github.com/org/claude-templates", "v1.2.0", "https://github.com/org/claude-templates", false},
+		{"https url", "https://git.example.com/t.git@main", "https://git.example.com/t.git", "main", "https://git.example.com/t.git", false},
+		{"scp style", "git@github.com:org/t.git", "git@github.com:org/t.git", "", "git@github.com:org/t.git", false},
+		{"scp style pinned", "git@github.com:org/t.git@v2", "git@github.com:org/t.git", "v2", "git@github.com:org/t.git", false},
+		{"local path", "/srv/packs/t", "/srv/packs/t", "", "/srv/packs/t", false},
+		{"empty", "  ", "", "", "", true},
+		{"empty version", "github.com/org/t@", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseRef(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRef(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if ref.Source != tt.wantSource || ref.Version != tt.wantVersion || ref.URL() != tt.wantURL {
+				t.Errorf("ParseRef(%q) = %+v (url %s), want %s@%s (url %s)", tt.in, ref, ref.URL(), tt.wantSource, tt.wantVersion, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	a := fstest.MapFS{"agents/coder.md": {Data: []byte("x")}, "workflow.md": {Data: []byte("y")}}
+	b := fstest.MapFS{"workflow.md": {Data: []byte("y"), Mode: 0600}, "agents/coder.md": {Data: []byte("x")}}
+	c := fstest.MapFS{"agents/coder.md": {Data: []byte("x")}, "workflow.md": {Data: []byte("z")}}
+
+	sumA, err := Checksum(a)
+	if err != nil {
+		t.Fatalf("Checksum: %v", err)
+	}
+	if !strings.HasPrefix(sumA, "sha256:") {
+		t.Errorf("checksum %q missing sha256: prefix", sumA)
+	}
+	if sumB, _ := Checksum(b); sumB != sumA {
+		t.Errorf("same content, different checksum: %s vs %s", sumA, sumB)
+	}
+	if sumC, _ := Checksum(c); sumC == sumA {
+		t.Error("different content, same checksum")
+	}
+
+	if _, err := Verify(a, strings.ToUpper(sumA)); err != nil {
+		t.Errorf("Verify with matching checksum: %v", err)
+	}
+	if _, err := Verify(c, sumA); err == nil {
+		t.Error("Verify with wrong checksum should fail")
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	base := fstest.MapFS{
+		"templates/agents/coder.md":  {Data: []byte("base coder")},
+		"templates/agents/tester.md": {Data: []byte("base tester")},
+		"templates/workflow.md":      {Data: []byte("base workflow")},
+	}
+	packFS := fstest.MapFS{
+		"agents/coder.md":   {Data: []byte("org coder")},
+		"agents/auditor.md": {Data: []byte("org auditor")},
+	}
+	o := &Overlay{Pack: packFS, Base: base, Prefix: "templates"}
+
+	for path, want := range map[string]string{
+		"templates/agents/coder.md":   "org coder",
+		"templates/agents/tester.md":  "base tester",
+		"templates/agents/auditor.md": "org auditor",
+		"templates/workflow.md":       "base workflow",
+	} {
+		got, err := fs.ReadFile(o, path)
+		if err != nil || string(got) != want {
+			t.Errorf("ReadFile(%s) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+
+	entries, err := fs.ReadDir(o, "templates/agents")
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "auditor.md,coder.md,tester.md" {
+		t.Errorf("ReadDir = %v", names)
+	}
+
+	if _, err := fs.ReadDir(o, "templates/nope"); err == nil {
+		t.Error("ReadDir of missing dir should fail")
+	}
+
+	overrides, err := o.Overrides()
+	if err != nil || len(overrides) != 2 {
+		t.Errorf("Overrides = %v, %v", overrides, err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	// A local pack repository with a v1 tag.
+	repo := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	writeFile := func(name, body string) {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun("init", "--quiet")
+	writeFile("templates/agents/coder.md", "v1 coder")
+	gitRun("add", ".")
+	gitRun("commit", "--quiet", "-m", "v1")
+	gitRun("tag", "v1")
+	writeFile("templates/agents/coder.md", "v2 coder")
+	gitRun("commit", "--quiet", "-am", "v2")
+
+	cache := t.TempDir()
+	readCoder := func(dir string) string {
+		t.Helper()
+		data, err := fs.ReadFile(Root(dir), "agents/coder.md")
+		if err != nil {
+			t.Fatalf("read pack: %v", err)
+		}
+		return string(data)
+	}
+
+	pinned := Ref{Source: repo, Version: "v1"}
+	dir, err := Fetch(pinned, cache, false)
+	if err != nil {
+		t.Fatalf("Fetch pinned: %v", err)
+	}
+	if got := readCoder(dir); got != "v1 coder" {
+		t.Errorf("pinned pack = %q, want v1 coder", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !os.IsNotExist(err) {
+		t.Error("cached pack should not keep .git")
+	}
+
+	// A pinned pack is served from the cache without refetching.
+	os.WriteFile(filepath.Join(dir, "templates", "agents", "coder.md"), []byte("cached"), 0644)
+	if dir, _ = Fetch(pinned, cache, false); readCoder(dir) != "cached" {
+		t.Error("pinned pack was refetched despite cache")
+	}
+	if dir, _ = Fetch(pinned, cache, true); readCoder(dir) != "v1 coder" {
+		t.Error("refresh did not refetch pinned pack")
+	}
+
+	dir, err = Fetch(Ref{Source: repo}, cache, false)
+	if err != nil {
+		t.Fatalf("Fetch unpinned: %v", err)
+	}
+	if got := readCoder(dir); got != "v2 coder" {
+		t.Errorf("unpinned pack = %q, want v2 coder", got)
+	}
+
+	// A pinned commit is fetched by its SHA.
+	out, err := exec.Command("git", "-C", repo, "rev-parse", "v1").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err = Fetch(Ref{Source: repo, Version: strings.TrimSpace(string(out))}, cache, false)
+	if err != nil {
+		t.Fatalf("Fetch commit: %v", err)
+	}
+	if got := readCoder(dir); got != "v1 coder" {
+		t.Errorf("commit pack = %q, want v1 coder", got)
+	}
+
+	if _, err := Fetch(Ref{Source: repo, Version: "v9"}, cache, false); err == nil {
+		t.Error("expected error for missing version")
+	}
+}
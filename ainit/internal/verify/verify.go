@@ -0,0 +1,131 @@
+// Package verify smoke-tests the build, test and lint commands written into
+// a generated CLAUDE.md, so agents are not handed commands that fail.
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single command.
+const DefaultTimeout = 5 * time.Minute
+
+// tailLines is how much of a failing command's output a Result keeps.
+const tailLines = 20
+
+// Command is a shell command to run in a directory.
+type Command struct {
+	Dir   string // working directory
+	Label string // shown in reports, e.g. "agix build"
+	Run   string // passed to sh -c
+}
+
+// Result is the outcome of one Command.
+type Result struct {
+	Command
+	Err      error // nil when the command exited 0
+	TimedOut bool
+	Duration time.Duration
+	Output   string // last lines of combined stdout and stderr, on failure
+}
+
+// OK reports whether the command succeeded.
+func (r Result) OK() bool { return r.Err == nil }
+
+// Run runs the commands one after another, each with its own timeout, and
+// returns a Result for each. A failing command does not stop the rest.
+func Run(cmds []Command, timeout time.Duration) []Result {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	results := make([]Result, 0, len(cmds))
+	for _, c := range cmds {
+		results = append(results, runOne(c, timeout))
+	}
+	return results
+}
+
+func runOne(c Command, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Run)
+	cmd.Dir = c.Dir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// Don't wait forever on children that inherited the output pipe.
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	r := Result{Command: c, Duration: time.Since(start)}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		r.TimedOut = true
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		r.Err = err
+		r.Output = tail(out.String(), tailLines)
+	}
+	return r
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FromClaudeMD extracts the commands in the shell code blocks of a
+// CLAUDE.md's build/test/lint sections: those whose heading mentions
+// "build", "test" or "lint". Comment and blank lines are skipped.
+func FromClaudeMD(doc string) []string {
+	var cmds []string
+	inSection, inBlock, shell := false, false, false
+	sc := bufio.NewScanner(strings.NewReader(doc))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if fence, ok := strings.CutPrefix(line, "```"); ok {
+			if inBlock {
+				inBlock = false
+				continue
+			}
+			inBlock = true
+			lang := strings.ToLower(strings.TrimSpace(fence))
+			shell = lang == "" || lang == "bash" || lang == "sh" || lang == "shell"
+			continue
+		}
+		if inBlock {
+			if inSection && shell && line != "" && !strings.HasPrefix(line, "#") {
+				cmds = append(cmds, strings.TrimPrefix(line, "$ "))
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			heading := strings.ToLower(strings.TrimLeft(line, "# "))
+			inSection = strings.Contains(heading, "build") || strings.Contains(heading, "test") || strings.Contains(heading, "lint")
+		}
+	}
+	return cmds
+}
+
+// Failed returns the results that did not succeed.
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if !r.OK() {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
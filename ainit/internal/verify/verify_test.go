@@ -0,0 +1,58 @@
+package verify
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	results := Run([]Command{
+		{Dir: dir, Label: "ok", Run: "test -d ."},
+		{Dir: dir, Label: "fail", Run: "echo building; echo missing target >&2; exit 2"},
+		{Dir: dir, Label: "slow", Run: "sleep 5"},
+	}, 200*time.Millisecond)
+
+	if len(results) != 3 {
+		t.Fatalf("results = %d, want 3", len(results))
+	}
+	if !results[0].OK() || results[0].Output != "" {
+		t.Errorf("ok result = %+v", results[0])
+	}
+	if r := results[1]; r.OK() || r.TimedOut || r.Output != "building\nmissing target" {
+		t.Errorf("fail result = %+v", r)
+	}
+	if r := results[2]; r.OK() || !r.TimedOut || r.Duration > 3*time.Second {
+		t.Errorf("slow result = %+v", r)
+	}
+	if failed := Failed(results); len(failed) != 2 || failed[0].Label != "fail" {
+		t.Errorf("Failed = %+v", failed)
+	}
+}
+
+func TestTail(t *testing.T) {
+	long := strings.Repeat("line\n", 50) + "last\n"
+	if got := strings.Count(tail(long, tailLines), "\n"); got != tailLines-1 {
+		t.Errorf("tail kept %d lines, want %d", got+1, tailLines)
+	}
+	if got := tail(long, 2); got != "line\nlast" {
+		t.Errorf("tail = %q", got)
+	}
+}
+
+func TestFromClaudeMD(t *testing.T) {
+	doc := "# proj\n\n## Directory Structure\n\n```\n├── main.go\n```\n\n" +
+		"## Build & Test\n\n```bash\n# Build\nmake build\n\n# Test\n$ make test\n```\n\n" +
+		"```go\nfunc main() {}\n```\n\n" +
+		"### Lint\n\n```sh\ngo vet ./...\n```\n\n" +
+		"## Coding Standards\n\n```bash\nrm -rf /\n```\n"
+	want := []string{"make build", "make test", "go vet ./..."}
+	if got := FromClaudeMD(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromClaudeMD = %q, want %q", got, want)
+	}
+	if got := FromClaudeMD("# empty\n"); len(got) != 0 {
+		t.Errorf("FromClaudeMD(empty) = %q", got)
+	}
+}
@@ -0,0 +1,194 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	rootBegin    = "<!-- ainit:workspace -->"
+	rootEnd      = "<!-- /ainit:workspace -->"
+	memberBegin  = "<!-- ainit:workspace-member -->"
+	memberEnd    = "<!-- /ainit:workspace-member -->"
+	protocolMark = "<!-- ainit:backlog-protocol -->"
+)
+
+// Options controls Init.
+type Options struct {
+	Name     string // workspace name for backlog.json; defaults to the root dir name
+	Protocol []byte // backlog protocol appended to the root CLAUDE.md if missing
+	DryRun   bool
+}
+
+// Change describes one file Init created or updated (or would, in dry-run).
+type Change struct {
+	Path   string // relative to the workspace root
+	Action string // created, updated, unchanged
+}
+
+// Init writes the root CLAUDE.md section, a member section in each project's
+// CLAUDE.md and the shared backlog. Generated sections sit between ainit
+// markers and are replaced on rerun; everything else is left alone.
+func Init(root string, projects []Project, opts Options) ([]Change, error) {
+	var changes []Change
+	record := func(rel string, c Change, err error) error {
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		changes = append(changes, c)
+		return nil
+	}
+
+	rootMD := RootSection(projects)
+	c, err := upsertSection(root, "CLAUDE.md", rootBegin, rootEnd, rootMD, opts.Protocol, opts.DryRun)
+	if err := record("CLAUDE.md", c, err); err != nil {
+		return nil, err
+	}
+
+	for _, p := range projects {
+		rel := path.Join(p.Path, "CLAUDE.md")
+		c, err := upsertSection(root, rel, memberBegin, memberEnd, MemberSection(p), nil, opts.DryRun)
+		if err := record(rel, c, err); err != nil {
+			return nil, err
+		}
+	}
+
+	name := opts.Name
+	if name == "" {
+		abs, _ := filepath.Abs(root)
+		name = filepath.Base(abs)
+	}
+	c, err = ensureBacklog(root, name, opts.DryRun)
+	if err := record("backlog.json", c, err); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// RootSection renders the workspace section of the root CLAUDE.md.
+func RootSection(projects []Project) string {
+	var b strings.Builder
+	b.WriteString(rootBegin + "\n")
+	b.WriteString("## Workspace\n\n")
+	b.WriteString("This repository is a monorepo. Run commands from the repository root; each package has its own CLAUDE.md with package-specific guidance.\n\n")
+	b.WriteString("| Package | Kind | Build | Test |\n")
+	b.WriteString("|---------|------|-------|------|\n")
+	for _, p := range projects {
+		fmt.Fprintf(&b, "| [%s](%s/CLAUDE.md) | %s | %s | %s |\n",
+			p.Path, p.Path, p.Kind, code(rootCommand(p, p.Build)), code(rootCommand(p, p.Test)))
+	}
+	b.WriteString("\nAll packages share the backlog at the repository root (`backlog.json`, `backlog/`). Stories that touch several packages list each package in their tasks.\n")
+	b.WriteString(rootEnd + "\n")
+	return b.String()
+}
+
+// MemberSection renders the workspace section of a package's CLAUDE.md.
+func MemberSection(p Project) string {
+	up := strings.Repeat("../", strings.Count(p.Path, "/")+1)
+	var b strings.Builder
+	b.WriteString(memberBegin + "\n")
+	b.WriteString("## Workspace\n\n")
+	fmt.Fprintf(&b, "This package (`%s`) is part of a monorepo; see the [root CLAUDE.md](%sCLAUDE.md) for the other packages.\n\n", p.Path, up)
+	if p.Build != "" {
+		fmt.Fprintf(&b, "- Build: %s\n", code(p.Build))
+	}
+	if p.Test != "" {
+		fmt.Fprintf(&b, "- Test: %s\n", code(p.Test))
+	}
+	fmt.Fprintf(&b, "- Backlog: shared at the repository root (`%sbacklog.json`); do not create a backlog in this package.\n", up)
+	b.WriteString(memberEnd + "\n")
+	return b.String()
+}
+
+// rootCommand prefixes cmd with a cd into the project directory.
+func rootCommand(p Project, cmd string) string {
+	if cmd == "" {
+		return ""
+	}
+	return "cd " + p.Path + " && " + cmd
+}
+
+func code(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return "`" + s + "`"
+}
+
+// upsertSection replaces the marked section of root/rel, or appends it (and
+// extra, if its marker is not already present) when missing.
+func upsertSection(root, rel, begin, end, section string, extra []byte, dryRun bool) (Change, error) {
+	file := filepath.Join(root, filepath.FromSlash(rel))
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return Change{}, err
+	}
+	action := "updated"
+	if os.IsNotExist(err) {
+		action = "created"
+	}
+
+	old := string(data)
+	updated := replaceSection(old, begin, end, section)
+	if len(extra) > 0 && !strings.Contains(updated, protocolMark) {
+		updated = appendBlock(updated, string(extra))
+	}
+	if updated == old {
+		return Change{Path: rel, Action: "unchanged"}, nil
+	}
+	if !dryRun {
+		if err := os.WriteFile(file, []byte(updated), 0644); err != nil {
+			return Change{}, err
+		}
+	}
+	return Change{Path: rel, Action: action}, nil
+}
+
+func replaceSection(doc, begin, end, section string) string {
+	i := strings.Index(doc, begin)
+	j := strings.Index(doc, end)
+	if i < 0 || j < i {
+		return appendBlock(doc, section)
+	}
+	j += len(end)
+	if j < len(doc) && doc[j] == '\n' {
+		j++
+	}
+	return doc[:i] + section + doc[j:]
+}
+
+func appendBlock(doc, block string) string {
+	switch {
+	case doc == "":
+		return block
+	case strings.HasSuffix(doc, "\n\n"):
+		return doc + block
+	case strings.HasSuffix(doc, "\n"):
+		return doc + "\n" + block
+	default:
+		return doc + "\n\n" + block
+	}
+}
+
+// ensureBacklog creates the shared backlog.json and backlog/ at the root,
+// leaving an existing backlog.json untouched.
+func ensureBacklog(root, name string, dryRun bool) (Change, error) {
+	file := filepath.Join(root, "backlog.json")
+	if _, err := os.Stat(file); err == nil {
+		return Change{Path: "backlog.json", Action: "unchanged"}, nil
+	}
+	if dryRun {
+		return Change{Path: "backlog.json", Action: "created"}, nil
+	}
+	content := fmt.Sprintf("{\"project\": %q, \"current_sprint\": 1, \"last_story_id\": 0, \"stories\": []}\n", name)
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		return Change{}, err
+	}
+	if err := os.MkdirAll(filepath.Join(root, "backlog"), 0755); err != nil {
+		return Change{}, err
+	}
+	return Change{Path: "backlog.json", Action: "created"}, nil
+}
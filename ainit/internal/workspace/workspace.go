@@ -0,0 +1,187 @@
+// Package workspace initializes a monorepo as one workspace: a root
+// CLAUDE.md that links every subproject, and a single shared backlog.
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Project is a subproject discovered in the workspace.
+type Project struct {
+	Path  string // slash-separated, relative to the workspace root
+	Name  string
+	Kind  string // go, node, python, rust, java
+	Build string // build command, run from the project directory
+	Test  string // test command, run from the project directory
+}
+
+// manifests maps a manifest file to the project kind it identifies, in
+// detection order.
+var manifests = []struct {
+	file string
+	kind string
+}{
+	{"go.mod", "go"},
+	{"package.json", "node"},
+	{"pyproject.toml", "python"},
+	{"Cargo.toml", "rust"},
+	{"pom.xml", "java"},
+	{"build.gradle", "java"},
+	{"build.gradle.kts", "java"},
+}
+
+// skipDirs are never searched for subprojects.
+var skipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true,
+	"target": true, "testdata": true, "backlog": true,
+}
+
+// Discover finds the subprojects under root. A directory with a manifest is
+// a project and is not searched further; the root itself is not a project.
+func Discover(root string) ([]Project, error) {
+	var projects []Project
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || skipDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+		if path == root {
+			return nil
+		}
+		kind, manifest := detectKind(path)
+		if kind == "" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		p := Project{Path: filepath.ToSlash(rel), Kind: kind, Name: projectName(path, manifest)}
+		p.Build, p.Test = commands(path, kind, manifest)
+		projects = append(projects, p)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discover projects: %w", err)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Path < projects[j].Path })
+	return projects, nil
+}
+
+func detectKind(dir string) (kind, manifest string) {
+	for _, m := range manifests {
+		if fileExists(filepath.Join(dir, m.file)) {
+			return m.kind, m.file
+		}
+	}
+	return "", ""
+}
+
+var (
+	goModuleRe   = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	tomlNameRe   = regexp.MustCompile(`(?m)^name\s*=\s*"([^"]+)"`)
+	makeTargetRe = regexp.MustCompile(`(?m)^([A-Za-z0-9_-]+)\s*:`)
+)
+
+// projectName reads the name from the manifest, falling back to the
+// directory name (same rules as ainit-setup.sh).
+func projectName(dir, manifest string) string {
+	data, _ := os.ReadFile(filepath.Join(dir, manifest))
+	switch manifest {
+	case "go.mod":
+		if m := goModuleRe.FindSubmatch(data); m != nil {
+			return filepath.Base(string(m[1]))
+		}
+	case "package.json":
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.Name != "" {
+			return pkg.Name
+		}
+	case "pyproject.toml", "Cargo.toml":
+		if m := tomlNameRe.FindSubmatch(data); m != nil {
+			return string(m[1])
+		}
+	}
+	return filepath.Base(dir)
+}
+
+// commands returns the build and test commands for a project: Makefile
+// targets when present, otherwise the ecosystem defaults.
+func commands(dir, kind, manifest string) (build, test string) {
+	if data, err := os.ReadFile(filepath.Join(dir, "Makefile")); err == nil {
+		targets := make(map[string]bool)
+		for _, m := range makeTargetRe.FindAllSubmatch(data, -1) {
+			targets[string(m[1])] = true
+		}
+		if targets["build"] {
+			build = "make build"
+		}
+		if targets["test"] {
+			test = "make test"
+		}
+	}
+
+	var defBuild, defTest string
+	switch kind {
+	case "go":
+		defBuild, defTest = "go build ./...", "go test ./..."
+	case "node":
+		defBuild, defTest = nodeScripts(dir)
+	case "python":
+		defTest = "pytest"
+	case "rust":
+		defBuild, defTest = "cargo build", "cargo test"
+	case "java":
+		if manifest == "pom.xml" {
+			defBuild, defTest = "mvn package", "mvn test"
+		} else {
+			defBuild, defTest = "./gradlew build", "./gradlew test"
+		}
+	}
+	if build == "" {
+		build = defBuild
+	}
+	if test == "" {
+		test = defTest
+	}
+	return build, test
+}
+
+func nodeScripts(dir string) (build, test string) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return "", ""
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return "", ""
+	}
+	if _, ok := pkg.Scripts["build"]; ok {
+		build = "npm run build"
+	}
+	if _, ok := pkg.Scripts["test"]; ok {
+		test = "npm test"
+	}
+	return build, test
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,152 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, root, name, body string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, root, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func newMonorepo(t *testing.T) string {
+	root := t.TempDir()
+	writeFile(t, root, "go.work", "go 1.22\n")
+	writeFile(t, root, "agix/go.mod", "module github.com/org/agix\n")
+	writeFile(t, root, "agix/Makefile", "build:\n\tgo build\ntest:\n\tgo test ./...\n")
+	writeFile(t, root, "agix/internal/sub/go.mod", "module nested\n")
+	writeFile(t, root, "web/package.json", `{"name": "@org/web", "scripts": {"build": "vite build", "test": "vitest"}}`)
+	writeFile(t, root, "web/node_modules/dep/package.json", `{"name": "dep"}`)
+	writeFile(t, root, "tools/py/pyproject.toml", "[project]\nname = \"pytool\"\n")
+	writeFile(t, root, ".hidden/go.mod", "module hidden\n")
+	writeFile(t, root, "docs/README.md", "docs\n")
+	return root
+}
+
+func TestDiscover(t *testing.T) {
+	root := newMonorepo(t)
+	projects, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	want := []Project{
+		{Path: "agix", Name: "agix", Kind: "go", Build: "make build", Test: "make test"},
+		{Path: "tools/py", Name: "pytool", Kind: "python", Test: "pytest"},
+		{Path: "web", Name: "@org/web", Kind: "node", Build: "npm run build", Test: "npm test"},
+	}
+	if len(projects) != len(want) {
+		t.Fatalf("Discover found %+v, want %d projects", projects, len(want))
+	}
+	for i := range want {
+		if projects[i] != want[i] {
+			t.Errorf("project %d = %+v, want %+v", i, projects[i], want[i])
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	root := newMonorepo(t)
+	writeFile(t, root, "CLAUDE.md", "# Monorepo\n\nHand-written notes.\n")
+	writeFile(t, root, "agix/CLAUDE.md", "# agix\n")
+	projects, err := Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protocol := []byte(protocolMark + "\n## Development Workflow\n")
+
+	changes, err := Init(root, projects, Options{Name: "mono", Protocol: protocol})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	actions := make(map[string]string)
+	for _, c := range changes {
+		actions[c.Path] = c.Action
+	}
+	for path, want := range map[string]string{
+		"CLAUDE.md":          "updated",
+		"agix/CLAUDE.md":     "updated",
+		"web/CLAUDE.md":      "created",
+		"tools/py/CLAUDE.md": "created",
+		"backlog.json":       "created",
+	} {
+		if actions[path] != want {
+			t.Errorf("%s: action %q, want %q", path, actions[path], want)
+		}
+	}
+
+	rootMD := readFile(t, root, "CLAUDE.md")
+	for _, want := range []string{
+		"Hand-written notes.",
+		"| [agix](agix/CLAUDE.md) | go | `cd agix && make build` | `cd agix && make test` |",
+		"| [tools/py](tools/py/CLAUDE.md) | python | — | `cd tools/py && pytest` |",
+		protocolMark,
+	} {
+		if !strings.Contains(rootMD, want) {
+			t.Errorf("root CLAUDE.md missing %q:\n%s", want, rootMD)
+		}
+	}
+
+	member := readFile(t, root, "tools/py/CLAUDE.md")
+	if !strings.Contains(member, "[root CLAUDE.md](../../CLAUDE.md)") || !strings.Contains(member, "`../../backlog.json`") {
+		t.Errorf("member CLAUDE.md has wrong relative links:\n%s", member)
+	}
+	if !strings.HasPrefix(readFile(t, root, "agix/CLAUDE.md"), "# agix\n\n"+memberBegin) {
+		t.Error("existing package CLAUDE.md content not preserved")
+	}
+	if got := readFile(t, root, "backlog.json"); !strings.Contains(got, `"project": "mono"`) {
+		t.Errorf("backlog.json = %s", got)
+	}
+
+	// Rerunning replaces the generated sections in place.
+	changes, err = Init(root, projects, Options{Protocol: protocol})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range changes {
+		if c.Action != "unchanged" {
+			t.Errorf("rerun: %s %s, want unchanged", c.Path, c.Action)
+		}
+	}
+	if n := strings.Count(readFile(t, root, "CLAUDE.md"), rootBegin); n != 1 {
+		t.Errorf("root section appears %d times after rerun", n)
+	}
+}
+
+func TestInitDryRun(t *testing.T) {
+	root := newMonorepo(t)
+	projects, err := Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := Init(root, projects, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if len(changes) != 5 {
+		t.Errorf("dry run reported %d changes, want 5", len(changes))
+	}
+	for _, name := range []string{"CLAUDE.md", "web/CLAUDE.md", "backlog.json"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("dry run wrote %s", name)
+		}
+	}
+}
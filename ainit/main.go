@@ -4,10 +4,12 @@ import (
 	"embed"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 
 	"github.com/agent-platform/tools/ainit/internal/installer"
+	"github.com/agent-platform/tools/ainit/internal/pack"
 )
 
 //go:embed templates/*
@@ -16,8 +18,20 @@ var templateFS embed.FS
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "workspace" {
+		runWorkspace(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		runVerify(os.Args[2:])
+		return
+	}
+
 	showVersion := flag.Bool("version", false, "print version and exit")
 	dryRun := flag.Bool("dry-run", false, "show what would be installed without writing files")
+	templatePack := flag.String("template-pack", "", "git template pack overriding the built-in templates, e.g. github.com/org/claude-templates@v1.2.0")
+	packChecksum := flag.String("pack-checksum", "", "expected sha256 checksum of the template pack (sha256:...)")
+	refreshPack := flag.Bool("refresh-pack", false, "refetch a pinned template pack instead of using the cache")
 	flag.Parse()
 
 	if *showVersion {
@@ -31,7 +45,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	inst := &installer.Installer{FS: templateFS, DryRun: *dryRun}
+	var templates fs.FS = templateFS
+	if *templatePack != "" {
+		templates, err = loadTemplatePack(*templatePack, *packChecksum, *refreshPack)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	inst := &installer.Installer{FS: templates, DryRun: *dryRun}
 	claudeDir := filepath.Join(homeDir, ".claude")
 
 	// 1. Install slash command
@@ -78,3 +101,36 @@ func main() {
 		fmt.Println("Installed. Run /ainit in any project to set up multi-agent collaboration.")
 	}
 }
+
+// loadTemplatePack fetches (or reuses from cache) a template pack, verifies
+// its checksum and overlays it on the embedded templates.
+func loadTemplatePack(spec, checksum string, refresh bool) (fs.FS, error) {
+	ref, err := pack.ParseRef(spec)
+	if err != nil {
+		return nil, err
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("find cache directory: %w", err)
+	}
+	dir, err := pack.Fetch(ref, filepath.Join(cacheDir, "ainit", "packs"), refresh)
+	if err != nil {
+		return nil, err
+	}
+	root := pack.Root(dir)
+	sum, err := pack.Verify(root, checksum)
+	if err != nil {
+		return nil, fmt.Errorf("template pack %s: %w", ref, err)
+	}
+
+	overlay := &pack.Overlay{Pack: root, Base: templateFS, Prefix: "templates"}
+	overrides, err := overlay.Overrides()
+	if err != nil {
+		return nil, fmt.Errorf("template pack %s: %w", ref, err)
+	}
+	fmt.Printf("  template pack %s (%d file(s), %s)\n", ref, len(overrides), sum)
+	if ref.Version == "" {
+		fmt.Println("  warning: template pack is not pinned; append @<tag> to pin a version")
+	}
+	return overlay, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agent-platform/tools/ainit/internal/verify"
+)
+
+// runVerify implements `ainit verify [--timeout d] [dir]`: it runs the
+// build/test/lint commands listed in dir/CLAUDE.md and reports which fail.
+func runVerify(args []string) {
+	fset := flag.NewFlagSet("verify", flag.ExitOnError)
+	timeout := fset.Duration("timeout", verify.DefaultTimeout, "timeout for each command")
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "Usage: ainit verify [flags] [dir]")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+
+	root := "."
+	if fset.NArg() > 0 {
+		root = fset.Arg(0)
+	}
+	doc, err := os.ReadFile(filepath.Join(root, "CLAUDE.md"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var cmds []verify.Command
+	for _, c := range verify.FromClaudeMD(string(doc)) {
+		cmds = append(cmds, verify.Command{Dir: root, Label: c, Run: c})
+	}
+	if len(cmds) == 0 {
+		fmt.Fprintln(os.Stderr, "error: no build/test/lint commands found in CLAUDE.md")
+		os.Exit(1)
+	}
+	if !reportVerify(cmds, *timeout) {
+		os.Exit(1)
+	}
+}
+
+// reportVerify runs cmds, prints a line per command and the output of the
+// failures, and reports whether all of them passed.
+func reportVerify(cmds []verify.Command, timeout time.Duration) bool {
+	fmt.Printf("Verifying %d command(s):\n", len(cmds))
+	results := verify.Run(cmds, timeout)
+	for _, r := range results {
+		status := "ok  "
+		if !r.OK() {
+			status = "FAIL"
+		}
+		fmt.Printf("  %s %-40s %s\n", status, r.Label, r.Duration.Round(time.Millisecond))
+	}
+
+	failed := verify.Failed(results)
+	for _, r := range failed {
+		fmt.Printf("\n%s (in %s): %v\n", r.Label, r.Dir, r.Err)
+		if r.Output != "" {
+			fmt.Println("  " + strings.ReplaceAll(r.Output, "\n", "\n  "))
+		}
+	}
+	fmt.Println()
+	if len(failed) > 0 {
+		fmt.Printf("%d of %d command(s) failed. Fix them or correct CLAUDE.md before handing it to agents.\n", len(failed), len(results))
+		return false
+	}
+	fmt.Println("All commands passed.")
+	return true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/agent-platform/tools/ainit/internal/verify"
+	"github.com/agent-platform/tools/ainit/internal/workspace"
+)
+
+// runWorkspace implements `ainit workspace [--dry-run] [--verify] [dir]`: it
+// discovers the subprojects of a monorepo and links them under one root
+// CLAUDE.md and a shared backlog, optionally smoke-testing each package's
+// build and test commands.
+func runWorkspace(args []string) {
+	fset := flag.NewFlagSet("workspace", flag.ExitOnError)
+	dryRun := fset.Bool("dry-run", false, "show what would be written without writing files")
+	name := fset.String("name", "", "workspace name for backlog.json (default: directory name)")
+	templatePack := fset.String("template-pack", "", "git template pack providing the backlog protocol")
+	packChecksum := fset.String("pack-checksum", "", "expected sha256 checksum of the template pack (sha256:...)")
+	refreshPack := fset.Bool("refresh-pack", false, "refetch a pinned template pack instead of using the cache")
+	runChecks := fset.Bool("verify", false, "run each package's build and test commands and report failures")
+	verifyTimeout := fset.Duration("verify-timeout", verify.DefaultTimeout, "timeout for each command run by --verify")
+	fset.Usage = func() {
+		fmt.Fprintln(fset.Output(), "Usage: ainit workspace [flags] [dir]")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+
+	root := "."
+	if fset.NArg() > 0 {
+		root = fset.Arg(0)
+	}
+
+	var templates fs.FS = templateFS
+	if *templatePack != "" {
+		var err error
+		templates, err = loadTemplatePack(*templatePack, *packChecksum, *refreshPack)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	protocol, err := fs.ReadFile(templates, "templates/backlog-protocol.md")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	projects, err := workspace.Discover(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(projects) == 0 {
+		fmt.Fprintf(os.Stderr, "error: no subprojects found under %s\n", root)
+		os.Exit(1)
+	}
+	fmt.Printf("Found %d package(s):\n", len(projects))
+	for _, p := range projects {
+		fmt.Printf("  %-24s %s\n", p.Path, p.Kind)
+	}
+	fmt.Println()
+
+	changes, err := workspace.Init(root, projects, workspace.Options{Name: *name, Protocol: protocol, DryRun: *dryRun})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, c := range changes {
+		if *dryRun {
+			fmt.Printf("  [dry-run] %s (%s)\n", c.Path, c.Action)
+		} else {
+			fmt.Printf("  %s (%s)\n", c.Path, c.Action)
+		}
+	}
+	if !*dryRun {
+		fmt.Println("  backlog/")
+	}
+
+	if *runChecks {
+		fmt.Println()
+		if !reportVerify(workspaceCommands(root, projects), *verifyTimeout) {
+			os.Exit(1)
+		}
+	}
+
+	fmt.Println()
+	if *dryRun {
+		fmt.Println("Dry run complete. No files were written.")
+	} else {
+		fmt.Println("Workspace initialized. Run /ainit at the repository root to install agents and the backlog CLI.")
+	}
+}
+
+// workspaceCommands returns the build and test command of every project,
+// run from the project directory.
+func workspaceCommands(root string, projects []workspace.Project) []verify.Command {
+	var cmds []verify.Command
+	for _, p := range projects {
+		dir := filepath.Join(root, filepath.FromSlash(p.Path))
+		if p.Build != "" {
+			cmds = append(cmds, verify.Command{Dir: dir, Label: p.Path + ": " + p.Build, Run: p.Build})
+		}
+		if p.Test != "" {
+			cmds = append(cmds, verify.Command{Dir: dir, Label: p.Path + ": " + p.Test, Run: p.Test})
+		}
+	}
+	return cmds
+}
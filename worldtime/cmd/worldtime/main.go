@@ -1,9 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -11,7 +13,44 @@ import (
 )
 
 func main() {
-	cities := clock.DefaultCities()
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := serve(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "worldtime:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	hour12 := flag.Bool("12h", false, "use a 12-hour clock")
+	themeName := flag.String("theme", envOr("WORLDTIME_THEME", "default"),
+		"color theme: "+fmt.Sprint(clock.ThemeNames())+" (NO_COLOR forces none)")
+	width := flag.Int("width", 0, "layout width in columns (default: terminal width)")
+	once := flag.Bool("once", false, "print the clock once and exit")
+	hours := flag.String("hours", envOr("WORLDTIME_HOURS", ""),
+		`working hours for the best overlap, e.g. "9-18" or "9-18,Tokyo=10-19"`)
+	flag.Parse()
+
+	if os.Getenv("NO_COLOR") != "" {
+		*themeName = "none"
+	}
+	theme, err := clock.LookupTheme(*themeName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "worldtime:", err)
+		os.Exit(2)
+	}
+
+	cities, err := clock.ApplyWorkHours(clock.DefaultCities(), *hours)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "worldtime:", err)
+		os.Exit(2)
+	}
+	opts := clock.Options{Hour12: *hour12, Theme: theme, Clear: !*once}
+
+	if *once {
+		opts.Width = layoutWidth(*width)
+		render(cities, opts)
+		return
+	}
 
 	// Handle Ctrl+C gracefully
 	sig := make(chan os.Signal, 1)
@@ -21,12 +60,15 @@ func main() {
 	defer ticker.Stop()
 
 	// Initial render
-	render(cities)
+	fmt.Print("\033[?25l") // hide cursor
+	opts.Width = layoutWidth(*width)
+	render(cities, opts)
 
 	for {
 		select {
 		case <-ticker.C:
-			render(cities)
+			opts.Width = layoutWidth(*width) // follow terminal resizes
+			render(cities, opts)
 		case <-sig:
 			fmt.Print("\033[?25h") // show cursor
 			fmt.Println("\n  Goodbye!")
@@ -35,7 +77,7 @@ func main() {
 	}
 }
 
-func render(cities []clock.City) {
+func render(cities []clock.City, opts clock.Options) {
 	now := time.Now()
 	local := clock.GetLocalTime(now)
 
@@ -48,6 +90,31 @@ func render(cities []clock.City) {
 		cityTimes = append(cityTimes, ct)
 	}
 
-	fmt.Print("\033[?25l") // hide cursor
-	fmt.Print(clock.Render(local, cityTimes))
+	if overlap, err := clock.BestOverlap(cities, now, clock.DefaultWorkStart, clock.DefaultWorkEnd); err == nil {
+		opts.Overlap = &overlap
+	}
+
+	fmt.Print(clock.Render(local, cityTimes, opts))
+}
+
+// layoutWidth returns the --width flag if set, else $COLUMNS, else the
+// width of the terminal on stdout, else 80.
+func layoutWidth(flagWidth int) int {
+	if flagWidth > 0 {
+		return flagWidth
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if n := terminalWidth(os.Stdout); n > 0 {
+		return n
+	}
+	return 80
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ryjiang/agent-platform/tools/worldtime/internal/clock"
+	"github.com/ryjiang/agent-platform/tools/worldtime/internal/server"
+)
+
+// serve runs `worldtime serve`: the JSON API and HTML page.
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	port := fs.Int("port", 9000, "port to listen on")
+	host := fs.String("host", "127.0.0.1", "address to bind (0.0.0.0 for all interfaces)")
+	names := fs.String("cities", "", "comma-separated cities to serve (default: all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cities := clock.DefaultCities()
+	if *names != "" {
+		var err error
+		if cities, err = clock.FindCities(strings.Split(*names, ",")); err != nil {
+			return err
+		}
+	}
+
+	addr := fmt.Sprintf("%s:%d", *host, *port)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           server.New(cities),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("worldtime serving on http://%s", addr)
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+import "os"
+
+// terminalWidth is not supported on this platform; callers fall back to
+// $COLUMNS or a default width.
+func terminalWidth(*os.File) int {
+	return 0
+}
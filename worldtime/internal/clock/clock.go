@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // City represents a city with its timezone.
 type City struct {
 	Name     string
 	Timezone string
+	// WorkStart and WorkEnd are the city's local working hours
+	// [WorkStart, WorkEnd); both zero means the planner's defaults.
+	WorkStart, WorkEnd int
 }
 
 // DefaultCities returns the default list of world cities to display.
@@ -35,6 +39,7 @@ type CityTime struct {
 	Date     string
 	Offset   string
 	IsLocal  bool
+	At       time.Time // the instant in the city's zone, for alternative formats
 }
 
 // GetCityTime returns the current time for a city.
@@ -56,6 +61,7 @@ func GetCityTime(city City, now time.Time) (CityTime, error) {
 		Time:   t.Format("15:04:05"),
 		Date:   t.Format("Mon, 02 Jan"),
 		Offset: fmt.Sprintf("UTC%s%d", sign, hours),
+		At:     t,
 	}, nil
 }
 
@@ -75,31 +81,164 @@ func GetLocalTime(now time.Time) CityTime {
 		Date:    now.Format("Mon, 02 Jan 2006"),
 		Offset:  fmt.Sprintf("UTC%s%d", sign, hours),
 		IsLocal: true,
+		At:      now,
 	}
 }
 
-// Render produces the full terminal output string.
-func Render(local CityTime, cities []CityTime) string {
+// Options controls how Render lays out and colors the output.
+type Options struct {
+	Width  int   // terminal width in columns; 0 = 80
+	Hour12 bool  // 12-hour clock with AM/PM
+	Theme  Theme // zero Theme = no color
+	Clear  bool  // clear the screen first and show the exit hint (live mode)
+	// Overlap, if set, is shown as the best call window below the cities.
+	Overlap *Overlap
+}
+
+const (
+	indent = "  "
+	gap    = "    " // between grid columns
+)
+
+// narrowWidth is the width below which the date is dropped from city rows.
+const narrowWidth = 48
+
+// Render produces the full terminal output string. Cities are laid out in
+// as many columns as fit in opts.Width, and in a single compact column on
+// narrow terminals.
+func Render(local CityTime, cities []CityTime, opts Options) string {
+	width := opts.Width
+	if width <= 0 {
+		width = 80
+	}
+	th := opts.Theme
+	compact := width < narrowWidth
+
+	var nameW, timeW, dateW, offW int
+	for _, ct := range cities {
+		nameW = max(nameW, utf8.RuneCountInString(ct.Name))
+		timeW = max(timeW, len(opts.TimeString(ct)))
+		dateW = max(dateW, len(ct.Date))
+		offW = max(offW, len(ct.Offset))
+	}
+	cellW := 3 + nameW + 1 + timeW + 2 + offW // "🕐 " is two columns plus a space
+	if !compact {
+		cellW += dateW + 2
+	}
+	cols := max(1, (width-len(indent)+len(gap))/(cellW+len(gap)))
+	ruleW := max(20, min(width-len(indent), cols*cellW+(cols-1)*len(gap)))
+	rule := paint(th.Rule, indent+strings.Repeat("─", ruleW)) + "\n"
+
 	var b strings.Builder
+	if opts.Clear {
+		b.WriteString("\033[2J\033[H") // clear screen, cursor home
+	}
 
 	// Header
-	b.WriteString("\033[2J\033[H") // clear screen, cursor home
-	b.WriteString("\033[1;36m")
-	b.WriteString("  🌍 World Time Clock\033[0m\n")
-	b.WriteString("\033[90m  ─────────────────────────────────────────────\033[0m\n\n")
+	b.WriteString(paint(th.Header, indent+"🌍 World Time Clock") + "\n")
+	b.WriteString(rule + "\n")
 
 	// Local time (highlighted)
-	b.WriteString(fmt.Sprintf("  \033[1;33m⏰ %-20s\033[0m \033[1;37m%s\033[0m  \033[90m%s  %s\033[0m\n",
-		local.Name, local.Time, local.Date, local.Offset))
+	localName := local.Name
+	if !compact {
+		localName = fmt.Sprintf("%-20s", localName)
+	}
+	b.WriteString(fmt.Sprintf("%s%s %s  %s\n", indent,
+		paint(th.Local, "⏰ "+localName),
+		paint(th.Time, opts.TimeString(local)),
+		paint(th.Detail, joinDetail(compact, local.Date, local.Offset))))
 	b.WriteString("\n")
-	b.WriteString("\033[90m  ─────────────────────────────────────────────\033[0m\n\n")
+	b.WriteString(rule + "\n")
 
-	// World cities
-	for _, ct := range cities {
-		b.WriteString(fmt.Sprintf("  \033[36m🕐 %-20s\033[0m \033[37m%s\033[0m  \033[90m%s  %s\033[0m\n",
-			ct.Name, ct.Time, ct.Date, ct.Offset))
+	// World cities, row-major across the grid
+	for i, ct := range cities {
+		col := i % cols
+		if col == 0 {
+			b.WriteString(indent)
+		} else {
+			b.WriteString(gap)
+		}
+		detail := fmt.Sprintf("%-*s", offW, ct.Offset)
+		if !compact {
+			detail = fmt.Sprintf("%-*s  %s", dateW, ct.Date, detail)
+		}
+		b.WriteString(fmt.Sprintf("%s %s  %s",
+			paint(th.City, fmt.Sprintf("🕐 %-*s", nameW, ct.Name)),
+			paint(th.Time, fmt.Sprintf("%-*s", timeW, opts.TimeString(ct))),
+			paint(th.Detail, detail)))
+		if col == cols-1 || i == len(cities)-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	// Offsets from local time, then the best call window
+	if !local.At.IsZero() && len(cities) > 0 {
+		b.WriteString("\n")
+		line := indent + "vs local:"
+		lineW := utf8.RuneCountInString(line)
+		for _, ct := range cities {
+			item := ct.Name + " " + RelativeOffset(local.At, ct.At)
+			itemW := utf8.RuneCountInString(item)
+			if lineW+2+itemW > width && lineW > len(indent) {
+				b.WriteString(paint(th.Detail, line) + "\n")
+				line, lineW = indent, len(indent)-2
+			}
+			line += "  " + item
+			lineW += 2 + itemW
+		}
+		b.WriteString(paint(th.Detail, line) + "\n")
+	}
+	if o := opts.Overlap; o != nil && o.Cities > 0 {
+		b.WriteString(paint(th.Local, indent+"best overlap: "+opts.windowString(*o)) + "\n")
 	}
 
-	b.WriteString("\n\033[90m  Press Ctrl+C to exit\033[0m\n")
+	if opts.Clear {
+		b.WriteString("\n" + paint(th.Rule, indent+"Press Ctrl+C to exit") + "\n")
+	}
 	return b.String()
 }
+
+// TimeString formats the time of day according to the 12/24h setting.
+func (o Options) TimeString(ct CityTime) string {
+	if o.Hour12 && !ct.At.IsZero() {
+		return ct.At.Format("03:04:05 PM")
+	}
+	return ct.Time
+}
+
+// windowString describes an Overlap, e.g. "14:00–16:00 local (7/10 cities)".
+func (o Options) windowString(ov Overlap) string {
+	layout := "15:04"
+	if o.Hour12 {
+		layout = "3:04 PM"
+	}
+	who := "all cities"
+	if ov.Cities < ov.Total {
+		who = fmt.Sprintf("%d/%d cities", ov.Cities, ov.Total)
+	}
+	return fmt.Sprintf("%s–%s local (%s)", ov.Start.Format(layout), ov.End.Format(layout), who)
+}
+
+// RelativeOffset returns how far t's zone is ahead of local's, e.g. "+8h",
+// "-5h" or "+5h30m".
+func RelativeOffset(local, t time.Time) string {
+	_, lo := local.Zone()
+	_, to := t.Zone()
+	d := to - lo
+	sign := "+"
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	if m := d % 3600 / 60; m != 0 {
+		return fmt.Sprintf("%s%dh%02dm", sign, d/3600, m)
+	}
+	return fmt.Sprintf("%s%dh", sign, d/3600)
+}
+
+func joinDetail(compact bool, date, offset string) string {
+	if compact {
+		return offset
+	}
+	return date + "  " + offset
+}
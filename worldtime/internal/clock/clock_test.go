@@ -72,7 +72,7 @@ func TestRender(t *testing.T) {
 		{Name: "New York", Time: "07:00:00", Date: "Sun, 15 Feb", Offset: "UTC-5"},
 		{Name: "London", Time: "12:00:00", Date: "Sun, 15 Feb", Offset: "UTC+0"},
 	}
-	output := Render(local, cities)
+	output := Render(local, cities, Options{})
 	if !strings.Contains(output, "World Time Clock") {
 		t.Error("output missing header")
 	}
@@ -83,3 +83,229 @@ func TestRender(t *testing.T) {
 		t.Error("output missing New York")
 	}
 }
+
+func TestRenderLayout(t *testing.T) {
+	now := time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC)
+	local := GetLocalTime(now)
+	var cities []CityTime
+	for _, c := range DefaultCities() {
+		ct, err := GetCityTime(c, now)
+		if err != nil {
+			t.Fatalf("GetCityTime(%s): %v", c.Name, err)
+		}
+		cities = append(cities, ct)
+	}
+
+	tests := []struct {
+		name     string
+		width    int
+		wantRows int // rows of city output
+		wantDate bool
+	}{
+		{"narrow single column", 40, 10, false},
+		{"standard single column", 80, 10, true},
+		{"wide two columns", 130, 5, true},
+		{"very wide", 200, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := Render(local, cities, Options{Width: tt.width})
+			var rows int
+			for _, line := range strings.Split(out, "\n") {
+				if strings.Contains(line, "🕐") {
+					rows++
+				}
+			}
+			if rows != tt.wantRows {
+				t.Errorf("city rows = %d, want %d\n%s", rows, tt.wantRows, out)
+			}
+			if got := strings.Contains(out, "Sun, 15 Feb\x20"); got != tt.wantDate {
+				t.Errorf("date shown = %v, want %v\n%s", got, tt.wantDate, out)
+			}
+		})
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	at := time.Date(2026, 2, 15, 20, 0, 0, 0, time.UTC)
+	local := CityTime{Name: "Local (UTC)", Time: "20:00:00", Date: "Sun, 15 Feb 2026", Offset: "UTC+0", IsLocal: true, At: at}
+	cities := []CityTime{{Name: "London", Time: "20:00:00", Date: "Sun, 15 Feb", Offset: "UTC+0", At: at}}
+
+	plain := Render(local, cities, Options{Hour12: true})
+	if !strings.Contains(plain, "08:00:00 PM") {
+		t.Errorf("12h output missing 08:00:00 PM:\n%s", plain)
+	}
+	if strings.Contains(plain, "\033[") {
+		t.Errorf("no-color output contains escape sequences:\n%q", plain)
+	}
+
+	theme, err := LookupTheme("default")
+	if err != nil {
+		t.Fatalf("LookupTheme: %v", err)
+	}
+	colored := Render(local, cities, Options{Theme: theme, Clear: true})
+	if !strings.Contains(colored, "\033[36m") || !strings.HasPrefix(colored, "\033[2J") {
+		t.Errorf("default theme output missing colors or clear:\n%q", colored)
+	}
+	if !strings.Contains(colored, "Ctrl+C") {
+		t.Error("live output missing exit hint")
+	}
+}
+
+func TestLookupTheme(t *testing.T) {
+	for _, name := range ThemeNames() {
+		th, err := LookupTheme(name)
+		if err != nil {
+			t.Errorf("LookupTheme(%q): %v", name, err)
+		}
+		if th.Colored() != (name != "none") {
+			t.Errorf("theme %q Colored() = %v", name, th.Colored())
+		}
+	}
+	if _, err := LookupTheme("neon"); err == nil {
+		t.Error("expected error for unknown theme")
+	}
+}
+
+func TestPlanner(t *testing.T) {
+	cities := []City{
+		{Name: "New York", Timezone: "America/New_York"},
+		{Name: "Shanghai", Timezone: "Asia/Shanghai"},
+	}
+	rows, err := Planner(cities, time.Date(2026, 2, 15, 17, 30, 0, 0, time.UTC), DefaultWorkStart, DefaultWorkEnd)
+	if err != nil {
+		t.Fatalf("Planner: %v", err)
+	}
+	if len(rows) != 24 {
+		t.Fatalf("rows = %d, want 24", len(rows))
+	}
+	if got := rows[0].UTC; !got.Equal(time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("first row = %v, want midnight UTC", got)
+	}
+	// 14:00 UTC = 09:00 New York, 22:00 Shanghai
+	r := rows[14]
+	if r.Cities[0].Time != "09:00" || !r.Cities[0].Working || r.Cities[1].Working || r.AllWorking {
+		t.Errorf("row 14 = %+v", r)
+	}
+	// New York and Shanghai (13h apart) never share a 9–18 working hour.
+	for _, r := range rows {
+		if r.AllWorking {
+			t.Errorf("unexpected overlap at %v", r.UTC)
+		}
+	}
+
+	if _, err := Planner([]City{{Name: "X", Timezone: "Invalid/Zone"}}, time.Now(), 9, 18); err == nil {
+		t.Error("expected error for invalid timezone")
+	}
+}
+
+func TestFindCities(t *testing.T) {
+	got, err := FindCities([]string{"tokyo", " London"})
+	if err != nil || len(got) != 2 || got[0].Name != "Tokyo" || got[1].Name != "London" {
+		t.Errorf("FindCities = %+v, %v", got, err)
+	}
+	if _, err := FindCities([]string{"Atlantis"}); err == nil {
+		t.Error("expected error for unknown city")
+	}
+}
+
+func TestBestOverlap(t *testing.T) {
+	day := time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC)
+	ny := City{Name: "New York", Timezone: "America/New_York"}
+	london := City{Name: "London", Timezone: "Europe/London"}
+	shanghai := City{Name: "Shanghai", Timezone: "Asia/Shanghai"}
+	at := func(h int) time.Time { return time.Date(2026, 2, 15, h, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name       string
+		cities     []City
+		start, end time.Time
+		n          int
+	}{
+		// New York 9–18 is 14–23 UTC in February.
+		{"overlap", []City{ny, london}, at(14), at(18), 2},
+		{"own hours", []City{ny, {Name: "London", Timezone: "Europe/London", WorkStart: 8, WorkEnd: 17}}, at(14), at(17), 2},
+		// No shared hour: the first longest single-city run, Shanghai 01–10 UTC.
+		{"no overlap", []City{ny, shanghai}, at(1), at(10), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BestOverlap(tt.cities, day, DefaultWorkStart, DefaultWorkEnd)
+			if err != nil {
+				t.Fatalf("BestOverlap: %v", err)
+			}
+			if !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) || got.Cities != tt.n || got.Total != len(tt.cities) {
+				t.Errorf("BestOverlap = %v–%v %d/%d, want %v–%v %d", got.Start, got.End, got.Cities, got.Total, tt.start, tt.end, tt.n)
+			}
+		})
+	}
+
+	if _, err := BestOverlap([]City{{Name: "X", Timezone: "Invalid/Zone"}}, day, 9, 18); err == nil {
+		t.Error("expected error for invalid timezone")
+	}
+}
+
+func TestApplyWorkHours(t *testing.T) {
+	cities := []City{{Name: "Tokyo"}, {Name: "London"}}
+	got, err := ApplyWorkHours(cities, "8-17, tokyo=10-19")
+	if err != nil {
+		t.Fatalf("ApplyWorkHours: %v", err)
+	}
+	if got[0].WorkStart != 10 || got[0].WorkEnd != 19 || got[1].WorkStart != 8 || got[1].WorkEnd != 17 {
+		t.Errorf("ApplyWorkHours = %+v", got)
+	}
+	if cities[0].WorkStart != 0 {
+		t.Error("ApplyWorkHours modified its input")
+	}
+	for _, spec := range []string{"9", "18-9", "9-25", "Atlantis=9-18", "Tokyo=a-b"} {
+		if _, err := ApplyWorkHours(cities, spec); err == nil {
+			t.Errorf("ApplyWorkHours(%q): expected error", spec)
+		}
+	}
+}
+
+func TestRelativeOffset(t *testing.T) {
+	now := time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC)
+	zone := func(name string) time.Time {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return now.In(loc)
+	}
+	shanghai := zone("Asia/Shanghai")
+	tests := []struct {
+		local, t time.Time
+		want     string
+	}{
+		{now, shanghai, "+8h"},
+		{now, zone("America/New_York"), "-5h"},
+		{now, zone("Asia/Kolkata"), "+5h30m"},
+		{shanghai, now, "-8h"},
+		{now, now, "+0h"},
+	}
+	for _, tt := range tests {
+		if got := RelativeOffset(tt.local, tt.t); got != tt.want {
+			t.Errorf("RelativeOffset(%v, %v) = %q, want %q", tt.local, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestRenderSummary(t *testing.T) {
+	at := time.Date(2026, 2, 15, 20, 0, 0, 0, time.UTC)
+	loc, _ := time.LoadLocation("Asia/Tokyo")
+	local := CityTime{Name: "Local (UTC)", Time: "20:00:00", Date: "Sun, 15 Feb 2026", Offset: "UTC+0", IsLocal: true, At: at}
+	cities := []CityTime{{Name: "Tokyo", Time: "05:00:00", Date: "Mon, 16 Feb", Offset: "UTC+9", At: at.In(loc)}}
+	overlap := &Overlap{Start: time.Date(2026, 2, 15, 14, 0, 0, 0, time.UTC), End: time.Date(2026, 2, 15, 16, 0, 0, 0, time.UTC), Cities: 7, Total: 10}
+
+	out := Render(local, cities, Options{Overlap: overlap})
+	for _, want := range []string{"vs local:  Tokyo +9h", "best overlap: 14:00–16:00 local (7/10 cities)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	overlap.Cities = 10
+	if out := Render(local, cities, Options{Hour12: true, Overlap: overlap}); !strings.Contains(out, "2:00 PM–4:00 PM local (all cities)") {
+		t.Errorf("12h output missing window:\n%s", out)
+	}
+}
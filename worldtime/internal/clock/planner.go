@@ -0,0 +1,195 @@
+package clock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Working hours used by the meeting planner, in each city's local time.
+const (
+	DefaultWorkStart = 9  // 09:00
+	DefaultWorkEnd   = 18 // 18:00
+)
+
+// PlannerCell is one city's local time in a planner row.
+type PlannerCell struct {
+	Name    string `json:"name"`
+	Time    string `json:"time"`
+	Date    string `json:"date"`
+	Working bool   `json:"working"`
+}
+
+// PlannerRow is one hour of the meeting planner.
+type PlannerRow struct {
+	UTC        time.Time     `json:"utc"`
+	Cities     []PlannerCell `json:"cities"`
+	AllWorking bool          `json:"all_working"` // every city is within working hours
+}
+
+// Planner returns a 24-row grid, one row per hour of day (a UTC date),
+// showing each city's local time and whether it falls within working hours
+// [workStart, workEnd), or the city's own hours if it has them.
+func Planner(cities []City, day time.Time, workStart, workEnd int) ([]PlannerRow, error) {
+	locs := make([]*time.Location, len(cities))
+	for i, c := range cities {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("load timezone %s: %w", c.Timezone, err)
+		}
+		locs[i] = loc
+	}
+
+	y, m, d := day.UTC().Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	rows := make([]PlannerRow, 0, 24)
+	for h := 0; h < 24; h++ {
+		at := start.Add(time.Duration(h) * time.Hour)
+		row := PlannerRow{UTC: at, AllWorking: len(cities) > 0}
+		for i, c := range cities {
+			t := at.In(locs[i])
+			working := c.working(t, workStart, workEnd)
+			row.Cities = append(row.Cities, PlannerCell{
+				Name:    c.Name,
+				Time:    t.Format("15:04"),
+				Date:    t.Format("Mon, 02 Jan"),
+				Working: working,
+			})
+			row.AllWorking = row.AllWorking && working
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// working reports whether t, in the city's zone, is within its working
+// hours, falling back to [workStart, workEnd).
+func (c City) working(t time.Time, workStart, workEnd int) bool {
+	if c.WorkStart != 0 || c.WorkEnd != 0 {
+		workStart, workEnd = c.WorkStart, c.WorkEnd
+	}
+	return t.Hour() >= workStart && t.Hour() < workEnd
+}
+
+// Overlap is the best window for a call: the longest stretch of a day in
+// which the most cities are within working hours at once.
+type Overlap struct {
+	Start, End time.Time
+	Cities     int // cities within working hours throughout the window
+	Total      int
+}
+
+// overlapStep is the resolution of BestOverlap; it divides every UTC
+// offset in use (some are a half or three quarters of an hour).
+const overlapStep = 15 * time.Minute
+
+// BestOverlap finds the Overlap during the day of day, in day's location.
+// If no two cities ever work at the same time the window is the longest
+// one in which a single city works. The result is zero if no city works
+// that day.
+func BestOverlap(cities []City, day time.Time, workStart, workEnd int) (Overlap, error) {
+	locs := make([]*time.Location, len(cities))
+	for i, c := range cities {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return Overlap{}, fmt.Errorf("load timezone %s: %w", c.Timezone, err)
+		}
+		locs[i] = loc
+	}
+
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	best := Overlap{Total: len(cities)}
+	var run Overlap
+	for at := start; !at.After(end); at = at.Add(overlapStep) {
+		n := -1 // closes the last run at the end of the day
+		if at.Before(end) {
+			n = 0
+			for i, c := range cities {
+				if c.working(at.In(locs[i]), workStart, workEnd) {
+					n++
+				}
+			}
+		}
+		if n == run.Cities && n > 0 {
+			continue
+		}
+		// The previous run ends here.
+		if run.Cities > 0 {
+			run.End = at
+			if run.Cities > best.Cities || run.Cities == best.Cities && run.End.Sub(run.Start) > best.End.Sub(best.Start) {
+				best.Start, best.End, best.Cities = run.Start, run.End, run.Cities
+			}
+		}
+		run = Overlap{Start: at, Cities: max(n, 0)}
+	}
+	return best, nil
+}
+
+// ApplyWorkHours sets working hours on cities from spec, a comma-separated
+// list of "City=START-END" entries (e.g. "Tokyo=10-19,London=8-17"). A bare
+// "START-END" entry applies to every city not named elsewhere in the spec.
+func ApplyWorkHours(cities []City, spec string) ([]City, error) {
+	out := append([]City(nil), cities...)
+	if strings.TrimSpace(spec) == "" {
+		return out, nil
+	}
+	named := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		name, hours, ok := strings.Cut(entry, "=")
+		if !ok {
+			hours, name = name, ""
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		start, end, err := parseWorkHours(hours)
+		if err != nil {
+			return nil, fmt.Errorf("work hours %q: %w", strings.TrimSpace(entry), err)
+		}
+		found := false
+		for i, c := range out {
+			lower := strings.ToLower(c.Name)
+			if name == lower || name == "" && !named[lower] {
+				out[i].WorkStart, out[i].WorkEnd = start, end
+				found = true
+			}
+		}
+		if name != "" {
+			if !found {
+				return nil, fmt.Errorf("unknown city %q", strings.TrimSpace(entry[:strings.Index(entry, "=")]))
+			}
+			named[name] = true
+		}
+	}
+	return out, nil
+}
+
+// parseWorkHours parses "START-END" in whole hours, 0 <= START < END <= 24.
+func parseWorkHours(s string) (int, int, error) {
+	a, b, ok := strings.Cut(strings.TrimSpace(s), "-")
+	start, err1 := strconv.Atoi(strings.TrimSpace(a))
+	end, err2 := strconv.Atoi(strings.TrimSpace(b))
+	if !ok || err1 != nil || err2 != nil || start < 0 || end > 24 || start >= end {
+		return 0, 0, fmt.Errorf("want START-END in hours, e.g. 9-18")
+	}
+	return start, end, nil
+}
+
+// FindCities returns the default cities whose names are listed, in the
+// order given. Names match case-insensitively; unknown names are an error.
+func FindCities(names []string) ([]City, error) {
+	byName := make(map[string]City)
+	for _, c := range DefaultCities() {
+		byName[strings.ToLower(c.Name)] = c
+	}
+	out := make([]City, 0, len(names))
+	for _, n := range names {
+		c, ok := byName[strings.ToLower(strings.TrimSpace(n))]
+		if !ok {
+			return nil, fmt.Errorf("unknown city %q", n)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
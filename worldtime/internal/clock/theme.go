@@ -0,0 +1,92 @@
+package clock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Theme holds the ANSI escape sequences used to color each part of the
+// output. The zero Theme renders plain text with no escape sequences.
+type Theme struct {
+	Name   string
+	Header string // title
+	Rule   string // separator lines and footer
+	Local  string // local time label
+	City   string // city labels
+	Time   string // time of day
+	Detail string // date and UTC offset
+}
+
+const reset = "\033[0m"
+
+var themes = map[string]Theme{
+	"default": {
+		Name:   "default",
+		Header: "\033[1;36m",
+		Rule:   "\033[90m",
+		Local:  "\033[1;33m",
+		City:   "\033[36m",
+		Time:   "\033[37m",
+		Detail: "\033[90m",
+	},
+	"mono": {
+		Name:   "mono",
+		Header: "\033[1m",
+		Rule:   "\033[2m",
+		Local:  "\033[1m",
+		Time:   "\033[1m",
+		Detail: "\033[2m",
+	},
+	"ocean": {
+		Name:   "ocean",
+		Header: "\033[1;34m",
+		Rule:   "\033[34m",
+		Local:  "\033[1;96m",
+		City:   "\033[94m",
+		Time:   "\033[97m",
+		Detail: "\033[36m",
+	},
+	"solarized": {
+		Name:   "solarized",
+		Header: "\033[1;38;5;136m",
+		Rule:   "\033[38;5;240m",
+		Local:  "\033[1;38;5;166m",
+		City:   "\033[38;5;33m",
+		Time:   "\033[38;5;245m",
+		Detail: "\033[38;5;240m",
+	},
+	"none": {Name: "none"},
+}
+
+// LookupTheme returns the named theme.
+func LookupTheme(name string) (Theme, error) {
+	t, ok := themes[strings.ToLower(name)]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return t, nil
+}
+
+// ThemeNames returns the names of the built-in themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for n := range themes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Colored reports whether the theme emits escape sequences.
+func (t Theme) Colored() bool {
+	return t.Header != "" || t.Rule != "" || t.Local != "" || t.City != "" || t.Time != "" || t.Detail != ""
+}
+
+// paint wraps s in the given color, or returns s unchanged when color is empty.
+func paint(color, s string) string {
+	if color == "" {
+		return s
+	}
+	return color + s + reset
+}
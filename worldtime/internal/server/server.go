@@ -0,0 +1,207 @@
+// Package server exposes the world clock and meeting planner over HTTP.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ryjiang/agent-platform/tools/worldtime/internal/clock"
+)
+
+// Server serves the JSON API and the HTML page.
+type Server struct {
+	cities []clock.City
+	now    func() time.Time
+	mux    *http.ServeMux
+}
+
+// New creates a Server for the given cities.
+func New(cities []clock.City) *Server {
+	s := &Server{cities: cities, now: time.Now, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/api/times", s.handleTimes)
+	s.mux.HandleFunc("/api/planner", s.handlePlanner)
+	s.mux.HandleFunc("/", s.handleIndex)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+type cityTime struct {
+	Name     string    `json:"name"`
+	Timezone string    `json:"timezone,omitempty"`
+	Time     string    `json:"time"`
+	Date     string    `json:"date"`
+	Offset   string    `json:"offset"`
+	ISO      time.Time `json:"iso"`
+}
+
+type timesResponse struct {
+	Now    time.Time  `json:"now"`
+	Cities []cityTime `json:"cities"`
+}
+
+// handleTimes serves GET /api/times[?cities=Tokyo,London][&format=12h].
+func (s *Server) handleTimes(w http.ResponseWriter, r *http.Request) {
+	cities, err := s.citiesParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts := clock.Options{Hour12: r.URL.Query().Get("format") == "12h"}
+
+	now := s.now()
+	resp := timesResponse{Now: now.UTC(), Cities: make([]cityTime, 0, len(cities))}
+	for _, c := range cities {
+		ct, err := clock.GetCityTime(c, now)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		resp.Cities = append(resp.Cities, cityTime{
+			Name:     ct.Name,
+			Timezone: c.Timezone,
+			Time:     opts.TimeString(ct),
+			Date:     ct.Date,
+			Offset:   ct.Offset,
+			ISO:      ct.At,
+		})
+	}
+	writeJSON(w, resp)
+}
+
+type plannerResponse struct {
+	Date      string             `json:"date"`
+	WorkStart int                `json:"work_start"`
+	WorkEnd   int                `json:"work_end"`
+	Rows      []clock.PlannerRow `json:"rows"`
+}
+
+// handlePlanner serves GET /api/planner[?date=YYYY-MM-DD][&cities=...]
+// [&work_start=9&work_end=18].
+func (s *Server) handlePlanner(w http.ResponseWriter, r *http.Request) {
+	cities, err := s.citiesParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	q := r.URL.Query()
+
+	day := s.now().UTC()
+	if v := q.Get("date"); v != "" {
+		day, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("date: want YYYY-MM-DD"))
+			return
+		}
+	}
+	start, err := hourParam(q.Get("work_start"), clock.DefaultWorkStart)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("work_start: %w", err))
+		return
+	}
+	end, err := hourParam(q.Get("work_end"), clock.DefaultWorkEnd)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("work_end: %w", err))
+		return
+	}
+
+	rows, err := clock.Planner(cities, day, start, end)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, plannerResponse{Date: day.Format("2006-01-02"), WorkStart: start, WorkEnd: end, Rows: rows})
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexHTML)
+}
+
+// citiesParam returns the cities named in ?cities=, or all served cities.
+func (s *Server) citiesParam(r *http.Request) ([]clock.City, error) {
+	v := r.URL.Query().Get("cities")
+	if v == "" {
+		return s.cities, nil
+	}
+	return clock.FindCities(strings.Split(v, ","))
+}
+
+func hourParam(v string, def int) (int, error) {
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > 24 {
+		return 0, fmt.Errorf("want an hour between 0 and 24")
+	}
+	return n, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>World Time Clock</title>
+<style>
+body { font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; margin: 2rem; color: #1f2328; }
+table { border-collapse: collapse; }
+td, th { padding: 4px 12px; text-align: left; }
+.time { font-variant-numeric: tabular-nums; font-weight: 600; }
+.dim { color: #6e7781; }
+.work { background: #dafbe1; }
+.all { background: #aceebb; font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>🌍 World Time Clock</h1>
+<table id="times"></table>
+<h2>Meeting planner <span class="dim" id="date"></span></h2>
+<p class="dim">Green cells are within working hours; bold rows work for everyone.</p>
+<table id="planner"></table>
+<script>
+async function times() {
+  const res = await fetch("/api/times");
+  const data = await res.json();
+  document.getElementById("times").innerHTML = data.cities.map(c =>
+    "<tr><td>" + c.name + "</td><td class=time>" + c.time + "</td><td class=dim>" + c.date + "</td><td class=dim>" + c.offset + "</td></tr>").join("");
+}
+async function planner() {
+  const res = await fetch("/api/planner");
+  const data = await res.json();
+  document.getElementById("date").textContent = data.date + " (UTC)";
+  const head = "<tr><th>UTC</th>" + data.rows[0].cities.map(c => "<th>" + c.name + "</th>").join("") + "</tr>";
+  document.getElementById("planner").innerHTML = head + data.rows.map(r =>
+    "<tr" + (r.all_working ? " class=all" : "") + "><td class=dim>" + r.utc.substring(11, 16) + "</td>" +
+    r.cities.map(c => "<td" + (c.working ? " class=work" : "") + ">" + c.time + "</td>").join("") + "</tr>").join("");
+}
+times(); planner();
+setInterval(times, 1000);
+</script>
+</body>
+</html>
+`
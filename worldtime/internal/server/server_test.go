@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryjiang/agent-platform/tools/worldtime/internal/clock"
+)
+
+func newTestServer() *Server {
+	s := New(clock.DefaultCities())
+	s.now = func() time.Time { return time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func get(t *testing.T, s *Server, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestHandleTimes(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		wantLen  int
+		wantTime string // time of the first city
+	}{
+		{"all cities", "/api/times", http.StatusOK, 10, "07:00:00"},
+		{"filtered", "/api/times?cities=tokyo,London", http.StatusOK, 2, "21:00:00"},
+		{"12h", "/api/times?cities=Tokyo&format=12h", http.StatusOK, 1, "09:00:00 PM"},
+		{"unknown city", "/api/times?cities=Atlantis", http.StatusBadRequest, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(t, newTestServer(), tt.path)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var resp timesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if len(resp.Cities) != tt.wantLen || resp.Cities[0].Time != tt.wantTime {
+				t.Errorf("cities = %+v, want %d starting at %s", resp.Cities, tt.wantLen, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestHandlePlanner(t *testing.T) {
+	w := get(t, newTestServer(), "/api/planner?date=2026-02-16&cities=London,Paris")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp plannerResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Date != "2026-02-16" || len(resp.Rows) != 24 {
+		t.Fatalf("date = %s, rows = %d", resp.Date, len(resp.Rows))
+	}
+	// London (UTC+0) and Paris (UTC+1) both work from 09:00 to 16:59 UTC.
+	var overlap int
+	for _, r := range resp.Rows {
+		if r.AllWorking {
+			overlap++
+		}
+	}
+	if overlap != 8 {
+		t.Errorf("overlap hours = %d, want 8", overlap)
+	}
+
+	for _, path := range []string{"/api/planner?date=16-02-2026", "/api/planner?work_start=25"} {
+		if w := get(t, newTestServer(), path); w.Code != http.StatusBadRequest {
+			t.Errorf("%s status = %d, want 400", path, w.Code)
+		}
+	}
+}
+
+func TestHandleIndex(t *testing.T) {
+	w := get(t, newTestServer(), "/")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/api/times") {
+		t.Errorf("index status = %d", w.Code)
+	}
+	if w := get(t, newTestServer(), "/nope"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown path status = %d, want 404", w.Code)
+	}
+}